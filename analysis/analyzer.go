@@ -3,7 +3,9 @@ package analysis
 import (
 	"errors"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/alecthomas/participle/v2"
 	"github.com/alecthomas/participle/v2/lexer"
@@ -20,8 +22,27 @@ type Analyzer struct {
 	// Can be nil if cross-file validation is not needed.
 	resolver CrossFileResolver
 
+	// queryAnalyzer is used by rules that need query metadata (e.g., return fields).
+	// Can be nil, in which case those rules are skipped.
+	queryAnalyzer scaf.QueryAnalyzer
+
+	// schema is used by rules that validate values against the type schema.
+	// Can be nil, in which case those rules are skipped.
+	schema *TypeSchema
+
 	// rules is the set of semantic checks to run.
 	rules []*Rule
+
+	// mu guards severities and strict, which the LSP server changes while
+	// documents are being analyzed.
+	mu sync.RWMutex
+
+	// severities overrides the severity of diagnostics by code.
+	// SeverityOff drops the diagnostic entirely.
+	severities map[string]DiagnosticSeverity
+
+	// strict enables the report-recovered hint without a severity override.
+	strict bool
 }
 
 // FileLoader is an interface for loading files during analysis.
@@ -43,6 +64,15 @@ type CrossFileResolver interface {
 	LoadAndAnalyze(path string) *AnalyzedFile
 }
 
+// ProjectResolver is a CrossFileResolver that can also list the files of the
+// project, so that rules can find the files importing the one they check.
+type ProjectResolver interface {
+	CrossFileResolver
+
+	// ProjectFiles returns the paths of every scaf file in the project.
+	ProjectFiles() []string
+}
+
 // NewAnalyzer creates a new analyzer with default rules.
 // Pass nil for loader to do single-file analysis only.
 func NewAnalyzer(loader FileLoader) *Analyzer {
@@ -70,16 +100,40 @@ func NewAnalyzerWithRules(loader FileLoader, rules []*Rule) *Analyzer {
 	}
 }
 
+// SetQueryAnalyzer sets the dialect analyzer used by rules that inspect query bodies.
+func (a *Analyzer) SetQueryAnalyzer(qa scaf.QueryAnalyzer) {
+	a.queryAnalyzer = qa
+}
+
+// SetSchema sets the type schema used by schema validation rules.
+func (a *Analyzer) SetSchema(schema *TypeSchema) {
+	a.schema = schema
+}
+
+// SetSeverityOverrides sets per-code severity overrides (e.g., "unused-import" -> SeverityHint).
+// Codes mapped to SeverityOff are suppressed.
+func (a *Analyzer) SetSeverityOverrides(overrides map[string]DiagnosticSeverity) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.severities = overrides
+}
+
+// SetStrict enables strict analysis, which reports every construct the
+// recovering parser patched or skipped as a report-recovered hint.
+// A severity override for report-recovered takes precedence.
+func (a *Analyzer) SetStrict(strict bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.strict = strict
+}
+
 // Analyze parses and analyzes a scaf file.
 // On parse errors, still extracts symbols from the partial AST so that
 // LSP features like completion and hover continue to work.
 func (a *Analyzer) Analyze(path string, content []byte) *AnalyzedFile {
-	result := &AnalyzedFile{
-		Path:        path,
-		Diagnostics: []Diagnostic{},
-		Symbols:     NewSymbolTable(),
-		Resolver:    a.resolver,
-	}
+	result := a.newFile(path, content)
 
 	// Parse the file - returns partial AST even on error.
 	// NOTE: We use non-recovery mode here because recovery can break parsing of valid
@@ -102,6 +156,54 @@ func (a *Analyzer) Analyze(path string, content []byte) *AnalyzedFile {
 		result.RecoveryError = recoveryErr
 	}
 
+	a.check(result, content)
+
+	return result
+}
+
+// Reanalyze analyzes content, an edit of the file prev was analyzed from.
+// When the edit stays within the body of one query scope, only that scope is
+// reparsed and the rest of prev's AST is reused; otherwise the file is
+// analyzed in full. Either way the result is the same as Analyze's.
+//
+// The rules still run over the whole file. Many of them relate a scope to the
+// file's queries, imports and other scopes, and they cost little next to the
+// reparse (see BenchmarkAnalyzer_Reanalyze).
+func (a *Analyzer) Reanalyze(prev *AnalyzedFile, path string, content []byte) *AnalyzedFile {
+	if prev == nil || prev.Path != path || prev.ParseError != nil || prev.content == nil {
+		return a.Analyze(path, content)
+	}
+
+	suite, ok := scaf.ReparseScope(prev.Suite, prev.content, content)
+	if !ok {
+		return a.Analyze(path, content)
+	}
+
+	result := a.newFile(path, content)
+	result.Suite = suite
+	a.check(result, content)
+
+	return result
+}
+
+// newFile returns an empty analysis of content.
+func (a *Analyzer) newFile(path string, content []byte) *AnalyzedFile {
+	return &AnalyzedFile{
+		Path:          path,
+		Diagnostics:   []Diagnostic{},
+		Symbols:       NewSymbolTable(),
+		Resolver:      a.resolver,
+		QueryAnalyzer: a.queryAnalyzer,
+		Schema:        a.schema,
+		content:       content,
+	}
+}
+
+// check builds the symbol table and runs the rules over a parsed file.
+func (a *Analyzer) check(result *AnalyzedFile, content []byte) {
+	suite := result.Suite
+	err := result.ParseError
+
 	// Build symbol table from partial or complete AST.
 	// Symbols defined before the error location will still be available.
 	if suite != nil {
@@ -111,15 +213,92 @@ func (a *Analyzer) Analyze(path string, content []byte) *AnalyzedFile {
 		extractPartialSymbols(result, content)
 	}
 
+	// Rules may analyze imported files with this analyzer, so the settings are
+	// read once rather than held locked.
+	a.mu.RLock()
+	severities, strict := a.severities, a.strict
+	a.mu.RUnlock()
+
 	// Run semantic rules only on complete parses to avoid spurious errors.
 	// Partial ASTs may have nil fields that rules don't expect.
 	if result.ParseError == nil {
 		for _, rule := range a.rules {
+			if rule.OptIn && !enabled(severities, strict, rule.Name) {
+				continue
+			}
+
 			rule.Run(result)
 		}
+	} else if enabled(severities, strict, reportRecoveredRule.Name) && slices.Contains(a.rules, reportRecoveredRule) {
+		reportRecoveredRule.Run(result)
 	}
 
-	return result
+	if len(severities) > 0 {
+		result.Diagnostics = applySeverityOverrides(result.Diagnostics, severities)
+	}
+}
+
+// enabled reports whether a severity override, or strict mode for the
+// report-recovered hint, turns on the given code.
+func enabled(severities map[string]DiagnosticSeverity, strict bool, code string) bool {
+	sev, ok := severities[code]
+	if !ok {
+		return strict && code == reportRecoveredRule.Name
+	}
+
+	return sev != SeverityOff
+}
+
+func init() {
+	scaf.RegisterValidator(validateSuite)
+}
+
+// validateSuite runs ValidationRules against an already parsed suite.
+func validateSuite(suite *scaf.Suite) []*scaf.AnalysisError {
+	f := &AnalyzedFile{
+		Suite:       suite,
+		Diagnostics: []Diagnostic{},
+		Symbols:     NewSymbolTable(),
+	}
+
+	buildSymbols(f)
+
+	for _, rule := range ValidationRules() {
+		rule.Run(f)
+	}
+
+	errs := make([]*scaf.AnalysisError, 0, len(f.Diagnostics))
+	for _, d := range f.Diagnostics {
+		errs = append(errs, d.Err())
+	}
+
+	return errs
+}
+
+// Validate parses and analyzes a single file with the default rules and returns
+// AnalyzedFile.Err: a *scaf.ParseError, or the *scaf.AnalysisError for each
+// error-level diagnostic. Imports are not resolved.
+func Validate(path string, content []byte) error {
+	return NewAnalyzer(nil).Analyze(path, content).Err()
+}
+
+// applySeverityOverrides rewrites diagnostic severities by code, dropping those turned off.
+func applySeverityOverrides(diags []Diagnostic, overrides map[string]DiagnosticSeverity) []Diagnostic {
+	kept := diags[:0]
+
+	for _, d := range diags {
+		if sev, ok := overrides[d.Code]; ok {
+			if sev == SeverityOff {
+				continue
+			}
+
+			d.Severity = sev
+		}
+
+		kept = append(kept, d)
+	}
+
+	return kept
 }
 
 // parseErrorToDiagnostic converts a parse error to a diagnostic.
@@ -154,17 +333,20 @@ func parseErrorsToDiagnostics(err error) []Diagnostic {
 
 // singleErrorToDiagnostic converts a single error to a diagnostic.
 func singleErrorToDiagnostic(err error) Diagnostic {
-	// participle errors implement Error interface with Position().
+	// scaf.Parse wraps errors in *scaf.ParseError; errors from a recovery
+	// parse are raw participle errors, which implement Position().
 	span := scaf.Span{}
 	msg := err.Error()
 
-	// Try to extract position from participle error
 	type participleError interface {
 		Position() lexer.Position
 		Message() string
 	}
 
-	if pe, ok := err.(participleError); ok {
+	var parseErr *scaf.ParseError
+	if errors.As(err, &parseErr) {
+		span, msg = parseErr.Span, parseErr.Message
+	} else if pe, ok := err.(participleError); ok {
 		pos := pe.Position()
 		span = scaf.Span{Start: pos, End: pos}
 		msg = pe.Message()
@@ -210,6 +392,32 @@ func buildSymbols(f *AnalyzedFile) {
 		}
 	}
 
+	// Extract re-exports.
+	for _, exp := range f.Suite.Exports {
+		if exp == nil || (exp.Module == nil && exp.From == nil) {
+			continue // Skip incomplete exports in partial AST
+		}
+
+		var name string
+		if exp.Module != nil {
+			name = *exp.Module
+		} else {
+			name = *exp.From
+		}
+
+		f.Symbols.Exports = append(f.Symbols.Exports, &ExportSymbol{
+			Symbol: Symbol{
+				Name: name,
+				Span: exp.Span(),
+				Kind: SymbolKindExport,
+			},
+			Module: exp.Module,
+			Names:  exp.Names,
+			From:   exp.From,
+			Node:   exp,
+		})
+	}
+
 	// Extract queries.
 	for _, q := range f.Suite.Queries {
 		if q == nil || q.Name == "" {
@@ -246,17 +454,19 @@ func extractTestSymbols(f *AnalyzedFile, queryScope, groupPath string, items []*
 			continue // Skip nil items in partial AST
 		}
 
-		if item.Test != nil && item.Test.Name != "" {
-			fullPath := buildTestPath(queryScope, groupPath, item.Test.Name)
-			f.Symbols.Tests[fullPath] = &TestSymbol{
-				Symbol: Symbol{
-					Name: item.Test.Name,
-					Span: item.Test.Span(),
-					Kind: SymbolKindTest,
-				},
-				FullPath:   fullPath,
-				QueryScope: queryScope,
-				Node:       item.Test,
+		for _, test := range item.Tests() {
+			if test.Name != "" {
+				fullPath := buildTestPath(queryScope, groupPath, test.Name)
+				f.Symbols.Tests[fullPath] = &TestSymbol{
+					Symbol: Symbol{
+						Name: test.Name,
+						Span: test.Span(),
+						Kind: SymbolKindTest,
+					},
+					FullPath:   fullPath,
+					QueryScope: queryScope,
+					Node:       test,
+				}
 			}
 		}
 
@@ -308,9 +518,12 @@ func extractPartialSymbols(f *AnalyzedFile, content []byte) {
 			path := match[2]
 			alias := match[1] // May be empty if no alias specified
 
-			// If no alias, derive from path
+			// Unaliased imports must keep a nil Alias: their queries join the file's namespace.
+			var aliasPtr *string
 			if alias == "" {
 				alias = baseNameFromPath(path)
+			} else {
+				aliasPtr = &alias
 			}
 
 			f.Symbols.Imports[alias] = &ImportSymbol{
@@ -318,7 +531,7 @@ func extractPartialSymbols(f *AnalyzedFile, content []byte) {
 					Name: alias,
 					Kind: SymbolKindImport,
 				},
-				Alias: nil, // Simplified - not tracking alias pointer in partial extraction
+				Alias: aliasPtr,
 				Path:  path,
 				Node:  nil, // No AST node available
 			}
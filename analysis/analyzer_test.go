@@ -1,10 +1,18 @@
 package analysis_test
 
 import (
+	"errors"
+	"fmt"
 	"slices"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/rlch/scaf"
 	"github.com/rlch/scaf/analysis"
+	"github.com/rlch/scaf/dialects/cypher"
 )
 
 func TestAnalyzer_Analyze(t *testing.T) {
@@ -277,3 +285,248 @@ GetUser {
 		}
 	}
 }
+
+func TestAnalyzer_SeverityOverrides(t *testing.T) {
+	t.Parallel()
+
+	input := `import unused "./unused"
+query Q ` + "`Q`" + `
+Undefined {
+	test "t" {}
+}
+`
+
+	overrides, err := analysis.ParseSeverityOverrides(map[string]string{
+		"undefined-query": "warning",
+		"unused-import":   "off",
+	})
+	if err != nil {
+		t.Fatalf("ParseSeverityOverrides() error: %v", err)
+	}
+
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetSeverityOverrides(overrides)
+
+	result := analyzer.Analyze("test.scaf", []byte(input))
+
+	var sawUndefined bool
+
+	for _, d := range result.Diagnostics {
+		switch d.Code {
+		case "undefined-query":
+			sawUndefined = true
+
+			if d.Severity != analysis.SeverityWarning {
+				t.Errorf("undefined-query severity = %v, want warning", d.Severity)
+			}
+		case "unused-import":
+			t.Error("unused-import should be suppressed")
+		}
+	}
+
+	if !sawUndefined {
+		t.Error("expected undefined-query diagnostic")
+	}
+
+	if _, err := analysis.ParseSeverityOverrides(map[string]string{"x": "loud"}); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+}
+
+// The LSP server changes overrides while documents are analyzed; run with
+// -race to check they are read safely.
+func TestAnalyzer_SeverityOverridesConcurrent(t *testing.T) {
+	t.Parallel()
+
+	analyzer := analysis.NewAnalyzer(nil)
+	content := []byte(largeSuite(2))
+
+	var wg sync.WaitGroup
+
+	for i := range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 10 {
+				analyzer.Analyze("test.scaf", content)
+				analyzer.SetSeverityOverrides(map[string]analysis.DiagnosticSeverity{"unused-import": analysis.SeverityOff})
+				analyzer.SetStrict(i%2 == 0)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// largeSuite returns a file with n scopes of ten tests each.
+func largeSuite(n int) string {
+	var b strings.Builder
+
+	for i := range n {
+		fmt.Fprintf(&b, "query Q%d `MATCH (u:User {id: $id}) RETURN u.name AS name`\n", i)
+	}
+
+	for i := range n {
+		fmt.Fprintf(&b, "\nQ%d {\n", i)
+
+		for j := range 10 {
+			fmt.Fprintf(&b, "\ttest \"t%d\" {\n\t\t$id: %d\n\t\tname: \"user %d\"\n\t}\n", j, j, j)
+		}
+
+		b.WriteString("}\n")
+	}
+
+	return b.String()
+}
+
+func TestAnalyzer_Reanalyze(t *testing.T) {
+	t.Parallel()
+
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetQueryAnalyzer(cypher.NewAnalyzer())
+
+	content := largeSuite(3)
+	prev := analyzer.Analyze("test.scaf", []byte(content))
+
+	// Each edit applies to the previous one's result, as keystrokes do.
+	edits := []struct{ old, new string }{
+		{`name: "user 3"`, `name: "user 33"`},
+		{"\t\t$id: 4\n", "\t\t$id: 4\n\t\t$unknown: 1\n"},
+		{"Q1 {\n", "Q1 {\n\ttest \"t0\" {}\n"},
+		{`name: "user 5"`, `name: `},
+		{"name: \n", "name: \"user 5\"\n"},
+		{"query Q2 ", "query Q9 "},
+		{"\ttest \"t9\" {\n\t\t$id: 9\n\t\tname: \"user 9\"\n\t}\n}", "}"},
+		{"\t\t$id: 2\n", "\t\t// about id\n\t\t$id: 2\n"},
+		{`name: "user 9"`, `name: "user 9" // trailing`},
+		// The comment loses its node and attaches to the next scope.
+		{`name: "user 9"`, "name: \"user 9\"\n\n\n"},
+		{"\n\n\n // trailing", " // trailing"},
+		{"\t}\n}", "\t}\n\t// end\n}"},
+	}
+
+	for _, edit := range edits {
+		next := strings.Replace(content, edit.old, edit.new, 1)
+		if next == content {
+			t.Fatalf("edit %q not found", edit.old)
+		}
+
+		content = next
+		got := analyzer.Reanalyze(prev, "test.scaf", []byte(content))
+		want := analyzer.Analyze("test.scaf", []byte(content))
+
+		if diff := cmp.Diff(want.Diagnostics, got.Diagnostics); diff != "" {
+			t.Errorf("after %q -> %q: diagnostics differ (-full +incremental):\n%s", edit.old, edit.new, diff)
+		}
+
+		if (want.ParseError == nil) != (got.ParseError == nil) ||
+			(want.ParseError == nil && scaf.Format(want.Suite) != scaf.Format(got.Suite)) {
+			t.Errorf("after %q -> %q: suites differ", edit.old, edit.new)
+		}
+
+		prev = got
+	}
+}
+
+func BenchmarkAnalyzer_Reanalyze(b *testing.B) {
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetQueryAnalyzer(scaf.NewCachedAnalyzer(cypher.NewAnalyzer(), scaf.DefaultAnalyzerCacheSize))
+
+	content := []byte(largeSuite(100))
+	edited := []byte(strings.Replace(string(content), `name: "user 3"`, `name: "user 33"`, 1))
+	prev := analyzer.Analyze("test.scaf", content)
+
+	b.Run("full", func(b *testing.B) {
+		for b.Loop() {
+			analyzer.Analyze("test.scaf", edited)
+		}
+	})
+
+	b.Run("incremental", func(b *testing.B) {
+		for b.Loop() {
+			analyzer.Reanalyze(prev, "test.scaf", edited)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	// Warnings (unused import) don't fail validation; the undefined query does.
+	input := "import unused \"./unused\"\nquery Q `Q`\nUndefined {\n\ttest \"t\" {}\n}\n"
+
+	err := analysis.Validate("test.scaf", []byte(input))
+
+	var analysisErr *scaf.AnalysisError
+	if !errors.As(err, &analysisErr) {
+		t.Fatalf("Expected *scaf.AnalysisError, got %T: %v", err, err)
+	}
+
+	if analysisErr.Code != "undefined-query" {
+		t.Errorf("Code = %q, want undefined-query", analysisErr.Code)
+	}
+
+	if analysisErr.Span.Start.Line != 3 || analysisErr.Span.Start.Column != 1 {
+		t.Errorf("Span.Start = %v, want 3:1", analysisErr.Span.Start)
+	}
+
+	if analysisErr.Severity != int(analysis.SeverityError) {
+		t.Errorf("Severity = %d, want %d", analysisErr.Severity, analysis.SeverityError)
+	}
+
+	var parseErr *scaf.ParseError
+	if err := analysis.Validate("test.scaf", []byte("query Q")); !errors.As(err, &parseErr) {
+		t.Errorf("Expected *scaf.ParseError for a syntax error, got %T: %v", err, err)
+	}
+
+	if err := analysis.Validate("test.scaf", []byte("query Q `Q`\nQ {\n\ttest \"t\" {}\n}\n")); err != nil {
+		t.Errorf("Validate() of a valid file = %v, want nil", err)
+	}
+}
+
+func TestSuiteValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code  string
+		input string
+	}{
+		{"undefined-query", "query Q `Q`\nUndefined {\n\ttest \"t\" {}\n}\n"},
+		{"duplicate-query", "query Q `Q`\nquery Q `Q`\n"},
+		{"duplicate-import", "import a \"./a\"\nimport a \"./b\"\nquery Q `Q`\n"},
+		{"unknown-parameter", "query Q `MATCH (n) RETURN n`\nQ {\n\ttest \"t\" {\n\t\t$nope: 1\n\t}\n}\n"},
+		{"unused-import", "import unused \"./unused\"\nquery Q `Q`\n"},
+		{"duplicate-test", "query Q `Q`\nQ {\n\ttest \"t\" {}\n\ttest \"t\" {}\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			t.Parallel()
+
+			suite, err := scaf.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+
+			var codes []string
+			for _, e := range suite.Validate() {
+				codes = append(codes, e.Code)
+			}
+
+			if !slices.Contains(codes, tt.code) {
+				t.Errorf("Validate() codes = %v, want %s", codes, tt.code)
+			}
+		})
+	}
+
+	suite, err := scaf.Parse([]byte("query Q `MATCH (n {id: $id}) RETURN n`\nQ {\n\ttest \"t\" {\n\t\t$id: 1\n\t}\n}\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if errs := suite.Validate(); len(errs) != 0 {
+		t.Errorf("Validate() on a clean suite = %v, want none", errs)
+	}
+}
@@ -0,0 +1,47 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/rlch/scaf/analysis"
+	"github.com/rlch/scaf/dialects/cypher"
+)
+
+// FuzzAnalyzePosition checks that analyzing arbitrary input and looking up
+// the completion and navigation context at an arbitrary position never panic.
+// Failing inputs found by go test -fuzz are saved under testdata/fuzz and
+// rerun as regression seeds by plain go test.
+func FuzzAnalyzePosition(f *testing.F) {
+	seeds := []struct {
+		input     string
+		line, col uint32
+	}{
+		{"", 0, 0},
+		{"query Q `MATCH (u:User) RETURN u`\nQ {\n\ttest \"t\" {\n\t\tu.\n\t}\n}\n", 3, 4},
+		{"import fixtures \"./fixtures\"\nquery Q `Q`\nQ {\n\tsetup fixtures.\n}\n", 3, 16},
+		{"query Q `Q`\nQ {\n\ttest \"t\" {\n\t\tassert Q(", 3, 11},
+		{"query Q `Q`\nQ {\n\tsequence {\n\t\ttest \"a\" { $", 3, 15},
+		{"#!scaf\nmeta {", 1, 6},
+		{"query Q `Q`\n", 40, 200},
+	}
+
+	for _, seed := range seeds {
+		f.Add([]byte(seed.input), seed.line, seed.col)
+	}
+
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetQueryAnalyzer(cypher.NewAnalyzer())
+
+	f.Fuzz(func(_ *testing.T, data []byte, line, col uint32) {
+		file := analyzer.Analyze("fuzz.scaf", data)
+		pos := analysis.PositionToLexer(line%64, col%256)
+
+		_ = analysis.NodeAtPosition(file, pos)
+		_ = analysis.QueryAtPosition(file, pos)
+		_ = analysis.SymbolAtPosition(file, pos)
+		_ = analysis.TokenAtPosition(file, pos)
+		_ = analysis.PrevTokenAtPosition(file, pos)
+		_ = analysis.GetTokenContext(file, pos)
+		_ = analysis.GetRecoveryCompletionContext(file, pos)
+	})
+}
@@ -0,0 +1,173 @@
+package analysis
+
+import "sort"
+
+// UnaliasedImports returns the file's imports written without an alias, in source order.
+// Besides being reachable by base name (db.CreateUser), an unaliased import merges
+// its queries into the file's namespace, so they can be called unqualified:
+//
+//	import "../setup/db"
+//	setup CreateUser($id: 1)
+func UnaliasedImports(f *AnalyzedFile) []*ImportSymbol {
+	if f == nil || f.Symbols == nil {
+		return nil
+	}
+
+	var imports []*ImportSymbol
+
+	for _, imp := range f.Symbols.Imports {
+		if imp.Alias == nil {
+			imports = append(imports, imp)
+		}
+	}
+
+	sort.Slice(imports, func(i, j int) bool {
+		if a, b := imports[i].Span.Start.Offset, imports[j].Span.Start.Offset; a != b {
+			return a < b
+		}
+
+		return imports[i].Name < imports[j].Name
+	})
+
+	return imports
+}
+
+// ResolveNamespaceQuery resolves an unqualified setup call query name against the
+// file's namespace: its own queries first, then those of each unaliased import.
+// Returns the query, the file defining it, and the import it came through
+// (nil for a local query). Imports are only searched when f has a Resolver.
+func ResolveNamespaceQuery(f *AnalyzedFile, name string) (*QuerySymbol, *AnalyzedFile, *ImportSymbol) {
+	if f == nil || f.Symbols == nil {
+		return nil, nil, nil
+	}
+
+	if q, ok := f.Symbols.Queries[name]; ok {
+		return q, f, nil
+	}
+
+	if f.Resolver == nil {
+		return nil, nil, nil
+	}
+
+	for _, imp := range UnaliasedImports(f) {
+		imported := f.Resolver.LoadAndAnalyze(f.Resolver.ResolveImportPath(f.Path, imp.Path))
+		if imported == nil || imported.Symbols == nil {
+			continue
+		}
+
+		if q, def := ResolveModuleQuery(f.Resolver, imported, name); q != nil {
+			return q, def, imp
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// ResolveModuleQuery looks up a query reachable through an imported file: one it
+// defines, or one it re-exports, followed through any chain of re-exports to the
+// original definition. Returns the query and the file defining it, or nils if
+// the query can't be found or the re-exports form a cycle. Re-exports are only
+// followed when r is non-nil.
+func ResolveModuleQuery(r CrossFileResolver, f *AnalyzedFile, name string) (*QuerySymbol, *AnalyzedFile) {
+	return resolveModuleQuery(r, f, name, make(map[string]bool))
+}
+
+func resolveModuleQuery(
+	r CrossFileResolver, f *AnalyzedFile, name string, seen map[string]bool,
+) (*QuerySymbol, *AnalyzedFile) {
+	if f == nil || f.Symbols == nil || seen[f.Path] {
+		return nil, nil
+	}
+
+	seen[f.Path] = true
+
+	if q, ok := f.Symbols.Queries[name]; ok {
+		return q, f
+	}
+
+	if r == nil {
+		return nil, nil
+	}
+
+	for _, exp := range f.Symbols.Exports {
+		if !exp.ExportsQuery(name) {
+			continue
+		}
+
+		if q, def := resolveModuleQuery(r, ExportSource(r, f, exp), name, seen); q != nil {
+			return q, def
+		}
+	}
+
+	return nil, nil
+}
+
+// ModuleQueries returns every query reachable through an imported file, keyed by
+// name: its own queries, then its re-exports in source order. Re-exported queries
+// are those of their original definitions.
+func ModuleQueries(r CrossFileResolver, f *AnalyzedFile) map[string]*QuerySymbol {
+	queries := make(map[string]*QuerySymbol)
+	collectModuleQueries(r, f, queries, make(map[string]bool))
+
+	return queries
+}
+
+func collectModuleQueries(r CrossFileResolver, f *AnalyzedFile, queries map[string]*QuerySymbol, seen map[string]bool) {
+	if f == nil || f.Symbols == nil || seen[f.Path] {
+		return
+	}
+
+	seen[f.Path] = true
+
+	for name, q := range f.Symbols.Queries {
+		if _, ok := queries[name]; !ok {
+			queries[name] = q
+		}
+	}
+
+	if r == nil {
+		return
+	}
+
+	for _, exp := range f.Symbols.Exports {
+		src := ExportSource(r, f, exp)
+
+		if exp.Module != nil {
+			collectModuleQueries(r, src, queries, seen)
+			continue
+		}
+
+		for _, name := range exp.Names {
+			if _, ok := queries[name]; ok {
+				continue
+			}
+
+			if q, _ := ResolveModuleQuery(r, src, name); q != nil {
+				queries[name] = q
+			}
+		}
+	}
+}
+
+// ExportSource loads the file a re-export draws from: the import named by
+// "export alias", or the path of "export { ... } from". Returns nil if it can't
+// be loaded.
+func ExportSource(r CrossFileResolver, f *AnalyzedFile, exp *ExportSymbol) *AnalyzedFile {
+	var path string
+
+	switch {
+	case exp.Module != nil:
+		imp, ok := f.Symbols.Imports[*exp.Module]
+		if !ok {
+			return nil
+		}
+
+		path = imp.Path
+	case exp.From != nil:
+		path = *exp.From
+	default:
+		return nil
+	}
+
+	return r.LoadAndAnalyze(r.ResolveImportPath(f.Path, path))
+}
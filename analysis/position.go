@@ -101,12 +101,14 @@ func nodeInSetupBlock(setup *scaf.SetupClause, pos lexer.Position) scaf.Node {
 //nolint:ireturn // Returning interface is intentional for AST node polymorphism.
 func nodeInItems(items []*scaf.TestOrGroup, pos lexer.Position) scaf.Node {
 	for _, item := range items {
-		if item.Test != nil && containsPosition(item.Test.Span(), pos) {
-			// Check for more specific nodes inside test
-			if child := nodeInTest(item.Test, pos); child != nil {
-				return child
+		for _, test := range item.Tests() {
+			if containsPosition(test.Span(), pos) {
+				// Check for more specific nodes inside test
+				if child := nodeInTest(test, pos); child != nil {
+					return child
+				}
+				return test
 			}
-			return item.Test
 		}
 
 		if item.Group != nil && containsPosition(item.Group.Span(), pos) {
@@ -334,8 +336,8 @@ func findTokenInTestOrGroup(item *scaf.TestOrGroup, pos lexer.Position) *lexer.T
 		}
 	}
 
-	if item.Test != nil {
-		if tok := findTokenInTest(item.Test, pos); tok != nil {
+	for _, test := range item.Tests() {
+		if tok := findTokenInTest(test, pos); tok != nil {
 			return tok
 		}
 	}
@@ -615,12 +617,12 @@ func findPrevTokenInTestOrGroup(item *scaf.TestOrGroup, pos lexer.Position, best
 		checkToken(&item.Tokens[i])
 	}
 
-	if item.Test != nil {
-		for i := range item.Test.Tokens {
-			checkToken(&item.Test.Tokens[i])
+	for _, test := range item.Tests() {
+		for i := range test.Tokens {
+			checkToken(&test.Tokens[i])
 		}
-		if item.Test.Setup != nil {
-			findPrevTokenInSetupClause(item.Test.Setup, pos, best, bestEnd)
+		if test.Setup != nil {
+			findPrevTokenInSetupClause(test.Setup, pos, best, bestEnd)
 		}
 	}
 
@@ -732,16 +734,18 @@ func GetTokenContext(f *AnalyzedFile, pos lexer.Position) *TokenContext {
 
 			// Check items
 			for _, item := range scope.Items {
-				if item.Test != nil && containsPosition(item.Test.Span(), pos) {
-					ctx.InTest = true
-					// Check setup in test
-					if item.Test.Setup != nil && containsPosition(item.Test.Setup.Span(), pos) {
-						ctx.InSetup = true
-					}
-					// Check asserts
-					for _, assert := range item.Test.Asserts {
-						if containsPosition(assert.Span(), pos) {
-							ctx.InAssert = true
+				for _, test := range item.Tests() {
+					if containsPosition(test.Span(), pos) {
+						ctx.InTest = true
+						// Check setup in test
+						if test.Setup != nil && containsPosition(test.Setup.Span(), pos) {
+							ctx.InSetup = true
+						}
+						// Check asserts
+						for _, assert := range test.Asserts {
+							if containsPosition(assert.Span(), pos) {
+								ctx.InAssert = true
+							}
 						}
 					}
 				}
@@ -769,14 +773,16 @@ func checkInGroup(group *scaf.Group, pos lexer.Position, ctx *TokenContext) bool
 	}
 
 	for _, item := range group.Items {
-		if item.Test != nil && containsPosition(item.Test.Span(), pos) {
-			ctx.InTest = true
-			if item.Test.Setup != nil && containsPosition(item.Test.Setup.Span(), pos) {
-				ctx.InSetup = true
-			}
-			for _, assert := range item.Test.Asserts {
-				if containsPosition(assert.Span(), pos) {
-					ctx.InAssert = true
+		for _, test := range item.Tests() {
+			if containsPosition(test.Span(), pos) {
+				ctx.InTest = true
+				if test.Setup != nil && containsPosition(test.Setup.Span(), pos) {
+					ctx.InSetup = true
+				}
+				for _, assert := range test.Asserts {
+					if containsPosition(assert.Span(), pos) {
+						ctx.InAssert = true
+					}
 				}
 			}
 		}
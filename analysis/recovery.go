@@ -55,6 +55,8 @@ const (
 	RecoveryCompletionReturnField
 	// RecoveryCompletionKeyword indicates completing a keyword.
 	RecoveryCompletionKeyword
+	// RecoveryCompletionQueryName indicates completing a query name after "assert".
+	RecoveryCompletionQueryName
 )
 
 // GetRecoveryCompletionContext analyzes the AST and parse errors to find completion context.
@@ -97,15 +99,23 @@ func GetRecoveryCompletionContext(f *AnalyzedFile, pos lexer.Position) *Recovery
 		if errorPos.Line > 0 {
 			ctx.ErrorPos = errorPos
 			// Get the token before the error position - use RecoverySuite if available
-			if f.RecoverySuite != nil {
-				ctx.PrevToken = prevTokenFromSuite(f.RecoverySuite, errorPos)
-			} else {
-				ctx.PrevToken = PrevTokenAtPosition(f, errorPos)
+			prevTokenAt := func(p lexer.Position) *lexer.Token {
+				if f.RecoverySuite != nil {
+					return prevTokenFromSuite(f.RecoverySuite, p)
+				}
+				return PrevTokenAtPosition(f, p)
 			}
-			
+			ctx.PrevToken = prevTokenAt(errorPos)
+
+			// The token before that distinguishes e.g. "assert Get" from "setup Get"
+			var beforePrev *lexer.Token
+			if ctx.PrevToken != nil {
+				beforePrev = prevTokenAt(ctx.PrevToken.Pos)
+			}
+
 			// If cursor is at or near error position, use error context
 			if isNearPosition(pos, errorPos) {
-				analyzeErrorContext(ctx, f.Symbols)
+				analyzeErrorContext(ctx, beforePrev, f.Symbols)
 				if ctx.Kind != RecoveryCompletionNone {
 					return ctx
 				}
@@ -184,9 +194,9 @@ func prevTokenFromSuite(suite *scaf.Suite, pos lexer.Position) *lexer.Token {
 			for i := range item.Tokens {
 				checkToken(&item.Tokens[i])
 			}
-			if item.Test != nil {
-				for i := range item.Test.Tokens {
-					checkToken(&item.Test.Tokens[i])
+			for _, test := range item.Tests() {
+				for i := range test.Tokens {
+					checkToken(&test.Tokens[i])
 				}
 			}
 			if item.Group != nil {
@@ -211,7 +221,12 @@ func getErrorPosition(err error) lexer.Position {
 		}
 	}
 	
-	// Check for single participle error
+	// Check for a single parse error
+	var parseErr *scaf.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.Span.Start
+	}
+
 	if perr, ok := err.(participle.Error); ok {
 		return perr.Position()
 	}
@@ -239,14 +254,20 @@ func isNearPosition(pos1, pos2 lexer.Position) bool {
 
 // analyzeErrorContext determines completion kind from error position context.
 // This is called when there's a parse error and the cursor is near the error position.
-// We use the token before the error to determine what completion to offer.
-func analyzeErrorContext(ctx *RecoveryCompletionContext, symbols *SymbolTable) {
+// We use the token before the error (and the one before that) to determine what
+// completion to offer.
+func analyzeErrorContext(ctx *RecoveryCompletionContext, beforePrev *lexer.Token, symbols *SymbolTable) {
 	if ctx.PrevToken == nil {
 		return
 	}
 	
 	// Check what token precedes the error
 	switch ctx.PrevToken.Type {
+	case scaf.TokenAssert:
+		// Error after "assert" - offer query names
+		ctx.Kind = RecoveryCompletionQueryName
+		ctx.InAssert = true
+		return
 	case scaf.TokenSetup:
 		// Error after "setup" - offer import aliases
 		ctx.Kind = RecoveryCompletionSetupAlias
@@ -262,6 +283,13 @@ func analyzeErrorContext(ctx *RecoveryCompletionContext, symbols *SymbolTable) {
 		// Note: ModuleAlias is NOT set here - caller must extract it from context
 		return
 	case scaf.TokenIdent:
+		// "assert Get" - partially typed query name
+		if beforePrev != nil && beforePrev.Type == scaf.TokenAssert {
+			ctx.Prefix = ctx.PrevToken.Value
+			ctx.Kind = RecoveryCompletionQueryName
+			ctx.InAssert = true
+			return
+		}
 		// If the previous token is an identifier, check if it's an import alias
 		if symbols != nil {
 			if _, ok := symbols.Imports[ctx.PrevToken.Value]; ok {
@@ -402,8 +430,8 @@ func findRecoveredInTestOrGroup(item *scaf.TestOrGroup, pos lexer.Position, ctx
 		ctx.RecoveredTokens = item.RecoveredTokens
 	}
 
-	if item.Test != nil {
-		findRecoveredInTest(item.Test, pos, ctx)
+	for _, test := range item.Tests() {
+		findRecoveredInTest(test, pos, ctx)
 	}
 
 	if item.Group != nil {
@@ -570,6 +598,24 @@ func analyzeRecoveredTokens(ctx *RecoveryCompletionContext, symbols *SymbolTable
 		return
 	}
 
+	// An assert recovered after its keyword keeps only the partial query name
+	if assert, ok := ctx.RecoveredNode.(*scaf.Assert); ok && assert.Query == nil {
+		if sig := significantTokens(tokens); len(sig) == 1 && sig[0].Type == scaf.TokenIdent {
+			ctx.Kind = RecoveryCompletionQueryName
+			ctx.Prefix = sig[0].Value
+			ctx.InAssert = true
+			return
+		}
+	}
+
+	// Check for trailing "assert <identifier>" pattern (query name completion)
+	if hasAssertIdentPattern(tokens) {
+		ctx.Kind = RecoveryCompletionQueryName
+		ctx.Prefix = extractAssertPrefix(tokens)
+		ctx.InAssert = true
+		return
+	}
+
 	// Check for "$" or "$<identifier>" pattern (parameter completion)
 	if hasParameterPattern(tokens) {
 		ctx.Kind = RecoveryCompletionParameter
@@ -642,6 +688,30 @@ func hasSetupIdentPattern(tokens []lexer.Token) bool {
 	return false
 }
 
+// hasAssertIdentPattern checks if tokens end with "assert" or "assert <ident>".
+func hasAssertIdentPattern(tokens []lexer.Token) bool {
+	tokens = significantTokens(tokens)
+	n := len(tokens)
+	if n == 0 {
+		return false
+	}
+	if tokens[n-1].Type == scaf.TokenAssert {
+		return true // Just "assert"
+	}
+	return n >= 2 && tokens[n-2].Type == scaf.TokenAssert && tokens[n-1].Type == scaf.TokenIdent
+}
+
+// significantTokens returns tokens without whitespace and comments.
+func significantTokens(tokens []lexer.Token) []lexer.Token {
+	result := make([]lexer.Token, 0, len(tokens))
+	for _, tok := range tokens {
+		if tok.Type != scaf.TokenWhitespace && tok.Type != scaf.TokenComment {
+			result = append(result, tok)
+		}
+	}
+	return result
+}
+
 // hasParameterPattern checks if tokens contain a $ parameter pattern.
 func hasParameterPattern(tokens []lexer.Token) bool {
 	for _, tok := range tokens {
@@ -713,6 +783,15 @@ func extractSetupPrefix(tokens []lexer.Token) string {
 	return ""
 }
 
+// extractAssertPrefix extracts the query name prefix typed after a trailing "assert".
+func extractAssertPrefix(tokens []lexer.Token) string {
+	tokens = significantTokens(tokens)
+	if n := len(tokens); n >= 2 && tokens[n-2].Type == scaf.TokenAssert {
+		return tokens[n-1].Value
+	}
+	return ""
+}
+
 // extractParameterPrefix extracts the $-prefixed identifier.
 func extractParameterPrefix(tokens []lexer.Token) string {
 	for _, tok := range tokens {
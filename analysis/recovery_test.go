@@ -0,0 +1,82 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/participle/v2/lexer"
+
+	"github.com/rlch/scaf/analysis"
+)
+
+func TestGetRecoveryCompletionContext_IncompleteAssert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		line       string
+		wantKind   analysis.RecoveryCompletionKind
+		wantPrefix string
+	}{
+		{
+			name:       "partial query name",
+			line:       "assert Get",
+			wantKind:   analysis.RecoveryCompletionQueryName,
+			wantPrefix: "Get",
+		},
+		{
+			name:       "bare assert",
+			line:       "assert ",
+			wantKind:   analysis.RecoveryCompletionQueryName,
+			wantPrefix: "",
+		},
+		{
+			name:       "setup still offers aliases",
+			line:       "setup ",
+			wantKind:   analysis.RecoveryCompletionSetupAlias,
+			wantPrefix: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			input := "query GetUser `MATCH (u:User) RETURN u`\n" +
+				"query Q `Q`\n" +
+				"Q {\n" +
+				"\ttest \"t\" {\n" +
+				"\t\t" + tt.line + "\n" +
+				"\t}\n" +
+				"}\n"
+
+			f := analyze(t, input)
+			if f.ParseError == nil {
+				t.Fatal("expected a parse error for incomplete input")
+			}
+
+			// Cursor at the end of the incomplete line (1-indexed, after two tabs).
+			pos := lexer.Position{Line: 5, Column: 3 + len(tt.line)}
+
+			ctx := analysis.GetRecoveryCompletionContext(f, pos)
+			if ctx == nil {
+				t.Fatal("GetRecoveryCompletionContext() = nil")
+			}
+
+			if ctx.Kind != tt.wantKind {
+				t.Errorf("Kind = %v, want %v", ctx.Kind, tt.wantKind)
+			}
+
+			if ctx.Prefix != tt.wantPrefix {
+				t.Errorf("Prefix = %q, want %q", ctx.Prefix, tt.wantPrefix)
+			}
+
+			if !ctx.InTest {
+				t.Error("InTest = false, want true")
+			}
+
+			if ctx.QueryScope != "Q" {
+				t.Errorf("QueryScope = %q, want Q", ctx.QueryScope)
+			}
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package analysis
 
 import (
+	"errors"
+	"math"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/alecthomas/participle/v2"
+	"github.com/alecthomas/participle/v2/lexer"
 	"github.com/rlch/scaf"
 )
 
@@ -21,6 +27,9 @@ type Rule struct {
 
 	// Run executes the rule and appends any diagnostics to the file.
 	Run func(f *AnalyzedFile)
+
+	// OptIn rules only run when a severity override enables their code.
+	OptIn bool
 }
 
 // DefaultRules returns all built-in semantic analysis rules.
@@ -28,24 +37,74 @@ func DefaultRules() []*Rule {
 	return []*Rule{
 		// Error-level checks.
 		undefinedQueryRule,
+		undefinedProfileRule,
 		undefinedImportRule,
 		duplicateQueryRule,
 		duplicateImportRule,
+		duplicateImportSymbolRule,
 		undefinedAssertQueryRule,
 		undefinedSetupQueryRule, // Cross-file validation
+		undefinedExportRule,     // Cross-file validation
+		circularImportRule,      // Cross-file validation
+		undefinedBindingRule,
+		paramTypeMismatchRule,
+		typeMismatchRule, // Schema validation
+		numberForStringFieldRule,
+		enumViolationRule,
+		undefinedFieldRefRule,
+		unionColumnMismatchRule,
 
 		// Warning-level checks.
 		unusedImportRule,
 		unknownParameterRule,
+		unknownAssertFieldRule,
+		aggregateGroupingRule,
 		duplicateTestRule,
 		duplicateGroupRule,
 		missingRequiredParamsRule,
 		emptyGroupRule,
+		misorderedSetupRule,
+		unknownLabelRule, // Schema validation
+		unknownPropertyRule,
 
 		// Hint-level checks.
 		emptyTestRule,
+		unknownReturnFieldRule,
 		unusedQueryParamRule,
+		unusedQueryRule,                // Opt-in
+		emptyAssertRule,                // Opt-in
+		emptyFileRule,                  // Opt-in
+		writeQueryWithoutIsolationRule, // Opt-in
+		reportRecoveredRule,            // Opt-in, runs on failed parses
+	}
+}
+
+// SchemaRules returns the rules that validate a suite against a type schema.
+// They are part of DefaultRules, and do nothing unless a schema is set.
+func SchemaRules() []*Rule {
+	return []*Rule{
+		unknownLabelRule,
+		unknownPropertyRule,
+		typeMismatchRule,
+		numberForStringFieldRule,
+		enumViolationRule,
+	}
+}
+
+// ValidationRules returns the rules that need nothing beyond the suite itself:
+// DefaultRules without the schema and opt-in rules. Suite.Validate runs these.
+func ValidationRules() []*Rule {
+	schema := SchemaRules()
+
+	var rules []*Rule
+
+	for _, rule := range DefaultRules() {
+		if !rule.OptIn && !slices.Contains(schema, rule) {
+			rules = append(rules, rule)
+		}
 	}
+
+	return rules
 }
 
 // ----------------------------------------------------------------------------
@@ -64,12 +123,24 @@ func checkUndefinedQueries(f *AnalyzedFile) {
 		return
 	}
 
+	names := make([]string, 0, len(f.Symbols.Queries))
+	for name := range f.Symbols.Queries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
 	for _, scope := range f.Suite.Scopes {
 		if _, ok := f.Symbols.Queries[scope.QueryName]; !ok {
+			msg := "undefined query: " + scope.QueryName
+			if suggestion := closestName(scope.QueryName, names); suggestion != "" {
+				msg += " (did you mean " + suggestion + "?)"
+			}
+
 			f.Diagnostics = append(f.Diagnostics, Diagnostic{
 				Span:     scope.Span(),
 				Severity: SeverityError,
-				Message:  "undefined query: " + scope.QueryName,
+				Message:  msg,
 				Code:     "undefined-query",
 				Source:   "scaf",
 			})
@@ -77,13 +148,121 @@ func checkUndefinedQueries(f *AnalyzedFile) {
 	}
 }
 
+// ----------------------------------------------------------------------------
+// Rule: undefined-profile
+// ----------------------------------------------------------------------------
+
+var undefinedProfileRule = &Rule{
+	Name:     "undefined-profile",
+	Doc:      "Reports query scopes that extend undefined profiles.",
+	Severity: SeverityError,
+	Run:      checkUndefinedProfiles,
+}
+
+func checkUndefinedProfiles(f *AnalyzedFile) {
+	if f.Suite == nil {
+		return
+	}
+
+	names := make([]string, 0, len(f.Suite.Profiles))
+	for _, p := range f.Suite.Profiles {
+		names = append(names, p.Name)
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		if scope.Extends == nil || f.Suite.Profile(*scope.Extends) != nil {
+			continue
+		}
+
+		msg := "undefined profile: " + *scope.Extends
+		if suggestion := closestName(*scope.Extends, names); suggestion != "" {
+			msg += " (did you mean " + suggestion + "?)"
+		}
+
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     extendsSpan(scope),
+			Severity: SeverityError,
+			Message:  msg,
+			Code:     "undefined-profile",
+			Source:   "scaf",
+		})
+	}
+}
+
+// extendsSpan returns the span of a scope's "extends <profile>", or the
+// scope's span if its tokens don't hold one.
+func extendsSpan(scope *scaf.QueryScope) scaf.Span {
+	for i, tok := range scope.Tokens {
+		if tok.Type != scaf.TokenIdent || tok.Value != "extends" {
+			continue
+		}
+
+		for _, next := range scope.Tokens[i+1:] {
+			if next.Type == scaf.TokenIdent {
+				end := next.Pos
+				end.Offset += len(next.Value)
+				end.Column += len(next.Value)
+
+				return scaf.Span{Start: tok.Pos, End: end}
+			}
+		}
+	}
+
+	return scope.Span()
+}
+
+// maxSuggestionDistance is the largest edit distance for a "did you mean" suggestion.
+const maxSuggestionDistance = 2
+
+// closestName returns the candidate nearest to name within maxSuggestionDistance edits,
+// or "" if there is none. Ties go to the earliest candidate.
+func closestName(name string, candidates []string) string {
+	best, bestDist := "", maxSuggestionDistance+1
+
+	for _, c := range candidates {
+		// Very short names are within two edits of almost anything.
+		if d := editDistance(name, c); d < bestDist && d < len(name) {
+			best, bestDist = c, d
+		}
+	}
+
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
 // ----------------------------------------------------------------------------
 // Rule: undefined-import
 // ----------------------------------------------------------------------------
 
 var undefinedImportRule = &Rule{
 	Name:     "undefined-import",
-	Doc:      "Reports setup calls that reference undefined imports.",
+	Doc:      "Reports setup calls and re-exports that reference undefined imports.",
 	Severity: SeverityError,
 	Run:      checkUndefinedImports,
 }
@@ -121,8 +300,8 @@ func checkUndefinedImports(f *AnalyzedFile) {
 
 	checkItems = func(items []*scaf.TestOrGroup) {
 		for _, item := range items {
-			if item.Test != nil {
-				checkSetup(item.Test.Setup)
+			for _, test := range item.Tests() {
+				checkSetup(test.Setup)
 			}
 
 			if item.Group != nil {
@@ -138,6 +317,13 @@ func checkUndefinedImports(f *AnalyzedFile) {
 		checkSetup(scope.Setup)
 		checkItems(scope.Items)
 	}
+
+	// Re-exporting a module uses its import.
+	for _, exp := range f.Suite.Exports {
+		if exp.Module != nil {
+			checkSetupModuleImport(f, *exp.Module, exp.Span())
+		}
+	}
 }
 
 func checkSetupModuleImport(f *AnalyzedFile, moduleAlias string, span scaf.Span) {
@@ -155,6 +341,11 @@ func checkSetupModuleImport(f *AnalyzedFile, moduleAlias string, span scaf.Span)
 }
 
 func checkSetupCallImport(f *AnalyzedFile, call *scaf.SetupCall) {
+	if call.Module == "" {
+		markNamespaceImportUsed(f, call.Query)
+		return
+	}
+
 	if imp, ok := f.Symbols.Imports[call.Module]; !ok {
 		f.Diagnostics = append(f.Diagnostics, Diagnostic{
 			Span:     call.Span(),
@@ -168,6 +359,27 @@ func checkSetupCallImport(f *AnalyzedFile, call *scaf.SetupCall) {
 	}
 }
 
+// markNamespaceImportUsed marks the unaliased import providing an unqualified
+// setup call's query as used. Without a resolver the provider can't be known,
+// so every unaliased import is assumed used rather than reported.
+func markNamespaceImportUsed(f *AnalyzedFile, queryName string) {
+	if _, ok := f.Symbols.Queries[queryName]; ok {
+		return
+	}
+
+	if f.Resolver == nil {
+		for _, imp := range UnaliasedImports(f) {
+			imp.Used = true
+		}
+
+		return
+	}
+
+	if _, _, imp := ResolveNamespaceQuery(f, queryName); imp != nil {
+		imp.Used = true
+	}
+}
+
 // ----------------------------------------------------------------------------
 // Rule: duplicate-query
 // ----------------------------------------------------------------------------
@@ -189,7 +401,7 @@ func checkDuplicateQueries(f *AnalyzedFile) {
 	for _, q := range f.Suite.Queries {
 		if firstSpan, exists := seen[q.Name]; exists {
 			f.Diagnostics = append(f.Diagnostics, Diagnostic{
-				Span:     q.Span(),
+				Span:     nameSpan(q.Tokens, scaf.TokenIdent, q.Span()),
 				Severity: SeverityError,
 				Message:  "duplicate query name: " + q.Name + " (first defined at line " + formatLine(firstSpan) + ")",
 				Code:     "duplicate-query",
@@ -239,6 +451,61 @@ func checkDuplicateImports(f *AnalyzedFile) {
 	}
 }
 
+// ----------------------------------------------------------------------------
+// Rule: duplicate-import-symbol
+// ----------------------------------------------------------------------------
+
+var duplicateImportSymbolRule = &Rule{
+	Name:     "duplicate-import-symbol",
+	Doc:      "Reports unaliased imports whose queries collide with local queries or other unaliased imports.",
+	Severity: SeverityError,
+	Run:      checkDuplicateImportSymbols,
+}
+
+func checkDuplicateImportSymbols(f *AnalyzedFile) {
+	if f.Suite == nil || f.Resolver == nil {
+		return // Requires loading the imported files
+	}
+
+	// Query name -> where it entered the namespace.
+	owners := make(map[string]string, len(f.Symbols.Queries))
+	for name := range f.Symbols.Queries {
+		owners[name] = "this file"
+	}
+
+	for _, imp := range UnaliasedImports(f) {
+		imported := f.Resolver.LoadAndAnalyze(f.Resolver.ResolveImportPath(f.Path, imp.Path))
+		if imported == nil || imported.Symbols == nil {
+			continue
+		}
+
+		queries := ModuleQueries(f.Resolver, imported)
+
+		names := make([]string, 0, len(queries))
+		for name := range queries {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			if owner, exists := owners[name]; exists {
+				f.Diagnostics = append(f.Diagnostics, Diagnostic{
+					Span:     imp.Span,
+					Severity: SeverityError,
+					Message:  "duplicate import symbol: " + name + " from " + strconv.Quote(imp.Path) + " is already defined in " + owner,
+					Code:     "duplicate-import-symbol",
+					Source:   "scaf",
+				})
+
+				continue
+			}
+
+			owners[name] = strconv.Quote(imp.Path)
+		}
+	}
+}
+
 // ----------------------------------------------------------------------------
 // Rule: unused-import
 // ----------------------------------------------------------------------------
@@ -287,26 +554,52 @@ func checkUnknownParameters(f *AnalyzedFile) {
 			continue // Already reported as undefined-query.
 		}
 
+		params := query.Params
+
+		// Prefer the dialect's view of the body, which ignores $ in strings and
+		// comments; a body it can't analyze can't be checked.
+		if f.QueryAnalyzer != nil {
+			metadata, err := f.QueryAnalyzer.AnalyzeQuery(query.Body)
+			if err != nil || metadata == nil {
+				continue
+			}
+
+			params = metadata.Params()
+		}
+
 		queryParams := make(map[string]bool)
-		for _, p := range query.Params {
+		for _, p := range params {
 			queryParams[p] = true
 		}
 
-		checkItemParams(f, scope.Items, queryParams, scope.QueryName)
+		// A declared default names a parameter even if the body doesn't use it.
+		for p := range query.Node.ParamDefaults() {
+			if !queryParams[p] {
+				queryParams[p] = true
+				params = append(params, p)
+			}
+		}
+
+		available := "query " + scope.QueryName + " has no parameters"
+		if len(params) > 0 {
+			available = "available: $" + strings.Join(params, ", $")
+		}
+
+		checkItemParams(f, scope.Items, queryParams, scope.QueryName, available)
 	}
 }
 
-func checkItemParams(f *AnalyzedFile, items []*scaf.TestOrGroup, queryParams map[string]bool, queryName string) {
+func checkItemParams(f *AnalyzedFile, items []*scaf.TestOrGroup, queryParams map[string]bool, queryName, available string) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, stmt := range item.Test.Statements {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
 				key := stmt.Key()
 				if paramName, ok := strings.CutPrefix(key, "$"); ok {
 					if !queryParams[paramName] {
 						f.Diagnostics = append(f.Diagnostics, Diagnostic{
-							Span:     item.Test.Span(),
+							Span:     stmt.KeyParts.Span(),
 							Severity: SeverityWarning,
-							Message:  "parameter $" + paramName + " not found in query " + queryName,
+							Message:  "parameter $" + paramName + " not found in query " + queryName + " (" + available + ")",
 							Code:     "unknown-parameter",
 							Source:   "scaf",
 						})
@@ -316,11 +609,90 @@ func checkItemParams(f *AnalyzedFile, items []*scaf.TestOrGroup, queryParams map
 		}
 
 		if item.Group != nil {
-			checkItemParams(f, item.Group.Items, queryParams, queryName)
+			checkItemParams(f, item.Group.Items, queryParams, queryName, available)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Rule: unknown-return-field
+// ----------------------------------------------------------------------------
+
+var unknownReturnFieldRule = &Rule{
+	Name:     "unknown-return-field",
+	Doc:      "Reports expected fields that the query doesn't return.",
+	Severity: SeverityHint,
+	Run:      checkUnknownReturnFields,
+}
+
+func checkUnknownReturnFields(f *AnalyzedFile) {
+	if f.Suite == nil || f.QueryAnalyzer == nil {
+		return // Return fields are only known with a dialect analyzer
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		query, ok := f.Symbols.Queries[scope.QueryName]
+		if !ok {
+			continue // Already reported as undefined-query.
+		}
+
+		metadata, err := f.QueryAnalyzer.AnalyzeQuery(query.Body)
+		if err != nil || metadata == nil || len(metadata.Returns) == 0 || returnsStar(metadata.Returns) {
+			continue
+		}
+
+		checkItemReturnFields(f, scope.Items, metadata.Returns, scope.QueryName)
+	}
+}
+
+func checkItemReturnFields(f *AnalyzedFile, items []*scaf.TestOrGroup, returns []scaf.ReturnInfo, queryName string) {
+	for _, item := range items {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
+				key := stmt.Key()
+				if key == "" || strings.HasPrefix(key, "$") || expectsReturnField(returns, key) {
+					continue
+				}
+
+				msg := "field " + key + " is not returned by query " + queryName
+				if suggestion := closestName(key, returnColumns(returns)); suggestion != "" {
+					msg += " (did you mean " + suggestion + "?)"
+				}
+
+				f.Diagnostics = append(f.Diagnostics, Diagnostic{
+					Span:     stmt.Span(),
+					Severity: SeverityHint,
+					Message:  msg,
+					Code:     "unknown-return-field",
+					Source:   "scaf",
+				})
+			}
+		}
+
+		if item.Group != nil {
+			checkItemReturnFields(f, item.Group.Items, returns, queryName)
 		}
 	}
 }
 
+// expectsReturnField reports whether a statement key names one of a query's
+// return fields, by column, dialect key, or position.
+func expectsReturnField(returns []scaf.ReturnInfo, key string) bool {
+	if index, ok := scaf.ParseColumnKey(key); ok {
+		return index < len(returns)
+	}
+
+	return returnForKey(returns, key) != nil || returnsField(returns, key)
+}
+
+// returnsStar reports whether a query returns columns that aren't known until
+// it runs: RETURN * or a star projection like u {.*}.
+func returnsStar(returns []scaf.ReturnInfo) bool {
+	return slices.ContainsFunc(returns, func(ret scaf.ReturnInfo) bool {
+		return ret.IsWildcard || strings.Contains(ret.Expression, ".*")
+	})
+}
+
 // ----------------------------------------------------------------------------
 // Rule: empty-test
 // ----------------------------------------------------------------------------
@@ -341,12 +713,12 @@ func checkEmptyTests(f *AnalyzedFile) {
 
 	checkItems = func(items []*scaf.TestOrGroup) {
 		for _, item := range items {
-			if item.Test != nil {
-				if len(item.Test.Statements) == 0 && len(item.Test.Asserts) == 0 && item.Test.Setup == nil {
+			for _, test := range item.Tests() {
+				if len(test.Statements) == 0 && test.Expect == nil && len(test.Asserts) == 0 && test.Setup == nil {
 					f.Diagnostics = append(f.Diagnostics, Diagnostic{
-						Span:     item.Test.Span(),
+						Span:     test.Span(),
 						Severity: SeverityHint,
-						Message:  "empty test: " + item.Test.Name,
+						Message:  "empty test: " + test.Name,
 						Code:     "empty-test",
 						Source:   "scaf",
 					})
@@ -370,7 +742,7 @@ func checkEmptyTests(f *AnalyzedFile) {
 
 var duplicateTestRule = &Rule{
 	Name:     "duplicate-test",
-	Doc:      "Reports duplicate test names within the same scope.",
+	Doc:      "Reports tests named like another test or group in the same scope or group.",
 	Severity: SeverityWarning,
 	Run:      checkDuplicateTests,
 }
@@ -391,7 +763,7 @@ func checkDuplicateTests(f *AnalyzedFile) {
 
 var duplicateGroupRule = &Rule{
 	Name:     "duplicate-group",
-	Doc:      "Reports duplicate group names within the same scope.",
+	Doc:      "Reports groups named like another group or test in the same scope or group.",
 	Severity: SeverityWarning,
 	Run:      checkDuplicateGroups,
 }
@@ -406,40 +778,54 @@ func checkDuplicateGroups(f *AnalyzedFile) {
 	}
 }
 
+// checkDuplicateTestNamesInItems reports tests named like an earlier test or
+// group among the same items, whose run paths would collide.
 func checkDuplicateTestNamesInItems(f *AnalyzedFile, items []*scaf.TestOrGroup) {
-	testNames := make(map[string]scaf.Span)
+	names := make(map[string]scaf.Span)
 
 	for _, item := range items {
-		if item.Test != nil {
-			if firstSpan, exists := testNames[item.Test.Name]; exists {
+		for _, test := range item.Tests() {
+			if firstSpan, exists := names[test.Name]; exists {
 				f.Diagnostics = append(f.Diagnostics, Diagnostic{
-					Span:     item.Test.Span(),
+					Span:     nameSpan(test.Tokens, scaf.TokenString, test.Span()),
 					Severity: SeverityWarning,
-					Message: "duplicate test name in scope: " + item.Test.Name +
+					Message: "duplicate test name in scope: " + test.Name +
 						" (first defined at line " + formatLine(firstSpan) + ")",
 					Code:   "duplicate-test",
 					Source: "scaf",
 				})
 			} else {
-				testNames[item.Test.Name] = item.Test.Span()
+				names[test.Name] = test.Span()
 			}
 		}
 
 		if item.Group != nil {
+			if _, exists := names[item.Group.Name]; !exists {
+				names[item.Group.Name] = item.Group.Span()
+			}
+
 			// Recurse into group.
 			checkDuplicateTestNamesInItems(f, item.Group.Items)
 		}
 	}
 }
 
+// checkDuplicateGroupNamesInItems reports groups named like an earlier group
+// or test among the same items, whose run paths would collide.
 func checkDuplicateGroupNamesInItems(f *AnalyzedFile, items []*scaf.TestOrGroup) {
-	groupNames := make(map[string]scaf.Span)
+	names := make(map[string]scaf.Span)
 
 	for _, item := range items {
+		for _, test := range item.Tests() {
+			if _, exists := names[test.Name]; !exists {
+				names[test.Name] = test.Span()
+			}
+		}
+
 		if item.Group != nil {
-			if firstSpan, exists := groupNames[item.Group.Name]; exists {
+			if firstSpan, exists := names[item.Group.Name]; exists {
 				f.Diagnostics = append(f.Diagnostics, Diagnostic{
-					Span:     item.Group.Span(),
+					Span:     nameSpan(item.Group.Tokens, scaf.TokenString, item.Group.Span()),
 					Severity: SeverityWarning,
 					Message: "duplicate group name in scope: " + item.Group.Name +
 						" (first defined at line " + formatLine(firstSpan) + ")",
@@ -447,7 +833,7 @@ func checkDuplicateGroupNamesInItems(f *AnalyzedFile, items []*scaf.TestOrGroup)
 					Source: "scaf",
 				})
 			} else {
-				groupNames[item.Group.Name] = item.Group.Span()
+				names[item.Group.Name] = item.Group.Span()
 			}
 
 			// Recurse into group.
@@ -476,13 +862,13 @@ func checkUndefinedAssertQueries(f *AnalyzedFile) {
 
 	checkItems = func(items []*scaf.TestOrGroup) {
 		for _, item := range items {
-			if item.Test != nil {
-				for _, assert := range item.Test.Asserts {
+			for _, test := range item.Tests() {
+				for _, assert := range test.Asserts {
 					if assert.Query != nil && assert.Query.QueryName != nil {
 						queryName := *assert.Query.QueryName
 						if _, ok := f.Symbols.Queries[queryName]; !ok {
 							f.Diagnostics = append(f.Diagnostics, Diagnostic{
-								Span:     item.Test.Span(),
+								Span:     test.Span(),
 								Severity: SeverityError,
 								Message:  "assert references undefined query: " + queryName,
 								Code:     "undefined-assert-query",
@@ -505,19 +891,19 @@ func checkUndefinedAssertQueries(f *AnalyzedFile) {
 }
 
 // ----------------------------------------------------------------------------
-// Rule: missing-required-params
+// Rule: undefined-field-ref
 // ----------------------------------------------------------------------------
 
-var missingRequiredParamsRule = &Rule{
-	Name:     "missing-required-params",
-	Doc:      "Reports tests that don't provide all required query parameters.",
-	Severity: SeverityWarning,
-	Run:      checkMissingRequiredParams,
+var undefinedFieldRefRule = &Rule{
+	Name:     "undefined-field-ref",
+	Doc:      "Reports assert query parameters that reference fields the main query doesn't return.",
+	Severity: SeverityError,
+	Run:      checkUndefinedFieldRefs,
 }
 
-func checkMissingRequiredParams(f *AnalyzedFile) {
-	if f.Suite == nil {
-		return
+func checkUndefinedFieldRefs(f *AnalyzedFile) {
+	if f.Suite == nil || f.QueryAnalyzer == nil {
+		return // Return fields are only known with a dialect analyzer
 	}
 
 	for _, scope := range f.Suite.Scopes {
@@ -526,245 +912,1898 @@ func checkMissingRequiredParams(f *AnalyzedFile) {
 			continue // Already reported as undefined-query.
 		}
 
-		checkItemMissingParams(f, scope.Items, query.Params, scope.QueryName)
+		metadata, err := f.QueryAnalyzer.AnalyzeQuery(query.Body)
+		if err != nil || metadata == nil {
+			continue
+		}
+
+		checkItemFieldRefs(f, scope.Items, metadata.Returns, scope.QueryName)
 	}
 }
 
-func checkItemMissingParams(f *AnalyzedFile, items []*scaf.TestOrGroup, queryParams []string, queryName string) {
+func checkItemFieldRefs(f *AnalyzedFile, items []*scaf.TestOrGroup, returns []scaf.ReturnInfo, queryName string) {
 	for _, item := range items {
-		if item.Test != nil {
-			providedParams := make(map[string]bool)
-
-			for _, stmt := range item.Test.Statements {
-				key := stmt.Key()
-				if paramName, ok := strings.CutPrefix(key, "$"); ok {
-					providedParams[paramName] = true
+		for _, test := range item.Tests() {
+			for _, assert := range test.Asserts {
+				if assert.Query == nil {
+					continue
 				}
-			}
 
-			var missing []string
+				for _, p := range assert.Query.Params {
+					if p.Value == nil || !p.Value.IsFieldRef() {
+						continue
+					}
 
-			for _, p := range queryParams {
-				if !providedParams[p] {
-					missing = append(missing, "$"+p)
-				}
-			}
+					ref := p.Value.FieldRefString()
+					if returnsField(returns, ref) {
+						continue
+					}
 
-			if len(missing) > 0 {
-				f.Diagnostics = append(f.Diagnostics, Diagnostic{
-					Span:     item.Test.Span(),
-					Severity: SeverityWarning,
-					Message:  "test is missing required parameters for " + queryName + ": " + strings.Join(missing, ", "),
-					Code:     "missing-required-params",
-					Source:   "scaf",
-				})
+					f.Diagnostics = append(f.Diagnostics, Diagnostic{
+						Span:     p.Value.Span(),
+						Severity: SeverityError,
+						Message:  "field " + ref + " is not returned by query " + queryName,
+						Code:     "undefined-field-ref",
+						Source:   "scaf",
+					})
+				}
 			}
 		}
 
 		if item.Group != nil {
-			checkItemMissingParams(f, item.Group.Items, queryParams, queryName)
+			checkItemFieldRefs(f, item.Group.Items, returns, queryName)
+		}
+	}
+}
+
+// returnsField reports whether a field reference can be resolved from a result row.
+// A reference matches a return column exactly (u.id) or accesses a property of
+// a returned map/node (u.id when the query returns u).
+func returnsField(returns []scaf.ReturnInfo, ref string) bool {
+	for _, ret := range returns {
+		if ret.IsWildcard {
+			return true
+		}
+
+		column := ret.Alias
+		if column == "" {
+			column = ret.Expression
+		}
+
+		if ref == column || strings.HasPrefix(ref, column+".") {
+			return true
 		}
 	}
+
+	return false
 }
 
 // ----------------------------------------------------------------------------
-// Rule: empty-group
+// Rule: aggregate-grouping
 // ----------------------------------------------------------------------------
 
-var emptyGroupRule = &Rule{
-	Name:     "empty-group",
-	Doc:      "Reports groups with no tests or nested groups.",
+var aggregateGroupingRule = &Rule{
+	Name:     "aggregate-grouping",
+	Doc:      "Reports aggregate fields expected alongside non-aggregated fields, which group the aggregate.",
 	Severity: SeverityWarning,
-	Run:      checkEmptyGroups,
+	Run:      checkAggregateGrouping,
 }
 
-func checkEmptyGroups(f *AnalyzedFile) {
-	if f.Suite == nil {
-		return
+func checkAggregateGrouping(f *AnalyzedFile) {
+	if f.Suite == nil || f.QueryAnalyzer == nil {
+		return // Aggregates are only known with a dialect analyzer
 	}
 
-	var checkItems func([]*scaf.TestOrGroup)
+	for _, scope := range f.Suite.Scopes {
+		query, ok := f.Symbols.Queries[scope.QueryName]
+		if !ok {
+			continue // Already reported as undefined-query.
+		}
 
-	checkItems = func(items []*scaf.TestOrGroup) {
-		for _, item := range items {
-			if item.Group != nil {
-				if len(item.Group.Items) == 0 {
-					f.Diagnostics = append(f.Diagnostics, Diagnostic{
-						Span:     item.Group.Span(),
-						Severity: SeverityWarning,
-						Message:  "empty group: " + item.Group.Name,
-						Code:     "empty-group",
-						Source:   "scaf",
-					})
-				}
+		metadata, err := f.QueryAnalyzer.AnalyzeQuery(query.Body)
+		if err != nil || metadata == nil {
+			continue
+		}
 
-				checkItems(item.Group.Items)
+		var groupingKeys []string
+
+		for _, ret := range metadata.Returns {
+			if !ret.IsAggregate {
+				groupingKeys = append(groupingKeys, returnColumn(ret))
 			}
 		}
-	}
 
-	for _, scope := range f.Suite.Scopes {
-		checkItems(scope.Items)
+		if len(groupingKeys) == 0 || len(groupingKeys) == len(metadata.Returns) {
+			continue // Nothing aggregated, or nothing to group by.
+		}
+
+		checkItemAggregateGrouping(f, scope.Items, metadata.Returns, strings.Join(groupingKeys, ", "))
+	}
+}
+
+func checkItemAggregateGrouping(f *AnalyzedFile, items []*scaf.TestOrGroup, returns []scaf.ReturnInfo, groupingKeys string) {
+	for _, item := range items {
+		if item.Group != nil {
+			checkItemAggregateGrouping(f, item.Group.Items, returns, groupingKeys)
+		}
+
+		for _, test := range item.Tests() {
+			var (
+				aggregates []*scaf.Statement
+				grouped    bool
+			)
+
+			for _, stmt := range test.Statements {
+				ret := returnForKey(returns, stmt.Key())
+				if ret == nil {
+					continue
+				}
+
+				if ret.IsAggregate {
+					aggregates = append(aggregates, stmt)
+				} else {
+					grouped = true
+				}
+			}
+
+			if !grouped {
+				continue
+			}
+
+			for _, stmt := range aggregates {
+				ret := returnForKey(returns, stmt.Key())
+				f.Diagnostics = append(f.Diagnostics, Diagnostic{
+					Span:     stmt.Span(),
+					Severity: SeverityWarning,
+					Message: "aggregate " + stmt.Key() + " (" + ret.Expression + ") is grouped by " + groupingKeys +
+						": it is computed per group, not over all matched rows",
+					Code:   "aggregate-grouping",
+					Source: "scaf",
+				})
+			}
+		}
+	}
+}
+
+// returnForKey returns the return field an expected value's key names, or nil.
+func returnForKey(returns []scaf.ReturnInfo, key string) *scaf.ReturnInfo {
+	for i, ret := range returns {
+		if ret.Alias == key || ret.Name == key || ret.Expression == key || ret.Key == key {
+			return &returns[i]
+		}
+	}
+
+	return nil
+}
+
+// returnColumn returns the result column name of a return field.
+func returnColumn(ret scaf.ReturnInfo) string {
+	if ret.Alias != "" {
+		return ret.Alias
+	}
+
+	return ret.Expression
+}
+
+// ----------------------------------------------------------------------------
+// Rule: unknown-assert-field
+// ----------------------------------------------------------------------------
+
+var unknownAssertFieldRule = &Rule{
+	Name:     "unknown-assert-field",
+	Doc:      "Reports assert conditions that reference fields the query they run against doesn't return.",
+	Severity: SeverityWarning,
+	Run:      checkUnknownAssertFields,
+}
+
+// exprKeywords are expr-lang words that lex as identifiers but never name a field.
+var exprKeywords = map[string]bool{
+	"true": true, "false": true, "nil": true, "null": true,
+	"and": true, "or": true, "not": true, "in": true, "matches": true,
+	"contains": true, "startsWith": true, "endsWith": true, "let": true,
+}
+
+func checkUnknownAssertFields(f *AnalyzedFile) {
+	if f.Suite == nil || f.QueryAnalyzer == nil {
+		return // Return fields are only known with a dialect analyzer
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		// Asserts without a query run against the scope query's rows.
+		var mainReturns []scaf.ReturnInfo
+
+		if query, ok := f.Symbols.Queries[scope.QueryName]; ok {
+			if metadata, err := f.QueryAnalyzer.AnalyzeQuery(query.Body); err == nil && metadata != nil {
+				mainReturns = metadata.Returns
+			}
+		}
+
+		checkItemAssertFields(f, scope.Items, mainReturns)
+	}
+}
+
+func checkItemAssertFields(f *AnalyzedFile, items []*scaf.TestOrGroup, mainReturns []scaf.ReturnInfo) {
+	for _, item := range items {
+		if item.Group != nil {
+			checkItemAssertFields(f, item.Group.Items, mainReturns)
+		}
+
+		for _, test := range item.Tests() {
+			for _, assert := range test.Asserts {
+				if assert.Query == nil {
+					if len(mainReturns) == 0 {
+						continue
+					}
+
+					for _, cond := range assert.Conditions {
+						checkConditionFields(f, cond, mainReturns, "the main query")
+					}
+
+					continue
+				}
+
+				var body string
+
+				switch {
+				case assert.Query.Inline != nil:
+					body = *assert.Query.Inline
+				case assert.Query.QueryName != nil:
+					q, ok := f.Symbols.Queries[*assert.Query.QueryName]
+					if !ok {
+						continue // Already reported as undefined-assert-query.
+					}
+
+					body = q.Body
+				}
+
+				metadata, err := f.QueryAnalyzer.AnalyzeQuery(body)
+				if err != nil || metadata == nil || len(metadata.Returns) == 0 {
+					continue
+				}
+
+				for _, cond := range assert.Conditions {
+					checkConditionFields(f, cond, metadata.Returns, "the assert query")
+				}
+			}
+		}
+	}
+}
+
+// checkConditionFields reports each field path in an expression that the returns of
+// source (the query the condition runs against) can't resolve. A field path is an
+// identifier with any trailing ".ident" accesses; identifiers after a dot, before a
+// '(' (function calls), and expr-lang keywords are skipped.
+func checkConditionFields(f *AnalyzedFile, cond *scaf.Expr, returns []scaf.ReturnInfo, source string) {
+	tokens := cond.ExprTokens
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if !tok.IsIdent() || exprKeywords[*tok.Ident] || (i > 0 && tokens[i-1].IsDot()) {
+			continue
+		}
+
+		path := *tok.Ident
+
+		end := i + 1
+		for end+1 < len(tokens) && tokens[end].IsDot() && tokens[end+1].IsIdent() {
+			path += "." + *tokens[end+1].Ident
+			end += 2
+		}
+
+		if end < len(tokens) && tokens[end].LParen {
+			i = end
+
+			continue
+		}
+
+		i = end - 1
+
+		if returnsField(returns, path) {
+			continue
+		}
+
+		msg := "assert condition references " + path + ", which " + source + " doesn't return"
+		if suggestion := closestName(*tok.Ident, returnColumns(returns)); suggestion != "" {
+			msg += " (did you mean " + suggestion + "?)"
+		}
+
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     tok.Span(),
+			Severity: SeverityWarning,
+			Message:  msg,
+			Code:     "unknown-assert-field",
+			Source:   "scaf",
+		})
+	}
+}
+
+// returnColumns returns the column names a query's result rows are keyed by.
+func returnColumns(returns []scaf.ReturnInfo) []string {
+	columns := make([]string, 0, len(returns))
+
+	for _, ret := range returns {
+		if ret.Alias != "" {
+			columns = append(columns, ret.Alias)
+		} else {
+			columns = append(columns, ret.Expression)
+		}
+	}
+
+	sort.Strings(columns)
+
+	return columns
+}
+
+// ----------------------------------------------------------------------------
+// Rule: union-column-mismatch
+// ----------------------------------------------------------------------------
+
+var unionColumnMismatchRule = &Rule{
+	Name:     "union-column-mismatch",
+	Doc:      "Reports UNION queries whose branches return different columns.",
+	Severity: SeverityError,
+	Run:      checkUnionColumnMismatch,
+}
+
+func checkUnionColumnMismatch(f *AnalyzedFile) {
+	if f.Suite == nil || f.QueryAnalyzer == nil {
+		return // UNION branches are only known with a dialect analyzer
+	}
+
+	for _, query := range f.Suite.Queries {
+		metadata, err := f.QueryAnalyzer.AnalyzeQuery(query.Body)
+		if err != nil || metadata == nil {
+			continue
+		}
+
+		for _, m := range metadata.UnionMismatches {
+			f.Diagnostics = append(f.Diagnostics, Diagnostic{
+				Span:     query.Span(),
+				Severity: SeverityError,
+				Message: "UNION branch " + strconv.Itoa(m.Branch+1) + " of query " + query.Name +
+					" returns (" + strings.Join(m.Columns, ", ") + "), but the first branch returns (" +
+					strings.Join(m.Expected, ", ") + ")",
+				Code:   "union-column-mismatch",
+				Source: "scaf",
+			})
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Rule: missing-required-params
+// ----------------------------------------------------------------------------
+
+var missingRequiredParamsRule = &Rule{
+	Name:     "missing-required-params",
+	Doc:      "Reports tests that don't provide all required query parameters.",
+	Severity: SeverityWarning,
+	Run:      checkMissingRequiredParams,
+}
+
+func checkMissingRequiredParams(f *AnalyzedFile) {
+	if f.Suite == nil {
+		return
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		query, ok := f.Symbols.Queries[scope.QueryName]
+		if !ok {
+			continue // Already reported as undefined-query.
+		}
+
+		// Parameters with a declared default never need to be provided.
+		var required []string
+
+		defaults := query.Node.ParamDefaults()
+		for _, p := range query.Params {
+			if _, ok := defaults[p]; !ok {
+				required = append(required, p)
+			}
+		}
+
+		checkItemMissingParams(f, scope.Items, required, scope.QueryName)
+	}
+}
+
+func checkItemMissingParams(f *AnalyzedFile, items []*scaf.TestOrGroup, queryParams []string, queryName string) {
+	for _, item := range items {
+		for _, test := range item.Tests() {
+			providedParams := make(map[string]bool)
+
+			for _, stmt := range test.Statements {
+				key := stmt.Key()
+				if paramName, ok := strings.CutPrefix(key, "$"); ok {
+					providedParams[paramName] = true
+				}
+			}
+
+			var missing []string
+
+			for _, p := range queryParams {
+				if !providedParams[p] {
+					missing = append(missing, "$"+p)
+				}
+			}
+
+			if len(missing) > 0 {
+				f.Diagnostics = append(f.Diagnostics, Diagnostic{
+					Span:     test.Span(),
+					Severity: SeverityWarning,
+					Message:  "test is missing required parameters for " + queryName + ": " + strings.Join(missing, ", "),
+					Code:     "missing-required-params",
+					Source:   "scaf",
+				})
+			}
+		}
+
+		if item.Group != nil {
+			checkItemMissingParams(f, item.Group.Items, queryParams, queryName)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Rule: param-type-mismatch
+// ----------------------------------------------------------------------------
+
+var paramTypeMismatchRule = &Rule{
+	Name:     "param-type-mismatch",
+	Doc:      "Reports parameter values whose type doesn't match the query's type annotation.",
+	Severity: SeverityError,
+	Run:      checkParamTypeMismatches,
+}
+
+func checkParamTypeMismatches(f *AnalyzedFile) {
+	if f.Suite == nil {
+		return
+	}
+
+	// Resolves a setup call to the annotated query it invokes, if any.
+	callTypes := func(call *scaf.SetupCall) map[string]string {
+		if call.Module == "" {
+			if q, _, _ := ResolveNamespaceQuery(f, call.Query); q != nil && q.Node != nil {
+				return q.Node.ParamTypes
+			}
+
+			return nil
+		}
+
+		imp, ok := f.Symbols.Imports[call.Module]
+		if !ok || f.Resolver == nil {
+			return nil
+		}
+
+		imported := f.Resolver.LoadAndAnalyze(f.Resolver.ResolveImportPath(f.Path, imp.Path))
+		if q, _ := ResolveModuleQuery(f.Resolver, imported, call.Query); q != nil && q.Node != nil {
+			return q.Node.ParamTypes
+		}
+
+		return nil
+	}
+
+	checkParams := func(types map[string]string, params []*scaf.SetupParam) {
+		for _, p := range params {
+			if p.Value != nil {
+				checkParamType(f, types, p.Name, p.Value.Literal)
+			}
+		}
+	}
+
+	checkSetup := func(setup *scaf.SetupClause) {
+		if setup == nil {
+			return
+		}
+
+		if setup.Call != nil {
+			checkParams(callTypes(setup.Call), setup.Call.Params)
+		}
+
+		for _, item := range setup.Block {
+			if item.Call != nil {
+				checkParams(callTypes(item.Call), item.Call.Params)
+			}
+		}
+	}
+
+	var checkItems func(items []*scaf.TestOrGroup, types map[string]string)
+	checkItems = func(items []*scaf.TestOrGroup, types map[string]string) {
+		for _, item := range items {
+			for _, test := range item.Tests() {
+				checkSetup(test.Setup)
+
+				for _, stmt := range test.Statements {
+					if strings.HasPrefix(stmt.Key(), "$") {
+						checkParamType(f, types, stmt.Key(), stmt.Value)
+					}
+				}
+
+				for _, a := range test.Asserts {
+					if a.Query == nil || a.Query.QueryName == nil {
+						continue
+					}
+
+					if q, ok := f.Symbols.Queries[*a.Query.QueryName]; ok && q.Node != nil {
+						checkParams(q.Node.ParamTypes, a.Query.Params)
+					}
+				}
+			}
+
+			if item.Group != nil {
+				checkSetup(item.Group.Setup)
+				checkItems(item.Group.Items, types)
+			}
+		}
+	}
+
+	checkSetup(f.Suite.Setup)
+
+	for _, scope := range f.Suite.Scopes {
+		checkSetup(scope.Setup)
+
+		var types map[string]string
+		if q, ok := f.Symbols.Queries[scope.QueryName]; ok && q.Node != nil {
+			types = q.Node.ParamTypes
+		}
+
+		checkItems(scope.Items, types)
+	}
+}
+
+// checkParamType reports v if it can't be passed as the annotated parameter name.
+func checkParamType(f *AnalyzedFile, types map[string]string, name string, v *scaf.Value) {
+	declared, ok := types[strings.TrimPrefix(name, "$")]
+	if !ok || v == nil {
+		return
+	}
+
+	t, err := ParseTypeString(declared)
+	if err != nil || schemaTypeAccepts(t, v) {
+		return
+	}
+
+	f.Diagnostics = append(f.Diagnostics, Diagnostic{
+		Span:     v.Span(),
+		Severity: SeverityError,
+		Message:  "parameter $" + strings.TrimPrefix(name, "$") + " expects " + declared,
+		Code:     "param-type-mismatch",
+		Source:   "scaf",
+	})
+}
+
+// ----------------------------------------------------------------------------
+// Rule: empty-group
+// ----------------------------------------------------------------------------
+
+var emptyGroupRule = &Rule{
+	Name:     "empty-group",
+	Doc:      "Reports groups with no tests or nested groups.",
+	Severity: SeverityWarning,
+	Run:      checkEmptyGroups,
+}
+
+func checkEmptyGroups(f *AnalyzedFile) {
+	if f.Suite == nil {
+		return
+	}
+
+	var checkItems func([]*scaf.TestOrGroup)
+
+	checkItems = func(items []*scaf.TestOrGroup) {
+		for _, item := range items {
+			if item.Group != nil {
+				if len(item.Group.Items) == 0 {
+					f.Diagnostics = append(f.Diagnostics, Diagnostic{
+						Span:     item.Group.Span(),
+						Severity: SeverityWarning,
+						Message:  "empty group: " + item.Group.Name,
+						Code:     "empty-group",
+						Source:   "scaf",
+					})
+				}
+
+				checkItems(item.Group.Items)
+			}
+		}
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		checkItems(scope.Items)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Rule: misordered-setup
+// ----------------------------------------------------------------------------
+
+var misorderedSetupRule = &Rule{
+	Name:     "misordered-setup",
+	Doc:      "Reports setup and teardown clauses written after a scope's tests.",
+	Severity: SeverityWarning,
+	Run:      checkMisorderedSetup,
+}
+
+func checkMisorderedSetup(f *AnalyzedFile) {
+	if f.Suite == nil {
+		return
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		for _, c := range scope.Trailing {
+			kind := "teardown"
+			if c.Setup != nil {
+				kind = "setup"
+			}
+
+			diag := Diagnostic{
+				Span:     c.Span(),
+				Severity: SeverityWarning,
+				Message:  kind + " should come before the tests in " + scope.QueryName,
+				Code:     "misordered-setup",
+				Source:   "scaf",
+			}
+
+			// A scope keeps only one clause of each kind; later ones never run.
+			if !c.Promoted(scope) {
+				diag.Severity = SeverityError
+				diag.Message = scope.QueryName + " already has a " + kind + "; this one is ignored"
+			}
+
+			f.Diagnostics = append(f.Diagnostics, diag)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Rule: undefined-setup-query
+// ----------------------------------------------------------------------------
+
+var undefinedSetupQueryRule = &Rule{
+	Name:     "undefined-setup-query",
+	Doc:      "Reports setup calls that reference queries not found in the imported module or file namespace.",
+	Severity: SeverityError,
+	Run:      checkUndefinedSetupQueries,
+}
+
+func checkUndefinedSetupQueries(f *AnalyzedFile) {
+	if f.Suite == nil || f.Resolver == nil {
+		return // Cross-file validation requires a resolver
+	}
+
+	// Helper to check a setup call
+	checkSetupCall := func(call *scaf.SetupCall) {
+		if call == nil {
+			return
+		}
+
+		// Unqualified calls resolve against local queries and unaliased imports
+		if call.Module == "" {
+			if q, _, _ := ResolveNamespaceQuery(f, call.Query); q == nil {
+				f.Diagnostics = append(f.Diagnostics, Diagnostic{
+					Span:     call.Span(),
+					Severity: SeverityError,
+					Message:  "undefined query: " + call.Query + " (not defined in this file or any unaliased import)",
+					Code:     "undefined-setup-query",
+					Source:   "scaf",
+				})
+			}
+
+			return
+		}
+
+		// Get the import for this module
+		imp, ok := f.Symbols.Imports[call.Module]
+		if !ok {
+			// undefined-import rule handles this
+			return
+		}
+
+		// Resolve the import path
+		importedPath := f.Resolver.ResolveImportPath(f.Path, imp.Path)
+		importedFile := f.Resolver.LoadAndAnalyze(importedPath)
+		if importedFile == nil || importedFile.Symbols == nil {
+			// Can't load/analyze the file - don't report error since file might just not exist yet
+			return
+		}
+
+		// Check if the query exists in the imported module, directly or re-exported
+		if q, _ := ResolveModuleQuery(f.Resolver, importedFile, call.Query); q == nil {
+			// Build list of available queries for better error message
+			var available []string
+			for name := range ModuleQueries(f.Resolver, importedFile) {
+				available = append(available, name)
+			}
+
+			sort.Strings(available)
+
+			msg := "undefined query in module " + call.Module + ": " + call.Query
+			if len(available) > 0 {
+				msg += " (available: " + strings.Join(available, ", ") + ")"
+			}
+
+			f.Diagnostics = append(f.Diagnostics, Diagnostic{
+				Span:     call.Span(),
+				Severity: SeverityError,
+				Message:  msg,
+				Code:     "undefined-setup-query",
+				Source:   "scaf",
+			})
+		}
+	}
+
+	// Helper to check a setup clause
+	checkSetup := func(setup *scaf.SetupClause) {
+		if setup == nil {
+			return
+		}
+
+		checkSetupCall(setup.Call)
+
+		for _, item := range setup.Block {
+			checkSetupCall(item.Call)
+		}
+	}
+
+	// Check test/group items recursively
+	var checkItems func([]*scaf.TestOrGroup)
+	checkItems = func(items []*scaf.TestOrGroup) {
+		for _, item := range items {
+			for _, test := range item.Tests() {
+				checkSetup(test.Setup)
+			}
+			if item.Group != nil {
+				checkSetup(item.Group.Setup)
+				checkItems(item.Group.Items)
+			}
+		}
+	}
+
+	// Check global setup
+	checkSetup(f.Suite.Setup)
+
+	// Check all scopes
+	for _, scope := range f.Suite.Scopes {
+		checkSetup(scope.Setup)
+		checkItems(scope.Items)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Rule: undefined-export
+// ----------------------------------------------------------------------------
+
+var undefinedExportRule = &Rule{
+	Name:     "undefined-export",
+	Doc:      "Reports re-exported names not defined or re-exported by the module they come from.",
+	Severity: SeverityError,
+	Run:      checkUndefinedExports,
+}
+
+func checkUndefinedExports(f *AnalyzedFile) {
+	if f.Suite == nil || f.Resolver == nil {
+		return // Cross-file validation requires a resolver
+	}
+
+	for _, exp := range f.Symbols.Exports {
+		if exp.From == nil {
+			continue // undefined-import handles "export alias"
+		}
+
+		src := ExportSource(f.Resolver, f, exp)
+		if src == nil {
+			continue // The file might just not exist yet
+		}
+
+		for _, name := range exp.Names {
+			// Seeding the walk with this file reports re-export cycles through it.
+			if q, _ := resolveModuleQuery(f.Resolver, src, name, map[string]bool{f.Path: true}); q == nil {
+				f.Diagnostics = append(f.Diagnostics, Diagnostic{
+					Span:     exp.Span,
+					Severity: SeverityError,
+					Message:  "undefined export: " + name + " is not defined or re-exported by " + *exp.From,
+					Code:     "undefined-export",
+					Source:   "scaf",
+				})
+			}
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Rule: circular-import
+// ----------------------------------------------------------------------------
+
+var circularImportRule = &Rule{
+	Name:     "circular-import",
+	Doc:      "Reports imports and re-exports whose module leads back to this file.",
+	Severity: SeverityError,
+	Run:      checkCircularImports,
+}
+
+func checkCircularImports(f *AnalyzedFile) {
+	if f.Suite == nil || f.Resolver == nil {
+		return // Cross-file validation requires a resolver
+	}
+
+	for _, dep := range moduleDependencies(f.Suite) {
+		depPath := f.Resolver.ResolveImportPath(f.Path, dep.path)
+
+		// Each dependency is searched on its own, so a module reached along two
+		// paths (a diamond) is only visited once and never mistaken for a cycle.
+		cycle := importCycle(f.Resolver, f.Path, depPath, make(map[string]bool))
+		if cycle == nil {
+			continue
+		}
+
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     dep.node.Span(),
+			Severity: SeverityError,
+			Message:  "circular import: " + strings.Join(append([]string{f.Path}, cycle...), " -> "),
+			Code:     "circular-import",
+			Source:   "scaf",
+		})
+	}
+}
+
+// moduleDependency is an import or re-export naming another module by path.
+type moduleDependency struct {
+	path string
+	node interface{ Span() scaf.Span }
+}
+
+// moduleDependencies returns the modules a suite imports or re-exports from,
+// in source order.
+func moduleDependencies(suite *scaf.Suite) []moduleDependency {
+	var deps []moduleDependency
+
+	for _, imp := range suite.Imports {
+		deps = append(deps, moduleDependency{path: imp.Path, node: imp})
+	}
+
+	for _, exp := range suite.Exports {
+		if exp.From != nil {
+			deps = append(deps, moduleDependency{path: *exp.From, node: exp})
+		}
+	}
+
+	return deps
+}
+
+// importCycle returns the chain of modules from path back to target, ending
+// with target, or nil if none of path's dependencies lead there.
+func importCycle(r CrossFileResolver, target, path string, seen map[string]bool) []string {
+	if path == target {
+		return []string{target}
+	}
+
+	if seen[path] {
+		return nil
+	}
+
+	seen[path] = true
+
+	file := r.LoadAndAnalyze(path)
+	if file == nil || file.Suite == nil {
+		return nil
+	}
+
+	for _, dep := range moduleDependencies(file.Suite) {
+		if cycle := importCycle(r, target, r.ResolveImportPath(path, dep.path), seen); cycle != nil {
+			return append([]string{path}, cycle...)
+		}
+	}
+
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Rule: undefined-binding
+// ----------------------------------------------------------------------------
+
+var undefinedBindingRule = &Rule{
+	Name:     "undefined-binding",
+	Doc:      "Reports computed values that reference setup bindings not in scope.",
+	Severity: SeverityError,
+	Run:      checkUndefinedBindings,
+}
+
+func checkUndefinedBindings(f *AnalyzedFile) {
+	if f.Suite == nil {
+		return
+	}
+
+	// Profiles are chosen at run time, so their bindings count as in scope everywhere.
+	suite := addBindings(nil, f.Suite.Setup)
+	for _, p := range f.Suite.Profiles {
+		suite = addBindings(suite, p.Setup)
+	}
+
+	var checkItems func(items []*scaf.TestOrGroup, scope map[string]bool)
+
+	checkItems = func(items []*scaf.TestOrGroup, scope map[string]bool) {
+		for _, item := range items {
+			if item.Group != nil {
+				checkItems(item.Group.Items, addBindings(scope, item.Group.Setup))
+
+				continue
+			}
+
+			for _, test := range item.Tests() {
+				inTest := addBindings(scope, test.Setup)
+
+				for _, stmt := range test.Statements {
+					if stmt.Value == nil || stmt.Value.Computed == nil {
+						continue
+					}
+
+					for _, ref := range stmt.Value.Computed.Refs() {
+						if inTest[ref.Parts[0]] {
+							continue
+						}
+
+						f.Diagnostics = append(f.Diagnostics, Diagnostic{
+							Span:     ref.Span(),
+							Severity: SeverityError,
+							Message:  "undefined binding: " + ref.Parts[0],
+							Code:     "undefined-binding",
+							Source:   "scaf",
+						})
+					}
+				}
+			}
+		}
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		checkItems(scope.Items, addBindings(suite, scope.Setup))
+	}
+}
+
+// addBindings returns a copy of scope extended with the names bound by setup.
+func addBindings(scope map[string]bool, setup *scaf.SetupClause) map[string]bool {
+	out := make(map[string]bool, len(scope))
+	for name := range scope {
+		out[name] = true
+	}
+
+	if setup == nil {
+		return out
+	}
+
+	if setup.Binding != nil {
+		out[*setup.Binding] = true
+	}
+
+	for _, item := range setup.Block {
+		if item.Binding != nil {
+			out[*item.Binding] = true
+		}
+	}
+
+	return out
+}
+
+// ----------------------------------------------------------------------------
+// Rule: empty-assert
+// ----------------------------------------------------------------------------
+
+var emptyAssertRule = &Rule{
+	Name:     "empty-assert",
+	Doc:      "Reports asserts with no conditions whose query doesn't write, so nothing is checked.",
+	Severity: SeverityHint,
+	Run:      checkEmptyAsserts,
+	OptIn:    true,
+}
+
+func checkEmptyAsserts(f *AnalyzedFile) {
+	if f.Suite == nil {
+		return
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		checkItemEmptyAsserts(f, scope.Items)
+	}
+}
+
+func checkItemEmptyAsserts(f *AnalyzedFile, items []*scaf.TestOrGroup) {
+	for _, item := range items {
+		if item.Group != nil && !item.Group.HasDirective(scaf.DirectiveRunOnly) {
+			checkItemEmptyAsserts(f, item.Group.Items)
+		}
+
+		for _, test := range item.Tests() {
+			if test.HasDirective(scaf.DirectiveRunOnly) {
+				continue
+			}
+
+			for _, assert := range test.Asserts {
+				if assert.Idempotent || len(assert.Conditions) > 0 || assertWrites(f, assert.Query) {
+					continue
+				}
+
+				f.Diagnostics = append(f.Diagnostics, Diagnostic{
+					Span:     assert.Span(),
+					Severity: SeverityHint,
+					Message: "assert checks nothing: add a condition, remove it, " +
+						"or mark the test // scaf:" + scaf.DirectiveRunOnly,
+					Code:   "empty-assert",
+					Source: "scaf",
+				})
+			}
+		}
+	}
+}
+
+// assertWrites reports whether an assert's query is known to modify data,
+// in which case running it is the assert's effect.
+func assertWrites(f *AnalyzedFile, q *scaf.AssertQuery) bool {
+	if q == nil {
+		return false
+	}
+
+	var body string
+
+	switch {
+	case q.Inline != nil:
+		body = *q.Inline
+	case q.QueryName != nil:
+		sym, ok := f.Symbols.Queries[*q.QueryName]
+		if !ok {
+			return false
+		}
+
+		body = sym.Body
+	}
+
+	return queryWrites(f, body)
+}
+
+// queryWrites reports whether the dialect classifies a query body as a write.
+func queryWrites(f *AnalyzedFile, body string) bool {
+	if f.QueryAnalyzer == nil {
+		return false
+	}
+
+	metadata, err := f.QueryAnalyzer.AnalyzeQuery(body)
+
+	return err == nil && metadata != nil && metadata.Writes
+}
+
+// ----------------------------------------------------------------------------
+// Rule: write-query-without-isolation-note
+// ----------------------------------------------------------------------------
+
+var writeQueryWithoutIsolationRule = &Rule{
+	Name: "write-query-without-isolation-note",
+	Doc: "Reports scopes whose query writes and whose tests expect outputs with no teardown " +
+		"to reset state, so a second run against a non-isolated database can see different data.",
+	Severity: SeverityHint,
+	Run:      checkWriteQueryWithoutIsolation,
+	OptIn:    true,
+}
+
+func checkWriteQueryWithoutIsolation(f *AnalyzedFile) {
+	if f.Suite == nil || f.Suite.Teardown != nil || f.QueryAnalyzer == nil {
+		return
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		if scope.Teardown != nil || !hasUnisolatedExpectations(scope.Items) {
+			continue
+		}
+
+		sym, ok := f.Symbols.Queries[scope.QueryName]
+		if !ok || !queryWrites(f, sym.Body) {
+			continue
+		}
+
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     nameSpan(scope.Tokens, scaf.TokenIdent, scope.Span()),
+			Severity: SeverityHint,
+			Message: "query " + scope.QueryName + " writes but nothing resets state between runs: " +
+				"run tests in per-test transactions (don't use --keep-state) or add a teardown",
+			Code:   "write-query-without-isolation-note",
+			Source: "scaf",
+		})
+	}
+}
+
+// hasUnisolatedExpectations reports whether any test under items expects
+// output values without a group teardown to clean up after it. Tests that
+// assert idempotent already account for a second run.
+func hasUnisolatedExpectations(items []*scaf.TestOrGroup) bool {
+	for _, item := range items {
+		if item.Group != nil && item.Group.Teardown == nil && hasUnisolatedExpectations(item.Group.Items) {
+			return true
+		}
+
+		for _, test := range item.Tests() {
+			if expectsOutputs(test) && !slices.ContainsFunc(test.Asserts, func(a *scaf.Assert) bool { return a.Idempotent }) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// expectsOutputs reports whether a test has a statement other than a $param input.
+func expectsOutputs(test *scaf.Test) bool {
+	return slices.ContainsFunc(test.Statements, func(s *scaf.Statement) bool {
+		return !strings.HasPrefix(s.Key(), "$")
+	})
+}
+
+// ----------------------------------------------------------------------------
+// Rule: empty-file
+// ----------------------------------------------------------------------------
+
+var emptyFileRule = &Rule{
+	Name:     "empty-file",
+	Doc:      "Reports files that declare nothing (empty or comment-only).",
+	Severity: SeverityHint,
+	Run:      checkEmptyFile,
+	OptIn:    true,
+}
+
+func checkEmptyFile(f *AnalyzedFile) {
+	s := f.Suite
+	if s == nil {
+		return
+	}
+
+	if len(s.Imports) > 0 || len(s.Exports) > 0 || len(s.Queries) > 0 || s.Setup != nil ||
+		s.Teardown != nil || len(s.Profiles) > 0 || len(s.Scopes) > 0 {
+		return
+	}
+
+	f.Diagnostics = append(f.Diagnostics, Diagnostic{
+		Span:     s.Span(),
+		Severity: SeverityHint,
+		Message:  "file is empty: it declares no queries or tests",
+		Code:     "empty-file",
+		Source:   "scaf",
+	})
+}
+
+// ----------------------------------------------------------------------------
+// Rule: report-recovered
+// ----------------------------------------------------------------------------
+
+// reportRecoveredRule only has work to do when the file failed to parse, so the
+// analyzer runs it against the recovery parse rather than with the other rules.
+var reportRecoveredRule = &Rule{
+	Name:     "report-recovered",
+	Doc:      "Reports constructs the recovering parser patched or skipped, where the editor guessed.",
+	Severity: SeverityHint,
+	Run:      checkRecovered,
+	OptIn:    true,
+}
+
+func checkRecovered(f *AnalyzedFile) {
+	if f.RecoverySuite == nil {
+		return
+	}
+
+	report := func(span scaf.Span, msg string) {
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     span,
+			Severity: SeverityHint,
+			Message:  msg,
+			Code:     "report-recovered",
+			Source:   "scaf",
+		})
+	}
+
+	for _, m := range recoveredNodes(f.RecoverySuite) {
+		msg := "recovered from a parse error here"
+		if text := m.RecoveredText(); text != "" {
+			msg += ": skipped " + strconv.Quote(text)
+		}
+
+		report(scaf.Span{Start: m.RecoveredSpan, End: m.RecoveredEnd}, msg)
+	}
+
+	// Constructs recovery dropped entirely (e.g. an empty setup) leave no
+	// node behind, only the error recovery resumed from.
+	var recoveryErr *participle.RecoveryError
+	if errors.As(f.RecoveryError, &recoveryErr) {
+		for _, err := range recoveryErr.Errors {
+			d := singleErrorToDiagnostic(err)
+			report(d.Span, "recovered from a parse error here: the preceding construct was dropped")
+		}
+	}
+}
+
+// recoveredNodes returns the recovery metadata of the suite's nodes that the
+// parser recovered, in source order.
+func recoveredNodes(suite *scaf.Suite) []*scaf.RecoveryMeta {
+	var nodes []*scaf.RecoveryMeta
+
+	add := func(m *scaf.RecoveryMeta) {
+		if m.WasRecovered() {
+			nodes = append(nodes, m)
+		}
+	}
+
+	addSetup := func(setup *scaf.SetupClause) {
+		if setup != nil {
+			add(&setup.RecoveryMeta)
+		}
+	}
+
+	var addItems func(items []*scaf.TestOrGroup)
+	addItems = func(items []*scaf.TestOrGroup) {
+		for _, item := range items {
+			add(&item.RecoveryMeta)
+
+			if q := item.Sequence; q != nil {
+				add(&q.RecoveryMeta)
+			}
+
+			for _, t := range item.Tests() {
+				add(&t.RecoveryMeta)
+				addSetup(t.Setup)
+
+				for _, stmt := range t.Statements {
+					add(&stmt.RecoveryMeta)
+				}
+
+				for _, a := range t.Asserts {
+					add(&a.RecoveryMeta)
+				}
+			}
+
+			if g := item.Group; g != nil {
+				add(&g.RecoveryMeta)
+				addSetup(g.Setup)
+				addItems(g.Items)
+			}
+		}
+	}
+
+	add(&suite.RecoveryMeta)
+
+	for _, imp := range suite.Imports {
+		add(&imp.RecoveryMeta)
+	}
+
+	for _, q := range suite.Queries {
+		add(&q.RecoveryMeta)
+	}
+
+	addSetup(suite.Setup)
+
+	for _, p := range suite.Profiles {
+		add(&p.RecoveryMeta)
+		addSetup(p.Setup)
+	}
+
+	for _, scope := range suite.Scopes {
+		add(&scope.RecoveryMeta)
+		addSetup(scope.Setup)
+		addItems(scope.Items)
+	}
+
+	return nodes
+}
+
+// ----------------------------------------------------------------------------
+// Rule: unused-query-param
+// ----------------------------------------------------------------------------
+
+var unusedQueryParamRule = &Rule{
+	Name:     "unused-query-param",
+	Doc:      "Reports query parameters that are never provided in any test.",
+	Severity: SeverityHint,
+	Run:      checkUnusedQueryParams,
+}
+
+func checkUnusedQueryParams(f *AnalyzedFile) {
+	if f.Suite == nil {
+		return
+	}
+
+	for _, scope := range f.Suite.Scopes {
+		query, ok := f.Symbols.Queries[scope.QueryName]
+		if !ok {
+			continue // Already reported as undefined-query.
+		}
+
+		if len(query.Params) == 0 {
+			continue // No parameters to check.
+		}
+
+		// Collect all parameters provided across all tests in this scope.
+		providedParams := make(map[string]bool)
+		collectProvidedParams(scope.Items, providedParams)
+
+		// Parameters with a declared default are used even when no test provides them.
+		for name := range query.Node.ParamDefaults() {
+			providedParams[name] = true
+		}
+
+		// Report parameters that exist in query but never appear in any test.
+		for _, param := range query.Params {
+			if !providedParams[param] {
+				f.Diagnostics = append(f.Diagnostics, Diagnostic{
+					Span:     scope.Span(),
+					Severity: SeverityHint,
+					Message:  "query parameter $" + param + " is never provided in any test within this scope",
+					Code:     "unused-query-param",
+					Source:   "scaf",
+				})
+			}
+		}
+	}
+}
+
+func collectProvidedParams(items []*scaf.TestOrGroup, provided map[string]bool) {
+	for _, item := range items {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
+				key := stmt.Key()
+				if paramName, ok := strings.CutPrefix(key, "$"); ok {
+					provided[paramName] = true
+				}
+			}
+		}
+
+		if item.Group != nil {
+			collectProvidedParams(item.Group.Items, provided)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Rule: unused-query
+// ----------------------------------------------------------------------------
+
+var unusedQueryRule = &Rule{
+	Name:     "unused-query",
+	Doc:      "Reports queries that no scope, setup call, assert, or re-export in the project refers to, unless marked // scaf:export.",
+	Severity: SeverityHint,
+	Run:      checkUnusedQueries,
+	OptIn:    true,
+}
+
+func checkUnusedQueries(f *AnalyzedFile) {
+	// A file without scopes is a fixtures module: its queries are there for
+	// other files to call.
+	if f.Suite == nil || len(f.Suite.Scopes) == 0 {
+		return
+	}
+
+	used := make(map[string]bool)
+	collectQueryRefs(f.Suite, "", used)
+
+	// Other files use a query through an import of this one. Without a
+	// project to search, only this file's references count.
+	if project, ok := f.Resolver.(ProjectResolver); ok {
+		for _, path := range project.ProjectFiles() {
+			if path == f.Path {
+				continue
+			}
+
+			if collectImporterRefs(project, f.Path, project.LoadAndAnalyze(path), used) {
+				return // Every query is re-exported.
+			}
+		}
+	}
+
+	for _, q := range f.Suite.Queries {
+		if used[q.Name] || q.HasDirective(scaf.DirectiveExport) {
+			continue
+		}
+
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     nameSpan(q.Tokens, scaf.TokenIdent, q.Span()),
+			Severity: SeverityHint,
+			Message:  "unused query: " + q.Name + " has no scope and no setup call, assert, or re-export refers to it",
+			Code:     "unused-query",
+			Source:   "scaf",
+		})
+	}
+}
+
+// collectImporterRefs adds the queries of the file at path that importer refers
+// to through its imports of that file: setup calls qualified by the import
+// (or unqualified, for an unaliased import) and re-exports. It reports whether
+// importer re-exports the whole module.
+func collectImporterRefs(r CrossFileResolver, path string, importer *AnalyzedFile, used map[string]bool) bool {
+	if importer == nil || importer.Suite == nil {
+		return false
+	}
+
+	for name, imp := range importer.Symbols.Imports {
+		if r.ResolveImportPath(importer.Path, imp.Path) != path {
+			continue
+		}
+
+		collectQueryRefs(importer.Suite, name, used)
+
+		if imp.Alias == nil {
+			collectQueryRefs(importer.Suite, "", used)
+		}
+	}
+
+	for _, exp := range importer.Symbols.Exports {
+		switch {
+		case exp.Module != nil:
+			if imp, ok := importer.Symbols.Imports[*exp.Module]; ok && r.ResolveImportPath(importer.Path, imp.Path) == path {
+				return true
+			}
+		case exp.From != nil && r.ResolveImportPath(importer.Path, *exp.From) == path:
+			for _, name := range exp.Names {
+				used[name] = true
+			}
+		}
+	}
+
+	return false
+}
+
+// collectQueryRefs adds the queries suite's setup calls qualified by module
+// refer to. With an empty module it adds the unqualified calls, and the
+// queries of the suite's scopes and named asserts.
+func collectQueryRefs(suite *scaf.Suite, module string, used map[string]bool) {
+	addSetup := func(setup *scaf.SetupClause) {
+		if setup == nil {
+			return
+		}
+
+		calls := []*scaf.SetupCall{setup.Call}
+		for _, item := range setup.Block {
+			calls = append(calls, item.Call)
+		}
+
+		for _, call := range calls {
+			if call != nil && call.Module == module {
+				used[call.Query] = true
+			}
+		}
+	}
+
+	var addItems func([]*scaf.TestOrGroup)
+
+	addItems = func(items []*scaf.TestOrGroup) {
+		for _, item := range items {
+			for _, test := range item.Tests() {
+				addSetup(test.Setup)
+
+				for _, assert := range test.Asserts {
+					if module == "" && assert.Query != nil && assert.Query.QueryName != nil {
+						used[*assert.Query.QueryName] = true
+					}
+				}
+			}
+
+			if item.Group != nil {
+				addSetup(item.Group.Setup)
+				addItems(item.Group.Items)
+			}
+		}
+	}
+
+	addSetup(suite.Setup)
+
+	for _, profile := range suite.Profiles {
+		addSetup(profile.Setup)
+	}
+
+	for _, scope := range suite.Scopes {
+		if module == "" {
+			used[scope.QueryName] = true
+		}
+
+		addSetup(scope.Setup)
+
+		for _, c := range scope.Trailing {
+			addSetup(c.Setup)
+		}
+
+		addItems(scope.Items)
 	}
 }
 
 // ----------------------------------------------------------------------------
-// Rule: undefined-setup-query
+// Rule: unknown-label
 // ----------------------------------------------------------------------------
 
-var undefinedSetupQueryRule = &Rule{
-	Name:     "undefined-setup-query",
-	Doc:      "Reports setup calls that reference queries not found in the imported module.",
-	Severity: SeverityError,
-	Run:      checkUndefinedSetupQueries,
+var unknownLabelRule = &Rule{
+	Name:     "unknown-label",
+	Doc:      "Reports data tables whose label is not a model in the type schema.",
+	Severity: SeverityWarning,
+	Run:      checkUnknownLabels,
 }
 
-func checkUndefinedSetupQueries(f *AnalyzedFile) {
-	if f.Suite == nil || f.Resolver == nil {
-		return // Cross-file validation requires a resolver
+func checkUnknownLabels(f *AnalyzedFile) {
+	if f.Suite == nil || f.Schema == nil {
+		return // Requires a type schema
 	}
 
-	// Helper to check a setup call
-	checkSetupCall := func(call *scaf.SetupCall) {
-		if call == nil {
-			return
-		}
+	models := make([]string, 0, len(f.Schema.Models))
+	for name := range f.Schema.Models {
+		models = append(models, name)
+	}
 
-		// Get the import for this module
-		imp, ok := f.Symbols.Imports[call.Module]
-		if !ok {
-			// undefined-import rule handles this
-			return
+	sort.Strings(models)
+
+	for _, table := range dataTables(f.Suite) {
+		if _, ok := f.Schema.Models[table.Label]; ok {
+			continue
 		}
 
-		// Resolve the import path
-		importedPath := f.Resolver.ResolveImportPath(f.Path, imp.Path)
-		importedFile := f.Resolver.LoadAndAnalyze(importedPath)
-		if importedFile == nil || importedFile.Symbols == nil {
-			// Can't load/analyze the file - don't report error since file might just not exist yet
-			return
+		msg := "unknown label: " + table.Label + " is not a model in the schema"
+		if suggestion := closestName(table.Label, models); suggestion != "" {
+			msg += " (did you mean " + suggestion + "?)"
 		}
 
-		// Check if the query exists in the imported module
-		if _, ok := importedFile.Symbols.Queries[call.Query]; !ok {
-			// Build list of available queries for better error message
-			var available []string
-			for name := range importedFile.Symbols.Queries {
-				available = append(available, name)
-			}
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     table.Span(),
+			Severity: SeverityWarning,
+			Message:  msg,
+			Code:     "unknown-label",
+			Source:   "scaf",
+		})
+	}
+}
 
-			msg := "undefined query in module " + call.Module + ": " + call.Query
-			if len(available) > 0 {
-				msg += " (available: " + strings.Join(available, ", ") + ")"
+// ----------------------------------------------------------------------------
+// Rule: unknown-property
+// ----------------------------------------------------------------------------
+
+var unknownPropertyRule = &Rule{
+	Name:     "unknown-property",
+	Doc:      "Reports data table columns that are not fields of the table's model.",
+	Severity: SeverityWarning,
+	Run:      checkUnknownProperties,
+}
+
+func checkUnknownProperties(f *AnalyzedFile) {
+	if f.Suite == nil || f.Schema == nil {
+		return // Requires a type schema
+	}
+
+	for _, table := range dataTables(f.Suite) {
+		model, ok := f.Schema.Models[table.Label]
+		if !ok {
+			continue // Reported as unknown-label.
+		}
+
+		for _, column := range table.Columns {
+			if model.Field(column) != nil {
+				continue
 			}
 
 			f.Diagnostics = append(f.Diagnostics, Diagnostic{
-				Span:     call.Span(),
-				Severity: SeverityError,
-				Message:  msg,
-				Code:     "undefined-setup-query",
+				Span:     table.Span(),
+				Severity: SeverityWarning,
+				Message:  "unknown property: " + model.Name + " has no field " + column,
+				Code:     "unknown-property",
 				Source:   "scaf",
 			})
 		}
 	}
+}
 
-	// Helper to check a setup clause
-	checkSetup := func(setup *scaf.SetupClause) {
-		if setup == nil {
-			return
-		}
+// ----------------------------------------------------------------------------
+// Rule: type-mismatch
+// ----------------------------------------------------------------------------
 
-		checkSetupCall(setup.Call)
+var typeMismatchRule = &Rule{
+	Name:     "type-mismatch",
+	Doc:      "Reports data table cells and expected values whose type doesn't match the schema field.",
+	Severity: SeverityError,
+	Run:      checkTypeMismatches,
+}
 
-		for _, item := range setup.Block {
-			checkSetupCall(item.Call)
+func checkTypeMismatches(f *AnalyzedFile) {
+	for _, sv := range schemaValues(f) {
+		if schemaTypeAccepts(sv.field.Type, sv.value) || numberForString(sv) {
+			continue
 		}
+
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     sv.value.Span(),
+			Severity: SeverityError,
+			Message:  "type mismatch: " + sv.model + "." + sv.field.Name + " is " + sv.field.Type.String(),
+			Code:     "type-mismatch",
+			Source:   "scaf",
+		})
 	}
+}
 
-	// Check test/group items recursively
-	var checkItems func([]*scaf.TestOrGroup)
-	checkItems = func(items []*scaf.TestOrGroup) {
-		for _, item := range items {
-			if item.Test != nil {
-				checkSetup(item.Test.Setup)
-			}
-			if item.Group != nil {
-				checkSetup(item.Group.Setup)
-				checkItems(item.Group.Items)
+// schemaTypeAccepts reports whether v can be stored in a field of type t.
+// Named types (time.Time, UUIDs, ...) and nulls are accepted, since their
+// literal forms vary by driver.
+func schemaTypeAccepts(t *Type, v *scaf.Value) bool {
+	if t == nil || v.Null || v.Absent || v.Computed != nil {
+		return true
+	}
+
+	switch t.Kind {
+	case TypeKindPointer:
+		return schemaTypeAccepts(t.Elem, v)
+	case TypeKindPrimitive:
+		switch t.Name {
+		case "string":
+			return v.Str != nil
+		case "bool":
+			return v.Boolean != nil
+		case "float", "float32", "float64":
+			return v.Number != nil
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+			return v.Number != nil && *v.Number == math.Trunc(*v.Number)
+		}
+
+		return true
+	case TypeKindSlice, TypeKindArray:
+		if v.Bytes != nil {
+			return t.Elem != nil && (t.Elem.Name == "byte" || t.Elem.Name == "uint8")
+		}
+
+		if v.List == nil {
+			return false
+		}
+
+		for _, item := range v.List.Values {
+			if !schemaTypeAccepts(t.Elem, item) {
+				return false
 			}
 		}
+
+		return true
+	case TypeKindMap:
+		return v.Map != nil
+	default:
+		return true
 	}
+}
 
-	// Check global setup
-	checkSetup(f.Suite.Setup)
+// ----------------------------------------------------------------------------
+// Rule: number-for-string-field
+// ----------------------------------------------------------------------------
 
-	// Check all scopes
-	for _, scope := range f.Suite.Scopes {
-		checkSetup(scope.Setup)
-		checkItems(scope.Items)
+var numberForStringFieldRule = &Rule{
+	Name:     "number-for-string-field",
+	Doc:      "Reports number literals written for string fields, which are bound as numbers and never match.",
+	Severity: SeverityError,
+	Run:      checkNumbersForStringFields,
+}
+
+func checkNumbersForStringFields(f *AnalyzedFile) {
+	for _, sv := range schemaValues(f) {
+		if !numberForString(sv) {
+			continue
+		}
+
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     sv.value.Span(),
+			Severity: SeverityError,
+			Message: "number for string field: " + sv.model + "." + sv.field.Name +
+				" is a string, so this number never matches; quote it",
+			Code:   "number-for-string-field",
+			Source: "scaf",
+		})
+	}
+}
+
+// numberForString reports whether a number literal targets a string field.
+// Such values get their own diagnostic rather than a type-mismatch.
+func numberForString(sv schemaValue) bool {
+	if sv.value.Number == nil {
+		return false
+	}
+
+	t := sv.field.Type
+	for t != nil && t.Kind == TypeKindPointer {
+		t = t.Elem
 	}
+
+	return t != nil && t.Kind == TypeKindPrimitive && t.Name == "string"
 }
 
 // ----------------------------------------------------------------------------
-// Rule: unused-query-param
+// Rule: enum-violation
 // ----------------------------------------------------------------------------
 
-var unusedQueryParamRule = &Rule{
-	Name:     "unused-query-param",
-	Doc:      "Reports query parameters that are never provided in any test.",
-	Severity: SeverityHint,
-	Run:      checkUnusedQueryParams,
+var enumViolationRule = &Rule{
+	Name:     "enum-violation",
+	Doc:      "Reports data table cells and expected values outside a schema field's enum.",
+	Severity: SeverityError,
+	Run:      checkEnumViolations,
 }
 
-func checkUnusedQueryParams(f *AnalyzedFile) {
-	if f.Suite == nil {
-		return
+func checkEnumViolations(f *AnalyzedFile) {
+	for _, sv := range schemaValues(f) {
+		if len(sv.field.Enum) == 0 {
+			continue
+		}
+
+		var literal string
+
+		switch {
+		case sv.value.Str != nil:
+			literal = *sv.value.Str
+		case sv.value.Number != nil:
+			literal = strconv.FormatFloat(*sv.value.Number, 'f', -1, 64)
+		default:
+			continue
+		}
+
+		if slices.Contains(sv.field.Enum, literal) {
+			continue
+		}
+
+		f.Diagnostics = append(f.Diagnostics, Diagnostic{
+			Span:     sv.value.Span(),
+			Severity: SeverityError,
+			Message: "enum violation: " + sv.model + "." + sv.field.Name + " must be one of " +
+				strings.Join(sv.field.Enum, ", "),
+			Code:   "enum-violation",
+			Source: "scaf",
+		})
 	}
+}
 
-	for _, scope := range f.Suite.Scopes {
-		query, ok := f.Symbols.Queries[scope.QueryName]
+// schemaValue is a literal destined for a schema field.
+type schemaValue struct {
+	model string
+	field *Field
+	value *scaf.Value
+}
+
+// schemaValues returns the literals the schema constrains: data table cells,
+// and expected values whose key resolves to a single model's field.
+func schemaValues(f *AnalyzedFile) []schemaValue {
+	if f.Suite == nil || f.Schema == nil {
+		return nil
+	}
+
+	var values []schemaValue
+
+	for _, table := range dataTables(f.Suite) {
+		model, ok := f.Schema.Models[table.Label]
 		if !ok {
-			continue // Already reported as undefined-query.
+			continue
 		}
 
-		if len(query.Params) == 0 {
-			continue // No parameters to check.
+		for _, row := range table.Rows {
+			for i, v := range row.Values {
+				if i >= len(table.Columns) {
+					break
+				}
+
+				if field := model.Field(table.Columns[i]); field != nil {
+					values = append(values, schemaValue{model: model.Name, field: field, value: v})
+				}
+			}
 		}
+	}
 
-		// Collect all parameters provided across all tests in this scope.
-		providedParams := make(map[string]bool)
-		collectProvidedParams(scope.Items, providedParams)
+	var walk func(items []*scaf.TestOrGroup, body string)
 
-		// Report parameters that exist in query but never appear in any test.
-		for _, param := range query.Params {
-			if !providedParams[param] {
-				f.Diagnostics = append(f.Diagnostics, Diagnostic{
-					Span:     scope.Span(),
-					Severity: SeverityHint,
-					Message:  "query parameter $" + param + " is never provided in any test within this scope",
-					Code:     "unused-query-param",
-					Source:   "scaf",
-				})
+	walk = func(items []*scaf.TestOrGroup, body string) {
+		for _, item := range items {
+			if item.Group != nil {
+				walk(item.Group.Items, body)
+			}
+
+			for _, test := range item.Tests() {
+				for _, stmt := range test.Statements {
+					key := stmt.Key()
+					if stmt.Value == nil || strings.HasPrefix(key, "$") {
+						continue // Parameters aren't tied to a model.
+					}
+
+					if model, field := schemaFieldForKey(f, body, key); field != nil {
+						values = append(values, schemaValue{model: model, field: field, value: stmt.Value})
+					}
+				}
 			}
 		}
 	}
+
+	for _, scope := range f.Suite.Scopes {
+		if q, ok := f.Symbols.Queries[scope.QueryName]; ok {
+			walk(scope.Items, q.Body)
+		}
+	}
+
+	return values
 }
 
-func collectProvidedParams(items []*scaf.TestOrGroup, provided map[string]bool) {
-	for _, item := range items {
-		if item.Test != nil {
-			for _, stmt := range item.Test.Statements {
-				key := stmt.Key()
-				if paramName, ok := strings.CutPrefix(key, "$"); ok {
-					provided[paramName] = true
+// schemaFieldForKey resolves an expected value's key to a schema field. The key
+// is mapped to its return expression (e.g., alias "s" -> "r.sentiment") and the
+// property looked up on the models labelled in the query body. Keys matching
+// fields of several models are ambiguous and resolve to nothing.
+func schemaFieldForKey(f *AnalyzedFile, body, key string) (string, *Field) {
+	expr := key
+
+	if f.QueryAnalyzer != nil {
+		if metadata, err := f.QueryAnalyzer.AnalyzeQuery(body); err == nil {
+			for _, ret := range metadata.Returns {
+				if ret.Alias == key || ret.Name == key {
+					expr = ret.Expression
+
+					break
 				}
 			}
 		}
+	}
 
-		if item.Group != nil {
-			collectProvidedParams(item.Group.Items, provided)
+	prop := expr
+	if dot := strings.LastIndex(expr, "."); dot >= 0 {
+		prop = expr[dot+1:]
+	}
+
+	var (
+		model string
+		found *Field
+	)
+
+	for name, m := range f.Schema.Models {
+		if !strings.Contains(body, ":"+name) {
+			continue
+		}
+
+		if field := m.Field(prop); field != nil {
+			if found != nil {
+				return "", nil
+			}
+
+			model, found = name, field
+		}
+	}
+
+	return model, found
+}
+
+// dataTables returns every data table in the suite's setup blocks.
+func dataTables(suite *scaf.Suite) []*scaf.DataTable {
+	var tables []*scaf.DataTable
+
+	addSetup := func(setup *scaf.SetupClause) {
+		if setup == nil {
+			return
+		}
+
+		for _, item := range setup.Block {
+			if item.Data != nil {
+				tables = append(tables, item.Data)
+			}
+		}
+	}
+
+	var walk func(items []*scaf.TestOrGroup)
+
+	walk = func(items []*scaf.TestOrGroup) {
+		for _, item := range items {
+			if item.Group != nil {
+				addSetup(item.Group.Setup)
+				walk(item.Group.Items)
+			}
+
+			for _, test := range item.Tests() {
+				addSetup(test.Setup)
+			}
 		}
 	}
+
+	addSetup(suite.Setup)
+
+	for _, p := range suite.Profiles {
+		addSetup(p.Setup)
+	}
+
+	for _, scope := range suite.Scopes {
+		addSetup(scope.Setup)
+		walk(scope.Items)
+	}
+
+	return tables
 }
 
 // ----------------------------------------------------------------------------
@@ -773,4 +2812,27 @@ func collectProvidedParams(items []*scaf.TestOrGroup, provided map[string]bool)
 
 func formatLine(span scaf.Span) string {
 	return strconv.Itoa(span.Start.Line)
-}
\ No newline at end of file
+}
+
+// nameSpan returns the span of the first token of type typ, such as a query's
+// name or a test's quoted name, or fallback if the node has no such token.
+func nameSpan(tokens []lexer.Token, typ lexer.TokenType, fallback scaf.Span) scaf.Span {
+	for i, tok := range tokens {
+		if tok.Type != typ {
+			continue
+		}
+
+		// Token values are unquoted, so the next token marks the end
+		if i+1 < len(tokens) {
+			return scaf.Span{Start: tok.Pos, End: tokens[i+1].Pos}
+		}
+
+		end := tok.Pos
+		end.Offset += len(tok.Value)
+		end.Column += len(tok.Value)
+
+		return scaf.Span{Start: tok.Pos, End: end}
+	}
+
+	return fallback
+}
@@ -1,9 +1,15 @@
 package analysis_test
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/rlch/scaf"
 	"github.com/rlch/scaf/analysis"
+	"github.com/rlch/scaf/dialects/cypher"
 )
 
 func TestRule_UndefinedQuery(t *testing.T) {
@@ -20,6 +26,72 @@ UndefinedQuery {
 	assertHasDiagnostic(t, result, "undefined-query")
 }
 
+func TestRule_UndefinedQuery_Suggestion(t *testing.T) {
+	t.Parallel()
+
+	result := analyze(t, `
+query GetUser `+"`Q`"+`
+query DeletePost `+"`Q`"+`
+
+GetUsr {
+	test "t" {}
+}
+
+Unrelated {
+	test "t" {}
+}
+`)
+
+	var messages []string
+	for _, d := range result.Diagnostics {
+		if d.Code == "undefined-query" {
+			messages = append(messages, d.Message)
+		}
+	}
+
+	want := []string{
+		"undefined query: GetUsr (did you mean GetUser?)",
+		"undefined query: Unrelated",
+	}
+	if !slices.Equal(messages, want) {
+		t.Errorf("undefined-query messages = %q, want %q", messages, want)
+	}
+}
+
+func TestRule_UndefinedProfile(t *testing.T) {
+	t.Parallel()
+
+	result := analyze(t, `
+query Q `+"`Q`"+`
+
+profile Base {
+	teardown `+"`T`"+`
+}
+
+Q extends Base {
+	test "t" {}
+}
+
+Q extends Bsae {
+	test "t" {}
+}
+`)
+
+	var got []string
+
+	for _, d := range result.Diagnostics {
+		if d.Code == "undefined-profile" {
+			got = append(got, fmt.Sprintf("%d:%d-%d:%d %s",
+				d.Span.Start.Line, d.Span.Start.Column, d.Span.End.Line, d.Span.End.Column, d.Message))
+		}
+	}
+
+	want := []string{"12:3-12:15 undefined profile: Bsae (did you mean Base?)"}
+	if !slices.Equal(got, want) {
+		t.Errorf("undefined-profile diagnostics = %q, want %q", got, want)
+	}
+}
+
 func TestRule_UndefinedImport(t *testing.T) {
 	t.Parallel()
 
@@ -119,6 +191,103 @@ GetUser {
 	assertHasDiagnostic(t, result, "unknown-parameter")
 }
 
+func TestRule_UnknownParameter_QueryAnalyzer(t *testing.T) {
+	t.Parallel()
+
+	input := `
+query GetUser ` + "`MATCH (u:User {id: $id}) WHERE u.name = $name RETURN u`" + `
+
+GetUser {
+	test "finds user" {
+		$id: 1
+		$nope: "test"
+	}
+}
+`
+
+	var found []analysis.Diagnostic
+
+	for _, d := range analyzeWithQueryAnalyzer(t, input).Diagnostics {
+		if d.Code == "unknown-parameter" {
+			found = append(found, d)
+		}
+	}
+
+	if len(found) != 1 {
+		t.Fatalf("expected 1 unknown-parameter diagnostic, got %d: %v", len(found), found)
+	}
+
+	d := found[0]
+	if !strings.Contains(d.Message, "available: $id, $name") {
+		t.Errorf("message should list the available parameters, got %q", d.Message)
+	}
+
+	if d.Span.Start.Line != 7 || d.Span.Start.Column != 3 || d.Span.End.Column != 8 {
+		t.Errorf("expected the span on $nope (7:3-8), got %d:%d-%d",
+			d.Span.Start.Line, d.Span.Start.Column, d.Span.End.Column)
+	}
+
+	// A body the dialect can't analyze isn't checked.
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetQueryAnalyzer(failingQueryAnalyzer{})
+
+	assertNoDiagnostic(t, analyzer.Analyze("test.scaf", []byte(input)), "unknown-parameter")
+}
+
+type failingQueryAnalyzer struct{}
+
+func (failingQueryAnalyzer) AnalyzeQuery(string) (*scaf.QueryMetadata, error) {
+	return nil, errors.New("syntax error")
+}
+
+func TestRule_UnknownReturnField(t *testing.T) {
+	t.Parallel()
+
+	result := analyzeWithQueryAnalyzer(t, `
+query GetUser `+"`MATCH (u:User {id: $id}) RETURN u.name AS name, u.age, count(*)`"+`
+query All `+"`MATCH (u:User) RETURN *`"+`
+
+GetUser {
+	test "t" {
+		$id: 1
+		name: "Alice"
+		u.age: 30
+		result[2]: 1
+		result[3]: 1
+		nmae: "Alice"
+	}
+}
+
+All {
+	test "t" {
+		anything: 1
+	}
+}
+`)
+
+	var messages []string
+
+	for _, d := range result.Diagnostics {
+		if d.Code != "unknown-return-field" {
+			continue
+		}
+
+		if d.Severity != analysis.SeverityHint {
+			t.Errorf("unknown-return-field severity = %v, want hint", d.Severity)
+		}
+
+		messages = append(messages, fmt.Sprintf("%d: %s", d.Span.Start.Line, d.Message))
+	}
+
+	want := []string{
+		"11: field result[3] is not returned by query GetUser",
+		"12: field nmae is not returned by query GetUser (did you mean name?)",
+	}
+	if !slices.Equal(messages, want) {
+		t.Errorf("unknown-return-field diagnostics = %q, want %q", messages, want)
+	}
+}
+
 func TestRule_EmptyTest(t *testing.T) {
 	t.Parallel()
 
@@ -171,6 +340,49 @@ Q {
 	assertHasDiagnostic(t, result, "duplicate-group")
 }
 
+func TestRule_DuplicateNameSpans(t *testing.T) {
+	t.Parallel()
+
+	result := analyze(t, `
+query Q `+"`Q`"+`
+
+Q {
+	test "a" { $x: 1 }
+	test  "a" { $x: 2 }
+	group "g" {
+		test "a" { $x: 3 }
+	}
+	test "g" { $x: 4 }
+	group "a" {
+		test "b" { $x: 5 }
+	}
+}
+`)
+
+	var got []string
+
+	for _, d := range result.Diagnostics {
+		if d.Code == "duplicate-test" || d.Code == "duplicate-group" {
+			got = append(got, fmt.Sprintf("%s %d:%d-%d:%d %s", d.Code,
+				d.Span.Start.Line, d.Span.Start.Column, d.Span.End.Line, d.Span.End.Column, d.Message))
+		}
+	}
+
+	// The test nested in g is in a different group, so doesn't collide.
+	want := []string{
+		`duplicate-test 6:8-6:11 duplicate test name in scope: a (first defined at line 5)`,
+		`duplicate-test 10:7-10:10 duplicate test name in scope: g (first defined at line 7)`,
+		`duplicate-group 11:8-11:11 duplicate group name in scope: a (first defined at line 5)`,
+	}
+
+	slices.Sort(got)
+	slices.Sort(want)
+
+	if !slices.Equal(got, want) {
+		t.Errorf("diagnostics =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
 func TestRule_UndefinedAssertQuery(t *testing.T) {
 	t.Parallel()
 
@@ -221,6 +433,107 @@ GetUser {
 	assertNoDiagnostic(t, result, "missing-required-params")
 }
 
+func TestRule_ParamTypeMismatch(t *testing.T) {
+	t.Parallel()
+
+	result := analyze(t, `
+query GetUser($id: int, $tags: []string) `+"`MATCH (u:User {id: $id}) WHERE u.tags = $tags RETURN u`"+`
+
+GetUser {
+	test "string id" {
+		$id: "oops"
+		$tags: ["a"]
+	}
+}
+`)
+
+	assertHasDiagnostic(t, result, "param-type-mismatch")
+
+	result = analyze(t, `
+query GetUser($id: int, $tags: []string) `+"`MATCH (u:User {id: $id}) WHERE u.tags = $tags RETURN u`"+`
+
+GetUser {
+	setup GetUser($id: 2, $tags: [])
+
+	test "typed" {
+		$id: 1
+		$tags: ["a", "b"]
+
+		assert GetUser($id: 1, $tags: ["a"]) {}
+	}
+}
+`)
+
+	assertNoDiagnostic(t, result, "param-type-mismatch")
+}
+
+func TestRule_EmptyFile(t *testing.T) {
+	t.Parallel()
+
+	analyzer := analysis.NewAnalyzer(nil)
+
+	if result := analyzer.Analyze("test.scaf", []byte("// Nothing yet.\n")); slices.ContainsFunc(result.Diagnostics, func(d analysis.Diagnostic) bool {
+		return d.Code == "empty-file"
+	}) {
+		t.Error("empty-file reported without being enabled")
+	}
+
+	analyzer.SetSeverityOverrides(map[string]analysis.DiagnosticSeverity{"empty-file": analysis.SeverityHint})
+
+	for _, input := range []string{"", "// Nothing yet.\n"} {
+		assertHasDiagnostic(t, analyzer.Analyze("test.scaf", []byte(input)), "empty-file")
+	}
+
+	assertNoDiagnostic(t, analyzer.Analyze("test.scaf", []byte("query Q `Q`\n")), "empty-file")
+}
+
+func TestRule_ReportRecovered(t *testing.T) {
+	t.Parallel()
+
+	input := "query Q `Q`\nQ {\n\tsetup\n\ttest \"t\" {}\n}\n"
+
+	analyzer := analysis.NewAnalyzer(nil)
+	assertNoDiagnostic(t, analyzer.Analyze("test.scaf", []byte(input)), "report-recovered")
+
+	analyzer.SetStrict(true)
+
+	result := analyzer.Analyze("test.scaf", []byte(input))
+	assertHasDiagnostic(t, result, "report-recovered")
+
+	for _, d := range result.Diagnostics {
+		if d.Code == "report-recovered" && d.Severity != analysis.SeverityHint {
+			t.Errorf("report-recovered severity = %v, want hint", d.Severity)
+		}
+	}
+
+	// A stray token is skipped by recovery, leaving a recovered scope behind.
+	result = analyzer.Analyze("test.scaf", []byte("query Q `Q`\nQ {\n\tsetup `Q` extra\n\ttest \"t\" {}\n}\n"))
+	if !slices.ContainsFunc(result.Diagnostics, func(d analysis.Diagnostic) bool {
+		return d.Code == "report-recovered" && strings.Contains(d.Message, `skipped "extra"`)
+	}) {
+		t.Errorf("expected report-recovered hint for the skipped token, got %v", result.Diagnostics)
+	}
+
+	assertNoDiagnostic(t, analyzer.Analyze("test.scaf", []byte("query Q `Q`\n")), "report-recovered")
+}
+
+func TestRule_DefaultedParamsNotRequired(t *testing.T) {
+	t.Parallel()
+
+	result := analyze(t, `
+query ListUsers($limit = 10) `+"`MATCH (u:User {name: $name}) RETURN u LIMIT $limit`"+`
+
+ListUsers {
+	test "uses default limit" {
+		$name: "Alice"
+	}
+}
+`)
+
+	assertNoDiagnostic(t, result, "missing-required-params")
+	assertNoDiagnostic(t, result, "unused-query-param")
+}
+
 func TestRule_EmptyGroup(t *testing.T) {
 	t.Parallel()
 
@@ -235,6 +548,432 @@ Q {
 	assertHasDiagnostic(t, result, "empty-group")
 }
 
+func TestRule_MisorderedSetup(t *testing.T) {
+	t.Parallel()
+
+	result := analyze(t, `
+query Q `+"`Q`"+`
+
+Q {
+	test "t" {}
+	setup `+"`CREATE (:User)`"+`
+}
+`)
+
+	assertHasDiagnostic(t, result, "misordered-setup")
+
+	result = analyze(t, `
+query Q `+"`Q`"+`
+
+Q {
+	test "a" {}
+	setup `+"`CREATE (:User)`"+`
+	test "b" {}
+}
+`)
+
+	assertHasDiagnostic(t, result, "misordered-setup")
+
+	if result.ParseError != nil {
+		t.Errorf("Expected a setup between tests to parse, got: %v", result.ParseError)
+	}
+
+	result = analyze(t, `
+query Q `+"`Q`"+`
+
+Q {
+	setup `+"`CREATE (:User)`"+`
+	test "t" {}
+}
+`)
+
+	assertNoDiagnostic(t, result, "misordered-setup")
+}
+
+func TestRule_UndefinedBinding(t *testing.T) {
+	t.Parallel()
+
+	result := analyze(t, `
+query Q `+"`Q`"+`
+
+Q {
+	test "a" {
+		setup admins = `+"`MATCH (a:Admin) RETURN a`"+`
+		count: len(admins)
+	}
+	test "b" {
+		count: len(admins)
+	}
+}
+`)
+
+	assertHasDiagnostic(t, result, "undefined-binding")
+
+	result = analyze(t, `
+query Q `+"`Q`"+`
+
+setup users = `+"`MATCH (u:User) RETURN u`"+`
+
+Q {
+	group "g" {
+		setup {
+			admins = `+"`MATCH (a:Admin) RETURN a`"+`
+		}
+		test "t" {
+			count: len(users)
+			admins: len(admins)
+		}
+	}
+}
+`)
+
+	assertNoDiagnostic(t, result, "undefined-binding")
+}
+
+func TestRule_EmptyAssert(t *testing.T) {
+	t.Parallel()
+
+	input := `
+query GetUser ` + "`MATCH (u:User) RETURN u`" + `
+query CreateUser ` + "`CREATE (u:User) RETURN u`" + `
+
+GetUser {
+	test "empty read" {
+		assert GetUser() {}
+	}
+	test "write" {
+		assert CreateUser() {}
+	}
+	test "checked" {
+		assert GetUser() { u != nil }
+	}
+	// scaf:run-only
+	test "intentional" {
+		assert GetUser() {}
+	}
+}
+`
+
+	// Opt-in: not reported without an override.
+	assertNoDiagnostic(t, analyzeWithQueryAnalyzer(t, input), "empty-assert")
+
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetQueryAnalyzer(cypher.NewAnalyzer())
+	analyzer.SetSeverityOverrides(map[string]analysis.DiagnosticSeverity{"empty-assert": analysis.SeverityHint})
+
+	result := analyzer.Analyze("test.scaf", []byte(input))
+
+	var lines []int
+
+	for _, d := range result.Diagnostics {
+		if d.Code == "empty-assert" {
+			lines = append(lines, d.Span.Start.Line)
+		}
+	}
+
+	if len(lines) != 1 || lines[0] != 7 {
+		t.Errorf("empty-assert lines = %v, want [7]", lines)
+	}
+}
+
+func TestRule_WriteQueryWithoutIsolation(t *testing.T) {
+	t.Parallel()
+
+	input := `
+query CreateUser ` + "`CREATE (u:User {name: $name}) RETURN u.name AS name`" + `
+query CreatePost ` + "`CREATE (p:Post) RETURN p`" + `
+query GetUser ` + "`MATCH (u:User) RETURN u.name AS name`" + `
+query CreateTag ` + "`CREATE (t:Tag {name: $name}) RETURN t.name AS name`" + `
+
+CreateUser {
+	test "creates" {
+		$name: "Alice"
+		name: "Alice"
+	}
+}
+
+CreatePost {
+	test "creates" {
+		p: null
+	}
+	teardown ` + "`MATCH (p:Post) DELETE p`" + `
+}
+
+GetUser {
+	test "reads" {
+		name: "Alice"
+	}
+}
+
+CreateTag {
+	test "inputs only" {
+		$name: "go"
+	}
+
+	test "idempotent" {
+		$name: "go"
+		name: "go"
+		assert idempotent
+	}
+}
+`
+
+	// Opt-in: not reported without an override.
+	assertNoDiagnostic(t, analyzeWithQueryAnalyzer(t, input), "write-query-without-isolation-note")
+
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetQueryAnalyzer(cypher.NewAnalyzer())
+	analyzer.SetSeverityOverrides(map[string]analysis.DiagnosticSeverity{
+		"write-query-without-isolation-note": analysis.SeverityHint,
+	})
+
+	result := analyzer.Analyze("test.scaf", []byte(input))
+
+	var found []analysis.Diagnostic
+
+	for _, d := range result.Diagnostics {
+		if d.Code == "write-query-without-isolation-note" {
+			found = append(found, d)
+		}
+	}
+
+	// Reported on the scope's query name.
+	if len(found) != 1 || found[0].Span.Start.Line != 7 || found[0].Span.Start.Column != 1 ||
+		found[0].Span.End.Line != 7 || found[0].Span.End.Column != 11 {
+		t.Fatalf("write-query-without-isolation-note = %v, want one on CreateUser at 7:1-7:11", found)
+	}
+
+	if !strings.Contains(found[0].Message, "per-test transactions") {
+		t.Errorf("message %q should suggest per-test transactions", found[0].Message)
+	}
+}
+
+func TestRule_SchemaValidation(t *testing.T) {
+	t.Parallel()
+
+	input := `
+query Q ` + "`MATCH (u:User), (p:Post) RETURN u.name AS name, p.title AS title, u.id AS id`" + `
+
+setup {
+	data User { id, name | 1.5, "Alice" }
+}
+
+Q {
+	test "t" {
+		name: 1
+		id: "x"
+	}
+}
+`
+
+	// Without a schema nothing is checked.
+	assertNoDiagnostic(t, analyzeWithQueryAnalyzer(t, input), "type-mismatch")
+
+	schema := analysis.NewTypeSchema()
+	schema.Models["User"] = &analysis.Model{Name: "User", Fields: []*analysis.Field{
+		{Name: "id", Type: analysis.TypeInt},
+		{Name: "name", Type: analysis.TypeString},
+	}}
+	// Post also has a name, so the "name" key is ambiguous and left unchecked.
+	schema.Models["Post"] = &analysis.Model{Name: "Post", Fields: []*analysis.Field{
+		{Name: "name", Type: analysis.TypeInt},
+		{Name: "title", Type: analysis.TypeString},
+	}}
+
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetQueryAnalyzer(cypher.NewAnalyzer())
+	analyzer.SetSchema(schema)
+
+	result := analyzer.Analyze("test.scaf", []byte(input))
+
+	var lines []int
+
+	for _, d := range result.Diagnostics {
+		if d.Code == "type-mismatch" {
+			lines = append(lines, d.Span.Start.Line)
+		}
+	}
+
+	// The non-integral id in the table, and the string expected for u.id.
+	if !slices.Equal(lines, []int{5, 11}) {
+		t.Errorf("type-mismatch lines = %v, want [5 11]", lines)
+	}
+}
+
+func TestRule_NumberForStringField(t *testing.T) {
+	t.Parallel()
+
+	input := `
+query Q ` + "`MATCH (a:Address) RETURN a.zip AS zip, a.number AS number`" + `
+
+setup {
+	data Address { zip, number | 90210, 12 }
+}
+
+Q {
+	test "t" {
+		zip: 2134
+		number: 12
+	}
+}
+`
+
+	schema := analysis.NewTypeSchema()
+	schema.Models["Address"] = &analysis.Model{Name: "Address", Fields: []*analysis.Field{
+		{Name: "zip", Type: analysis.TypeString},
+		{Name: "number", Type: analysis.TypeInt},
+	}}
+
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetQueryAnalyzer(cypher.NewAnalyzer())
+	analyzer.SetSchema(schema)
+
+	result := analyzer.Analyze("test.scaf", []byte(input))
+
+	var lines []int
+
+	for _, d := range result.Diagnostics {
+		if d.Code == "number-for-string-field" {
+			lines = append(lines, d.Span.Start.Line)
+		}
+	}
+
+	// The zip in the table and the expected zip; numbers for int fields are fine.
+	if !slices.Equal(lines, []int{5, 10}) {
+		t.Errorf("number-for-string-field lines = %v, want [5 10]", lines)
+	}
+
+	// Reported instead of, not as well as, a type mismatch.
+	assertNoDiagnostic(t, result, "type-mismatch")
+}
+
+func TestRule_UndefinedFieldRef(t *testing.T) {
+	t.Parallel()
+
+	result := analyzeWithQueryAnalyzer(t, `
+query GetUser `+"`MATCH (u:User {id: $id}) RETURN u.name`"+`
+query CountPosts `+"`MATCH (p:Post {authorId: $authorId}) RETURN count(p) as cnt`"+`
+
+GetUser {
+	test "t" {
+		$id: 1
+		assert CountPosts($authorId: u.id) { cnt > 0 }
+	}
+}
+`)
+
+	assertHasDiagnostic(t, result, "undefined-field-ref")
+}
+
+func TestRule_DefinedFieldRef(t *testing.T) {
+	t.Parallel()
+
+	result := analyzeWithQueryAnalyzer(t, `
+query GetUser `+"`MATCH (u:User {id: $id}) RETURN u.id, u`"+`
+query CountPosts `+"`MATCH (p:Post {authorId: $authorId}) RETURN count(p) as cnt`"+`
+
+GetUser {
+	test "column" {
+		$id: 1
+		assert CountPosts($authorId: u.id) { cnt > 0 }
+	}
+	test "property of returned node" {
+		$id: 1
+		assert CountPosts($authorId: u.name) { cnt > 0 }
+	}
+}
+`)
+
+	assertNoDiagnostic(t, result, "undefined-field-ref")
+}
+
+func TestRule_AggregateGrouping(t *testing.T) {
+	t.Parallel()
+
+	result := analyzeWithQueryAnalyzer(t, `
+query CountPosts `+"`MATCH (u:User)-[:WROTE]->(p:Post) RETURN u.name AS name, count(p) AS total`"+`
+query Total `+"`MATCH (p:Post) RETURN count(p) AS total`"+`
+
+CountPosts {
+	test "per author" {
+		name: "Alice"
+		total: 3
+	}
+	test "aggregate only" {
+		total: 3
+	}
+}
+
+Total {
+	test "overall" {
+		total: 3
+	}
+}
+`)
+
+	var lines []int
+
+	for _, d := range result.Diagnostics {
+		if d.Code == "aggregate-grouping" {
+			lines = append(lines, d.Span.Start.Line)
+		}
+	}
+
+	if !slices.Equal(lines, []int{8}) {
+		t.Errorf("aggregate-grouping lines = %v, want [8]", lines)
+	}
+}
+
+func TestRule_UnknownAssertField(t *testing.T) {
+	t.Parallel()
+
+	result := analyzeWithQueryAnalyzer(t, `
+query GetUser `+"`MATCH (u:User {id: $id}) RETURN u`"+`
+query CountPosts `+"`MATCH (p:Post) RETURN count(p) as total, p.title`"+`
+
+GetUser {
+	test "t" {
+		$id: 1
+		assert CountPosts() { totl > 0 && p.title != nil && len(p.title) > 0 }
+		assert `+"`MATCH (n) RETURN n`"+` { n.name == "x" && total == 1 }
+		assert { u.age > 18 && n.name == "x" }
+	}
+}
+`)
+
+	var messages []string
+	for _, d := range result.Diagnostics {
+		if d.Code == "unknown-assert-field" {
+			messages = append(messages, d.Message)
+		}
+	}
+
+	want := []string{
+		"assert condition references totl, which the assert query doesn't return (did you mean total?)",
+		"assert condition references total, which the assert query doesn't return",
+		"assert condition references n.name, which the main query doesn't return",
+	}
+	if !slices.Equal(messages, want) {
+		t.Errorf("unknown-assert-field messages = %q, want %q", messages, want)
+	}
+}
+
+func TestRule_UnionColumnMismatch(t *testing.T) {
+	t.Parallel()
+
+	result := analyzeWithQueryAnalyzer(t, `
+query Names `+"`MATCH (u:User) RETURN u.name AS name UNION MATCH (p:Pet) RETURN p.name AS petName`"+`
+`)
+
+	assertHasDiagnostic(t, result, "union-column-mismatch")
+
+	result = analyzeWithQueryAnalyzer(t, `
+query Names `+"`MATCH (u:User) RETURN u.name AS name UNION ALL MATCH (p:Pet) RETURN p.name AS name`"+`
+`)
+
+	assertNoDiagnostic(t, result, "union-column-mismatch")
+}
+
 // Test helpers
 
 func analyze(t *testing.T, input string) *analysis.AnalyzedFile {
@@ -245,6 +984,15 @@ func analyze(t *testing.T, input string) *analysis.AnalyzedFile {
 	return analyzer.Analyze("test.scaf", []byte(input))
 }
 
+func analyzeWithQueryAnalyzer(t *testing.T, input string) *analysis.AnalyzedFile {
+	t.Helper()
+
+	analyzer := analysis.NewAnalyzer(nil)
+	analyzer.SetQueryAnalyzer(cypher.NewAnalyzer())
+
+	return analyzer.Analyze("test.scaf", []byte(input))
+}
+
 func assertHasDiagnostic(t *testing.T, result *analysis.AnalyzedFile, code string) {
 	t.Helper()
 
@@ -46,9 +46,10 @@ type yamlModel struct {
 
 // yamlField is the YAML representation of Field.
 type yamlField struct {
-	Type     string `yaml:"type"`
-	Required bool   `yaml:"required,omitempty"`
-	Unique   bool   `yaml:"unique,omitempty"`
+	Type     string   `yaml:"type"`
+	Required bool     `yaml:"required,omitempty"`
+	Unique   bool     `yaml:"unique,omitempty"`
+	Enum     []string `yaml:"enum,omitempty"`
 }
 
 // yamlRelationship is the YAML representation of Relationship.
@@ -110,6 +111,7 @@ func yamlSchemaToTypeSchema(ys *yamlSchema) (*TypeSchema, error) {
 					Type:     typ,
 					Required: yf.Required,
 					Unique:   yf.Unique,
+					Enum:     yf.Enum,
 				})
 			}
 		}
@@ -169,6 +171,7 @@ func WriteSchema(w io.Writer, schema *TypeSchema) error {
 					Type:     field.Type.String(),
 					Required: field.Required,
 					Unique:   field.Unique,
+					Enum:     field.Enum,
 				}
 			}
 		}
@@ -488,6 +491,21 @@ type Field struct {
 	// Unique indicates whether this field has a uniqueness constraint.
 	// When a query filters on a unique field with equality, it returns at most one row.
 	Unique bool
+
+	// Enum lists the allowed values when the field is constrained to a fixed set
+	// (e.g., "positive", "negative", "neutral"). Empty for unconstrained fields.
+	Enum []string
+}
+
+// Field returns the field with the given name, or nil if the model has none.
+func (m *Model) Field(name string) *Field {
+	for _, f := range m.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	return nil
 }
 
 // Relationship represents an edge from one model to another.
@@ -61,6 +61,36 @@ models:
 	assert.Equal(t, TypeKindPrimitive, idField.Type.Kind)
 }
 
+func TestLoadSchemaEnum(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	schemaPath := filepath.Join(tmpDir, "schema.yaml")
+
+	schemaYAML := `
+models:
+  Review:
+    fields:
+      sentiment:
+        type: string
+        enum: [positive, negative, neutral]
+      body:
+        type: string
+`
+
+	err := os.WriteFile(schemaPath, []byte(schemaYAML), 0o644)
+	require.NoError(t, err)
+
+	schema, err := LoadSchema(schemaPath, "")
+	require.NoError(t, err)
+
+	review := schema.Models["Review"]
+	require.NotNil(t, review)
+	assert.Equal(t, []string{"positive", "negative", "neutral"}, review.Field("sentiment").Enum)
+	assert.Empty(t, review.Field("body").Enum)
+	assert.Nil(t, review.Field("missing"))
+}
+
 func TestLoadSchemaWithRelationships(t *testing.T) {
 	t.Parallel()
 
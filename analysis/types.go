@@ -2,6 +2,11 @@
 package analysis
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/rlch/scaf"
 )
 
@@ -34,6 +39,19 @@ type AnalyzedFile struct {
 	// Resolver is used for cross-file analysis (e.g., validating setup calls).
 	// May be nil if cross-file analysis is not available.
 	Resolver CrossFileResolver
+
+	// QueryAnalyzer is the dialect analyzer used by rules that inspect query bodies
+	// (e.g., validating field references against return fields).
+	// May be nil if no dialect analyzer is configured.
+	QueryAnalyzer scaf.QueryAnalyzer
+
+	// Schema is the type schema used by schema validation rules
+	// (e.g., unknown labels and enum violations).
+	// May be nil, in which case those rules are skipped.
+	Schema *TypeSchema
+
+	// content is the source the file was analyzed from, kept for Reanalyze.
+	content []byte
 }
 
 // SymbolTable holds all named definitions in a file.
@@ -44,6 +62,9 @@ type SymbolTable struct {
 	// Imports maps alias (or base name) to import symbol.
 	Imports map[string]*ImportSymbol
 
+	// Exports lists the file's re-exports in source order; earlier exports win.
+	Exports []*ExportSymbol
+
 	// Setups maps setup name to its symbol (for named setups in SetupClause blocks).
 	// Note: scaf doesn't define setups in-file yet, but imports reference them.
 	Setups map[string]*SetupSymbol
@@ -73,6 +94,7 @@ const (
 	SymbolKindTest
 	SymbolKindGroup
 	SymbolKindParam
+	SymbolKindExport
 )
 
 // Symbol is the base type for all symbol kinds.
@@ -110,6 +132,26 @@ type ImportSymbol struct {
 	Used bool
 }
 
+// ExportSymbol represents a re-export statement.
+type ExportSymbol struct {
+	Symbol
+
+	// Module is the re-exported import alias (nil for export { ... } from).
+	Module *string
+	// Names are the re-exported query names (empty when re-exporting a module).
+	Names []string
+	// From is the path the names are re-exported from (nil when re-exporting a module).
+	From *string
+	// Node is the AST node for this export.
+	Node *scaf.Export
+}
+
+// ExportsQuery reports whether the export makes the named query reachable,
+// provided its source module defines or re-exports it.
+func (e *ExportSymbol) ExportsQuery(name string) bool {
+	return e.Module != nil || slices.Contains(e.Names, name)
+}
+
 // SetupSymbol represents a named setup (from imports).
 type SetupSymbol struct {
 	Symbol
@@ -141,6 +183,35 @@ type Diagnostic struct {
 	Source   string // "scaf"
 }
 
+// Err converts the diagnostic to a typed error that callers can switch on by Code.
+func (d Diagnostic) Err() *scaf.AnalysisError {
+	return &scaf.AnalysisError{
+		Code:     d.Code,
+		Span:     d.Span,
+		Message:  d.Message,
+		Severity: int(d.Severity),
+	}
+}
+
+// Err returns the file's problems as an error: its *scaf.ParseError if parsing
+// failed, otherwise every error-severity diagnostic as a *scaf.AnalysisError,
+// joined. Returns nil if there are none.
+func (f *AnalyzedFile) Err() error {
+	if f.ParseError != nil {
+		return f.ParseError
+	}
+
+	var errs []error
+
+	for _, d := range f.Diagnostics {
+		if d.Severity == SeverityError {
+			errs = append(errs, d.Err())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // DiagnosticSeverity indicates the severity of a diagnostic.
 type DiagnosticSeverity int
 
@@ -151,3 +222,45 @@ const (
 	SeverityInformation
 	SeverityHint
 )
+
+// SeverityOff suppresses a diagnostic. It is only meaningful as a severity override.
+const SeverityOff DiagnosticSeverity = -1
+
+// ErrUnknownSeverity is returned when a severity name is not recognized.
+var ErrUnknownSeverity = errors.New("unknown severity")
+
+// ParseSeverity parses a severity name: "error", "warning", "information"
+// (or "info"), "hint", or "off".
+func ParseSeverity(name string) (DiagnosticSeverity, error) {
+	switch strings.ToLower(name) {
+	case "error":
+		return SeverityError, nil
+	case "warning", "warn":
+		return SeverityWarning, nil
+	case "information", "info":
+		return SeverityInformation, nil
+	case "hint":
+		return SeverityHint, nil
+	case "off", "none":
+		return SeverityOff, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnknownSeverity, name)
+	}
+}
+
+// ParseSeverityOverrides parses a map of diagnostic code to severity name,
+// as found in .scaf.yaml or editor settings.
+func ParseSeverityOverrides(names map[string]string) (map[string]DiagnosticSeverity, error) {
+	overrides := make(map[string]DiagnosticSeverity, len(names))
+
+	for code, name := range names {
+		sev, err := ParseSeverity(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", code, err)
+		}
+
+		overrides[code] = sev
+	}
+
+	return overrides, nil
+}
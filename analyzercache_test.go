@@ -0,0 +1,85 @@
+package scaf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rlch/scaf"
+)
+
+var errAnalyze = errors.New("analyze failed")
+
+// countingAnalyzer records how often each query body is analyzed.
+type countingAnalyzer struct {
+	calls map[string]int
+}
+
+func (a *countingAnalyzer) AnalyzeQuery(query string) (*scaf.QueryMetadata, error) {
+	a.calls[query]++
+
+	if query == "bad" {
+		return nil, errAnalyze
+	}
+
+	return &scaf.QueryMetadata{Parameters: []scaf.ParameterInfo{{Name: query}}}, nil
+}
+
+func TestCachedAnalyzer_ReusesResults(t *testing.T) {
+	t.Parallel()
+
+	inner := &countingAnalyzer{calls: make(map[string]int)}
+	cached := scaf.NewCachedAnalyzer(inner, 4)
+
+	first, err := cached.AnalyzeQuery("a")
+	if err != nil {
+		t.Fatalf("AnalyzeQuery() error: %v", err)
+	}
+
+	for range 3 {
+		got, err := cached.AnalyzeQuery("a")
+		if err != nil {
+			t.Fatalf("AnalyzeQuery() error: %v", err)
+		}
+
+		if got != first {
+			t.Errorf("AnalyzeQuery() returned %p, want cached %p", got, first)
+		}
+	}
+
+	for range 2 {
+		if _, err := cached.AnalyzeQuery("bad"); !errors.Is(err, errAnalyze) {
+			t.Errorf("AnalyzeQuery(bad) error = %v, want %v", err, errAnalyze)
+		}
+	}
+
+	if inner.calls["a"] != 1 || inner.calls["bad"] != 1 {
+		t.Errorf("inner calls = %v, want each body analyzed once", inner.calls)
+	}
+}
+
+func TestCachedAnalyzer_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	inner := &countingAnalyzer{calls: make(map[string]int)}
+	cached := scaf.NewCachedAnalyzer(inner, 2)
+
+	_, _ = cached.AnalyzeQuery("a")
+	_, _ = cached.AnalyzeQuery("b")
+	_, _ = cached.AnalyzeQuery("a") // a is now most recently used
+	_, _ = cached.AnalyzeQuery("c") // evicts b
+
+	if got := cached.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+
+	_, _ = cached.AnalyzeQuery("a")
+	_, _ = cached.AnalyzeQuery("b")
+
+	if inner.calls["a"] != 1 {
+		t.Errorf("a analyzed %d times, want 1", inner.calls["a"])
+	}
+
+	if inner.calls["b"] != 2 {
+		t.Errorf("b analyzed %d times, want 2 (evicted)", inner.calls["b"])
+	}
+}
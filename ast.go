@@ -2,7 +2,9 @@
 package scaf
 
 import (
+	"encoding/base64"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -30,6 +32,45 @@ type CommentMeta struct {
 	TrailingComment string   `parser:""`
 }
 
+// Comment directives recognized on tests, groups, and queries, written as a
+// leading "// scaf:<name>" comment.
+const (
+	// DirectiveFocus runs only focused tests when any test or group in the suite is focused.
+	DirectiveFocus = "focus"
+	// DirectiveSkip skips the test, or every test in the group. It may carry a
+	// reason that is reported with the skip: "// scaf:skip("flaky on CI")".
+	DirectiveSkip = "skip"
+	// DirectiveRunOnly marks asserts without conditions in the test, or every
+	// test in the group, as intentionally running their query unchecked.
+	DirectiveRunOnly = "run-only"
+	// DirectiveUnordered compares the test's expected rows regardless of order,
+	// as --unordered does for every test.
+	DirectiveUnordered = "unordered"
+	// DirectiveExport marks a query as meant for other files to call, so it
+	// isn't reported as unused when nothing in the workspace calls it yet.
+	DirectiveExport = "export"
+)
+
+// DirectiveMeta holds the comment directives of a node (populated after parsing).
+type DirectiveMeta struct {
+	// Directives are the names of leading "// scaf:<name>" comments, in order.
+	Directives []string `parser:""`
+
+	// DirectiveArgs maps a directive name to its argument, if it has one:
+	// `// scaf:skip("flaky on CI")` or `// scaf:skip flaky on CI`.
+	DirectiveArgs map[string]string `parser:""`
+}
+
+// HasDirective reports whether the node carries the named directive.
+func (d *DirectiveMeta) HasDirective(name string) bool {
+	return slices.Contains(d.Directives, name)
+}
+
+// DirectiveArg returns the argument of the named directive, or "" if it has none.
+func (d *DirectiveMeta) DirectiveArg(name string) string {
+	return d.DirectiveArgs[name]
+}
+
 // RecoveryMeta holds recovery metadata for nodes that support error recovery.
 // If RecoveredSpan is non-zero, it indicates recovery happened during parsing.
 // Participle automatically populates these fields when recovery occurs.
@@ -93,16 +134,165 @@ type CompletableNode interface {
 // =============================================================================
 
 // Suite represents a complete test file with queries, setup, teardown, and test scopes.
+//
+// An optional meta block at the top of the file holds free-form metadata for
+// reports, such as an owner or ticket:
+//
+//	meta {owner: "team-x", jira: "ABC-123"}
+//
+// The first line may be a shebang declaring options for tooling, such as the
+// query dialect. It has no effect on the suite's semantics:
+//
+//	#!scaf dialect=cypher
 type Suite struct {
 	NodeMeta
 	CommentMeta
 	RecoveryMeta
 
+	Shebang  string        `parser:"@Shebang?"`
+	Meta     *Map          `parser:"('meta' @@)?"`
 	Imports  []*Import     `parser:"@@*"`
+	Exports  []*Export     `parser:"@@*"`
 	Queries  []*Query      `parser:"@@*"`
 	Setup    *SetupClause  `parser:"('setup' @@)?"`
 	Teardown *string       `parser:"('teardown' @RawString)?"`
+	Profiles []*Profile    `parser:"@@*"`
 	Scopes   []*QueryScope `parser:"@@*"`
+
+	// BodySpans holds the span of every raw-string body in source order,
+	// including teardowns, which have no node of their own.
+	BodySpans []Span `parser:""`
+}
+
+// Query returns the query with the given name, or nil if none is defined.
+// If a name is defined more than once, the last definition wins.
+func (s *Suite) Query(name string) *Query {
+	var found *Query
+
+	for _, q := range s.Queries {
+		if q.Name == name {
+			found = q
+		}
+	}
+
+	return found
+}
+
+// Metadata returns the suite's meta entries as Go values, or nil if it has none.
+func (s *Suite) Metadata() map[string]any {
+	if s.Meta == nil || len(s.Meta.Entries) == 0 {
+		return nil
+	}
+
+	meta := make(map[string]any, len(s.Meta.Entries))
+	for _, e := range s.Meta.Entries {
+		meta[e.Key] = e.Value.ToGo()
+	}
+
+	return meta
+}
+
+// Dialect returns the dialect declared by the shebang line ("#!scaf dialect=sql"),
+// or "" if there is none.
+func (s *Suite) Dialect() string {
+	fields := strings.Fields(strings.TrimPrefix(s.Shebang, "#!"))
+	if len(fields) == 0 || fields[0] != "scaf" {
+		return ""
+	}
+
+	for _, field := range fields[1:] {
+		if name, ok := strings.CutPrefix(field, "dialect="); ok {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// Profile returns the profile with the given name, or nil if none is defined.
+func (s *Suite) Profile(name string) *Profile {
+	for _, p := range s.Profiles {
+		if p.Name == name {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// TestByPath finds the test at path, where path is the scope's query name followed
+// by any group names and finally the test name, e.g. ["GetUser", "edge cases", "handles null"].
+// It returns the enclosing scope and groups (outermost first) alongside the test,
+// or a nil test if no test matches.
+func (s *Suite) TestByPath(path []string) (*QueryScope, []*Group, *Test) {
+	if len(path) < 2 {
+		return nil, nil, nil
+	}
+
+	for _, scope := range s.Scopes {
+		if scope.QueryName != path[0] {
+			continue
+		}
+
+		if groups, test := testByPath(scope.Items, path[1:], nil); test != nil {
+			return scope, groups, test
+		}
+	}
+
+	return nil, nil, nil
+}
+
+func testByPath(items []*TestOrGroup, path []string, groups []*Group) ([]*Group, *Test) {
+	for _, item := range items {
+		switch {
+		case item.Test != nil && len(path) == 1 && item.Test.Name == path[0]:
+			return groups, item.Test
+		case item.Group != nil && len(path) > 1 && item.Group.Name == path[0]:
+			nested := append(groups[:len(groups):len(groups)], item.Group)
+			if found, test := testByPath(item.Group.Items, path[1:], nested); test != nil {
+				return found, test
+			}
+		case item.Sequence != nil && len(path) == 1:
+			for _, test := range item.Sequence.Tests {
+				if test.Name == path[0] {
+					return groups, test
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// Paths returns the slash-separated path of every runnable scope, group, and
+// test in source order, parents before their children, e.g. "GetUser",
+// "GetUser/edge cases", "GetUser/edge cases/handles null". These are the paths
+// the runner matches filters against.
+func (s *Suite) Paths() []string {
+	var paths []string
+
+	var walk func(prefix string, items []*TestOrGroup)
+	walk = func(prefix string, items []*TestOrGroup) {
+		for _, item := range items {
+			switch {
+			case item.Test != nil, item.Sequence != nil:
+				for _, test := range item.Tests() {
+					paths = append(paths, prefix+"/"+test.Name)
+				}
+			case item.Group != nil:
+				path := prefix + "/" + item.Group.Name
+				paths = append(paths, path)
+				walk(path, item.Group.Items)
+			}
+		}
+	}
+
+	for _, scope := range s.Scopes {
+		paths = append(paths, scope.QueryName)
+		walk(scope.QueryName, scope.Items)
+	}
+
+	return paths
 }
 
 // Import represents a module import statement.
@@ -119,13 +309,90 @@ type Import struct {
 	Path  string  `parser:"@String"`
 }
 
-// Query defines a named database query.
+// Export re-exports queries so that files importing this one can call them
+// through it, making a fixtures hub possible. Exports follow the imports.
+// Examples:
+//
+//	export fixtures                      // every query of the imported fixtures module
+//	export { CreateUser } from "./users" // selected queries of another module
+type Export struct {
+	NodeMeta
+	CommentMeta
+	RecoveryMeta
+
+	Module *string  `parser:"'export' ( @Ident"`
+	Names  []string `parser:"| '{' (@Ident (Comma @Ident)* Comma?)? '}'"`
+	From   *string  `parser:"'from' @String )"`
+}
+
+// Query defines a named database query, optionally declaring parameter types
+// and defaults. Tests that omit a defaulted parameter are bound to its default
+// value, and values passed for a typed parameter are checked against its type:
+//
+//	query ListUsers($role: string, $limit: int = 10) `MATCH (u:User) RETURN u LIMIT $limit`
 type Query struct {
 	NodeMeta
 	CommentMeta
+	DirectiveMeta
 	RecoveryMeta
-	Name string `parser:"'query' @Ident"`
-	Body string `parser:"@RawString"`
+	Name     string          `parser:"'query' @Ident"`
+	Defaults []*ParamDefault `parser:"('(' (@@ (Comma @@)* Comma?)? ')')?"`
+	Body     string          `parser:"@RawString"`
+
+	// BodySpan is the source span of Body between its backticks.
+	BodySpan Span `parser:""`
+
+	// ParamTypes maps parameter names, without the $ prefix, to their declared
+	// types (e.g., "int", "[]string"). Populated from Defaults after parsing.
+	ParamTypes map[string]string `parser:""`
+}
+
+// ParamDefaults returns the query's default parameter values keyed by
+// parameter name without the $ prefix.
+func (q *Query) ParamDefaults() map[string]*Value {
+	if q == nil || len(q.Defaults) == 0 {
+		return nil
+	}
+
+	defaults := make(map[string]*Value, len(q.Defaults))
+	for _, d := range q.Defaults {
+		if d.Value != nil {
+			defaults[strings.TrimPrefix(d.Name, "$")] = d.Value
+		}
+	}
+
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	return defaults
+}
+
+// indexParamTypes populates ParamTypes from the parameter declarations.
+func (q *Query) indexParamTypes() {
+	q.ParamTypes = nil
+
+	for _, d := range q.Defaults {
+		if d.Type == nil {
+			continue
+		}
+
+		if q.ParamTypes == nil {
+			q.ParamTypes = make(map[string]string)
+		}
+
+		q.ParamTypes[strings.TrimPrefix(d.Name, "$")] = *d.Type
+	}
+}
+
+// ParamDefault declares a query parameter's type, its default value, or both:
+// $limit = 10, $limit: int, or $limit: int = 10.
+type ParamDefault struct {
+	NodeMeta
+	RecoveryMeta
+	Name  string  `parser:"@Ident"`
+	Type  *string `parser:"( Colon @('[' ']')* @Ident ( '=' "`
+	Value *Value  `parser:"@@ )? | '=' @@ )"`
 }
 
 // =============================================================================
@@ -139,13 +406,24 @@ type Query struct {
 //	setup fixtures                                      // module setup (runs module's setup clause)
 //	setup fixtures.CreateUser($id: 1, $name: "Alice")   // query call with params
 //	setup { fixtures; fixtures.CreateUser($id: 1) }     // block with multiple items
+//	setup users = fixtures.CreateUsers($n: 3)           // bind the returned rows as users
+//
+// A binding names the rows returned by an inline query or call (or, in a block,
+// the rows of a data table) so that expected values can be computed from them,
+// e.g. count: len(users). Bindings are visible to every test beneath the setup;
+// a binding in an inner scope shadows an outer one of the same name.
 type SetupClause struct {
 	NodeMeta
+	CommentMeta
 	RecoveryMeta
-	Inline *string      `parser:"@RawString"`
-	Call   *SetupCall   `parser:"| @@"`
-	Module *string      `parser:"| @Ident"`
-	Block  []*SetupItem `parser:"| '{' @@* '}'"`
+	Binding *string      `parser:"(@Ident '=')?"`
+	Inline  *string      `parser:"( @RawString"`
+	Call    *SetupCall   `parser:"| @@"`
+	Module  *string      `parser:"| @Ident"`
+	Block   []*SetupItem `parser:"| '{' @@* '}' )"`
+
+	// BodySpan is the source span of Inline between its backticks, if set.
+	BodySpan Span `parser:""`
 }
 
 // IsComplete returns true if the setup clause has content.
@@ -154,31 +432,93 @@ func (s *SetupClause) IsComplete() bool {
 }
 
 // SetupItem represents a single item in a setup block.
-// Can be an inline query, module setup, or query call.
+// Can be an inline query, module setup, query call, or data table, optionally
+// bound to a name as in SetupClause.
+// Each item keeps its own comments so annotated setup steps survive formatting.
 type SetupItem struct {
 	NodeMeta
+	CommentMeta
 	RecoveryMeta
-	Inline *string    `parser:"@RawString"`
-	Call   *SetupCall `parser:"| @@"`
-	Module *string    `parser:"| @Ident"`
+	Binding *string    `parser:"(@Ident '=')?"`
+	Inline  *string    `parser:"( @RawString"`
+	Data    *DataTable `parser:"| @@"`
+	Call    *SetupCall `parser:"| @@"`
+	Module  *string    `parser:"| @Ident )"`
+
+	// BodySpan is the source span of Inline between its backticks, if set.
+	BodySpan Span `parser:""`
+}
+
+// DataTable is a literal block of rows to insert in bulk during setup.
+// The header names the columns; each row starts with '|'.
+// Example:
+//
+//	data User {
+//	  id, name
+//	| 1,  "Alice"
+//	| 2,  "Bob"
+//	}
+type DataTable struct {
+	NodeMeta
+	RecoveryMeta
+	Label   string     `parser:"'data' @Ident '{'"`
+	Columns []string   `parser:"@Ident (Comma @Ident)*"`
+	Rows    []*DataRow `parser:"@@* '}'"`
+}
+
+// DataRow is a single row of a data table.
+type DataRow struct {
+	NodeMeta
+	RecoveryMeta
+	Values []*Value `parser:"'|' @@ (Comma @@)*"`
+}
+
+// Bindings returns one map per row, keyed by column name.
+// Rows shorter than the header leave the remaining columns unset.
+func (t *DataTable) Bindings() []map[string]any {
+	rows := make([]map[string]any, len(t.Rows))
+
+	for i, row := range t.Rows {
+		rows[i] = make(map[string]any, len(t.Columns))
+
+		for j, v := range row.Values {
+			if j < len(t.Columns) {
+				rows[i][t.Columns[j]] = v.ToGo()
+			}
+		}
+	}
+
+	return rows
 }
 
 // SetupCall invokes a query from a module with parameters.
+// Without a module, the query is resolved in the file's namespace: its own
+// queries plus those of every unaliased import.
 // Examples:
 //
 //	fixtures.CreateUser($id: 1, $name: "Alice")
 //	db.SeedData()
+//	CreateUser($id: 1)
 type SetupCall struct {
 	NodeMeta
 	RecoveryMeta
-	Module string        `parser:"@Ident Dot"`
+	Module string        `parser:"(@Ident Dot)?"`
 	Query  string        `parser:"@Ident '('"`
 	Params []*SetupParam `parser:"(@@ (Comma @@)*)? ')'"`
 }
 
 // IsComplete returns true if the setup call has all required parts.
 func (c *SetupCall) IsComplete() bool {
-	return c.Module != "" && c.Query != ""
+	return c.Query != ""
+}
+
+// Ref returns the called query as written: "fixtures.CreateUser" or "CreateUser".
+func (c *SetupCall) Ref() string {
+	if c.Module == "" {
+		return c.Query
+	}
+
+	return c.Module + "." + c.Query
 }
 
 // SetupParam is a parameter passed to a named setup.
@@ -246,16 +586,128 @@ func (p *ParamValue) String() string {
 // Scope and Test nodes
 // =============================================================================
 
+// Profile defines a named setup and teardown that query scopes can share.
+// A scope opts in with extends:
+//
+//	profile Base {
+//		setup fixtures.SetupUsers()
+//	}
+//
+//	GetUser extends Base {
+//		setup `CREATE (:Post)`
+//		test "finds user" { ... }
+//	}
+//
+// Profile setup runs before the scope's own setup and profile teardown runs
+// after the scope's own teardown. A scope never replaces its profile's clauses;
+// both always run.
+type Profile struct {
+	NodeMeta
+	CommentMeta
+	RecoveryMeta
+	Name     string       `parser:"'profile' @Ident '{'"`
+	Setup    *SetupClause `parser:"('setup' @@)?"`
+	Teardown *string      `parser:"('teardown' @RawString)?"`
+	Close    string       `parser:"@'}'"`
+}
+
+// IsComplete returns true if the profile has a closing brace.
+func (p *Profile) IsComplete() bool {
+	return p.Close != ""
+}
+
 // QueryScope groups tests that target a specific query.
+// Extends names an optional profile whose setup and teardown wrap the scope's own.
 type QueryScope struct {
 	NodeMeta
 	CommentMeta
 	RecoveryMeta
-	QueryName string         `parser:"@Ident '{'"`
+	QueryName string         `parser:"@Ident"`
+	Extends   *string        `parser:"('extends' @Ident)? '{'"`
 	Setup     *SetupClause   `parser:"('setup' @@)?"`
 	Teardown  *string        `parser:"('teardown' @RawString)?"`
 	Items     []*TestOrGroup `parser:"@@*"`
-	Close     string         `parser:"@'}'"`
+	// Trailing holds setup and teardown clauses written after the first test or
+	// group, including between tests.
+	Trailing []*TrailingClause `parser:"@@*"`
+	Close    string            `parser:"@'}'"`
+}
+
+// TrailingClause is a setup or teardown written after a scope's tests, or
+// between them. Setup and teardown belong before the tests, but the parser
+// accepts them here so analysis can report misordered-setup. If the scope has no
+// clause of the same kind before its tests, the trailing one is also stored in
+// the scope's Setup or Teardown, so it runs and formats as if it were written in
+// place; a second clause of a kind never runs, and the runner refuses the scope.
+type TrailingClause struct {
+	NodeMeta
+	Setup    *SetupClause `parser:"(  'setup' @@"`
+	Teardown *string      `parser:" | 'teardown' @RawString )"`
+	// Following holds the tests and groups written after the clause. Parsing
+	// moves them to the scope's Items, leaving this empty.
+	Following []*TestOrGroup `parser:"@@*"`
+}
+
+// Promoted reports whether the clause is stored in the scope's Setup or Teardown.
+func (c *TrailingClause) Promoted(scope *QueryScope) bool {
+	if c.Setup != nil {
+		return scope.Setup == c.Setup
+	}
+
+	return c.Teardown != nil && scope.Teardown == c.Teardown
+}
+
+// promoteTrailingClauses moves the tests written after each scope's trailing
+// clauses into its Items, and fills its empty Setup and Teardown from its first
+// trailing clause of that kind.
+func promoteTrailingClauses(suite *Suite) {
+	for _, scope := range suite.Scopes {
+		for _, c := range scope.Trailing {
+			if len(c.Following) > 0 {
+				c.endBefore(c.Following[0].Pos.Offset)
+				scope.Items = append(scope.Items, c.Following...)
+				c.Following = nil
+			}
+
+			if c.Setup != nil && scope.Setup == nil {
+				scope.Setup = c.Setup
+			}
+
+			if c.Teardown != nil && scope.Teardown == nil {
+				scope.Teardown = c.Teardown
+			}
+		}
+	}
+}
+
+// endBefore trims the clause's extent to the tokens before offset, where the
+// tests that followed it begin.
+func (c *TrailingClause) endBefore(offset int) {
+	var (
+		end  = -1 // index of the token after the last significant one
+		kept = len(c.Tokens)
+	)
+
+	for i, tok := range c.Tokens {
+		if tok.Pos.Offset >= offset {
+			kept = i
+
+			break
+		}
+
+		if tok.Type != TokenWhitespace && tok.Type != TokenComment {
+			end = i + 1
+		}
+	}
+
+	switch {
+	case end >= 0 && end < kept:
+		c.EndPos = c.Tokens[end].Pos
+	case kept < len(c.Tokens):
+		c.EndPos = c.Tokens[kept].Pos
+	}
+
+	c.Tokens = c.Tokens[:kept]
 }
 
 // IsComplete returns true if the query scope has a closing brace.
@@ -263,18 +715,58 @@ func (q *QueryScope) IsComplete() bool {
 	return q.Close != ""
 }
 
-// TestOrGroup is a union type - either a Test or a Group.
+// TestOrGroup is a union type - a Test, a Group, or a Sequence.
 type TestOrGroup struct {
 	NodeMeta
 	RecoveryMeta
-	Test  *Test  `parser:"@@"`
-	Group *Group `parser:"| @@"`
+	Test     *Test     `parser:"@@"`
+	Group    *Group    `parser:"| @@"`
+	Sequence *Sequence `parser:"| @@"`
+}
+
+// Tests returns the item's test, or the tests of its sequence, in order.
+func (t *TestOrGroup) Tests() []*Test {
+	switch {
+	case t.Test != nil:
+		return []*Test{t.Test}
+	case t.Sequence != nil:
+		return t.Sequence.Tests
+	default:
+		return nil
+	}
+}
+
+// Sequence runs its tests in order against shared state: the tests share one
+// transaction, rolled back after the last test, so later tests observe the
+// writes of earlier ones. A sequence has no name of its own; its tests are
+// addressed as if written directly in the enclosing scope or group.
+//
+//	sequence {
+//		test "creates user" { ... }
+//		test "reads user back" { ... }
+//	}
+//
+// The tests are no longer isolated from each other: a failing step can leave
+// state that makes later steps fail too, and running one of them alone (with a
+// filter) runs it without the steps before it.
+type Sequence struct {
+	NodeMeta
+	CommentMeta
+	RecoveryMeta
+	Tests []*Test `parser:"'sequence' '{' @@*"`
+	Close string  `parser:"@'}'"`
+}
+
+// IsComplete returns true if the sequence has a closing brace.
+func (q *Sequence) IsComplete() bool {
+	return q.Close != ""
 }
 
 // Group organizes related tests with optional shared setup and teardown.
 type Group struct {
 	NodeMeta
 	CommentMeta
+	DirectiveMeta
 	RecoveryMeta
 	Name     string         `parser:"'group' @String '{'"`
 	Setup    *SetupClause   `parser:"('setup' @@)?"`
@@ -290,15 +782,31 @@ func (g *Group) IsComplete() bool {
 
 // Test defines a single test case with inputs, expected outputs, and optional assertions.
 // Tests run in a transaction that rolls back after execution, so no teardown is needed.
+//
+// A rows block describes the full expected result set, one map per row:
+//
+//	rows {
+//		{name: "Alice"},
+//		{name: "Bob"}
+//	}
+//
+// An expect block describes the single result row as a whole: the row must
+// have exactly the map's fields. Statements naming the same field take
+// precedence over the map's entries, and also count as expected fields:
+//
+//	expect {name: "Alice", age: 30}
 type Test struct {
 	NodeMeta
 	CommentMeta
+	DirectiveMeta
 	RecoveryMeta
-	Name       string       `parser:"'test' @String '{'"`
-	Setup      *SetupClause `parser:"('setup' @@)?"`
-	Statements []*Statement `parser:"@@*"`
-	Asserts    []*Assert    `parser:"@@*"`
-	Close      string       `parser:"@'}'"`
+	Name         string       `parser:"'test' @String '{'"`
+	Setup        *SetupClause `parser:"('setup' @@)?"`
+	Statements   []*Statement `parser:"@@*"`
+	Expect       *Map         `parser:"('expect' @@)?"`
+	ExpectedRows []*Map       `parser:"('rows' '{' (@@ (Comma @@)* Comma?)? '}')?"`
+	Asserts      []*Assert    `parser:"@@*"`
+	Close        string       `parser:"@'}'"`
 }
 
 // IsComplete returns true if the test has a closing brace.
@@ -318,17 +826,24 @@ func (t *Test) IsComplete() bool {
 //	assert { x > 0; y < 10; z == 5 }                         // multiple exprs
 //	assert CreatePost($title: "x") { p.title == "x" }        // named query with conditions
 //	assert `MATCH (n) RETURN count(n) as cnt` { cnt > 0 }    // inline query with conditions
+//	assert idempotent                                        // re-run the main query
+//
+// An idempotent assert takes no block. It executes the test's main query a second
+// time with the same parameters and passes only if the second run returns the same
+// rows as the first, in the same order. Later asserts observe the database after
+// the second run, so a following count query checks that no data was duplicated.
 type Assert struct {
 	NodeMeta
 	RecoveryMeta
-	Query      *AssertQuery `parser:"'assert' @@? '{'"`
+	Idempotent bool         `parser:"'assert' ( @'idempotent'"`
+	Query      *AssertQuery `parser:"| @@? '{'"`
 	Conditions []*Expr      `parser:"(@@ Semi?)*"`
-	Close      string       `parser:"@'}'"`
+	Close      string       `parser:"@'}' )"`
 }
 
-// IsComplete returns true if the assert has a closing brace.
+// IsComplete returns true if the assert is an idempotent check or has a closing brace.
 func (a *Assert) IsComplete() bool {
-	return a.Close != ""
+	return a.Idempotent || a.Close != ""
 }
 
 // AssertQuery specifies the query to run before evaluating conditions.
@@ -341,6 +856,9 @@ type AssertQuery struct {
 	// Or named query reference with required parentheses
 	QueryName *string       `parser:"| @Ident '('"`
 	Params    []*SetupParam `parser:"(@@ (Comma @@)*)? ')'"`
+
+	// BodySpan is the source span of Inline between its backticks, if set.
+	BodySpan Span `parser:""`
 }
 
 // =============================================================================
@@ -480,15 +998,27 @@ func (d *DottedIdent) String() string {
 //
 //	$userId: 1                                    // input parameter
 //	u.name: "Alice"                               // expected output (equality)
+//	result[0]: 5                                  // expected output, by column position
+//
+// A positional key refers to the result's column at that 0-based index in the
+// order the query returns them, for columns with no usable name, such as an
+// unaliased count(*).
 type Statement struct {
 	NodeMeta
+	CommentMeta
 	RecoveryMeta
-	KeyParts *DottedIdent `parser:"@@"`
+	Column   *int         `parser:"( 'result' '[' @Number ']'"`
+	KeyParts *DottedIdent `parser:"| @@ )"`
 	Value    *Value       `parser:"Colon @@"`
 }
 
-// Key returns the statement key as a dot-joined string.
+// Key returns the statement key as a dot-joined string, or as result[N] for a
+// positional key.
 func (s *Statement) Key() string {
+	if s.Column != nil {
+		return ColumnKey(*s.Column)
+	}
+
 	if s.KeyParts == nil {
 		return ""
 	}
@@ -496,6 +1026,32 @@ func (s *Statement) Key() string {
 	return s.KeyParts.String()
 }
 
+// ColumnKey returns the positional key of the result column at index, e.g. "result[0]".
+func ColumnKey(index int) string {
+	return "result[" + strconv.Itoa(index) + "]"
+}
+
+// ParseColumnKey returns the column index of a positional key like "result[0]",
+// and false for any other key.
+func ParseColumnKey(key string) (int, bool) {
+	digits, ok := strings.CutPrefix(key, "result[")
+	if !ok {
+		return 0, false
+	}
+
+	digits, ok = strings.CutSuffix(digits, "]")
+	if !ok {
+		return 0, false
+	}
+
+	index, err := strconv.Atoi(digits)
+	if err != nil || index < 0 || ColumnKey(index) != key {
+		return 0, false
+	}
+
+	return index, true
+}
+
 // NewStatement creates a Statement from a dot-separated key string and value.
 // This is a convenience constructor for testing and programmatic AST construction.
 //
@@ -519,16 +1075,109 @@ func (b *Boolean) Capture(values []string) error {
 	return nil
 }
 
-// Value represents a literal value (string, number, bool, null, map, or list).
+// Bytes is a binary literal written as base64: bytes("aGVsbG8=").
+type Bytes []byte
+
+// Capture implements participle's Capture interface for Bytes, decoding base64.
+func (b *Bytes) Capture(values []string) error {
+	decoded, err := base64.StdEncoding.DecodeString(values[0])
+	if err != nil {
+		return fmt.Errorf("invalid base64 in bytes literal: %w", err)
+	}
+
+	// Keep empty literals non-nil so bytes("") is distinguishable from absent.
+	if decoded == nil {
+		decoded = []byte{}
+	}
+
+	*b = decoded
+
+	return nil
+}
+
+// String returns the literal form, e.g. bytes("aGVsbG8=").
+func (b Bytes) String() string {
+	return `bytes("` + base64.StdEncoding.EncodeToString(b) + `")`
+}
+
+// Value represents a literal value (string, number, bool, null, bytes, map, or list).
+// As an expected value, absent requires the field to be missing from the result,
+// whereas null also matches a field that is present but null.
 type Value struct {
 	NodeMeta
 	RecoveryMeta
-	Null    bool     `parser:"@'null'"`
-	Str     *string  `parser:"| @String"`
-	Number  *float64 `parser:"| @Number"`
-	Boolean *Boolean `parser:"| @('true' | 'false')"`
-	Map     *Map     `parser:"| @@"`
-	List    *List    `parser:"| @@"`
+	Null     bool      `parser:"@'null'"`
+	Absent   bool      `parser:"| @'absent'"`
+	Str      *string   `parser:"| @String"`
+	Number   *float64  `parser:"| @Number"`
+	Boolean  *Boolean  `parser:"| @('true' | 'false')"`
+	Bytes    Bytes     `parser:"| 'bytes' '(' @String ')'"`
+	Map      *Map      `parser:"| @@"`
+	List     *List     `parser:"| @@"`
+	Computed *Computed `parser:"| @@"`
+}
+
+// Computed is an expected value derived from setup bindings by an expr-lang
+// function call, evaluated when the test runs:
+//
+//	count: len(users)
+//	oldest: max(len(users), len(admins))
+//
+// Computed values are only valid as a test's expected outputs.
+type Computed struct {
+	NodeMeta
+	RecoveryMeta
+	Func string         `parser:"@Ident '('"`
+	Args []*ComputedArg `parser:"(@@ (Comma @@)*)? ')'"`
+}
+
+// ComputedArg is an argument of a computed value: a literal (or nested call),
+// or a reference to a binding such as users.
+type ComputedArg struct {
+	NodeMeta
+	RecoveryMeta
+	Value *Value       `parser:"@@"`
+	Ref   *DottedIdent `parser:"| @@"`
+}
+
+// String returns the call as written, which is also its expr-lang source.
+func (c *Computed) String() string {
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		args[i] = a.String()
+	}
+
+	return c.Func + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Refs returns the binding names the computed value references, including
+// those of nested calls, in order.
+func (c *Computed) Refs() []*DottedIdent {
+	var refs []*DottedIdent
+
+	for _, a := range c.Args {
+		switch {
+		case a.Ref != nil:
+			refs = append(refs, a.Ref)
+		case a.Value != nil && a.Value.Computed != nil:
+			refs = append(refs, a.Value.Computed.Refs()...)
+		}
+	}
+
+	return refs
+}
+
+// String returns the argument as written.
+func (a *ComputedArg) String() string {
+	if a.Ref != nil {
+		return a.Ref.String()
+	}
+
+	if a.Value != nil {
+		return a.Value.String()
+	}
+
+	return ""
 }
 
 // Map represents a key-value map literal.
@@ -554,9 +1203,12 @@ type List struct {
 }
 
 // ToGo converts a Value to a native Go type.
+// Absent has no Go equivalent and converts to nil like null; callers comparing
+// results must check Absent themselves. Computed values convert to nil; they
+// must be evaluated against setup bindings by the runner.
 func (v *Value) ToGo() any {
 	switch {
-	case v.Null:
+	case v.Null, v.Absent:
 		return nil
 	case v.Str != nil:
 		return *v.Str
@@ -564,6 +1216,8 @@ func (v *Value) ToGo() any {
 		return *v.Number
 	case v.Boolean != nil:
 		return bool(*v.Boolean)
+	case v.Bytes != nil:
+		return []byte(v.Bytes)
 	case v.Map != nil:
 		m := make(map[string]any)
 		for _, e := range v.Map.Entries {
@@ -588,16 +1242,22 @@ func (v *Value) String() string {
 	switch {
 	case v.Null:
 		return "null"
+	case v.Absent:
+		return "absent"
 	case v.Str != nil:
 		return fmt.Sprintf("%q", *v.Str)
 	case v.Number != nil:
 		return fmt.Sprintf("%v", *v.Number)
 	case v.Boolean != nil:
 		return strconv.FormatBool(bool(*v.Boolean))
+	case v.Bytes != nil:
+		return v.Bytes.String()
 	case v.Map != nil:
 		return v.mapString()
 	case v.List != nil:
 		return v.listString()
+	case v.Computed != nil:
+		return v.Computed.String()
 	default:
 		return "nil"
 	}
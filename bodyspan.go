@@ -0,0 +1,134 @@
+package scaf
+
+import (
+	"unicode/utf8"
+
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// ContainsOffset reports whether a byte offset lies within the span.
+// The end is inclusive so a cursor just before a closing delimiter is inside.
+func (s Span) ContainsOffset(offset int) bool {
+	return offset >= s.Start.Offset && offset <= s.End.Offset
+}
+
+// attachBodySpans records the source span of every raw-string body.
+// Raw strings have no escapes, so data[span.Start.Offset:span.End.Offset] is the body
+// exactly as written (Unquote drops carriage returns, so the token value may be shorter).
+func attachBodySpans(suite *Suite, data []byte) {
+	if suite == nil {
+		return
+	}
+
+	byOpen := make(map[int]Span)
+
+	for _, tok := range suite.Tokens {
+		if tok.Type != TokenRawString {
+			continue
+		}
+
+		span, ok := rawBodySpan(data, tok.Pos)
+		if !ok {
+			continue
+		}
+
+		suite.BodySpans = append(suite.BodySpans, span)
+		byOpen[tok.Pos.Offset] = span
+	}
+
+	if len(byOpen) == 0 {
+		return
+	}
+
+	for _, q := range suite.Queries {
+		q.BodySpan = firstBodySpan(q.Tokens, byOpen)
+	}
+
+	applySetupBodySpans(suite.Setup, byOpen)
+
+	for _, p := range suite.Profiles {
+		applySetupBodySpans(p.Setup, byOpen)
+	}
+
+	for _, scope := range suite.Scopes {
+		applySetupBodySpans(scope.Setup, byOpen)
+		applyItemBodySpans(scope.Items, byOpen)
+	}
+}
+
+func applyItemBodySpans(items []*TestOrGroup, byOpen map[int]Span) {
+	for _, item := range items {
+		if item.Group != nil {
+			applySetupBodySpans(item.Group.Setup, byOpen)
+			applyItemBodySpans(item.Group.Items, byOpen)
+		}
+
+		for _, test := range item.Tests() {
+			applySetupBodySpans(test.Setup, byOpen)
+
+			for _, a := range test.Asserts {
+				if a.Query != nil && a.Query.Inline != nil {
+					a.Query.BodySpan = firstBodySpan(a.Query.Tokens, byOpen)
+				}
+			}
+		}
+	}
+}
+
+func applySetupBodySpans(setup *SetupClause, byOpen map[int]Span) {
+	if setup == nil {
+		return
+	}
+
+	if setup.Inline != nil {
+		setup.BodySpan = firstBodySpan(setup.Tokens, byOpen)
+	}
+
+	for _, item := range setup.Block {
+		if item.Inline != nil {
+			item.BodySpan = firstBodySpan(item.Tokens, byOpen)
+		}
+	}
+}
+
+// firstBodySpan returns the span of the first raw string among a node's tokens.
+func firstBodySpan(tokens []lexer.Token, byOpen map[int]Span) Span {
+	for _, tok := range tokens {
+		if tok.Type == TokenRawString {
+			return byOpen[tok.Pos.Offset]
+		}
+	}
+
+	return Span{}
+}
+
+// rawBodySpan computes the span between a raw string's backticks, given the
+// position of the opening backtick. Columns count runes, matching the lexer.
+func rawBodySpan(data []byte, open lexer.Position) (Span, bool) {
+	if open.Offset >= len(data) || data[open.Offset] != '`' {
+		return Span{}, false
+	}
+
+	start := open
+	start.Offset++
+	start.Column++
+
+	end := start
+	for end.Offset < len(data) && data[end.Offset] != '`' {
+		r, size := utf8.DecodeRune(data[end.Offset:])
+		end.Offset += size
+
+		if r == '\n' {
+			end.Line++
+			end.Column = 1
+		} else {
+			end.Column++
+		}
+	}
+
+	if end.Offset >= len(data) {
+		return Span{}, false
+	}
+
+	return Span{Start: start, End: end}, true
+}
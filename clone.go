@@ -0,0 +1,568 @@
+package scaf
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a deep copy of the suite. The copy shares no pointers, slices,
+// or trivia with the original, so tools can rewrite it freely.
+func (s *Suite) Clone() *Suite {
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+	c.NodeMeta = s.NodeMeta.clone()
+	c.CommentMeta = s.CommentMeta.clone()
+	c.RecoveryMeta = s.RecoveryMeta.clone()
+	c.Meta = s.Meta.Clone()
+	c.Imports = cloneAll(s.Imports)
+	c.Exports = cloneAll(s.Exports)
+	c.Queries = cloneAll(s.Queries)
+	c.Setup = s.Setup.Clone()
+	c.Teardown = clonePtr(s.Teardown)
+	c.Profiles = cloneAll(s.Profiles)
+	c.Scopes = cloneAll(s.Scopes)
+	c.BodySpans = slices.Clone(s.BodySpans)
+
+	return &c
+}
+
+// Clone returns a deep copy of the import.
+func (i *Import) Clone() *Import {
+	if i == nil {
+		return nil
+	}
+
+	c := *i
+	c.NodeMeta = i.NodeMeta.clone()
+	c.CommentMeta = i.CommentMeta.clone()
+	c.RecoveryMeta = i.RecoveryMeta.clone()
+	c.Alias = clonePtr(i.Alias)
+
+	return &c
+}
+
+// Clone returns a deep copy of the export.
+func (e *Export) Clone() *Export {
+	if e == nil {
+		return nil
+	}
+
+	c := *e
+	c.NodeMeta = e.NodeMeta.clone()
+	c.CommentMeta = e.CommentMeta.clone()
+	c.RecoveryMeta = e.RecoveryMeta.clone()
+	c.Module = clonePtr(e.Module)
+	c.Names = slices.Clone(e.Names)
+	c.From = clonePtr(e.From)
+
+	return &c
+}
+
+// Clone returns a deep copy of the query.
+func (q *Query) Clone() *Query {
+	if q == nil {
+		return nil
+	}
+
+	c := *q
+	c.NodeMeta = q.NodeMeta.clone()
+	c.CommentMeta = q.CommentMeta.clone()
+	c.DirectiveMeta = q.DirectiveMeta.clone()
+	c.RecoveryMeta = q.RecoveryMeta.clone()
+	c.Defaults = cloneAll(q.Defaults)
+	c.ParamTypes = maps.Clone(q.ParamTypes)
+
+	return &c
+}
+
+// Clone returns a deep copy of the parameter default.
+func (p *ParamDefault) Clone() *ParamDefault {
+	if p == nil {
+		return nil
+	}
+
+	c := *p
+	c.NodeMeta = p.NodeMeta.clone()
+	c.RecoveryMeta = p.RecoveryMeta.clone()
+	c.Type = clonePtr(p.Type)
+	c.Value = p.Value.Clone()
+
+	return &c
+}
+
+// Clone returns a deep copy of the setup clause.
+func (s *SetupClause) Clone() *SetupClause {
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+	c.NodeMeta = s.NodeMeta.clone()
+	c.CommentMeta = s.CommentMeta.clone()
+	c.RecoveryMeta = s.RecoveryMeta.clone()
+	c.Binding = clonePtr(s.Binding)
+	c.Inline = clonePtr(s.Inline)
+	c.Call = s.Call.Clone()
+	c.Module = clonePtr(s.Module)
+	c.Block = cloneAll(s.Block)
+
+	return &c
+}
+
+// Clone returns a deep copy of the setup item.
+func (s *SetupItem) Clone() *SetupItem {
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+	c.NodeMeta = s.NodeMeta.clone()
+	c.CommentMeta = s.CommentMeta.clone()
+	c.RecoveryMeta = s.RecoveryMeta.clone()
+	c.Binding = clonePtr(s.Binding)
+	c.Inline = clonePtr(s.Inline)
+	c.Data = s.Data.Clone()
+	c.Call = s.Call.Clone()
+	c.Module = clonePtr(s.Module)
+
+	return &c
+}
+
+// Clone returns a deep copy of the data table.
+func (t *DataTable) Clone() *DataTable {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	c.NodeMeta = t.NodeMeta.clone()
+	c.RecoveryMeta = t.RecoveryMeta.clone()
+	c.Columns = slices.Clone(t.Columns)
+	c.Rows = cloneAll(t.Rows)
+
+	return &c
+}
+
+// Clone returns a deep copy of the data row.
+func (r *DataRow) Clone() *DataRow {
+	if r == nil {
+		return nil
+	}
+
+	c := *r
+	c.NodeMeta = r.NodeMeta.clone()
+	c.RecoveryMeta = r.RecoveryMeta.clone()
+	c.Values = cloneAll(r.Values)
+
+	return &c
+}
+
+// Clone returns a deep copy of the setup call.
+func (s *SetupCall) Clone() *SetupCall {
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+	c.NodeMeta = s.NodeMeta.clone()
+	c.RecoveryMeta = s.RecoveryMeta.clone()
+	c.Params = cloneAll(s.Params)
+
+	return &c
+}
+
+// Clone returns a deep copy of the setup parameter.
+func (p *SetupParam) Clone() *SetupParam {
+	if p == nil {
+		return nil
+	}
+
+	c := *p
+	c.NodeMeta = p.NodeMeta.clone()
+	c.RecoveryMeta = p.RecoveryMeta.clone()
+	c.Value = p.Value.Clone()
+
+	return &c
+}
+
+// Clone returns a deep copy of the parameter value.
+func (p *ParamValue) Clone() *ParamValue {
+	if p == nil {
+		return nil
+	}
+
+	c := *p
+	c.NodeMeta = p.NodeMeta.clone()
+	c.RecoveryMeta = p.RecoveryMeta.clone()
+	c.Literal = p.Literal.Clone()
+	c.FieldRef = p.FieldRef.Clone()
+
+	return &c
+}
+
+// Clone returns a deep copy of the profile.
+func (p *Profile) Clone() *Profile {
+	if p == nil {
+		return nil
+	}
+
+	c := *p
+	c.NodeMeta = p.NodeMeta.clone()
+	c.CommentMeta = p.CommentMeta.clone()
+	c.RecoveryMeta = p.RecoveryMeta.clone()
+	c.Setup = p.Setup.Clone()
+	c.Teardown = clonePtr(p.Teardown)
+
+	return &c
+}
+
+// Clone returns a deep copy of the query scope.
+func (q *QueryScope) Clone() *QueryScope {
+	if q == nil {
+		return nil
+	}
+
+	c := *q
+	c.NodeMeta = q.NodeMeta.clone()
+	c.CommentMeta = q.CommentMeta.clone()
+	c.RecoveryMeta = q.RecoveryMeta.clone()
+	c.Extends = clonePtr(q.Extends)
+	c.Setup = q.Setup.Clone()
+	c.Teardown = clonePtr(q.Teardown)
+	c.Items = cloneAll(q.Items)
+	c.Trailing = cloneAll(q.Trailing)
+
+	// Keep promoted trailing clauses shared with Setup and Teardown.
+	for i, t := range q.Trailing {
+		if t.Setup != nil && q.Setup == t.Setup {
+			c.Setup = c.Trailing[i].Setup
+		}
+
+		if t.Teardown != nil && q.Teardown == t.Teardown {
+			c.Teardown = c.Trailing[i].Teardown
+		}
+	}
+
+	return &c
+}
+
+// Clone returns a deep copy of the trailing clause.
+func (t *TrailingClause) Clone() *TrailingClause {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	c.NodeMeta = t.NodeMeta.clone()
+	c.Setup = t.Setup.Clone()
+	c.Teardown = clonePtr(t.Teardown)
+	c.Following = cloneAll(t.Following)
+
+	return &c
+}
+
+// Clone returns a deep copy of the test, group, or sequence.
+func (t *TestOrGroup) Clone() *TestOrGroup {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	c.NodeMeta = t.NodeMeta.clone()
+	c.RecoveryMeta = t.RecoveryMeta.clone()
+	c.Test = t.Test.Clone()
+	c.Group = t.Group.Clone()
+	c.Sequence = t.Sequence.Clone()
+
+	return &c
+}
+
+// Clone returns a deep copy of the sequence.
+func (q *Sequence) Clone() *Sequence {
+	if q == nil {
+		return nil
+	}
+
+	c := *q
+	c.NodeMeta = q.NodeMeta.clone()
+	c.CommentMeta = q.CommentMeta.clone()
+	c.RecoveryMeta = q.RecoveryMeta.clone()
+	c.Tests = cloneAll(q.Tests)
+
+	return &c
+}
+
+// Clone returns a deep copy of the group.
+func (g *Group) Clone() *Group {
+	if g == nil {
+		return nil
+	}
+
+	c := *g
+	c.NodeMeta = g.NodeMeta.clone()
+	c.CommentMeta = g.CommentMeta.clone()
+	c.DirectiveMeta = g.DirectiveMeta.clone()
+	c.RecoveryMeta = g.RecoveryMeta.clone()
+	c.Setup = g.Setup.Clone()
+	c.Teardown = clonePtr(g.Teardown)
+	c.Items = cloneAll(g.Items)
+
+	return &c
+}
+
+// Clone returns a deep copy of the test.
+func (t *Test) Clone() *Test {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	c.NodeMeta = t.NodeMeta.clone()
+	c.CommentMeta = t.CommentMeta.clone()
+	c.DirectiveMeta = t.DirectiveMeta.clone()
+	c.RecoveryMeta = t.RecoveryMeta.clone()
+	c.Setup = t.Setup.Clone()
+	c.Statements = cloneAll(t.Statements)
+	c.Expect = t.Expect.Clone()
+	c.ExpectedRows = cloneAll(t.ExpectedRows)
+	c.Asserts = cloneAll(t.Asserts)
+
+	return &c
+}
+
+// Clone returns a deep copy of the assert.
+func (a *Assert) Clone() *Assert {
+	if a == nil {
+		return nil
+	}
+
+	c := *a
+	c.NodeMeta = a.NodeMeta.clone()
+	c.RecoveryMeta = a.RecoveryMeta.clone()
+	c.Query = a.Query.Clone()
+	c.Conditions = cloneAll(a.Conditions)
+
+	return &c
+}
+
+// Clone returns a deep copy of the assert query.
+func (a *AssertQuery) Clone() *AssertQuery {
+	if a == nil {
+		return nil
+	}
+
+	c := *a
+	c.NodeMeta = a.NodeMeta.clone()
+	c.RecoveryMeta = a.RecoveryMeta.clone()
+	c.Inline = clonePtr(a.Inline)
+	c.QueryName = clonePtr(a.QueryName)
+	c.Params = cloneAll(a.Params)
+
+	return &c
+}
+
+// Clone returns a deep copy of the expression.
+func (e *Expr) Clone() *Expr {
+	if e == nil {
+		return nil
+	}
+
+	c := *e
+	c.NodeMeta = e.NodeMeta.clone()
+	c.RecoveryMeta = e.RecoveryMeta.clone()
+	c.ExprTokens = cloneAll(e.ExprTokens)
+
+	return &c
+}
+
+// Clone returns a deep copy of the expression token.
+func (t *ExprToken) Clone() *ExprToken {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	c.NodeMeta = t.NodeMeta.clone()
+	c.RecoveryMeta = t.RecoveryMeta.clone()
+	c.Str = clonePtr(t.Str)
+	c.Number = clonePtr(t.Number)
+	c.Ident = clonePtr(t.Ident)
+	c.Op = clonePtr(t.Op)
+
+	return &c
+}
+
+// Clone returns a deep copy of the dotted identifier.
+func (d *DottedIdent) Clone() *DottedIdent {
+	if d == nil {
+		return nil
+	}
+
+	c := *d
+	c.NodeMeta = d.NodeMeta.clone()
+	c.RecoveryMeta = d.RecoveryMeta.clone()
+	c.Parts = slices.Clone(d.Parts)
+
+	return &c
+}
+
+// Clone returns a deep copy of the statement.
+func (s *Statement) Clone() *Statement {
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+	c.NodeMeta = s.NodeMeta.clone()
+	c.CommentMeta = s.CommentMeta.clone()
+	c.RecoveryMeta = s.RecoveryMeta.clone()
+	c.Column = clonePtr(s.Column)
+	c.KeyParts = s.KeyParts.Clone()
+	c.Value = s.Value.Clone()
+
+	return &c
+}
+
+// Clone returns a deep copy of the value.
+func (v *Value) Clone() *Value {
+	if v == nil {
+		return nil
+	}
+
+	c := *v
+	c.NodeMeta = v.NodeMeta.clone()
+	c.RecoveryMeta = v.RecoveryMeta.clone()
+	c.Str = clonePtr(v.Str)
+	c.Number = clonePtr(v.Number)
+	c.Boolean = clonePtr(v.Boolean)
+	c.Bytes = slices.Clone(v.Bytes) // keeps bytes("") non-nil
+	c.Map = v.Map.Clone()
+	c.List = v.List.Clone()
+	c.Computed = v.Computed.Clone()
+
+	return &c
+}
+
+// Clone returns a deep copy of the computed value.
+func (v *Computed) Clone() *Computed {
+	if v == nil {
+		return nil
+	}
+
+	c := *v
+	c.NodeMeta = v.NodeMeta.clone()
+	c.RecoveryMeta = v.RecoveryMeta.clone()
+	c.Args = cloneAll(v.Args)
+
+	return &c
+}
+
+// Clone returns a deep copy of the computed value's argument.
+func (a *ComputedArg) Clone() *ComputedArg {
+	if a == nil {
+		return nil
+	}
+
+	c := *a
+	c.NodeMeta = a.NodeMeta.clone()
+	c.RecoveryMeta = a.RecoveryMeta.clone()
+	c.Value = a.Value.Clone()
+	c.Ref = a.Ref.Clone()
+
+	return &c
+}
+
+// Clone returns a deep copy of the map literal.
+func (m *Map) Clone() *Map {
+	if m == nil {
+		return nil
+	}
+
+	c := *m
+	c.NodeMeta = m.NodeMeta.clone()
+	c.RecoveryMeta = m.RecoveryMeta.clone()
+	c.Entries = cloneAll(m.Entries)
+
+	return &c
+}
+
+// Clone returns a deep copy of the map entry.
+func (e *MapEntry) Clone() *MapEntry {
+	if e == nil {
+		return nil
+	}
+
+	c := *e
+	c.NodeMeta = e.NodeMeta.clone()
+	c.RecoveryMeta = e.RecoveryMeta.clone()
+	c.Value = e.Value.Clone()
+
+	return &c
+}
+
+// Clone returns a deep copy of the list literal.
+func (l *List) Clone() *List {
+	if l == nil {
+		return nil
+	}
+
+	c := *l
+	c.NodeMeta = l.NodeMeta.clone()
+	c.RecoveryMeta = l.RecoveryMeta.clone()
+	c.Values = cloneAll(l.Values)
+
+	return &c
+}
+
+func (n NodeMeta) clone() NodeMeta {
+	n.Tokens = slices.Clone(n.Tokens)
+
+	return n
+}
+
+func (m CommentMeta) clone() CommentMeta {
+	m.LeadingComments = slices.Clone(m.LeadingComments)
+
+	return m
+}
+
+func (d DirectiveMeta) clone() DirectiveMeta {
+	d.Directives = slices.Clone(d.Directives)
+	d.DirectiveArgs = maps.Clone(d.DirectiveArgs)
+
+	return d
+}
+
+func (r RecoveryMeta) clone() RecoveryMeta {
+	r.RecoveredTokens = slices.Clone(r.RecoveredTokens)
+
+	return r
+}
+
+// cloneAll deep-copies a slice of nodes, preserving nil.
+func cloneAll[T interface{ Clone() T }](nodes []T) []T {
+	if nodes == nil {
+		return nil
+	}
+
+	out := make([]T, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.Clone()
+	}
+
+	return out
+}
+
+// clonePtr copies the value behind p into a fresh pointer, preserving nil.
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+
+	v := *p
+
+	return &v
+}
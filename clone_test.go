@@ -0,0 +1,85 @@
+package scaf_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rlch/scaf"
+)
+
+func TestSuiteClone(t *testing.T) {
+	t.Parallel()
+
+	input := `import fixtures "../shared/fixtures"
+
+// Finds a user.
+query GetUser($id = 1) ` + "`MATCH (u:User {id: $id}) RETURN u.name`" + `
+
+setup {
+	fixtures.CreateUser($id: 1, $name: "Alice")
+	data User {
+		id, name
+	| 1, "Alice"
+	}
+}
+
+GetUser {
+	group "by id" {
+		test "finds alice" {
+			$id: 1
+			u.name: "Alice"
+			tags: ["a", {k: bytes("aGk=")}]
+
+			assert { u.name == "Alice" }
+		}
+	}
+}
+`
+
+	original, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	before := scaf.Format(original)
+
+	clone := original.Clone()
+	if diff := cmp.Diff(original, clone); diff != "" {
+		t.Fatalf("Clone() differs from original (-original +clone):\n%s", diff)
+	}
+
+	// Mutate every level of the clone, including shared trivia.
+	clone.Tokens[0].Value = "mutated"
+	clone.Imports[0].LeadingComments = append(clone.Imports[0].LeadingComments, "// new")
+	*clone.Imports[0].Alias = "other"
+	clone.Queries[0].Name = "Renamed"
+	clone.Queries[0].LeadingComments[0] = "// changed"
+	*clone.Queries[0].Defaults[0].Value.Number = 2
+	clone.Setup.Block[0].Call.Params[0].Value.Literal.Number = nil
+	clone.Setup.Block[1].Data.Columns[0] = "uid"
+	clone.Setup.Block[1].Data.Rows[0].Values[1] = nil
+	clone.Scopes[0].QueryName = "Renamed"
+
+	test := clone.Scopes[0].Items[0].Group.Items[0].Test
+	test.Name = "renamed"
+	test.Statements[1].KeyParts.Parts[1] = "age"
+	*test.Statements[1].Value.Str = "Bob"
+	test.Statements[2].Value.List.Values[1].Map.Entries[0].Value.Bytes[0] = 'X'
+	*test.Asserts[0].Conditions[0].ExprTokens[0].Ident = "v"
+
+	if after := scaf.Format(original); after != before {
+		t.Errorf("mutating the clone changed the original:\n--- before\n%s\n--- after\n%s", before, after)
+	}
+
+	if original.Tokens[0].Value == "mutated" {
+		t.Error("clone shares Suite.Tokens with the original")
+	}
+
+	if got := original.Setup.Block[0].Call.Params[0].Value.Literal.Number; got == nil || *got != 1 {
+		t.Errorf("original setup param = %v, want 1", got)
+	}
+
+	if (*scaf.Suite)(nil).Clone() != nil {
+		t.Error("nil Suite.Clone() should return nil")
+	}
+}
@@ -16,11 +16,15 @@ import (
 
 	// Import dialects to register their analyzers via init().
 	_ "github.com/rlch/scaf/dialects/cypher"
+
+	// Import databases so code lens commands can run tests.
+	_ "github.com/rlch/scaf/databases/neo4j"
 )
 
 var (
 	dialectFlag = flag.String("dialect", "cypher", "Query dialect (cypher, sql)")
 	debugFlag   = flag.Bool("debug", false, "Enable debug logging")
+	strictFlag  = flag.Bool("strict", false, "Report constructs recovered from parse errors as hints")
 )
 
 func main() {
@@ -48,22 +52,23 @@ func main() {
 
 	ctx := context.Background()
 
-	err = run(ctx, logger, os.Stdin, os.Stdout, *dialectFlag)
+	err = run(ctx, logger, os.Stdin, os.Stdout, *dialectFlag, *strictFlag)
 	if err != nil {
 		logger.Fatal("Server error", zap.Error(err))
 	}
 }
 
-func run(ctx context.Context, logger *zap.Logger, in io.Reader, out io.Writer, dialect string) error {
+func run(ctx context.Context, logger *zap.Logger, in io.Reader, out io.Writer, dialect string, strict bool) error {
 	// Create a JSON-RPC stream connection over stdio
 	stream := jsonrpc2.NewStream(&readWriteCloser{in, out})
 	conn := jsonrpc2.NewConn(stream)
 
 	// Create a client to send notifications to the editor
-	client := protocol.ClientDispatcher(conn, logger)
+	client := &lspClient{Client: protocol.ClientDispatcher(conn, logger), conn: conn}
 
 	// Create our LSP server
 	server := lsp.NewServer(client, logger, dialect)
+	server.SetStrict(strict)
 
 	// Register the server handler with the connection
 	conn.Go(ctx, protocol.ServerHandler(server, nil))
@@ -74,6 +79,27 @@ func run(ctx context.Context, logger *zap.Logger, in io.Reader, out io.Writer, d
 	return conn.Err()
 }
 
+// lspClient adds window/showDocument, which the dispatcher lacks, so the
+// server can open files for the user (see lsp.DocumentShower).
+type lspClient struct {
+	protocol.Client
+
+	conn jsonrpc2.Conn
+}
+
+func (c *lspClient) ShowDocument(
+	ctx context.Context, params *protocol.ShowDocumentParams,
+) (*protocol.ShowDocumentResult, error) {
+	var result protocol.ShowDocumentResult
+
+	err := protocol.Call(ctx, c.conn, "window/showDocument", params, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
 // readWriteCloser wraps separate reader/writer into io.ReadWriteCloser.
 type readWriteCloser struct {
 	io.Reader
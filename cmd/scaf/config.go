@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/rlch/scaf"
+	"github.com/urfave/cli/v3"
+)
+
+// loadConfig loads the nearest config walking up from dir. A missing config is
+// not an error and yields an empty one; a malformed config is.
+func loadConfig(dir string) (*scaf.Config, error) {
+	cfg, err := scaf.LoadConfig(dir)
+	if errors.Is(err, scaf.ErrConfigNotFound) {
+		return &scaf.Config{}, nil
+	}
+
+	return cfg, err
+}
+
+// loadWorkingConfig loads the nearest config walking up from the working
+// directory, as for loadConfig.
+func loadWorkingConfig() (*scaf.Config, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	return loadConfig(wd)
+}
+
+// boolOption returns the named flag if it was given, else the config default.
+func boolOption(cmd *cli.Command, name string, def bool) bool {
+	if cmd.IsSet(name) {
+		return cmd.Bool(name)
+	}
+
+	return def
+}
+
+// intOption returns the named flag if it was given, else the config default
+// if set, else the flag's own default.
+func intOption(cmd *cli.Command, name string, def int) int {
+	if cmd.IsSet(name) || def == 0 {
+		return cmd.Int(name)
+	}
+
+	return def
+}
+
+// stringSliceOption returns the named flag if it was given, else the config default.
+func stringSliceOption(cmd *cli.Command, name string, def []string) []string {
+	if cmd.IsSet(name) {
+		return cmd.StringSlice(name)
+	}
+
+	return def
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rlch/scaf"
+	"github.com/urfave/cli/v3"
+)
+
+func TestOptionPrecedence(t *testing.T) {
+	t.Parallel()
+
+	fromFile := scaf.TestConfig{Unordered: true, Concurrency: 4, Exclude: []string{"*slow*"}}
+
+	tests := []struct {
+		name string
+		args []string
+		cfg  scaf.TestConfig
+
+		wantUnordered   bool
+		wantConcurrency int
+		wantExclude     []string
+	}{
+		{"default", []string{"test"}, scaf.TestConfig{}, false, 1, nil},
+		{"file", []string{"test"}, fromFile, true, 4, []string{"*slow*"}},
+		{
+			"flag", []string{"test", "--unordered=false", "--concurrency=2", "--exclude=*flaky*"}, fromFile,
+			false, 2, []string{"*flaky*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				unordered   bool
+				concurrency int
+				exclude     []string
+			)
+
+			cmd := &cli.Command{
+				Name: "test",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "unordered"},
+					&cli.IntFlag{Name: "concurrency", Value: 1},
+					&cli.StringSliceFlag{Name: "exclude"},
+				},
+				Action: func(_ context.Context, cmd *cli.Command) error {
+					unordered = boolOption(cmd, "unordered", tt.cfg.Unordered)
+					concurrency = intOption(cmd, "concurrency", tt.cfg.Concurrency)
+					exclude = stringSliceOption(cmd, "exclude", tt.cfg.Exclude)
+
+					return nil
+				},
+			}
+
+			if err := cmd.Run(context.Background(), tt.args); err != nil {
+				t.Fatalf("Run(%v) error: %v", tt.args, err)
+			}
+
+			if unordered != tt.wantUnordered || concurrency != tt.wantConcurrency || !slices.Equal(exclude, tt.wantExclude) {
+				t.Errorf("unordered, concurrency, exclude = %v, %d, %v; want %v, %d, %v",
+					unordered, concurrency, exclude, tt.wantUnordered, tt.wantConcurrency, tt.wantExclude)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Malformed(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".scaf.yaml"), []byte("neo4j: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadConfig(dir); err == nil || !strings.Contains(err.Error(), ".scaf.yaml") {
+		t.Errorf("loadConfig() error = %v, want an error naming .scaf.yaml", err)
+	}
+
+	// A missing config falls back to defaults.
+	cfg, err := loadConfig(t.TempDir())
+	if err != nil || cfg.DatabaseName() != "" {
+		t.Errorf("loadConfig() without a config = %+v, %v; want an empty config", cfg, err)
+	}
+}
+
+func TestFmt_ConfigFromWorkingDirectory(t *testing.T) {
+	// The config is found from the working directory, not from the files
+	// being formatted, so run from a subdirectory of the project.
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, ".scaf.yaml"), []byte("fmt:\n  compact: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(project, "sub")
+	if err := os.Mkdir(sub, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(sub)
+
+	compact, err := scaf.FormatSourceWithOptions([]byte(unformattedSource), scaf.FormatOptions{CompactSingleStatement: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"file", nil, string(compact)},
+		{"flag", []string{"--compact=false"}, formattedSource},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, _ := writeScaf(t, t.TempDir(), "t.scaf", unformattedSource)
+
+			cmd := &cli.Command{Commands: []*cli.Command{fmtCommand()}}
+
+			args := append(append([]string{"scaf", "fmt", "-w"}, tt.args...), path)
+			if err := cmd.Run(context.Background(), args); err != nil {
+				t.Fatalf("Run(%v) error: %v", args, err)
+			}
+
+			if got := readFile(t, path); got != tt.want {
+				t.Errorf("formatted = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rlch/scaf/module"
+	"github.com/rlch/scaf/runner"
+	"github.com/urfave/cli/v3"
+)
+
+var ErrExplainUsage = errors.New("usage: scaf explain <scope/group/test> <file>")
+
+func explainCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "explain",
+		Usage:     "Describe how a single test would run, without running it",
+		ArgsUsage: "<scope/group/test> <file>",
+		Action:    runExplain,
+	}
+}
+
+func runExplain(_ context.Context, cmd *cli.Command) error {
+	args := cmd.Args().Slice()
+	if len(args) != 2 {
+		return ErrExplainUsage
+	}
+
+	return explainTest(os.Stdout, args[0], args[1])
+}
+
+// explainTest resolves the test at testPath (slash-separated) in file and writes its plan to w.
+func explainTest(w io.Writer, testPath, file string) error {
+	absPath, err := filepath.Abs(file)
+	if err != nil {
+		return fmt.Errorf("resolving path %s: %w", file, err)
+	}
+
+	resolver := module.NewResolver(module.NewLoader())
+
+	resolved, err := resolver.Resolve(absPath)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", file, err)
+	}
+
+	r := runner.New(runner.WithModules(resolved))
+
+	plan, err := r.Explain(resolved.Root.Suite, strings.Split(testPath, "/"))
+	if err != nil {
+		return err
+	}
+
+	return plan.Write(w)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rlch/scaf/runner"
+)
+
+func TestExplainTest_Golden(t *testing.T) {
+	var buf bytes.Buffer
+
+	inputFile := filepath.Join("testdata", "explain", "users.scaf")
+	if err := explainTest(&buf, "GetUser/edge cases/handles null", inputFile); err != nil {
+		t.Fatalf("explainTest() error: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "explain", "handles_null.golden")
+
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil { //nolint:gosec // G306: test fixture
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+
+	if got := buf.String(); got != string(want) {
+		t.Errorf("explain output does not match %s (run with -update to refresh):\n%s", golden, got)
+	}
+}
+
+func TestExplainTest_NotFound(t *testing.T) {
+	inputFile := filepath.Join("testdata", "explain", "users.scaf")
+
+	err := explainTest(&bytes.Buffer{}, "GetUser/missing", inputFile)
+	if !errors.Is(err, runner.ErrTestNotFound) {
+		t.Errorf("explainTest() error = %v, want ErrTestNotFound", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -8,22 +9,34 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/rlch/scaf"
 	"github.com/urfave/cli/v3"
 )
 
-var errNoScafFiles = errors.New("no .scaf files found")
+var (
+	errNoScafFiles = errors.New("no .scaf files found")
+	errWriteStdin  = errors.New("cannot use --write with standard input")
+	errStdinMixed  = errors.New(`"-" (standard input) cannot be combined with other arguments`)
+)
+
+const (
+	filePermissions = 0o600
 
-const filePermissions = 0o600
+	// stdinArg is the argument naming standard input, as in gofmt.
+	stdinArg  = "-"
+	stdinName = "<standard input>"
+)
 
 func fmtCommand() *cli.Command {
 	return &cli.Command{
 		Name:      "fmt",
 		Aliases:   []string{"format"},
 		Usage:     "Format scaf files",
-		ArgsUsage: "[files...]",
+		ArgsUsage: "[files... | -]",
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:    "write",
@@ -40,50 +53,97 @@ func fmtCommand() *cli.Command {
 				Aliases: []string{"d"},
 				Usage:   "display diffs instead of rewriting files",
 			},
+			&cli.BoolFlag{
+				Name:  "compact",
+				Usage: "keep tests with a single statement on one line (default from fmt.compact in .scaf.yaml)",
+			},
+			&cli.BoolFlag{
+				Name:  "sort",
+				Usage: "sort queries and scopes by name (default from fmt.sort in .scaf.yaml)",
+			},
+			&cli.IntFlag{
+				Name:  "map-width",
+				Usage: "write maps wider than this one entry per line, 0 for never (default from fmt.map-width in .scaf.yaml)",
+			},
 		},
 		Action: runFmt,
 	}
 }
 
 func runFmt(_ context.Context, cmd *cli.Command) error {
-	write := cmd.Bool("write")
-	check := cmd.Bool("check")
-	diff := cmd.Bool("diff")
 	args := cmd.Args().Slice()
 
-	if len(args) == 0 {
-		// Read from stdin
-		return formatStdin(os.Stdout)
-	}
-
-	// Collect all files to format
-	files, err := collectFiles(args)
+	cfg, err := loadWorkingConfig()
 	if err != nil {
-		return err
+		return fmt.Errorf("loading config: %w", err)
 	}
 
-	if len(files) == 0 {
-		return errNoScafFiles
-	}
+	opts := cfg.FormatOptions()
+	opts.CompactSingleStatement = boolOption(cmd, "compact", opts.CompactSingleStatement)
+	opts.SortDeclarations = boolOption(cmd, "sort", opts.SortDeclarations)
+	opts.MaxInlineMapWidth = intOption(cmd, "map-width", opts.MaxInlineMapWidth)
 
+	return formatArgs(args, cmd.Bool("write"), cmd.Bool("check"), cmd.Bool("diff"), opts,
+		os.Stdin, os.Stdout, os.Stderr)
+}
+
+// formatArgs formats files and directories following gofmt conventions:
+//
+//	scaf fmt file.scaf     print the formatted file to out
+//	scaf fmt -w file.scaf  rewrite the file in place, only if formatting changes it
+//	scaf fmt - (or none)   read in, write the formatted result to out
+//
+// A file that fails to parse is never written.
+func formatArgs(
+	args []string, write, check, showDiff bool, opts scaf.FormatOptions, in io.Reader, out, errOut io.Writer,
+) error {
 	var unformatted []string
 
-	for _, file := range files {
-		changed, err := formatFile(file, write, diff, os.Stdout)
+	if len(args) == 0 || (len(args) == 1 && args[0] == stdinArg) {
+		if write {
+			return errWriteStdin
+		}
+
+		changed, err := formatStdin(in, out, check, showDiff, opts)
 		if err != nil {
-			return fmt.Errorf("%s: %w", file, err)
+			return err
 		}
 
 		if changed {
-			unformatted = append(unformatted, file)
+			unformatted = append(unformatted, stdinName)
+		}
+	} else {
+		if slices.Contains(args, stdinArg) {
+			return errStdinMixed
+		}
+
+		// Collect all files to format
+		files, err := collectFiles(args)
+		if err != nil {
+			return err
+		}
+
+		if len(files) == 0 {
+			return errNoScafFiles
+		}
+
+		for _, file := range files {
+			changed, err := formatFile(file, write, check, showDiff, opts, out)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+
+			if changed {
+				unformatted = append(unformatted, file)
+			}
 		}
 	}
 
 	if check && len(unformatted) > 0 {
-		_, _ = fmt.Fprintf(os.Stderr, "The following files are not formatted:\n")
+		_, _ = fmt.Fprintf(errOut, "The following files are not formatted:\n")
 
 		for _, f := range unformatted {
-			_, _ = fmt.Fprintf(os.Stderr, "  %s\n", f)
+			_, _ = fmt.Fprintf(errOut, "  %s\n", f)
 		}
 
 		return cli.Exit("", 1)
@@ -125,43 +185,42 @@ func collectFiles(args []string) ([]string, error) {
 	return files, nil
 }
 
-func formatStdin(out io.Writer) error {
-	data, err := io.ReadAll(os.Stdin)
+func formatStdin(in io.Reader, out io.Writer, check, showDiff bool, opts scaf.FormatOptions) (bool, error) {
+	data, err := io.ReadAll(in)
 	if err != nil {
-		return fmt.Errorf("reading stdin: %w", err)
+		return false, fmt.Errorf("reading stdin: %w", err)
 	}
 
-	suite, err := scaf.Parse(data)
+	formatted, err := scaf.FormatSourceWithOptions(data, opts)
 	if err != nil {
-		return fmt.Errorf("parsing: %w", err)
+		return false, fmt.Errorf("%s: %w", stdinName, err)
 	}
 
-	formatted := scaf.Format(suite)
-	_, err = out.Write([]byte(formatted))
+	changed := !bytes.Equal(data, formatted)
 
-	return err
+	return changed, emitFormatted(out, stdinName, string(data), string(formatted), check, showDiff)
 }
 
-func formatFile(path string, write, showDiff bool, out io.Writer) (bool, error) {
+func formatFile(path string, write, check, showDiff bool, opts scaf.FormatOptions, out io.Writer) (bool, error) {
 	data, err := os.ReadFile(path) //#nosec G304 -- paths come from user args
 	if err != nil {
 		return false, err
 	}
 
-	suite, err := scaf.Parse(data)
+	formatted, err := scaf.FormatSourceWithOptions(data, opts)
 	if err != nil {
 		return false, err
 	}
 
-	formatted := scaf.Format(suite)
-	changed := string(data) != formatted
-
-	if !changed {
-		return false, nil
-	}
+	changed := !bytes.Equal(data, formatted)
 
 	if write {
-		writeErr := os.WriteFile(path, []byte(formatted), filePermissions)
+		// Leave formatted files untouched so their mtimes survive.
+		if !changed {
+			return false, nil
+		}
+
+		writeErr := os.WriteFile(path, formatted, filePermissions)
 		if writeErr != nil {
 			return true, writeErr
 		}
@@ -171,48 +230,47 @@ func formatFile(path string, write, showDiff bool, out io.Writer) (bool, error)
 		return true, nil
 	}
 
-	if showDiff {
-		printDiff(out, path, string(data), formatted)
+	return changed, emitFormatted(out, path, string(data), string(formatted), check, showDiff)
+}
 
-		return true, nil
-	}
+// emitFormatted writes the result of formatting without --write: a diff if requested,
+// nothing when only checking, and otherwise the formatted source, changed or not.
+func emitFormatted(out io.Writer, name, original, formatted string, check, showDiff bool) error {
+	switch {
+	case showDiff:
+		if original != formatted {
+			printDiff(out, name, original, formatted)
+		}
 
-	// Default: print formatted output
-	_, err = out.Write([]byte(formatted))
+		return nil
+	case check:
+		return nil
+	default:
+		_, err := io.WriteString(out, formatted)
 
-	return true, err
+		return err
+	}
 }
 
+// printDiff writes a unified diff from original to formatted, as gofmt -d does.
 func printDiff(out io.Writer, path, original, formatted string) {
 	_, _ = fmt.Fprintf(out, "diff %s\n", path)
-	_, _ = fmt.Fprintf(out, "--- %s\n", path)
-	_, _ = fmt.Fprintf(out, "+++ %s\n", path)
 
-	origLines := strings.Split(original, "\n")
-	fmtLines := strings.Split(formatted, "\n")
-
-	// Simple line-by-line diff
-	maxLines := max(len(origLines), len(fmtLines))
-
-	for i := range maxLines {
-		var origLine, fmtLine string
-
-		if i < len(origLines) {
-			origLine = origLines[i]
-		}
-
-		if i < len(fmtLines) {
-			fmtLine = fmtLines[i]
-		}
-
-		if origLine != fmtLine {
-			if origLine != "" {
-				_, _ = fmt.Fprintf(out, "-%s\n", origLine)
-			}
+	_ = difflib.WriteUnifiedDiff(out, difflib.UnifiedDiff{
+		A:        diffLines(original),
+		B:        diffLines(formatted),
+		FromFile: path + ".orig",
+		ToFile:   path,
+		Context:  3,
+	})
+}
 
-			if fmtLine != "" {
-				_, _ = fmt.Fprintf(out, "+%s\n", fmtLine)
-			}
-		}
+// diffLines splits text into lines that keep their newlines.
+func diffLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
 	}
+
+	return lines
 }
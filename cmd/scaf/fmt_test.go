@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rlch/scaf"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	unformattedSource = "query Q `Q`\nQ {\ntest \"t\" {\n$id: 1\n}\n}\n"
+	formattedSource   = "query Q `Q`\n\nQ {\n\ttest \"t\" {\n\t\t$id: 1\n\t}\n}\n"
+)
+
+// writeScaf writes a .scaf file into dir with an mtime in the past.
+func writeScaf(t *testing.T, dir, name, content string) (string, time.Time) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	return path, mtime
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path) //nolint:gosec // G304: test fixture
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestFmt_PrintsToStdout(t *testing.T) {
+	dir := t.TempDir()
+	messy, _ := writeScaf(t, dir, "messy.scaf", unformattedSource)
+	clean, _ := writeScaf(t, dir, "clean.scaf", formattedSource)
+
+	var out bytes.Buffer
+	if err := formatArgs([]string{messy, clean}, false, false, false, scaf.FormatOptions{}, nil, &out, &bytes.Buffer{}); err != nil {
+		t.Fatalf("formatArgs() error: %v", err)
+	}
+
+	// Every file is printed, formatted or not, and nothing is rewritten.
+	if got, want := out.String(), formattedSource+formattedSource; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	if got := readFile(t, messy); got != unformattedSource {
+		t.Errorf("file was modified without --write: %q", got)
+	}
+}
+
+func TestFmt_WriteInPlace(t *testing.T) {
+	dir := t.TempDir()
+	messy, _ := writeScaf(t, dir, "messy.scaf", unformattedSource)
+	clean, cleanMtime := writeScaf(t, dir, "clean.scaf", formattedSource)
+
+	var out bytes.Buffer
+	if err := formatArgs([]string{dir}, true, false, false, scaf.FormatOptions{}, nil, &out, &bytes.Buffer{}); err != nil {
+		t.Fatalf("formatArgs() error: %v", err)
+	}
+
+	if got := readFile(t, messy); got != formattedSource {
+		t.Errorf("rewritten file = %q, want %q", got, formattedSource)
+	}
+
+	// Only changed files are reported and rewritten.
+	if got := out.String(); got != messy+"\n" {
+		t.Errorf("output = %q, want only %q", got, messy)
+	}
+
+	info, err := os.Stat(clean)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.ModTime().Equal(cleanMtime) {
+		t.Errorf("formatted file mtime changed from %v to %v", cleanMtime, info.ModTime())
+	}
+}
+
+func TestFmt_WriteSkipsParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	broken, _ := writeScaf(t, dir, "broken.scaf", "query Q `Q`\nQ {\ntest \"t\" {\n")
+
+	err := formatArgs([]string{broken}, true, false, false, scaf.FormatOptions{}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), broken) {
+		t.Errorf("formatArgs() error = %v, want parse error naming %s", err, broken)
+	}
+
+	if got := readFile(t, broken); got != "query Q `Q`\nQ {\ntest \"t\" {\n" {
+		t.Errorf("unparseable file was written: %q", got)
+	}
+}
+
+func TestFmt_Stdin(t *testing.T) {
+	for _, args := range [][]string{nil, {"-"}} {
+		var out bytes.Buffer
+
+		in := strings.NewReader(unformattedSource)
+		if err := formatArgs(args, false, false, false, scaf.FormatOptions{}, in, &out, &bytes.Buffer{}); err != nil {
+			t.Fatalf("formatArgs(%q) error: %v", args, err)
+		}
+
+		if got := out.String(); got != formattedSource {
+			t.Errorf("formatArgs(%q) output = %q, want %q", args, got, formattedSource)
+		}
+	}
+}
+
+func TestFmt_StdinErrors(t *testing.T) {
+	var out bytes.Buffer
+
+	// Like files, input that fails to parse is reported by name and position,
+	// with nothing written.
+	broken := strings.NewReader("query Q `Q`\nQ {\ntest \"t\" {\n")
+
+	err := formatArgs([]string{"-"}, false, false, false, scaf.FormatOptions{}, broken, &out, &bytes.Buffer{})
+
+	var parseErr *scaf.ParseError
+	if !errors.As(err, &parseErr) || !strings.HasPrefix(err.Error(), stdinName+": 4:1: ") {
+		t.Errorf("unparseable stdin error = %v, want a parse error naming %s", err, stdinName)
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("unparseable stdin printed %q", out.String())
+	}
+
+	in := strings.NewReader(unformattedSource)
+
+	if err := formatArgs([]string{"-"}, true, false, false, scaf.FormatOptions{}, in, &bytes.Buffer{}, &bytes.Buffer{}); !errors.Is(err, errWriteStdin) {
+		t.Errorf("--write with stdin error = %v, want %v", err, errWriteStdin)
+	}
+
+	err = formatArgs([]string{"-", "other.scaf"}, false, false, false, scaf.FormatOptions{}, in, &bytes.Buffer{}, &bytes.Buffer{})
+	if !errors.Is(err, errStdinMixed) {
+		t.Errorf("stdin mixed with files error = %v, want %v", err, errStdinMixed)
+	}
+}
+
+func TestFmt_CheckStdin(t *testing.T) {
+	var out, errOut bytes.Buffer
+
+	err := formatArgs([]string{"-"}, false, true, false, scaf.FormatOptions{}, strings.NewReader(unformattedSource), &out, &errOut)
+
+	var exitErr cli.ExitCoder
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("formatArgs() error = %v, want exit code 1", err)
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("--check printed formatted output: %q", out.String())
+	}
+
+	if !strings.Contains(errOut.String(), stdinName) {
+		t.Errorf("--check report = %q, want it to name %s", errOut.String(), stdinName)
+	}
+}
+
+func TestFmt_CheckDiff(t *testing.T) {
+	dir := t.TempDir()
+	messy, _ := writeScaf(t, dir, "messy.scaf", unformattedSource)
+	clean, _ := writeScaf(t, dir, "clean.scaf", formattedSource)
+
+	var out, errOut bytes.Buffer
+
+	err := formatArgs([]string{messy, clean}, false, true, true, scaf.FormatOptions{}, nil, &out, &errOut)
+
+	var exitErr cli.ExitCoder
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("formatArgs() error = %v, want exit code 1", err)
+	}
+
+	want := "diff " + messy + "\n" +
+		"--- " + messy + ".orig\n" +
+		"+++ " + messy + "\n" +
+		"@@ -1,6 +1,7 @@\n" +
+		" query Q `Q`\n" +
+		"+\n" +
+		" Q {\n" +
+		"-test \"t\" {\n" +
+		"-$id: 1\n" +
+		"+\ttest \"t\" {\n" +
+		"+\t\t$id: 1\n" +
+		"+\t}\n" +
+		" }\n" +
+		"-}\n"
+	if got := out.String(); got != want {
+		t.Errorf("--check --diff output =\n%s\nwant:\n%s", got, want)
+	}
+
+	// Only the unformatted file is listed, and neither is rewritten.
+	if report := errOut.String(); !strings.Contains(report, messy) || strings.Contains(report, clean) {
+		t.Errorf("--check report = %q, want only %s", report, messy)
+	}
+
+	if got := readFile(t, messy); got != unformattedSource {
+		t.Errorf("--check rewrote the file: %q", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/rlch/scaf"
@@ -19,7 +20,10 @@ import (
 	_ "github.com/rlch/scaf/adapters/neogo"
 )
 
-var ErrNoScafFilesForGenerate = errors.New("no .scaf files found")
+var (
+	ErrNoScafFilesForGenerate = errors.New("no .scaf files found")
+	ErrOutputCollision        = errors.New("output file collision")
+)
 
 func generateCommand() *cli.Command {
 	return &cli.Command{
@@ -46,7 +50,7 @@ func generateCommand() *cli.Command {
 			},
 			&cli.StringFlag{
 				Name:    "out",
-				Aliases: []string{"o"},
+				Aliases: []string{"o", "output-dir"},
 				Usage:   "output directory (default: same as input file)",
 			},
 			&cli.StringFlag{
@@ -187,9 +191,11 @@ func runGenerate(ctx context.Context, cmd *cli.Command) error {
 		packageName: packageName,
 	}
 
-	// Process each file
+	// Process each file, refusing to let two inputs write the same output
+	written := make(map[string]string)
+
 	for _, inputFile := range files {
-		if err := generateFile(inputFile, opts); err != nil {
+		if err := generateFile(inputFile, opts, written); err != nil {
 			return err
 		}
 	}
@@ -207,7 +213,9 @@ type generateOptions struct {
 	packageName string
 }
 
-func generateFile(inputFile string, opts *generateOptions) error {
+// generateFile generates code for a single input file.
+// written maps output paths to the input that produced them, to detect collisions.
+func generateFile(inputFile string, opts *generateOptions, written map[string]string) error {
 	// Read and parse the scaf file
 	data, err := os.ReadFile(inputFile) //nolint:gosec // G304: file path from user input is expected
 	if err != nil {
@@ -243,6 +251,7 @@ func generateFile(inputFile string, opts *generateOptions) error {
 			QueryAnalyzer: opts.analyzer,
 			Schema:        opts.schema,
 			OutputDir:     outputDir,
+			SourceName:    filepath.Base(inputFile),
 		},
 		PackageName: packageName,
 		Binding:     opts.binding,
@@ -253,13 +262,26 @@ func generateFile(inputFile string, opts *generateOptions) error {
 		return fmt.Errorf("generating code for %s: %w", inputFile, err)
 	}
 
-	// Write output files
-	for filename, content := range files {
+	// Write output files in a stable order
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		content := files[filename]
 		if content == nil {
 			continue
 		}
 
-		outPath := filepath.Join(outputDir, filename)
+		outPath := filepath.Join(outputDir, outputFileName(inputFile, filename))
+
+		if prev, ok := written[outPath]; ok {
+			return fmt.Errorf("%w: %s and %s both generate %s", ErrOutputCollision, prev, inputFile, outPath)
+		}
+
+		written[outPath] = inputFile
 
 		err := os.WriteFile(outPath, content, 0o644) //nolint:gosec // G306: output file permissions are fine
 		if err != nil {
@@ -272,6 +294,14 @@ func generateFile(inputFile string, opts *generateOptions) error {
 	return nil
 }
 
+// outputFileName prefixes a generated filename with the input's base name,
+// so users.scaf produces users_scaf.go and users_scaf_test.go.
+func outputFileName(inputFile, filename string) string {
+	base := strings.TrimSuffix(filepath.Base(inputFile), ".scaf")
+
+	return base + "_" + filename
+}
+
 func collectScafFiles(args []string) ([]string, error) {
 	var files []string
 
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rlch/scaf"
+	golang "github.com/rlch/scaf/language/go"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerateFile_Golden(t *testing.T) {
+	inputFile := filepath.Join("testdata", "generate", "users.scaf")
+
+	opts := &generateOptions{
+		goLang:      golang.New(),
+		analyzer:    scaf.GetAnalyzer(scaf.DialectCypher),
+		binding:     golang.GetBinding(scaf.AdapterNeogo),
+		packageName: "users",
+	}
+
+	generate := func() map[string][]byte {
+		opts.outputDir = t.TempDir()
+
+		if err := generateFile(inputFile, opts, make(map[string]string)); err != nil {
+			t.Fatalf("generateFile() error: %v", err)
+		}
+
+		out := make(map[string][]byte)
+		for _, name := range []string{"users_scaf.go", "users_scaf_test.go"} {
+			data, err := os.ReadFile(filepath.Join(opts.outputDir, name))
+			if err != nil {
+				t.Fatalf("expected output %s: %v", name, err)
+			}
+
+			out[name] = data
+		}
+
+		return out
+	}
+
+	first := generate()
+
+	for name, got := range first {
+		golden := filepath.Join("testdata", "generate", name+".golden")
+
+		if *update {
+			if err := os.WriteFile(golden, got, 0o644); err != nil { //nolint:gosec // G306: test fixture
+				t.Fatal(err)
+			}
+
+			continue
+		}
+
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("reading golden file: %v", err)
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s does not match %s (run with -update to refresh):\n%s", name, golden, got)
+		}
+	}
+
+	// Re-running must produce byte-identical output.
+	for name, got := range generate() {
+		if !bytes.Equal(got, first[name]) {
+			t.Errorf("%s differs between runs", name)
+		}
+	}
+}
+
+func TestGenerateFile_Collision(t *testing.T) {
+	outputDir := t.TempDir()
+	inputFile := filepath.Join("testdata", "generate", "users.scaf")
+
+	opts := &generateOptions{
+		goLang:      golang.New(),
+		analyzer:    scaf.GetAnalyzer(scaf.DialectCypher),
+		binding:     golang.GetBinding(scaf.AdapterNeogo),
+		outputDir:   outputDir,
+		packageName: "users",
+	}
+
+	written := make(map[string]string)
+	if err := generateFile(inputFile, opts, written); err != nil {
+		t.Fatalf("generateFile() error: %v", err)
+	}
+
+	// A second input with the same base name would clobber the first.
+	err := generateFile(inputFile, opts, written)
+	if !errors.Is(err, ErrOutputCollision) {
+		t.Fatalf("generateFile() error = %v, want ErrOutputCollision", err)
+	}
+}
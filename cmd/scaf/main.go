@@ -23,6 +23,8 @@ func main() {
 			fmtCommand(),
 			testCommand(),
 			generateCommand(),
+			explainCommand(),
+			schemaCommand(),
 		},
 	}
 
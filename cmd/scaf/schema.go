@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/alecthomas/participle/v2/lexer"
+	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/analysis"
+	"github.com/rlch/scaf/runner"
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	errNoSchema            = errors.New("no schema: pass --schema or set generate.schema in .scaf.yaml")
+	errInvalidSchemaFormat = errors.New("--format expects github")
+)
+
+func schemaCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "schema",
+		Usage: "Work with type schemas",
+		Commands: []*cli.Command{
+			{
+				Name:      "validate",
+				Usage:     "Check scaf files against a type schema (exit 1 on violations)",
+				ArgsUsage: "[files...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "schema",
+						Aliases: []string{"s"},
+						Usage:   "path to schema file (default: generate.schema from .scaf.yaml)",
+					},
+					&cli.StringFlag{
+						Name:    "dialect",
+						Aliases: []string{"d"},
+						Usage:   "query dialect (default: from .scaf.yaml, or cypher)",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "output violations as `FORMAT`: github for GitHub Actions annotations",
+						Validator: func(s string) error {
+							if s != "github" {
+								return errInvalidSchemaFormat
+							}
+
+							return nil
+						},
+					},
+				},
+				Action: runSchemaValidate,
+			},
+		},
+	}
+}
+
+func runSchemaValidate(_ context.Context, cmd *cli.Command) error {
+	args := cmd.Args().Slice()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	files, err := collectFiles(args)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return errNoScafFiles
+	}
+
+	schemaPath, baseDir := cmd.String("schema"), ""
+	dialectName := cmd.String("dialect")
+
+	// Fall back to the config nearest the first file.
+	if cfg, err := scaf.LoadConfig(filepath.Dir(files[0])); err == nil {
+		if schemaPath == "" && cfg.Generate.Schema != "" {
+			schemaPath, baseDir = cfg.Generate.Schema, filepath.Dir(files[0])
+		}
+
+		if dialectName == "" {
+			dialectName = cfg.DialectName()
+		}
+	}
+
+	if schemaPath == "" {
+		return errNoSchema
+	}
+
+	if dialectName == "" {
+		dialectName = scaf.DialectCypher
+	}
+
+	schema, err := analysis.LoadSchema(schemaPath, baseDir)
+	if err != nil {
+		return fmt.Errorf("loading schema: %w", err)
+	}
+
+	return validateSchema(files, schema, scaf.GetAnalyzer(dialectName), cmd.String("format"), os.Stdout)
+}
+
+// validateSchema runs the schema rules over files, printing each violation
+// (and any parse error) to out, as GitHub Actions annotations if format is
+// "github". Returns exit code 1 if there were any.
+func validateSchema(files []string, schema *analysis.TypeSchema, qa scaf.QueryAnalyzer, format string, out io.Writer) error {
+	analyzer := analysis.NewAnalyzerWithRules(nil, analysis.SchemaRules())
+	analyzer.SetSchema(schema)
+
+	if qa != nil {
+		analyzer.SetQueryAnalyzer(qa)
+	}
+
+	violations := 0
+
+	for _, file := range files {
+		data, err := os.ReadFile(file) //#nosec G304 -- paths come from user args
+		if err != nil {
+			return err
+		}
+
+		result := analyzer.Analyze(file, data)
+		if result.ParseError != nil {
+			if format == "github" {
+				var (
+					pos      *runner.Position
+					message  = result.ParseError.Error()
+					parseErr *scaf.ParseError
+				)
+
+				if errors.As(result.ParseError, &parseErr) {
+					pos, message = githubPosition(parseErr.Span.Start), parseErr.Message
+				}
+
+				_ = runner.WriteGitHubAnnotation(out, "error", file, pos, "parse error", message)
+			} else {
+				_, _ = fmt.Fprintf(out, "%s:%v\n", file, result.ParseError)
+			}
+
+			violations++
+
+			continue
+		}
+
+		diags := result.Diagnostics
+		sort.SliceStable(diags, func(i, j int) bool {
+			a, b := diags[i].Span.Start, diags[j].Span.Start
+
+			return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+		})
+
+		for _, d := range diags {
+			if format == "github" {
+				pos := githubPosition(d.Span.Start)
+				_ = runner.WriteGitHubAnnotation(out, githubLevel(d.Severity), file, pos, d.Code, d.Message)
+			} else {
+				_, _ = fmt.Fprintf(out, "%s:%v\n", file, d.Err())
+			}
+		}
+
+		violations += len(diags)
+	}
+
+	if violations > 0 {
+		return cli.Exit(fmt.Sprintf("%d schema violation(s)", violations), 1)
+	}
+
+	return nil
+}
+
+// githubPosition converts a 1-indexed source position to the runner's 0-indexed
+// one, or nil if the position is unknown.
+func githubPosition(pos lexer.Position) *runner.Position {
+	if pos.Line == 0 {
+		return nil
+	}
+
+	return &runner.Position{Line: pos.Line - 1, Column: pos.Column - 1}
+}
+
+// githubLevel maps a diagnostic severity to a workflow command.
+func githubLevel(severity analysis.DiagnosticSeverity) string {
+	switch severity {
+	case analysis.SeverityError:
+		return "error"
+	case analysis.SeverityWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/analysis"
+	"github.com/urfave/cli/v3"
+)
+
+func TestValidateSchema(t *testing.T) {
+	dir := filepath.Join("testdata", "schema")
+
+	schema, err := analysis.LoadSchema(".scaf-schema.json", dir)
+	if err != nil {
+		t.Fatalf("LoadSchema() error: %v", err)
+	}
+
+	qa := scaf.GetAnalyzer(scaf.DialectCypher)
+
+	var out bytes.Buffer
+	if err := validateSchema([]string{filepath.Join(dir, "valid.scaf")}, schema, qa, "", &out); err != nil {
+		t.Fatalf("validateSchema(valid.scaf) error: %v\n%s", err, out.String())
+	}
+
+	drift := filepath.Join(dir, "drift.scaf")
+
+	out.Reset()
+
+	err = validateSchema([]string{drift}, schema, qa, "", &out)
+
+	var exitErr cli.ExitCoder
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("validateSchema(drift.scaf) error = %v, want exit code 1", err)
+	}
+
+	want := []string{
+		drift + ":4:2: unknown property: User has no field email [unknown-property]",
+		drift + ":4:50: enum violation: User.role must be one of admin, member [enum-violation]",
+		drift + ":4:67: type mismatch: User.id is int [type-mismatch]",
+		drift + ":5:2: unknown label: Usr is not a model in the schema (did you mean User?) [unknown-label]",
+		drift + ":11:9: number for string field: User.name is a string, so this number never matches; quote it [number-for-string-field]",
+		drift + ":12:9: enum violation: User.role must be one of admin, member [enum-violation]",
+	}
+
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("output:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateSchema_GitHub(t *testing.T) {
+	dir := filepath.Join("testdata", "schema")
+
+	schema, err := analysis.LoadSchema(".scaf-schema.json", dir)
+	if err != nil {
+		t.Fatalf("LoadSchema() error: %v", err)
+	}
+
+	drift := filepath.Join(dir, "drift.scaf")
+
+	var out bytes.Buffer
+
+	err = validateSchema([]string{drift}, schema, scaf.GetAnalyzer(scaf.DialectCypher), "github", &out)
+
+	var exitErr cli.ExitCoder
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("validateSchema(drift.scaf) error = %v, want exit code 1", err)
+	}
+
+	// The same violations as TestValidateSchema, at the same lines and columns,
+	// annotated at their severity.
+	got := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(got) != 6 {
+		t.Fatalf("output:\n%s\nwant 6 annotations", out.String())
+	}
+
+	want := "::warning file=" + drift + ",line=4,col=2,title=unknown-property::unknown property: User has no field email"
+	if got[0] != want {
+		t.Errorf("first annotation = %q, want %q", got[0], want)
+	}
+
+	want = "::error file=" + drift + ",line=11,col=9,title=number-for-string-field::"
+	if !strings.HasPrefix(got[4], want) {
+		t.Errorf("fifth annotation = %q, want prefix %q", got[4], want)
+	}
+}
+
+func TestSchemaValidateCommand(t *testing.T) {
+	// Violations exit the process, so only the passing and misconfigured cases run here.
+	cmd := &cli.Command{Commands: []*cli.Command{schemaCommand()}}
+
+	dir := filepath.Join("testdata", "schema")
+	args := []string{"scaf", "schema", "validate", "--schema", filepath.Join(dir, ".scaf-schema.json")}
+
+	if err := cmd.Run(context.Background(), append(args, filepath.Join(dir, "valid.scaf"))); err != nil {
+		t.Errorf("validate valid.scaf error: %v", err)
+	}
+
+	if err := cmd.Run(context.Background(), []string{"scaf", "schema", "validate", filepath.Join(dir, "valid.scaf")}); !errors.Is(err, errNoSchema) {
+		t.Errorf("validate without a schema error = %v, want %v", err, errNoSchema)
+	}
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/rlch/scaf"
@@ -20,6 +24,11 @@ var (
 	ErrNoScafFiles     = errors.New("no .scaf files found")
 	ErrNoDatabase      = errors.New("no database specified (use neo4j config in .scaf.yaml)")
 	ErrNoConnectionURI = errors.New("no connection URI specified (use --uri or .scaf.yaml)")
+	ErrInvalidBail     = errors.New("--bail expects a non-negative number of failures")
+	ErrInvalidBench    = errors.New("--bench and --bench-warmup expect non-negative iteration counts")
+	ErrConcurrentState = errors.New("--concurrency needs per-test rollback and cannot be combined with --no-teardown")
+	ErrInvalidRepeat   = errors.New("--repeat expects a positive number of runs")
+	ErrInvalidFormat   = errors.New("--format expects json or github")
 )
 
 func testCommand() *cli.Command {
@@ -52,7 +61,27 @@ func testCommand() *cli.Command {
 			},
 			&cli.BoolFlag{
 				Name:  "json",
-				Usage: "output results as JSON",
+				Usage: "output results as JSON (same as --format json)",
+			},
+			&cli.StringFlag{
+				Name: "format",
+				Usage: "output results as `FORMAT`: json, or github for GitHub Actions annotations" +
+					" (with the summary on stderr)",
+				Validator: func(s string) error {
+					if s != "json" && s != "github" {
+						return ErrInvalidFormat
+					}
+
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "junit-out",
+				Usage: "also write a JUnit XML report to `PATH`",
+			},
+			&cli.StringFlag{
+				Name:  "json-out",
+				Usage: "also write the JSON report to `PATH`",
 			},
 			&cli.BoolFlag{
 				Name:    "verbose",
@@ -63,10 +92,55 @@ func testCommand() *cli.Command {
 				Name:  "fail-fast",
 				Usage: "stop on first failure",
 			},
+			&cli.GenericFlag{
+				Name:  "bail",
+				Usage: "stop the whole run after N failures (--bail alone means 1)",
+				Value: &bailValue{},
+			},
 			&cli.StringFlag{
 				Name:  "run",
 				Usage: "run only tests matching pattern",
 			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "skip tests and groups whose path matches `glob` (repeatable; wins over --run)",
+			},
+			&cli.BoolFlag{
+				Name:  "list",
+				Usage: "print the paths of the tests that would run, without running them (as JSON with --json)",
+			},
+			&cli.BoolFlag{
+				Name:  "unordered",
+				Usage: "compare expected rows regardless of order",
+			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "run up to N query scopes at once; scopes with their own setup or teardown still run alone",
+				Value: 1,
+			},
+			&cli.IntFlag{
+				Name:  "repeat",
+				Usage: "run the selected tests N times (with setup and teardown each time) and report how often each failed",
+				Value: 1,
+			},
+			&cli.IntFlag{
+				Name:  "bench",
+				Usage: "after each passing test, time N more runs of its query and report latency (assertions run once)",
+			},
+			&cli.IntFlag{
+				Name:  "bench-warmup",
+				Usage: "untimed query runs before benchmarking each test",
+				Value: 1,
+			},
+			&cli.BoolFlag{
+				Name:  "profile",
+				Usage: "profile each test's main query and report its plan (db hits, rows); no-op if the dialect can't",
+			},
+			&cli.BoolFlag{
+				Name:    "no-teardown",
+				Aliases: []string{"keep-state"},
+				Usage:   "skip teardown and transaction rollback, leaving data in the database for debugging",
+			},
 			&cli.BoolFlag{
 				Name:   "lag",
 				Usage:  "add artificial lag (500ms-1.5s) for TUI testing",
@@ -77,6 +151,46 @@ func testCommand() *cli.Command {
 	}
 }
 
+// outputFormat returns the --format value, "json" if --json is set, or "" for
+// the default output.
+func outputFormat(cmd *cli.Command) string {
+	if cmd.Bool("json") {
+		return "json"
+	}
+
+	return cmd.String("format")
+}
+
+// bailValue is the --bail[=N] flag. It reports itself as a boolean flag so that
+// a bare --bail doesn't consume the next argument; "true" then means one failure.
+type bailValue struct {
+	n int
+}
+
+func (b *bailValue) Set(s string) error {
+	switch s {
+	case "true":
+		b.n = 1
+	case "false":
+		b.n = 0
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%w: %q", ErrInvalidBail, s)
+		}
+
+		b.n = n
+	}
+
+	return nil
+}
+
+func (b *bailValue) String() string { return strconv.Itoa(b.n) }
+
+func (b *bailValue) Get() any { return b.n }
+
+func (b *bailValue) IsBoolFlag() bool { return true }
+
 // parsedSuite holds a parsed suite with its source path and resolved modules.
 type parsedSuite struct {
 	suite    *scaf.Suite
@@ -101,14 +215,25 @@ func runTest(ctx context.Context, cmd *cli.Command) error {
 		return ErrNoScafFiles
 	}
 
-	// Load config
-	configDir := filepath.Dir(files[0])
-	loadedCfg, configErr := scaf.LoadConfig(configDir)
+	// Load config; flags take precedence over its defaults.
+	cfg, err := loadWorkingConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	if cmd.Bool("list") {
+		selector := runner.New(
+			runner.WithFilter(cmd.String("run")),
+			runner.WithExclude(stringSliceOption(cmd, "exclude", cfg.Test.Exclude)...),
+		)
+
+		return listTests(os.Stdout, files, selector, outputFormat(cmd) == "json")
+	}
 
 	// Determine database name (flag > config)
 	databaseName := cmd.String("database")
-	if databaseName == "" && configErr == nil {
-		databaseName = loadedCfg.DatabaseName()
+	if databaseName == "" {
+		databaseName = cfg.DatabaseName()
 	}
 
 	if databaseName == "" {
@@ -121,8 +246,8 @@ func runTest(ctx context.Context, cmd *cli.Command) error {
 	switch databaseName {
 	case scaf.DatabaseNeo4j:
 		neo4jCfg := &scaf.Neo4jConfig{}
-		if configErr == nil && loadedCfg.Neo4j != nil {
-			neo4jCfg = loadedCfg.Neo4j
+		if cfg.Neo4j != nil {
+			neo4jCfg = cfg.Neo4j
 		}
 		// Override with flags if provided
 		if uri := cmd.String("uri"); uri != "" {
@@ -174,6 +299,28 @@ func runTest(ctx context.Context, cmd *cli.Command) error {
 		})
 	}
 
+	benchIterations, benchWarmup := cmd.Int("bench"), cmd.Int("bench-warmup")
+	if benchIterations < 0 || benchWarmup < 0 {
+		return ErrInvalidBench
+	}
+
+	repeat := cmd.Int("repeat")
+	if repeat < 1 {
+		return ErrInvalidRepeat
+	}
+
+	concurrency := intOption(cmd, "concurrency", cfg.Test.Concurrency)
+
+	keepState := cmd.Bool("no-teardown")
+	if keepState && concurrency > 1 {
+		return ErrConcurrentState
+	}
+
+	if keepState {
+		fmt.Fprintln(os.Stderr, "WARNING: --no-teardown is set. Teardown is skipped and tests are not rolled back;"+
+			" all data written by this run will remain in the database.")
+	}
+
 	// Create database
 	database, err := scaf.NewDatabase(databaseName, dbCfg)
 	if err != nil {
@@ -186,10 +333,13 @@ func runTest(ctx context.Context, cmd *cli.Command) error {
 
 	var formatHandler runner.Handler
 
-	switch {
-	case cmd.Bool("json"):
+	switch format := outputFormat(cmd); {
+	case format == "json":
 		formatter := runner.NewJSONFormatter(os.Stdout)
 		formatHandler = runner.NewFormatHandler(formatter, os.Stderr)
+	case format == "github":
+		formatter := runner.NewGitHubFormatter(os.Stdout, os.Stderr)
+		formatHandler = runner.NewFormatHandler(formatter, os.Stderr)
 	case verbose:
 		formatter := runner.NewVerboseFormatter(os.Stdout)
 		formatHandler = runner.NewFormatHandler(formatter, os.Stderr)
@@ -212,29 +362,88 @@ func runTest(ctx context.Context, cmd *cli.Command) error {
 		formatHandler = tuiHandler
 	}
 
+	// Report files are buffered in memory and written once the run is over,
+	// so stdout keeps the human-readable output.
+	var reports []*reportFile
+
+	if path := cmd.String("junit-out"); path != "" {
+		reports = append(reports, newReportFile(path, func(w io.Writer) runner.Formatter {
+			return runner.NewJUnitFormatter(w)
+		}))
+	}
+
+	if path := cmd.String("json-out"); path != "" {
+		reports = append(reports, newReportFile(path, func(w io.Writer) runner.Formatter {
+			return runner.NewJSONFormatter(w)
+		}))
+	}
+
+	handler := formatHandler
+
+	if len(reports) > 0 {
+		handlers := []runner.Handler{formatHandler}
+		for _, report := range reports {
+			handlers = append(handlers, report.handler)
+		}
+
+		handler = runner.NewMultiHandler(handlers...)
+	}
+
+	// --bail counts failures across every file; --fail-fast is --bail=1.
+	bail, _ := cmd.Value("bail").(int)
+	if bail == 0 && boolOption(cmd, "fail-fast", cfg.Test.FailFast) {
+		bail = 1
+	}
+
 	// Run all test files
-	var totalResult *runner.Result
+	var (
+		totalResult *runner.Result
+		failures    int
+	)
 
 	for _, ps := range suites {
+		if bail > 0 && failures >= bail {
+			break
+		}
+
+		maxFailures := 0
+		if bail > 0 {
+			maxFailures = bail - failures
+		}
+
 		// Create runner with module context for this suite
 		suiteRunner := runner.New(
 			runner.WithDatabase(database),
-			runner.WithHandler(formatHandler),
-			runner.WithFailFast(cmd.Bool("fail-fast")),
+			runner.WithHandler(handler),
+			runner.WithMaxFailures(maxFailures),
 			runner.WithFilter(cmd.String("run")),
+			runner.WithExclude(stringSliceOption(cmd, "exclude", cfg.Test.Exclude)...),
+			runner.WithUnorderedRows(boolOption(cmd, "unordered", cfg.Test.Unordered)),
+			runner.WithKeepState(keepState),
+			runner.WithConcurrency(concurrency),
+			runner.WithRepeat(repeat),
+			runner.WithBench(benchWarmup, benchIterations),
+			runner.WithProfile(cmd.Bool("profile")),
 			runner.WithModules(ps.resolved),
 			runner.WithLag(cmd.Bool("lag")),
 		)
 
 		result, err := suiteRunner.Run(ctx, ps.suite, ps.path)
-		if err != nil {
-			return fmt.Errorf("running %s: %w", ps.path, err)
+		if result != nil {
+			failures += result.Failed + result.Errors
+
+			if totalResult == nil {
+				totalResult = result
+			} else {
+				totalResult.Merge(result)
+			}
 		}
 
-		if totalResult == nil {
-			totalResult = result
-		} else {
-			totalResult.Merge(result)
+		if err != nil {
+			// The report of an aborted run still covers the tests that ran.
+			err = fmt.Errorf("running %s: %w", ps.path, err)
+
+			return errors.Join(err, writeReports(reports, totalResult))
 		}
 	}
 
@@ -244,6 +453,11 @@ func runTest(ctx context.Context, cmd *cli.Command) error {
 			_ = summarizer.Summary(totalResult)
 		}
 
+		err := writeReports(reports, totalResult)
+		if err != nil {
+			return err
+		}
+
 		if !totalResult.Ok() {
 			return cli.Exit("", 1)
 		}
@@ -252,6 +466,132 @@ func runTest(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
+// listedTest is a test printed by --list --json.
+type listedTest struct {
+	Suite string `json:"suite"`
+	Path  string `json:"path"`
+}
+
+// listTests writes the path of each test in files that selector would run,
+// one per line, or as a JSON array of listedTest when asJSON is set.
+func listTests(w io.Writer, files []string, selector *runner.Runner, asJSON bool) error {
+	listed := []listedTest{}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file) //nolint:gosec // G304: file path from user input is expected
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", file, err)
+		}
+
+		suite, err := scaf.Parse(data)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", file, err)
+		}
+
+		for _, path := range selector.List(suite) {
+			listed = append(listed, listedTest{Suite: file, Path: path})
+		}
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(listed)
+	}
+
+	for _, test := range listed {
+		if _, err := fmt.Fprintln(w, test.Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportFile collects a formatter's output in memory and writes it to path
+// after the run.
+type reportFile struct {
+	path    string
+	buf     *bytes.Buffer
+	handler *runner.FormatHandler
+}
+
+func newReportFile(path string, newFormatter func(io.Writer) runner.Formatter) *reportFile {
+	buf := &bytes.Buffer{}
+
+	return &reportFile{
+		path:    path,
+		buf:     buf,
+		handler: runner.NewFormatHandler(newFormatter(buf), io.Discard),
+	}
+}
+
+// writeReports writes each report for result, which is nil when no suite ran.
+func writeReports(reports []*reportFile, result *runner.Result) error {
+	if result == nil {
+		return nil
+	}
+
+	for _, report := range reports {
+		err := report.write(result)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write renders the summary and replaces the report file with the result.
+func (r *reportFile) write(result *runner.Result) error {
+	err := r.handler.Summary(result)
+	if err != nil {
+		return fmt.Errorf("rendering %s: %w", r.path, err)
+	}
+
+	err = writeFileAtomic(r.path, r.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", r.path, err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames it
+// into place, creating parent directories as needed. Readers never observe a
+// partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+
+	err := os.MkdirAll(dir, 0o755) //nolint:gosec // G301: report directories are not sensitive
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
 func collectTestFiles(args []string) ([]string, error) {
 	var files []string
 
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/runner"
+	"github.com/urfave/cli/v3"
+)
+
+func TestBailFlag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args     []string
+		wantBail int
+		wantArgs []string
+	}{
+		{[]string{"test", "./queries"}, 0, []string{"./queries"}},
+		{[]string{"test", "--bail", "./queries"}, 1, []string{"./queries"}},
+		{[]string{"test", "--bail=3", "./queries"}, 3, []string{"./queries"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args[len(tt.args)-2], func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				gotBail int
+				gotArgs []string
+			)
+
+			cmd := &cli.Command{
+				Name:  "test",
+				Flags: []cli.Flag{&cli.GenericFlag{Name: "bail", Value: &bailValue{}}},
+				Action: func(_ context.Context, cmd *cli.Command) error {
+					gotBail, _ = cmd.Value("bail").(int)
+					gotArgs = cmd.Args().Slice()
+
+					return nil
+				},
+			}
+
+			if err := cmd.Run(context.Background(), tt.args); err != nil {
+				t.Fatalf("Run(%v) error: %v", tt.args, err)
+			}
+
+			if gotBail != tt.wantBail || !slices.Equal(gotArgs, tt.wantArgs) {
+				t.Errorf("Run(%v): bail = %d, args = %v; want %d, %v",
+					tt.args, gotBail, gotArgs, tt.wantBail, tt.wantArgs)
+			}
+		})
+	}
+
+	cmd := &cli.Command{
+		Name:   "test",
+		Flags:  []cli.Flag{&cli.GenericFlag{Name: "bail", Value: &bailValue{}}},
+		Action: func(context.Context, *cli.Command) error { return nil },
+	}
+
+	if err := cmd.Run(context.Background(), []string{"test", "--bail=many"}); err == nil {
+		t.Error("Run(--bail=many) succeeded, want an error")
+	}
+}
+
+// stubDatabase returns the same row for every query.
+type stubDatabase struct{}
+
+func (stubDatabase) Name() string          { return "stub" }
+func (stubDatabase) Dialect() scaf.Dialect { return nil }
+func (stubDatabase) Close() error          { return nil }
+
+func (stubDatabase) Execute(context.Context, string, map[string]any) ([]map[string]any, error) {
+	return []map[string]any{{"n": int64(1)}}, nil
+}
+
+func TestReportFile_JUnit(t *testing.T) {
+	t.Parallel()
+
+	suite, err := scaf.Parse([]byte("query Q `RETURN 1 AS n`\n\n" +
+		"Q {\n\ttest \"passes\" {\n\t\tn: 1\n\t}\n\ttest \"fails\" {\n\t\tn: 2\n\t}\n}\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "reports", "scaf")
+	path := filepath.Join(dir, "junit.xml")
+
+	report := newReportFile(path, func(w io.Writer) runner.Formatter {
+		return runner.NewJUnitFormatter(w)
+	})
+
+	r := runner.New(runner.WithDatabase(stubDatabase{}), runner.WithHandler(report.handler))
+
+	result, err := r.Run(context.Background(), suite, "users.scaf")
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	err = report.write(result)
+	if err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+
+	var got struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name      string `xml:"name,attr"`
+			TestCases []struct {
+				Name    string `xml:"name,attr"`
+				Failure *struct {
+					Message string `xml:"message,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+
+	err = xml.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("report is not well-formed XML: %v\n%s", err, data)
+	}
+
+	if got.Tests != 2 || got.Failures != 1 {
+		t.Errorf("tests = %d, failures = %d; want 2, 1", got.Tests, got.Failures)
+	}
+
+	if len(got.Suites) != 1 || got.Suites[0].Name != "users.scaf" || len(got.Suites[0].TestCases) != 2 {
+		t.Fatalf("unexpected suites:\n%s", data)
+	}
+
+	cases := got.Suites[0].TestCases
+	if cases[0].Name != "Q/passes" || cases[0].Failure != nil {
+		t.Errorf("first case = %+v, want passing Q/passes", cases[0])
+	}
+
+	if cases[1].Name != "Q/fails" || cases[1].Failure == nil || cases[1].Failure.Message != "n: expected 2, got 1" {
+		t.Errorf("second case = %+v, want Q/fails failing on n", cases[1])
+	}
+
+	// The temporary file is renamed into place, leaving only the report.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("report directory has %d entries, want 1", len(entries))
+	}
+}
+
+func TestWriteReports_AbortedRun(t *testing.T) {
+	t.Parallel()
+
+	// The second scope names an undefined query, which aborts the run after
+	// the first scope's test has passed.
+	suite, err := scaf.Parse([]byte("query Q `RETURN 1 AS n`\n\n" +
+		"Q {\n\ttest \"passes\" {\n\t\tn: 1\n\t}\n}\n\nMissing {\n\ttest \"never runs\" {}\n}\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	report := newReportFile(path, func(w io.Writer) runner.Formatter {
+		return runner.NewJSONFormatter(w)
+	})
+
+	r := runner.New(runner.WithDatabase(stubDatabase{}), runner.WithHandler(report.handler))
+
+	result, err := r.Run(context.Background(), suite, "users.scaf")
+	if err == nil {
+		t.Fatal("Run() succeeded, want an error")
+	}
+
+	err = writeReports([]*reportFile{report}, result)
+	if err != nil {
+		t.Fatalf("writeReports() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+
+	if !bytes.Contains(data, []byte("Q/passes")) {
+		t.Errorf("report lacks the test that ran:\n%s", data)
+	}
+}
+
+func TestTestList(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.scaf")
+
+	err := os.WriteFile(path, []byte("query Q `Q`\n\nQ {\n\ttest \"unfocused\" {}\n\n"+
+		"\t// scaf:focus\n\tgroup \"focused\" {\n\t\ttest \"runs\" {}\n\t\ttest \"excluded\" {}\n\t}\n}\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	selector := runner.New(runner.WithExclude("Q/focused/excluded"))
+
+	var text bytes.Buffer
+	if err := listTests(&text, []string{path}, selector, false); err != nil {
+		t.Fatalf("listTests() error: %v", err)
+	}
+
+	if got, want := text.String(), "Q/focused/runs\n"; got != want {
+		t.Errorf("listTests() = %q, want %q", got, want)
+	}
+
+	var out bytes.Buffer
+	if err := listTests(&out, []string{path}, selector, true); err != nil {
+		t.Fatalf("listTests(json) error: %v", err)
+	}
+
+	var listed []listedTest
+	if err := json.Unmarshal(out.Bytes(), &listed); err != nil {
+		t.Fatalf("decoding %q: %v", out.String(), err)
+	}
+
+	if want := []listedTest{{Suite: path, Path: "Q/focused/runs"}}; !slices.Equal(listed, want) {
+		t.Errorf("listTests(json) = %+v, want %+v", listed, want)
+	}
+}
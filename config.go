@@ -1,6 +1,7 @@
 package scaf
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -17,6 +18,15 @@ type Config struct {
 
 	// Generate config for code generation
 	Generate GenerateConfig `yaml:"generate,omitempty"`
+
+	// Lint config for diagnostics
+	Lint LintConfig `yaml:"lint,omitempty"`
+
+	// Fmt holds defaults for the fmt command and editor formatting.
+	Fmt FmtConfig `yaml:"fmt,omitempty"`
+
+	// Test holds defaults for the test command.
+	Test TestConfig `yaml:"test,omitempty"`
 }
 
 // Neo4jConfig holds Neo4j connection settings.
@@ -75,10 +85,61 @@ type GenerateConfig struct {
 	Schema string `yaml:"schema,omitempty"`
 }
 
+// LintConfig holds settings for diagnostics.
+type LintConfig struct {
+	// Severity overrides the severity of diagnostics by code, e.g.:
+	//
+	//	lint:
+	//	  severity:
+	//	    unused-import: hint
+	//	    undefined-query: off
+	//
+	// Valid levels are error, warning, information, hint, and off.
+	Severity map[string]string `yaml:"severity,omitempty"`
+}
+
+// FmtConfig holds formatting defaults. Command-line flags take precedence.
+type FmtConfig struct {
+	// Compact keeps single-statement tests on one line (FormatOptions.CompactSingleStatement).
+	Compact bool `yaml:"compact,omitempty"`
+
+	// Sort orders queries and scopes by name (FormatOptions.SortDeclarations).
+	Sort bool `yaml:"sort,omitempty"`
+
+	// MapWidth breaks wider maps across lines (FormatOptions.MaxInlineMapWidth).
+	MapWidth int `yaml:"map-width,omitempty"`
+}
+
+// FormatOptions returns the format options the config selects.
+func (c *Config) FormatOptions() FormatOptions {
+	return FormatOptions{
+		CompactSingleStatement: c.Fmt.Compact,
+		SortDeclarations:       c.Fmt.Sort,
+		MaxInlineMapWidth:      c.Fmt.MapWidth,
+	}
+}
+
+// TestConfig holds defaults for the test command. Command-line flags take precedence.
+type TestConfig struct {
+	// Unordered compares expected rows regardless of order.
+	Unordered bool `yaml:"unordered,omitempty"`
+
+	// FailFast stops on the first failure.
+	FailFast bool `yaml:"fail-fast,omitempty"`
+
+	// Concurrency is the number of query scopes to run at once.
+	Concurrency int `yaml:"concurrency,omitempty"`
+
+	// Exclude skips tests and groups whose path matches any of these globs.
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
 // DefaultConfigNames are the filenames we search for.
 var DefaultConfigNames = []string{".scaf.yaml", ".scaf.yml", "scaf.yaml", "scaf.yml"}
 
 // LoadConfig finds and loads the nearest .scaf.yaml walking up from dir.
+// It returns ErrConfigNotFound if there is none, and an error naming the file
+// if it is malformed.
 func LoadConfig(dir string) (*Config, error) {
 	path, err := FindConfig(dir)
 	if err != nil {
@@ -125,7 +186,7 @@ func LoadConfigFile(path string) (*Config, error) {
 
 	err = yaml.Unmarshal(data, &cfg)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
 
 	return &cfg, nil
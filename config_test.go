@@ -0,0 +1,88 @@
+package scaf_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rlch/scaf"
+)
+
+func TestLoadConfig_Discovery(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	nested := filepath.Join(root, "tests", "users")
+
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := scaf.LoadConfig(nested); !errors.Is(err, scaf.ErrConfigNotFound) {
+		t.Fatalf("LoadConfig() without a config error = %v, want ErrConfigNotFound", err)
+	}
+
+	content := `neo4j:
+  uri: bolt://localhost:7687
+fmt:
+  compact: true
+test:
+  unordered: true
+  concurrency: 4
+  exclude: ["*slow*"]
+`
+	if err := os.WriteFile(filepath.Join(root, "scaf.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := scaf.LoadConfig(nested)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+
+	if cfg.DialectName() != scaf.DialectCypher {
+		t.Errorf("DialectName() = %q, want %q", cfg.DialectName(), scaf.DialectCypher)
+	}
+
+	if !cfg.FormatOptions().CompactSingleStatement {
+		t.Error("FormatOptions().CompactSingleStatement = false, want true")
+	}
+
+	want := scaf.TestConfig{Unordered: true, Concurrency: 4, Exclude: []string{"*slow*"}}
+	if diff := cmp.Diff(want, cfg.Test); diff != "" {
+		t.Errorf("Test config mismatch (-want +got):\n%s", diff)
+	}
+
+	// The nearest config wins.
+	if err := os.WriteFile(filepath.Join(nested, ".scaf.yaml"), []byte("fmt:\n  compact: false\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = scaf.LoadConfig(nested)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+
+	if cfg.FormatOptions().CompactSingleStatement || cfg.Neo4j != nil {
+		t.Errorf("LoadConfig() = %+v, want the nested config", cfg)
+	}
+}
+
+func TestLoadConfig_Malformed(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".scaf.yaml")
+
+	if err := os.WriteFile(path, []byte("test:\n  concurrency: lots\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := scaf.LoadConfig(dir)
+	if err == nil || errors.Is(err, scaf.ErrConfigNotFound) || !strings.Contains(err.Error(), path) {
+		t.Errorf("LoadConfig() error = %v, want a parse error naming %s", err, path)
+	}
+}
@@ -7,6 +7,13 @@ import (
 
 // Database represents an execution target (neo4j, postgres, mysql).
 // It handles connection establishment and query execution.
+//
+// Backends plug in by implementing Database, and TransactionalDatabase to
+// isolate tests, then either registering a factory with RegisterDatabase,
+// selected by the database configured in .scaf.yaml, or passing an instance
+// to runner.WithDatabase, as tests do with in-memory mocks. A Database is the
+// driver: the runner executes every query through it, so no separate driver
+// type sits between them.
 type Database interface {
 	// Name returns the database identifier (e.g., "neo4j", "postgres").
 	Name() string
@@ -43,6 +50,28 @@ type TransactionalDatabase interface {
 	Begin(ctx context.Context) (DatabaseTransaction, error)
 }
 
+// QueryPlan summarizes the execution plan a database reported for a profiled query.
+type QueryPlan struct {
+	// Operator is the root operator of the plan (e.g., "ProduceResults").
+	Operator string
+
+	// DBHits is the total number of storage accesses across every operator.
+	DBHits int64
+
+	// Rows is the number of rows the root operator produced.
+	Rows int64
+}
+
+// ProfilingExecutor is implemented by databases and transactions that can report
+// a query's execution plan.
+type ProfilingExecutor interface {
+	// ExecuteProfiled runs a query like Execute, with the statement whose rows are
+	// returned wrapped by profiler, and returns the rows along with its plan.
+	ExecuteProfiled(
+		ctx context.Context, query string, params map[string]any, profiler QueryProfiler,
+	) ([]map[string]any, *QueryPlan, error)
+}
+
 // DatabaseFactory creates a Database from configuration.
 type DatabaseFactory func(cfg any) (Database, error)
 
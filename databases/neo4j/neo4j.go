@@ -26,10 +26,11 @@ func init() {
 
 // Database implements scaf.Database and scaf.TransactionalDatabase for Neo4j.
 type Database struct {
-	driver  neo4j.DriverWithContext
-	session neo4j.SessionWithContext
-	db      string
-	dialect scaf.Dialect
+	driver     neo4j.DriverWithContext
+	session    neo4j.SessionWithContext
+	sessionCfg neo4j.SessionConfig
+	db         string
+	dialect    scaf.Dialect
 }
 
 // New creates a new Neo4j database connection from the given configuration.
@@ -61,14 +62,14 @@ func New(cfg *scaf.Neo4jConfig) (*Database, error) {
 	}
 
 	// Create session config
-	sessionCfg := neo4j.SessionConfig{
+	d.sessionCfg = neo4j.SessionConfig{
 		AccessMode: neo4j.AccessModeWrite,
 	}
 	if d.db != "" {
-		sessionCfg.DatabaseName = d.db
+		d.sessionCfg.DatabaseName = d.db
 	}
 
-	d.session = driver.NewSession(ctx, sessionCfg)
+	d.session = driver.NewSession(ctx, d.sessionCfg)
 
 	return d, nil
 }
@@ -114,6 +115,15 @@ func (d *Database) Execute(ctx context.Context, query string, params map[string]
 	return rows, nil
 }
 
+// ExecuteProfiled runs a Cypher query like Execute, profiling its last statement.
+func (d *Database) ExecuteProfiled(
+	ctx context.Context, query string, params map[string]any, profiler scaf.QueryProfiler,
+) ([]map[string]any, *scaf.QueryPlan, error) {
+	return executeProfiled(ctx, func(ctx context.Context, stmt string) (neo4j.ResultWithContext, error) {
+		return d.session.Run(ctx, stmt, params)
+	}, query, profiler)
+}
+
 // Close releases the database connection.
 func (d *Database) Close() error {
 	ctx := context.Background()
@@ -136,18 +146,26 @@ func (d *Database) Close() error {
 }
 
 // Begin starts a new transaction for isolated test execution.
+// Each transaction runs in a session of its own, closed when it ends: a
+// session holds one transaction at a time and isn't safe for concurrent use,
+// and the runner may begin transactions from several scopes at once.
 func (d *Database) Begin(ctx context.Context) (scaf.DatabaseTransaction, error) {
-	tx, err := d.session.BeginTransaction(ctx)
+	session := d.driver.NewSession(ctx, d.sessionCfg)
+
+	tx, err := session.BeginTransaction(ctx)
 	if err != nil {
+		_ = session.Close(ctx)
+
 		return nil, fmt.Errorf("neo4j: failed to begin transaction: %w", err)
 	}
 
-	return &Transaction{tx: tx}, nil
+	return &Transaction{tx: tx, session: session}, nil
 }
 
 // Transaction wraps a Neo4j transaction to implement scaf.DatabaseTransaction.
 type Transaction struct {
-	tx neo4j.ExplicitTransaction
+	tx      neo4j.ExplicitTransaction
+	session neo4j.SessionWithContext
 }
 
 // Execute runs a Cypher query within this transaction.
@@ -176,14 +194,105 @@ func (t *Transaction) Execute(ctx context.Context, query string, params map[stri
 	return rows, nil
 }
 
-// Commit commits the transaction.
+// ExecuteProfiled runs a Cypher query within this transaction, profiling its last statement.
+func (t *Transaction) ExecuteProfiled(
+	ctx context.Context, query string, params map[string]any, profiler scaf.QueryProfiler,
+) ([]map[string]any, *scaf.QueryPlan, error) {
+	return executeProfiled(ctx, func(ctx context.Context, stmt string) (neo4j.ResultWithContext, error) {
+		return t.tx.Run(ctx, stmt, params)
+	}, query, profiler)
+}
+
+// Commit commits the transaction and closes its session.
 func (t *Transaction) Commit(ctx context.Context) error {
-	return t.tx.Commit(ctx)
+	return t.end(ctx, t.tx.Commit(ctx))
 }
 
-// Rollback aborts the transaction.
+// Rollback aborts the transaction and closes its session.
 func (t *Transaction) Rollback(ctx context.Context) error {
-	return t.tx.Rollback(ctx)
+	return t.end(ctx, t.tx.Rollback(ctx))
+}
+
+// end closes the transaction's session once it has committed or rolled back,
+// returning err, the outcome, in preference to a failure to close.
+func (t *Transaction) end(ctx context.Context, err error) error {
+	closeErr := t.session.Close(ctx)
+	if err != nil {
+		return err
+	}
+
+	if closeErr != nil {
+		return fmt.Errorf("neo4j: failed to close session: %w", closeErr)
+	}
+
+	return nil
+}
+
+// executeProfiled runs each statement of query in turn, wrapping the last one
+// with profiler, and returns its rows and plan.
+func executeProfiled(
+	ctx context.Context,
+	run func(ctx context.Context, stmt string) (neo4j.ResultWithContext, error),
+	query string,
+	profiler scaf.QueryProfiler,
+) ([]map[string]any, *scaf.QueryPlan, error) {
+	statements := splitStatements(query)
+
+	var (
+		rows []map[string]any
+		plan *scaf.QueryPlan
+	)
+
+	for i, stmt := range statements {
+		last := i == len(statements)-1
+		if last {
+			stmt = profiler.ProfileQuery(stmt)
+		}
+
+		result, err := run(ctx, stmt)
+		if err != nil {
+			return nil, nil, fmt.Errorf("neo4j: query execution failed: %w", err)
+		}
+
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("neo4j: failed to collect results: %w", err)
+		}
+
+		rows = make([]map[string]any, len(records))
+		for i, record := range records {
+			rows[i] = flattenRecord(record.Keys, record.Values)
+		}
+
+		if !last {
+			continue
+		}
+
+		summary, err := result.Consume(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("neo4j: failed to read profile: %w", err)
+		}
+
+		if profile := summary.Profile(); profile != nil {
+			plan = &scaf.QueryPlan{
+				Operator: profile.Operator(),
+				DBHits:   totalDBHits(profile),
+				Rows:     profile.Records(),
+			}
+		}
+	}
+
+	return rows, plan, nil
+}
+
+// totalDBHits sums the db hits of a plan and all of its children.
+func totalDBHits(plan neo4j.ProfiledPlan) int64 {
+	hits := plan.DbHits()
+	for _, child := range plan.Children() {
+		hits += totalDBHits(child)
+	}
+
+	return hits
 }
 
 // splitStatements splits a multi-statement query into individual statements.
@@ -321,4 +430,6 @@ var (
 	_ scaf.Database              = (*Database)(nil)
 	_ scaf.TransactionalDatabase = (*Database)(nil)
 	_ scaf.DatabaseTransaction   = (*Transaction)(nil)
+	_ scaf.ProfilingExecutor     = (*Database)(nil)
+	_ scaf.ProfilingExecutor     = (*Transaction)(nil)
 )
@@ -2,10 +2,14 @@
 package neo4j
 
 import (
+	"context"
+	"errors"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
 	"github.com/rlch/scaf"
 )
@@ -55,6 +59,93 @@ func TestDatabase_Registration(t *testing.T) {
 	}
 }
 
+// stubDriver hands out sessions that, like the real driver's, refuse to begin
+// a transaction while another one is open on them. Only the methods Begin uses
+// are implemented.
+type stubDriver struct {
+	neo4j.DriverWithContext
+
+	mu     sync.Mutex
+	opened int
+	closed int
+}
+
+func (d *stubDriver) NewSession(context.Context, neo4j.SessionConfig) neo4j.SessionWithContext {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.opened++
+
+	return &stubSession{driver: d}
+}
+
+type stubSession struct {
+	neo4j.SessionWithContext
+
+	driver *stubDriver
+	open   bool
+}
+
+func (s *stubSession) BeginTransaction(
+	context.Context, ...func(*neo4j.TransactionConfig),
+) (neo4j.ExplicitTransaction, error) {
+	if s.open {
+		return nil, errors.New("session already has an open transaction")
+	}
+
+	s.open = true
+
+	return &stubTx{session: s}, nil
+}
+
+func (s *stubSession) Close(context.Context) error {
+	s.driver.mu.Lock()
+	defer s.driver.mu.Unlock()
+
+	s.driver.closed++
+
+	return nil
+}
+
+type stubTx struct {
+	neo4j.ExplicitTransaction
+
+	session *stubSession
+}
+
+func (t *stubTx) Commit(context.Context) error   { t.session.open = false; return nil }
+func (t *stubTx) Rollback(context.Context) error { t.session.open = false; return nil }
+
+func TestDatabase_BeginOverlapping(t *testing.T) {
+	ctx := context.Background()
+	driver := &stubDriver{}
+	db := &Database{driver: driver, session: driver.NewSession(ctx, neo4j.SessionConfig{})}
+
+	// Concurrent scopes hold transactions open at the same time.
+	first, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("first Begin() error: %v", err)
+	}
+
+	second, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("overlapping Begin() error: %v", err)
+	}
+
+	if err := first.Commit(ctx); err != nil {
+		t.Errorf("Commit() error: %v", err)
+	}
+
+	if err := second.Rollback(ctx); err != nil {
+		t.Errorf("Rollback() error: %v", err)
+	}
+
+	// The database's own session stays open; each transaction's is closed.
+	if driver.opened != 3 || driver.closed != 2 {
+		t.Errorf("opened %d sessions, closed %d; want 3 and 2", driver.opened, driver.closed)
+	}
+}
+
 func TestFlattenRecord_Primitives(t *testing.T) {
 	keys := []string{"name", "age", "active"}
 	values := []any{"Alice", int64(30), true}
@@ -8,6 +8,60 @@ type Dialect interface {
 
 	// Analyze extracts metadata from a query string.
 	Analyze(query string) (*QueryMetadata, error)
+
+	// MarkdownLanguage returns the markdown code fence language for queries
+	// (e.g., "cypher", "sql"), used for highlighting in hover and completion docs.
+	MarkdownLanguage() string
+}
+
+// BulkInserter is implemented by dialects that can load a setup data table in a single query.
+type BulkInserter interface {
+	// BulkInsert returns a query that inserts every row of the $rows parameter,
+	// a list of maps keyed by column name, as a record with the given label
+	// (a node label or table name, depending on the dialect).
+	BulkInsert(label string, columns []string) string
+}
+
+// QueryCompleter is implemented by dialects that offer completions inside query bodies.
+type QueryCompleter interface {
+	// CompleteQuery returns completion candidates for the cursor at byte offset in query.
+	// Candidates are not filtered by the word being typed; callers do that.
+	CompleteQuery(query string, offset int) []QueryCompletion
+}
+
+// QueryProfiler is implemented by dialects with a way to ask the database for
+// the execution plan of a query as it runs.
+type QueryProfiler interface {
+	// ProfileQuery wraps a single statement so executing it also reports its plan.
+	ProfileQuery(statement string) string
+}
+
+// QueryFormatter is implemented by dialects that can lay out query bodies.
+type QueryFormatter interface {
+	// FormatQuery returns query with one clause per line and no leading or
+	// trailing whitespace. It fails, formatting nothing, if query doesn't parse.
+	FormatQuery(query string) (string, error)
+}
+
+// QueryCompletionKind classifies a query body completion.
+type QueryCompletionKind string
+
+// Query completion kinds.
+const (
+	QueryCompletionKeyword   QueryCompletionKind = "keyword"
+	QueryCompletionParameter QueryCompletionKind = "parameter"
+)
+
+// QueryCompletion is a single completion candidate inside a query body.
+type QueryCompletion struct {
+	// Label is the text to insert, e.g. "RETURN" or "$userId".
+	Label string
+
+	// Kind classifies the candidate.
+	Kind QueryCompletionKind
+
+	// Detail is a short description shown next to the label.
+	Detail string
 }
 
 var dialects = make(map[string]Dialect)
@@ -91,7 +145,12 @@ func RegisteredAnalyzers() []string {
 
 // MarkdownLanguage returns the markdown language identifier for a dialect.
 // Used for syntax highlighting in IDE hover/completion documentation.
+// A registered dialect supplies its own; database names map to their dialect's.
 func MarkdownLanguage(dialectName string) string {
+	if d := GetDialect(dialectName); d != nil {
+		return d.MarkdownLanguage()
+	}
+
 	// Common dialect name to markdown language mapping
 	switch dialectName {
 	case DialectCypher, DatabaseNeo4j:
@@ -105,7 +164,9 @@ func MarkdownLanguage(dialectName string) string {
 
 // QueryMetadata holds extracted information about a query.
 type QueryMetadata struct {
-	// Parameters are the $-prefixed parameters used in the query.
+	// Parameters are the $-prefixed parameters used anywhere in the query, in
+	// order of first appearance, one entry per name. Position, Line, and Column
+	// locate the first reference; Count records every reference.
 	Parameters []ParameterInfo
 
 	// Returns are the fields returned by the query.
@@ -116,6 +177,52 @@ type QueryMetadata struct {
 	// Set to true when the query filters on a unique field with equality,
 	// uses LIMIT 1, or is otherwise guaranteed to return a single row.
 	ReturnsOne bool
+
+	// Writes indicates the query modifies data (e.g. CREATE, MERGE, SET, DELETE).
+	// When false, the query is read-only.
+	Writes bool
+
+	// UnionMismatches lists UNION branches whose columns differ from the first branch's.
+	// Returns still holds every column returned by any branch.
+	UnionMismatches []UnionMismatch
+}
+
+// Params returns the names of the query's parameters, without the $ prefix,
+// in source order and deduplicated.
+func (m *QueryMetadata) Params() []string {
+	if m == nil {
+		return nil
+	}
+
+	names := make([]string, len(m.Parameters))
+	for i, p := range m.Parameters {
+		names[i] = p.Name
+	}
+
+	return names
+}
+
+// Parameters returns the names of the parameters referenced by a query body
+// in the given dialect, without the $ prefix, in source order and deduplicated.
+func Parameters(d Dialect, query string) ([]string, error) {
+	metadata, err := d.Analyze(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata.Params(), nil
+}
+
+// UnionMismatch describes a UNION branch whose columns don't match the first branch.
+type UnionMismatch struct {
+	// Branch is the 0-indexed position of the branch in the UNION (always at least 1).
+	Branch int
+
+	// Columns are the branch's column names, in order.
+	Columns []string
+
+	// Expected are the first branch's column names, in order.
+	Expected []string
 }
 
 // ParameterInfo describes a query parameter.
@@ -158,6 +265,11 @@ type ReturnInfo struct {
 	// When Alias is empty, the database column name is Expression.
 	Alias string
 
+	// Key is how a test's statements refer to the column: its column name when
+	// that is a valid key, otherwise a positional key such as "result[0]" for
+	// an unaliased expression like count(*). Empty if the dialect doesn't say.
+	Key string
+
 	// IsAggregate indicates this is an aggregate function result.
 	IsAggregate bool
 
@@ -1,6 +1,8 @@
 package cypher
 
 import (
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -78,6 +80,9 @@ func (a *Analyzer) analyzeQueryInternal(query string, schema *analysis.TypeSchem
 	// Extract return items with type inference
 	extractReturns(tree, result, ctx)
 
+	// Classify the query as read-only or writing
+	result.Writes = hasUpdatingStatement(tree)
+
 	// Check for unique field filters if schema is provided
 	if schema != nil {
 		result.ReturnsOne = checkUniqueFilter(tree, schema)
@@ -315,8 +320,109 @@ func inferParameterType(propName string, labels []string, ctx *queryContext) str
 	return ""
 }
 
-// extractReturns walks the tree to find RETURN clause items.
+// extractReturns collects the query's return columns. For a UNION, each branch is
+// analyzed separately and the branches are merged into a single column list,
+// recording any branch whose columns differ from the first.
 func extractReturns(tree antlr.ParseTree, result *scaf.QueryMetadata, ctx *queryContext) {
+	regular := topLevelRegularQuery(tree)
+	if regular == nil || len(regular.AllUnionSt()) == 0 {
+		extractBranchReturns(tree, result, ctx)
+
+		return
+	}
+
+	branches := []antlr.Tree{regular.SingleQuery()}
+	for _, union := range regular.AllUnionSt() {
+		branches = append(branches, union.SingleQuery())
+	}
+
+	var expected []string
+
+	for i, branch := range branches {
+		if branch == nil {
+			continue
+		}
+
+		branchResult := &scaf.QueryMetadata{}
+		extractBranchReturns(branch, branchResult, ctx)
+
+		columns := returnColumns(branchResult.Returns)
+		if i == 0 {
+			expected = columns
+			result.Returns = append(result.Returns, branchResult.Returns...)
+
+			continue
+		}
+
+		if !slices.Equal(columns, expected) {
+			result.UnionMismatches = append(result.UnionMismatches, scaf.UnionMismatch{
+				Branch:   i,
+				Columns:  columns,
+				Expected: expected,
+			})
+		}
+
+		mergeUnionReturns(result, branchResult.Returns)
+	}
+}
+
+// topLevelRegularQuery returns the script's regular query, or nil for standalone calls.
+func topLevelRegularQuery(tree antlr.ParseTree) *cyphergrammar.RegularQueryContext {
+	script, ok := tree.(*cyphergrammar.ScriptContext)
+	if !ok || script.Query() == nil {
+		return nil
+	}
+
+	regular, _ := script.Query().RegularQuery().(*cyphergrammar.RegularQueryContext)
+
+	return regular
+}
+
+// returnColumns returns the result column name of each return item.
+func returnColumns(returns []scaf.ReturnInfo) []string {
+	columns := make([]string, len(returns))
+	for i, ret := range returns {
+		columns[i] = returnColumn(ret)
+	}
+
+	return columns
+}
+
+func returnColumn(ret scaf.ReturnInfo) string {
+	if ret.Alias != "" {
+		return ret.Alias
+	}
+
+	return ret.Expression
+}
+
+// mergeUnionReturns folds a later UNION branch's returns into result.
+// Columns new to the union are appended; a column whose inferred type differs
+// between branches loses its type, since either branch may produce the row.
+func mergeUnionReturns(result *scaf.QueryMetadata, returns []scaf.ReturnInfo) {
+	for _, ret := range returns {
+		column := returnColumn(ret)
+
+		idx := slices.IndexFunc(result.Returns, func(r scaf.ReturnInfo) bool {
+			return returnColumn(r) == column
+		})
+		if idx < 0 {
+			result.Returns = append(result.Returns, ret)
+
+			continue
+		}
+
+		existing := &result.Returns[idx]
+		if existing.Type != ret.Type {
+			existing.Type = ""
+		}
+
+		existing.IsAggregate = existing.IsAggregate || ret.IsAggregate
+	}
+}
+
+// extractBranchReturns walks the tree to find RETURN clause items.
+func extractBranchReturns(tree antlr.Tree, result *scaf.QueryMetadata, ctx *queryContext) {
 	var walk func(node antlr.Tree)
 
 	walk = func(node antlr.Tree) {
@@ -337,6 +443,24 @@ func extractReturns(tree antlr.ParseTree, result *scaf.QueryMetadata, ctx *query
 	walk(tree)
 }
 
+// hasUpdatingStatement reports whether the tree contains an updating clause
+// (CREATE, MERGE, SET, DELETE, or REMOVE).
+func hasUpdatingStatement(node antlr.Tree) bool {
+	if _, ok := node.(*cyphergrammar.UpdatingStatementContext); ok {
+		return true
+	}
+
+	if ruleCtx, ok := node.(antlr.RuleContext); ok {
+		for i := 0; i < ruleCtx.GetChildCount(); i++ {
+			if child := ruleCtx.GetChild(i); child != nil && hasUpdatingStatement(child) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // extractReturnInfo processes a ReturnStContext to extract return items.
 func extractReturnInfo(returnCtx *cyphergrammar.ReturnStContext, result *scaf.QueryMetadata, ctx *queryContext) {
 	projBody := returnCtx.ProjectionBody()
@@ -368,6 +492,10 @@ func extractReturnInfo(returnCtx *cyphergrammar.ReturnStContext, result *scaf.Qu
 	}
 }
 
+// keyPattern matches column names a statement key can spell: identifiers
+// and property paths such as "u.name".
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
 // extractProjectionItem processes a single ProjectionItemContext.
 func extractProjectionItem(itemCtx *cyphergrammar.ProjectionItemContext, result *scaf.QueryMetadata, ctx *queryContext) {
 	if itemCtx == nil {
@@ -416,11 +544,26 @@ func extractProjectionItem(itemCtx *cyphergrammar.ProjectionItemContext, result
 	// Infer type from schema
 	returnType := inferReturnType(expression, ctx)
 
+	// Statements can only name a column that is an identifier or property
+	// path; the rest are referred to by position.
+	key := alias
+	if key == "" {
+		key = expression
+	}
+
+	switch {
+	case isWildcard:
+		key = ""
+	case !keyPattern.MatchString(key):
+		key = scaf.ColumnKey(len(result.Returns))
+	}
+
 	result.Returns = append(result.Returns, scaf.ReturnInfo{
 		Name:        name,
 		Type:        returnType,
 		Expression:  expression,
 		Alias:       alias,
+		Key:         key,
 		IsAggregate: isAggregate,
 		IsWildcard:  isWildcard,
 		Line:        line,
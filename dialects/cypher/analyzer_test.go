@@ -89,37 +89,46 @@ func TestAnalyzer_AnalyzeQuery_Returns(t *testing.T) {
 			name:  "simple variable",
 			query: "MATCH (u:User) RETURN u",
 			wantReturns: []scaf.ReturnInfo{
-				{Name: "u", Expression: "u"},
+				{Name: "u", Expression: "u", Key: "u"},
 			},
 		},
 		{
 			name:  "property access",
 			query: "MATCH (u:User) RETURN u.name",
 			wantReturns: []scaf.ReturnInfo{
-				{Name: "name", Expression: "u.name"},
+				{Name: "name", Expression: "u.name", Key: "u.name"},
 			},
 		},
 		{
 			name:  "multiple properties",
 			query: "MATCH (u:User) RETURN u.name, u.email, u.age",
 			wantReturns: []scaf.ReturnInfo{
-				{Name: "name", Expression: "u.name"},
-				{Name: "email", Expression: "u.email"},
-				{Name: "age", Expression: "u.age"},
+				{Name: "name", Expression: "u.name", Key: "u.name"},
+				{Name: "email", Expression: "u.email", Key: "u.email"},
+				{Name: "age", Expression: "u.age", Key: "u.age"},
 			},
 		},
 		{
 			name:  "with alias",
 			query: "MATCH (u:User) RETURN u.createdAt AS created",
 			wantReturns: []scaf.ReturnInfo{
-				{Name: "created", Expression: "u.createdAt"},
+				{Name: "created", Expression: "u.createdAt", Key: "created"},
 			},
 		},
 		{
 			name:  "count aggregate",
 			query: "MATCH (u:User) RETURN count(u) AS total",
 			wantReturns: []scaf.ReturnInfo{
-				{Name: "total", Expression: "count(u)", IsAggregate: true},
+				{Name: "total", Expression: "count(u)", Key: "total", IsAggregate: true},
+			},
+		},
+		{
+			name:  "unnamed expressions",
+			query: "MATCH (u:User) RETURN u.name, count(*), u.age + 1 AS next",
+			wantReturns: []scaf.ReturnInfo{
+				{Name: "name", Expression: "u.name", Key: "u.name"},
+				{Name: "count", Expression: "count(*)", Key: "result[1]", IsAggregate: true},
+				{Name: "next", Expression: "u.age+1", Key: "next"},
 			},
 		},
 		{
@@ -156,6 +165,10 @@ func TestAnalyzer_AnalyzeQuery_Returns(t *testing.T) {
 					t.Errorf("return[%d].Expression = %q, want %q", i, got.Expression, want.Expression)
 				}
 
+				if got.Key != want.Key {
+					t.Errorf("return[%d].Key = %q, want %q", i, got.Key, want.Key)
+				}
+
 				if got.IsAggregate != want.IsAggregate {
 					t.Errorf("return[%d].IsAggregate = %v, want %v", i, got.IsAggregate, want.IsAggregate)
 				}
@@ -365,6 +378,124 @@ func TestAnalyzer_AnalyzeQuery_ParameterCount(t *testing.T) {
 	}
 }
 
+func TestAnalyzer_AnalyzeQuery_ParamsAcrossClauses(t *testing.T) {
+	t.Parallel()
+
+	// $since only appears in MATCH and WHERE, $id in every clause.
+	query := "MATCH (u:User {id: $id})-[:FOLLOWS]->(f) WHERE f.joined > $since AND f.id <> $id " +
+		"WITH u, f ORDER BY f.joined SKIP $offset RETURN u, $id AS requested"
+
+	metadata, err := cypher.NewAnalyzer().AnalyzeQuery(query)
+	if err != nil {
+		t.Fatalf("AnalyzeQuery() error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"id", "since", "offset"}, metadata.Params()); diff != "" {
+		t.Errorf("Params() mismatch (-want +got):\n%s", diff)
+	}
+
+	if metadata.Parameters[0].Count != 3 {
+		t.Errorf("id count = %d, want 3", metadata.Parameters[0].Count)
+	}
+
+	params, err := scaf.Parameters(cypher.NewDialect(), query)
+	if err != nil {
+		t.Fatalf("Parameters() error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"id", "since", "offset"}, params); diff != "" {
+		t.Errorf("Parameters() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_Writes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query  string
+		writes bool
+	}{
+		{"MATCH (u:User) RETURN u", false},
+		{"MATCH (u:User) WITH u RETURN count(u) AS c", false},
+		{"CREATE (u:User {name: $name}) RETURN u", true},
+		{"MERGE (u:User {id: $id})", true},
+		{"MATCH (u:User) SET u.active = true", true},
+		{"MATCH (u:User) DETACH DELETE u", true},
+		{"MATCH (u:User) REMOVE u.age", true},
+	}
+
+	analyzer := cypher.NewAnalyzer()
+
+	for _, tt := range tests {
+		metadata, err := analyzer.AnalyzeQuery(tt.query)
+		if err != nil {
+			t.Fatalf("AnalyzeQuery(%q) error: %v", tt.query, err)
+		}
+
+		if metadata.Writes != tt.writes {
+			t.Errorf("AnalyzeQuery(%q).Writes = %v, want %v", tt.query, metadata.Writes, tt.writes)
+		}
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_Union(t *testing.T) {
+	t.Parallel()
+
+	analyzer := cypher.NewAnalyzer()
+
+	metadata, err := analyzer.AnalyzeQuery(
+		"MATCH (u:User) RETURN u.name AS name, u.id AS id " +
+			"UNION MATCH (p:Pet) RETURN p.name AS name, p.id AS id " +
+			"UNION ALL MATCH (c:Company) RETURN c.title AS name, c.id AS id")
+	if err != nil {
+		t.Fatalf("AnalyzeQuery() error: %v", err)
+	}
+
+	var names []string
+	for _, ret := range metadata.Returns {
+		names = append(names, ret.Name)
+	}
+
+	if diff := cmp.Diff([]string{"name", "id"}, names); diff != "" {
+		t.Errorf("Returns mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(metadata.UnionMismatches) != 0 {
+		t.Errorf("UnionMismatches = %+v, want none", metadata.UnionMismatches)
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_UnionMismatch(t *testing.T) {
+	t.Parallel()
+
+	analyzer := cypher.NewAnalyzer()
+
+	metadata, err := analyzer.AnalyzeQuery(
+		"MATCH (u:User) RETURN u.name AS name UNION MATCH (p:Pet) RETURN p.name AS petName, p.age")
+	if err != nil {
+		t.Fatalf("AnalyzeQuery() error: %v", err)
+	}
+
+	var names []string
+	for _, ret := range metadata.Returns {
+		names = append(names, ret.Name)
+	}
+
+	// Every column from every branch stays available for completion.
+	if diff := cmp.Diff([]string{"name", "petName", "age"}, names); diff != "" {
+		t.Errorf("Returns mismatch (-want +got):\n%s", diff)
+	}
+
+	want := []scaf.UnionMismatch{{
+		Branch:   1,
+		Columns:  []string{"petName", "p.age"},
+		Expected: []string{"name"},
+	}}
+	if diff := cmp.Diff(want, metadata.UnionMismatches); diff != "" {
+		t.Errorf("UnionMismatches mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestAnalyzer_AnalyzeQuery_EmptyQuery(t *testing.T) {
 	t.Parallel()
 
@@ -711,3 +842,25 @@ func TestAnalyzer_AnalyzeQueryWithSchema_TypeInference(t *testing.T) {
 		}
 	})
 }
+
+func BenchmarkAnalyzer_AnalyzeQuery(b *testing.B) {
+	query := `MATCH (u:User {id: $id})-[:FOLLOWS]->(f:User)
+		WHERE f.age > $minAge AND f.name STARTS WITH $prefix
+		WITH u, collect(f) AS friends
+		RETURN u.name AS name, size(friends) AS friendCount, [x IN friends | x.email] AS emails
+		ORDER BY name LIMIT 10`
+
+	b.Run("uncached", func(b *testing.B) {
+		analyzer := cypher.NewAnalyzer()
+		for b.Loop() {
+			_, _ = analyzer.AnalyzeQuery(query)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		analyzer := scaf.NewCachedAnalyzer(cypher.NewAnalyzer(), scaf.DefaultAnalyzerCacheSize)
+		for b.Loop() {
+			_, _ = analyzer.AnalyzeQuery(query)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package cypher
+
+import (
+	"regexp"
+
+	"github.com/rlch/scaf"
+)
+
+// clauseKeywords are the Cypher keywords offered when completing inside a query body.
+var clauseKeywords = []string{
+	"MATCH", "OPTIONAL MATCH", "WHERE", "RETURN", "WITH", "UNWIND", "ORDER BY", "SKIP", "LIMIT",
+	"CREATE", "MERGE", "ON CREATE SET", "ON MATCH SET", "SET", "DELETE", "DETACH DELETE", "REMOVE",
+	"CALL", "YIELD", "UNION", "UNION ALL", "DISTINCT", "AS", "AND", "OR", "XOR", "NOT", "IN",
+	"IS NULL", "IS NOT NULL", "STARTS WITH", "ENDS WITH", "CONTAINS", "CASE", "WHEN", "THEN",
+	"ELSE", "END", "EXISTS", "ASC", "DESC",
+}
+
+// CompleteQuery returns keyword completions, or the query's parameters when the
+// word at offset starts with '$'.
+func (d *Dialect) CompleteQuery(query string, offset int) []scaf.QueryCompletion {
+	offset = max(0, min(offset, len(query)))
+
+	if start, ok := parameterStart(query, offset); ok {
+		return parameterCompletions(query, query[start:offset])
+	}
+
+	items := make([]scaf.QueryCompletion, len(clauseKeywords))
+	for i, kw := range clauseKeywords {
+		items[i] = scaf.QueryCompletion{Label: kw, Kind: scaf.QueryCompletionKeyword, Detail: "cypher keyword"}
+	}
+
+	return items
+}
+
+// parameterStart returns the start of the parameter name being typed at offset,
+// if the word ending there is preceded by '$'.
+func parameterStart(query string, offset int) (int, bool) {
+	i := offset
+	for i > 0 && isWordByte(query[i-1]) {
+		i--
+	}
+
+	return i, i > 0 && query[i-1] == '$'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// parameterCompletions lists the parameters already referenced in the query,
+// leaving out the partial name being typed unless it is also used elsewhere.
+// References are found lexically because the body is usually incomplete while
+// being edited; types come from the analyzer when the body parses.
+func parameterCompletions(query, typing string) []scaf.QueryCompletion {
+	types := make(map[string]string)
+	if metadata, err := NewAnalyzer().AnalyzeQuery(query); err == nil && metadata != nil {
+		for _, p := range metadata.Parameters {
+			types[p.Name] = p.Type
+		}
+	}
+
+	counts := make(map[string]int)
+
+	var names []string
+
+	for _, m := range parameterRefPattern.FindAllStringSubmatch(query, -1) {
+		if counts[m[1]] == 0 {
+			names = append(names, m[1])
+		}
+
+		counts[m[1]]++
+	}
+
+	items := make([]scaf.QueryCompletion, 0, len(names))
+	for _, name := range names {
+		if name == typing && counts[name] == 1 {
+			continue
+		}
+
+		detail := "parameter"
+		if t := types[name]; t != "" {
+			detail += " (" + t + ")"
+		}
+
+		items = append(items, scaf.QueryCompletion{Label: "$" + name, Kind: scaf.QueryCompletionParameter, Detail: detail})
+	}
+
+	return items
+}
+
+var parameterRefPattern = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+var _ scaf.QueryCompleter = (*Dialect)(nil)
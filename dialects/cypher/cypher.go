@@ -26,4 +26,24 @@ func (d *Dialect) Analyze(query string) (*scaf.QueryMetadata, error) {
 	return NewAnalyzer().AnalyzeQuery(query)
 }
 
-var _ scaf.Dialect = (*Dialect)(nil)
+// MarkdownLanguage returns the code fence language for Cypher queries.
+func (d *Dialect) MarkdownLanguage() string {
+	return "cypher"
+}
+
+// ProfileQuery prefixes a statement with PROFILE, which runs it and reports the plan.
+func (d *Dialect) ProfileQuery(statement string) string {
+	return "PROFILE " + statement
+}
+
+// BulkInsert returns an UNWIND query creating one node per row, with the row's columns as properties.
+func (d *Dialect) BulkInsert(label string, _ []string) string {
+	return "UNWIND $rows AS row CREATE (n:" + label + ") SET n = row"
+}
+
+var (
+	_ scaf.Dialect        = (*Dialect)(nil)
+	_ scaf.BulkInserter   = (*Dialect)(nil)
+	_ scaf.QueryProfiler  = (*Dialect)(nil)
+	_ scaf.QueryFormatter = (*Dialect)(nil)
+)
@@ -2,6 +2,7 @@
 package cypher
 
 import (
+	"errors"
 	"slices"
 	"testing"
 
@@ -49,3 +50,84 @@ func TestDialect_Analyze(t *testing.T) {
 		t.Errorf("Analyze() returns = %d, want 2", len(metadata.Returns))
 	}
 }
+
+func TestDialect_BulkInsert(t *testing.T) {
+	t.Parallel()
+
+	got := NewDialect().BulkInsert("User", []string{"id", "name"})
+	if want := "UNWIND $rows AS row CREATE (n:User) SET n = row"; got != want {
+		t.Errorf("BulkInsert() = %q, want %q", got, want)
+	}
+}
+
+func TestDialect_CompleteQuery(t *testing.T) {
+	t.Parallel()
+
+	d := NewDialect()
+
+	query := "MATCH (u:User) RET"
+	if items := d.CompleteQuery(query, len(query)); !slices.ContainsFunc(items, func(c scaf.QueryCompletion) bool {
+		return c.Label == "RETURN" && c.Kind == scaf.QueryCompletionKeyword
+	}) {
+		t.Errorf("CompleteQuery(%q) = %v, want RETURN keyword", query, items)
+	}
+
+	query = "MATCH (u:User {id: $id}) WHERE u.org = $org AND u.age > $i"
+
+	var labels []string
+	for _, c := range d.CompleteQuery(query, len(query)) {
+		labels = append(labels, c.Label)
+	}
+
+	if want := []string{"$id", "$org"}; !slices.Equal(labels, want) {
+		t.Errorf("CompleteQuery(%q) = %v, want %v", query, labels, want)
+	}
+}
+
+func TestDialect_FormatQuery(t *testing.T) {
+	d := NewDialect()
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "clauses",
+			query: "match (u:User {id: $id})\n\n   where u.name starts with 'A'  return u.name as name order by name limit 1",
+			want:  "MATCH (u:User {id: $id})\nWHERE u.name starts with 'A'\nRETURN u.name as name\nORDER BY name\nLIMIT 1",
+		},
+		{
+			name:  "merge",
+			query: "merge (u:User {id: $id}) on create set u.created = 1 on match set u.seen = 2 return u",
+			want:  "MERGE (u:User {id: $id})\n  ON CREATE SET u.created = 1\n  ON MATCH SET u.seen = 2\nRETURN u",
+		},
+		{
+			name:  "keywords as names",
+			query: "OPTIONAL MATCH (n:Set) WITH n, \"return  where\" AS s RETURN n.limit, s",
+			want:  "OPTIONAL MATCH (n:Set)\nWITH n, \"return  where\" AS s\nRETURN n.limit, s",
+		},
+		{
+			name:  "line comment",
+			query: "MATCH (n) // every node\nRETURN n",
+			want:  "MATCH (n) // every node\nRETURN n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.FormatQuery(tt.query)
+			if err != nil {
+				t.Fatalf("FormatQuery() error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("FormatQuery() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := d.FormatQuery("MATCH (u:User RETURN u"); !errors.Is(err, ErrSyntax) {
+		t.Errorf("FormatQuery(invalid) error = %v, want %v", err, ErrSyntax)
+	}
+}
@@ -0,0 +1,232 @@
+package cypher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	cyphergrammar "github.com/rlch/scaf/dialects/cypher/grammar"
+)
+
+// ErrSyntax is returned when a query to format doesn't parse.
+var ErrSyntax = errors.New("cypher: syntax error")
+
+// FormatQuery lays out a query one clause per line: whitespace is collapsed,
+// each top-level clause starts a line, clause keywords are upper-cased, and
+// ON CREATE / ON MATCH are indented under their MERGE. Strings, comments and
+// everything inside brackets are left as written.
+func (d *Dialect) FormatQuery(query string) (string, error) {
+	if err := checkSyntax(query); err != nil {
+		return "", err
+	}
+
+	toks := scanQuery(query)
+
+	var (
+		lines []string
+		line  strings.Builder
+		depth int
+	)
+
+	flush := func() {
+		if line.Len() > 0 {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+	}
+
+	for i, tok := range toks {
+		word := strings.ToUpper(tok.text)
+		keyword := depth == 0 && tok.word && !afterAccessor(toks, i) && clauseWord(toks, i)
+
+		switch {
+		case keyword && clauseStart(toks, i):
+			flush()
+
+			if word == "ON" {
+				line.WriteString("  ")
+			}
+		case line.Len() > 0 && tok.spaced:
+			line.WriteByte(' ')
+		}
+
+		if keyword {
+			line.WriteString(word)
+		} else {
+			line.WriteString(tok.text)
+		}
+
+		switch tok.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth = max(0, depth-1)
+		}
+
+		// A line comment runs to the end of its line.
+		if strings.HasPrefix(tok.text, "//") {
+			flush()
+		}
+	}
+
+	flush()
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// checkSyntax parses query, returning the first syntax error.
+func checkSyntax(query string) error {
+	lexer := cyphergrammar.NewCypherLexer(antlr.NewInputStream(query))
+	parser := cyphergrammar.NewCypherParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+
+	listener := &parseErrorListener{}
+
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(listener)
+	parser.RemoveErrorListeners()
+	parser.AddErrorListener(listener)
+	parser.Script()
+
+	if len(listener.errors) > 0 {
+		return fmt.Errorf("%w: %s", ErrSyntax, listener.errors[0])
+	}
+
+	return nil
+}
+
+// queryToken is a word, quoted string, comment, or punctuation character.
+type queryToken struct {
+	text string
+	// word is set for identifiers, numbers and parameters.
+	word bool
+	// spaced is set when whitespace preceded the token.
+	spaced bool
+}
+
+// scanQuery splits a query into tokens, dropping whitespace.
+func scanQuery(query string) []queryToken {
+	var toks []queryToken
+
+	rs := []rune(query)
+	spaced := false
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			spaced = true
+			i++
+
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			i++
+			for i < len(rs) && rs[i] != r {
+				if rs[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i = min(i+1, len(rs))
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '/':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			i += 2
+			for i+1 < len(rs) && (rs[i] != '*' || rs[i+1] != '/') {
+				i++
+			}
+			i = min(i+2, len(rs))
+		case isWordRune(r):
+			for i < len(rs) && isWordRune(rs[i]) {
+				i++
+			}
+		default:
+			i++
+		}
+
+		toks = append(toks, queryToken{text: string(rs[start:i]), word: isWordRune(r), spaced: spaced})
+		spaced = false
+	}
+
+	return toks
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// afterAccessor reports whether the word at i is a property, label, or
+// relationship type (n.limit, :Set) rather than a keyword.
+func afterAccessor(toks []queryToken, i int) bool {
+	return i > 0 && (toks[i-1].text == "." || toks[i-1].text == ":")
+}
+
+// wordAt returns the upper-cased word at i, or "" if there is none.
+func wordAt(toks []queryToken, i int) string {
+	if i < 0 || i >= len(toks) || !toks[i].word {
+		return ""
+	}
+
+	return strings.ToUpper(toks[i].text)
+}
+
+// clauseStart reports whether the word at i begins a clause.
+func clauseStart(toks []queryToken, i int) bool {
+	prev, next := wordAt(toks, i-1), wordAt(toks, i+1)
+
+	switch wordAt(toks, i) {
+	case "WHERE", "RETURN", "UNWIND", "SKIP", "LIMIT", "MERGE", "REMOVE", "CALL", "YIELD", "UNION", "FOREACH":
+		return true
+	case "OPTIONAL":
+		return next == "MATCH"
+	case "ORDER":
+		return next == "BY"
+	case "DETACH":
+		return next == "DELETE"
+	case "ON":
+		return next == "CREATE" || next == "MATCH"
+	case "MATCH":
+		return prev != "OPTIONAL" && prev != "ON"
+	case "CREATE":
+		return prev != "ON"
+	case "DELETE":
+		return prev != "DETACH"
+	case "SET":
+		return wordAt(toks, i-2) != "ON"
+	case "WITH":
+		return prev != "STARTS" && prev != "ENDS"
+	}
+
+	return false
+}
+
+// clauseWord reports whether the word at i starts a clause or continues a
+// multi-word clause keyword (OPTIONAL MATCH, ORDER BY, ON CREATE SET, ...).
+func clauseWord(toks []queryToken, i int) bool {
+	if clauseStart(toks, i) {
+		return true
+	}
+
+	switch prev := wordAt(toks, i-1); wordAt(toks, i) {
+	case "MATCH":
+		return prev == "OPTIONAL" || prev == "ON"
+	case "BY":
+		return prev == "ORDER"
+	case "DELETE":
+		return prev == "DETACH"
+	case "CREATE":
+		return prev == "ON"
+	case "SET":
+		return wordAt(toks, i-2) == "ON"
+	case "ALL":
+		return prev == "UNION"
+	}
+
+	return false
+}
@@ -1,6 +1,12 @@
 package scaf
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alecthomas/participle/v2"
+	"github.com/alecthomas/participle/v2/lexer"
+)
 
 // Sentinel errors.
 var (
@@ -10,3 +16,105 @@ var (
 	// ErrUnknownDatabase is returned when an unknown database is requested.
 	ErrUnknownDatabase = errors.New("scaf: unknown database")
 )
+
+// ParseError reports a syntax error at a source location. Parse and its
+// variants return one for every failure; it unwraps to the underlying
+// participle, lexer, or strict-mode error.
+type ParseError struct {
+	// Span covers the offending input. For most errors only the start is known,
+	// so Start == End.
+	Span Span
+	// Message describes the error without position information.
+	Message string
+
+	err error
+}
+
+func (e *ParseError) Error() string {
+	return e.Span.Start.String() + ": " + e.Message
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.err
+}
+
+// newParseError wraps err in a ParseError, locating it from the first error
+// of a recovery parse and noting how many followed. Returns nil for a nil err.
+func newParseError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	first, more := err, 0
+
+	var recoveryErr *participle.RecoveryError
+	if errors.As(err, &recoveryErr) && len(recoveryErr.Errors) > 0 {
+		first, more = recoveryErr.Errors[0], len(recoveryErr.Errors)-1
+	}
+
+	pe := &ParseError{Message: first.Error(), err: err}
+
+	var (
+		unknownErr *UnknownConstructError
+		lexErr     *LexerError
+		located    interface {
+			Position() lexer.Position
+			Message() string
+		}
+	)
+
+	switch {
+	case errors.As(first, &unknownErr):
+		pe.Span, pe.Message = unknownErr.Span, unknownErr.Message()
+	case errors.As(first, &lexErr):
+		pe.Span, pe.Message = Span{Start: lexErr.pos, End: lexErr.pos}, lexErr.message()
+	case errors.As(first, &located):
+		pos := located.Position()
+		pe.Span, pe.Message = Span{Start: pos, End: pos}, located.Message()
+	}
+
+	if more > 0 {
+		pe.Message += fmt.Sprintf(" (and %d more errors)", more)
+	}
+
+	return pe
+}
+
+// AnalysisError reports a semantic problem found by analysis, such as an
+// undefined query. Code identifies the check (e.g. "undefined-query"), so
+// callers can switch on it rather than matching messages.
+type AnalysisError struct {
+	Code    string
+	Span    Span
+	Message string
+	// Severity uses LSP numbering: 1=error, 2=warning, 3=information, 4=hint.
+	Severity int
+}
+
+func (e *AnalysisError) Error() string {
+	return e.Span.Start.String() + ": " + e.Message + " [" + e.Code + "]"
+}
+
+// SuiteValidator checks a parsed suite on its own, without a schema, database,
+// or imported files.
+type SuiteValidator func(s *Suite) []*AnalysisError
+
+var suiteValidator SuiteValidator
+
+// RegisterValidator sets the validator used by Suite.Validate.
+// The analysis package registers its rules in its init() function.
+func RegisterValidator(v SuiteValidator) {
+	suiteValidator = v
+}
+
+// Validate runs the schema-free semantic checks (undefined queries, duplicate
+// names, unknown parameters, unused imports, ...) against the suite and
+// returns every problem found, whatever its severity. It returns nil if no
+// validator is registered; import github.com/rlch/scaf/analysis to register one.
+func (s *Suite) Validate() []*AnalysisError {
+	if suiteValidator == nil || s == nil {
+		return nil
+	}
+
+	return suiteValidator(s)
+}
@@ -0,0 +1,124 @@
+// Package expr evaluates scaf expressions: assert conditions and computed
+// expected values. Expressions are written in the expr-lang language
+// (https://expr-lang.org) and evaluated against an environment of named values,
+// typically a query's result row or the setup bindings in scope.
+//
+// # Values and coercion
+//
+// Environment values are used as given; nested maps and slices are reached
+// with field access (u.name or u["name"]) and indexing (u.posts[0]).
+//
+//   - Numbers of any Go integer or float type combine and compare with each
+//     other by value, so an int64 column equals the literal 3 and 3.0. An
+//     operation mixing an integer and a float yields a float64, and / always
+//     yields a float64.
+//   - Lists compare element by element, with the same number rules.
+//   - nil equals only nil.
+//   - Reading a missing key of a map yields nil. Field access on nil is an
+//     error unless written with ?. (u?.name), which yields nil.
+//
+// Operands are type-checked when the expression compiles, as far as the
+// environment's Go types allow: an operator applied to a top-level value of a
+// type it doesn't accept, such as name > 0 for a string name, is a compile
+// error, as is == between a string and a number. Values read from inside
+// maps and slices are only known when evaluated: ordering or arithmetic on
+// unsupported types is then an evaluation error, and == between different
+// non-number types is false. &&, || and ! are meant for booleans but check
+// this only at compile time, so conditions should compare explicitly.
+//
+// # Functions
+//
+// Every expr-lang builtin is available, including len, now, and duration:
+// u.createdAt - now() < duration("24h") compares a time column with a
+// moving window. Register adds functions of its own.
+//
+// # Errors
+//
+// Errors wrap ErrCompile when the source does not parse or type-check against
+// the environment, which includes referring to a name the environment does
+// not define, and ErrEval when evaluation fails, such as an operator applied
+// to operands it doesn't accept or a function returning an error.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/rlch/scaf"
+)
+
+// Sentinel errors for the expr package.
+var (
+	// ErrEmpty is returned when there is no expression to evaluate.
+	ErrEmpty = errors.New("expr: empty expression")
+
+	// ErrCompile is returned when an expression does not compile.
+	ErrCompile = errors.New("expr: compile")
+
+	// ErrEval is returned when evaluating an expression fails.
+	ErrEval = errors.New("expr: evaluate")
+)
+
+// Func is a function callable from expressions. It receives the evaluated
+// arguments and returns the call's value, or an error that fails the
+// evaluation.
+type Func func(args ...any) (any, error)
+
+var (
+	funcsMu sync.RWMutex
+	funcs   = map[string]Func{}
+)
+
+// Register makes fn callable as name from expressions evaluated afterwards.
+// Registering a name again replaces the earlier function, and a registered
+// function takes precedence over a builtin of the same name. It is safe to
+// call concurrently with Eval.
+func Register(name string, fn Func) {
+	funcsMu.Lock()
+	defer funcsMu.Unlock()
+
+	funcs[name] = fn
+}
+
+// Eval evaluates an assert condition against env.
+func Eval(e *scaf.Expr, env map[string]any) (any, error) {
+	if e == nil {
+		return nil, ErrEmpty
+	}
+
+	return EvalString(e.String(), env)
+}
+
+// EvalString evaluates an expression given as source against env.
+func EvalString(src string, env map[string]any) (any, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, ErrEmpty
+	}
+
+	if env == nil {
+		env = map[string]any{}
+	}
+
+	opts := []expr.Option{expr.Env(env)}
+
+	funcsMu.RLock()
+	for name, fn := range funcs {
+		opts = append(opts, expr.Function(name, fn))
+	}
+	funcsMu.RUnlock()
+
+	program, err := expr.Compile(src, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrCompile, src, err)
+	}
+
+	out, err := expr.Run(program, env)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrEval, src, err)
+	}
+
+	return out, nil
+}
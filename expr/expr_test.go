@@ -0,0 +1,245 @@
+package expr_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/expr"
+)
+
+func TestEval_AssertConditions(t *testing.T) {
+	t.Parallel()
+
+	// The conditions of TestParseExprAssert, evaluated as parsed.
+	suite, err := scaf.Parse([]byte("query Q `Q`\nQ {\n\ttest \"t\" {\n" +
+		"\t\tassert { u.age > 18 }\n" +
+		"\t\tassert { u.createdAt - now() < duration(\"24h\") }\n" +
+		"\t\tassert { len(u.posts) > 0 && u.verified == true }\n" +
+		"\t\tassert { x > 0; y < 10; z == 5 }\n" +
+		"\t}\n}\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	env := map[string]any{
+		"u": map[string]any{
+			"age":       int64(30),
+			"createdAt": time.Now().Add(time.Hour),
+			"posts":     []any{"hello"},
+			"verified":  true,
+		},
+		"x": 1,
+		"y": 9.5,
+		"z": int64(5),
+	}
+
+	var conditions []*scaf.Expr
+	for _, assert := range suite.Scopes[0].Items[0].Test.Asserts {
+		conditions = append(conditions, assert.Conditions...)
+	}
+
+	if len(conditions) != 6 {
+		t.Fatalf("parsed %d conditions, want 6", len(conditions))
+	}
+
+	for _, cond := range conditions {
+		got, err := expr.Eval(cond, env)
+		if err != nil {
+			t.Errorf("Eval(%q) error: %v", cond.String(), err)
+
+			continue
+		}
+
+		if got != true {
+			t.Errorf("Eval(%q) = %v, want true", cond.String(), got)
+		}
+	}
+
+	// The same conditions fail against an environment that doesn't satisfy them.
+	env["u"] = map[string]any{
+		"age":       17,
+		"createdAt": time.Now().Add(48 * time.Hour),
+		"posts":     []any{},
+		"verified":  true,
+	}
+	env["z"] = 6
+
+	for i, want := range []bool{false, false, false, true, true, false} {
+		if got, err := expr.Eval(conditions[i], env); err != nil || got != want {
+			t.Errorf("Eval(%q) = %v, %v; want %v", conditions[i].String(), got, err, want)
+		}
+	}
+}
+
+func TestEvalString(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]any{
+		"n":     int64(3),
+		"f":     1.5,
+		"s":     "Alice",
+		"ok":    true,
+		"none":  nil,
+		"when":  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		"tags":  []any{"a", "b"},
+		"ids":   []any{int64(1), 2.0},
+		"user":  map[string]any{"name": "Bob", "age": int64(41), "address": nil},
+		"users": []any{map[string]any{"name": "Ann"}, map[string]any{"name": "Cy"}},
+	}
+
+	tests := []struct {
+		src  string
+		want any
+	}{
+		// Comparisons, with numbers compared by value across Go types.
+		{"n == 3", true},
+		{"n == 3.0", true},
+		{"n > 2.5", true},
+		{"f <= 1.5", true},
+		{"s != \"Bob\"", true},
+		{"s < \"Bob\"", true},
+		{"when < date(\"2025-01-01\")", true},
+		{"none == nil", true},
+		{"user.address == nil", true},
+		{"user.name == 1", false}, // Typed at run time: different types are unequal.
+
+		// Boolean operators.
+		{"ok && n > 1", true},
+		{"!ok || n > 1", true},
+		{"not ok", false},
+		{"n > 5 ? \"big\" : \"small\"", "small"},
+
+		// Arithmetic: mixing integers and floats, and /, yield float64.
+		{"n + 1", 4},
+		{"n + f", 4.5},
+		{"n / 2", 1.5},
+		{"n % 2", 1},
+		{"n ** 2", 9.0},
+		{"s + \"!\"", "Alice!"},
+
+		// Field access and indexing.
+		{"user.name", "Bob"},
+		{"user[\"age\"]", int64(41)},
+		{"user.missing", nil},
+		{"user.address?.city", nil},
+		{"users[1].name", "Cy"},
+		{"tags[-1]", "b"},
+		{"ids == [1, 2]", true},
+		{"\"a\" in tags", true},
+
+		// Builtin functions.
+		{"len(tags)", 2},
+		{"len(s)", 5},
+		{"duration(\"90m\") > duration(\"1h\")", true},
+		{"now() > when", true},
+		{"map(users, .name)", []any{"Ann", "Cy"}},
+		{"all(ids, # > 0)", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := expr.EvalString(tt.src, env)
+			if err != nil {
+				t.Fatalf("EvalString(%q) error: %v", tt.src, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EvalString(%q) = %#v, want %#v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalString_Errors(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]any{
+		"n":    1,
+		"s":    "Alice",
+		"none": nil,
+		"user": map[string]any{"name": "Bob"},
+		"tags": []any{"a"},
+	}
+
+	tests := []struct {
+		src     string
+		wantErr error
+	}{
+		{"", expr.ErrEmpty},
+		{" \t\n", expr.ErrEmpty},
+		{"n > > 1", expr.ErrCompile},
+		{"missing > 1", expr.ErrCompile},
+		{"s > 0", expr.ErrCompile},
+		{"s == 1", expr.ErrCompile},
+		{"!n", expr.ErrCompile},
+		{"nope(1)", expr.ErrCompile},
+		{"none.name", expr.ErrCompile},
+		{"user.name > 0", expr.ErrEval},
+		{"user.name.first", expr.ErrEval},
+		{"user.missing.name", expr.ErrEval},
+		{"tags[3]", expr.ErrEval},
+		{"duration(\"soon\")", expr.ErrEval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := expr.EvalString(tt.src, env)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EvalString(%q) = %#v, %v; want error %v", tt.src, got, err, tt.wantErr)
+			}
+
+			if !errors.Is(tt.wantErr, expr.ErrEmpty) && !strings.Contains(err.Error(), tt.src) {
+				t.Errorf("error %q should quote the expression", err)
+			}
+		})
+	}
+
+	if _, err := expr.Eval(nil, env); !errors.Is(err, expr.ErrEmpty) {
+		t.Errorf("Eval(nil) error = %v, want %v", err, expr.ErrEmpty)
+	}
+
+	// A nil environment is empty.
+	if got, err := expr.EvalString("1 + 1", nil); err != nil || got != 2 {
+		t.Errorf("EvalString with nil env = %v, %v; want 2", got, err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	expr.Register("testDouble", func(args ...any) (any, error) {
+		n, ok := args[0].(int)
+		if !ok {
+			return nil, fmt.Errorf("testDouble: want an int, got %T", args[0])
+		}
+
+		return 2 * n, nil
+	})
+
+	got, err := expr.EvalString("testDouble(n) == 8", map[string]any{"n": 4})
+	if err != nil || got != true {
+		t.Errorf("EvalString(testDouble) = %v, %v; want true", got, err)
+	}
+
+	// A function's error fails the evaluation.
+	if _, err := expr.EvalString("testDouble(\"x\")", nil); !errors.Is(err, expr.ErrEval) ||
+		!strings.Contains(err.Error(), "want an int") {
+		t.Errorf("EvalString(testDouble(\"x\")) error = %v, want the function's error", err)
+	}
+
+	// Registering again replaces the function.
+	expr.Register("testDouble", func(...any) (any, error) { return 0, nil })
+
+	if got, _ := expr.EvalString("testDouble(4)", nil); got != 0 {
+		t.Errorf("EvalString(testDouble(4)) after re-registering = %v, want 0", got)
+	}
+}
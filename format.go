@@ -1,23 +1,111 @@
 package scaf
 
 import (
+	"cmp"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// IndentStyle selects the characters the formatter indents nested blocks with.
+type IndentStyle int
+
+const (
+	// IndentTabs indents with one tab per level, the default.
+	IndentTabs IndentStyle = iota
+	// IndentSpaces indents with FormatOptions.IndentWidth spaces per level.
+	IndentSpaces
 )
 
+// DefaultIndentWidth is the number of spaces per level with IndentSpaces when
+// FormatOptions.IndentWidth is unset.
+const DefaultIndentWidth = 4
+
+// FormatOptions configures FormatWithOptions. The zero value matches Format.
+type FormatOptions struct {
+	// CompactSingleStatement keeps a test with exactly one statement and no setup,
+	// expected rows, or asserts on a single line: test "a" { $x: 1 }.
+	CompactSingleStatement bool
+
+	// IndentStyle selects tabs or spaces for indentation.
+	IndentStyle IndentStyle
+
+	// IndentWidth is the number of spaces per level with IndentSpaces, or
+	// DefaultIndentWidth if zero. Tabs are always one per level.
+	IndentWidth int
+
+	// NoBlankLineBetweenInputsAndOutputs drops the blank line that otherwise
+	// separates a test's $parameters from its expected outputs.
+	NoBlankLineBetweenInputsAndOutputs bool
+
+	// SortDeclarations writes queries sorted by name and scopes by query name,
+	// keeping scopes of the same query in source order. Comments before each
+	// move with it, including those before a query that starts the file.
+	SortDeclarations bool
+
+	// MaxInlineMapWidth writes a map with several entries whose one-line form
+	// is wider than this across lines instead, one entry per line with the
+	// values aligned. Zero keeps every map on one line, as do expected rows
+	// and data tables, which are laid out as tables.
+	MaxInlineMapWidth int
+}
+
+// indentUnit returns the string written once per indentation level.
+func (o FormatOptions) indentUnit() string {
+	if o.IndentStyle != IndentSpaces {
+		return "\t"
+	}
+
+	width := o.IndentWidth
+	if width <= 0 {
+		width = DefaultIndentWidth
+	}
+
+	return strings.Repeat(" ", width)
+}
+
 // Format formats a Suite AST back into scaf DSL source code, preserving comments.
 func Format(s *Suite) string {
+	return FormatWithOptions(s, FormatOptions{})
+}
+
+// FormatWithOptions formats a Suite AST like Format, with layout options.
+func FormatWithOptions(s *Suite, opts FormatOptions) string {
 	var b strings.Builder
 
-	f := &formatter{b: &b, indent: 0}
+	f := &formatter{b: &b, indent: 0, unit: opts.indentUnit(), opts: opts}
 	f.formatSuite(s)
 
 	return strings.TrimSpace(b.String()) + "\n"
 }
 
+// FormatSource parses src and formats it like Format. On a parse error it
+// returns src unchanged with the *ParseError, never formatting partial input.
+func FormatSource(src []byte) ([]byte, error) {
+	return FormatSourceWithOptions(src, FormatOptions{})
+}
+
+// FormatSourceWithOptions is FormatSource with layout options.
+func FormatSourceWithOptions(src []byte, opts FormatOptions) ([]byte, error) {
+	suite, err := Parse(src)
+	if err != nil {
+		return src, err
+	}
+
+	return []byte(FormatWithOptions(suite, opts)), nil
+}
+
 type formatter struct {
 	b      *strings.Builder
 	indent int
+	unit   string // written once per indentation level
+	opts   FormatOptions
+
+	depth  int  // levels of broken maps around the value being formatted
+	inline bool // keep maps on one line regardless of MaxInlineMapWidth
 }
 
 func (f *formatter) write(s string) {
@@ -32,7 +120,7 @@ func (f *formatter) writeLine(s string) {
 
 func (f *formatter) writeIndent() {
 	for range f.indent {
-		f.write("\t")
+		f.write(f.unit)
 	}
 }
 
@@ -55,26 +143,84 @@ func (f *formatter) writeTrailingComment(trailing string) {
 }
 
 func (f *formatter) formatSuite(s *Suite) {
+	// Shebang stays the first line
+	if s.Shebang != "" {
+		f.writeLine(s.Shebang)
+	}
+
+	queries, scopes := s.Queries, s.Scopes
+
+	// Comments before a query that starts the file are attached to the suite;
+	// when sorting moves the query, they go with it.
+	var moved *Query
+
+	if f.opts.SortDeclarations {
+		queries = slices.Clone(queries)
+		slices.SortStableFunc(queries, func(a, b *Query) int { return cmp.Compare(a.Name, b.Name) })
+
+		scopes = slices.Clone(scopes)
+		slices.SortStableFunc(scopes, func(a, b *QueryScope) int { return cmp.Compare(a.QueryName, b.QueryName) })
+
+		if len(queries) > 0 && s.Queries[0].Pos == s.Pos && queries[0] != s.Queries[0] {
+			moved = s.Queries[0]
+		}
+	}
+
 	// Leading comments for the whole file
-	f.writeLeadingComments(s.LeadingComments)
+	if moved == nil {
+		f.writeLeadingComments(s.LeadingComments)
+	}
+
+	// Metadata
+	if s.Meta != nil {
+		f.writeLine("meta " + f.formatMap(s.Meta))
+
+		if len(s.Imports) > 0 || len(s.Exports) > 0 || len(s.Queries) > 0 || s.Setup != nil ||
+			s.Teardown != nil || len(s.Profiles) > 0 || len(s.Scopes) > 0 {
+			f.blankLine()
+		}
+	}
 
 	// Imports
-	for _, imp := range s.Imports {
+	for i, imp := range s.Imports {
+		if i > 0 {
+			f.separate(s, s.Imports[i-1], imp, false)
+		}
+
 		f.formatImport(imp)
 	}
 
+	// Exports
+	for i, exp := range s.Exports {
+		switch {
+		case i > 0:
+			f.separate(s, s.Exports[i-1], exp, false)
+		case len(s.Imports) > 0:
+			f.blankLine()
+		}
+
+		f.formatExport(exp)
+	}
+
 	// Queries
-	for i, q := range s.Queries {
-		if i > 0 || len(s.Imports) > 0 {
+	for i, q := range queries {
+		switch {
+		case i > 0 && !f.opts.SortDeclarations:
+			f.separate(s, queries[i-1], q, true)
+		case i > 0 || len(s.Imports) > 0 || len(s.Exports) > 0:
 			f.blankLine()
 		}
 
+		if q == moved {
+			f.writeLeadingComments(s.LeadingComments)
+		}
+
 		f.formatQuery(q)
 	}
 
 	// Global setup
 	if s.Setup != nil {
-		if len(s.Queries) > 0 || len(s.Imports) > 0 {
+		if len(s.Queries) > 0 || len(s.Imports) > 0 || len(s.Exports) > 0 {
 			f.blankLine()
 		}
 
@@ -86,9 +232,25 @@ func (f *formatter) formatSuite(s *Suite) {
 		f.formatTeardown(*s.Teardown)
 	}
 
+	// Profiles
+	for i, p := range s.Profiles {
+		switch {
+		case i > 0:
+			f.separate(s, s.Profiles[i-1], p, true)
+		case len(s.Queries) > 0 || len(s.Imports) > 0 || len(s.Exports) > 0 || s.Setup != nil || s.Teardown != nil:
+			f.blankLine()
+		}
+
+		f.formatProfile(p)
+	}
+
 	// Scopes
-	for i, scope := range s.Scopes {
-		if i > 0 || len(s.Queries) > 0 || len(s.Imports) > 0 || s.Setup != nil || s.Teardown != nil {
+	for i, scope := range scopes {
+		switch {
+		case i > 0 && !f.opts.SortDeclarations:
+			f.separate(s, scopes[i-1], scope, true)
+		case i > 0 || len(s.Queries) > 0 || len(s.Imports) > 0 || len(s.Exports) > 0 ||
+			s.Setup != nil || s.Teardown != nil || len(s.Profiles) > 0:
 			f.blankLine()
 		}
 
@@ -96,6 +258,38 @@ func (f *formatter) formatSuite(s *Suite) {
 	}
 }
 
+// separate writes a blank line between consecutive top-level declarations of
+// one kind if the source has one between them, collapsing longer runs to one.
+// Without source tokens, as for a suite built in code, it writes one if def.
+func (f *formatter) separate(s *Suite, prev, next interface{ Span() Span }, def bool) {
+	if blank, ok := blankLineBetween(s.Tokens, prev.Span().End.Offset, next.Span().Start.Offset); ok {
+		def = blank
+	}
+
+	if def {
+		f.blankLine()
+	}
+}
+
+// blankLineBetween reports whether the whitespace in [start, end) of the
+// source the tokens came from holds a blank line, and false for ok when the
+// tokens don't cover the range.
+func blankLineBetween(tokens []lexer.Token, start, end int) (blank, ok bool) {
+	i, _ := slices.BinarySearchFunc(tokens, start, func(tok lexer.Token, offset int) int {
+		return cmp.Compare(tok.Pos.Offset, offset)
+	})
+
+	for ; i < len(tokens) && tokens[i].Pos.Offset < end; i++ {
+		ok = true
+
+		if tokens[i].Type == TokenWhitespace && strings.Count(tokens[i].Value, "\n") >= 2 {
+			return true, true
+		}
+	}
+
+	return false, ok
+}
+
 func (f *formatter) formatImport(imp *Import) {
 	f.writeLeadingComments(imp.LeadingComments)
 
@@ -111,69 +305,205 @@ func (f *formatter) formatImport(imp *Import) {
 	f.write("\n")
 }
 
+func (f *formatter) formatExport(exp *Export) {
+	f.writeLeadingComments(exp.LeadingComments)
+	f.writeIndent()
+
+	switch {
+	case exp.Module != nil:
+		f.write("export " + *exp.Module)
+	case len(exp.Names) == 0:
+		f.write("export {}")
+	default:
+		f.write("export { " + strings.Join(exp.Names, ", ") + " }")
+	}
+
+	if exp.From != nil {
+		f.write(" from " + f.quotedString(*exp.From))
+	}
+
+	f.writeTrailingComment(exp.TrailingComment)
+	f.write("\n")
+}
+
 func (f *formatter) formatQuery(q *Query) {
 	f.writeLeadingComments(q.LeadingComments)
 	f.writeIndent()
-	f.write("query " + q.Name + " " + f.rawString(q.Body))
+	f.write("query " + q.Name)
+
+	if len(q.Defaults) > 0 {
+		defaults := make([]string, len(q.Defaults))
+		for i, d := range q.Defaults {
+			defaults[i] = d.Name
+
+			if d.Type != nil {
+				defaults[i] += ": " + *d.Type
+			}
+
+			if d.Value != nil {
+				defaults[i] += " = " + f.formatValue(d.Value)
+			}
+		}
+
+		f.write("(" + strings.Join(defaults, ", ") + ")")
+	}
+
+	f.write(" " + f.rawString(q.Body))
 	f.writeTrailingComment(q.TrailingComment)
 	f.write("\n")
 }
 
 func (f *formatter) formatSetupClause(s *SetupClause) {
+	f.writeLeadingComments(s.LeadingComments)
+
+	setup := "setup " + bindingPrefix(s.Binding)
+
 	switch {
 	case s.Inline != nil:
-		f.writeLine("setup " + f.rawString(*s.Inline))
+		f.writeCommentedLine(setup+f.rawString(*s.Inline), s.TrailingComment)
 	case s.Module != nil:
-		f.writeLine("setup " + *s.Module)
+		f.writeCommentedLine(setup+*s.Module, s.TrailingComment)
 	case s.Call != nil:
-		f.writeLine("setup " + f.formatSetupCall(s.Call))
+		f.writeCommentedLine(setup+f.formatSetupCall(s.Call), s.TrailingComment)
 	case len(s.Block) > 0:
-		f.formatSetupBlock(s.Block)
+		f.formatSetupBlock(s.Block, s.TrailingComment)
 	}
 }
 
-func (f *formatter) formatSetupBlock(items []*SetupItem) {
-	if len(items) == 1 {
-		// Single item - inline format
-		f.writeLine("setup { " + f.formatSetupItem(items[0]) + " }")
+// bindingPrefix returns "name = " for a bound setup, or "" if unbound.
+func bindingPrefix(binding *string) string {
+	if binding == nil {
+		return ""
+	}
+
+	return *binding + " = "
+}
+
+func (f *formatter) formatSetupBlock(items []*SetupItem, trailing string) {
+	if len(items) == 1 && items[0].Data == nil && len(items[0].LeadingComments) == 0 && items[0].TrailingComment == "" {
+		// Single uncommented item - inline format
+		f.writeCommentedLine("setup { "+f.formatSetupItem(items[0])+" }", trailing)
 
 		return
 	}
 
-	// Multiple items - block format
+	// Multiple, commented, or data table items - block format
 	f.writeLine("setup {")
 	f.indent++
 
 	for _, item := range items {
-		f.writeLine(f.formatSetupItem(item))
+		f.writeLeadingComments(item.LeadingComments)
+
+		if item.Data != nil {
+			f.formatDataTable(bindingPrefix(item.Binding), item.Data, item.TrailingComment)
+
+			continue
+		}
+
+		f.writeCommentedLine(f.formatSetupItem(item), item.TrailingComment)
 	}
 
 	f.indent--
-	f.writeLine("}")
+	f.writeCommentedLine("}", trailing)
+}
+
+// writeCommentedLine writes an indented line followed by an optional trailing comment.
+func (f *formatter) writeCommentedLine(s, trailing string) {
+	f.writeIndent()
+	f.write(s)
+	f.writeTrailingComment(trailing)
+	f.write("\n")
 }
 
 func (f *formatter) formatSetupItem(item *SetupItem) string {
+	prefix := bindingPrefix(item.Binding)
+
 	if item.Inline != nil {
-		return f.rawString(*item.Inline)
+		return prefix + f.rawString(*item.Inline)
 	}
 
 	if item.Module != nil {
-		return *item.Module
+		return prefix + *item.Module
 	}
 
 	if item.Call != nil {
-		return f.formatSetupCall(item.Call)
+		return prefix + f.formatSetupCall(item.Call)
 	}
 
 	return ""
 }
 
+// formatDataTable writes a data table with its columns aligned, after prefix
+// (its binding, if any):
+//
+//	data User {
+//	  id, name
+//	| 1,  "Alice"
+//	}
+func (f *formatter) formatDataTable(prefix string, t *DataTable, trailing string) {
+	lines := make([][]string, 0, len(t.Rows)+1)
+	lines = append(lines, t.Columns)
+
+	inline := f.inline
+	f.inline = true
+
+	for _, row := range t.Rows {
+		cells := make([]string, len(row.Values))
+		for i, v := range row.Values {
+			cells[i] = f.formatValue(v)
+		}
+
+		lines = append(lines, cells)
+	}
+
+	f.inline = inline
+
+	var widths []int
+
+	for _, cells := range lines {
+		for i, cell := range cells {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	f.writeLine(prefix + "data " + t.Label + " {")
+	f.indent++
+
+	for i, cells := range lines {
+		var b strings.Builder
+
+		if i == 0 {
+			b.WriteString("  ")
+		} else {
+			b.WriteString("| ")
+		}
+
+		for j, cell := range cells {
+			if j == len(cells)-1 {
+				b.WriteString(cell)
+
+				break
+			}
+
+			b.WriteString(cell + ",")
+			b.WriteString(strings.Repeat(" ", widths[j]-len(cell)+1))
+		}
+
+		f.writeLine(b.String())
+	}
+
+	f.indent--
+	f.writeCommentedLine("}", trailing)
+}
+
 func (f *formatter) formatSetupCall(c *SetupCall) string {
 	var b strings.Builder
 
-	b.WriteString(c.Module)
-	b.WriteString(".")
-	b.WriteString(c.Query)
+	b.WriteString(c.Ref())
 	b.WriteString("(")
 
 	for i, p := range c.Params {
@@ -195,9 +525,31 @@ func (f *formatter) formatTeardown(body string) {
 	f.writeLine("teardown " + f.rawString(body))
 }
 
+func (f *formatter) formatProfile(p *Profile) {
+	f.writeLeadingComments(p.LeadingComments)
+	f.writeLine("profile " + p.Name + " {")
+	f.indent++
+
+	if p.Setup != nil {
+		f.formatSetupClause(p.Setup)
+	}
+
+	if p.Teardown != nil {
+		f.formatTeardown(*p.Teardown)
+	}
+
+	f.indent--
+	f.writeLine("}")
+}
+
 func (f *formatter) formatScope(s *QueryScope) {
 	f.writeLeadingComments(s.LeadingComments)
-	f.writeLine(s.QueryName + " {")
+
+	if s.Extends != nil {
+		f.writeLine(s.QueryName + " extends " + *s.Extends + " {")
+	} else {
+		f.writeLine(s.QueryName + " {")
+	}
 	f.indent++
 
 	if s.Setup != nil {
@@ -210,6 +562,21 @@ func (f *formatter) formatScope(s *QueryScope) {
 
 	f.formatItems(s.Items, s.Setup != nil || s.Teardown != nil)
 
+	// Promoted trailing clauses were written above; only duplicates stay at the end.
+	for _, c := range s.Trailing {
+		if c.Promoted(s) {
+			continue
+		}
+
+		f.blankLine()
+
+		if c.Setup != nil {
+			f.formatSetupClause(c.Setup)
+		} else {
+			f.formatTeardown(*c.Teardown)
+		}
+	}
+
 	f.indent--
 	f.writeLine("}")
 }
@@ -230,10 +597,33 @@ func (f *formatter) formatItems(items []*TestOrGroup, hasSetupOrTeardown bool) {
 			}
 
 			f.formatGroup(item.Group)
+		} else if item.Sequence != nil {
+			if needsBlank {
+				f.blankLine()
+			}
+
+			f.formatSequence(item.Sequence)
 		}
 	}
 }
 
+func (f *formatter) formatSequence(q *Sequence) {
+	f.writeLeadingComments(q.LeadingComments)
+	f.writeLine("sequence {")
+	f.indent++
+
+	for i, t := range q.Tests {
+		if i > 0 {
+			f.blankLine()
+		}
+
+		f.formatTest(t)
+	}
+
+	f.indent--
+	f.writeLine("}")
+}
+
 func (f *formatter) formatGroup(g *Group) {
 	f.writeLeadingComments(g.LeadingComments)
 	f.writeLine("group " + f.quotedString(g.Name) + " {")
@@ -255,6 +645,15 @@ func (f *formatter) formatGroup(g *Group) {
 
 func (f *formatter) formatTest(t *Test) {
 	f.writeLeadingComments(t.LeadingComments)
+
+	if f.opts.CompactSingleStatement && isSingleStatement(t) {
+		s := t.Statements[0]
+		f.writeCommentedLine("test "+f.quotedString(t.Name)+" { "+s.Key()+": "+f.formatValue(s.Value)+" }",
+			t.TrailingComment)
+
+		return
+	}
+
 	f.writeLine("test " + f.quotedString(t.Name) + " {")
 	f.indent++
 
@@ -284,16 +683,34 @@ func (f *formatter) formatTest(t *Test) {
 
 	// Format outputs with blank line separator from inputs
 	for i, stmt := range outputs {
-		if i == 0 && len(inputs) > 0 {
+		if i == 0 && len(inputs) > 0 && !f.opts.NoBlankLineBetweenInputsAndOutputs {
 			f.blankLine()
 		}
 
 		f.formatStatement(stmt)
 	}
 
+	// Expected row as a whole
+	if t.Expect != nil {
+		if len(t.Statements) > 0 || t.Setup != nil {
+			f.blankLine()
+		}
+
+		f.writeLine("expect " + f.formatMap(t.Expect))
+	}
+
+	// Expected rows
+	if len(t.ExpectedRows) > 0 {
+		if len(t.Statements) > 0 || t.Expect != nil || t.Setup != nil {
+			f.blankLine()
+		}
+
+		f.formatRows(t.ExpectedRows)
+	}
+
 	// Assertions
 	for i, a := range t.Asserts {
-		if i == 0 && (len(t.Statements) > 0 || t.Setup != nil) {
+		if i == 0 && (len(t.Statements) > 0 || t.Expect != nil || len(t.ExpectedRows) > 0 || t.Setup != nil) {
 			f.blankLine()
 		}
 
@@ -301,14 +718,48 @@ func (f *formatter) formatTest(t *Test) {
 	}
 
 	f.indent--
-	f.writeLine("}")
+	f.writeCommentedLine("}", t.TrailingComment)
+}
+
+// isSingleStatement reports whether a test consists of exactly one statement,
+// with no comments that would be lost on one line.
+func isSingleStatement(t *Test) bool {
+	return len(t.Statements) == 1 && t.Setup == nil && t.Expect == nil && len(t.ExpectedRows) == 0 &&
+		len(t.Asserts) == 0 && len(t.Statements[0].LeadingComments) == 0 && t.Statements[0].TrailingComment == ""
 }
 
 func (f *formatter) formatStatement(s *Statement) {
-	f.writeLine(s.Key() + ": " + f.formatValue(s.Value))
+	f.writeLeadingComments(s.LeadingComments)
+	f.writeCommentedLine(s.Key()+": "+f.formatValue(s.Value), s.TrailingComment)
+}
+
+func (f *formatter) formatRows(rows []*Map) {
+	f.writeLine("rows {")
+	f.indent++
+
+	inline := f.inline
+	f.inline = true
+
+	for i, row := range rows {
+		if i < len(rows)-1 {
+			f.writeLine(f.formatMap(row) + ",")
+		} else {
+			f.writeLine(f.formatMap(row))
+		}
+	}
+
+	f.inline = inline
+	f.indent--
+	f.writeLine("}")
 }
 
 func (f *formatter) formatAssert(a *Assert) {
+	if a.Idempotent {
+		f.writeLine("assert idempotent")
+
+		return
+	}
+
 	var queryPart string
 	if a.Query != nil {
 		if a.Query.Inline != nil {
@@ -359,21 +810,41 @@ func (f *formatter) formatValue(v *Value) string {
 	switch {
 	case v.Null:
 		return "null"
+	case v.Absent:
+		return "absent"
 	case v.Str != nil:
 		return f.quotedString(*v.Str)
 	case v.Number != nil:
 		return f.formatNumber(*v.Number)
 	case v.Boolean != nil:
 		return strconv.FormatBool(bool(*v.Boolean))
+	case v.Bytes != nil:
+		return v.Bytes.String()
 	case v.Map != nil:
 		return f.formatMap(v.Map)
 	case v.List != nil:
 		return f.formatList(v.List)
+	case v.Computed != nil:
+		return f.formatComputed(v.Computed)
 	default:
 		return "null"
 	}
 }
 
+func (f *formatter) formatComputed(c *Computed) string {
+	args := make([]string, len(c.Args))
+
+	for i, a := range c.Args {
+		if a.Ref != nil {
+			args[i] = a.Ref.String()
+		} else if a.Value != nil {
+			args[i] = f.formatValue(a.Value)
+		}
+	}
+
+	return c.Func + "(" + strings.Join(args, ", ") + ")"
+}
+
 func (f *formatter) formatParamValue(v *ParamValue) string {
 	if v.IsFieldRef() {
 		return v.FieldRefString()
@@ -399,6 +870,10 @@ func (f *formatter) formatMap(m *Map) string {
 		return "{}"
 	}
 
+	if f.breaksMap(m) {
+		return f.formatMapLines(m)
+	}
+
 	parts := make([]string, len(m.Entries))
 	for i, e := range m.Entries {
 		parts[i] = e.Key + ": " + f.formatValue(e.Value)
@@ -407,6 +882,58 @@ func (f *formatter) formatMap(m *Map) string {
 	return "{" + strings.Join(parts, ", ") + "}"
 }
 
+// breaksMap reports whether m is written across lines: it has several entries
+// and its one-line form is wider than MaxInlineMapWidth.
+func (f *formatter) breaksMap(m *Map) bool {
+	if f.opts.MaxInlineMapWidth <= 0 || f.inline || len(m.Entries) < 2 {
+		return false
+	}
+
+	f.inline = true
+	oneLine := f.formatMap(m)
+	f.inline = false
+
+	return utf8.RuneCountInString(oneLine) > f.opts.MaxInlineMapWidth
+}
+
+// formatMapLines writes m with one entry per line, indented a level past the
+// line it opens on, and each value aligned after the longest key:
+//
+//	{
+//		id:   1,
+//		name: "Alice"
+//	}
+func (f *formatter) formatMapLines(m *Map) string {
+	width := 0
+	for _, e := range m.Entries {
+		width = max(width, utf8.RuneCountInString(e.Key))
+	}
+
+	var b strings.Builder
+
+	b.WriteString("{\n")
+
+	f.depth++
+
+	for i, e := range m.Entries {
+		b.WriteString(strings.Repeat(f.unit, f.indent+f.depth))
+		b.WriteString(e.Key + ":" + strings.Repeat(" ", width-utf8.RuneCountInString(e.Key)+1))
+		b.WriteString(f.formatValue(e.Value))
+
+		if i < len(m.Entries)-1 {
+			b.WriteString(",")
+		}
+
+		b.WriteString("\n")
+	}
+
+	f.depth--
+
+	b.WriteString(strings.Repeat(f.unit, f.indent+f.depth) + "}")
+
+	return b.String()
+}
+
 func (f *formatter) formatList(l *List) string {
 	if len(l.Values) == 0 {
 		return "[]"
@@ -1,6 +1,7 @@
 package scaf_test
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -374,6 +375,72 @@ Q {
 		assert ` + "`MATCH (n) RETURN n`" + ` {}
 	}
 }
+`,
+		},
+		{
+			name: "expected rows",
+			suite: &scaf.Suite{
+				Queries: []*scaf.Query{{Name: "Q", Body: "Q"}},
+				Scopes: []*scaf.QueryScope{
+					{
+						QueryName: "Q",
+						Items: []*scaf.TestOrGroup{
+							{
+								Test: &scaf.Test{
+									Name: "t",
+									Statements: []*scaf.Statement{
+										scaf.NewStatement("$limit", &scaf.Value{Number: ptr(2.0)}),
+									},
+									ExpectedRows: []*scaf.Map{
+										{Entries: []*scaf.MapEntry{{Key: "name", Value: &scaf.Value{Str: ptr("Alice")}}}},
+										{Entries: []*scaf.MapEntry{{Key: "name", Value: &scaf.Value{Str: ptr("Bob")}}}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: `query Q ` + "`Q`" + `
+
+Q {
+	test "t" {
+		$limit: 2
+
+		rows {
+			{name: "Alice"},
+			{name: "Bob"}
+		}
+	}
+}
+`,
+		},
+		{
+			name: "profile and extends",
+			suite: &scaf.Suite{
+				Queries: []*scaf.Query{{Name: "Q", Body: "Q"}},
+				Profiles: []*scaf.Profile{
+					{Name: "Base", Setup: inlineSetup("CREATE (:User)"), Teardown: ptr("CLEANUP")},
+				},
+				Scopes: []*scaf.QueryScope{
+					{
+						QueryName: "Q",
+						Extends:   ptr("Base"),
+						Items:     []*scaf.TestOrGroup{{Test: &scaf.Test{Name: "t"}}},
+					},
+				},
+			},
+			expected: `query Q ` + "`Q`" + `
+
+profile Base {
+	setup ` + "`CREATE (:User)`" + `
+	teardown ` + "`CLEANUP`" + `
+}
+
+Q extends Base {
+	test "t" {
+	}
+}
 `,
 		},
 		{
@@ -418,6 +485,7 @@ func TestFormatValues(t *testing.T) {
 		expected string
 	}{
 		{name: "null", value: &scaf.Value{Null: true}, expected: "null"},
+		{name: "absent", value: &scaf.Value{Absent: true}, expected: "absent"},
 		{name: "string", value: &scaf.Value{Str: ptr("hello")}, expected: `"hello"`},
 		{name: "integer", value: &scaf.Value{Number: ptr(42.0)}, expected: "42"},
 		{name: "float", value: &scaf.Value{Number: ptr(3.14)}, expected: "3.14"},
@@ -426,6 +494,7 @@ func TestFormatValues(t *testing.T) {
 		{name: "zero", value: &scaf.Value{Number: ptr(0.0)}, expected: "0"},
 		{name: "bool true", value: &scaf.Value{Boolean: boolPtr(true)}, expected: "true"},
 		{name: "bool false", value: &scaf.Value{Boolean: boolPtr(false)}, expected: "false"},
+		{name: "bytes", value: &scaf.Value{Bytes: scaf.Bytes{0xde, 0xad, 0xbe, 0xef}}, expected: `bytes("3q2+7w==")`},
 		{name: "empty list", value: &scaf.Value{List: &scaf.List{}}, expected: "[]"},
 		{
 			name: "list with values",
@@ -563,8 +632,10 @@ Q {
 Q {
 	test "complex" {
 		list: [1, "two", true, null]
+		u.middleName: absent
 		map: {a: 1, b: "two"}
 		nested: {arr: [1, {x: true}]}
+		blob: bytes("3q2+7w==")
 	}
 }
 `,
@@ -582,6 +653,137 @@ Q {
 		}
 	}
 }
+`,
+		},
+		{
+			name: "expected rows",
+			input: `query Q ` + "`Q`" + `
+
+Q {
+	test "t" {
+		$limit: 2
+
+		rows {
+			{name: "Alice"},
+			{name: "Bob", age: 30}
+		}
+
+		assert { name == "Alice" }
+	}
+}
+`,
+		},
+		{
+			name: "idempotent assert",
+			input: `query UpsertUser ` + "`MERGE (u:User {id: $id}) RETURN u.id`" + `
+
+UpsertUser {
+	test "t" {
+		$id: 1
+
+		assert idempotent
+		assert ` + "`MATCH (u:User) RETURN count(u) as c`" + ` { c == 1 }
+	}
+}
+`,
+		},
+		{
+			name: "query param defaults",
+			input: `query ListUsers($limit = 10, $role = "admin") ` + "`MATCH (u:User) RETURN u LIMIT $limit`" + `
+
+ListUsers {
+	test "t" {
+		$role: "user"
+	}
+}
+`,
+		},
+		{
+			name: "profiles",
+			input: `import fixtures "./fixtures"
+
+query Q ` + "`Q`" + `
+
+// Shared user fixtures.
+profile Base {
+	setup fixtures.SetupUsers()
+}
+
+profile Empty {
+}
+
+Q extends Base {
+	setup ` + "`CREATE (:Post)`" + `
+
+	test "t" {
+		$id: 1
+	}
+}
+`,
+		},
+		{
+			name: "unaliased import",
+			input: `import "./fixtures"
+
+query Q ` + "`Q`" + `
+
+Q {
+	setup CreateUser($id: 1)
+
+	test "t" {
+		$id: 1
+	}
+}
+`,
+		},
+		{
+			name: "setup bindings and computed values",
+			input: `import fixtures "./fixtures"
+
+query Q ` + "`Q`" + `
+
+setup all = ` + "`MATCH (n) RETURN n`" + `
+
+Q {
+	setup {
+		users = data User {
+			  id
+			| 1
+		}
+		posts = fixtures.CreatePosts($n: 2)
+	}
+
+	test "t" {
+		count: len(users)
+		most: max(len(users), len(posts), 2)
+	}
+}
+`,
+		},
+		{
+			name: "skip directive with reason",
+			input: `query Q ` + "`Q`" + `
+
+Q {
+	// scaf:skip("flaky on CI")
+	test "t" {}
+}
+`,
+		},
+		{
+			name: "typed query params",
+			input: `query GetUser($id: int, $tags: []string, $limit: int = 10) ` + "`MATCH (u:User) RETURN u`" + `
+`,
+		},
+		{
+			name: "re-exports",
+			input: `import fixtures "./fixtures"
+
+export fixtures
+// Users live elsewhere.
+export { CreateUser, DeleteUser } from "./users"
+
+query Q ` + "`Q`" + `
 `,
 		},
 	}
@@ -612,10 +814,52 @@ Q {
 			if diff := cmp.Diff(formatted, formatted2); diff != "" {
 				t.Errorf("Format() not idempotent (-first +second):\n%s", diff)
 			}
+
+			// So should they under every layout option.
+			for _, opts := range formatOptionCombinations() {
+				once := scaf.FormatWithOptions(suite, opts)
+
+				reparsed, err := scaf.Parse([]byte(once))
+				if err != nil {
+					t.Fatalf("Parse() of output formatted with %+v error: %v\nFormatted:\n%s", opts, err, once)
+				}
+
+				if diff := cmp.Diff(once, scaf.FormatWithOptions(reparsed, opts)); diff != "" {
+					t.Errorf("FormatWithOptions(%+v) not idempotent (-first +second):\n%s", opts, diff)
+				}
+			}
 		})
 	}
 }
 
+// formatOptionCombinations returns every combination of the layout options.
+func formatOptionCombinations() []scaf.FormatOptions {
+	var combinations []scaf.FormatOptions
+
+	for _, compact := range []bool{false, true} {
+		for _, style := range []scaf.IndentStyle{scaf.IndentTabs, scaf.IndentSpaces} {
+			for _, width := range []int{0, 2} {
+				for _, noBlank := range []bool{false, true} {
+					for _, sorted := range []bool{false, true} {
+						for _, mapWidth := range []int{0, 12} {
+							combinations = append(combinations, scaf.FormatOptions{
+								CompactSingleStatement:             compact,
+								IndentStyle:                        style,
+								IndentWidth:                        width,
+								NoBlankLineBetweenInputsAndOutputs: noBlank,
+								SortDeclarations:                   sorted,
+								MaxInlineMapWidth:                  mapWidth,
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return combinations
+}
+
 func TestFormatPreservesSemantics(t *testing.T) {
 	t.Parallel()
 
@@ -887,6 +1131,76 @@ func TestFormatEmptySuite(t *testing.T) {
 	}
 }
 
+func TestFormatEmptyGroupsAndScopes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:  "empty group",
+			input: "query Q `Q`\nQ { group \"g\" {} test \"t\" {} }\n",
+			expected: `query Q ` + "`Q`" + `
+
+Q {
+	group "g" {
+	}
+
+	test "t" {
+	}
+}
+`,
+		},
+		{
+			name:  "setup-only scope",
+			input: "query Q `Q`\nQ { setup `CREATE (:Node)` teardown `MATCH (n) DELETE n` }\n",
+			expected: `query Q ` + "`Q`" + `
+
+Q {
+	setup ` + "`CREATE (:Node)`" + `
+	teardown ` + "`MATCH (n) DELETE n`" + `
+}
+`,
+		},
+		{
+			name:  "empty scope",
+			input: "query Q `Q`\nQ {}\n",
+			expected: `query Q ` + "`Q`" + `
+
+Q {
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite, err := scaf.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+
+			got := scaf.Format(suite)
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Fatalf("Format() mismatch (-want +got):\n%s", diff)
+			}
+
+			reparsed, err := scaf.Parse([]byte(got))
+			if err != nil {
+				t.Fatalf("Parse(formatted) error: %v", err)
+			}
+
+			if again := scaf.Format(reparsed); again != got {
+				t.Errorf("Format() is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
+
 func TestFormatQueryOnly(t *testing.T) {
 	t.Parallel()
 
@@ -958,9 +1272,10 @@ Q {
 	}
 }
 
-func TestFormatWithComments(t *testing.T) {
-	// Not parallel - trivia state requires serialized access
-	input := "// File-level comment\nquery GetUser `MATCH (u:User) RETURN u`\n\n// Scope comment\nGetUser {\n\t// Group comment\n\tgroup \"tests\" {\n\t\t// Test comment\n\t\ttest \"finds user\" {\n\t\t\t$id: 1\n\t\t}\n\t}\n}\n"
+func TestFormatTrailingSetup(t *testing.T) {
+	t.Parallel()
+
+	input := "query Q `Q`\n\nQ {\n\ttest \"t\" {\n\t\t$id: 1\n\t}\n\tteardown `DROP`\n\tsetup `CREATE`\n}\n"
 
 	result, err := scaf.Parse([]byte(input))
 	if err != nil {
@@ -968,28 +1283,52 @@ func TestFormatWithComments(t *testing.T) {
 	}
 
 	got := scaf.Format(result)
+	want := "query Q `Q`\n\nQ {\n\tsetup `CREATE`\n\tteardown `DROP`\n\n\ttest \"t\" {\n\t\t$id: 1\n\t}\n}\n"
 
-	// The formatter should preserve comments
-	if !strings.Contains(got, "// File-level comment") {
-		t.Errorf("Missing file-level comment in output:\n%s", got)
+	if got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
 	}
 
-	if !strings.Contains(got, "// Scope comment") {
-		t.Errorf("Missing scope comment in output:\n%s", got)
+	// Clauses between tests are hoisted too, and the tests after them kept.
+	input = "query Q `Q`\n\nQ {\n\ttest \"a\" {}\n\tsetup `CREATE` // seed\n\ttest \"b\" {}\n}\n"
+
+	result, err = scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
 	}
 
-	if !strings.Contains(got, "// Group comment") {
-		t.Errorf("Missing group comment in output:\n%s", got)
+	got = scaf.Format(result)
+	want = "query Q `Q`\n\nQ {\n\tsetup `CREATE` // seed\n\n\ttest \"a\" {\n\t}\n\n\ttest \"b\" {\n\t}\n}\n"
+
+	if got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
 	}
+}
 
-	if !strings.Contains(got, "// Test comment") {
-		t.Errorf("Missing test comment in output:\n%s", got)
+func TestFormatSequence(t *testing.T) {
+	t.Parallel()
+
+	input := "query Q `Q`\nQ {\n\ttest \"alone\" {}\n\t// steps\n\tsequence {\n" +
+		"\t\ttest \"create\" { $id: 1 }\n\t\ttest \"read\" { u.id: 1 }\n\t}\n}\n"
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	got := scaf.Format(result)
+	want := "query Q `Q`\n\nQ {\n\ttest \"alone\" {\n\t}\n\n\t// steps\n\tsequence {\n" +
+		"\t\ttest \"create\" {\n\t\t\t$id: 1\n\t\t}\n\n\t\ttest \"read\" {\n\t\t\tu.id: 1\n\t\t}\n\t}\n}\n"
+
+	if got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
 	}
 }
 
-func TestFormatWithTrailingComments(t *testing.T) {
-	// Not parallel - trivia state requires serialized access
-	input := "query GetUser `MATCH (u:User) RETURN u` // query comment\n\nGetUser {\n\ttest \"finds user\" {\n\t\t$id: 1\n\t}\n}\n"
+func TestFormatMeta(t *testing.T) {
+	t.Parallel()
+
+	input := "// Users\nmeta {\n\towner: \"team-x\",\n\tjira: \"ABC-123\"\n}\nquery Q `Q`\n"
 
 	result, err := scaf.Parse([]byte(input))
 	if err != nil {
@@ -997,9 +1336,684 @@ func TestFormatWithTrailingComments(t *testing.T) {
 	}
 
 	got := scaf.Format(result)
+	want := "// Users\nmeta {owner: \"team-x\", jira: \"ABC-123\"}\n\nquery Q `Q`\n"
 
-	// The formatter should preserve trailing comments
-	if !strings.Contains(got, "// query comment") {
-		t.Errorf("Missing trailing comment in output:\n%s", got)
+	if got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+
+	again, err := scaf.Parse([]byte(got))
+	if err != nil {
+		t.Fatalf("Parse(formatted) error: %v", err)
+	}
+
+	if scaf.Format(again) != got {
+		t.Errorf("Format() is not stable:\n%s", scaf.Format(again))
+	}
+}
+
+func TestFormatExpect(t *testing.T) {
+	t.Parallel()
+
+	input := "query Q `Q`\nQ {\n\ttest \"t\" {\n\t\t$id: 1\n\t\texpect {\n\t\t\tname: \"Alice\",\n\t\t\tage: 30\n\t\t}\n\t\tassert { name != \"\" }\n\t}\n}\n"
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	got := scaf.Format(result)
+	want := "query Q `Q`\n\nQ {\n\ttest \"t\" {\n\t\t$id: 1\n\n\t\texpect {name: \"Alice\", age: 30}\n\n\t\tassert { name != \"\" }\n\t}\n}\n"
+
+	if got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+
+	again, err := scaf.Parse([]byte(got))
+	if err != nil {
+		t.Fatalf("Parse(formatted) error: %v", err)
+	}
+
+	if scaf.Format(again) != got {
+		t.Errorf("Format() is not stable:\n%s", scaf.Format(again))
+	}
+}
+
+func TestFormatShebang(t *testing.T) {
+	t.Parallel()
+
+	input := "#!scaf dialect=cypher\n// Users\nquery Q `Q`\n"
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if got := scaf.Format(result); got != input {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, input)
+	}
+}
+
+func TestFormatWithComments(t *testing.T) {
+	// Not parallel - trivia state requires serialized access
+	input := "// File-level comment\nquery GetUser `MATCH (u:User) RETURN u`\n\n// Scope comment\nGetUser {\n\t// Group comment\n\tgroup \"tests\" {\n\t\t// Test comment\n\t\ttest \"finds user\" {\n\t\t\t$id: 1\n\t\t}\n\t}\n}\n"
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	got := scaf.Format(result)
+
+	// The formatter should preserve comments
+	if !strings.Contains(got, "// File-level comment") {
+		t.Errorf("Missing file-level comment in output:\n%s", got)
+	}
+
+	if !strings.Contains(got, "// Scope comment") {
+		t.Errorf("Missing scope comment in output:\n%s", got)
+	}
+
+	if !strings.Contains(got, "// Group comment") {
+		t.Errorf("Missing group comment in output:\n%s", got)
+	}
+
+	if !strings.Contains(got, "// Test comment") {
+		t.Errorf("Missing test comment in output:\n%s", got)
+	}
+}
+
+func TestFormatWithTrailingComments(t *testing.T) {
+	// Not parallel - trivia state requires serialized access
+	input := "query GetUser `MATCH (u:User) RETURN u` // query comment\n\nGetUser {\n\ttest \"finds user\" {\n\t\t$id: 1\n\t}\n}\n"
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	got := scaf.Format(result)
+
+	// The formatter should preserve trailing comments
+	if !strings.Contains(got, "// query comment") {
+		t.Errorf("Missing trailing comment in output:\n%s", got)
+	}
+}
+
+func TestFormatStatementComments(t *testing.T) {
+	// Not parallel - trivia state requires serialized access
+	input := `query Q ` + "`Q`" + `
+
+Q {
+	test "t" {
+		u.name: "Alice"   // expected name
+		$id: 1 // the user id
+		// output follows inputs
+		u.age: 30//age
+		$role: "admin"	// last input
+	}
+
+	test "one" { $id: 2 } // whole test
+	test "commented" {
+		$id: 3 // kept on its own line
+	}
+}
+`
+
+	want := `query Q ` + "`Q`" + `
+
+Q {
+	test "t" {
+		$id: 1 // the user id
+		$role: "admin" // last input
+
+		u.name: "Alice" // expected name
+		// output follows inputs
+		u.age: 30 //age
+	}
+
+	test "one" { $id: 2 } // whole test
+
+	test "commented" {
+		$id: 3 // kept on its own line
+	}
+}
+`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if got := scaf.FormatWithOptions(result, scaf.FormatOptions{CompactSingleStatement: true}); got != want {
+		t.Fatalf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+
+	reparsed, err := scaf.Parse([]byte(want))
+	if err != nil {
+		t.Fatalf("Parse(formatted) error: %v", err)
+	}
+
+	if got := scaf.FormatWithOptions(reparsed, scaf.FormatOptions{CompactSingleStatement: true}); got != want {
+		t.Errorf("Format() is not idempotent:\n%s", got)
+	}
+}
+
+func TestFormatDataTable(t *testing.T) {
+	t.Parallel()
+
+	input := `query Q ` + "`Q`" + `
+Q {
+	setup { data User { id, name, active | 1, "Alice", true | 100, "Bo", false } }
+	test "t" {}
+}
+`
+
+	want := `query Q ` + "`Q`" + `
+
+Q {
+	setup {
+		data User {
+			  id,  name,    active
+			| 1,   "Alice", true
+			| 100, "Bo",    false
+		}
+	}
+
+	test "t" {
+	}
+}
+`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if got := scaf.Format(result); got != want {
+		t.Fatalf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+
+	reparsed, err := scaf.Parse([]byte(want))
+	if err != nil {
+		t.Fatalf("Parse(formatted) error: %v", err)
+	}
+
+	if got := scaf.Format(reparsed); got != want {
+		t.Errorf("Format() is not idempotent:\n%s", got)
+	}
+}
+
+func TestFormatSetupBlockComments(t *testing.T) {
+	// Not parallel - trivia state requires serialized access
+	input := `query Q ` + "`Q`" + `
+
+Q {
+	// Seed the database
+	setup {
+		// Base users
+		fixtures
+		// Posts for the first user
+		fixtures.CreatePosts($n: 10) // ten is enough
+		` + "`CREATE (:Marker)`" + `
+	}
+
+	test "t" {
+		setup {
+			// Only step
+			fixtures.CreateUser($id: 1)
+		}
+
+		$id: 1
+	}
+}
+`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	block := result.Scopes[0].Setup.Block
+	if len(block) != 3 {
+		t.Fatalf("setup block items = %d, want 3", len(block))
+	}
+
+	if got := block[1].LeadingComments; len(got) != 1 || got[0] != "// Posts for the first user" {
+		t.Errorf("item leading comments = %q", got)
+	}
+
+	if got := block[1].TrailingComment; got != "// ten is enough" {
+		t.Errorf("item trailing comment = %q", got)
+	}
+
+	got := scaf.Format(result)
+	if got != input {
+		t.Errorf("Format() did not round-trip setup block comments:\n--- got ---\n%s\n--- want ---\n%s", got, input)
+	}
+}
+
+func TestFormatCompactSingleStatement(t *testing.T) {
+	t.Parallel()
+
+	input := `query Q ` + "`Q`" + `
+
+Q {
+	test "single" {
+		$x: 1
+	}
+	test "inline" { u.name: "Alice" }
+	test "two statements" { $x: 1 u.name: "Alice" }
+	test "asserted" {
+		$x: 1
+		assert { u.age > 18 }
+	}
+	test "with setup" {
+		setup ` + "`CREATE (:User)`" + `
+		$x: 1
+	}
+}
+`
+
+	compact := `query Q ` + "`Q`" + `
+
+Q {
+	test "single" { $x: 1 }
+
+	test "inline" { u.name: "Alice" }
+
+	test "two statements" {
+		$x: 1
+
+		u.name: "Alice"
+	}
+
+	test "asserted" {
+		$x: 1
+
+		assert { u.age > 18 }
+	}
+
+	test "with setup" {
+		setup ` + "`CREATE (:User)`" + `
+
+		$x: 1
+	}
+}
+`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	opts := scaf.FormatOptions{CompactSingleStatement: true}
+
+	got := scaf.FormatWithOptions(suite, opts)
+	if diff := cmp.Diff(compact, got); diff != "" {
+		t.Errorf("FormatWithOptions() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Compact output parses back and formats identically.
+	reparsed, err := scaf.Parse([]byte(got))
+	if err != nil {
+		t.Fatalf("Parse() of compact output error: %v", err)
+	}
+
+	if again := scaf.FormatWithOptions(reparsed, opts); again != got {
+		t.Errorf("compact formatting is not idempotent:\n%s", again)
+	}
+
+	// Without the option, single-statement tests expand.
+	if expanded := scaf.Format(reparsed); !strings.Contains(expanded, "test \"single\" {\n\t\t$x: 1\n\t}") {
+		t.Errorf("Format() did not expand single-statement test:\n%s", expanded)
+	}
+}
+
+func TestFormatIndentAndBlankLines(t *testing.T) {
+	t.Parallel()
+
+	input := `query Q ` + "`Q`" + `
+
+Q {
+	group "g" {
+		test "t" {
+			$x: 1
+
+			u.name: "Alice"
+		}
+	}
+}
+`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts scaf.FormatOptions
+		want string
+	}{
+		{
+			name: "default spaces",
+			opts: scaf.FormatOptions{IndentStyle: scaf.IndentSpaces},
+			want: "query Q `Q`\n\nQ {\n    group \"g\" {\n        test \"t\" {\n            $x: 1\n\n" +
+				"            u.name: \"Alice\"\n        }\n    }\n}\n",
+		},
+		{
+			name: "two spaces without blank line",
+			opts: scaf.FormatOptions{IndentStyle: scaf.IndentSpaces, IndentWidth: 2, NoBlankLineBetweenInputsAndOutputs: true},
+			want: "query Q `Q`\n\nQ {\n  group \"g\" {\n    test \"t\" {\n      $x: 1\n" +
+				"      u.name: \"Alice\"\n    }\n  }\n}\n",
+		},
+		{
+			name: "tabs ignore width",
+			opts: scaf.FormatOptions{IndentWidth: 2},
+			want: input,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, scaf.FormatWithOptions(suite, tt.opts)); diff != "" {
+				t.Errorf("FormatWithOptions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatSortDeclarations(t *testing.T) {
+	t.Parallel()
+
+	input := `// scaf:export
+// Zeta comes first in the source.
+query Zeta ` + "`Z`" + `
+
+// Alpha's comment.
+query Alpha ` + "`A`" + ` // trailing
+
+setup ` + "`CREATE ()`" + `
+
+Zeta {
+	test "z" {
+		$id: 1
+	}
+}
+
+// First Alpha scope.
+Alpha {
+	test "one" {
+		$id: 1
+	}
+}
+
+// Second Alpha scope.
+Alpha {
+	test "two" {
+		$id: 1
+	}
+}
+`
+
+	want := `// Alpha's comment.
+query Alpha ` + "`A`" + ` // trailing
+
+// scaf:export
+// Zeta comes first in the source.
+query Zeta ` + "`Z`" + `
+
+setup ` + "`CREATE ()`" + `
+
+// First Alpha scope.
+Alpha {
+	test "one" {
+		$id: 1
+	}
+}
+
+// Second Alpha scope.
+Alpha {
+	test "two" {
+		$id: 1
+	}
+}
+
+Zeta {
+	test "z" {
+		$id: 1
+	}
+}
+`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	opts := scaf.FormatOptions{SortDeclarations: true}
+
+	got := scaf.FormatWithOptions(suite, opts)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FormatWithOptions() mismatch (-want +got):\n%s", diff)
+	}
+
+	reparsed, err := scaf.Parse([]byte(got))
+	if err != nil {
+		t.Fatalf("Parse() of sorted output error: %v", err)
+	}
+
+	if again := scaf.FormatWithOptions(reparsed, opts); again != got {
+		t.Errorf("sorted formatting is not idempotent:\n%s", again)
+	}
+
+	// The directive still applies to the query it moved with.
+	for _, q := range reparsed.Queries {
+		if q.HasDirective(scaf.DirectiveExport) != (q.Name == "Zeta") {
+			t.Errorf("query %s HasDirective(export) = %v", q.Name, q.HasDirective(scaf.DirectiveExport))
+		}
+	}
+}
+
+func TestFormatMaxInlineMapWidth(t *testing.T) {
+	t.Parallel()
+
+	input := `meta {owner: "data-team", tier: 1}
+
+query Q ` + "`Q`" + `
+
+Q {
+	test "t" {
+		short: {a: 1}
+		u: {id: 1, name: "Alice", address: {city: "Paris", zip: "75001"}, tags: [{k: "a", v: 1}]}
+
+		expect {u: {id: 1}}
+
+		rows {
+			{id: 1, name: "a long row that stays on one line"}
+		}
+	}
+}
+`
+
+	want := `meta {
+	owner: "data-team",
+	tier:  1
+}
+
+query Q ` + "`Q`" + `
+
+Q {
+	test "t" {
+		short: {a: 1}
+		u: {
+			id:      1,
+			name:    "Alice",
+			address: {
+				city: "Paris",
+				zip:  "75001"
+			},
+			tags:    [{k: "a", v: 1}]
+		}
+
+		expect {u: {id: 1}}
+
+		rows {
+			{id: 1, name: "a long row that stays on one line"}
+		}
+	}
+}
+`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	opts := scaf.FormatOptions{MaxInlineMapWidth: 20}
+
+	got := scaf.FormatWithOptions(suite, opts)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FormatWithOptions() mismatch (-want +got):\n%s", diff)
+	}
+
+	reparsed, err := scaf.Parse([]byte(got))
+	if err != nil {
+		t.Fatalf("Parse() of broken maps error: %v", err)
+	}
+
+	// Breaking maps changes only the layout.
+	if diff := cmp.Diff(scaf.Format(suite), scaf.Format(reparsed)); diff != "" {
+		t.Errorf("broken maps parse to a different suite (-want +got):\n%s", diff)
+	}
+
+	if again := scaf.FormatWithOptions(reparsed, opts); again != got {
+		t.Errorf("map breaking is not idempotent:\n%s", again)
+	}
+}
+
+func TestFormatBlankLinesBetweenDeclarations(t *testing.T) {
+	t.Parallel()
+
+	input := `import a "./a"
+import b "./b"
+
+
+import c "./c"
+
+query One ` + "`1`" + `
+query Two ` + "`2`" + ` // two
+
+
+
+// Three's comment.
+query Three ` + "`3`" + `
+
+One {
+	test "t" {
+		$x: 1
+	}
+}
+Two {
+	test "t" {
+		$x: 1
+	}
+}
+`
+
+	want := `import a "./a"
+import b "./b"
+
+import c "./c"
+
+query One ` + "`1`" + `
+query Two ` + "`2`" + ` // two
+
+// Three's comment.
+query Three ` + "`3`" + `
+
+One {
+	test "t" {
+		$x: 1
+	}
+}
+Two {
+	test "t" {
+		$x: 1
+	}
+}
+`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	got := scaf.Format(suite)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Format() mismatch (-want +got):\n%s", diff)
+	}
+
+	reparsed, err := scaf.Parse([]byte(got))
+	if err != nil {
+		t.Fatalf("Parse() of formatted output error: %v", err)
+	}
+
+	if again := scaf.Format(reparsed); again != got {
+		t.Errorf("Format() is not idempotent:\n%s", again)
+	}
+
+	// Without source positions, queries and scopes are separated by one blank line.
+	built := &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "One", Body: "1"}, {Name: "Two", Body: "2"}},
+		Scopes:  []*scaf.QueryScope{{QueryName: "One"}, {QueryName: "Two"}},
+	}
+
+	wantBuilt := "query One `1`\n\nquery Two `2`\n\nOne {\n}\n\nTwo {\n}\n"
+	if got := scaf.Format(built); got != wantBuilt {
+		t.Errorf("Format() of a built suite = %q, want %q", got, wantBuilt)
+	}
+}
+
+func TestFormatSource(t *testing.T) {
+	t.Parallel()
+
+	got, err := scaf.FormatSource([]byte("query Q `Q`\nQ { test \"a\" { $x: 1 } }"))
+	if err != nil {
+		t.Fatalf("FormatSource() error: %v", err)
+	}
+
+	want := "query Q `Q`\n\nQ {\n\ttest \"a\" {\n\t\t$x: 1\n\t}\n}\n"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("FormatSource() mismatch (-want +got):\n%s", diff)
+	}
+
+	compact, err := scaf.FormatSourceWithOptions(got, scaf.FormatOptions{CompactSingleStatement: true})
+	if err != nil {
+		t.Fatalf("FormatSourceWithOptions() error: %v", err)
+	}
+
+	if !strings.Contains(string(compact), "test \"a\" { $x: 1 }") {
+		t.Errorf("FormatSourceWithOptions() did not compact:\n%s", compact)
+	}
+}
+
+func TestFormatSource_ParseError(t *testing.T) {
+	t.Parallel()
+
+	input := []byte("query Q `Q`\nQ { test \"a\" { $x: }\n")
+
+	got, err := scaf.FormatSource(input)
+
+	var parseErr *scaf.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("FormatSource() error = %v, want *scaf.ParseError", err)
+	}
+
+	if string(got) != string(input) {
+		t.Errorf("FormatSource() = %q, want input unchanged", got)
 	}
 }
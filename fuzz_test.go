@@ -0,0 +1,55 @@
+package scaf_test
+
+import (
+	"testing"
+
+	"github.com/rlch/scaf"
+)
+
+// FuzzParseFormat checks that parsing arbitrary input never panics, and that
+// formatting a file that parses yields a file that parses to the same output.
+// Failing inputs found by go test -fuzz are saved under testdata/fuzz and
+// rerun as regression seeds by plain go test.
+func FuzzParseFormat(f *testing.F) {
+	seeds := []string{
+		"",
+		"query Q `Q`",
+		"#!scaf dialect=cypher\nmeta {owner: \"x\"}\nquery Q `MATCH (u:User {id: $id}) RETURN u.name AS name`\n",
+		"import fixtures \"./fixtures\"\nquery Q `Q`\nsetup fixtures.CreateUser($id: 1)\nQ {\n\ttest \"t\" {\n\t\t$id: 1\n\t\tname: \"Alice\"\n\t}\n}\n",
+		"query Q `Q`\nQ {\n\tgroup \"g\" {\n\t\ttest \"a\" {\n\t\t\tassert { x > 1 && y == [1, 2] }\n\t\t}\n\t}\n}\n",
+		"query Q `Q`\nQ {\n\tsequence {\n\t\ttest \"a\" {}\n\t\ttest \"b\" { n: {a: 1, b: [true, null]} }\n\t}\n}\n",
+		"query Q(limit = 10) `Q`\nprofile Base { setup `CREATE ()` }\nQ extends Base {\n\t// scaf:skip\n\ttest \"t\" {\n\t\trows { {a: 1}, {a: 2} }\n\t}\n}\n",
+		"query Q `Q`\nQ {\n\ttest \"t\" {\n\t\t$id: 1\n\t\texpect {name: \"x\", tags: [1]}\n\t}\n}\n",
+		"query Q `RETURN count(*)`\nQ {\n\ttest \"t\" {\n\t\tresult[0]: 1\n\t\tresult: 2\n\t}\n}\n",
+		"query Q `Q`\nQ {\n\ttest \"t\" {\n\t\tn: 1 // out\n\t\t// in\n\t\t$id: 1//id\n\t} // t\n}\n",
+		"query Q `Q`\nQ {\n\ttest \"t\" {\n\t\tsetup fixtures.",
+		"Q { test \"t\" { a: 1 } ",
+		"query Q `unterminated",
+		"\"unterminated",
+		"#!",
+	}
+
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = scaf.ParseWithRecovery(data, true)
+
+		suite, err := scaf.Parse(data)
+		if err != nil {
+			return
+		}
+
+		formatted := scaf.Format(suite)
+
+		again, err := scaf.Parse([]byte(formatted))
+		if err != nil {
+			t.Fatalf("Parse(Format(input)) error: %v\ninput:\n%s\nformatted:\n%s", err, data, formatted)
+		}
+
+		if got := scaf.Format(again); got != formatted {
+			t.Fatalf("Format is not idempotent\nfirst:\n%s\nsecond:\n%s", formatted, got)
+		}
+	})
+}
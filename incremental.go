@@ -0,0 +1,352 @@
+package scaf
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// ReparseScope returns the suite for data, an edit of prevData whose suite is
+// prev, by parsing only the query scope the edit falls in. The scopes before it
+// are shared with prev, and the ones after it are copied with their positions
+// moved past the edit; prev itself is not modified.
+//
+// It returns false when the edit isn't confined to the body of one scope,
+// changes how the scope parses (e.g. removing its closing brace), or the scope
+// holds a comment that attaches to a node outside it, in which case the caller
+// should parse data in full.
+func ReparseScope(prev *Suite, prevData, data []byte) (*Suite, bool) {
+	if prev == nil || len(prev.Scopes) == 0 {
+		return nil, false
+	}
+
+	start, oldEnd, newEnd := editRange(prevData, data)
+
+	i := slices.IndexFunc(prev.Scopes, func(scope *QueryScope) bool {
+		open, ok := scopeOpenOffset(scope)
+
+		return ok && start > open && oldEnd < scope.EndPos.Offset
+	})
+	if i < 0 {
+		return nil, false
+	}
+
+	scope := prev.Scopes[i]
+	delta := newEnd - oldEnd
+	scopeEnd := scope.EndPos.Offset + delta
+
+	sub, err := Parse(blankOutside(data, scope.Pos.Offset, scopeEnd))
+	if err != nil || len(sub.Scopes) != 1 || len(sub.Queries) != 0 || len(sub.Imports) != 0 ||
+		len(sub.Exports) != 0 || len(sub.Profiles) != 0 || sub.Setup != nil || sub.Teardown != nil ||
+		sub.Meta != nil {
+		return nil, false
+	}
+
+	scoped := sub.Scopes[0]
+	if scoped.Pos != scope.Pos || scoped.EndPos.Offset != scopeEnd {
+		return nil, false
+	}
+
+	// Comments the scope doesn't hold, before or after the edit, attach across
+	// its boundary, which only a full parse resolves.
+	if !commentsAttached(scope) || !commentsAttached(scoped) {
+		return nil, false
+	}
+
+	// Trivia before the scope, and comments attached from outside its braces,
+	// were blanked out of the partial parse; they are unchanged.
+	scoped.Tokens = append(tokensBefore(scope.Tokens, scope.Pos.Offset), tokensFrom(scoped.Tokens, scope.Pos.Offset)...)
+	scoped.LeadingComments = slices.Clone(scope.LeadingComments)
+	scoped.TrailingComment = scope.TrailingComment
+
+	shift := newPositionShift(prevData, data, oldEnd, newEnd)
+
+	suite := *prev
+	suite.EndPos = shift.position(prev.EndPos)
+	suite.Scopes = slices.Clone(prev.Scopes)
+	suite.Scopes[i] = scoped
+
+	for j := i + 1; j < len(suite.Scopes) && !shift.identity(); j++ {
+		later := suite.Scopes[j].Clone()
+		shift.apply(reflect.ValueOf(later), map[uintptr]bool{})
+		suite.Scopes[j] = later
+	}
+
+	suite.Tokens = tokensBefore(prev.Tokens, scope.Pos.Offset)
+	suite.Tokens = append(suite.Tokens, tokensFrom(scoped.Tokens, scope.Pos.Offset)...)
+
+	for _, tok := range tokensFrom(prev.Tokens, scope.EndPos.Offset) {
+		tok.Pos = shift.position(tok.Pos)
+		suite.Tokens = append(suite.Tokens, tok)
+	}
+
+	suite.BodySpans = nil
+
+	for _, span := range prev.BodySpans {
+		switch {
+		case span.Start.Offset < scope.Pos.Offset:
+			suite.BodySpans = append(suite.BodySpans, span)
+		case span.Start.Offset >= scope.EndPos.Offset:
+			suite.BodySpans = append(suite.BodySpans, Span{Start: shift.position(span.Start), End: shift.position(span.End)})
+		}
+	}
+
+	suite.BodySpans = append(suite.BodySpans, sub.BodySpans...)
+	slices.SortFunc(suite.BodySpans, func(a, b Span) int { return a.Start.Offset - b.Start.Offset })
+
+	return &suite, true
+}
+
+// editRange returns the byte range an edit replaced: [start, oldEnd) in old
+// became [start, newEnd) in updated.
+func editRange(old, updated []byte) (start, oldEnd, newEnd int) {
+	limit := min(len(old), len(updated))
+
+	for start < limit && old[start] == updated[start] {
+		start++
+	}
+
+	suffix := 0
+	for suffix < limit-start && old[len(old)-1-suffix] == updated[len(updated)-1-suffix] {
+		suffix++
+	}
+
+	return start, len(old) - suffix, len(updated) - suffix
+}
+
+// scopeOpenOffset returns the offset of the scope's opening brace.
+func scopeOpenOffset(scope *QueryScope) (int, bool) {
+	for _, tok := range scope.Tokens {
+		if tok.Type == TokenLBrace {
+			return tok.Pos.Offset, true
+		}
+	}
+
+	return 0, false
+}
+
+// commentsAttached reports whether every comment in the scope's braces is
+// attached to a node inside it. One that isn't, such as a comment with no
+// node after it in its block, attaches to whatever follows, possibly a
+// later scope.
+func commentsAttached(scope *QueryScope) bool {
+	comments := 0
+
+	for _, tok := range tokensFrom(scope.Tokens, scope.Pos.Offset) {
+		if tok.Type == TokenComment {
+			comments++
+		}
+	}
+
+	attached := countComments(reflect.ValueOf(scope), map[uintptr]bool{}) - len(scope.LeadingComments)
+	if scope.TrailingComment != "" {
+		attached--
+	}
+
+	return attached == comments
+}
+
+// countComments counts the comments attached to nodes reachable from v
+// through exported fields, visiting each pointer once.
+func countComments(v reflect.Value, seen map[uintptr]bool) int {
+	switch v.Kind() { //nolint:exhaustive // Only containers can hold comments.
+	case reflect.Pointer:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+
+		seen[v.Pointer()] = true
+
+		return countComments(v.Elem(), seen)
+	case reflect.Slice:
+		if v.Type() == tokenSliceType {
+			return 0
+		}
+
+		n := 0
+		for i := range v.Len() {
+			n += countComments(v.Index(i), seen)
+		}
+
+		return n
+	case reflect.Struct:
+		if v.Type() == commentMetaType {
+			meta := v.Interface().(CommentMeta) //nolint:forcetypeassert // Checked above.
+
+			n := len(meta.LeadingComments)
+			if meta.TrailingComment != "" {
+				n++
+			}
+
+			return n
+		}
+
+		n := 0
+		for _, i := range fieldsWithPositions(v.Type()) {
+			n += countComments(v.Field(i), seen)
+		}
+
+		return n
+	}
+
+	return 0
+}
+
+// blankOutside copies data with everything outside [start, end) replaced by
+// spaces, keeping newlines so that positions inside the range are unchanged.
+func blankOutside(data []byte, start, end int) []byte {
+	out := bytes.Clone(data)
+
+	for i, b := range out {
+		if (i < start || i >= end) && b != '\n' {
+			out[i] = ' '
+		}
+	}
+
+	return out
+}
+
+// tokensBefore returns the tokens starting before offset.
+func tokensBefore(tokens []lexer.Token, offset int) []lexer.Token {
+	i := slices.IndexFunc(tokens, func(tok lexer.Token) bool { return tok.Pos.Offset >= offset })
+	if i < 0 {
+		return slices.Clone(tokens)
+	}
+
+	return slices.Clone(tokens[:i])
+}
+
+// tokensFrom returns the tokens starting at or after offset.
+func tokensFrom(tokens []lexer.Token, offset int) []lexer.Token {
+	i := slices.IndexFunc(tokens, func(tok lexer.Token) bool { return tok.Pos.Offset >= offset })
+	if i < 0 {
+		return nil
+	}
+
+	return tokens[i:]
+}
+
+// positionShift moves positions at or after the end of an edit to where they
+// are once the edit is applied.
+type positionShift struct {
+	offsets, lines int
+	endLine        int // line the edit ended on, before the edit
+	columns        int // column change on endLine
+}
+
+func newPositionShift(old, updated []byte, oldEnd, newEnd int) positionShift {
+	oldLine, oldCol := lineColumn(old, oldEnd)
+	newLine, newCol := lineColumn(updated, newEnd)
+
+	return positionShift{
+		offsets: newEnd - oldEnd,
+		lines:   newLine - oldLine,
+		endLine: oldLine,
+		columns: newCol - oldCol,
+	}
+}
+
+// lineColumn returns the 1-based line and column of offset, counting columns
+// in runes as the lexer does.
+func lineColumn(data []byte, offset int) (int, int) {
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+
+	return bytes.Count(data[:offset], []byte{'\n'}) + 1, utf8.RuneCount(data[lineStart:offset]) + 1
+}
+
+// identity reports whether the edit left every later position in place,
+// as replacing a character with another on the same line does.
+func (s positionShift) identity() bool {
+	return s.offsets == 0 && s.lines == 0 && s.columns == 0
+}
+
+func (s positionShift) position(pos lexer.Position) lexer.Position {
+	if pos.Line == 0 {
+		return pos // unset, e.g. a node that wasn't recovered
+	}
+
+	if pos.Line == s.endLine {
+		pos.Column += s.columns
+	}
+
+	pos.Offset += s.offsets
+	pos.Line += s.lines
+
+	return pos
+}
+
+var (
+	positionType    = reflect.TypeFor[lexer.Position]()
+	tokenSliceType  = reflect.TypeFor[[]lexer.Token]()
+	commentMetaType = reflect.TypeFor[CommentMeta]()
+
+	// positionFields caches, per struct type, the exported fields that can hold positions.
+	positionFields sync.Map // reflect.Type -> []int
+)
+
+// apply shifts every position reachable from v through exported fields,
+// visiting each pointer once (promoted trailing clauses share theirs).
+func (s positionShift) apply(v reflect.Value, seen map[uintptr]bool) {
+	switch v.Kind() { //nolint:exhaustive // Only containers can hold positions.
+	case reflect.Pointer:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+
+		seen[v.Pointer()] = true
+		s.apply(v.Elem(), seen)
+	case reflect.Slice:
+		if v.Type() == tokenSliceType {
+			tokens := v.Interface().([]lexer.Token) //nolint:forcetypeassert // Checked above.
+			for i := range tokens {
+				tokens[i].Pos = s.position(tokens[i].Pos)
+			}
+
+			return
+		}
+
+		for i := range v.Len() {
+			s.apply(v.Index(i), seen)
+		}
+	case reflect.Struct:
+		if v.Type() == positionType {
+			pos := v.Addr().Interface().(*lexer.Position) //nolint:forcetypeassert // Checked above.
+			*pos = s.position(*pos)
+
+			return
+		}
+
+		for _, i := range fieldsWithPositions(v.Type()) {
+			s.apply(v.Field(i), seen)
+		}
+	}
+}
+
+// fieldsWithPositions returns the indices of t's exported fields that are
+// pointers, slices, or structs, the only kinds that can hold a position.
+func fieldsWithPositions(t reflect.Type) []int {
+	if fields, ok := positionFields.Load(t); ok {
+		return fields.([]int) //nolint:forcetypeassert // Only []int is stored.
+	}
+
+	var fields []int
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		switch f.Type.Kind() { //nolint:exhaustive // Only containers can hold positions.
+		case reflect.Pointer, reflect.Slice, reflect.Struct:
+			if f.IsExported() {
+				fields = append(fields, i)
+			}
+		}
+	}
+
+	positionFields.Store(t, fields)
+
+	return fields
+}
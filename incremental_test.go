@@ -0,0 +1,105 @@
+package scaf_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rlch/scaf"
+)
+
+const incrementalBase = `// Users
+query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u.name AS name`" + `
+query CountUsers ` + "`MATCH (u:User) RETURN count(u) AS n`" + `
+
+// Lookups
+GetUser {
+	setup ` + "`CREATE (:User {id: 1, name: \"Alice\"})`" + `
+
+	test "finds Alice" {
+		$id: 1
+		name: "Alice"
+	}
+
+	// scaf:skip
+	group "missing" {
+		test "none" {
+			$id: 2
+			name: null
+		}
+	}
+} // trailing
+
+CountUsers {
+	test "counts" {
+		n: 0
+		assert ` + "`MATCH (u) RETURN count(u) AS c`" + ` { c == 0 }
+	}
+	teardown ` + "`MATCH (n) DETACH DELETE n`" + `
+}
+`
+
+func TestReparseScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		old    string
+		new    string
+		reused bool
+	}{
+		{"edit the setup", `name: "Alice"`, `name: "Alicia"`, true},
+		{"change a value", "name: null", `name: "Bob"`, true},
+		{"replace a character", "$id: 2", "$id: 3", true},
+		{"add a statement", "\t\t$id: 1\n", "\t\t$id: 1\n\t\t$other: \"é\"\n", true},
+		{"add a test", "\t// scaf:skip\n", "\ttest \"new\" {}\n\n\t// scaf:skip\n", true},
+		{"remove a group", "\t// scaf:skip\n\tgroup \"missing\" {\n\t\ttest \"none\" {\n\t\t\t$id: 2\n\t\t\tname: null\n\t\t}\n\t}\n", "", true},
+		{"edit the last scope", "n: 0", "n: 10", true},
+		{"edit a query", "RETURN count(u) AS n`", "RETURN count(u) AS total`", false},
+		{"rename a scope", "CountUsers {", "GetUser {", false},
+		{"span two scopes", "} // trailing\n\nCountUsers {", "", false},
+		{"close the scope early", "\t\tname: \"Alice\"\n\t}\n", "\t\tname: \"Alice\"\n\t}\n}\n", false},
+		{"break the scope", "$id: 1\n", "$id: [1\n", false},
+	}
+
+	prev, err := scaf.Parse([]byte(incrementalBase))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			edited := strings.Replace(incrementalBase, tt.old, tt.new, 1)
+			if edited == incrementalBase {
+				t.Fatalf("edit %q not found", tt.old)
+			}
+
+			got, ok := scaf.ReparseScope(prev, []byte(incrementalBase), []byte(edited))
+			if ok != tt.reused {
+				t.Fatalf("ReparseScope() ok = %v, want %v", ok, tt.reused)
+			}
+
+			if !ok {
+				return
+			}
+
+			want, err := scaf.Parse([]byte(edited))
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+
+			if diff := cmp.Diff(want, got, cmp.Exporter(func(reflect.Type) bool { return true })); diff != "" {
+				t.Errorf("ReparseScope() differs from Parse() (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// The previous suite is left as it was.
+	again, _ := scaf.Parse([]byte(incrementalBase))
+	if diff := cmp.Diff(again, prev, cmp.Exporter(func(reflect.Type) bool { return true })); diff != "" {
+		t.Errorf("ReparseScope() modified prev (-want +got):\n%s", diff)
+	}
+}
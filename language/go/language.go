@@ -186,6 +186,16 @@ func (g *generator) Generate() (map[string][]byte, error) {
 	return files, nil
 }
 
+// generatedBanner returns the standard "Code generated" comment recognised by
+// Go tooling, naming the source file when known.
+func generatedBanner(sourceName string) string {
+	if sourceName == "" {
+		return "// Code generated by scaf. DO NOT EDIT."
+	}
+
+	return "// Code generated by scaf from " + sourceName + ". DO NOT EDIT."
+}
+
 // extractSignatures builds FuncSignature for each query in the suite.
 func (g *generator) extractSignatures() ([]*FuncSignature, error) {
 	return ExtractSignatures(g.ctx.Suite, g.ctx.QueryAnalyzer, g.ctx.Schema)
@@ -3,6 +3,7 @@ package golang
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/rlch/scaf"
@@ -44,20 +45,19 @@ func ExtractTestCases(scope *scaf.QueryScope) []*TestCase {
 
 // extractFromTestOrGroup recursively extracts test cases from a TestOrGroup.
 func extractFromTestOrGroup(item *scaf.TestOrGroup) []*TestCase {
-	if item.Test != nil {
-		return []*TestCase{extractFromTest(item.Test)}
+	var cases []*TestCase
+
+	for _, test := range item.Tests() {
+		cases = append(cases, extractFromTest(test))
 	}
 
 	if item.Group != nil {
-		var cases []*TestCase
 		for _, child := range item.Group.Items {
 			cases = append(cases, extractFromTestOrGroup(child)...)
 		}
-
-		return cases
 	}
 
-	return nil
+	return cases
 }
 
 // extractFromTest extracts a single TestCase from a Test.
@@ -177,7 +177,7 @@ func (m *mockGenerator) needsReflectImport(mocks []*MockFunc) bool {
 }
 
 func (m *mockGenerator) writeHeader() {
-	m.buf.WriteString("// Code generated by scaf. DO NOT EDIT.\n")
+	m.buf.WriteString(generatedBanner(m.ctx.SourceName) + "\n")
 	m.buf.WriteString("//go:build !scaf_prod\n\n")
 	fmt.Fprintf(m.buf, "package %s\n\n", m.packageName)
 
@@ -416,13 +416,20 @@ func (m *mockGenerator) findOutputValue(tc *TestCase, ret FuncReturn) string {
 		return goLiteral(val)
 	}
 
-	// Try to find by field name with any prefix (e.g., "u.name" -> "name")
-	for key, val := range tc.Outputs {
+	// Try to find by field name with any prefix (e.g., "u.name" -> "name").
+	// Keys are sorted so the chosen value is stable across runs.
+	keys := make([]string, 0, len(tc.Outputs))
+	for key := range tc.Outputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
 		parts := strings.Split(key, ".")
 		fieldName := parts[len(parts)-1]
 
 		if fieldName == ret.Name {
-			return goLiteral(val)
+			return goLiteral(tc.Outputs[key])
 		}
 	}
 
@@ -476,7 +483,7 @@ func toLower(r rune) rune {
 // isComplexType returns true if the value requires reflect.DeepEqual for comparison.
 func isComplexType(v any) bool {
 	switch v.(type) {
-	case map[string]any, []any:
+	case map[string]any, []any, []byte:
 		return true
 	default:
 		return false
@@ -503,6 +510,8 @@ func goLiteral(v any) string {
 		return mapLiteral(val)
 	case []any:
 		return sliceLiteral(val)
+	case []byte:
+		return fmt.Sprintf("[]byte(%q)", val)
 	default:
 		return fmt.Sprintf("%v", v)
 	}
@@ -513,9 +522,15 @@ func mapLiteral(m map[string]any) string {
 		return "map[string]any{}"
 	}
 
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	pairs := make([]string, 0, len(m))
-	for k, v := range m {
-		pairs = append(pairs, fmt.Sprintf("%q: %s", k, goLiteral(v)))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%q: %s", k, goLiteral(m[k])))
 	}
 
 	return fmt.Sprintf("map[string]any{%s}", strings.Join(pairs, ", "))
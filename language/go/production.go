@@ -66,7 +66,7 @@ func (p *productionGenerator) writeResultStructs() {
 }
 
 func (p *productionGenerator) writeHeader() {
-	p.buf.WriteString("// Code generated by scaf. DO NOT EDIT.\n\n")
+	p.buf.WriteString(generatedBanner(p.ctx.SourceName) + "\n\n")
 	fmt.Fprintf(p.buf, "package %s\n\n", p.packageName)
 
 	// Collect imports from binding
@@ -2,6 +2,7 @@ package golang
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -241,9 +242,15 @@ func lookupFieldType(fieldName string, schema *analysis.TypeSchema) *analysis.Ty
 		return nil
 	}
 
-	// Search all models for a matching field
-	for _, model := range schema.Models {
-		for _, field := range model.Fields {
+	// Search all models for a matching field, in name order for stable results
+	names := make([]string, 0, len(schema.Models))
+	for name := range schema.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, field := range schema.Models[name].Fields {
 			if field.Name == fieldName {
 				return field.Type
 			}
@@ -36,6 +36,10 @@ type GenerateContext struct {
 
 	// OutputDir is the directory where files will be written.
 	OutputDir string
+
+	// SourceName is the name of the input .scaf file (e.g., "users.scaf").
+	// Generators mention it in the generated-file banner. May be empty.
+	SourceName string
 }
 
 // Registration for language discovery.
@@ -39,6 +39,7 @@ const (
 	TokenTest     // test
 	TokenGroup    // group
 	TokenAssert   // assert
+	TokenShebang  // #! line at the very start of a file
 )
 
 // keywords maps keyword strings to their token types.
@@ -68,11 +69,16 @@ type LexerError struct {
 }
 
 func (e *LexerError) Error() string {
+	return e.pos.String() + ": " + e.message()
+}
+
+// message returns the error message without position information.
+func (e *LexerError) message() string {
 	if e.ch != 0 {
-		return e.pos.String() + ": " + e.msg + ": " + string(e.ch)
+		return e.msg + ": " + string(e.ch)
 	}
 
-	return e.pos.String() + ": " + e.msg
+	return e.msg
 }
 
 func (e *LexerError) withPos(pos lexer.Position) *LexerError {
@@ -123,6 +129,7 @@ func newDSLLexer() *dslDefinition {
 			"test":     TokenTest,
 			"group":    TokenGroup,
 			"assert":   TokenAssert,
+			"Shebang":  TokenShebang,
 		},
 	}
 }
@@ -211,6 +218,15 @@ func (l *lexerState) Next() (lexer.Token, error) {
 	start := l.pos()
 	r := l.peek()
 
+	// Shebang - only recognized as the first line of a file
+	if l.offset == 0 && strings.HasPrefix(l.input, "#!") {
+		for !l.eof() && l.peek() != '\n' {
+			l.advance()
+		}
+
+		return l.token(TokenShebang, start), nil
+	}
+
 	// Whitespace - track blank lines for "detached" comment detection
 	if isSpace(r) {
 		newlineCount := 0
@@ -534,6 +550,20 @@ func IsKeywordToken(typ lexer.TokenType) bool {
 		typ == TokenAssert
 }
 
+// IsIdentifier reports whether name lexes as a single identifier that isn't a
+// keyword or a $-prefixed parameter, as query names and import aliases must.
+func IsIdentifier(name string) bool {
+	for i, r := range name {
+		if i == 0 && (r == '$' || !isIdentStart(r)) || i > 0 && !isIdentContinue(r) {
+			return false
+		}
+	}
+
+	_, isKeyword := keywords[name]
+
+	return name != "" && !isKeyword
+}
+
 // Character helpers.
 
 func isSpace(r rune) bool {
@@ -99,6 +99,32 @@ func TestLexer_Identifiers(t *testing.T) {
 	}
 }
 
+func TestIsIdentifier(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"GetUser", true},
+		{"_private", true},
+		{"résumé2", true},
+		{"", false},
+		{"123Invalid", false},
+		{"$userId", false},
+		{"get-user", false},
+		{"users.Get", false},
+		{"test", false},
+		{"query", false},
+	}
+
+	for _, tt := range tests {
+		if got := scaf.IsIdentifier(tt.name); got != tt.want {
+			t.Errorf("IsIdentifier(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 func TestLexer_Numbers(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,452 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+
+	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/analysis"
+)
+
+// Call hierarchy treats queries as the "functions" of a scaf project.
+// A query is called by the scopes that test it, by asserts that run it, and by
+// setup clauses (in any file) that invoke it through an import. Outgoing calls
+// are the queries a scope, group or test invokes from its setup and assert blocks.
+
+// queryCall is a single reference to a query from within a suite.
+type queryCall struct {
+	// caller is the item (scope, group, test or suite setup) making the call.
+	caller protocol.CallHierarchyItem
+	// scope is the enclosing query scope name (empty for suite-level setup).
+	scope string
+	// module is the import alias for cross-file calls (empty for local queries).
+	module string
+	// query is the name of the called query.
+	query string
+	// rng is the range of the query name at the call site.
+	rng protocol.Range
+	// scopeRef is true when the call is a scope declaration testing the query.
+	scopeRef bool
+}
+
+// PrepareCallHierarchy handles textDocument/prepareCallHierarchy requests.
+// Resolves the query under the cursor, following setup calls into imported modules.
+func (s *Server) PrepareCallHierarchy(
+	_ context.Context,
+	params *protocol.CallHierarchyPrepareParams,
+) ([]protocol.CallHierarchyItem, error) {
+	s.logger.Debug("PrepareCallHierarchy",
+		zap.String("uri", string(params.TextDocument.URI)),
+		zap.Uint32("line", params.Position.Line),
+		zap.Uint32("character", params.Position.Character))
+
+	doc, ok := s.getDocument(params.TextDocument.URI)
+	if !ok || doc.Analysis == nil || doc.Analysis.Suite == nil {
+		return nil, nil
+	}
+
+	pos := analysis.PositionToLexer(params.Position.Line, params.Position.Character)
+	tokenCtx := analysis.GetTokenContext(doc.Analysis, pos)
+
+	var item *protocol.CallHierarchyItem
+
+	switch node := tokenCtx.Node.(type) {
+	case *scaf.Query:
+		item = queryCallItem(doc.URI, node)
+
+	case *scaf.QueryScope:
+		item = s.localQueryCallItem(doc, node.QueryName)
+
+	case *scaf.AssertQuery:
+		if node.QueryName != nil {
+			item = s.localQueryCallItem(doc, *node.QueryName)
+		}
+
+	case *scaf.SetupCall:
+		if tokenCtx.Token != nil && tokenCtx.Token.Value == node.Query {
+			item = s.importedQueryCallItem(URIToPath(doc.URI), doc.Analysis, node.Module, node.Query)
+		}
+	}
+
+	if item == nil {
+		return nil, nil
+	}
+
+	return []protocol.CallHierarchyItem{*item}, nil
+}
+
+// IncomingCalls handles callHierarchy/incomingCalls requests.
+// Returns the scopes, tests and setups (across the workspace) that reference a query.
+func (s *Server) IncomingCalls(
+	_ context.Context,
+	params *protocol.CallHierarchyIncomingCallsParams,
+) ([]protocol.CallHierarchyIncomingCall, error) {
+	s.logger.Debug("IncomingCalls",
+		zap.String("uri", string(params.Item.URI)),
+		zap.String("name", params.Item.Name))
+
+	if params.Item.Kind != protocol.SymbolKindFunction {
+		return nil, nil // Only queries can be called
+	}
+
+	targetPath := URIToPath(params.Item.URI)
+	queryName := params.Item.Name
+
+	var (
+		calls []protocol.CallHierarchyIncomingCall
+		index = make(map[string]int)
+	)
+
+	s.forEachScafFile(func(uri protocol.DocumentURI, f *analysis.AnalyzedFile) {
+		path := URIToPath(uri)
+
+		for _, call := range collectQueryCalls(uri, f.Suite) {
+			if call.query != queryName || s.callTargetPath(path, f, call) != targetPath {
+				continue
+			}
+
+			key := callItemKey(call.caller)
+			if i, ok := index[key]; ok {
+				calls[i].FromRanges = append(calls[i].FromRanges, call.rng)
+
+				continue
+			}
+
+			index[key] = len(calls)
+			calls = append(calls, protocol.CallHierarchyIncomingCall{
+				From:       call.caller,
+				FromRanges: []protocol.Range{call.rng},
+			})
+		}
+	})
+
+	return calls, nil
+}
+
+// OutgoingCalls handles callHierarchy/outgoingCalls requests.
+// For a query, returns the queries invoked by the setup and assert blocks of its scopes.
+// For a scope, group or test, returns the queries invoked within it.
+func (s *Server) OutgoingCalls(
+	_ context.Context,
+	params *protocol.CallHierarchyOutgoingCallsParams,
+) ([]protocol.CallHierarchyOutgoingCall, error) {
+	s.logger.Debug("OutgoingCalls",
+		zap.String("uri", string(params.Item.URI)),
+		zap.String("name", params.Item.Name))
+
+	path := URIToPath(params.Item.URI)
+
+	f := s.analysisForURI(params.Item.URI)
+	if f == nil || f.Suite == nil {
+		return nil, nil
+	}
+
+	var (
+		calls []protocol.CallHierarchyOutgoingCall
+		index = make(map[string]int)
+	)
+
+	for _, call := range collectQueryCalls(params.Item.URI, f.Suite) {
+		if call.scopeRef {
+			continue
+		}
+
+		if params.Item.Kind == protocol.SymbolKindFunction {
+			if call.scope != params.Item.Name {
+				continue
+			}
+		} else if !rangeContains(params.Item.Range, call.rng) {
+			continue
+		}
+
+		var callee *protocol.CallHierarchyItem
+		if call.module == "" {
+			if q, ok := f.Symbols.Queries[call.query]; ok && q.Node != nil {
+				callee = queryCallItem(params.Item.URI, q.Node)
+			}
+		} else {
+			callee = s.importedQueryCallItem(path, f, call.module, call.query)
+		}
+
+		if callee == nil {
+			continue
+		}
+
+		key := callItemKey(*callee)
+		if i, ok := index[key]; ok {
+			calls[i].FromRanges = append(calls[i].FromRanges, call.rng)
+
+			continue
+		}
+
+		index[key] = len(calls)
+		calls = append(calls, protocol.CallHierarchyOutgoingCall{
+			To:         *callee,
+			FromRanges: []protocol.Range{call.rng},
+		})
+	}
+
+	return calls, nil
+}
+
+// localQueryCallItem returns a call hierarchy item for a query defined in doc.
+func (s *Server) localQueryCallItem(doc *Document, queryName string) *protocol.CallHierarchyItem {
+	q, ok := doc.Analysis.Symbols.Queries[queryName]
+	if !ok || q.Node == nil {
+		return nil
+	}
+
+	return queryCallItem(doc.URI, q.Node)
+}
+
+// importedQueryCallItem returns a call hierarchy item for a query in an imported module.
+func (s *Server) importedQueryCallItem(
+	basePath string,
+	f *analysis.AnalyzedFile,
+	moduleAlias, queryName string,
+) *protocol.CallHierarchyItem {
+	imp, ok := f.Symbols.Imports[moduleAlias]
+	if !ok || s.fileLoader == nil {
+		return nil
+	}
+
+	importedPath := s.fileLoader.ResolveImportPath(basePath, imp.Path)
+
+	imported := s.analysisForURI(PathToURI(importedPath))
+	if imported == nil || imported.Symbols == nil {
+		return nil
+	}
+
+	q, ok := imported.Symbols.Queries[queryName]
+	if !ok || q.Node == nil {
+		return nil
+	}
+
+	return queryCallItem(PathToURI(importedPath), q.Node)
+}
+
+// callTargetPath returns the path of the file defining the query a call refers to.
+func (s *Server) callTargetPath(path string, f *analysis.AnalyzedFile, call queryCall) string {
+	if call.module == "" {
+		return path
+	}
+
+	imp, ok := f.Symbols.Imports[call.module]
+	if !ok || s.fileLoader == nil {
+		return ""
+	}
+
+	return s.fileLoader.ResolveImportPath(path, imp.Path)
+}
+
+// analysisForURI returns the analysis of an open document, or loads it from disk.
+func (s *Server) analysisForURI(uri protocol.DocumentURI) *analysis.AnalyzedFile {
+	if doc, ok := s.getDocument(uri); ok && doc.Analysis != nil {
+		return doc.Analysis
+	}
+
+	if s.fileLoader == nil {
+		return nil
+	}
+
+	f, err := s.fileLoader.LoadAndAnalyze(URIToPath(uri))
+	if err != nil {
+		return nil
+	}
+
+	return f
+}
+
+// forEachScafFile calls fn for every open document and every .scaf file in the workspace.
+// Open documents take precedence over their on-disk content.
+func (s *Server) forEachScafFile(fn func(uri protocol.DocumentURI, f *analysis.AnalyzedFile)) {
+	s.mu.RLock()
+	open := make(map[protocol.DocumentURI]*analysis.AnalyzedFile, len(s.documents))
+	for uri, doc := range s.documents {
+		if doc.Analysis != nil && doc.Analysis.Suite != nil {
+			open[uri] = doc.Analysis
+		}
+	}
+	s.mu.RUnlock()
+
+	for uri, f := range open {
+		fn(uri, f)
+	}
+
+	if s.workspaceRoot == "" || s.fileLoader == nil {
+		return
+	}
+
+	err := filepath.Walk(s.workspaceRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip errors
+		}
+
+		if info.IsDir() || !strings.HasSuffix(path, ".scaf") {
+			return nil
+		}
+
+		uri := PathToURI(path)
+		if _, isOpen := open[uri]; isOpen {
+			return nil
+		}
+
+		analyzed, err := s.fileLoader.LoadAndAnalyze(path)
+		if err != nil || analyzed.Suite == nil {
+			return nil
+		}
+
+		fn(uri, analyzed)
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Debug("Error walking workspace for call hierarchy", zap.Error(err))
+	}
+}
+
+// collectQueryCalls returns every query reference in a suite, attributed to its innermost caller.
+func collectQueryCalls(uri protocol.DocumentURI, suite *scaf.Suite) []queryCall {
+	if suite == nil {
+		return nil
+	}
+
+	var calls []queryCall
+
+	addSetup := func(caller protocol.CallHierarchyItem, scope string, setup *scaf.SetupClause) {
+		if setup == nil {
+			return
+		}
+
+		setupCalls := []*scaf.SetupCall{setup.Call}
+		for _, item := range setup.Block {
+			setupCalls = append(setupCalls, item.Call)
+		}
+
+		for _, call := range setupCalls {
+			if call == nil || call.Query == "" {
+				continue
+			}
+
+			calls = append(calls, queryCall{
+				caller: caller,
+				scope:  scope,
+				module: call.Module,
+				query:  call.Query,
+				rng:    setupCallQueryRange(call),
+			})
+		}
+	}
+
+	var addItems func(scope string, parent string, items []*scaf.TestOrGroup)
+
+	addItems = func(scope string, parent string, items []*scaf.TestOrGroup) {
+		for _, item := range items {
+			if item == nil {
+				continue
+			}
+
+			for _, test := range item.Tests() {
+				caller := callItem(uri, test.Name, protocol.SymbolKindMethod, parent+"/"+test.Name,
+					spanToRange(test.Span()), testNameRange(test))
+				addSetup(caller, scope, test.Setup)
+
+				for _, assert := range test.Asserts {
+					if assert.Query == nil || assert.Query.QueryName == nil {
+						continue
+					}
+
+					calls = append(calls, queryCall{
+						caller: caller,
+						scope:  scope,
+						query:  *assert.Query.QueryName,
+						rng:    assertQueryNameRange(assert.Query),
+					})
+				}
+			}
+
+			if group := item.Group; group != nil {
+				path := parent + "/" + group.Name
+				caller := callItem(uri, group.Name, protocol.SymbolKindNamespace, path,
+					spanToRange(group.Span()), groupNameRange(group))
+				addSetup(caller, scope, group.Setup)
+				addItems(scope, path, group.Items)
+			}
+		}
+	}
+
+	if suite.Setup != nil {
+		rng := spanToRange(suite.Setup.Span())
+		addSetup(callItem(uri, "setup", protocol.SymbolKindConstructor, "suite setup", rng, rng), "", suite.Setup)
+	}
+
+	for _, scope := range suite.Scopes {
+		if scope == nil || scope.QueryName == "" {
+			continue
+		}
+
+		caller := callItem(uri, scope.QueryName, protocol.SymbolKindClass, "query scope",
+			spanToRange(scope.Span()), scopeNameRange(scope))
+
+		calls = append(calls, queryCall{
+			caller:   caller,
+			scope:    scope.QueryName,
+			query:    scope.QueryName,
+			rng:      scopeNameRange(scope),
+			scopeRef: true,
+		})
+
+		addSetup(caller, scope.QueryName, scope.Setup)
+		addItems(scope.QueryName, scope.QueryName, scope.Items)
+	}
+
+	return calls
+}
+
+// queryCallItem creates a call hierarchy item for a query definition.
+func queryCallItem(uri protocol.DocumentURI, q *scaf.Query) *protocol.CallHierarchyItem {
+	item := callItem(uri, q.Name, protocol.SymbolKindFunction, "query", spanToRange(q.Span()), queryNameRange(q))
+
+	return &item
+}
+
+// callItem creates a call hierarchy item.
+func callItem(
+	uri protocol.DocumentURI,
+	name string,
+	kind protocol.SymbolKind,
+	detail string,
+	rng, selection protocol.Range,
+) protocol.CallHierarchyItem {
+	return protocol.CallHierarchyItem{
+		Name:           name,
+		Kind:           kind,
+		Detail:         detail,
+		URI:            uri,
+		Range:          rng,
+		SelectionRange: selection,
+	}
+}
+
+// callItemKey returns a key identifying a call hierarchy item, used to merge call sites.
+func callItemKey(item protocol.CallHierarchyItem) string {
+	start := item.SelectionRange.Start
+
+	return fmt.Sprintf("%s#%s#%d:%d", item.URI, item.Name, start.Line, start.Character)
+}
+
+// rangeContains reports whether inner lies entirely within outer.
+func rangeContains(outer, inner protocol.Range) bool {
+	return !positionBefore(inner.Start, outer.Start) && !positionBefore(outer.End, inner.End)
+}
+
+// positionBefore reports whether a comes strictly before b.
+func positionBefore(a, b protocol.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
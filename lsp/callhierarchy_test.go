@@ -0,0 +1,125 @@
+package lsp_test
+
+import (
+	"context"
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestServer_CallHierarchy_SharedFixture(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	fixturesPath := tmpDir + "/fixtures.scaf"
+	fixturesContent := "query CreateUser `CREATE (u:User {name: $name}) RETURN u`\n"
+	if err := writeFile(fixturesPath, fixturesContent); err != nil {
+		t.Fatalf("Failed to write fixtures.scaf: %v", err)
+	}
+
+	mainPath := tmpDir + "/main.scaf"
+	mainContent := `import fixtures "./fixtures"
+
+query GetUser ` + "`MATCH (u:User {name: $name}) RETURN u.name`" + `
+
+GetUser {
+	test "finds alice" {
+		setup fixtures.CreateUser($name: "alice")
+		$name: "alice"
+	}
+	test "finds bob" {
+		setup fixtures.CreateUser($name: "bob")
+		$name: "bob"
+	}
+}
+`
+	if err := writeFile(mainPath, mainContent); err != nil {
+		t.Fatalf("Failed to write main.scaf: %v", err)
+	}
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	fixturesURI := protocol.DocumentURI("file://" + fixturesPath)
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: fixturesURI, Version: 1, Text: fixturesContent},
+	})
+
+	// Prepare on "CreateUser" in the fixtures file.
+	items, err := server.PrepareCallHierarchy(ctx, &protocol.CallHierarchyPrepareParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: fixturesURI},
+			Position:     protocol.Position{Line: 0, Character: 8},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrepareCallHierarchy() error: %v", err)
+	}
+
+	if len(items) != 1 || items[0].Name != "CreateUser" {
+		t.Fatalf("PrepareCallHierarchy() = %+v, want CreateUser", items)
+	}
+
+	incoming, err := server.IncomingCalls(ctx, &protocol.CallHierarchyIncomingCallsParams{Item: items[0]})
+	if err != nil {
+		t.Fatalf("IncomingCalls() error: %v", err)
+	}
+
+	callers := make(map[string]bool)
+	for _, call := range incoming {
+		callers[call.From.Name] = true
+
+		if call.From.URI != protocol.DocumentURI("file://"+mainPath) {
+			t.Errorf("caller %q URI = %s, want main.scaf", call.From.Name, call.From.URI)
+		}
+	}
+
+	if len(incoming) != 2 || !callers["finds alice"] || !callers["finds bob"] {
+		t.Errorf("IncomingCalls() callers = %v, want both tests", callers)
+	}
+
+	// Outgoing calls from a calling test lead back to the fixture.
+	outgoing, err := server.OutgoingCalls(ctx, &protocol.CallHierarchyOutgoingCallsParams{Item: incoming[0].From})
+	if err != nil {
+		t.Fatalf("OutgoingCalls() error: %v", err)
+	}
+
+	if len(outgoing) != 1 || outgoing[0].To.Name != "CreateUser" || outgoing[0].To.URI != fixturesURI {
+		t.Errorf("OutgoingCalls() = %+v, want CreateUser in fixtures.scaf", outgoing)
+	}
+
+	// The main query's scope setups are its outgoing calls.
+	mainURI := protocol.DocumentURI("file://" + mainPath)
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: mainURI, Version: 1, Text: mainContent},
+	})
+
+	items, err = server.PrepareCallHierarchy(ctx, &protocol.CallHierarchyPrepareParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: mainURI},
+			Position:     protocol.Position{Line: 4, Character: 2}, // On "GetUser" scope
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrepareCallHierarchy() error: %v", err)
+	}
+
+	if len(items) != 1 || items[0].Name != "GetUser" {
+		t.Fatalf("PrepareCallHierarchy() = %+v, want GetUser", items)
+	}
+
+	outgoing, err = server.OutgoingCalls(ctx, &protocol.CallHierarchyOutgoingCallsParams{Item: items[0]})
+	if err != nil {
+		t.Fatalf("OutgoingCalls() error: %v", err)
+	}
+
+	if len(outgoing) != 1 || len(outgoing[0].FromRanges) != 2 {
+		t.Errorf("OutgoingCalls() = %+v, want CreateUser called from two ranges", outgoing)
+	}
+}
@@ -3,8 +3,11 @@ package lsp
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/alecthomas/participle/v2/lexer"
 	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
 
@@ -58,9 +61,105 @@ func (s *Server) CodeAction(_ context.Context, params *protocol.CodeActionParams
 		}
 	}
 
+	actions = append(actions, s.convertToSetupBlock(doc, params.Range)...)
+
 	return actions, nil
 }
 
+// convertToSetupBlock offers to rewrite a single-item setup clause at the
+// cursor into block form, ready for further steps.
+func (s *Server) convertToSetupBlock(doc *Document, rng protocol.Range) []protocol.CodeAction {
+	if doc.Analysis.Suite == nil {
+		return nil
+	}
+
+	for _, clause := range setupClauses(doc.Analysis.Suite) {
+		if clause.Block != nil || !clause.IsComplete() {
+			continue
+		}
+
+		// Include the setup keyword before the clause.
+		clauseRange := spanToRange(clause.Span())
+		clauseRange.Start.Character = 0
+
+		if !rangesOverlap(clauseRange, rng) {
+			continue
+		}
+
+		start, end := clause.Pos.Offset, clause.EndPos.Offset
+		if start < 0 || end > len(doc.Content) || start >= end {
+			return nil
+		}
+
+		lineStart := strings.LastIndex(doc.Content[:start], "\n") + 1
+		line := doc.Content[lineStart:start]
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		edit := protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				doc.URI: {
+					{
+						Range:   spanToRange(clause.Span()),
+						NewText: "{\n" + indent + "\t" + doc.Content[start:end] + "\n" + indent + "}",
+					},
+				},
+			},
+		}
+
+		return []protocol.CodeAction{
+			{
+				Title: "Convert to setup block",
+				Kind:  protocol.RefactorRewrite,
+				Edit:  &edit,
+			},
+		}
+	}
+
+	return nil
+}
+
+// setupClauses returns the suite's setup clauses, outermost first.
+func setupClauses(suite *scaf.Suite) []*scaf.SetupClause {
+	var clauses []*scaf.SetupClause
+
+	add := func(clause *scaf.SetupClause) {
+		if clause != nil {
+			clauses = append(clauses, clause)
+		}
+	}
+
+	var addItems func(items []*scaf.TestOrGroup)
+	addItems = func(items []*scaf.TestOrGroup) {
+		for _, item := range items {
+			for _, test := range item.Tests() {
+				add(test.Setup)
+			}
+
+			if item.Group != nil {
+				add(item.Group.Setup)
+				addItems(item.Group.Items)
+			}
+		}
+	}
+
+	add(suite.Setup)
+
+	for _, profile := range suite.Profiles {
+		add(profile.Setup)
+	}
+
+	// Setup written after a scope's tests is left out: it has its own fix.
+	for _, scope := range suite.Scopes {
+		if !isTrailingSetup(scope) {
+			add(scope.Setup)
+		}
+
+		addItems(scope.Items)
+	}
+
+	return clauses
+}
+
 // convertSeverityForAction converts analysis severity to protocol severity.
 func convertSeverityForAction(sev analysis.DiagnosticSeverity) protocol.DiagnosticSeverity {
 	switch sev {
@@ -114,6 +213,12 @@ func (s *Server) codeActionsForDiagnostic(doc *Document, diag protocol.Diagnosti
 
 	case "empty-group":
 		actions = append(actions, s.fixEmptyGroup(doc, diag)...)
+
+	case "misordered-setup":
+		actions = append(actions, s.fixMisorderedSetup(doc, diag)...)
+
+	case "number-for-string-field":
+		actions = append(actions, s.fixNumberForStringField(doc, diag)...)
 	}
 
 	return actions
@@ -154,33 +259,51 @@ func (s *Server) fixMissingParams(doc *Document, diag protocol.Diagnostic) []pro
 		return nil
 	}
 
+	brace := slices.IndexFunc(targetTest.Tokens, func(tok lexer.Token) bool { return tok.Type == scaf.TokenLBrace })
+	if brace < 0 {
+		return nil
+	}
+
+	// Indent the parameters one level past the test, which may be nested in groups
+	lines := strings.Split(doc.Content, "\n")
+	if targetTest.Pos.Line < 1 || targetTest.Pos.Line > len(lines) {
+		return nil
+	}
+
+	testLine := lines[targetTest.Pos.Line-1]
+	indent := testLine[:len(testLine)-len(strings.TrimLeft(testLine, " \t"))]
+
 	// Generate the insertion text for missing parameters
-	var insertLines []string
+	var insertText strings.Builder
 	for _, param := range params {
 		param = strings.TrimSpace(param)
 		if param != "" {
-			insertLines = append(insertLines, fmt.Sprintf("\t\t%s: ", param))
+			fmt.Fprintf(&insertText, "\n%s\t%s: ", indent, param)
 		}
 	}
 
-	if len(insertLines) == 0 {
+	if insertText.Len() == 0 {
 		return nil
 	}
 
-	// Insert after the test opening brace
-	// Find the position right after "test "name" {"
-	insertLine := diag.Range.Start.Line + 1
-	insertText := strings.Join(insertLines, "\n") + "\n"
+	// Insert right after the test's opening brace, moving the rest of a
+	// single-line test onto its own line
+	open := targetTest.Tokens[brace].Pos
+	if targetTest.EndPos.Line == open.Line {
+		insertText.WriteString("\n" + indent)
+	}
+
+	insertAt := protocol.Position{
+		Line:      uint32(open.Line - 1), //nolint:gosec // G115: values are small line numbers
+		Character: uint32(open.Column),   //nolint:gosec // G115: values are small column numbers
+	}
 
 	edit := protocol.WorkspaceEdit{
 		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
 			doc.URI: {
 				{
-					Range: protocol.Range{
-						Start: protocol.Position{Line: insertLine, Character: 0},
-						End:   protocol.Position{Line: insertLine, Character: 0},
-					},
-					NewText: insertText,
+					Range:   protocol.Range{Start: insertAt, End: insertAt},
+					NewText: insertText.String(),
 				},
 			},
 		},
@@ -198,10 +321,10 @@ func (s *Server) fixMissingParams(doc *Document, diag protocol.Diagnostic) []pro
 
 // findTestAtRange finds a test that contains the given range.
 func (s *Server) findTestAtRange(item *scaf.TestOrGroup, rng protocol.Range) *scaf.Test {
-	if item.Test != nil {
-		testRange := spanToRange(item.Test.Span())
+	for _, test := range item.Tests() {
+		testRange := spanToRange(test.Span())
 		if rangesOverlap(testRange, rng) {
-			return item.Test
+			return test
 		}
 	}
 	if item.Group != nil {
@@ -271,12 +394,18 @@ func (s *Server) fixUndefinedQuery(doc *Document, diag protocol.Diagnostic) []pr
 	}
 
 	// Extract query name from diagnostic message
-	// Message format: "undefined query: QueryName"
+	// Message format: "undefined query: QueryName" with an optional " (did you mean Other?)"
 	prefix := "undefined query: "
 	if !strings.HasPrefix(diag.Message, prefix) {
 		return nil
 	}
-	queryName := strings.TrimPrefix(diag.Message, prefix)
+	queryName, suggestion, _ := strings.Cut(strings.TrimPrefix(diag.Message, prefix), " (did you mean ")
+	suggestion = strings.TrimSuffix(suggestion, "?)")
+
+	var actions []protocol.CodeAction
+	if suggestion != "" {
+		actions = append(actions, s.renameScopeAction(doc, diag, queryName, suggestion)...)
+	}
 
 	// Find where to insert the new query (before the first scope, or after last query)
 	var insertLine uint32
@@ -309,14 +438,49 @@ func (s *Server) fixUndefinedQuery(doc *Document, diag protocol.Diagnostic) []pr
 		},
 	}
 
-	return []protocol.CodeAction{
-		{
-			Title:       fmt.Sprintf("Create query '%s'", queryName),
-			Kind:        protocol.QuickFix,
-			Diagnostics: []protocol.Diagnostic{diag},
-			Edit:        &edit,
-		},
+	return append(actions, protocol.CodeAction{
+		Title:       fmt.Sprintf("Create query '%s'", queryName),
+		Kind:        protocol.QuickFix,
+		Diagnostics: []protocol.Diagnostic{diag},
+		Edit:        &edit,
+	})
+}
+
+// renameScopeAction generates a quick fix that renames a scope header to a near-miss query name.
+func (s *Server) renameScopeAction(doc *Document, diag protocol.Diagnostic, from, to string) []protocol.CodeAction {
+	for _, scope := range doc.Analysis.Suite.Scopes {
+		line := uint32(scope.Pos.Line - 1) //nolint:gosec
+		if scope.QueryName != from || line != diag.Range.Start.Line {
+			continue
+		}
+
+		col := uint32(scope.Pos.Column - 1) //nolint:gosec
+		edit := protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				doc.URI: {
+					{
+						Range: protocol.Range{
+							Start: protocol.Position{Line: line, Character: col},
+							End:   protocol.Position{Line: line, Character: col + uint32(len(from))}, //nolint:gosec
+						},
+						NewText: to,
+					},
+				},
+			},
+		}
+
+		return []protocol.CodeAction{
+			{
+				Title:       fmt.Sprintf("Rename to '%s'", to),
+				Kind:        protocol.QuickFix,
+				Diagnostics: []protocol.Diagnostic{diag},
+				IsPreferred: true,
+				Edit:        &edit,
+			},
+		}
 	}
+
+	return nil
 }
 
 // fixEmptyTest generates quick fixes for an empty test.
@@ -427,3 +591,155 @@ func (s *Server) fixEmptyGroup(doc *Document, diag protocol.Diagnostic) []protoc
 		},
 	}
 }
+
+// fixNumberForStringField generates a quick fix that quotes a number literal
+// written for a string field, keeping its source text (e.g., leading zeros).
+func (s *Server) fixNumberForStringField(doc *Document, diag protocol.Diagnostic) []protocol.CodeAction {
+	lines := strings.Split(doc.Content, "\n")
+	if int(diag.Range.Start.Line) >= len(lines) {
+		return nil
+	}
+
+	line := lines[diag.Range.Start.Line]
+	start := int(diag.Range.Start.Character)
+
+	end := start
+	for end < len(line) && strings.ContainsRune("0123456789abcdefABCDEFxXoO._+-", rune(line[end])) {
+		end++
+	}
+
+	if end == start {
+		return nil
+	}
+
+	literal := line[start:end]
+
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			doc.URI: {
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: diag.Range.Start.Line, Character: uint32(start)}, //nolint:gosec // G115: line offsets are small
+						End:   protocol.Position{Line: diag.Range.Start.Line, Character: uint32(end)},   //nolint:gosec // G115: line offsets are small
+					},
+					NewText: strconv.Quote(literal),
+				},
+			},
+		},
+	}
+
+	return []protocol.CodeAction{
+		{
+			Title:       fmt.Sprintf("Quote %s as a string", literal),
+			Kind:        protocol.QuickFix,
+			Diagnostics: []protocol.Diagnostic{diag},
+			Edit:        &edit,
+		},
+	}
+}
+
+// fixMisorderedSetup generates a quick fix that moves a setup or teardown written
+// after a scope's tests to the top of the scope. Teardown goes after a setup that
+// is already in place, matching the formatter's order.
+func (s *Server) fixMisorderedSetup(doc *Document, diag protocol.Diagnostic) []protocol.CodeAction {
+	if doc.Analysis.Suite == nil {
+		return nil
+	}
+
+	for _, scope := range doc.Analysis.Suite.Scopes {
+		for _, clause := range scope.Trailing {
+			// A duplicate clause can't move without clashing with the existing one.
+			if !clause.Promoted(scope) || !rangesOverlap(spanToRange(clause.Span()), diag.Range) {
+				continue
+			}
+
+			start, end := clause.Pos.Offset, clause.EndPos.Offset
+			if start < 0 || end > len(doc.Content) || start >= end {
+				return nil
+			}
+
+			// Move whole lines when the clause is alone on them, keeping a trailing comment.
+			lineStart := strings.LastIndex(doc.Content[:start], "\n") + 1
+			lineEnd := len(doc.Content)
+			if i := strings.Index(doc.Content[end:], "\n"); i >= 0 {
+				lineEnd = end + i
+			}
+
+			indent := doc.Content[lineStart:start]
+			rest := strings.TrimSpace(doc.Content[end:lineEnd])
+			text := doc.Content[start:end]
+			deleteRange := spanToRange(clause.Span())
+
+			if strings.TrimSpace(indent) == "" && (rest == "" || strings.HasPrefix(rest, "//")) {
+				text = strings.TrimRight(doc.Content[start:lineEnd], " \t\r")
+				deleteRange = protocol.Range{
+					Start: protocol.Position{Line: uint32(clause.Pos.Line - 1), Character: 0}, //nolint:gosec
+					End:   protocol.Position{Line: uint32(clause.EndPos.Line), Character: 0},  //nolint:gosec
+				}
+			} else {
+				indent = "\t"
+			}
+
+			insertLine := scopeBodyLine(scope)
+			if clause.Teardown != nil && scope.Setup != nil && !isTrailingSetup(scope) {
+				insertLine = uint32(scope.Setup.EndPos.Line) //nolint:gosec
+			}
+
+			insert := protocol.Position{Line: insertLine, Character: 0}
+
+			edit := protocol.WorkspaceEdit{
+				Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+					doc.URI: {
+						{
+							Range:   protocol.Range{Start: insert, End: insert},
+							NewText: indent + text + "\n",
+						},
+						{
+							Range:   deleteRange,
+							NewText: "",
+						},
+					},
+				},
+			}
+
+			kind := "teardown"
+			if clause.Setup != nil {
+				kind = "setup"
+			}
+
+			return []protocol.CodeAction{
+				{
+					Title:       fmt.Sprintf("Move %s before the tests", kind),
+					Kind:        protocol.QuickFix,
+					Diagnostics: []protocol.Diagnostic{diag},
+					IsPreferred: true,
+					Edit:        &edit,
+				},
+			}
+		}
+	}
+
+	return nil
+}
+
+// scopeBodyLine returns the 0-indexed line just after a scope's opening brace.
+func scopeBodyLine(scope *scaf.QueryScope) uint32 {
+	for _, tok := range scope.Tokens {
+		if tok.Value == "{" {
+			return uint32(tok.Pos.Line) //nolint:gosec
+		}
+	}
+
+	return uint32(scope.Pos.Line) //nolint:gosec
+}
+
+// isTrailingSetup reports whether the scope's setup was written after its tests.
+func isTrailingSetup(scope *scaf.QueryScope) bool {
+	for _, clause := range scope.Trailing {
+		if clause.Setup != nil && clause.Setup == scope.Setup {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,10 +2,13 @@ package lsp_test
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"testing"
 
 	"go.lsp.dev/protocol"
+
+	"github.com/rlch/scaf"
 )
 
 func TestServer_CodeAction_MissingParams(t *testing.T) {
@@ -86,6 +89,85 @@ GetUser {
 	}
 }
 
+func TestServer_CodeAction_MissingParamsInsertion(t *testing.T) {
+	t.Parallel()
+
+	content := "query GetUser `MATCH (u:User {id: $id, name: $name}) RETURN u`\n\n" +
+		"GetUser {\n" +
+		"\tgroup \"g\" {\n" +
+		"\t\ttest \"nested\" {\n" +
+		"\t\t\t$id: 1\n" +
+		"\t\t}\n" +
+		"\t}\n\n" +
+		"\ttest \"inline\" { $id: 1 }\n" +
+		"}\n"
+
+	tests := []struct {
+		name string
+		line uint32
+		want string
+	}{
+		{
+			name: "nested in a group",
+			line: 4,
+			want: strings.Replace(content, "\t\t\t$id: 1\n", "\t\t\t$name: \n\t\t\t$id: 1\n", 1),
+		},
+		{
+			name: "single line",
+			line: 9,
+			want: strings.Replace(content, "{ $id: 1 }", "{\n\t\t$name: \n\t $id: 1 }", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, client := newTestServer(t)
+			ctx := context.Background()
+
+			_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+			_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+			uri := protocol.DocumentURI("file:///test.scaf")
+			_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+				TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: content},
+			})
+
+			var diag *protocol.Diagnostic
+
+			for _, params := range client.diagnostics {
+				for i, d := range params.Diagnostics {
+					if d.Code == "missing-required-params" && d.Range.Start.Line == tt.line {
+						diag = &params.Diagnostics[i]
+					}
+				}
+			}
+
+			if diag == nil {
+				t.Fatalf("Expected missing-required-params diagnostic on line %d, got: %v", tt.line, client.diagnostics)
+			}
+
+			result, err := server.CodeAction(ctx, &protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Range:        diag.Range,
+				Context:      protocol.CodeActionContext{Diagnostics: []protocol.Diagnostic{*diag}},
+			})
+			if err != nil {
+				t.Fatalf("CodeAction() error: %v", err)
+			}
+
+			if len(result) != 1 || result[0].Edit == nil {
+				t.Fatalf("Expected one code action with an edit, got: %v", result)
+			}
+
+			if got := applyEdits(content, result[0].Edit.Changes[uri]); got != tt.want {
+				t.Errorf("After fix:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestServer_CodeAction_UnusedImport(t *testing.T) {
 	t.Parallel()
 
@@ -252,6 +334,80 @@ func TestServer_CodeAction_UndefinedQuery(t *testing.T) {
 	}
 }
 
+func TestServer_CodeAction_UndefinedQuerySuggestion(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	content := `query GetUser ` + "`MATCH (u:User) RETURN u`" + `
+
+GetUsr {
+	test "finds user" {}
+}
+`
+	uri := protocol.DocumentURI("file:///test.scaf")
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     uri,
+			Version: 1,
+			Text:    content,
+		},
+	})
+
+	result, err := server.CodeAction(ctx, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 2, Character: 0},
+			End:   protocol.Position{Line: 2, Character: 6},
+		},
+		Context: protocol.CodeActionContext{
+			Diagnostics: []protocol.Diagnostic{
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 2, Character: 0},
+						End:   protocol.Position{Line: 4, Character: 1},
+					},
+					Message: "undefined query: GetUsr (did you mean GetUser?)",
+					Code:    "undefined-query",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CodeAction() error: %v", err)
+	}
+
+	if len(result) != 2 {
+		t.Fatalf("Expected rename and create actions, got %d", len(result))
+	}
+
+	rename := result[0]
+	if rename.Title != "Rename to 'GetUser'" || !rename.IsPreferred {
+		t.Fatalf("Expected preferred rename action first, got %q", rename.Title)
+	}
+
+	edits := rename.Edit.Changes[uri]
+	if len(edits) != 1 {
+		t.Fatalf("Expected 1 edit, got %d", len(edits))
+	}
+
+	wantRange := protocol.Range{
+		Start: protocol.Position{Line: 2, Character: 0},
+		End:   protocol.Position{Line: 2, Character: 6},
+	}
+	if edits[0].Range != wantRange || edits[0].NewText != "GetUser" {
+		t.Errorf("Rename edit = %+v, want %q at %+v", edits[0], "GetUser", wantRange)
+	}
+
+	if result[1].Title != "Create query 'GetUsr'" {
+		t.Errorf("Expected create action for GetUsr, got %q", result[1].Title)
+	}
+}
+
 func TestServer_CodeAction_NoDiagnostics(t *testing.T) {
 	t.Parallel()
 
@@ -299,3 +455,243 @@ GetUser {
 		t.Errorf("Expected no code actions, got %d", len(result))
 	}
 }
+
+func TestServer_CodeAction_MisorderedSetup(t *testing.T) {
+	t.Parallel()
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	content := `query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+GetUser {
+	test "finds user" {
+		$id: 1
+	}
+
+	setup ` + "`CREATE (:User {id: 1})`" + ` // seed
+}
+`
+	uri := protocol.DocumentURI("file:///test.scaf")
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     uri,
+			Version: 1,
+			Text:    content,
+		},
+	})
+
+	var diag *protocol.Diagnostic
+
+	for _, params := range client.diagnostics {
+		for i, d := range params.Diagnostics {
+			if d.Code == "misordered-setup" {
+				diag = &params.Diagnostics[i]
+			}
+		}
+	}
+
+	if diag == nil {
+		t.Fatalf("Expected misordered-setup diagnostic, got: %v", client.diagnostics)
+	}
+
+	result, err := server.CodeAction(ctx, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        diag.Range,
+		Context:      protocol.CodeActionContext{Diagnostics: []protocol.Diagnostic{*diag}},
+	})
+	if err != nil {
+		t.Fatalf("CodeAction() error: %v", err)
+	}
+
+	if len(result) != 1 || result[0].Edit == nil {
+		t.Fatalf("Expected one code action with an edit, got: %v", result)
+	}
+
+	got := applyEdits(content, result[0].Edit.Changes[uri])
+	want := `query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+GetUser {
+	setup ` + "`CREATE (:User {id: 1})`" + ` // seed
+	test "finds user" {
+		$id: 1
+	}
+
+}
+`
+	if got != want {
+		t.Errorf("After fix:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestServer_CodeAction_NumberForStringField(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	if err := writeFile(tmpDir+"/.scaf.yaml", "generate:\n  schema: .scaf-schema.yaml\n"); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	schemaContent := `models:
+  Address:
+    fields:
+      zip:
+        type: string
+`
+	if err := writeFile(tmpDir+"/.scaf-schema.yaml", schemaContent); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	content := `query GetAddress ` + "`MATCH (a:Address) RETURN a.zip AS zip`" + `
+
+GetAddress {
+	test "zip" {
+		zip: 02134
+	}
+}
+`
+	uri := protocol.DocumentURI("file://" + tmpDir + "/addresses.scaf")
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: content},
+	})
+
+	var diag *protocol.Diagnostic
+
+	for _, params := range client.diagnostics {
+		for i, d := range params.Diagnostics {
+			if d.Code == "number-for-string-field" {
+				diag = &params.Diagnostics[i]
+			}
+		}
+	}
+
+	if diag == nil {
+		t.Fatalf("Expected number-for-string-field diagnostic, got: %v", client.diagnostics)
+	}
+
+	result, err := server.CodeAction(ctx, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        diag.Range,
+		Context:      protocol.CodeActionContext{Diagnostics: []protocol.Diagnostic{*diag}},
+	})
+	if err != nil {
+		t.Fatalf("CodeAction() error: %v", err)
+	}
+
+	if len(result) != 1 || result[0].Edit == nil {
+		t.Fatalf("Expected one code action with an edit, got: %v", result)
+	}
+
+	got := applyEdits(content, result[0].Edit.Changes[uri])
+	want := strings.Replace(content, "02134", `"02134"`, 1)
+
+	if got != want {
+		t.Errorf("After fix:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// applyEdits applies non-overlapping text edits to content.
+func applyEdits(content string, edits []protocol.TextEdit) string {
+	offset := func(pos protocol.Position) int {
+		lines := strings.SplitAfter(content, "\n")
+		off := 0
+
+		for i := range int(pos.Line) {
+			off += len(lines[i])
+		}
+
+		return off + int(pos.Character)
+	}
+
+	sorted := slices.Clone(edits)
+	slices.SortFunc(sorted, func(a, b protocol.TextEdit) int {
+		return offset(b.Range.Start) - offset(a.Range.Start)
+	})
+
+	for _, e := range sorted {
+		content = content[:offset(e.Range.Start)] + e.NewText + content[offset(e.Range.End):]
+	}
+
+	return content
+}
+
+func TestServer_CodeAction_ConvertToSetupBlock(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	content := `query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+GetUser {
+	test "finds user" {
+		setup ` + "`CREATE (:User {id: 1})`" + `
+		$id: 1
+	}
+}
+`
+	uri := protocol.DocumentURI("file:///test.scaf")
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     uri,
+			Version: 1,
+			Text:    content,
+		},
+	})
+
+	// Cursor on the setup keyword
+	pos := protocol.Position{Line: 4, Character: 3}
+
+	result, err := server.CodeAction(ctx, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        protocol.Range{Start: pos, End: pos},
+	})
+	if err != nil {
+		t.Fatalf("CodeAction() error: %v", err)
+	}
+
+	if len(result) != 1 || result[0].Title != "Convert to setup block" || result[0].Edit == nil {
+		t.Fatalf("Expected a convert to setup block action, got: %v", result)
+	}
+
+	got := applyEdits(content, result[0].Edit.Changes[uri])
+	want := `query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+GetUser {
+	test "finds user" {
+		setup {
+			` + "`CREATE (:User {id: 1})`" + `
+		}
+		$id: 1
+	}
+}
+`
+	if got != want {
+		t.Errorf("After refactor:\n%s\nwant:\n%s", got, want)
+	}
+
+	suite, err := scaf.Parse([]byte(got))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	setup := suite.Scopes[0].Items[0].Test.Setup
+	if len(setup.Block) != 1 || setup.Block[0].Inline == nil || *setup.Block[0].Inline != "CREATE (:User {id: 1})" {
+		t.Errorf("Expected a setup block with the original query, got: %+v", setup)
+	}
+}
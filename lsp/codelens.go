@@ -1,7 +1,9 @@
 package lsp
 
 import (
+	"cmp"
 	"context"
+	"slices"
 
 	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
@@ -21,7 +23,7 @@ func (s *Server) CodeLens(_ context.Context, params *protocol.CodeLensParams) ([
 	}
 
 	filePath := URIToPath(params.TextDocument.URI)
-	var lenses []protocol.CodeLens
+	lenses := []protocol.CodeLens{}
 
 	// Walk through all query scopes
 	for _, scope := range doc.Analysis.Suite.Scopes {
@@ -30,7 +32,7 @@ func (s *Server) CodeLens(_ context.Context, params *protocol.CodeLensParams) ([
 			Range: scopeNameRange(scope),
 			Command: &protocol.Command{
 				Title:     "▶ Run All",
-				Command:   "scaf.runScope",
+				Command:   CommandRunScope,
 				Arguments: []interface{}{filePath, scope.QueryName},
 			},
 		})
@@ -39,7 +41,32 @@ func (s *Server) CodeLens(_ context.Context, params *protocol.CodeLensParams) ([
 		lenses = append(lenses, s.collectItemLenses(filePath, scope.QueryName, "", scope.Items)...)
 	}
 
-	return lenses, nil
+	return sortLenses(lenses), nil
+}
+
+// lensPriority orders lenses that start on the same line: broader runs first.
+var lensPriority = map[string]int{
+	CommandRunScope: 0,
+	CommandRunGroup: 1,
+	CommandRunTest:  2,
+}
+
+// sortLenses orders lenses by line and then command priority, so clients render
+// lenses sharing a line consistently, and drops exact duplicates.
+func sortLenses(lenses []protocol.CodeLens) []protocol.CodeLens {
+	slices.SortStableFunc(lenses, func(a, b protocol.CodeLens) int {
+		return cmp.Or(
+			cmp.Compare(a.Range.Start.Line, b.Range.Start.Line),
+			cmp.Compare(lensPriority[a.Command.Command], lensPriority[b.Command.Command]),
+			cmp.Compare(a.Range.Start.Character, b.Range.Start.Character),
+		)
+	})
+
+	return slices.CompactFunc(lenses, func(a, b protocol.CodeLens) bool {
+		return a.Range.Start.Line == b.Range.Start.Line &&
+			a.Command.Command == b.Command.Command &&
+			slices.Equal(a.Command.Arguments, b.Command.Arguments)
+	})
 }
 
 // collectItemLenses recursively collects code lenses for tests and groups.
@@ -51,13 +78,13 @@ func (s *Server) collectItemLenses(filePath, queryScope, groupPath string, items
 			continue
 		}
 
-		if item.Test != nil {
-			testFullPath := buildPath(queryScope, groupPath, item.Test.Name)
+		for _, test := range item.Tests() {
+			testFullPath := buildPath(queryScope, groupPath, test.Name)
 			lenses = append(lenses, protocol.CodeLens{
-				Range: testNameRange(item.Test),
+				Range: testNameRange(test),
 				Command: &protocol.Command{
 					Title:     "▶ Run Test",
-					Command:   "scaf.runTest",
+					Command:   CommandRunTest,
 					Arguments: []interface{}{filePath, testFullPath},
 				},
 			})
@@ -69,7 +96,7 @@ func (s *Server) collectItemLenses(filePath, queryScope, groupPath string, items
 				Range: groupNameRange(item.Group),
 				Command: &protocol.Command{
 					Title:     "▶ Run Group",
-					Command:   "scaf.runGroup",
+					Command:   CommandRunGroup,
 					Arguments: []interface{}{filePath, groupFullPath},
 				},
 			})
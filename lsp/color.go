@@ -0,0 +1,147 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+
+	"github.com/rlch/scaf"
+)
+
+// hexColorPattern matches string values that are #RRGGBB colors.
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// SetColors enables or disables reporting #RRGGBB string values as colors.
+func (s *Server) SetColors(colors bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.colors = colors
+}
+
+// DocumentColor handles textDocument/documentColor requests.
+// When the colors setting is enabled, returns every #RRGGBB string value
+// in test statements (including values nested in maps and lists).
+func (s *Server) DocumentColor(_ context.Context, params *protocol.DocumentColorParams) ([]protocol.ColorInformation, error) {
+	s.logger.Debug("DocumentColor",
+		zap.String("uri", string(params.TextDocument.URI)))
+
+	s.mu.RLock()
+	enabled := s.colors
+	s.mu.RUnlock()
+
+	if !enabled {
+		return nil, nil
+	}
+
+	doc, ok := s.getDocument(params.TextDocument.URI)
+	if !ok || doc.Analysis == nil || doc.Analysis.Suite == nil {
+		return nil, nil
+	}
+
+	colors := []protocol.ColorInformation{}
+
+	for _, scope := range doc.Analysis.Suite.Scopes {
+		colors = append(colors, itemColors(scope.Items)...)
+	}
+
+	return colors, nil
+}
+
+// ColorPresentation handles textDocument/colorPresentation requests.
+// The only presentation is the quoted #RRGGBB string replacing the value.
+func (s *Server) ColorPresentation(
+	_ context.Context, params *protocol.ColorPresentationParams,
+) ([]protocol.ColorPresentation, error) {
+	label := hexColor(params.Color)
+
+	return []protocol.ColorPresentation{{
+		Label: label,
+		TextEdit: &protocol.TextEdit{
+			Range:   params.Range,
+			NewText: strconv.Quote(label),
+		},
+	}}, nil
+}
+
+// itemColors collects colors from the statements of tests under items.
+func itemColors(items []*scaf.TestOrGroup) []protocol.ColorInformation {
+	var colors []protocol.ColorInformation
+
+	for _, item := range items {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
+				colors = append(colors, valueColors(stmt.Value)...)
+			}
+		}
+
+		if item.Group != nil {
+			colors = append(colors, itemColors(item.Group.Items)...)
+		}
+	}
+
+	return colors
+}
+
+// valueColors returns the color of a #RRGGBB string value, or the colors
+// nested in a map or list value.
+func valueColors(v *scaf.Value) []protocol.ColorInformation {
+	if v == nil {
+		return nil
+	}
+
+	var colors []protocol.ColorInformation
+
+	switch {
+	case v.Str != nil:
+		if color, ok := parseHexColor(*v.Str); ok {
+			colors = append(colors, protocol.ColorInformation{
+				Range: spanToRange(v.Span()),
+				Color: color,
+			})
+		}
+	case v.Map != nil:
+		for _, entry := range v.Map.Entries {
+			colors = append(colors, valueColors(entry.Value)...)
+		}
+	case v.List != nil:
+		for _, elem := range v.List.Values {
+			colors = append(colors, valueColors(elem)...)
+		}
+	}
+
+	return colors
+}
+
+// parseHexColor parses a #RRGGBB string into an opaque color.
+func parseHexColor(s string) (protocol.Color, bool) {
+	if !hexColorPattern.MatchString(s) {
+		return protocol.Color{}, false
+	}
+
+	rgb, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return protocol.Color{}, false
+	}
+
+	return protocol.Color{
+		Red:   float64(rgb>>16&0xFF) / 255,
+		Green: float64(rgb>>8&0xFF) / 255,
+		Blue:  float64(rgb&0xFF) / 255,
+		Alpha: 1,
+	}, true
+}
+
+// hexColor formats a color as #RRGGBB, ignoring alpha.
+func hexColor(c protocol.Color) string {
+	channel := func(f float64) int {
+		return int(math.Round(math.Max(0, math.Min(1, f)) * 255))
+	}
+
+	return fmt.Sprintf("#%02X%02X%02X", channel(c.Red), channel(c.Green), channel(c.Blue))
+}
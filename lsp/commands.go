@@ -0,0 +1,224 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+
+	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/module"
+	"github.com/rlch/scaf/runner"
+)
+
+// Commands issued by code lenses. Each takes the file path and the path of the
+// scope, group or test to run.
+const (
+	CommandRunScope = "scaf.runScope"
+	CommandRunGroup = "scaf.runGroup"
+	CommandRunTest  = "scaf.runTest"
+)
+
+// CommandListPaths takes a document URI and returns the slash path of every
+// scope, group, and test in it (see scaf.Suite.Paths), for filter pickers.
+const CommandListPaths = "scaf.listPaths"
+
+var (
+	errCommandArgs = errors.New("expected file path and test path arguments")
+	errNoDatabase  = errors.New("no database configured in .scaf.yaml")
+	errNoURI       = errors.New("no database connection URI configured in .scaf.yaml")
+	errConnect     = errors.New("cannot connect to the database")
+	errDocumentArg = errors.New("expected the URI of an open document")
+)
+
+// actionOpenConfig is offered when a run fails because of the database
+// config, or because the database it names can't be reached.
+const actionOpenConfig = "Open .scaf.yaml"
+
+// ExecuteCommand handles workspace/executeCommand for scaf.listPaths and the
+// code lens commands. Tests run against the database configured for the file,
+// and the result is recorded for inline values.
+func (s *Server) ExecuteCommand(ctx context.Context, params *protocol.ExecuteCommandParams) (any, error) {
+	s.logger.Debug("ExecuteCommand",
+		zap.String("command", params.Command))
+
+	switch params.Command {
+	case CommandListPaths:
+		return s.listPaths(params.Arguments)
+	case CommandRunScope, CommandRunGroup, CommandRunTest:
+	default:
+		return nil, nil //nolint:nilnil // Unknown commands have no result
+	}
+
+	if len(params.Arguments) != 2 {
+		return nil, fmt.Errorf("%s: %w", params.Command, errCommandArgs)
+	}
+
+	filePath, _ := params.Arguments[0].(string)
+	target, _ := params.Arguments[1].(string)
+
+	if filePath == "" || target == "" {
+		return nil, fmt.Errorf("%s: %w", params.Command, errCommandArgs)
+	}
+
+	result, err := runTests(ctx, filePath, target)
+	if err != nil {
+		s.showRunError(ctx, filePath, err)
+
+		return nil, err
+	}
+
+	s.RecordRun(PathToURI(filePath), result)
+
+	return nil, nil //nolint:nilnil // Results are reported through inline values
+}
+
+// listPaths returns the runnable paths of the open document named by args.
+// A document with parse errors reports the paths of its last valid parse.
+func (s *Server) listPaths(args []any) ([]string, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s: %w", CommandListPaths, errDocumentArg)
+	}
+
+	uri, _ := args[0].(string)
+
+	doc, ok := s.getDocument(protocol.DocumentURI(uri))
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: %s", CommandListPaths, errDocumentArg, uri)
+	}
+
+	f := doc.Analysis
+	if f == nil || f.ParseError != nil {
+		f = doc.LastValidAnalysis
+	}
+
+	if f == nil || f.Suite == nil {
+		return []string{}, nil
+	}
+
+	paths := f.Suite.Paths()
+	if paths == nil {
+		paths = []string{}
+	}
+
+	return paths, nil
+}
+
+// runTests runs the tests at or under target in the file at filePath.
+func runTests(ctx context.Context, filePath, target string) (*runner.Result, error) {
+	cfg, err := scaf.LoadConfig(filepath.Dir(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	var dbCfg any
+
+	switch cfg.DatabaseName() {
+	case scaf.DatabaseNeo4j:
+		dbCfg = cfg.Neo4j
+	case scaf.DatabasePostgres:
+		dbCfg = cfg.Postgres
+	default:
+		return nil, errNoDatabase
+	}
+
+	if (cfg.Neo4j != nil && cfg.Neo4j.URI == "") ||
+		(cfg.Postgres != nil && cfg.Postgres.URI == "" && cfg.Postgres.Host == "") {
+		return nil, errNoURI
+	}
+
+	resolved, err := module.NewResolver(module.NewLoader()).Resolve(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := scaf.NewDatabase(cfg.DatabaseName(), dbCfg)
+	if errors.Is(err, scaf.ErrUnknownDatabase) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", errConnect, err)
+	}
+	defer func() { _ = database.Close() }()
+
+	r := runner.New(
+		runner.WithDatabase(database),
+		runner.WithModules(resolved),
+		runner.WithFilter("^"+regexp.QuoteMeta(target)+"(/|$)"),
+	)
+
+	return r.Run(ctx, resolved.Root.Suite, filePath)
+}
+
+// showRunError tells the user why a run failed. Config errors and connection
+// failures offer to open the config file; the prompt is answered
+// asynchronously, as the client's reply arrives on the connection this
+// request is being handled on.
+func (s *Server) showRunError(ctx context.Context, filePath string, err error) {
+	var message string
+
+	switch {
+	case errors.Is(err, scaf.ErrConfigNotFound):
+		message = "scaf: cannot run tests: no .scaf.yaml found for " + filepath.Base(filePath)
+	case errors.Is(err, errNoDatabase), errors.Is(err, errNoURI):
+		message = "scaf: cannot run tests: " + err.Error()
+	case errors.Is(err, errConnect):
+		message = "scaf: " + err.Error() + "; check the connection settings in .scaf.yaml"
+	default:
+		if showErr := s.client.ShowMessage(ctx, &protocol.ShowMessageParams{
+			Type:    protocol.MessageTypeError,
+			Message: "scaf: cannot run tests: " + err.Error(),
+		}); showErr != nil {
+			s.logger.Warn("Failed to show message", zap.Error(showErr))
+		}
+
+		return
+	}
+
+	var actions []protocol.MessageActionItem
+
+	configPath, findErr := scaf.FindConfig(filepath.Dir(filePath))
+	if findErr == nil {
+		actions = append(actions, protocol.MessageActionItem{Title: actionOpenConfig})
+	}
+
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+
+		item, err := s.client.ShowMessageRequest(ctx, &protocol.ShowMessageRequestParams{
+			Type:    protocol.MessageTypeError,
+			Message: message,
+			Actions: actions,
+		})
+		if err != nil {
+			s.logger.Warn("Failed to show message", zap.Error(err))
+
+			return
+		}
+
+		if item == nil || item.Title != actionOpenConfig {
+			return
+		}
+
+		shower, ok := s.client.(DocumentShower)
+		if !ok {
+			return
+		}
+
+		if _, err := shower.ShowDocument(ctx, &protocol.ShowDocumentParams{
+			URI:       protocol.URI(PathToURI(configPath)),
+			TakeFocus: true,
+		}); err != nil {
+			s.logger.Warn("Failed to open config", zap.String("path", configPath), zap.Error(err))
+		}
+	}()
+}
+
+// DocumentShower is implemented by clients that can ask the editor to open a
+// document (window/showDocument), which protocol.Client doesn't cover.
+type DocumentShower interface {
+	ShowDocument(ctx context.Context, params *protocol.ShowDocumentParams) (*protocol.ShowDocumentResult, error)
+}
@@ -2,6 +2,9 @@ package lsp
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -49,7 +52,7 @@ func (s *Server) Completion(_ context.Context, params *protocol.CompletionParams
 	case CompletionKindQueryName:
 		items = s.completeQueryNames(doc, cc)
 	case CompletionKindKeyword:
-		items = s.completeKeywords(cc)
+		items = s.completeKeywords(doc, cc)
 	case CompletionKindParameter:
 		items = s.completeParameters(doc, cc)
 	case CompletionKindReturnField:
@@ -58,6 +61,10 @@ func (s *Server) Completion(_ context.Context, params *protocol.CompletionParams
 		items = s.completeImportAliases(doc, cc)
 	case CompletionKindSetupFunction:
 		items = s.completeSetupFunctions(doc, cc)
+	case CompletionKindValue:
+		items = s.completeValues(doc, cc)
+	case CompletionKindQueryBody:
+		items = s.completeQueryBody(cc)
 	}
 
 	// Filter by prefix
@@ -84,18 +91,25 @@ const (
 	CompletionKindReturnField   CompletionKind = "return_field"
 	CompletionKindImportAlias   CompletionKind = "import_alias"
 	CompletionKindSetupFunction CompletionKind = "setup_function"
+	CompletionKindValue         CompletionKind = "value"
+	CompletionKindQueryBody     CompletionKind = "query_body"
 )
 
 // CompletionContext holds information about where completion was triggered.
 type CompletionContext struct {
 	Kind        CompletionKind
-	Prefix      string // Text being typed (for filtering)
-	InScope     string // Name of enclosing QueryScope
-	InTest      bool   // Inside a test body
-	InSetup     bool   // Inside a setup clause
-	InAssert    bool   // Inside an assert block
-	ModuleAlias string // Import alias for module.function completion
-	TriggerChar string // The trigger character (., $)
+	Prefix      string     // Text being typed (for filtering)
+	InScope     string     // Name of enclosing QueryScope
+	InTest      bool       // Inside a test body
+	Test        *scaf.Test // Enclosing test, if InTest
+	InSetup     bool       // Inside a setup clause
+	InAssert    bool       // Inside an assert block
+	ModuleAlias string     // Import alias for module.function completion
+	TriggerChar string     // The trigger character (., $)
+	ValueKey    string     // Statement key when completing a value (e.g., "r.sentiment")
+	ValueQuoted bool       // The value being typed already has an opening quote
+	QueryBody   string     // Raw query text when completing inside a query body
+	BodyOffset  int        // Cursor byte offset within QueryBody
 }
 
 // buildCompletionContext analyzes the document and returns completion context.
@@ -139,6 +153,15 @@ func (s *Server) buildCompletionContext(doc *Document, pos protocol.Position, tr
 		symbolsAnalysis = doc.LastValidAnalysis
 	}
 
+	// Inside a query body (query, inline setup, or assert), the dialect completes.
+	if body, offset, ok := queryBodyAtPosition(af.Suite, content, pos); ok {
+		cc.Kind = CompletionKindQueryBody
+		cc.QueryBody = body
+		cc.BodyOffset = offset
+
+		return cc
+	}
+
 	// Convert to lexer position (1-indexed)
 	lexPos := analysis.PositionToLexer(pos.Line, pos.Character)
 
@@ -171,14 +194,17 @@ func (s *Server) determinePositionalContext(cc *CompletionContext, af *analysis.
 
 		// Check items (tests and groups)
 		for _, item := range scope.Items {
-			if item.Test != nil && containsLexerPosition(item.Test.Span(), pos) {
-				cc.InTest = true
-				if item.Test.Setup != nil && containsLexerPosition(item.Test.Setup.Span(), pos) {
-					cc.InSetup = true
-				}
-				for _, assert := range item.Test.Asserts {
-					if containsLexerPosition(assert.Span(), pos) {
-						cc.InAssert = true
+			for _, test := range item.Tests() {
+				if containsLexerPosition(test.Span(), pos) {
+					cc.InTest = true
+					cc.Test = test
+					if test.Setup != nil && containsLexerPosition(test.Setup.Span(), pos) {
+						cc.InSetup = true
+					}
+					for _, assert := range test.Asserts {
+						if containsLexerPosition(assert.Span(), pos) {
+							cc.InAssert = true
+						}
 					}
 				}
 			}
@@ -195,10 +221,13 @@ func (s *Server) checkGroupContext(cc *CompletionContext, group *scaf.Group, pos
 		cc.InSetup = true
 	}
 	for _, item := range group.Items {
-		if item.Test != nil && containsLexerPosition(item.Test.Span(), pos) {
-			cc.InTest = true
-			if item.Test.Setup != nil && containsLexerPosition(item.Test.Setup.Span(), pos) {
-				cc.InSetup = true
+		for _, test := range item.Tests() {
+			if containsLexerPosition(test.Span(), pos) {
+				cc.InTest = true
+				cc.Test = test
+				if test.Setup != nil && containsLexerPosition(test.Setup.Span(), pos) {
+					cc.InSetup = true
+				}
 			}
 		}
 		if item.Group != nil && containsLexerPosition(item.Group.Span(), pos) {
@@ -249,6 +278,13 @@ func (s *Server) determineCompletionKind(cc *CompletionContext, doc *Document, a
 
 	// Case 4: Inside test body
 	if cc.InTest {
+		// After "key:" - value position, offer schema enum values
+		if m := valuePositionPattern.FindStringSubmatch(textBeforeCursor); m != nil {
+			cc.ValueKey = m[1]
+			cc.ValueQuoted = m[2] != ""
+
+			return CompletionKindValue
+		}
 		// After colon - value position, no completion
 		if prevToken != nil && prevToken.Type == scaf.TokenColon {
 			return CompletionKindNone
@@ -281,6 +317,10 @@ func (s *Server) determineCompletionKind(cc *CompletionContext, doc *Document, a
 	return CompletionKindKeyword
 }
 
+// valuePositionPattern matches text ending in a statement value position,
+// capturing the key and an optional opening quote: `sentiment: "pos`.
+var valuePositionPattern = regexp.MustCompile(`^\s*\{?\s*(\$?[A-Za-z_][\w.]*)\s*:\s*(")?\w*$`)
+
 // isIdentifierPrefix checks if s looks like an identifier being typed.
 func isIdentifierPrefix(s string) bool {
 	if s == "" {
@@ -430,14 +470,23 @@ func (s *Server) completeQueryNames(doc *Document, _ *CompletionContext) []proto
 			}
 			item.Documentation = &protocol.MarkupContent{
 				Kind:  protocol.Markdown,
-				Value: s.markdownCodeBlock(preview),
+				Value: s.markdownCodeBlock(doc, preview),
 			}
 		}
 		items = append(items, item)
 	}
+	sortCompletionItems(items)
 	return items
 }
 
+// sortCompletionItems orders items by label so that completions built from
+// symbol maps are stable across requests.
+func sortCompletionItems(items []protocol.CompletionItem) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+}
+
 // keywordSnippet defines a keyword completion with its snippet.
 type keywordSnippet struct {
 	label      string
@@ -447,7 +496,7 @@ type keywordSnippet struct {
 }
 
 // completeKeywords returns keyword completions based on context.
-func (s *Server) completeKeywords(cc *CompletionContext) []protocol.CompletionItem {
+func (s *Server) completeKeywords(doc *Document, cc *CompletionContext) []protocol.CompletionItem {
 	var snippets []keywordSnippet
 
 	if cc.InScope == "" {
@@ -500,7 +549,7 @@ func (s *Server) completeKeywords(cc *CompletionContext) []protocol.CompletionIt
 			{
 				label:   "test",
 				detail:  "Define a test case",
-				snippet: "test \"${1:test name}\" {\n\t${2:\\$param: value}\n\t${3:field: expected}\n}",
+				snippet: s.testSnippet(doc, cc),
 				doc:     "Defines a test case with inputs and expected outputs.",
 			},
 			{
@@ -538,6 +587,74 @@ func (s *Server) completeKeywords(cc *CompletionContext) []protocol.CompletionIt
 	return items
 }
 
+// genericTestSnippet is the test snippet offered when the query in scope
+// can't be analyzed.
+const genericTestSnippet = "test \"${1:test name}\" {\n\t${2:\\$param: value}\n\t${3:field: expected}\n}"
+
+// snippetEscaper escapes text for a snippet, where $, } and \ are syntax.
+var snippetEscaper = strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`)
+
+// testSnippet returns a test skeleton with a tabstop for each parameter and
+// return field of the query in scope, or the generic snippet when the query
+// can't be analyzed.
+func (s *Server) testSnippet(doc *Document, cc *CompletionContext) string {
+	af := s.getSymbolsAnalysis(doc)
+	if af == nil || af.Symbols == nil || s.queryAnalyzer == nil {
+		return genericTestSnippet
+	}
+
+	q, ok := af.Symbols.Queries[cc.InScope]
+	if !ok || q.Body == "" {
+		return genericTestSnippet
+	}
+
+	metadata, err := s.queryAnalyzer.AnalyzeQuery(q.Body)
+	if err != nil {
+		s.logger.Debug("Failed to analyze query for test snippet", zap.Error(err))
+		return genericTestSnippet
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("test \"${1:test name}\" {\n")
+
+	tabstop := 2
+	for _, param := range q.Params {
+		fmt.Fprintf(&sb, "\t\\$%s: ${%d}\n", snippetEscaper.Replace(param), tabstop)
+		tabstop++
+	}
+
+	seen := make(map[string]bool)
+
+	for _, ret := range metadata.Returns {
+		field := ret.Expression
+		if ret.Alias != "" {
+			field = ret.Alias
+		}
+
+		if ret.Key != "" {
+			field = ret.Key
+		}
+
+		if field == "" || seen[field] {
+			continue
+		}
+
+		seen[field] = true
+
+		fmt.Fprintf(&sb, "\t%s: ${%d}\n", snippetEscaper.Replace(field), tabstop)
+		tabstop++
+	}
+
+	if tabstop == 2 {
+		return genericTestSnippet
+	}
+
+	sb.WriteString("}")
+
+	return sb.String()
+}
+
 // completeParameters returns parameter completions from the query in scope.
 func (s *Server) completeParameters(doc *Document, cc *CompletionContext) []protocol.CompletionItem {
 	af := s.getSymbolsAnalysis(doc)
@@ -550,18 +667,95 @@ func (s *Server) completeParameters(doc *Document, cc *CompletionContext) []prot
 		return nil
 	}
 
+	defaults := q.Node.ParamDefaults()
+
 	items := make([]protocol.CompletionItem, 0, len(q.Params))
 	for _, param := range q.Params {
-		items = append(items, protocol.CompletionItem{
+		item := protocol.CompletionItem{
 			Label:      "$" + param,
 			Kind:       protocol.CompletionItemKindVariable,
 			Detail:     "parameter",
 			InsertText: "$" + param + ": ",
+		}
+
+		if typ, ok := q.Node.ParamTypes[param]; ok {
+			item.Detail += ": " + typ
+		}
+
+		if def, ok := defaults[param]; ok {
+			item.Detail += " (default " + def.String() + ")"
+		}
+
+		items = append(items, item)
+	}
+	return items
+}
+
+// completeQueryBody delegates completion inside a query body to the dialect.
+func (s *Server) completeQueryBody(cc *CompletionContext) []protocol.CompletionItem {
+	completer, ok := scaf.GetDialect(s.dialectName).(scaf.QueryCompleter)
+	if !ok {
+		return nil
+	}
+
+	candidates := completer.CompleteQuery(cc.QueryBody, cc.BodyOffset)
+
+	items := make([]protocol.CompletionItem, 0, len(candidates))
+	for _, c := range candidates {
+		kind := protocol.CompletionItemKindKeyword
+		if c.Kind == scaf.QueryCompletionParameter {
+			kind = protocol.CompletionItemKindVariable
+		}
+
+		items = append(items, protocol.CompletionItem{
+			Label:  c.Label,
+			Kind:   kind,
+			Detail: c.Detail,
 		})
 	}
+
 	return items
 }
 
+// queryBodyAtPosition finds the raw string (query body, inline setup, or assert
+// query) containing pos and returns its text and the cursor's offset within it.
+func queryBodyAtPosition(suite *scaf.Suite, content string, pos protocol.Position) (string, int, bool) {
+	if suite == nil {
+		return "", 0, false
+	}
+
+	cursor := byteOffset(content, pos)
+
+	for _, span := range suite.BodySpans {
+		if span.ContainsOffset(cursor) && span.End.Offset <= len(content) {
+			return content[span.Start.Offset:span.End.Offset], cursor - span.Start.Offset, true
+		}
+	}
+
+	return "", 0, false
+}
+
+// byteOffset converts an LSP position to a byte offset in content.
+func byteOffset(content string, pos protocol.Position) int {
+	offset := 0
+
+	for range pos.Line {
+		idx := strings.IndexByte(content[offset:], '\n')
+		if idx < 0 {
+			return len(content)
+		}
+
+		offset += idx + 1
+	}
+
+	lineEnd := strings.IndexByte(content[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(content) - offset
+	}
+
+	return offset + min(int(pos.Character), lineEnd)
+}
+
 // completeReturnFields returns return field completions from the query in scope.
 func (s *Server) completeReturnFields(doc *Document, cc *CompletionContext) []protocol.CompletionItem {
 	af := s.getSymbolsAnalysis(doc)
@@ -593,15 +787,28 @@ func (s *Server) completeReturnFields(doc *Document, cc *CompletionContext) []pr
 		prefixProp = cc.Prefix[dotIdx+1:]
 	}
 
+	// Fields the current test already asserts sort after the rest
+	set := make(map[string]bool)
+	if cc.Test != nil {
+		for _, stmt := range cc.Test.Statements {
+			set[stmt.Key()] = true
+		}
+	}
+
 	items := make([]protocol.CompletionItem, 0, len(metadata.Returns))
 	for _, ret := range metadata.Returns {
 		// Use the full expression (e.g., "u.name") as the base
 		// If there's an alias, use that instead (it's the actual column name)
+		// Columns with no usable name are offered by position (result[0])
 		fullExpr := ret.Expression
 		if ret.Alias != "" {
 			fullExpr = ret.Alias
 		}
 
+		if ret.Key != "" {
+			fullExpr = ret.Key
+		}
+
 		// Determine label and insertText based on whether user typed a prefix with dot
 		var label, insertText string
 		if prefixBase != "" {
@@ -628,6 +835,10 @@ func (s *Server) completeReturnFields(doc *Document, cc *CompletionContext) []pr
 			Kind:       protocol.CompletionItemKindField,
 			Detail:     "return field",
 			InsertText: insertText,
+			SortText:   "0_" + label,
+		}
+		if set[fullExpr] {
+			item.SortText = "1_" + label
 		}
 		if ret.Alias != "" && ret.Expression != ret.Alias {
 			item.Documentation = &protocol.MarkupContent{
@@ -638,6 +849,9 @@ func (s *Server) completeReturnFields(doc *Document, cc *CompletionContext) []pr
 		if ret.IsAggregate {
 			item.Detail = "aggregate field"
 		}
+		if set[fullExpr] {
+			item.Detail += " (already set)"
+		}
 		items = append(items, item)
 	}
 	return items
@@ -658,6 +872,7 @@ func (s *Server) completeImportAliases(doc *Document, _ *CompletionContext) []pr
 			Detail: imp.Path,
 		})
 	}
+	sortCompletionItems(items)
 	return items
 }
 
@@ -706,8 +921,8 @@ func (s *Server) completeSetupFunctions(doc *Document, cc *CompletionContext) []
 
 	var items []protocol.CompletionItem
 
-	// Add queries from imported file as setup functions
-	for name, q := range importedFile.Symbols.Queries {
+	// Add queries from imported file as setup functions, including re-exports
+	for name, q := range analysis.ModuleQueries(NewLSPCrossFileResolver(s.fileLoader), importedFile) {
 		item := protocol.CompletionItem{
 			Label:  name,
 			Kind:   protocol.CompletionItemKindFunction,
@@ -721,7 +936,7 @@ func (s *Server) completeSetupFunctions(doc *Document, cc *CompletionContext) []
 			}
 			item.Documentation = &protocol.MarkupContent{
 				Kind:  protocol.Markdown,
-				Value: s.markdownCodeBlock(preview),
+				Value: s.markdownCodeBlock(doc, preview),
 			}
 		}
 
@@ -744,9 +959,91 @@ func (s *Server) completeSetupFunctions(doc *Document, cc *CompletionContext) []
 		items = append(items, item)
 	}
 
+	sortCompletionItems(items)
+	return items
+}
+
+// completeValues returns enum value completions for the statement key being assigned,
+// using the field's enum constraint from the workspace schema.
+func (s *Server) completeValues(doc *Document, cc *CompletionContext) []protocol.CompletionItem {
+	if s.schema == nil || cc.ValueKey == "" {
+		return nil
+	}
+
+	af := s.getSymbolsAnalysis(doc)
+	if af == nil || af.Symbols == nil || cc.InScope == "" {
+		return nil
+	}
+
+	q, ok := af.Symbols.Queries[cc.InScope]
+	if !ok || q.Body == "" {
+		return nil
+	}
+
+	model, field := s.schemaFieldForKey(q.Body, cc.ValueKey)
+	if field == nil || len(field.Enum) == 0 {
+		return nil
+	}
+
+	// Only string enums need quoting; numeric enums are inserted verbatim
+	quote := field.Type == nil || field.Type.Kind != analysis.TypeKindPrimitive || field.Type.Name == "string"
+
+	items := make([]protocol.CompletionItem, 0, len(field.Enum))
+	for _, value := range field.Enum {
+		insertText := value
+		if quote && !cc.ValueQuoted {
+			insertText = `"` + value + `"`
+		}
+
+		items = append(items, protocol.CompletionItem{
+			Label:      value,
+			Kind:       protocol.CompletionItemKindEnumMember,
+			Detail:     model + "." + field.Name,
+			InsertText: insertText,
+		})
+	}
 	return items
 }
 
+// schemaFieldForKey finds the schema field a statement key refers to.
+// The key is resolved to its return expression (e.g., alias "s" -> "r.sentiment"),
+// and the property is looked up on models whose name appears in the query body.
+func (s *Server) schemaFieldForKey(body, key string) (string, *analysis.Field) {
+	expr := strings.TrimPrefix(key, "$")
+
+	if s.queryAnalyzer != nil && !strings.HasPrefix(key, "$") {
+		if metadata, err := s.queryAnalyzer.AnalyzeQuery(body); err == nil {
+			for _, ret := range metadata.Returns {
+				if ret.Alias == key || ret.Name == key {
+					expr = ret.Expression
+					break
+				}
+			}
+		}
+	}
+
+	prop := expr
+	if dotIdx := strings.LastIndex(expr, "."); dotIdx >= 0 {
+		prop = expr[dotIdx+1:]
+	}
+
+	names := make([]string, 0, len(s.schema.Models))
+	for name := range s.schema.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !strings.Contains(body, name) {
+			continue
+		}
+		if field := s.schema.Models[name].Field(prop); field != nil && len(field.Enum) > 0 {
+			return name, field
+		}
+	}
+	return "", nil
+}
+
 // getSymbolsAnalysis returns the best analysis for symbol lookup.
 func (s *Server) getSymbolsAnalysis(doc *Document) *analysis.AnalyzedFile {
 	if doc.Analysis != nil && doc.Analysis.ParseError == nil {
@@ -813,8 +1110,8 @@ func containsLexerPosition(span scaf.Span, pos lexer.Position) bool {
 	return true
 }
 
-// markdownCodeBlock wraps code in a markdown code block.
-func (s *Server) markdownCodeBlock(code string) string {
-	lang := scaf.MarkdownLanguage(s.dialectName)
+// markdownCodeBlock wraps code in a markdown code block in the document's dialect.
+func (s *Server) markdownCodeBlock(doc *Document, code string) string {
+	lang := scaf.MarkdownLanguage(s.docDialect(doc))
 	return "```" + lang + "\n" + code + "\n```"
 }
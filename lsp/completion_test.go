@@ -3,6 +3,7 @@ package lsp_test
 import (
 	"context"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 
@@ -219,6 +220,76 @@ GetUser {
 	}
 }
 
+// TestServer_Completion_ReturnFields_UnsetFirst tests that fields already set in the
+// test are still offered but sort after the ones that are not.
+func TestServer_Completion_ReturnFields_UnsetFirst(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     "file:///test.scaf",
+			Version: 1,
+			Text: `query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u.name AS name, u.email AS email`" + `
+
+GetUser {
+	test "finds user" {
+		$id: 1
+		name: "Alice"
+
+	}
+}
+`,
+		},
+	})
+
+	// Line 6 is the blank line after name: "Alice"
+	result, err := server.Completion(ctx, &protocol.CompletionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+			Position:     protocol.Position{Line: 6, Character: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Completion() error: %v", err)
+	}
+
+	if result == nil {
+		t.Fatal("Expected completion result")
+	}
+
+	fields := make(map[string]protocol.CompletionItem)
+
+	for _, item := range result.Items {
+		if item.Kind == protocol.CompletionItemKindField {
+			fields[item.Label] = item
+		}
+	}
+
+	name, ok := fields["name"]
+	if !ok {
+		t.Fatalf("Expected already-set 'name' to still be offered, got: %v", fields)
+	}
+
+	email, ok := fields["email"]
+	if !ok {
+		t.Fatalf("Expected 'email' completion, got: %v", fields)
+	}
+
+	if email.SortText >= name.SortText {
+		t.Errorf("Expected unset 'email' (%q) to sort before set 'name' (%q)", email.SortText, name.SortText)
+	}
+
+	if !strings.Contains(name.Detail, "already set") {
+		t.Errorf("Expected 'name' detail to mention it is already set, got %q", name.Detail)
+	}
+}
+
 // TestServer_Completion_ReturnFields_NoAlias tests return field completions when
 // fields don't have explicit aliases - should use the full expression (e.g., u.name).
 func TestServer_Completion_ReturnFields_NoAlias(t *testing.T) {
@@ -398,6 +469,67 @@ GetUser {
 	}
 }
 
+func TestServer_Completion_ImportAliases_StableOrder(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     "file:///test.scaf",
+			Version: 1,
+			Text: `import users "./users"
+import accounts "./accounts"
+import posts "./posts"
+import billing "./billing"
+import comments "./comments"
+
+query GetUser ` + "`MATCH (u:User) RETURN u`" + `
+
+GetUser {
+	setup ` + "`CREATE (n:Node)`" + `
+	test "t" {}
+}
+`,
+		},
+	})
+
+	want := []string{"accounts", "billing", "comments", "posts", "users"}
+
+	// Map iteration order is randomised, so repeat the request to catch instability.
+	for range 20 {
+		result, err := server.Completion(ctx, &protocol.CompletionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+				Position:     protocol.Position{Line: 9, Character: 7}, // After "setup "
+			},
+		})
+		if err != nil {
+			t.Fatalf("Completion() error: %v", err)
+		}
+
+		if result == nil {
+			t.Fatal("Expected completion result")
+		}
+
+		var got []string
+
+		for _, item := range result.Items {
+			if item.Kind == protocol.CompletionItemKindModule {
+				got = append(got, item.Label)
+			}
+		}
+
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("import alias completions = %v, want %v", got, want)
+		}
+	}
+}
+
 func TestServer_Completion_Keywords_InTest(t *testing.T) {
 	t.Parallel()
 
@@ -444,6 +576,146 @@ GetUser {
 	}
 }
 
+func TestServer_Completion_TestSkeleton(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "query analyzes",
+			text: "query GetUser `MATCH (u:User {id: $id}) WHERE u.age > $minAge RETURN u.name AS name, u.email`\n\nGetUser {\n\t\n}\n",
+			want: "test \"${1:test name}\" {\n\t\\$id: ${2}\n\t\\$minAge: ${3}\n\tname: ${4}\n\tu.email: ${5}\n}",
+		},
+		{
+			name: "unknown query",
+			text: "query GetUser `MATCH (u:User) RETURN u`\n\nMissing {\n\t\n}\n",
+			want: "test \"${1:test name}\" {\n\t${2:\\$param: value}\n\t${3:field: expected}\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, _ := newTestServer(t)
+			ctx := context.Background()
+
+			_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+			_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+			_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+				TextDocument: protocol.TextDocumentItem{URI: "file:///test.scaf", Version: 1, Text: tt.text},
+			})
+
+			result, err := server.Completion(ctx, &protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+					Position:     protocol.Position{Line: 3, Character: 1},
+				},
+			})
+			if err != nil {
+				t.Fatalf("Completion() error: %v", err)
+			}
+
+			if result == nil {
+				t.Fatal("Expected completion result")
+			}
+
+			for _, item := range result.Items {
+				if item.Label == "test" {
+					if item.InsertText != tt.want {
+						t.Errorf("test snippet = %q, want %q", item.InsertText, tt.want)
+					}
+
+					return
+				}
+			}
+
+			t.Errorf("no test keyword completion in %v", result.Items)
+		})
+	}
+}
+
+func TestServer_Completion_SchemaEnumValues(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	if err := writeFile(tmpDir+"/.scaf.yaml", "generate:\n  schema: .scaf-schema.yaml\n"); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	schemaContent := `models:
+  Review:
+    fields:
+      sentiment:
+        type: string
+        enum: [positive, negative, neutral]
+`
+	if err := writeFile(tmpDir+"/.scaf-schema.yaml", schemaContent); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	validContent := `query GetReview ` + "`MATCH (r:Review) RETURN r.sentiment AS sentiment`" + `
+
+GetReview {
+	test "positive review" {
+		sentiment: "positive"
+	}
+}
+`
+	content, pos := parseContentWithCursor(strings.Replace(validContent, `"positive"`, "^", 1))
+
+	// Open a valid version first, then start typing the value.
+	uri := protocol.DocumentURI("file://" + tmpDir + "/reviews.scaf")
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: validContent},
+	})
+	_ = server.DidChange(ctx, &protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri},
+			Version:                2,
+		},
+		ContentChanges: []protocol.TextDocumentContentChangeEvent{{Text: content}},
+	})
+
+	result, err := server.Completion(ctx, &protocol.CompletionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     pos,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Completion() error: %v", err)
+	}
+
+	if result == nil || len(result.Items) != 3 {
+		t.Fatalf("Expected 3 enum completions, got %+v", result)
+	}
+
+	for i, want := range []string{"positive", "negative", "neutral"} {
+		item := result.Items[i]
+		if item.Label != want || item.Kind != protocol.CompletionItemKindEnumMember {
+			t.Errorf("Items[%d] = %s (kind=%v), want enum member %s", i, item.Label, item.Kind, want)
+		}
+
+		if item.InsertText != `"`+want+`"` {
+			t.Errorf("Items[%d].InsertText = %s, want quoted %s", i, item.InsertText, want)
+		}
+	}
+}
+
 func TestServer_Completion_Capabilities(t *testing.T) {
 	t.Parallel()
 
@@ -558,6 +830,69 @@ Get
 	}
 }
 
+func TestServer_Completion_QueryBody(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	content := "query GetUser `MATCH (u:User {id: $userId})\n\tWHERE u.age > $minAge\n\tRET`\n\n" +
+		"GetUser {\n\tsetup `MATCH (u) WHERE u.id = $orgId AND u.age > $`\n}\n"
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: "file:///test.scaf", Version: 1, Text: content},
+	})
+
+	complete := func(line, character uint32) []protocol.CompletionItem {
+		t.Helper()
+
+		result, err := server.Completion(ctx, &protocol.CompletionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+				Position:     protocol.Position{Line: line, Character: character},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Completion() error: %v", err)
+		}
+
+		return result.Items
+	}
+
+	// Half-typed "RET" at the end of the query body.
+	items := complete(2, 4)
+
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = item.Label
+	}
+
+	if !slices.Contains(labels, "RETURN") {
+		t.Errorf("Completion() labels = %v, want RETURN", labels)
+	}
+
+	for _, label := range labels {
+		if !strings.HasPrefix(label, "RET") {
+			t.Errorf("Completion() label %q does not match prefix RET", label)
+		}
+	}
+
+	// After "$" in an inline setup body, the parameters that body references are offered.
+	items = complete(5, 51)
+
+	labels = labels[:0]
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+
+	if !slices.Equal(labels, []string{"$orgId"}) {
+		t.Errorf("Completion() in setup body = %v, want [$orgId]", labels)
+	}
+}
+
 func TestServer_Completion_SetupFunctions_CrossFile(t *testing.T) {
 	t.Parallel()
 
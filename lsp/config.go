@@ -0,0 +1,170 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+
+	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/analysis"
+)
+
+// settings are the editor-provided options, accepted either as initialization
+// options or via workspace/didChangeConfiguration (optionally nested under "scaf"):
+//
+//	{"scaf": {"severity": {"unused-import": "hint", "undefined-query": "off"}}}
+//
+// Strict enables the report-recovered hint, which marks constructs the
+// recovering parser patched or skipped. Colors reports "#RRGGBB" string
+// values as colors (textDocument/documentColor); it is off by default.
+type settings struct {
+	Severity map[string]string `json:"severity"`
+	Strict   *bool             `json:"strict"`
+	Colors   *bool             `json:"colors"`
+	Scaf     *settings         `json:"scaf"`
+}
+
+// DidChangeConfiguration handles workspace/didChangeConfiguration.
+// Updated settings are applied and open documents are re-analyzed.
+func (s *Server) DidChangeConfiguration(ctx context.Context, params *protocol.DidChangeConfigurationParams) error {
+	s.logger.Info("DidChangeConfiguration")
+
+	if !s.applySettings(params.Settings) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, doc := range s.documents {
+		s.reanalyze(ctx, doc)
+	}
+
+	return nil
+}
+
+// loadWorkspaceConfig applies the nearest .scaf.yaml: the type schema
+// (used for value completions) and diagnostic severity overrides.
+func (s *Server) loadWorkspaceConfig(root string) {
+	configPath, err := scaf.FindConfig(root)
+	if err != nil {
+		return
+	}
+
+	cfg, err := scaf.LoadConfigFile(configPath)
+	if err != nil {
+		s.logger.Warn("Failed to load config", zap.String("path", configPath), zap.Error(err))
+
+		return
+	}
+
+	if cfg.Generate.Schema != "" {
+		schema, err := analysis.LoadSchema(cfg.Generate.Schema, filepath.Dir(configPath))
+		if err != nil {
+			s.logger.Warn("Failed to load schema", zap.String("path", cfg.Generate.Schema), zap.Error(err))
+		} else {
+			s.schema = schema
+			s.analyzer.SetSchema(schema)
+		}
+	}
+
+	if len(cfg.Lint.Severity) > 0 {
+		s.setSeverities(cfg.Lint.Severity)
+	}
+}
+
+// configDialect returns the dialect implied by the config nearest to path,
+// falling back to the server's dialect.
+func (s *Server) configDialect(path string) string {
+	cfg, err := scaf.LoadConfig(filepath.Dir(path))
+	if err == nil {
+		if name := cfg.DialectName(); name != "" {
+			return name
+		}
+	}
+
+	return s.dialectName
+}
+
+// docDialect returns the dialect declared by the document's shebang line,
+// else the document's dialect, else the server's.
+func (s *Server) docDialect(doc *Document) string {
+	if doc != nil && doc.Analysis != nil && doc.Analysis.Suite != nil {
+		if name := doc.Analysis.Suite.Dialect(); name != "" {
+			return name
+		}
+	}
+
+	if doc != nil && doc.Dialect != "" {
+		return doc.Dialect
+	}
+
+	return s.dialectName
+}
+
+// applySettings decodes editor settings and applies them.
+// Returns true if anything changed.
+func (s *Server) applySettings(raw any) bool {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return false
+	}
+
+	var opts settings
+	if err := json.Unmarshal(data, &opts); err != nil {
+		s.logger.Warn("Ignoring malformed settings", zap.Error(err))
+
+		return false
+	}
+
+	if opts.Scaf != nil {
+		opts = *opts.Scaf
+	}
+
+	changed := false
+
+	if opts.Strict != nil {
+		s.SetStrict(*opts.Strict)
+		changed = true
+	}
+
+	if opts.Colors != nil {
+		s.SetColors(*opts.Colors)
+		changed = true
+	}
+
+	if opts.Severity != nil && s.setSeverities(opts.Severity) {
+		changed = true
+	}
+
+	return changed
+}
+
+// SetStrict enables or disables strict analysis, which reports constructs
+// the recovering parser patched or skipped as report-recovered hints.
+func (s *Server) SetStrict(strict bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.analyzer.SetStrict(strict)
+}
+
+// setSeverities parses and installs diagnostic severity overrides.
+func (s *Server) setSeverities(names map[string]string) bool {
+	overrides, err := analysis.ParseSeverityOverrides(names)
+	if err != nil {
+		s.logger.Warn("Ignoring invalid severity overrides", zap.Error(err))
+
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.analyzer.SetSeverityOverrides(overrides)
+
+	return true
+}
@@ -202,6 +202,10 @@ func (s *Server) findSetupCallDefinition(doc *Document, tokenCtx *analysis.Token
 
 	// Check if the token is the query name (not the module)
 	if tokenCtx.Token != nil && tokenCtx.Token.Value == call.Query {
+		if call.Module == "" {
+			return findNamespaceDefinition(doc, call.Query)
+		}
+
 		// Look up in imported file
 		return s.findCrossFileDefinition(doc, call.Module, call.Query)
 	}
@@ -209,6 +213,22 @@ func (s *Server) findSetupCallDefinition(doc *Document, tokenCtx *analysis.Token
 	return nil
 }
 
+// findNamespaceDefinition finds the query an unqualified setup call refers to:
+// a local query, or one merged into the file's namespace by an unaliased import.
+func findNamespaceDefinition(doc *Document, queryName string) *protocol.Location {
+	q, defFile, _ := analysis.ResolveNamespaceQuery(doc.Analysis, queryName)
+	if q == nil || q.Node == nil {
+		return nil
+	}
+
+	uri := doc.URI
+	if defFile != doc.Analysis {
+		uri = PathToURI(defFile.Path)
+	}
+
+	return &protocol.Location{URI: uri, Range: queryNameRange(q.Node)}
+}
+
 // findParameterDefinition finds the definition of a parameter in the query body.
 // When clicking on $param in a test statement, this navigates to where $param is used in the query.
 func (s *Server) findParameterDefinition(doc *Document, tokenCtx *analysis.TokenContext) *protocol.Location {
@@ -453,18 +473,18 @@ func (s *Server) findCrossFileDefinition(doc *Document, moduleAlias, queryName s
 		return nil
 	}
 
-	// Find the query in the imported file
-	q, ok := importedFile.Symbols.Queries[queryName]
-	if !ok {
+	// Find the query in the imported file, following re-exports to the original
+	q, def := s.resolveImportedQuery(importedFile, queryName)
+	if q == nil {
 		s.logger.Debug("Query not found in imported file",
 			zap.String("queryName", queryName),
 			zap.String("path", importedPath))
 		return nil
 	}
 
-	// Return location in the imported file
+	// Return location in the defining file
 	return &protocol.Location{
-		URI:   PathToURI(importedPath),
+		URI:   PathToURI(def.Path),
 		Range: queryNameRange(q.Node),
 	}
 }
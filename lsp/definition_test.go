@@ -241,6 +241,137 @@ GetUser {
 		loc.URI, loc.Range.Start.Line, loc.Range.Start.Character, loc.Range.End.Character)
 }
 
+// TestServer_Definition_ImportAlias_WindowsURI tests that go-to-definition on an
+// import alias resolves against a Windows drive-letter document URI.
+func TestServer_Definition_ImportAlias_WindowsURI(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: "file:///C:/workspace",
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	content := `import fixtures "../shared/fixtures"
+
+query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+GetUser {
+	setup fixtures.CreateUser($name: "test")
+	test "finds user" {
+		$id: 1
+	}
+}
+`
+	uri := protocol.DocumentURI("file:///C:/workspace/tests/main.scaf")
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     uri,
+			Version: 1,
+			Text:    content,
+		},
+	})
+
+	result, err := server.Definition(ctx, &protocol.DefinitionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     protocol.Position{Line: 5, Character: 8}, // On "fixtures"
+		},
+	})
+	if err != nil {
+		t.Fatalf("Definition() error: %v", err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 location, got %d", len(result))
+	}
+
+	expected := protocol.DocumentURI("file:///C:/workspace/shared/fixtures.scaf")
+	if result[0].URI != expected {
+		t.Errorf("Expected URI %s, got %s", expected, result[0].URI)
+	}
+}
+
+// TestServer_Definition_UnaliasedImport tests go-to-definition from an unqualified
+// setup call to a query merged into the file's namespace by an unaliased import.
+func TestServer_Definition_UnaliasedImport(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	fixturesContent := `query CreatePost ` + "`CREATE (p:Post)`" + `
+query CreateUser ` + "`CREATE (u:User {name: $name}) RETURN u`" + `
+`
+	fixturesPath := tmpDir + "/fixtures.scaf"
+	if err := writeFile(fixturesPath, fixturesContent); err != nil {
+		t.Fatalf("Failed to create fixtures file: %v", err)
+	}
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	content := `import "./fixtures"
+
+query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+GetUser {
+	setup CreateUser($name: "test")
+	test "finds user" {
+		setup GetUser($id: 2)
+		$id: 1
+	}
+}
+`
+	mainPath := tmpDir + "/test.scaf"
+	if err := writeFile(mainPath, content); err != nil {
+		t.Fatalf("Failed to create main file: %v", err)
+	}
+
+	uri := protocol.DocumentURI("file://" + mainPath)
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: content},
+	})
+
+	tests := []struct {
+		name     string
+		position protocol.Position
+		wantURI  protocol.DocumentURI
+		wantLine uint32
+	}{
+		// "\tsetup " = 7 chars, so "CreateUser" starts at char 7
+		{name: "imported query", position: protocol.Position{Line: 5, Character: 9}, wantURI: protocol.DocumentURI("file://" + fixturesPath), wantLine: 1},
+		// "\t\tsetup " = 8 chars, so "GetUser" starts at char 8
+		{name: "local query", position: protocol.Position{Line: 7, Character: 10}, wantURI: uri, wantLine: 2},
+	}
+
+	for _, tt := range tests {
+		result, err := server.Definition(ctx, &protocol.DefinitionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     tt.position,
+			},
+		})
+		if err != nil {
+			t.Fatalf("%s: Definition() error: %v", tt.name, err)
+		}
+
+		if len(result) != 1 {
+			t.Fatalf("%s: expected 1 location, got %d", tt.name, len(result))
+		}
+
+		if loc := result[0]; loc.URI != tt.wantURI || loc.Range.Start.Line != tt.wantLine {
+			t.Errorf("%s: definition at %s:%d, want %s:%d", tt.name, loc.URI, loc.Range.Start.Line, tt.wantURI, tt.wantLine)
+		}
+	}
+}
+
 // TestServer_Definition_CrossFile_NamedSetup tests go-to-definition from a
 // module-prefixed named setup call to the query in the imported file.
 func TestServer_Definition_CrossFile_NamedSetup(t *testing.T) {
@@ -328,6 +459,97 @@ GetUser {
 
 // TestServer_Definition_NoResult tests that definition returns empty
 // when cursor is not on a navigable symbol.
+// TestServer_Definition_CrossFile_ReExport tests that go-to-definition through a
+// re-exporting hub jumps to the original query, not the hub.
+func TestServer_Definition_CrossFile_ReExport(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"users.scaf": "query DeleteUser `MATCH (u:User) DELETE u`\nquery CreateUser `CREATE (u:User {name: $name})`\n",
+		"seed.scaf":  "query Seed `CREATE (:Seed)`\n",
+		// The hub re-exports all of seed and one query of users.
+		"hub.scaf": "import seed \"./seed\"\n\nexport seed\nexport { CreateUser } from \"./users\"\n",
+	}
+	for name, content := range files {
+		if err := writeFile(tmpDir+"/"+name, content); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	mainContent := `import hub "./hub"
+
+query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+GetUser {
+	setup hub.CreateUser($name: "test")
+	test "finds user" {
+		setup hub.Seed()
+		$id: 1
+	}
+}
+`
+	mainPath := tmpDir + "/main.scaf"
+	if err := writeFile(mainPath, mainContent); err != nil {
+		t.Fatalf("Failed to create main file: %v", err)
+	}
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	mainURI := protocol.DocumentURI("file://" + mainPath)
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: mainURI, Version: 1, Text: mainContent},
+	})
+
+	// Both calls resolve through the hub.
+	for _, d := range client.diagnostics[len(client.diagnostics)-1].Diagnostics {
+		if d.Code == "undefined-setup-query" {
+			t.Errorf("Unexpected undefined-setup-query diagnostic: %s", d.Message)
+		}
+	}
+
+	tests := []struct {
+		name string
+		pos  protocol.Position
+		file string
+		line uint32
+	}{
+		{name: "named re-export", pos: protocol.Position{Line: 5, Character: 13}, file: "users.scaf", line: 1},
+		{name: "module re-export", pos: protocol.Position{Line: 7, Character: 13}, file: "seed.scaf", line: 0},
+	}
+
+	for _, tt := range tests {
+		result, err := server.Definition(ctx, &protocol.DefinitionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: mainURI},
+				Position:     tt.pos,
+			},
+		})
+		if err != nil {
+			t.Fatalf("%s: Definition() error: %v", tt.name, err)
+		}
+
+		if len(result) != 1 {
+			t.Fatalf("%s: expected 1 location, got %d", tt.name, len(result))
+		}
+
+		if want := protocol.DocumentURI("file://" + tmpDir + "/" + tt.file); result[0].URI != want {
+			t.Errorf("%s: expected URI %s, got %s", tt.name, want, result[0].URI)
+		}
+
+		if result[0].Range.Start.Line != tt.line {
+			t.Errorf("%s: expected definition on line %d, got line %d", tt.name, tt.line, result[0].Range.Start.Line)
+		}
+	}
+}
+
 func TestServer_Definition_NoResult(t *testing.T) {
 	t.Parallel()
 
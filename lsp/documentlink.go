@@ -11,6 +11,7 @@ import (
 
 // DocumentLink handles textDocument/documentLink requests.
 // Returns links for import paths that can be clicked to open the imported file.
+// Imports that don't resolve to an existing file get no link.
 func (s *Server) DocumentLink(_ context.Context, params *protocol.DocumentLinkParams) ([]protocol.DocumentLink, error) {
 	s.logger.Debug("DocumentLink",
 		zap.String("uri", string(params.TextDocument.URI)))
@@ -22,20 +23,22 @@ func (s *Server) DocumentLink(_ context.Context, params *protocol.DocumentLinkPa
 
 	var links []protocol.DocumentLink
 
+	docPath := URIToPath(params.TextDocument.URI)
+
 	for _, imp := range doc.Analysis.Suite.Imports {
+		// Resolve the import path to a file, omitting links for imports that
+		// don't resolve rather than pointing at a file that doesn't exist.
+		resolvedPath := s.fileLoader.ResolveImportPath(docPath, imp.Path)
+		if _, err := s.fileLoader.Load(resolvedPath); err != nil {
+			continue
+		}
+
 		// Calculate the range for just the path string (excluding quotes)
 		// Import format: import [alias] "path"
 		// We want to link the path part
-		pathRange := s.importPathRange(imp)
-
-		// Resolve the import path to a file URI
-		docPath := URIToPath(params.TextDocument.URI)
-		resolvedPath := s.fileLoader.ResolveImportPath(docPath, imp.Path)
-		targetURI := PathToURI(resolvedPath)
-
 		links = append(links, protocol.DocumentLink{
-			Range:   pathRange,
-			Target:  targetURI,
+			Range:   s.importPathRange(imp),
+			Target:  PathToURI(resolvedPath),
 			Tooltip: "Open " + imp.Path,
 		})
 	}
@@ -168,3 +168,63 @@ func TestServer_DocumentLink_NoImports(t *testing.T) {
 		t.Errorf("Expected no links for file without imports, got %d", len(result))
 	}
 }
+
+func TestServer_DocumentLink_RelativeTarget(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	if err := os.MkdirAll(tmpDir+"/shared", 0o755); err != nil {
+		t.Fatalf("Failed to create shared dir: %v", err)
+	}
+
+	fixturesPath := tmpDir + "/shared/fixtures.scaf"
+	if err := os.WriteFile(fixturesPath, []byte("query Q `Q`\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write fixtures.scaf: %v", err)
+	}
+
+	mainPath := tmpDir + "/tests/main.scaf"
+	mainContent := `import fixtures "../shared/fixtures"
+import missing "./missing"
+
+query GetUser ` + "`Q`" + `
+GetUser { test "t" {} }
+`
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	mainURI := protocol.DocumentURI("file://" + mainPath)
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: mainURI, Version: 1, Text: mainContent},
+	})
+
+	result, err := server.DocumentLink(ctx, &protocol.DocumentLinkParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: mainURI},
+	})
+	if err != nil {
+		t.Fatalf("DocumentLink() error: %v", err)
+	}
+
+	// The unresolved import is omitted rather than linked to a missing file.
+	if len(result) != 1 {
+		t.Fatalf("DocumentLink() returned %d links, want 1: %+v", len(result), result)
+	}
+
+	if want := protocol.DocumentURI("file://" + fixturesPath); result[0].Target != want {
+		t.Errorf("Target = %s, want %s", result[0].Target, want)
+	}
+
+	wantRange := protocol.Range{
+		Start: protocol.Position{Line: 0, Character: 16},
+		End:   protocol.Position{Line: 0, Character: 36},
+	}
+	if result[0].Range != wantRange {
+		t.Errorf("Range = %+v, want %+v", result[0].Range, wantRange)
+	}
+}
@@ -1,8 +1,10 @@
 package lsp
 
 import (
+	"io/fs"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -70,15 +72,17 @@ func (l *LSPFileLoader) Load(path string) ([]byte, error) {
 // ResolveImportPath resolves a relative import path to an absolute file path.
 // basePath is the path of the file containing the import (from document URI).
 // importPath is the relative path from the import statement (e.g., "../shared/fixtures").
+// Paths are resolved with forward slashes, so Windows paths such as
+// "C:\ws\main.scaf" resolve to "C:/ws/...".
 func (l *LSPFileLoader) ResolveImportPath(basePath, importPath string) string {
-	// Get the directory of the base file
-	baseDir := filepath.Dir(basePath)
-
-	// Resolve the import path relative to the base directory
-	resolved := filepath.Join(baseDir, importPath)
+	basePath = normalizePath(basePath)
+	if isWindowsPath(basePath) {
+		importPath = strings.ReplaceAll(importPath, `\`, "/")
+	}
 
-	// Clean the path to resolve .. and .
-	resolved = filepath.Clean(resolved)
+	// Resolve the import path relative to the directory of the base file,
+	// cleaning the result to resolve .. and .
+	resolved := path.Clean(path.Join(path.Dir(basePath), importPath))
 
 	// Try the path as-is first (for absolute paths or paths with extension)
 	if _, err := os.Stat(resolved); err == nil {
@@ -95,13 +99,11 @@ func (l *LSPFileLoader) ResolveImportPath(basePath, importPath string) string {
 
 		// Try dialect-specific extensions (e.g., .cypher.scaf, .sql.scaf)
 		// by globbing for any *.scaf file matching the base name
-		dir := filepath.Dir(resolved)
-		base := filepath.Base(resolved)
-		pattern := filepath.Join(dir, base+"*.scaf")
+		pattern := resolved + "*.scaf"
 
 		matches, err := filepath.Glob(pattern)
 		if err == nil && len(matches) == 1 {
-			return matches[0]
+			return normalizePath(matches[0])
 		}
 
 		// Fall back to adding .scaf extension (even if file doesn't exist)
@@ -112,25 +114,57 @@ func (l *LSPFileLoader) ResolveImportPath(basePath, importPath string) string {
 }
 
 // URIToPath converts a document URI to a file system path.
+// Windows drive-letter URIs (file:///C:/...) yield "C:/..." with forward slashes.
 func URIToPath(uri protocol.DocumentURI) string {
 	// Parse the URI
 	u, err := url.Parse(string(uri))
 	if err != nil {
 		// Fallback: strip file:// prefix
-		return strings.TrimPrefix(string(uri), "file://")
+		return trimDriveSlash(strings.TrimPrefix(string(uri), "file://"))
 	}
 
 	// For file:// URIs, return the path
 	if u.Scheme == "file" {
-		return u.Path
+		return trimDriveSlash(u.Path)
 	}
 
 	return string(uri)
 }
 
 // PathToURI converts a file system path to a document URI.
-func PathToURI(path string) protocol.DocumentURI {
-	return protocol.DocumentURI("file://" + path)
+// Backslashes in Windows paths are converted to forward slashes.
+func PathToURI(p string) protocol.DocumentURI {
+	p = normalizePath(p)
+	if isWindowsPath(p) {
+		return protocol.DocumentURI("file:///" + p)
+	}
+
+	return protocol.DocumentURI("file://" + p)
+}
+
+// isWindowsPath reports whether p starts with a drive letter, e.g. "C:".
+func isWindowsPath(p string) bool {
+	return len(p) >= 2 && p[1] == ':' &&
+		(('a' <= p[0] && p[0] <= 'z') || ('A' <= p[0] && p[0] <= 'Z'))
+}
+
+// normalizePath converts backslash separators to forward slashes for Windows
+// paths. Backslashes are left alone elsewhere, as they are valid in POSIX file names.
+func normalizePath(p string) string {
+	if filepath.Separator == '\\' || isWindowsPath(p) {
+		return strings.ReplaceAll(p, `\`, "/")
+	}
+
+	return p
+}
+
+// trimDriveSlash strips the leading slash from URI paths like "/C:/ws".
+func trimDriveSlash(p string) string {
+	if strings.HasPrefix(p, "/") && isWindowsPath(p[1:]) {
+		return p[1:]
+	}
+
+	return p
 }
 
 // LoadAndAnalyze loads a file and returns its analysis.
@@ -157,8 +191,8 @@ func (l *LSPFileLoader) LoadAndAnalyze(path string) (*analysis.AnalyzedFile, err
 		zap.String("path", path),
 		zap.Int("contentLen", len(content)))
 
-	// Create a temporary analyzer (without loader to avoid infinite recursion for now)
-	// TODO: Support recursive imports with cycle detection
+	// Analyze without a resolver: imported files are analyzed on their own, so
+	// import cycles can't recurse here. The circular-import rule reports them.
 	analyzer := analysis.NewAnalyzer(nil)
 	result := analyzer.Analyze(path, content)
 
@@ -233,5 +267,37 @@ func (r *LSPCrossFileResolver) LoadAndAnalyze(path string) *analysis.AnalyzedFil
 	return r.loader.LoadAndAnalyzeForResolver(path)
 }
 
-// Ensure LSPCrossFileResolver implements analysis.CrossFileResolver.
-var _ analysis.CrossFileResolver = (*LSPCrossFileResolver)(nil)
+// ProjectFiles implements analysis.ProjectResolver.
+// It returns the .scaf files under the workspace root, or none without one.
+func (r *LSPCrossFileResolver) ProjectFiles() []string {
+	if r.loader.workspaceRoot == "" {
+		return nil
+	}
+
+	var files []string
+
+	_ = filepath.WalkDir(r.loader.workspaceRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // Skip unreadable entries.
+		}
+
+		if !d.IsDir() && strings.HasSuffix(p, ".scaf") {
+			files = append(files, normalizePath(p))
+		}
+
+		return nil
+	})
+
+	return files
+}
+
+// Ensure LSPCrossFileResolver implements analysis.ProjectResolver.
+var _ analysis.ProjectResolver = (*LSPCrossFileResolver)(nil)
+
+// resolveImportedQuery looks up a query reachable through an imported file,
+// following its re-exports to the file that defines it.
+func (s *Server) resolveImportedQuery(
+	importedFile *analysis.AnalyzedFile, name string,
+) (*analysis.QuerySymbol, *analysis.AnalyzedFile) {
+	return analysis.ResolveModuleQuery(NewLSPCrossFileResolver(s.fileLoader), importedFile, name)
+}
@@ -29,6 +29,16 @@ func TestURIToPath(t *testing.T) {
 			uri:      "file:///Users/test/my%20project/file.scaf",
 			expected: "/Users/test/my project/file.scaf", // url.Parse decodes the path
 		},
+		{
+			name:     "windows drive letter",
+			uri:      "file:///C:/Users/test/project/file.scaf",
+			expected: "C:/Users/test/project/file.scaf",
+		},
+		{
+			name:     "windows drive letter with encoded colon",
+			uri:      "file:///c%3A/Users/test/project/file.scaf",
+			expected: "c:/Users/test/project/file.scaf",
+		},
 	}
 
 	for _, tt := range tests {
@@ -56,6 +66,16 @@ func TestPathToURI(t *testing.T) {
 			path:     "/Users/test/project/file.scaf",
 			expected: "file:///Users/test/project/file.scaf",
 		},
+		{
+			name:     "windows path",
+			path:     "C:/Users/test/project/file.scaf",
+			expected: "file:///C:/Users/test/project/file.scaf",
+		},
+		{
+			name:     "windows path with backslashes",
+			path:     `C:\Users\test\project\file.scaf`,
+			expected: "file:///C:/Users/test/project/file.scaf",
+		},
 	}
 
 	for _, tt := range tests {
@@ -106,6 +126,18 @@ func TestLSPFileLoader_ResolveImportPath(t *testing.T) {
 			importPath: "../../../shared/common/fixtures",
 			expected:   "/workspace/shared/common/fixtures.scaf",
 		},
+		{
+			name:       "windows parent directory",
+			basePath:   "C:/workspace/tests/main.scaf",
+			importPath: "../shared/fixtures",
+			expected:   "C:/workspace/shared/fixtures.scaf",
+		},
+		{
+			name:       "windows backslash separators",
+			basePath:   `C:\workspace\tests\main.scaf`,
+			importPath: `..\shared\fixtures`,
+			expected:   "C:/workspace/shared/fixtures.scaf",
+		},
 	}
 
 	for _, tt := range tests {
@@ -10,7 +10,9 @@ import (
 )
 
 // FoldingRanges handles textDocument/foldingRange requests.
-// Returns folding ranges for queries, scopes, groups, tests, and setup blocks.
+// Returns folding ranges for queries, scopes, groups, sequences, tests, asserts,
+// setup blocks, and multi-line query bodies. Nodes recovered without their
+// closing brace are skipped, though their complete children still fold.
 func (s *Server) FoldingRanges(_ context.Context, params *protocol.FoldingRangeParams) ([]protocol.FoldingRange, error) {
 	s.logger.Debug("FoldingRanges",
 		zap.String("uri", string(params.TextDocument.URI)))
@@ -48,9 +50,44 @@ func (s *Server) FoldingRanges(_ context.Context, params *protocol.FoldingRangeP
 		ranges = append(ranges, s.scopeFoldingRanges(scope)...)
 	}
 
+	// Add ranges for multi-line bodies not already folded with their node,
+	// such as teardown and inline assert queries
+	starts := make(map[uint32]bool, len(ranges))
+	for _, r := range ranges {
+		starts[r.StartLine] = true
+	}
+
+	for _, span := range doc.Analysis.Suite.BodySpans {
+		start := uint32(span.Start.Line - 1) //nolint:gosec
+		if span.End.Line > span.Start.Line && !starts[start] {
+			ranges = append(ranges, protocol.FoldingRange{
+				StartLine: start,
+				EndLine:   uint32(span.End.Line - 1), //nolint:gosec
+				Kind:      protocol.RegionFoldingRange,
+			})
+			starts[start] = true
+		}
+	}
+
 	return ranges, nil
 }
 
+// blockFoldingRange creates a folding range for a braced node, unless it was
+// recovered without its closing brace.
+func blockFoldingRange(node scaf.CompletableNode) []protocol.FoldingRange {
+	if !node.IsComplete() {
+		return nil
+	}
+
+	span := node.Span()
+
+	return []protocol.FoldingRange{{
+		StartLine: uint32(span.Start.Line - 1), //nolint:gosec
+		EndLine:   uint32(span.End.Line - 1),   //nolint:gosec
+		Kind:      protocol.RegionFoldingRange,
+	}}
+}
+
 // queryFoldingRange creates a folding range for a query definition.
 func (s *Server) queryFoldingRange(q *scaf.Query) protocol.FoldingRange {
 	return protocol.FoldingRange{
@@ -74,11 +111,7 @@ func (s *Server) scopeFoldingRanges(scope *scaf.QueryScope) []protocol.FoldingRa
 	var ranges []protocol.FoldingRange
 
 	// Add range for the scope itself
-	ranges = append(ranges, protocol.FoldingRange{
-		StartLine: uint32(scope.Pos.Line - 1),    //nolint:gosec
-		EndLine:   uint32(scope.EndPos.Line - 1), //nolint:gosec
-		Kind:      protocol.RegionFoldingRange,
-	})
+	ranges = append(ranges, blockFoldingRange(scope)...)
 
 	// Add range for scope setup if present
 	if scope.Setup != nil {
@@ -93,12 +126,16 @@ func (s *Server) scopeFoldingRanges(scope *scaf.QueryScope) []protocol.FoldingRa
 	return ranges
 }
 
-// itemFoldingRanges creates folding ranges for a test or group.
+// itemFoldingRanges creates folding ranges for a test, group, or sequence.
 func (s *Server) itemFoldingRanges(item *scaf.TestOrGroup) []protocol.FoldingRange {
 	var ranges []protocol.FoldingRange
 
-	if item.Test != nil {
-		ranges = append(ranges, s.testFoldingRanges(item.Test)...)
+	if item.Sequence != nil {
+		ranges = append(ranges, blockFoldingRange(item.Sequence)...)
+	}
+
+	for _, test := range item.Tests() {
+		ranges = append(ranges, s.testFoldingRanges(test)...)
 	}
 
 	if item.Group != nil {
@@ -113,11 +150,7 @@ func (s *Server) testFoldingRanges(test *scaf.Test) []protocol.FoldingRange {
 	var ranges []protocol.FoldingRange
 
 	// Add range for the test itself
-	ranges = append(ranges, protocol.FoldingRange{
-		StartLine: uint32(test.Pos.Line - 1),    //nolint:gosec
-		EndLine:   uint32(test.EndPos.Line - 1), //nolint:gosec
-		Kind:      protocol.RegionFoldingRange,
-	})
+	ranges = append(ranges, blockFoldingRange(test)...)
 
 	// Add range for test setup if present
 	if test.Setup != nil {
@@ -127,11 +160,7 @@ func (s *Server) testFoldingRanges(test *scaf.Test) []protocol.FoldingRange {
 	// Add ranges for asserts
 	for _, assert := range test.Asserts {
 		if assert.EndPos.Line > assert.Pos.Line {
-			ranges = append(ranges, protocol.FoldingRange{
-				StartLine: uint32(assert.Pos.Line - 1),    //nolint:gosec
-				EndLine:   uint32(assert.EndPos.Line - 1), //nolint:gosec
-				Kind:      protocol.RegionFoldingRange,
-			})
+			ranges = append(ranges, blockFoldingRange(assert)...)
 		}
 	}
 
@@ -143,11 +172,7 @@ func (s *Server) groupFoldingRanges(group *scaf.Group) []protocol.FoldingRange {
 	var ranges []protocol.FoldingRange
 
 	// Add range for the group itself
-	ranges = append(ranges, protocol.FoldingRange{
-		StartLine: uint32(group.Pos.Line - 1),    //nolint:gosec
-		EndLine:   uint32(group.EndPos.Line - 1), //nolint:gosec
-		Kind:      protocol.RegionFoldingRange,
-	})
+	ranges = append(ranges, blockFoldingRange(group)...)
 
 	// Add range for group setup if present
 	if group.Setup != nil {
@@ -2,6 +2,8 @@ package lsp_test
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"testing"
 
 	"go.lsp.dev/protocol"
@@ -154,3 +156,76 @@ Q { test "t" {} }
 		}
 	}
 }
+
+func TestServer_FoldingRanges_Nested(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string // "start-end" lines, in order
+	}{
+		{
+			name: "nested blocks and bodies",
+			content: "query Q `MATCH (n)\nRETURN n`\n\n" +
+				"Q {\n" +
+				"\tteardown `MATCH (n)\n\tDETACH DELETE n`\n" +
+				"\tgroup \"outer\" {\n" +
+				"\t\tgroup \"inner\" {\n" +
+				"\t\t\tsequence {\n" +
+				"\t\t\t\ttest \"t\" {\n" +
+				"\t\t\t\t\tassert `MATCH (n)\n\t\t\t\t\tRETURN count(n) AS c` { c > 0 }\n" +
+				"\t\t\t\t}\n" +
+				"\t\t\t}\n" +
+				"\t\t}\n" +
+				"\t}\n" +
+				"}\n",
+			want: []string{"0-1", "3-16", "6-15", "7-14", "8-13", "9-12", "10-11", "4-5"},
+		},
+		{
+			name: "recovered without closing braces",
+			content: "query Q `Q`\n\n" +
+				"Q {\n" +
+				"\ttest \"done\" {\n" +
+				"\t\t$id: 1\n" +
+				"\t}\n" +
+				"\ttest \"typing\" {\n" +
+				"\t\t$id: 2\n",
+			want: []string{"0-0", "3-5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, _ := newTestServer(t)
+			ctx := context.Background()
+
+			_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+			_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+			_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+				TextDocument: protocol.TextDocumentItem{URI: "file:///test.scaf", Version: 1, Text: tt.content},
+			})
+
+			result, err := server.FoldingRanges(ctx, &protocol.FoldingRangeParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+				},
+			})
+			if err != nil {
+				t.Fatalf("FoldingRanges() error: %v", err)
+			}
+
+			got := make([]string, 0, len(result))
+			for _, r := range result {
+				got = append(got, fmt.Sprintf("%d-%d", r.StartLine, r.EndLine))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FoldingRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
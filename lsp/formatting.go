@@ -2,6 +2,7 @@ package lsp
 
 import (
 	"context"
+	"path/filepath"
 	"strings"
 
 	"go.lsp.dev/protocol"
@@ -19,13 +20,19 @@ func (s *Server) Formatting(_ context.Context, params *protocol.DocumentFormatti
 		return nil, nil
 	}
 
+	// Layout options come from the nearest .scaf.yaml, as for scaf fmt.
+	var opts scaf.FormatOptions
+	if cfg, err := scaf.LoadConfig(filepath.Dir(URIToPath(doc.URI))); err == nil {
+		opts = cfg.FormatOptions()
+	}
+
 	// Need a valid parse to format (no parse errors)
-	if doc.Analysis == nil || doc.Analysis.Suite == nil || doc.Analysis.ParseError != nil {
-		return nil, nil
+	out, err := scaf.FormatSourceWithOptions([]byte(doc.Content), opts)
+	if err != nil {
+		return nil, nil //nolint:nilerr // Unparseable documents are left as they are.
 	}
 
-	// Use the existing formatter
-	formatted := scaf.Format(doc.Analysis.Suite)
+	formatted := string(out)
 
 	// If no change, return empty edits
 	if formatted == doc.Content {
@@ -49,3 +56,61 @@ func (s *Server) Formatting(_ context.Context, params *protocol.DocumentFormatti
 		},
 	}, nil
 }
+
+// RangeFormatting handles textDocument/rangeFormatting by formatting only the
+// query body (the text between backticks) containing the start of the range,
+// using the document dialect's QueryFormatter. Bodies that don't parse, and
+// ranges outside any body, are left unchanged.
+func (s *Server) RangeFormatting(_ context.Context, params *protocol.DocumentRangeFormattingParams) ([]protocol.TextEdit, error) {
+	s.logger.Debug("RangeFormatting", zap.String("uri", string(params.TextDocument.URI)))
+
+	doc, ok := s.getDocument(params.TextDocument.URI)
+	if !ok || doc.Analysis == nil || doc.Analysis.Suite == nil {
+		return nil, nil
+	}
+
+	formatter, ok := scaf.GetDialect(s.docDialect(doc)).(scaf.QueryFormatter)
+	if !ok {
+		return nil, nil
+	}
+
+	cursor := byteOffset(doc.Content, params.Range.Start)
+
+	for _, span := range doc.Analysis.Suite.BodySpans {
+		if !span.ContainsOffset(cursor) || span.End.Offset > len(doc.Content) {
+			continue
+		}
+
+		body := doc.Content[span.Start.Offset:span.End.Offset]
+
+		formatted, err := formatter.FormatQuery(body)
+		if err != nil {
+			return []protocol.TextEdit{}, nil //nolint:nilerr // A body that doesn't parse is left as it is.
+		}
+
+		if formatted = layoutBody(body, formatted); formatted == body {
+			return []protocol.TextEdit{}, nil
+		}
+
+		return []protocol.TextEdit{{Range: spanToRange(span), NewText: formatted}}, nil
+	}
+
+	return nil, nil
+}
+
+// layoutBody fits a formatted query into the layout of the body it replaces:
+// a single-line body stays on one line, and a multi-line body keeps its
+// surrounding whitespace with each line at the first line's indentation.
+func layoutBody(body, formatted string) string {
+	trimmed := strings.TrimSpace(body)
+	lead := body[:strings.Index(body, trimmed)]
+	trail := body[len(lead)+len(trimmed):]
+
+	if !strings.Contains(body, "\n") {
+		return lead + strings.Join(strings.Split(formatted, "\n"), " ") + trail
+	}
+
+	indent := lead[strings.LastIndex(lead, "\n")+1:]
+
+	return lead + strings.ReplaceAll(formatted, "\n", "\n"+indent) + trail
+}
@@ -125,8 +125,8 @@ func (s *Server) highlightQueryUsages(doc *Document, queryName string) []protoco
 // findAssertQueryHighlights recursively finds assert query references.
 func (s *Server) findAssertQueryHighlights(items []*scaf.TestOrGroup, queryName string, highlights *[]protocol.DocumentHighlight) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, assert := range item.Test.Asserts {
+		for _, test := range item.Tests() {
+			for _, assert := range test.Asserts {
 				if assert.Query != nil && assert.Query.QueryName != nil && *assert.Query.QueryName == queryName {
 					*highlights = append(*highlights, protocol.DocumentHighlight{
 						Range: assertQueryNameRange(assert.Query),
@@ -302,8 +302,8 @@ func setupItemModuleRange(item *scaf.SetupItem) protocol.Range {
 // findItemSetupImportHighlights recursively finds import references in test/group items.
 func (s *Server) findItemSetupImportHighlights(items []*scaf.TestOrGroup, alias string, highlights *[]protocol.DocumentHighlight) {
 	for _, item := range items {
-		if item.Test != nil {
-			s.findSetupImportHighlights(item.Test.Setup, alias, highlights)
+		for _, test := range item.Tests() {
+			s.findSetupImportHighlights(test.Setup, alias, highlights)
 		}
 		if item.Group != nil {
 			s.findSetupImportHighlights(item.Group.Setup, alias, highlights)
@@ -344,8 +344,8 @@ func (s *Server) highlightSetupCallQuery(doc *Document, module, query string) []
 	var findInItems func([]*scaf.TestOrGroup)
 	findInItems = func(items []*scaf.TestOrGroup) {
 		for _, item := range items {
-			if item.Test != nil {
-				findInSetup(item.Test.Setup)
+			for _, test := range item.Tests() {
+				findInSetup(test.Setup)
 			}
 			if item.Group != nil {
 				findInSetup(item.Group.Setup)
@@ -365,8 +365,8 @@ func (s *Server) highlightSetupCallQuery(doc *Document, module, query string) []
 
 // setupCallQueryRange returns the range for the query name in a setup call.
 func setupCallQueryRange(call *scaf.SetupCall) protocol.Range {
-	// Query name starts after "Module."
-	queryStartCol := call.Pos.Column + len(call.Module) + 1 // +1 for dot
+	// Query name starts after "Module.", if qualified
+	queryStartCol := call.Pos.Column + len(call.Ref()) - len(call.Query)
 	return protocol.Range{
 		Start: protocol.Position{
 			Line:      uint32(call.Pos.Line - 1),      //nolint:gosec
@@ -403,8 +403,8 @@ func (s *Server) highlightParameterUsages(doc *Document, queryScope, paramKey st
 // findParamInItems recursively finds parameter usages in test items.
 func (s *Server) findParamInItems(items []*scaf.TestOrGroup, paramKey string, highlights *[]protocol.DocumentHighlight) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, stmt := range item.Test.Statements {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
 				if stmt.Key() == paramKey {
 					*highlights = append(*highlights, protocol.DocumentHighlight{
 						Range: statementKeyRange(stmt),
@@ -458,8 +458,8 @@ func (s *Server) highlightReturnFieldUsages(doc *Document, queryScope, fieldKey
 // findFieldInItems recursively finds return field usages in test items.
 func (s *Server) findFieldInItems(items []*scaf.TestOrGroup, fieldKey string, highlights *[]protocol.DocumentHighlight) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, stmt := range item.Test.Statements {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
 				key := stmt.Key()
 				if len(key) > 0 && key[0] != '$' && key == fieldKey {
 					*highlights = append(*highlights, protocol.DocumentHighlight{
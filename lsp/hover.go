@@ -3,6 +3,8 @@ package lsp
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"go.lsp.dev/protocol"
@@ -12,9 +14,9 @@ import (
 	"github.com/rlch/scaf/analysis"
 )
 
-// markdownQueryBlock wraps a query body in a markdown code block with the appropriate language.
-func (s *Server) markdownQueryBlock(queryBody string) string {
-	lang := scaf.MarkdownLanguage(s.dialectName)
+// markdownQueryBlock wraps a query body in a markdown code block in the document's dialect.
+func (s *Server) markdownQueryBlock(doc *Document, queryBody string) string {
+	lang := scaf.MarkdownLanguage(s.docDialect(doc))
 	return "```" + lang + "\n" + strings.TrimSpace(queryBody) + "\n```"
 }
 
@@ -32,6 +34,18 @@ func (s *Server) Hover(_ context.Context, params *protocol.HoverParams) (*protoc
 
 	pos := analysis.PositionToLexer(params.Position.Line, params.Position.Character)
 
+	// The file's first line and its meta block show the suite's metadata
+	if meta := doc.Analysis.Suite.Meta; meta != nil && len(meta.Entries) > 0 &&
+		(params.Position.Line == 0 || (pos.Line >= meta.Pos.Line && pos.Line <= meta.EndPos.Line)) {
+		return &protocol.Hover{
+			Contents: protocol.MarkupContent{
+				Kind:  protocol.Markdown,
+				Value: hoverMeta(meta),
+			},
+			Range: rangePtr(spanToRange(meta.Span())),
+		}, nil
+	}
+
 	// Get token context for precise information
 	tokenCtx := analysis.GetTokenContext(doc.Analysis, pos)
 
@@ -60,7 +74,7 @@ func (s *Server) Hover(_ context.Context, params *protocol.HoverParams) (*protoc
 func (s *Server) hoverContent(doc *Document, f *analysis.AnalyzedFile, node scaf.Node, tokenCtx *analysis.TokenContext) (string, *protocol.Range) {
 	switch n := node.(type) {
 	case *scaf.Query:
-		return s.hoverQuery(n), rangePtr(spanToRange(n.Span()))
+		return s.hoverQuery(doc, n), rangePtr(spanToRange(n.Span()))
 
 	case *scaf.Import:
 		return s.hoverImport(n), rangePtr(spanToRange(n.Span()))
@@ -68,7 +82,7 @@ func (s *Server) hoverContent(doc *Document, f *analysis.AnalyzedFile, node scaf
 	case *scaf.QueryScope:
 		// When hovering over a scope, show info about the referenced query
 		if q, ok := f.Symbols.Queries[n.QueryName]; ok {
-			return s.hoverQueryRef(q), rangePtr(spanToRange(n.Span()))
+			return s.hoverQueryRef(doc, q) + definitionLink(doc.URI, q.Node), rangePtr(spanToRange(n.Span()))
 		}
 
 		return fmt.Sprintf("**Query Scope:** `%s` (undefined)", n.QueryName), rangePtr(spanToRange(n.Span()))
@@ -92,25 +106,113 @@ func (s *Server) hoverContent(doc *Document, f *analysis.AnalyzedFile, node scaf
 		return s.hoverSetupItem(doc, f, n, tokenCtx), rangePtr(spanToRange(n.Span()))
 
 	case *scaf.AssertQuery:
-		return s.hoverAssertQuery(f, n), rangePtr(spanToRange(n.Span()))
+		return s.hoverAssertQuery(doc, f, n), rangePtr(spanToRange(n.Span()))
 
 	default:
 		return "", nil
 	}
 }
 
-// hoverQuery generates hover content for a query definition.
-func (s *Server) hoverQuery(q *scaf.Query) string {
+// hoverMeta generates hover content for the suite's meta block, in source order.
+func hoverMeta(meta *scaf.Map) string {
+	var b strings.Builder
+
+	b.WriteString("**Suite metadata**\n\n")
+
+	for _, e := range meta.Entries {
+		b.WriteString(fmt.Sprintf("- **%s:** %s\n", e.Key, e.Value.String()))
+	}
+
+	return b.String()
+}
+
+// hoverQuery generates hover content for a query definition: a summary card with
+// the dialect, read/write classification, parameters, and return fields.
+func (s *Server) hoverQuery(doc *Document, q *scaf.Query) string {
 	var b strings.Builder
 
 	b.WriteString(fmt.Sprintf("**Query:** `%s`\n\n", q.Name))
-	b.WriteString(s.markdownQueryBlock(q.Body))
+
+	switch {
+	case s.queryAnalyzer == nil:
+		b.WriteString(fmt.Sprintf("**Dialect:** %s (no analyzer available)\n\n", s.dialectName))
+	default:
+		metadata, err := s.queryAnalyzer.AnalyzeQuery(q.Body)
+		if err != nil {
+			b.WriteString(fmt.Sprintf("**Dialect:** %s\n\n", s.dialectName))
+			b.WriteString(fmt.Sprintf("⚠️ Query body could not be analyzed: %s\n\n", err.Error()))
+		} else {
+			s.writeQuerySummary(&b, metadata, q.ParamDefaults())
+		}
+	}
+
+	b.WriteString(s.markdownQueryBlock(doc, q.Body))
 
 	return b.String()
 }
 
+// writeQuerySummary writes the dialect, classification, parameters, and returns of an analyzed query.
+func (s *Server) writeQuerySummary(b *strings.Builder, metadata *scaf.QueryMetadata, defaults map[string]*scaf.Value) {
+	mode := "read-only"
+	if metadata.Writes {
+		mode = "writes"
+	}
+
+	b.WriteString(fmt.Sprintf("**Dialect:** %s · %s\n\n", s.dialectName, mode))
+
+	if len(metadata.Parameters) > 0 {
+		b.WriteString("**Parameters:**\n")
+
+		for _, p := range metadata.Parameters {
+			b.WriteString("- `$" + p.Name + "`")
+
+			if p.Type != "" {
+				b.WriteString(": `" + p.Type + "`")
+			}
+
+			if def, ok := defaults[p.Name]; ok {
+				b.WriteString(" = `" + def.String() + "`")
+			}
+
+			b.WriteString("\n")
+		}
+
+		b.WriteString("\n")
+	}
+
+	if len(metadata.Returns) > 0 {
+		b.WriteString("**Returns:**\n")
+
+		for _, r := range metadata.Returns {
+			// Show the column name tests refer to: the alias, or else the expression.
+			column := r.Expression
+			if r.Alias != "" || column == "" {
+				column = r.Name
+			}
+
+			b.WriteString("- `" + column + "`")
+
+			if r.Type != "" {
+				b.WriteString(": `" + r.Type + "`")
+			}
+
+			if r.Alias != "" && r.Expression != r.Alias {
+				b.WriteString(" (`" + r.Expression + "`)")
+			}
+
+			b.WriteString("\n")
+		}
+
+		b.WriteString("\n")
+	}
+
+	if metadata.ReturnsOne {
+		b.WriteString("Returns at most one row\n\n")
+	}
+}
+
 // hoverQueryRef generates hover content for a query reference (in a scope).
-func (s *Server) hoverQueryRef(q *analysis.QuerySymbol) string {
+func (s *Server) hoverQueryRef(doc *Document, q *analysis.QuerySymbol) string {
 	var b strings.Builder
 
 	b.WriteString(fmt.Sprintf("**Query:** `%s`\n\n", q.Name))
@@ -124,16 +226,32 @@ func (s *Server) hoverQueryRef(q *analysis.QuerySymbol) string {
 			}
 
 			b.WriteString("`$" + p + "`")
+
+			if def, ok := q.Node.ParamDefaults()[p]; ok {
+				b.WriteString(" = `" + def.String() + "`")
+			}
 		}
 
 		b.WriteString("\n\n")
 	}
 
-	b.WriteString(s.markdownQueryBlock(q.Body))
+	b.WriteString(s.markdownQueryBlock(doc, q.Body))
 
 	return b.String()
 }
 
+// definitionLink returns a markdown paragraph linking to a query's definition,
+// e.g. "Defined in [fixtures.scaf:3](file:///.../fixtures.scaf#L3)", or "" if q is nil.
+func definitionLink(uri protocol.DocumentURI, q *scaf.Query) string {
+	if q == nil {
+		return ""
+	}
+
+	line := queryNameRange(q).Start.Line + 1
+
+	return fmt.Sprintf("\n\nDefined in [%s:%d](%s#L%d)", filepath.Base(URIToPath(uri)), line, uri, line)
+}
+
 // hoverImport generates hover content for an import.
 func (s *Server) hoverImport(imp *scaf.Import) string {
 	var b strings.Builder
@@ -173,6 +291,8 @@ func (s *Server) hoverTest(t *scaf.Test) string {
 		b.WriteString("- **Has Setup:** yes\n")
 	}
 
+	writeSkipHover(&b, &t.DirectiveMeta)
+
 	return b.String()
 }
 
@@ -195,17 +315,30 @@ func (s *Server) hoverGroup(g *scaf.Group) string {
 		b.WriteString("- **Has Teardown:** yes\n")
 	}
 
+	writeSkipHover(&b, &g.DirectiveMeta)
+
 	return b.String()
 }
 
+// writeSkipHover notes a skip directive, with its reason if given.
+func writeSkipHover(b *strings.Builder, meta *scaf.DirectiveMeta) {
+	if !meta.HasDirective(scaf.DirectiveSkip) {
+		return
+	}
+
+	if reason := meta.DirectiveArg(scaf.DirectiveSkip); reason != "" {
+		b.WriteString(fmt.Sprintf("- **Skipped:** %s\n", reason))
+	} else {
+		b.WriteString("- **Skipped:** yes\n")
+	}
+}
+
 // countItems counts tests and groups in an item list.
 func countItems(items []*scaf.TestOrGroup) (int, int) {
 	var tests, groups int
 
 	for _, item := range items {
-		if item.Test != nil {
-			tests++
-		}
+		tests += len(item.Tests())
 
 		if item.Group != nil {
 			groups++
@@ -268,6 +401,10 @@ func (s *Server) hoverParameter(key string, stmt *scaf.Statement, q *analysis.Qu
 
 		if found {
 			b.WriteString(fmt.Sprintf("Used in query `%s`\n", q.Name))
+
+			if def, ok := q.Node.ParamDefaults()[paramName]; ok {
+				b.WriteString(fmt.Sprintf("\n**Default:** `%s`\n", def.String()))
+			}
 		} else {
 			b.WriteString(fmt.Sprintf("⚠️ Parameter not found in query `%s`\n", q.Name))
 		}
@@ -315,11 +452,11 @@ func (s *Server) hoverReturnField(key string, stmt *scaf.Statement, q *analysis.
 				if ret.Name == key || ret.Expression == key || ret.Alias == key {
 					found = true
 
-					if ret.Alias != "" && ret.Expression != ret.Alias {
-						b.WriteString(fmt.Sprintf("**Expression:** `%s`\n", ret.Expression))
-					}
 					if ret.IsAggregate {
-						b.WriteString("**Type:** aggregate\n")
+						b.WriteString(fmt.Sprintf("**Aggregate:** `%s`\n", ret.Expression))
+						writeAggregateGrouping(&b, metadata.Returns)
+					} else if ret.Alias != "" && ret.Expression != ret.Alias {
+						b.WriteString(fmt.Sprintf("**Expression:** `%s`\n", ret.Expression))
 					}
 					break
 				}
@@ -334,6 +471,30 @@ func (s *Server) hoverReturnField(key string, stmt *scaf.Statement, q *analysis.
 	return b.String()
 }
 
+// writeAggregateGrouping notes the non-aggregated return fields an aggregate is grouped by.
+func writeAggregateGrouping(b *strings.Builder, returns []scaf.ReturnInfo) {
+	var keys []string
+
+	for _, ret := range returns {
+		if ret.IsAggregate {
+			continue
+		}
+
+		column := ret.Alias
+		if column == "" {
+			column = ret.Expression
+		}
+
+		keys = append(keys, "`"+column+"`")
+	}
+
+	if len(keys) == 0 {
+		b.WriteString("\nComputed over all matched rows.\n")
+	} else {
+		b.WriteString("\nComputed per group of " + strings.Join(keys, ", ") + ".\n")
+	}
+}
+
 // hoverSetupCall generates hover content for a setup call (module.Query()).
 func (s *Server) hoverSetupCall(doc *Document, f *analysis.AnalyzedFile, call *scaf.SetupCall, tokenCtx *analysis.TokenContext) string {
 	var b strings.Builder
@@ -347,7 +508,23 @@ func (s *Server) hoverSetupCall(doc *Document, f *analysis.AnalyzedFile, call *s
 	}
 
 	// Show info about the query being called
-	b.WriteString(fmt.Sprintf("**Setup Call:** `%s.%s`\n\n", call.Module, call.Query))
+	b.WriteString(fmt.Sprintf("**Setup Call:** `%s`\n\n", call.Ref()))
+
+	// Unqualified calls resolve against local queries and unaliased imports
+	if call.Module == "" {
+		_, defFile, _ := analysis.ResolveNamespaceQuery(f, call.Query)
+		if defFile == nil {
+			b.WriteString(fmt.Sprintf("⚠️ Query `%s` not found in this file or any unaliased import\n", call.Query))
+			return b.String()
+		}
+
+		uri := doc.URI
+		if defFile != f {
+			uri = PathToURI(defFile.Path)
+		}
+
+		return s.hoverSetupCallWithAnalysis(doc, call, uri, defFile, &b)
+	}
 
 	// Try to load the imported module and get query info
 	if s.fileLoader != nil {
@@ -360,7 +537,7 @@ func (s *Server) hoverSetupCall(doc *Document, f *analysis.AnalyzedFile, call *s
 			importedURI := PathToURI(importedPath)
 			if openDoc, ok := s.getDocument(importedURI); ok && openDoc.Analysis != nil {
 				// Use the open document's analysis
-				return s.hoverSetupCallWithAnalysis(call, openDoc.Analysis, &b)
+				return s.hoverSetupCallWithAnalysis(doc, call, importedURI, openDoc.Analysis, &b)
 			}
 
 			// Otherwise load from disk
@@ -376,7 +553,7 @@ func (s *Server) hoverSetupCall(doc *Document, f *analysis.AnalyzedFile, call *s
 				return b.String()
 			}
 
-			return s.hoverSetupCallWithAnalysis(call, importedFile, &b)
+			return s.hoverSetupCallWithAnalysis(doc, call, importedURI, importedFile, &b)
 		} else {
 			s.logger.Debug("Import not found for module",
 				zap.String("module", call.Module))
@@ -390,13 +567,21 @@ func (s *Server) hoverSetupCall(doc *Document, f *analysis.AnalyzedFile, call *s
 }
 
 // hoverSetupCallWithAnalysis generates hover content using a loaded/analyzed file.
-func (s *Server) hoverSetupCallWithAnalysis(call *scaf.SetupCall, importedFile *analysis.AnalyzedFile, b *strings.Builder) string {
+// importedURI locates the file for the definition link.
+func (s *Server) hoverSetupCallWithAnalysis(
+	doc *Document, call *scaf.SetupCall, importedURI protocol.DocumentURI, importedFile *analysis.AnalyzedFile, b *strings.Builder,
+) string {
 	if importedFile.Symbols == nil {
 		b.WriteString(fmt.Sprintf("⚠️ Module `%s` could not be analyzed\n", call.Module))
 		return b.String()
 	}
 
-	if q, ok := importedFile.Symbols.Queries[call.Query]; ok {
+	if q, def := s.resolveImportedQuery(importedFile, call.Query); q != nil {
+		if def != importedFile {
+			// Re-exported: link to the original definition.
+			importedURI = PathToURI(def.Path)
+		}
+
 		if len(q.Params) > 0 {
 			b.WriteString("**Parameters:** ")
 			for i, p := range q.Params {
@@ -407,7 +592,8 @@ func (s *Server) hoverSetupCallWithAnalysis(call *scaf.SetupCall, importedFile *
 			}
 			b.WriteString("\n\n")
 		}
-		b.WriteString(s.markdownQueryBlock(q.Body))
+		b.WriteString(s.markdownQueryBlock(doc, q.Body))
+		b.WriteString(definitionLink(importedURI, q.Node))
 		return b.String()
 	}
 
@@ -416,6 +602,7 @@ func (s *Server) hoverSetupCallWithAnalysis(call *scaf.SetupCall, importedFile *
 	for name := range importedFile.Symbols.Queries {
 		queryNames = append(queryNames, name)
 	}
+	sort.Strings(queryNames)
 	s.logger.Debug("Query not found in imported file",
 		zap.String("queryName", call.Query),
 		zap.Strings("availableQueries", queryNames))
@@ -445,7 +632,7 @@ func (s *Server) hoverSetupClause(doc *Document, f *analysis.AnalyzedFile, claus
 	if clause.Inline != nil {
 		var b strings.Builder
 		b.WriteString("**Inline Setup Query**\n\n")
-		b.WriteString(s.markdownQueryBlock(*clause.Inline))
+		b.WriteString(s.markdownQueryBlock(doc, *clause.Inline))
 		return b.String()
 	}
 
@@ -468,7 +655,7 @@ func (s *Server) hoverSetupItem(doc *Document, f *analysis.AnalyzedFile, item *s
 	if item.Inline != nil {
 		var b strings.Builder
 		b.WriteString("**Inline Setup Query**\n\n")
-		b.WriteString(s.markdownQueryBlock(*item.Inline))
+		b.WriteString(s.markdownQueryBlock(doc, *item.Inline))
 		return b.String()
 	}
 
@@ -497,7 +684,7 @@ func (s *Server) hoverModuleRef(f *analysis.AnalyzedFile, moduleName string) str
 }
 
 // hoverAssertQuery generates hover content for an assert query reference.
-func (s *Server) hoverAssertQuery(f *analysis.AnalyzedFile, aq *scaf.AssertQuery) string {
+func (s *Server) hoverAssertQuery(doc *Document, f *analysis.AnalyzedFile, aq *scaf.AssertQuery) string {
 	var b strings.Builder
 
 	// Check if it's a named query reference or inline
@@ -515,13 +702,14 @@ func (s *Server) hoverAssertQuery(f *analysis.AnalyzedFile, aq *scaf.AssertQuery
 				}
 				b.WriteString("\n\n")
 			}
-			b.WriteString(s.markdownQueryBlock(q.Body))
+			b.WriteString(s.markdownQueryBlock(doc, q.Body))
+			b.WriteString(definitionLink(doc.URI, q.Node))
 		} else {
 			b.WriteString("⚠️ Query not found\n")
 		}
 	} else if aq.Inline != nil {
 		b.WriteString("**Inline Assert Query**\n\n")
-		b.WriteString(s.markdownQueryBlock(*aq.Inline))
+		b.WriteString(s.markdownQueryBlock(doc, *aq.Inline))
 	}
 
 	return b.String()
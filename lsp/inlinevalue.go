@@ -0,0 +1,164 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+
+	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/runner"
+)
+
+// Inline values (LSP 3.17) are not part of go.lsp.dev/protocol v0.12.0, so the
+// request arrives through Server.Request and uses the minimal types below.
+
+// MethodTextDocumentInlineValue is the textDocument/inlineValue request method.
+const MethodTextDocumentInlineValue = "textDocument/inlineValue"
+
+// InlineValueParams are the parameters of a textDocument/inlineValue request.
+type InlineValueParams struct {
+	TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+	Range        protocol.Range                  `json:"range"`
+}
+
+// InlineValueText is an inline value shown as text at the end of Range.
+type InlineValueText struct {
+	Range protocol.Range `json:"range"`
+	Text  string         `json:"text"`
+}
+
+// RecordRun keeps the result of a test run of the document at uri, replacing
+// any earlier one. InlineValue reports from the most recent run.
+func (s *Server) RecordRun(uri protocol.DocumentURI, result *runner.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.runs == nil {
+		s.runs = make(map[protocol.DocumentURI]*runner.Result)
+	}
+
+	s.runs[uri] = result
+}
+
+// InlineValue handles textDocument/inlineValue requests.
+// Each statement of a test in the last recorded run gets the outcome of that
+// run: a check mark for expectations that held, and the actual versus expected
+// value for the one that failed. Run errors are shown on the test name.
+func (s *Server) InlineValue(_ context.Context, params *InlineValueParams) ([]InlineValueText, error) {
+	s.logger.Debug("InlineValue",
+		zap.String("uri", string(params.TextDocument.URI)))
+
+	s.mu.RLock()
+	result := s.runs[params.TextDocument.URI]
+	s.mu.RUnlock()
+
+	doc, ok := s.getDocument(params.TextDocument.URI)
+	if !ok || result == nil || doc.Analysis == nil || doc.Analysis.Suite == nil {
+		return nil, nil
+	}
+
+	values := []InlineValueText{}
+
+	for _, scope := range doc.Analysis.Suite.Scopes {
+		values = append(values, inlineItemValues(result, params.Range, []string{scope.QueryName}, scope.Items)...)
+	}
+
+	return values, nil
+}
+
+// inlineItemValues collects inline values for the tests under items.
+func inlineItemValues(
+	result *runner.Result, rng protocol.Range, parent []string, items []*scaf.TestOrGroup,
+) []InlineValueText {
+	var values []InlineValueText
+
+	for _, item := range items {
+		switch {
+		case item.Test != nil, item.Sequence != nil:
+			for _, test := range item.Tests() {
+				path := append(append([]string{}, parent...), test.Name)
+				if tr := result.Tests[strings.Join(path, "/")]; tr != nil {
+					values = append(values, inlineTestValues(tr, test, rng)...)
+				}
+			}
+		case item.Group != nil:
+			path := append(append([]string{}, parent...), item.Group.Name)
+			values = append(values, inlineItemValues(result, rng, path, item.Group.Items)...)
+		}
+	}
+
+	return values
+}
+
+// inlineTestValues returns the inline values for one test's result.
+func inlineTestValues(tr *runner.TestResult, test *scaf.Test, rng protocol.Range) []InlineValueText {
+	var values []InlineValueText
+
+	add := func(r protocol.Range, text string) {
+		if r.Start.Line >= rng.Start.Line && r.Start.Line <= rng.End.Line {
+			values = append(values, InlineValueText{Range: r, Text: text})
+		}
+	}
+
+	matched := false
+
+	for _, stmt := range test.Statements {
+		key := stmt.Key()
+		if strings.HasPrefix(key, "$") {
+			continue // Inputs, not expectations.
+		}
+
+		switch {
+		case tr.Status == runner.ActionFail && tr.Field == key:
+			matched = true
+			add(spanToRange(stmt.Span()), fmt.Sprintf("✗ %s: got %s, want %s",
+				key, inlineValueString(tr.Actual), inlineValueString(tr.Expected)))
+		case tr.Status == runner.ActionPass:
+			add(spanToRange(stmt.Span()), "✓ "+key)
+		}
+	}
+
+	switch {
+	case tr.Status == runner.ActionError && tr.Error != nil:
+		add(testNameRange(test), "error: "+tr.Error.Error())
+	case tr.Status == runner.ActionFail && !matched:
+		add(testNameRange(test), fmt.Sprintf("✗ %s: got %s, want %s",
+			tr.Field, inlineValueString(tr.Actual), inlineValueString(tr.Expected)))
+	}
+
+	return values
+}
+
+// inlineValueString renders a result value compactly, quoting strings.
+func inlineValueString(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
+
+// Request handles non-standard requests. Only textDocument/inlineValue, which
+// go.lsp.dev/protocol v0.12.0 doesn't dispatch itself, is supported.
+func (s *Server) Request(ctx context.Context, method string, params any) (any, error) {
+	if method != MethodTextDocumentInlineValue {
+		return nil, nil //nolint:nilnil // Unknown requests have no result
+	}
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var p InlineValueParams
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+
+	return s.InlineValue(ctx, &p)
+}
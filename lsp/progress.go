@@ -0,0 +1,100 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+
+	"github.com/rlch/scaf"
+)
+
+// workDone reports window/workDoneProgress for one long-running operation.
+// A nil *workDone is valid and reports nothing, so callers need not check
+// whether the client supports progress.
+type workDone struct {
+	client protocol.Client
+	token  protocol.ProgressToken
+}
+
+// beginProgress creates a progress token and sends the begin notification.
+// Returns nil if the client did not advertise window.workDoneProgress or refused the token.
+func (s *Server) beginProgress(ctx context.Context, title, message string) *workDone {
+	if !s.workDoneProgress {
+		return nil
+	}
+
+	s.progressSeq++
+	token := *protocol.NewProgressToken(fmt.Sprintf("scaf/%d", s.progressSeq))
+
+	err := s.client.WorkDoneProgressCreate(ctx, &protocol.WorkDoneProgressCreateParams{Token: token})
+	if err != nil {
+		s.logger.Debug("Client refused progress token", zap.Error(err))
+		return nil
+	}
+
+	p := &workDone{client: s.client, token: token}
+	p.notify(ctx, &protocol.WorkDoneProgressBegin{
+		Kind:    protocol.WorkDoneProgressKindBegin,
+		Title:   title,
+		Message: message,
+	})
+
+	return p
+}
+
+// report sends a progress update with a percentage in [0, 100].
+func (p *workDone) report(ctx context.Context, message string, percentage uint32) {
+	if p == nil {
+		return
+	}
+
+	p.notify(ctx, &protocol.WorkDoneProgressReport{
+		Kind:       protocol.WorkDoneProgressKindReport,
+		Message:    message,
+		Percentage: percentage,
+	})
+}
+
+// end sends the final progress notification.
+func (p *workDone) end(ctx context.Context, message string) {
+	if p == nil {
+		return
+	}
+
+	p.notify(ctx, &protocol.WorkDoneProgressEnd{
+		Kind:    protocol.WorkDoneProgressKindEnd,
+		Message: message,
+	})
+}
+
+func (p *workDone) notify(ctx context.Context, value any) {
+	_ = p.client.Progress(ctx, &protocol.ProgressParams{Token: p.token, Value: value})
+}
+
+// loadImports loads and analyzes a document's imports ahead of its own analysis,
+// reporting progress per import. Imports are otherwise loaded lazily during
+// analysis, which can stall the first diagnostics of a file with many imports.
+func (s *Server) loadImports(ctx context.Context, docPath, content string) {
+	suite, _ := scaf.Parse([]byte(content))
+	if suite == nil || len(suite.Imports) == 0 {
+		return
+	}
+
+	progress := s.beginProgress(ctx, "Loading imports", filepath.Base(docPath))
+
+	for i, imp := range suite.Imports {
+		resolved := s.fileLoader.ResolveImportPath(docPath, imp.Path)
+		if _, err := s.fileLoader.LoadAndAnalyze(resolved); err != nil {
+			s.logger.Debug("Failed to load import", zap.String("path", resolved), zap.Error(err))
+		}
+
+		//nolint:gosec // Percentages are bounded by 100.
+		progress.report(ctx, fmt.Sprintf("%d/%d: %s", i+1, len(suite.Imports), imp.Path),
+			uint32((i+1)*100/len(suite.Imports)))
+	}
+
+	progress.end(ctx, fmt.Sprintf("Loaded %d imports", len(suite.Imports)))
+}
@@ -137,8 +137,8 @@ func (s *Server) findQueryReferences(doc *Document, queryName string, includeDec
 // collectAssertQueryRefs recursively collects assert query references.
 func (s *Server) collectAssertQueryRefs(uri protocol.DocumentURI, items []*scaf.TestOrGroup, queryName string, locations *[]protocol.Location) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, assert := range item.Test.Asserts {
+		for _, test := range item.Tests() {
+			for _, assert := range test.Asserts {
 				if assert.Query != nil && assert.Query.QueryName != nil && *assert.Query.QueryName == queryName {
 					*locations = append(*locations, protocol.Location{
 						URI:   uri,
@@ -224,8 +224,8 @@ func (s *Server) collectSetupImportRefs(uri protocol.DocumentURI, setup *scaf.Se
 // collectItemSetupImportRefs recursively collects import references in test/group items.
 func (s *Server) collectItemSetupImportRefs(uri protocol.DocumentURI, items []*scaf.TestOrGroup, alias string, locations *[]protocol.Location) {
 	for _, item := range items {
-		if item.Test != nil {
-			s.collectSetupImportRefs(uri, item.Test.Setup, alias, locations)
+		for _, test := range item.Tests() {
+			s.collectSetupImportRefs(uri, test.Setup, alias, locations)
 		}
 		if item.Group != nil {
 			s.collectSetupImportRefs(uri, item.Group.Setup, alias, locations)
@@ -319,8 +319,8 @@ func (s *Server) collectSetupCallQueryRefs(uri protocol.DocumentURI, suite *scaf
 	var findInItems func([]*scaf.TestOrGroup)
 	findInItems = func(items []*scaf.TestOrGroup) {
 		for _, item := range items {
-			if item.Test != nil {
-				findInSetup(item.Test.Setup)
+			for _, test := range item.Tests() {
+				findInSetup(test.Setup)
 			}
 			if item.Group != nil {
 				findInSetup(item.Group.Setup)
@@ -439,8 +439,8 @@ func (s *Server) findParameterReferences(doc *Document, queryScope, paramKey str
 // collectParamRefs recursively collects parameter references in test items.
 func (s *Server) collectParamRefs(uri protocol.DocumentURI, items []*scaf.TestOrGroup, paramKey string, locations *[]protocol.Location) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, stmt := range item.Test.Statements {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
 				if stmt.Key() == paramKey {
 					*locations = append(*locations, protocol.Location{
 						URI:   uri,
@@ -513,8 +513,8 @@ func (s *Server) findReturnFieldReferences(doc *Document, queryScope, fieldKey s
 // collectFieldRefs recursively collects return field references in test items.
 func (s *Server) collectFieldRefs(uri protocol.DocumentURI, items []*scaf.TestOrGroup, fieldKey string, locations *[]protocol.Location) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, stmt := range item.Test.Statements {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
 				key := stmt.Key()
 				if len(key) > 0 && key[0] != '$' && key == fieldKey {
 					*locations = append(*locations, protocol.Location{
@@ -118,12 +118,15 @@ func (s *Server) getRenameContext(doc *Document, tokenCtx *analysis.TokenContext
 
 	case *scaf.SetupCall:
 		if tokenCtx.Token != nil {
-			if tokenCtx.Token.Value == node.Module {
+			switch tokenCtx.Token.Value {
+			case node.Module:
 				ctx.Kind = RenameKindImport
 				ctx.OldName = node.Module
+			case node.Query:
+				ctx.Kind = RenameKindQuery
+				ctx.OldName = node.Query
+				ctx.ModuleAlias = node.Module
 			}
-			// Note: Renaming the query name in a setup call would require
-			// cross-file rename, which we don't support yet
 		}
 
 	case *scaf.SetupClause:
@@ -183,6 +186,11 @@ func (s *Server) getRenameRange(doc *Document, tokenCtx *analysis.TokenContext,
 			return &rng
 		}
 
+		if ctx.Kind == RenameKindQuery {
+			rng := setupCallQueryRange(node)
+			return &rng
+		}
+
 	case *scaf.SetupClause:
 		if node.Module != nil {
 			rng := setupModuleRange(node)
@@ -215,6 +223,11 @@ func (s *Server) validateNewName(newName string, ctx RenameContext) error {
 		return fmt.Errorf("new name cannot be empty")
 	}
 
+	// Query names and import aliases must lex as a plain identifier
+	if (ctx.Kind == RenameKindQuery || ctx.Kind == RenameKindImport) && !scaf.IsIdentifier(newName) {
+		return fmt.Errorf("%q is not a valid identifier", newName)
+	}
+
 	// Check for valid identifier characters
 	for i, r := range newName {
 		if i == 0 {
@@ -259,8 +272,13 @@ func isDigit(r rune) bool {
 func (s *Server) checkRenameConflicts(doc *Document, newName string, ctx RenameContext) error {
 	switch ctx.Kind {
 	case RenameKindQuery:
-		// Check if query name already exists
-		if _, exists := doc.Analysis.Symbols.Queries[newName]; exists {
+		// Check if query name already exists in the file defining it
+		_, file := s.queryDefinitionFile(doc, ctx.ModuleAlias)
+		if file == nil || file.Symbols == nil {
+			return fmt.Errorf("cannot find the file defining query %q", ctx.OldName)
+		}
+
+		if _, exists := file.Symbols.Queries[newName]; exists {
 			return fmt.Errorf("query %q already exists", newName)
 		}
 
@@ -287,7 +305,7 @@ func (s *Server) generateRenameEdits(doc *Document, newName string, ctx RenameCo
 
 	switch ctx.Kind {
 	case RenameKindQuery:
-		s.generateQueryRenameEdits(doc, ctx.OldName, newName, edits)
+		s.generateQueryRenameEdits(doc, ctx.ModuleAlias, ctx.OldName, newName, edits)
 
 	case RenameKindImport:
 		s.generateImportRenameEdits(doc, ctx.OldName, newName, edits)
@@ -302,16 +320,21 @@ func (s *Server) generateRenameEdits(doc *Document, newName string, ctx RenameCo
 	return edits
 }
 
-// generateQueryRenameEdits generates edits to rename a query.
-func (s *Server) generateQueryRenameEdits(doc *Document, oldName, newName string, edits map[protocol.DocumentURI][]protocol.TextEdit) {
-	if doc.Analysis.Suite == nil {
+// generateQueryRenameEdits generates edits to rename a query, in the file
+// defining it (the current one, or the one imported as moduleAlias) and in
+// the setup calls of every file importing that one.
+func (s *Server) generateQueryRenameEdits(
+	doc *Document, moduleAlias, oldName, newName string, edits map[protocol.DocumentURI][]protocol.TextEdit,
+) {
+	uri, file := s.queryDefinitionFile(doc, moduleAlias)
+	if file == nil || file.Suite == nil {
 		return
 	}
 
 	var docEdits []protocol.TextEdit
 
 	// Rename the query definition
-	for _, q := range doc.Analysis.Suite.Queries {
+	for _, q := range file.Suite.Queries {
 		if q.Name == oldName {
 			docEdits = append(docEdits, protocol.TextEdit{
 				Range:   queryNameRange(q),
@@ -322,7 +345,7 @@ func (s *Server) generateQueryRenameEdits(doc *Document, oldName, newName string
 	}
 
 	// Rename all query scope references
-	for _, scope := range doc.Analysis.Suite.Scopes {
+	for _, scope := range file.Suite.Scopes {
 		if scope.QueryName == oldName {
 			docEdits = append(docEdits, protocol.TextEdit{
 				Range:   scopeNameRange(scope),
@@ -335,15 +358,79 @@ func (s *Server) generateQueryRenameEdits(doc *Document, oldName, newName string
 	}
 
 	if len(docEdits) > 0 {
-		edits[doc.URI] = docEdits
+		edits[uri] = docEdits
+	}
+
+	// Rename setup calls reaching the query through an import
+	if s.fileLoader == nil {
+		return
+	}
+
+	var callers []protocol.Location
+
+	s.collectImportedQueryRefs(URIToPath(uri), oldName, &callers)
+
+	for _, loc := range callers {
+		edits[loc.URI] = append(edits[loc.URI], protocol.TextEdit{Range: loc.Range, NewText: newName})
+	}
+}
+
+// queryDefinitionFile returns the file a query reference resolves to: the
+// current document, or the file imported as moduleAlias.
+func (s *Server) queryDefinitionFile(doc *Document, moduleAlias string) (protocol.DocumentURI, *analysis.AnalyzedFile) {
+	if moduleAlias == "" {
+		return doc.URI, doc.Analysis
+	}
+
+	imp, ok := doc.Analysis.Symbols.Imports[moduleAlias]
+	if !ok || s.fileLoader == nil {
+		return "", nil
+	}
+
+	path := s.fileLoader.ResolveImportPath(URIToPath(doc.URI), imp.Path)
+	uri := PathToURI(path)
+
+	// Prefer the open document, which may have unsaved edits
+	if open, ok := s.getDocument(uri); ok && open.Analysis != nil {
+		return uri, open.Analysis
+	}
+
+	file, err := s.fileLoader.LoadAndAnalyze(path)
+	if err != nil {
+		s.logger.Debug("Failed to load file defining renamed query", zap.String("path", path), zap.Error(err))
+		return "", nil
+	}
+
+	return uri, file
+}
+
+// collectImportedQueryRefs collects the setup calls to queryName, defined in
+// the file at path, from open documents and workspace files importing it.
+func (s *Server) collectImportedQueryRefs(path, queryName string, locations *[]protocol.Location) {
+	s.mu.RLock()
+	for uri, doc := range s.documents {
+		if doc.Analysis == nil || doc.Analysis.Suite == nil || doc.Analysis.Symbols == nil {
+			continue
+		}
+
+		for alias, imp := range doc.Analysis.Symbols.Imports {
+			if s.fileLoader.ResolveImportPath(URIToPath(uri), imp.Path) == path {
+				s.collectSetupCallQueryRefs(uri, doc.Analysis.Suite, alias, queryName, locations)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	if s.workspaceRoot != "" {
+		s.searchWorkspaceForQueryRefs(path, queryName, "", locations)
 	}
 }
 
 // collectAssertQueryEdits recursively collects edits for assert query references.
 func (s *Server) collectAssertQueryEdits(items []*scaf.TestOrGroup, oldName, newName string, edits *[]protocol.TextEdit) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, assert := range item.Test.Asserts {
+		for _, test := range item.Tests() {
+			for _, assert := range test.Asserts {
 				if assert.Query != nil && assert.Query.QueryName != nil && *assert.Query.QueryName == oldName {
 					*edits = append(*edits, protocol.TextEdit{
 						Range:   assertQueryNameRange(assert.Query),
@@ -446,8 +533,8 @@ func (s *Server) collectSetupImportEdits(setup *scaf.SetupClause, oldAlias, newA
 // collectItemSetupImportEdits recursively collects import rename edits.
 func (s *Server) collectItemSetupImportEdits(items []*scaf.TestOrGroup, oldAlias, newAlias string, edits *[]protocol.TextEdit) {
 	for _, item := range items {
-		if item.Test != nil {
-			s.collectSetupImportEdits(item.Test.Setup, oldAlias, newAlias, edits)
+		for _, test := range item.Tests() {
+			s.collectSetupImportEdits(test.Setup, oldAlias, newAlias, edits)
 		}
 		if item.Group != nil {
 			s.collectSetupImportEdits(item.Group.Setup, oldAlias, newAlias, edits)
@@ -480,8 +567,8 @@ func (s *Server) generateParameterRenameEdits(doc *Document, queryScope, oldName
 // collectParamEdits recursively collects parameter rename edits.
 func (s *Server) collectParamEdits(items []*scaf.TestOrGroup, oldName, newName string, edits *[]protocol.TextEdit) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, stmt := range item.Test.Statements {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
 				if stmt.Key() == oldName {
 					*edits = append(*edits, protocol.TextEdit{
 						Range:   statementKeyRange(stmt),
@@ -520,8 +607,8 @@ func (s *Server) generateReturnFieldRenameEdits(doc *Document, queryScope, oldNa
 // collectFieldEdits recursively collects return field rename edits.
 func (s *Server) collectFieldEdits(items []*scaf.TestOrGroup, oldName, newName string, edits *[]protocol.TextEdit) {
 	for _, item := range items {
-		if item.Test != nil {
-			for _, stmt := range item.Test.Statements {
+		for _, test := range item.Tests() {
+			for _, stmt := range test.Statements {
 				key := stmt.Key()
 				if len(key) > 0 && key[0] != '$' && key == oldName {
 					*edits = append(*edits, protocol.TextEdit{
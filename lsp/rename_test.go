@@ -2,6 +2,9 @@ package lsp_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"go.lsp.dev/protocol"
@@ -232,16 +235,127 @@ func TestServer_Rename_InvalidName(t *testing.T) {
 		},
 	})
 
-	// Try to rename to invalid name
-	_, err := server.Rename(ctx, &protocol.RenameParams{
+	// Try to rename to names that don't lex as an identifier
+	for _, name := range []string{"123Invalid", "Get.User", "$GetUser", "test"} {
+		_, err := server.Rename(ctx, &protocol.RenameParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     protocol.Position{Line: 0, Character: 8},
+			},
+			NewName: name,
+		})
+
+		if err == nil {
+			t.Errorf("Expected error for invalid name %q", name)
+		}
+	}
+}
+
+func TestServer_Rename_Query_CrossFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"fixtures.scaf": "query CreateUser `CREATE (u:User {name: $name})`\n\n" +
+			"CreateUser {\n\ttest \"creates\" {\n\t\t$name: \"Alice\"\n\t}\n}\n",
+		"main.scaf": "import fixtures \"./fixtures\"\n\n" +
+			"query GetUser `MATCH (u:User) RETURN u`\n\n" +
+			"GetUser {\n\tsetup fixtures.CreateUser($name: \"Alice\")\n\ttest \"finds user\" {}\n}\n",
+		"other.scaf": "import f \"./fixtures\"\n\n" +
+			"setup f.CreateUser($name: \"Bob\")\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	uri := func(name string) protocol.DocumentURI {
+		return protocol.DocumentURI("file://" + filepath.Join(tmpDir, name))
+	}
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri("main.scaf"), Version: 1, Text: files["main.scaf"]},
+	})
+
+	// Rename from the setup call's query name in main.scaf
+	pos := protocol.Position{Line: 5, Character: 18}
+
+	rng, err := server.PrepareRename(ctx, &protocol.PrepareRenameParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
-			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
-			Position:     protocol.Position{Line: 0, Character: 8},
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri("main.scaf")},
+			Position:     pos,
 		},
-		NewName: "123Invalid", // Starts with number
 	})
+	if err != nil || rng == nil {
+		t.Fatalf("PrepareRename() = %v, %v; want the query name's range", rng, err)
+	}
 
-	if err == nil {
-		t.Error("Expected error for invalid name")
+	if want := (protocol.Range{
+		Start: protocol.Position{Line: 5, Character: 16},
+		End:   protocol.Position{Line: 5, Character: 26},
+	}); *rng != want {
+		t.Errorf("PrepareRename() = %v, want %v", *rng, want)
+	}
+
+	rename := func(name string, pos protocol.Position) {
+		t.Helper()
+
+		result, err := server.Rename(ctx, &protocol.RenameParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri(name)},
+				Position:     pos,
+			},
+			NewName: "InsertUser",
+		})
+		if err != nil {
+			t.Fatalf("Rename() from %s error: %v", name, err)
+		}
+
+		if result == nil {
+			t.Fatalf("Rename() from %s: expected workspace edit", name)
+		}
+
+		if len(result.Changes) != len(files) {
+			t.Errorf("Rename() from %s changed %d files, want %d: %v", name, len(result.Changes), len(files), result.Changes)
+		}
+
+		for file, content := range files {
+			got := applyEdits(content, result.Changes[uri(file)])
+			if want := strings.ReplaceAll(content, "CreateUser", "InsertUser"); got != want {
+				t.Errorf("Rename() from %s: %s after rename:\n%s\nwant:\n%s", name, file, got, want)
+			}
+		}
+	}
+
+	rename("main.scaf", pos)
+
+	// Renaming from the definition reaches the files importing it
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri("fixtures.scaf"), Version: 1, Text: files["fixtures.scaf"]},
+	})
+
+	rename("fixtures.scaf", protocol.Position{Line: 0, Character: 8})
+
+	// The new name is checked against the queries of the file defining it
+	_, err = server.Rename(ctx, &protocol.RenameParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri("main.scaf")},
+			Position:     pos,
+		},
+		NewName: "GetUser",
+	})
+	if err != nil {
+		t.Errorf("Rename() to a name only main.scaf defines: %v", err)
 	}
 }
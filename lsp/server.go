@@ -10,6 +10,7 @@ import (
 
 	"github.com/rlch/scaf"
 	"github.com/rlch/scaf/analysis"
+	"github.com/rlch/scaf/runner"
 )
 
 // Server implements the LSP Server interface for scaf.
@@ -31,10 +32,23 @@ type Server struct {
 	dialectName   string              // e.g., "cypher", "sql"
 	queryAnalyzer scaf.QueryAnalyzer  // dialect-specific query analyzer
 
+	// Type schema from the workspace config, if any (used for value completions)
+	schema *analysis.TypeSchema
+
+	// Most recent test run per document, shown as inline values
+	runs map[protocol.DocumentURI]*runner.Result
+
+	// Whether "#RRGGBB" string values are reported as colors
+	colors bool
+
 	// Server state
 	initialized   bool
 	shutdown      bool
 	workspaceRoot string
+
+	// Work-done progress (only reported if the client advertises support)
+	workDoneProgress bool
+	progressSeq      int
 }
 
 // Document represents an open document in the server.
@@ -44,6 +58,10 @@ type Document struct {
 	Content  string
 	Analysis *analysis.AnalyzedFile
 
+	// Dialect is the query dialect for this document: the one implied by the
+	// nearest .scaf.yaml, or the server's default.
+	Dialect string
+
 	// LastValidAnalysis holds the most recent analysis that parsed successfully.
 	// Used for completion when the current document has parse errors.
 	LastValidAnalysis *analysis.AnalyzedFile
@@ -67,13 +85,19 @@ func NewServer(client protocol.Client, logger *zap.Logger, dialectName string) *
 		logger.Warn("No query analyzer registered for dialect",
 			zap.String("dialect", dialectName),
 			zap.Strings("available", scaf.RegisteredAnalyzers()))
+	} else {
+		// Completion and hover re-analyze unchanged query bodies on every request.
+		queryAnalyzer = scaf.NewCachedAnalyzer(queryAnalyzer, scaf.DefaultAnalyzerCacheSize)
 	}
 
+	analyzer := analysis.NewAnalyzerWithResolver(fileLoader, resolver)
+	analyzer.SetQueryAnalyzer(queryAnalyzer)
+
 	return &Server{
 		client:        client,
 		logger:        logger,
 		documents:     make(map[protocol.DocumentURI]*Document),
-		analyzer:      analysis.NewAnalyzerWithResolver(fileLoader, resolver),
+		analyzer:      analyzer,
 		fileLoader:    fileLoader,
 		dialectName:   dialectName,
 		queryAnalyzer: queryAnalyzer,
@@ -95,12 +119,26 @@ func (s *Server) Initialize(_ context.Context, params *protocol.InitializeParams
 		s.logger.Info("Workspace root (from RootPath)", zap.String("root", s.workspaceRoot))
 	}
 
+	if s.workspaceRoot != "" {
+		s.loadWorkspaceConfig(s.workspaceRoot)
+	}
+
+	if window := params.Capabilities.Window; window != nil {
+		s.workDoneProgress = window.WorkDoneProgress
+	}
+
+	// Editor-provided options take precedence over .scaf.yaml
+	if params.InitializationOptions != nil {
+		s.applySettings(params.InitializationOptions)
+	}
+
 	return &protocol.InitializeResult{
 		Capabilities: protocol.ServerCapabilities{
 			// Full document sync - client sends entire content on change
 			TextDocumentSync: &protocol.TextDocumentSyncOptions{
 				OpenClose: true,
 				Change:    protocol.TextDocumentSyncKindFull,
+				Save:      &protocol.SaveOptions{IncludeText: true},
 			},
 			// Hover support
 			HoverProvider: true,
@@ -142,10 +180,21 @@ func (s *Server) Initialize(_ context.Context, params *protocol.InitializeParams
 			},
 			// Document formatting
 			DocumentFormattingProvider: true,
+			// Range formatting lays out the query body under the cursor
+			DocumentRangeFormattingProvider: true,
 			// Code lens for running tests
 			CodeLensProvider: &protocol.CodeLensOptions{
 				ResolveProvider: false,
 			},
+			// Call hierarchy across setup/assert query references
+			CallHierarchyProvider: true,
+			// "#RRGGBB" string values (only reported when the colors setting is on)
+			ColorProvider: true,
+			// Code lens commands run tests (results feed textDocument/inlineValue);
+			// scaf.listPaths lists runnable paths for filter pickers
+			ExecuteCommandProvider: &protocol.ExecuteCommandOptions{
+				Commands: []string{CommandRunScope, CommandRunGroup, CommandRunTest, CommandListPaths},
+			},
 			// Note: InlayHintProvider requires LSP 3.17+ protocol types
 			// not available in go.lsp.dev/protocol v0.12.0
 		},
@@ -195,6 +244,8 @@ func (s *Server) DidOpen(ctx context.Context, params *protocol.DidOpenTextDocume
 	// Analyze the document
 	// Use the file system path (not URI) for proper import resolution
 	docPath := URIToPath(params.TextDocument.URI)
+	doc.Dialect = s.configDialect(docPath)
+	s.loadImports(ctx, docPath, params.TextDocument.Text)
 	doc.Analysis = s.analyzer.Analyze(docPath, []byte(params.TextDocument.Text))
 
 	// If parsing succeeded, save as last valid analysis for completion fallback
@@ -231,9 +282,10 @@ func (s *Server) DidChange(ctx context.Context, params *protocol.DidChangeTextDo
 		doc.Content = params.ContentChanges[len(params.ContentChanges)-1].Text
 		doc.Version = params.TextDocument.Version
 
-		// Re-analyze (use file system path for proper import resolution)
+		// Re-analyze (use file system path for proper import resolution).
+		// Edits within one scope only reparse that scope.
 		docPath := URIToPath(params.TextDocument.URI)
-		doc.Analysis = s.analyzer.Analyze(docPath, []byte(doc.Content))
+		doc.Analysis = s.analyzer.Reanalyze(doc.Analysis, docPath, []byte(doc.Content))
 
 		// If parsing succeeded, save as last valid analysis for completion fallback
 		if doc.Analysis.ParseError == nil {
@@ -269,12 +321,38 @@ func (s *Server) DidClose(ctx context.Context, params *protocol.DidCloseTextDocu
 }
 
 // DidSave handles textDocument/didSave notifications.
-func (s *Server) DidSave(_ context.Context, params *protocol.DidSaveTextDocumentParams) error {
+// The saved file is dropped from the cross-file cache and every open document
+// is re-analyzed, so importers see the saved version.
+func (s *Server) DidSave(ctx context.Context, params *protocol.DidSaveTextDocumentParams) error {
 	s.logger.Info("DidSave", zap.String("uri", string(params.TextDocument.URI)))
-	// Could trigger additional validation here if needed
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fileLoader.InvalidatePath(URIToPath(params.TextDocument.URI))
+
+	if doc, ok := s.documents[params.TextDocument.URI]; ok && params.Text != "" {
+		doc.Content = params.Text
+	}
+
+	for _, doc := range s.documents {
+		s.reanalyze(ctx, doc)
+	}
+
 	return nil
 }
 
+// reanalyze re-runs analysis on doc and publishes its diagnostics.
+// Callers must hold s.mu.
+func (s *Server) reanalyze(ctx context.Context, doc *Document) {
+	doc.Analysis = s.analyzer.Analyze(URIToPath(doc.URI), []byte(doc.Content))
+	if doc.Analysis.ParseError == nil {
+		doc.LastValidAnalysis = doc.Analysis
+	}
+
+	s.publishDiagnostics(ctx, doc)
+}
+
 // getDocument returns a document by URI (read-locked).
 func (s *Server) getDocument(uri protocol.DocumentURI) (*Document, bool) {
 	s.mu.RLock()
@@ -2,12 +2,22 @@ package lsp_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
 
+	"github.com/rlch/scaf"
 	"github.com/rlch/scaf/lsp"
+	"github.com/rlch/scaf/runner"
 
 	// Import dialects to register their analyzers via init().
 	_ "github.com/rlch/scaf/dialects/cypher"
@@ -15,7 +25,13 @@ import (
 
 // mockClient implements protocol.Client for testing.
 type mockClient struct {
-	diagnostics []protocol.PublishDiagnosticsParams
+	diagnostics    []protocol.PublishDiagnosticsParams
+	progressTokens []protocol.ProgressToken
+	progress       []protocol.ProgressParams
+
+	// messages receives window/showMessage and window/showMessageRequest
+	// params, which may be sent from another goroutine.
+	messages chan protocol.ShowMessageRequestParams
 }
 
 func (m *mockClient) PublishDiagnostics(_ context.Context, params *protocol.PublishDiagnosticsParams) error {
@@ -24,17 +40,33 @@ func (m *mockClient) PublishDiagnostics(_ context.Context, params *protocol.Publ
 	return nil
 }
 
-// Stub out remaining Client interface methods.
-func (m *mockClient) Progress(context.Context, *protocol.ProgressParams) error { return nil }
-func (m *mockClient) WorkDoneProgressCreate(context.Context, *protocol.WorkDoneProgressCreateParams) error {
+func (m *mockClient) Progress(_ context.Context, params *protocol.ProgressParams) error {
+	m.progress = append(m.progress, *params)
+
+	return nil
+}
+
+func (m *mockClient) WorkDoneProgressCreate(_ context.Context, params *protocol.WorkDoneProgressCreateParams) error {
+	m.progressTokens = append(m.progressTokens, params.Token)
+
+	return nil
+}
+
+func (m *mockClient) ShowMessage(_ context.Context, params *protocol.ShowMessageParams) error {
+	m.messages <- protocol.ShowMessageRequestParams{Type: params.Type, Message: params.Message}
+
 	return nil
 }
-func (m *mockClient) ShowMessage(context.Context, *protocol.ShowMessageParams) error { return nil }
+
 func (m *mockClient) ShowMessageRequest(
-	context.Context, *protocol.ShowMessageRequestParams,
+	_ context.Context, params *protocol.ShowMessageRequestParams,
 ) (*protocol.MessageActionItem, error) {
-	return nil, nil //nolint:nilnil // Mock stub returns nil for tests
+	m.messages <- *params
+
+	return nil, nil //nolint:nilnil // The user dismissed the message
 }
+
+// Stub out remaining Client interface methods.
 func (m *mockClient) LogMessage(context.Context, *protocol.LogMessageParams) error { return nil }
 func (m *mockClient) Telemetry(context.Context, any) error                         { return nil }
 func (m *mockClient) RegisterCapability(context.Context, *protocol.RegistrationParams) error {
@@ -57,7 +89,7 @@ func newTestServer(t *testing.T) (*lsp.Server, *mockClient) {
 	t.Helper()
 
 	logger := zap.NewNop()
-	client := &mockClient{}
+	client := &mockClient{messages: make(chan protocol.ShowMessageRequestParams, 16)}
 	server := lsp.NewServer(client, logger, "cypher")
 
 	return server, client
@@ -67,7 +99,7 @@ func newTestServerWithDebug(t *testing.T) (*lsp.Server, *mockClient) {
 	t.Helper()
 
 	logger, _ := zap.NewDevelopment()
-	client := &mockClient{}
+	client := &mockClient{messages: make(chan protocol.ShowMessageRequestParams, 16)}
 	server := lsp.NewServer(client, logger, "cypher")
 
 	return server, client
@@ -223,6 +255,70 @@ UndefinedQuery {
 	}
 }
 
+func TestServer_DiagnosticSeverityOverrides(t *testing.T) {
+	t.Parallel()
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	// Initialization options downgrade undefined-query to a warning.
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		InitializationOptions: map[string]any{
+			"severity": map[string]any{"undefined-query": "warning"},
+		},
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     "file:///test.scaf",
+			Version: 1,
+			Text: `query GetUser ` + "`Q`" + `
+
+UndefinedQuery {
+	test "t" {}
+}
+`,
+		},
+	})
+
+	severityOf := func(params protocol.PublishDiagnosticsParams) (protocol.DiagnosticSeverity, bool) {
+		for _, d := range params.Diagnostics {
+			if d.Code == "undefined-query" {
+				return d.Severity, true
+			}
+		}
+
+		return 0, false
+	}
+
+	if len(client.diagnostics) != 1 {
+		t.Fatalf("Expected 1 diagnostics publish, got %d", len(client.diagnostics))
+	}
+
+	if sev, ok := severityOf(client.diagnostics[0]); !ok || sev != protocol.DiagnosticSeverityWarning {
+		t.Errorf("undefined-query severity = %v (found=%v), want warning", sev, ok)
+	}
+
+	// Changing configuration to "off" re-publishes without the diagnostic.
+	err := server.DidChangeConfiguration(ctx, &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{
+			"scaf": map[string]any{"severity": map[string]any{"undefined-query": "off"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DidChangeConfiguration() error: %v", err)
+	}
+
+	if len(client.diagnostics) != 2 {
+		t.Fatalf("Expected diagnostics to be re-published, got %d publishes", len(client.diagnostics))
+	}
+
+	if _, ok := severityOf(client.diagnostics[1]); ok {
+		t.Error("Expected undefined-query to be suppressed")
+	}
+}
+
 func TestServer_DidChange(t *testing.T) {
 	t.Parallel()
 
@@ -364,6 +460,113 @@ GetUser {
 	}
 }
 
+func TestServer_Hover_QueryDialectFence(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	// A postgres config makes documents below it SQL.
+	err := writeFile(filepath.Join(tmpDir, ".scaf.yaml"), "postgres:\n  host: localhost\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{RootURI: protocol.DocumentURI("file://" + tmpDir)})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	uri := protocol.DocumentURI("file://" + filepath.Join(tmpDir, "users.scaf"))
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     uri,
+			Version: 1,
+			Text: `query GetUser ` + "`SELECT name FROM users WHERE id = $id`" + `
+
+GetUser {
+	test "finds user" {
+		$id: 1
+	}
+}
+`,
+		},
+	})
+
+	// Hover over the scope header, which previews the query body.
+	result, err := server.Hover(ctx, &protocol.HoverParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     protocol.Position{Line: 2, Character: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Hover() error: %v", err)
+	}
+
+	if result == nil {
+		t.Fatal("Expected hover result")
+	}
+
+	if !strings.Contains(result.Contents.Value, "```sql\nSELECT name FROM users") {
+		t.Errorf("Expected a sql code fence, got:\n%s", result.Contents.Value)
+	}
+}
+
+func TestServer_Hover_QueryKeywordSummary(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     "file:///test.scaf",
+			Version: 1,
+			Text: `query GetUser ` + "`MATCH (u:User {id: $id}) WHERE u.age > $minAge RETURN u.name AS name, u.age`" + `
+query CreateUser ` + "`CREATE (u:User {name: $name}) RETURN u`" + `
+`,
+		},
+	})
+
+	hover := func(line, char uint32) string {
+		t.Helper()
+
+		result, err := server.Hover(ctx, &protocol.HoverParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+				Position:     protocol.Position{Line: line, Character: char},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Hover() error: %v", err)
+		}
+
+		if result == nil {
+			t.Fatal("Expected hover result")
+		}
+
+		return result.Contents.Value
+	}
+
+	// Hover over the "query" keyword.
+	content := hover(0, 2)
+	for _, want := range []string{"**Dialect:** cypher · read-only", "**Parameters:**", "`$id`", "`$minAge`", "**Returns:**", "`name`", "`u.age`"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("hover on query keyword missing %q:\n%s", want, content)
+		}
+	}
+
+	// Hover over the body of a writing query.
+	content = hover(1, 20)
+	if !strings.Contains(content, "· writes") || !strings.Contains(content, "`$name`") {
+		t.Errorf("hover on query body missing write classification or params:\n%s", content)
+	}
+}
+
 func TestServer_Hover_NoContent(t *testing.T) {
 	t.Parallel()
 
@@ -518,6 +721,98 @@ GetUser {
 	t.Logf("Return field hover content:\n%s", content)
 }
 
+func TestServer_Hover_SuiteMeta(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     "file:///test.scaf",
+			Version: 1,
+			Text: `// User queries
+meta {owner: "team-x", jira: "ABC-123"}
+
+query GetUser ` + "`MATCH (u:User) RETURN u`" + `
+`,
+		},
+	})
+
+	for _, line := range []uint32{0, 1} {
+		result, err := server.Hover(ctx, &protocol.HoverParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+				Position:     protocol.Position{Line: line, Character: 2},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Hover() error: %v", err)
+		}
+
+		if result == nil {
+			t.Fatalf("Expected hover result on line %d", line)
+		}
+
+		content := result.Contents.Value
+		for _, want := range []string{"Suite metadata", "**owner:** \"team-x\"", "**jira:** \"ABC-123\""} {
+			if !contains(content, want) {
+				t.Errorf("Expected %q in hover on line %d, got: %s", want, line, content)
+			}
+		}
+	}
+}
+
+func TestServer_Hover_AggregateReturnField(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     "file:///test.scaf",
+			Version: 1,
+			Text: `query CountPosts ` + "`MATCH (u:User)-[:WROTE]->(p:Post) RETURN u.name, count(p) AS total`" + `
+
+CountPosts {
+	test "counts posts" {
+		total: 3
+	}
+}
+`,
+		},
+	})
+
+	// Hover over the total return field (line 4, column 3)
+	result, err := server.Hover(ctx, &protocol.HoverParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+			Position:     protocol.Position{Line: 4, Character: 3},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Hover() error: %v", err)
+	}
+
+	if result == nil {
+		t.Fatal("Expected hover result for aggregate return field")
+	}
+
+	content := result.Contents.Value
+	for _, want := range []string{"**Aggregate:** `count(p)`", "per group of `u.name`"} {
+		if !contains(content, want) {
+			t.Errorf("Expected %q in hover, got: %s", want, content)
+		}
+	}
+}
+
 func TestServer_Hover_AssertQuery(t *testing.T) {
 	t.Parallel()
 
@@ -605,15 +900,23 @@ func TestServer_DocumentSymbol(t *testing.T) {
 			Text: `import fixtures "./fixtures"
 
 query GetUser ` + "`MATCH (u:User {id: $userId}) RETURN u.name`" + `
-query CountPosts ` + "`MATCH (p:Post) RETURN count(p)`" + `
+query CountPosts ` + "`MATCH (p:Post) RETURN count(p) AS total`" + `
+
+teardown ` + "`MATCH (n) DETACH DELETE n`" + `
 
 GetUser {
 	setup fixtures.CreateUser($id: 1)
+	teardown ` + "`MATCH (u:User)\n\t\tDETACH DELETE u`" + `
 	test "finds user by id" {
 		$userId: 1
 		u.name: "Alice"
+		assert { u.name != "" }
+		assert CountPosts() { total > 0 }
+		assert ` + "`MATCH (p:Post)-[:BY]->(u:User) WHERE u.active RETURN count(p) AS n`" + ` { n > 0 }
+		assert idempotent
 	}
 	group "edge cases" {
+		teardown ` + "`MATCH (p:Post) DELETE p`" + `
 		test "handles null" {
 			$userId: null
 		}
@@ -630,44 +933,58 @@ GetUser {
 		t.Fatalf("DocumentSymbol() error: %v", err)
 	}
 
-	if result == nil {
-		t.Fatal("Expected document symbols")
-	}
+	// Each symbol as "name (kind) detail" with its range and selection range,
+	// indented by depth.
+	var got []string
+
+	var walk func(sym protocol.DocumentSymbol, indent string)
+	walk = func(sym protocol.DocumentSymbol, indent string) {
+		got = append(got, fmt.Sprintf("%s%s (%s) %q %s %s", indent, sym.Name, sym.Kind, sym.Detail,
+			formatRange(sym.Range), formatRange(sym.SelectionRange)))
 
-	// Should have: import, 2 queries, 1 scope
-	if len(result) < 4 {
-		t.Errorf("Expected at least 4 top-level symbols, got %d", len(result))
+		for _, child := range sym.Children {
+			walk(child, indent+"  ")
+		}
 	}
 
-	// Check symbol names
-	symbolNames := make(map[string]bool)
 	for _, sym := range result {
-		if docSym, ok := sym.(protocol.DocumentSymbol); ok {
-			symbolNames[docSym.Name] = true
-			t.Logf("Symbol: %s (%s) - %s", docSym.Name, docSym.Kind, docSym.Detail)
-			
-			// Check nested symbols for GetUser scope
-			if docSym.Name == "GetUser" && docSym.Kind == protocol.SymbolKindClass {
-				for _, child := range docSym.Children {
-					t.Logf("  Child: %s (%s) - %s", child.Name, child.Kind, child.Detail)
-					for _, grandchild := range child.Children {
-						t.Logf("    Grandchild: %s (%s) - %s", grandchild.Name, grandchild.Kind, grandchild.Detail)
-					}
-				}
-			}
+		docSym, ok := sym.(protocol.DocumentSymbol)
+		if !ok {
+			t.Fatalf("symbol %T, want protocol.DocumentSymbol", sym)
 		}
+
+		walk(docSym, "")
 	}
 
-	// Verify expected symbols
-	expected := []string{"fixtures", "GetUser", "CountPosts"}
-	for _, name := range expected {
-		if !symbolNames[name] {
-			t.Errorf("Expected symbol %q not found", name)
-		}
+	want := []string{
+		`fixtures (Module) "import" 0:0-0:28 0:0-0:28`,
+		`GetUser (Function) "query" 2:0-2:58 2:6-2:13`,
+		`CountPosts (Function) "query" 3:0-3:58 3:6-3:16`,
+		`teardown (Method) "MATCH (n) DETACH DELETE n" 5:0-5:36 5:0-5:8`,
+		`GetUser (Class) "query scope" 7:0-25:1 7:0-7:7`,
+		`  setup (Constructor) "setup fixtures.CreateUser" 8:1-8:34 8:1-8:6`,
+		`  teardown (Method) "MATCH (u:User) DETACH DELETE u" 9:1-10:18 9:1-9:9`,
+		`  finds user by id (Method) "test" 11:1-18:2 11:7-11:23`,
+		`    assert (Event) "main query" 14:2-14:25 14:2-14:8`,
+		`    assert CountPosts (Event) "CountPosts()" 15:2-15:35 15:2-15:8`,
+		`    assert (Event) "MATCH (p:Post)-[:BY]->(u:User) WHERE u.…" 16:2-16:87 16:2-16:8`,
+		`    assert idempotent (Event) "reruns the main query" 17:2-17:19 17:2-17:8`,
+		`  edge cases (Namespace) "group" 19:1-24:2 19:8-19:18`,
+		`    teardown (Method) "MATCH (p:Post) DELETE p" 20:2-20:36 20:2-20:10`,
+		`    handles null (Method) "test" 21:2-23:3 21:8-21:20`,
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DocumentSymbol() mismatch (-want +got):\n%s", diff)
 	}
 }
 
-func TestServer_DocumentSymbol_Empty(t *testing.T) {
+// formatRange formats a range as "line:char-line:char".
+func formatRange(r protocol.Range) string {
+	return fmt.Sprintf("%d:%d-%d:%d", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character)
+}
+
+func TestServer_DocumentSymbol_SourceOrder(t *testing.T) {
 	t.Parallel()
 
 	server, _ := newTestServer(t)
@@ -676,42 +993,146 @@ func TestServer_DocumentSymbol_Empty(t *testing.T) {
 	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
 	_ = server.Initialized(ctx, &protocol.InitializedParams{})
 
-	// Test with non-existent document
-	result, err := server.DocumentSymbol(ctx, &protocol.DocumentSymbolParams{
-		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///nonexistent.scaf"},
-	})
-	if err != nil {
-		t.Fatalf("DocumentSymbol() error: %v", err)
-	}
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     "file:///test.scaf",
+			Version: 1,
+			Text: `import fixtures "./fixtures"
 
-	// Should return nil/empty for non-existent document
-	if result != nil && len(result) > 0 {
-		t.Error("Expected nil or empty result for non-existent document")
+query GetUser ` + "`MATCH (u:User {id: $userId}) RETURN u.name`" + `
+query CountPosts ` + "`MATCH (p:Post) RETURN count(p)`" + `
+
+setup ` + "`CREATE (:Seed)`" + `
+
+GetUser {
+	test "finds user" {
+		$userId: 1
 	}
 }
+`,
+		},
+	})
 
-func TestServer_Hover_SetupCall_CrossFile(t *testing.T) {
-	t.Parallel()
+	want := []string{"fixtures", "GetUser", "CountPosts", "setup", "GetUser"}
 
-	// Create temporary directory structure
-	tmpDir := t.TempDir()
+	for range 5 {
+		result, err := server.DocumentSymbol(ctx, &protocol.DocumentSymbolParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+		})
+		if err != nil {
+			t.Fatalf("DocumentSymbol() error: %v", err)
+		}
 
-	// Create fixtures.scaf
-	fixturesPath := tmpDir + "/fixtures.scaf"
-	fixturesContent := "query SetupUsers `CREATE (u:User {name: $name}) RETURN u`\nquery SetupPosts `CREATE (p:Post {title: $title}) RETURN p`\n"
-	if err := writeFile(fixturesPath, fixturesContent); err != nil {
-		t.Fatalf("Failed to write fixtures.scaf: %v", err)
-	}
+		var got []string
 
-	// Create main.scaf that imports fixtures
-	mainPath := tmpDir + "/main.scaf"
-	mainContent := "import fixtures \"./fixtures\"\n\nquery GetUser `MATCH (u:User {id: $id}) RETURN u`\n\nGetUser {\n\tsetup fixtures.SetupUsers($name: \"test\")\n\ttest \"finds user\" {\n\t\t$id: 1\n\t}\n}\n"
-	if err := writeFile(mainPath, mainContent); err != nil {
-		t.Fatalf("Failed to write main.scaf: %v", err)
+		for _, sym := range result {
+			if docSym, ok := sym.(protocol.DocumentSymbol); ok {
+				got = append(got, docSym.Name)
+			}
+		}
+
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("DocumentSymbol() names = %v, want %v", got, want)
+		}
 	}
+}
 
-	server, _ := newTestServerWithDebug(t)
-	ctx := context.Background()
+func TestServer_SkipReason(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	uri := protocol.DocumentURI("file:///test.scaf")
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     uri,
+			Version: 1,
+			Text: `query Q ` + "`Q`" + `
+
+Q {
+	// scaf:skip("flaky on CI")
+	test "flaky" {}
+}
+`,
+		},
+	})
+
+	result, err := server.DocumentSymbol(ctx, &protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		t.Fatalf("DocumentSymbol() error: %v", err)
+	}
+
+	scope, _ := result[len(result)-1].(protocol.DocumentSymbol)
+	if len(scope.Children) != 1 || scope.Children[0].Detail != "test (skipped: flaky on CI)" {
+		t.Errorf("test symbol = %+v, want detail with skip reason", scope.Children)
+	}
+
+	hover, err := server.Hover(ctx, &protocol.HoverParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     protocol.Position{Line: 4, Character: 3},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Hover() error: %v", err)
+	}
+
+	if hover == nil || !strings.Contains(hover.Contents.Value, "**Skipped:** flaky on CI") {
+		t.Errorf("Hover() = %+v, want skip reason", hover)
+	}
+}
+
+func TestServer_DocumentSymbol_Empty(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	// Test with non-existent document
+	result, err := server.DocumentSymbol(ctx, &protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///nonexistent.scaf"},
+	})
+	if err != nil {
+		t.Fatalf("DocumentSymbol() error: %v", err)
+	}
+
+	// Should return nil/empty for non-existent document
+	if result != nil && len(result) > 0 {
+		t.Error("Expected nil or empty result for non-existent document")
+	}
+}
+
+func TestServer_Hover_SetupCall_CrossFile(t *testing.T) {
+	t.Parallel()
+
+	// Create temporary directory structure
+	tmpDir := t.TempDir()
+
+	// Create fixtures.scaf
+	fixturesPath := tmpDir + "/fixtures.scaf"
+	fixturesContent := "query SetupUsers `CREATE (u:User {name: $name}) RETURN u`\nquery SetupPosts `CREATE (p:Post {title: $title}) RETURN p`\n"
+	if err := writeFile(fixturesPath, fixturesContent); err != nil {
+		t.Fatalf("Failed to write fixtures.scaf: %v", err)
+	}
+
+	// Create main.scaf that imports fixtures
+	mainPath := tmpDir + "/main.scaf"
+	mainContent := "import fixtures \"./fixtures\"\n\nquery GetUser `MATCH (u:User {id: $id}) RETURN u`\n\nGetUser {\n\tsetup fixtures.SetupUsers($name: \"test\")\n\ttest \"finds user\" {\n\t\t$id: 1\n\t}\n}\n"
+	if err := writeFile(mainPath, mainContent); err != nil {
+		t.Fatalf("Failed to write main.scaf: %v", err)
+	}
+
+	server, _ := newTestServerWithDebug(t)
+	ctx := context.Background()
 
 	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
 		RootURI: protocol.DocumentURI("file://" + tmpDir),
@@ -756,6 +1177,11 @@ func TestServer_Hover_SetupCall_CrossFile(t *testing.T) {
 	if !contains(content, "CREATE") {
 		t.Errorf("Expected query body in hover, got: %s", content)
 	}
+
+	// Should link to the definition in fixtures.scaf
+	if want := "[fixtures.scaf:1](file://" + fixturesPath + "#L1)"; !contains(content, want) {
+		t.Errorf("Expected definition link %q in hover, got: %s", want, content)
+	}
 }
 
 func TestServer_Diagnostic_UndefinedSetupQuery(t *testing.T) {
@@ -827,6 +1253,349 @@ func TestServer_Diagnostic_UndefinedSetupQuery(t *testing.T) {
 	}
 }
 
+func TestServer_ReExportDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"users.scaf": "query CreateUser `CREATE (u:User)`\n",
+		"seed.scaf":  "query Seed `CREATE (:Seed)`\n",
+		// loop.scaf re-exports Loop back from the hub, forming a cycle.
+		"loop.scaf": "export { Loop } from \"./hub\"\n",
+	}
+	for name, content := range files {
+		if err := writeFile(tmpDir+"/"+name, content); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	hubPath := tmpDir + "/hub.scaf"
+	hubContent := "import seed \"./seed\"\n\nexport seed\nexport { CreateUser, Missing } from \"./users\"\nexport { Loop } from \"./loop\"\n"
+	if err := writeFile(hubPath, hubContent); err != nil {
+		t.Fatalf("Failed to write hub.scaf: %v", err)
+	}
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: protocol.DocumentURI("file://" + hubPath), Version: 1, Text: hubContent},
+	})
+
+	if len(client.diagnostics) == 0 {
+		t.Fatal("Expected diagnostics to be published")
+	}
+
+	codes := make(map[string][]string)
+	for _, d := range client.diagnostics[len(client.diagnostics)-1].Diagnostics {
+		code, _ := d.Code.(string)
+		codes[code] = append(codes[code], d.Message)
+	}
+
+	msgs := codes["undefined-export"]
+	if len(msgs) != 2 || !contains(msgs[0], "Missing") || !contains(msgs[1], "Loop") {
+		t.Errorf("Expected undefined-export diagnostics for Missing and Loop, got %v", msgs)
+	}
+
+	// Re-exporting seed uses its import.
+	if msgs := codes["unused-import"]; len(msgs) > 0 {
+		t.Errorf("Unexpected unused-import diagnostics: %v", msgs)
+	}
+}
+
+func TestServer_CircularImportDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		// fixtures.scaf imports main.scaf back.
+		"fixtures.scaf": "import main \"./main\"\n\nquery Seed `CREATE (:Seed)`\n",
+		"main.scaf":     "import fixtures \"./fixtures\"\nimport shapes \"./a\"\n",
+		// a imports b and c, which both import d: a diamond, not a cycle.
+		"a.scaf": "import b \"./b\"\nimport c \"./c\"\n\nquery A `A`\n",
+		"b.scaf": "import d \"./d\"\n\nquery B `B`\n",
+		"c.scaf": "import d \"./d\"\n\nquery C `C`\n",
+		"d.scaf": "query D `D`\n",
+	}
+	for name, content := range files {
+		if err := writeFile(tmpDir+"/"+name, content); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	circular := func(name string) []protocol.Diagnostic {
+		t.Helper()
+
+		path := tmpDir + "/" + name
+		_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{URI: protocol.DocumentURI("file://" + path), Version: 1, Text: files[name]},
+		})
+
+		if len(client.diagnostics) == 0 {
+			t.Fatal("Expected diagnostics to be published")
+		}
+
+		var found []protocol.Diagnostic
+
+		for _, d := range client.diagnostics[len(client.diagnostics)-1].Diagnostics {
+			if code, _ := d.Code.(string); code == "circular-import" {
+				found = append(found, d)
+			}
+		}
+
+		return found
+	}
+
+	found := circular("main.scaf")
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 circular-import diagnostic in main.scaf, got %v", found)
+	}
+
+	wantMsg := "circular import: " + tmpDir + "/main.scaf -> " + tmpDir + "/fixtures.scaf -> " + tmpDir + "/main.scaf"
+	if found[0].Message != wantMsg {
+		t.Errorf("Message = %q, want %q", found[0].Message, wantMsg)
+	}
+
+	if found[0].Range.Start.Line != 0 {
+		t.Errorf("Expected the diagnostic on the fixtures import (line 0), got line %d", found[0].Range.Start.Line)
+	}
+
+	if found := circular("fixtures.scaf"); len(found) != 1 {
+		t.Errorf("Expected 1 circular-import diagnostic in fixtures.scaf, got %v", found)
+	}
+
+	if found := circular("a.scaf"); len(found) != 0 {
+		t.Errorf("Expected no circular-import diagnostics for a diamond, got %v", found)
+	}
+}
+
+func TestServer_UnusedQueryDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		// No scopes: a fixtures module, never reported.
+		"fixtures.scaf": "query Seed `CREATE (:Seed)`\n",
+		"main.scaf":     "import users \"./users\"\n\nsetup users.CreateUser()\n",
+		"hub.scaf":      "export { Reexported } from \"./users\"\n",
+	}
+	for name, content := range files {
+		if err := writeFile(tmpDir+"/"+name, content); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	usersPath := tmpDir + "/users.scaf"
+	usersContent := "query GetUser `MATCH (u:User) RETURN u`\n" +
+		"query CountUsers `MATCH (u:User) RETURN count(u) AS n`\n" +
+		"query CreateUser `CREATE (:User)`\n" +
+		"query Reexported `MATCH (u) RETURN u`\n" +
+		"query Orphan `MATCH (o) RETURN o`\n" +
+		"// scaf:export\n" +
+		"query Later `MATCH (l) RETURN l`\n\n" +
+		"GetUser {\n\ttest \"counts\" {\n\t\tassert CountUsers() { n == 0 }\n\t}\n}\n"
+	if err := writeFile(usersPath, usersContent); err != nil {
+		t.Fatalf("Failed to write users.scaf: %v", err)
+	}
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	unused := func() []string {
+		var msgs []string
+
+		for _, d := range client.diagnostics[len(client.diagnostics)-1].Diagnostics {
+			if d.Code == "unused-query" {
+				msgs = append(msgs, fmt.Sprintf("%d:%d-%d %s", d.Range.Start.Line, d.Range.Start.Character, d.Range.End.Character, d.Message))
+			}
+		}
+
+		return msgs
+	}
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: protocol.DocumentURI("file://" + usersPath), Version: 1, Text: usersContent},
+	})
+
+	// Opt-in: not reported without an override.
+	if msgs := unused(); len(msgs) > 0 {
+		t.Errorf("Unexpected unused-query diagnostics before opting in: %v", msgs)
+	}
+
+	_ = server.DidChangeConfiguration(ctx, &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{
+			"scaf": map[string]any{"severity": map[string]any{"unused-query": "hint"}},
+		},
+	})
+
+	// Scoped, asserted, called from main.scaf, and re-exported by hub.scaf are
+	// all uses, and // scaf:export marks a query as meant to be called.
+	if msgs := unused(); len(msgs) != 1 || !strings.HasPrefix(msgs[0], "4:6-12 ") || !contains(msgs[0], "Orphan") {
+		t.Errorf("Expected one unused-query diagnostic on Orphan's name, got %v", msgs)
+	}
+}
+
+func TestServer_DidSaveRefreshesImporters(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	usersPath := tmpDir + "/users.scaf"
+	if err := writeFile(usersPath, "query Other `MATCH (u:User) RETURN u`\n"); err != nil {
+		t.Fatalf("Failed to write users.scaf: %v", err)
+	}
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	mainURI := protocol.DocumentURI("file://" + tmpDir + "/main.scaf")
+	mainContent := "import users \"./users\"\n\nquery Q `MATCH (n) RETURN n`\n\nQ {\n\tsetup users.CreateUser()\n\ttest \"t\" {}\n}\n"
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: mainURI, Version: 1, Text: mainContent},
+	})
+
+	mainCodes := func() []string {
+		var codes []string
+
+		for i := len(client.diagnostics) - 1; i >= 0; i-- {
+			if client.diagnostics[i].URI != mainURI {
+				continue
+			}
+
+			for _, d := range client.diagnostics[i].Diagnostics {
+				code, _ := d.Code.(string)
+				codes = append(codes, code)
+			}
+
+			break
+		}
+
+		return codes
+	}
+
+	if codes := mainCodes(); !slices.Contains(codes, "undefined-setup-query") {
+		t.Fatalf("Expected undefined-setup-query before save, got %v", codes)
+	}
+
+	saved := "query CreateUser `CREATE (u:User)`\n"
+	if err := writeFile(usersPath, saved); err != nil {
+		t.Fatalf("Failed to write users.scaf: %v", err)
+	}
+
+	_ = server.DidSave(ctx, &protocol.DidSaveTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentURI("file://" + usersPath)},
+		Text:         saved,
+	})
+
+	if codes := mainCodes(); slices.Contains(codes, "undefined-setup-query") {
+		t.Errorf("Expected undefined-setup-query to clear after save, got %v", codes)
+	}
+}
+
+func TestServer_UnaliasedImport(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	// fixtures.scaf also defines GetUser, which collides with main.scaf's own query
+	fixturesPath := tmpDir + "/fixtures.scaf"
+	fixturesContent := "query SetupUsers `CREATE (u:User {name: $name}) RETURN u`\nquery GetUser `MATCH (u) RETURN u`\n"
+	if err := writeFile(fixturesPath, fixturesContent); err != nil {
+		t.Fatalf("Failed to write fixtures.scaf: %v", err)
+	}
+
+	mainPath := tmpDir + "/main.scaf"
+	mainContent := "import \"./fixtures\"\n\nquery GetUser `MATCH (u:User {id: $id}) RETURN u`\n\nGetUser {\n\tsetup SetupUsers($name: \"test\")\n\ttest \"finds user\" {\n\t\tsetup Missing()\n\t\t$id: 1\n\t}\n}\n"
+	if err := writeFile(mainPath, mainContent); err != nil {
+		t.Fatalf("Failed to write main.scaf: %v", err)
+	}
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	mainURI := protocol.DocumentURI("file://" + mainPath)
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: mainURI, Version: 1, Text: mainContent},
+	})
+
+	if len(client.diagnostics) == 0 {
+		t.Fatal("Expected diagnostics to be published")
+	}
+
+	codes := make(map[string][]string)
+	for _, d := range client.diagnostics[len(client.diagnostics)-1].Diagnostics {
+		code, _ := d.Code.(string)
+		codes[code] = append(codes[code], d.Message)
+	}
+
+	// SetupUsers resolves through the unaliased import; Missing doesn't resolve anywhere
+	if msgs := codes["undefined-setup-query"]; len(msgs) != 1 || !contains(msgs[0], "Missing") {
+		t.Errorf("Expected one undefined-setup-query diagnostic for Missing, got %v", msgs)
+	}
+
+	if msgs := codes["duplicate-import-symbol"]; len(msgs) != 1 || !contains(msgs[0], "GetUser") {
+		t.Errorf("Expected one duplicate-import-symbol diagnostic for GetUser, got %v", msgs)
+	}
+
+	for _, code := range []string{"undefined-import", "unused-import"} {
+		if msgs := codes[code]; len(msgs) > 0 {
+			t.Errorf("Unexpected %s diagnostics: %v", code, msgs)
+		}
+	}
+
+	// Hover over "SetupUsers" (line 5, "\tsetup " = 7 chars)
+	result, err := server.Hover(ctx, &protocol.HoverParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: mainURI},
+			Position:     protocol.Position{Line: 5, Character: 9},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Hover() error: %v", err)
+	}
+
+	if result == nil {
+		t.Fatal("Expected hover result for unqualified setup call")
+	}
+
+	content := result.Contents.Value
+	if !contains(content, "CREATE (u:User") || !contains(content, "file://"+fixturesPath+"#L1") {
+		t.Errorf("Expected hover to show the imported query and link to fixtures.scaf, got: %s", content)
+	}
+}
+
 func TestServer_Diagnostic_ValidSetupQuery(t *testing.T) {
 	t.Parallel()
 
@@ -850,28 +1619,184 @@ func TestServer_Diagnostic_ValidSetupQuery(t *testing.T) {
 	server, client := newTestServer(t)
 	ctx := context.Background()
 
-	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
-		RootURI: protocol.DocumentURI("file://" + tmpDir),
-	})
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	mainURI := protocol.DocumentURI("file://" + mainPath)
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     mainURI,
+			Version: 1,
+			Text:    mainContent,
+		},
+	})
+
+	// Should NOT have undefined-setup-query diagnostic
+	if len(client.diagnostics) > 0 {
+		lastDiag := client.diagnostics[len(client.diagnostics)-1]
+		for _, d := range lastDiag.Diagnostics {
+			if d.Code == "undefined-setup-query" {
+				t.Errorf("Should not have undefined-setup-query diagnostic for valid setup call, got: %s", d.Message)
+			}
+		}
+	}
+}
+
+func TestServer_Diagnostic_DuplicateQuery(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	// The imported file defines a query of the same name, which isn't a duplicate.
+	fixturesContent := "query GetUser `CREATE (u:User {id: $id})`\n"
+	if err := writeFile(tmpDir+"/fixtures.scaf", fixturesContent); err != nil {
+		t.Fatalf("Failed to write fixtures.scaf: %v", err)
+	}
+
+	mainContent := "import fixtures \"./fixtures\"\n\n" +
+		"query GetUser `MATCH (u:User {id: $id}) RETURN u`\n" +
+		"query CountUsers `MATCH (u:User) RETURN count(u)`\n" +
+		"query   GetUser `MATCH (u:User) RETURN u`\n\n" +
+		"GetUser {\n\tsetup fixtures.GetUser($id: 1)\n\ttest \"finds user\" {\n\t\t$id: 1\n\t}\n}\n"
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     protocol.DocumentURI("file://" + tmpDir + "/main.scaf"),
+			Version: 1,
+			Text:    mainContent,
+		},
+	})
+
+	if len(client.diagnostics) == 0 {
+		t.Fatal("Expected diagnostics to be published")
+	}
+
+	var duplicates []protocol.Diagnostic
+
+	for _, d := range client.diagnostics[len(client.diagnostics)-1].Diagnostics {
+		if d.Code == "duplicate-query" {
+			duplicates = append(duplicates, d)
+		}
+	}
+
+	if len(duplicates) != 1 {
+		t.Fatalf("Expected one duplicate-query diagnostic, got: %v", duplicates)
+	}
+
+	// Reported on the redefinition's name, pointing at the first definition
+	want := protocol.Range{
+		Start: protocol.Position{Line: 4, Character: 8},
+		End:   protocol.Position{Line: 4, Character: 15},
+	}
+	if duplicates[0].Range != want {
+		t.Errorf("duplicate-query range = %v, want %v", duplicates[0].Range, want)
+	}
+
+	if want := "duplicate query name: GetUser (first defined at line 3)"; duplicates[0].Message != want {
+		t.Errorf("duplicate-query message = %q, want %q", duplicates[0].Message, want)
+	}
+}
+
+func TestServer_DidOpen_ImportProgress(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	for _, name := range []string{"users", "posts", "tags"} {
+		if err := writeFile(tmpDir+"/"+name+".scaf", "query Setup `CREATE (n)`\n"); err != nil {
+			t.Fatalf("Failed to write %s.scaf: %v", name, err)
+		}
+	}
+
+	mainPath := tmpDir + "/main.scaf"
+	mainContent := "import users \"./users\"\nimport posts \"./posts\"\nimport tags \"./tags\"\n\nquery Q `Q`\n"
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{
+		RootURI: protocol.DocumentURI("file://" + tmpDir),
+		Capabilities: protocol.ClientCapabilities{
+			Window: &protocol.WindowClientCapabilities{WorkDoneProgress: true},
+		},
+	})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     protocol.DocumentURI("file://" + mainPath),
+			Version: 1,
+			Text:    mainContent,
+		},
+	})
+
+	if len(client.progressTokens) != 1 {
+		t.Fatalf("Expected 1 progress token, got %d", len(client.progressTokens))
+	}
+
+	// begin, one report per import, end
+	if len(client.progress) != 5 {
+		t.Fatalf("Expected 5 progress notifications, got %d", len(client.progress))
+	}
+
+	begin, ok := client.progress[0].Value.(*protocol.WorkDoneProgressBegin)
+	if !ok || begin.Title != "Loading imports" {
+		t.Errorf("Expected begin notification titled 'Loading imports', got %+v", client.progress[0].Value)
+	}
+
+	var percentages []uint32
+	for _, p := range client.progress[1:4] {
+		if p.Token != client.progressTokens[0] {
+			t.Errorf("Progress token = %v, want %v", p.Token, client.progressTokens[0])
+		}
+
+		report, ok := p.Value.(*protocol.WorkDoneProgressReport)
+		if !ok {
+			t.Fatalf("Expected report notification, got %T", p.Value)
+		}
+
+		percentages = append(percentages, report.Percentage)
+	}
+
+	if want := []uint32{33, 66, 100}; !slices.Equal(percentages, want) {
+		t.Errorf("Percentages = %v, want %v", percentages, want)
+	}
+
+	if _, ok := client.progress[4].Value.(*protocol.WorkDoneProgressEnd); !ok {
+		t.Errorf("Expected end notification, got %T", client.progress[4].Value)
+	}
+}
+
+func TestServer_DidOpen_NoProgressWithoutCapability(t *testing.T) {
+	t.Parallel()
+
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
 	_ = server.Initialized(ctx, &protocol.InitializedParams{})
 
-	mainURI := protocol.DocumentURI("file://" + mainPath)
 	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
 		TextDocument: protocol.TextDocumentItem{
-			URI:     mainURI,
+			URI:     "file:///test.scaf",
 			Version: 1,
-			Text:    mainContent,
+			Text:    "import fixtures \"./fixtures\"\n\nquery Q `Q`\n",
 		},
 	})
 
-	// Should NOT have undefined-setup-query diagnostic
-	if len(client.diagnostics) > 0 {
-		lastDiag := client.diagnostics[len(client.diagnostics)-1]
-		for _, d := range lastDiag.Diagnostics {
-			if d.Code == "undefined-setup-query" {
-				t.Errorf("Should not have undefined-setup-query diagnostic for valid setup call, got: %s", d.Message)
-			}
-		}
+	if len(client.progressTokens) != 0 || len(client.progress) != 0 {
+		t.Errorf("Expected no progress, got %d tokens and %d notifications",
+			len(client.progressTokens), len(client.progress))
 	}
 }
 
@@ -952,6 +1877,279 @@ GetUser {
 	}
 }
 
+func TestServer_InlineValue(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	content := `query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+GetUser {
+	test "finds alice" {
+		$id: 1
+		u.name: "Alice"
+	}
+	group "edge" {
+		test "wrong name" {
+			$id: 2
+			u.name: "Bob"
+		}
+		test "broken" {
+			$id: 3
+		}
+	}
+}
+`
+	uri := protocol.DocumentURI("file:///test.scaf")
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: content},
+	})
+
+	result := runner.NewResult()
+	result.Add(runner.Event{Action: runner.ActionPass, Path: []string{"GetUser", "finds alice"}})
+	result.Add(runner.Event{
+		Action: runner.ActionFail, Path: []string{"GetUser", "edge", "wrong name"},
+		Field: "u.name", Expected: "Bob", Actual: "Carol",
+	})
+	result.Add(runner.Event{
+		Action: runner.ActionError, Path: []string{"GetUser", "edge", "broken"},
+		Error: errors.New("connection refused"),
+	})
+	server.RecordRun(uri, result)
+
+	params := &lsp.InlineValueParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        protocol.Range{End: protocol.Position{Line: 20}},
+	}
+
+	values, err := server.InlineValue(ctx, params)
+	if err != nil {
+		t.Fatalf("InlineValue() error: %v", err)
+	}
+
+	want := map[uint32]string{
+		5:  "✓ u.name",
+		10: `✗ u.name: got "Carol", want "Bob"`,
+		12: "error: connection refused",
+	}
+
+	got := make(map[uint32]string)
+	for _, v := range values {
+		got[v.Range.Start.Line] = v.Text
+	}
+
+	if !maps.Equal(want, got) {
+		t.Errorf("InlineValue() by line = %v, want %v", got, want)
+	}
+
+	// Clients reach the handler through the non-standard request path.
+	resp, err := server.Request(ctx, lsp.MethodTextDocumentInlineValue, map[string]any{
+		"textDocument": map[string]any{"uri": string(uri)},
+		"range":        map[string]any{"start": map[string]any{"line": 10}, "end": map[string]any{"line": 10}},
+	})
+	if err != nil {
+		t.Fatalf("Request() error: %v", err)
+	}
+
+	if ranged, ok := resp.([]lsp.InlineValueText); !ok || len(ranged) != 1 || ranged[0].Range.Start.Line != 10 {
+		t.Errorf("Request(inlineValue) = %#v, want the value on line 10 only", resp)
+	}
+}
+
+func TestServer_ListPathsCommand(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	uri := protocol.DocumentURI("file:///test.scaf")
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     uri,
+			Version: 1,
+			Text: `query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+GetUser {
+	test "by id" {
+		$id: 1
+	}
+	group "edge cases" {
+		group "nulls" {
+			test "null id" {
+				$id: null
+			}
+		}
+	}
+}
+`,
+		},
+	})
+
+	got, err := server.ExecuteCommand(ctx, &protocol.ExecuteCommandParams{
+		Command:   lsp.CommandListPaths,
+		Arguments: []any{string(uri)},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteCommand() error: %v", err)
+	}
+
+	want := []string{
+		"GetUser",
+		"GetUser/by id",
+		"GetUser/edge cases",
+		"GetUser/edge cases/nulls",
+		"GetUser/edge cases/nulls/null id",
+	}
+
+	if paths, ok := got.([]string); !ok || !slices.Equal(paths, want) {
+		t.Errorf("scaf.listPaths = %#v, want %v", got, want)
+	}
+
+	if _, err := server.ExecuteCommand(ctx, &protocol.ExecuteCommandParams{
+		Command:   lsp.CommandListPaths,
+		Arguments: []any{"file:///missing.scaf"},
+	}); err == nil {
+		t.Error("scaf.listPaths on an unopened document: expected error")
+	}
+}
+
+func TestServer_ExecuteCommand_ShowsRunErrors(t *testing.T) {
+	// Registered before the parallel tests run; no other test opens a database.
+	errRefused := errors.New("connection refused")
+	scaf.RegisterDatabase(scaf.DatabaseNeo4j, func(any) (scaf.Database, error) { return nil, errRefused })
+
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		config      string // .scaf.yaml content, or "" for none
+		wantMessage string
+		wantErr     error
+	}{
+		{"no config", "", "no .scaf.yaml found", scaf.ErrConfigNotFound},
+		{"no database", "lint:\n  severity: {}\n", "no database configured", nil},
+		{"no URI", "neo4j:\n  username: neo4j\n", "no database connection URI", nil},
+		{"connection", "neo4j:\n  uri: bolt://localhost:1\n", "cannot connect to the database", errRefused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			filePath := filepath.Join(tmpDir, "users.scaf")
+
+			if err := writeFile(filePath, "query Q `Q`\nQ {\n\ttest \"t\" {}\n}\n"); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.config != "" {
+				if err := writeFile(filepath.Join(tmpDir, ".scaf.yaml"), tt.config); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			server, client := newTestServer(t)
+			ctx := context.Background()
+
+			_, err := server.ExecuteCommand(ctx, &protocol.ExecuteCommandParams{
+				Command:   lsp.CommandRunTest,
+				Arguments: []any{filePath, "Q/t"},
+			})
+			if err == nil {
+				t.Fatal("ExecuteCommand() expected error")
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ExecuteCommand() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var msg protocol.ShowMessageRequestParams
+
+			select {
+			case msg = <-client.messages:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no message shown")
+			}
+
+			if msg.Type != protocol.MessageTypeError || !strings.Contains(msg.Message, tt.wantMessage) {
+				t.Errorf("message = %v %q, want an error containing %q", msg.Type, msg.Message, tt.wantMessage)
+			}
+
+			// Settings can be opened only when there are some.
+			wantActions := 1
+			if tt.config == "" {
+				wantActions = 0
+			}
+
+			if len(msg.Actions) != wantActions {
+				t.Errorf("actions = %v, want %d", msg.Actions, wantActions)
+			}
+		})
+	}
+}
+
+func TestServer_EmptyDocument(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"comment only", "// Nothing here yet.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, _ := newTestServer(t)
+			ctx := context.Background()
+
+			_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+			_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+			uri := protocol.DocumentURI("file:///empty.scaf")
+			_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+				TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: tt.content},
+			})
+
+			doc := protocol.TextDocumentIdentifier{URI: uri}
+			pos := protocol.TextDocumentPositionParams{TextDocument: doc}
+
+			symbols, err := server.DocumentSymbol(ctx, &protocol.DocumentSymbolParams{TextDocument: doc})
+			if err != nil || symbols == nil || len(symbols) != 0 {
+				t.Errorf("DocumentSymbol() = %v, %v; want empty", symbols, err)
+			}
+
+			lenses, err := server.CodeLens(ctx, &protocol.CodeLensParams{TextDocument: doc})
+			if err != nil || lenses == nil || len(lenses) != 0 {
+				t.Errorf("CodeLens() = %v, %v; want empty", lenses, err)
+			}
+
+			if _, err := server.Hover(ctx, &protocol.HoverParams{TextDocumentPositionParams: pos}); err != nil {
+				t.Errorf("Hover() error: %v", err)
+			}
+
+			if _, err := server.Completion(ctx, &protocol.CompletionParams{TextDocumentPositionParams: pos}); err != nil {
+				t.Errorf("Completion() error: %v", err)
+			}
+
+			if _, err := server.Definition(ctx, &protocol.DefinitionParams{TextDocumentPositionParams: pos}); err != nil {
+				t.Errorf("Definition() error: %v", err)
+			}
+		})
+	}
+}
+
 func TestServer_CodeLens_Ranges(t *testing.T) {
 	t.Parallel()
 
@@ -1020,6 +2218,54 @@ Q {
 	}
 }
 
+func TestServer_CodeLens_Ordering(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	// Everything starts on line 2, and the repeated group yields duplicate lenses.
+	content := `query Q ` + "`Q`" + `
+
+Q { group "g" { test "t" {} } group "g" { test "t" {} } test "last" {} }
+`
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     "file:///test.scaf",
+			Version: 1,
+			Text:    content,
+		},
+	})
+
+	want := []string{
+		"scaf.runScope:Q",
+		"scaf.runGroup:Q/g",
+		"scaf.runTest:Q/g/t",
+		"scaf.runTest:Q/last",
+	}
+
+	for range 3 {
+		result, err := server.CodeLens(ctx, &protocol.CodeLensParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+		})
+		if err != nil {
+			t.Fatalf("CodeLens() error: %v", err)
+		}
+
+		var got []string
+		for _, lens := range result {
+			got = append(got, lens.Command.Command+":"+lens.Command.Arguments[1].(string))
+		}
+
+		if !slices.Equal(got, want) {
+			t.Fatalf("CodeLens order = %v, want %v", got, want)
+		}
+	}
+}
+
 func TestServer_CodeLens_Empty(t *testing.T) {
 	t.Parallel()
 
@@ -1245,6 +2491,67 @@ GetUser {
 	}
 }
 
+func TestServer_RangeFormatting_QueryBody(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	// The DSL around the bodies is unformatted too, and must stay that way.
+	content := "query GetUser `\n\tmatch (u:User {id: $id})   where u.age > 18\n\treturn u`\n" +
+		"query Broken `MATCH (u:User RETURN u`\n" +
+		"GetUser {\ntest \"t\" {\n$id: 1\n}\n}\n"
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: "file:///test.scaf", Version: 1, Text: content},
+	})
+
+	rangeFormat := func(line, char uint32) []protocol.TextEdit {
+		t.Helper()
+
+		pos := protocol.Position{Line: line, Character: char}
+
+		edits, err := server.RangeFormatting(ctx, &protocol.DocumentRangeFormattingParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+			Range:        protocol.Range{Start: pos, End: pos},
+		})
+		if err != nil {
+			t.Fatalf("RangeFormatting() error: %v", err)
+		}
+
+		return edits
+	}
+
+	edits := rangeFormat(1, 3)
+	if len(edits) != 1 {
+		t.Fatalf("Expected 1 edit, got %d", len(edits))
+	}
+
+	if start := edits[0].Range.Start; start.Line != 0 || start.Character != 15 {
+		t.Errorf("Edit starts at %+v, want the body start 0:15", start)
+	}
+
+	want := "query GetUser `\n\tMATCH (u:User {id: $id})\n\tWHERE u.age > 18\n\tRETURN u`\n" +
+		"query Broken `MATCH (u:User RETURN u`\n" +
+		"GetUser {\ntest \"t\" {\n$id: 1\n}\n}\n"
+	if got := applyEdits(content, edits); got != want {
+		t.Errorf("After range formatting:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A body that doesn't parse is left alone.
+	if edits := rangeFormat(3, 16); len(edits) != 0 {
+		t.Errorf("Expected no edits for an unparseable body, got %+v", edits)
+	}
+
+	// Outside any body there is nothing to format.
+	if edits := rangeFormat(6, 1); len(edits) != 0 {
+		t.Errorf("Expected no edits outside a body, got %+v", edits)
+	}
+}
+
 func TestServer_Formatting_ParseError(t *testing.T) {
 	t.Parallel()
 
@@ -1306,3 +2613,77 @@ func TestServer_Formatting_UnknownDocument(t *testing.T) {
 		t.Errorf("Expected nil edits for unknown document, got %d edits", len(edits))
 	}
 }
+
+func TestServer_DocumentColor(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.Initialize(ctx, &protocol.InitializeParams{})
+	_ = server.Initialized(ctx, &protocol.InitializedParams{})
+
+	_ = server.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     "file:///test.scaf",
+			Version: 1,
+			Text: `query Q ` + "`Q`" + `
+
+Q {
+	test "t" {
+		u.color: "#FF0000"
+		u.name: "Alice"
+	}
+}
+`,
+		},
+	})
+
+	params := &protocol.DocumentColorParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.scaf"},
+	}
+
+	// Colors are off by default.
+	colors, err := server.DocumentColor(ctx, params)
+	if err != nil {
+		t.Fatalf("DocumentColor() error: %v", err)
+	}
+
+	if len(colors) != 0 {
+		t.Fatalf("Expected no colors before enabling the setting, got %v", colors)
+	}
+
+	_ = server.DidChangeConfiguration(ctx, &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{"scaf": map[string]any{"colors": true}},
+	})
+
+	colors, err = server.DocumentColor(ctx, params)
+	if err != nil {
+		t.Fatalf("DocumentColor() error: %v", err)
+	}
+
+	if len(colors) != 1 {
+		t.Fatalf("Expected 1 color, got %v", colors)
+	}
+
+	want := protocol.Color{Red: 1, Alpha: 1}
+	if colors[0].Color != want {
+		t.Errorf("Color = %+v, want %+v", colors[0].Color, want)
+	}
+
+	if colors[0].Range.Start.Line != 4 || colors[0].Range.Start.Character != 11 {
+		t.Errorf("Range starts at %+v, want line 4 character 11", colors[0].Range.Start)
+	}
+
+	presentations, err := server.ColorPresentation(ctx, &protocol.ColorPresentationParams{
+		Color: colors[0].Color,
+		Range: colors[0].Range,
+	})
+	if err != nil {
+		t.Fatalf("ColorPresentation() error: %v", err)
+	}
+
+	if len(presentations) != 1 || presentations[0].TextEdit.NewText != `"#FF0000"` {
+		t.Errorf("ColorPresentation() = %+v, want \"#FF0000\"", presentations)
+	}
+}
@@ -64,9 +64,9 @@ func (s *Server) SignatureHelp(_ context.Context, params *protocol.SignatureHelp
 		return nil, nil //nolint:nilnil
 	}
 
-	// Find the query in the imported file
-	query, ok := importedFile.Symbols.Queries[callInfo.query]
-	if !ok {
+	// Find the query in the imported file, following re-exports
+	query, _ := s.resolveImportedQuery(importedFile, callInfo.query)
+	if query == nil {
 		return nil, nil //nolint:nilnil
 	}
 
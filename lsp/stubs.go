@@ -35,10 +35,7 @@ func (s *Server) CodeLensResolve(_ context.Context, _ *protocol.CodeLens) (*prot
 	return nil, nil //nolint:nilnil // LSP stub returns nil for unimplemented features
 }
 
-// ColorPresentation handles textDocument/colorPresentation.
-func (s *Server) ColorPresentation(_ context.Context, _ *protocol.ColorPresentationParams) ([]protocol.ColorPresentation, error) {
-	return nil, nil
-}
+// ColorPresentation is implemented in color.go
 
 // Completion is implemented in completion.go
 
@@ -54,10 +51,7 @@ func (s *Server) Declaration(_ context.Context, _ *protocol.DeclarationParams) (
 
 // Definition is implemented in definition.go
 
-// DidChangeConfiguration handles workspace/didChangeConfiguration.
-func (s *Server) DidChangeConfiguration(_ context.Context, _ *protocol.DidChangeConfigurationParams) error {
-	return nil
-}
+// DidChangeConfiguration is implemented in config.go
 
 // DidChangeWatchedFiles handles workspace/didChangeWatchedFiles.
 func (s *Server) DidChangeWatchedFiles(_ context.Context, _ *protocol.DidChangeWatchedFilesParams) error {
@@ -69,10 +63,7 @@ func (s *Server) DidChangeWorkspaceFolders(_ context.Context, _ *protocol.DidCha
 	return nil
 }
 
-// DocumentColor handles textDocument/documentColor.
-func (s *Server) DocumentColor(_ context.Context, _ *protocol.DocumentColorParams) ([]protocol.ColorInformation, error) {
-	return nil, nil
-}
+// DocumentColor is implemented in color.go
 
 // DocumentHighlight is implemented in highlight.go
 
@@ -85,10 +76,7 @@ func (s *Server) DocumentLinkResolve(_ context.Context, _ *protocol.DocumentLink
 
 // DocumentSymbol is implemented in symbols.go
 
-// ExecuteCommand handles workspace/executeCommand.
-func (s *Server) ExecuteCommand(_ context.Context, _ *protocol.ExecuteCommandParams) (any, error) {
-	return nil, nil //nolint:nilnil // LSP stub returns nil for unimplemented features
-}
+// ExecuteCommand is implemented in commands.go
 
 // FoldingRanges is implemented in folding.go
 
@@ -106,10 +94,7 @@ func (s *Server) OnTypeFormatting(_ context.Context, _ *protocol.DocumentOnTypeF
 
 // PrepareRename is implemented in rename.go
 
-// RangeFormatting handles textDocument/rangeFormatting.
-func (s *Server) RangeFormatting(_ context.Context, _ *protocol.DocumentRangeFormattingParams) ([]protocol.TextEdit, error) {
-	return nil, nil
-}
+// RangeFormatting is implemented in formatting.go
 
 // References is implemented in references.go
 
@@ -174,20 +159,7 @@ func (s *Server) CodeLensRefresh(_ context.Context) error {
 	return nil
 }
 
-// PrepareCallHierarchy handles textDocument/prepareCallHierarchy.
-func (s *Server) PrepareCallHierarchy(_ context.Context, _ *protocol.CallHierarchyPrepareParams) ([]protocol.CallHierarchyItem, error) {
-	return nil, nil
-}
-
-// IncomingCalls handles callHierarchy/incomingCalls.
-func (s *Server) IncomingCalls(_ context.Context, _ *protocol.CallHierarchyIncomingCallsParams) ([]protocol.CallHierarchyIncomingCall, error) {
-	return nil, nil
-}
-
-// OutgoingCalls handles callHierarchy/outgoingCalls.
-func (s *Server) OutgoingCalls(_ context.Context, _ *protocol.CallHierarchyOutgoingCallsParams) ([]protocol.CallHierarchyOutgoingCall, error) {
-	return nil, nil
-}
+// PrepareCallHierarchy, IncomingCalls and OutgoingCalls are implemented in callhierarchy.go
 
 // SemanticTokensFull handles textDocument/semanticTokens/full.
 func (s *Server) SemanticTokensFull(_ context.Context, _ *protocol.SemanticTokensParams) (*protocol.SemanticTokens, error) {
@@ -219,7 +191,4 @@ func (s *Server) Moniker(_ context.Context, _ *protocol.MonikerParams) ([]protoc
 	return nil, nil
 }
 
-// Request handles custom requests.
-func (s *Server) Request(_ context.Context, _ string, _ any) (any, error) {
-	return nil, nil //nolint:nilnil // LSP stub returns nil for unimplemented features
-}
+// Request is implemented in inlinevalue.go
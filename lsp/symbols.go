@@ -2,7 +2,11 @@ package lsp
 
 import (
 	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/alecthomas/participle/v2/lexer"
 	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
 
@@ -62,9 +66,13 @@ func (s *Server) buildDocumentSymbols(f *analysis.AnalyzedFile) []protocol.Docum
 		})
 	}
 
-	// Add global setup if present
+	// Add global setup and teardown if present
 	if f.Suite.Setup != nil {
-		symbols = append(symbols, s.buildSetupSymbol(f.Suite.Setup, "setup"))
+		symbols = append(symbols, s.buildSetupSymbol(f.Suite.Setup, f.Suite.Tokens))
+	}
+
+	if sym, ok := s.buildTeardownSymbol(f.Suite.Teardown, f.Suite.Tokens, 0); ok {
+		symbols = append(symbols, sym)
 	}
 
 	// Add query scopes with nested tests/groups
@@ -72,6 +80,15 @@ func (s *Server) buildDocumentSymbols(f *analysis.AnalyzedFile) []protocol.Docum
 		symbols = append(symbols, s.buildScopeSymbol(scope))
 	}
 
+	// Present top-level symbols in source order regardless of their kind.
+	sort.SliceStable(symbols, func(i, j int) bool {
+		a, b := symbols[i].Range.Start, symbols[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Character < b.Character
+	})
+
 	return symbols
 }
 
@@ -87,15 +104,19 @@ func (s *Server) buildScopeSymbol(scope *scaf.QueryScope) protocol.DocumentSymbo
 
 	var children []protocol.DocumentSymbol
 
-	// Add setup if present
+	// Add setup and teardown if present
 	if scope.Setup != nil {
-		children = append(children, s.buildSetupSymbol(scope.Setup, "setup"))
+		children = append(children, s.buildSetupSymbol(scope.Setup, scope.Tokens))
+	}
+
+	if sym, ok := s.buildTeardownSymbol(scope.Teardown, scope.Tokens, 1); ok {
+		children = append(children, sym)
 	}
 
 	// Add tests and groups
 	for _, item := range scope.Items {
-		if item.Test != nil {
-			children = append(children, s.buildTestSymbol(item.Test))
+		for _, test := range item.Tests() {
+			children = append(children, s.buildTestSymbol(test))
 		}
 		if item.Group != nil {
 			children = append(children, s.buildGroupSymbol(item.Group))
@@ -113,37 +134,38 @@ func (s *Server) buildTestSymbol(test *scaf.Test) protocol.DocumentSymbol {
 		Kind:           protocol.SymbolKindMethod,
 		Range:          spanToRange(test.Span()),
 		SelectionRange: testNameRange(test),
-		Detail:         "test",
+		Detail:         skipDetail("test", &test.DirectiveMeta),
 	}
 
 	var children []protocol.DocumentSymbol
 
 	// Add setup if present
 	if test.Setup != nil {
-		children = append(children, s.buildSetupSymbol(test.Setup, "setup"))
+		children = append(children, s.buildSetupSymbol(test.Setup, test.Tokens))
 	}
 
 	// Add assertions as children
-	for i, assert := range test.Asserts {
-		name := "assert"
-		if assert.Query != nil && assert.Query.QueryName != nil {
-			name = "assert " + *assert.Query.QueryName
-		} else if i > 0 {
-			name = "assert " + string(rune('1'+i))
-		}
-		children = append(children, protocol.DocumentSymbol{
-			Name:           name,
-			Kind:           protocol.SymbolKindEvent,
-			Range:          spanToRange(assert.Span()),
-			SelectionRange: spanToRange(assert.Span()),
-			Detail:         "assertion",
-		})
+	for _, assert := range test.Asserts {
+		children = append(children, s.buildAssertSymbol(assert))
 	}
 
 	sym.Children = children
 	return sym
 }
 
+// skipDetail appends a skip marker, with its reason if given, to a symbol detail.
+func skipDetail(detail string, meta *scaf.DirectiveMeta) string {
+	if !meta.HasDirective(scaf.DirectiveSkip) {
+		return detail
+	}
+
+	if reason := meta.DirectiveArg(scaf.DirectiveSkip); reason != "" {
+		return detail + " (skipped: " + reason + ")"
+	}
+
+	return detail + " (skipped)"
+}
+
 // buildGroupSymbol creates a symbol for a group with nested children.
 func (s *Server) buildGroupSymbol(group *scaf.Group) protocol.DocumentSymbol {
 	sym := protocol.DocumentSymbol{
@@ -151,20 +173,24 @@ func (s *Server) buildGroupSymbol(group *scaf.Group) protocol.DocumentSymbol {
 		Kind:           protocol.SymbolKindNamespace,
 		Range:          spanToRange(group.Span()),
 		SelectionRange: groupNameRange(group),
-		Detail:         "group",
+		Detail:         skipDetail("group", &group.DirectiveMeta),
 	}
 
 	var children []protocol.DocumentSymbol
 
-	// Add setup if present
+	// Add setup and teardown if present
 	if group.Setup != nil {
-		children = append(children, s.buildSetupSymbol(group.Setup, "setup"))
+		children = append(children, s.buildSetupSymbol(group.Setup, group.Tokens))
+	}
+
+	if sym, ok := s.buildTeardownSymbol(group.Teardown, group.Tokens, 1); ok {
+		children = append(children, sym)
 	}
 
 	// Add nested tests and groups
 	for _, item := range group.Items {
-		if item.Test != nil {
-			children = append(children, s.buildTestSymbol(item.Test))
+		for _, test := range item.Tests() {
+			children = append(children, s.buildTestSymbol(test))
 		}
 		if item.Group != nil {
 			children = append(children, s.buildGroupSymbol(item.Group))
@@ -175,28 +201,170 @@ func (s *Server) buildGroupSymbol(group *scaf.Group) protocol.DocumentSymbol {
 	return sym
 }
 
-// buildSetupSymbol creates a symbol for a setup clause.
-func (s *Server) buildSetupSymbol(setup *scaf.SetupClause, name string) protocol.DocumentSymbol {
+// buildSetupSymbol creates a symbol for a setup clause. parentTokens are the
+// tokens of the node the clause belongs to, which hold its setup keyword.
+func (s *Server) buildSetupSymbol(setup *scaf.SetupClause, parentTokens []lexer.Token) protocol.DocumentSymbol {
 	detail := "setup"
 	if setup.Module != nil {
 		detail = "setup " + *setup.Module
 	} else if setup.Call != nil {
-		detail = "setup " + setup.Call.Module + "." + setup.Call.Query
+		detail = "setup " + setup.Call.Ref()
 	} else if setup.Inline != nil {
-		detail = "inline setup"
+		detail = bodyPreview(*setup.Inline)
 	} else if len(setup.Block) > 0 {
 		detail = "setup block"
 	}
 
+	rng := spanToRange(setup.Span())
+	selection := rng
+
+	if kw, ok := keywordBefore(parentTokens, "setup", setup.Pos); ok {
+		selection = tokenRange(kw)
+		rng.Start = selection.Start
+	}
+
 	return protocol.DocumentSymbol{
-		Name:           name,
+		Name:           "setup",
 		Kind:           protocol.SymbolKindConstructor,
-		Range:          spanToRange(setup.Span()),
-		SelectionRange: spanToRange(setup.Span()),
+		Range:          rng,
+		SelectionRange: selection,
+		Detail:         detail,
+	}
+}
+
+// buildTeardownSymbol creates a symbol for a teardown, found among the tokens of
+// the node it belongs to at the given brace depth (0 for the suite, 1 for the
+// body of a scope or group). It reports false if there is no teardown.
+func (s *Server) buildTeardownSymbol(teardown *string, tokens []lexer.Token, depth int) (protocol.DocumentSymbol, bool) {
+	if teardown == nil {
+		return protocol.DocumentSymbol{}, false
+	}
+
+	level := 0
+
+	for i, tok := range tokens {
+		switch {
+		case tok.Type == scaf.TokenLBrace:
+			level++
+		case tok.Type == scaf.TokenRBrace:
+			level--
+		case level == depth && tok.Value == "teardown":
+			body, ok := nextSignificant(tokens[i+1:])
+			if !ok || body.Type != scaf.TokenRawString {
+				continue
+			}
+
+			selection := tokenRange(tok)
+
+			return protocol.DocumentSymbol{
+				Name:           "teardown",
+				Kind:           protocol.SymbolKindMethod,
+				Range:          protocol.Range{Start: selection.Start, End: rawStringEnd(body)},
+				SelectionRange: selection,
+				Detail:         bodyPreview(*teardown),
+			}, true
+		}
+	}
+
+	return protocol.DocumentSymbol{}, false
+}
+
+// buildAssertSymbol creates a symbol for an assert block, detailed with the
+// query its conditions run against.
+func (s *Server) buildAssertSymbol(assert *scaf.Assert) protocol.DocumentSymbol {
+	name := "assert"
+	detail := "main query"
+
+	switch {
+	case assert.Idempotent:
+		name = "assert idempotent"
+		detail = "reruns the main query"
+	case assert.Query != nil && assert.Query.QueryName != nil:
+		name = "assert " + *assert.Query.QueryName
+		detail = *assert.Query.QueryName + "()"
+	case assert.Query != nil && assert.Query.Inline != nil:
+		detail = bodyPreview(*assert.Query.Inline)
+	}
+
+	selection := spanToRange(assert.Span())
+	if kw, ok := nextSignificant(assert.Tokens); ok {
+		selection = tokenRange(kw)
+	}
+
+	return protocol.DocumentSymbol{
+		Name:           name,
+		Kind:           protocol.SymbolKindEvent,
+		Range:          spanToRange(assert.Span()),
+		SelectionRange: selection,
 		Detail:         detail,
 	}
 }
 
+// maxPreviewLen caps the length of a query body shown as a symbol detail.
+const maxPreviewLen = 40
+
+// bodyPreview returns a query body on one line, shortened to maxPreviewLen runes.
+func bodyPreview(body string) string {
+	preview := strings.Join(strings.Fields(body), " ")
+	if utf8.RuneCountInString(preview) <= maxPreviewLen {
+		return preview
+	}
+
+	return string([]rune(preview)[:maxPreviewLen-1]) + "…"
+}
+
+// keywordBefore returns the last token with the given value before pos.
+func keywordBefore(tokens []lexer.Token, keyword string, pos lexer.Position) (lexer.Token, bool) {
+	var found lexer.Token
+
+	ok := false
+
+	for _, tok := range tokens {
+		if tok.Pos.Offset >= pos.Offset {
+			break
+		}
+
+		if tok.Value == keyword {
+			found, ok = tok, true
+		}
+	}
+
+	return found, ok
+}
+
+// nextSignificant returns the first token that isn't whitespace or a comment.
+func nextSignificant(tokens []lexer.Token) (lexer.Token, bool) {
+	for _, tok := range tokens {
+		if tok.Type != scaf.TokenWhitespace && tok.Type != scaf.TokenComment {
+			return tok, true
+		}
+	}
+
+	return lexer.Token{}, false
+}
+
+// tokenRange returns the range a single-line token covers.
+func tokenRange(tok lexer.Token) protocol.Range {
+	end := tok.Pos
+	end.Column += utf8.RuneCountInString(tok.Value)
+
+	return spanToRange(scaf.Span{Start: tok.Pos, End: end})
+}
+
+// rawStringEnd returns the position just past a raw string's closing backtick.
+// The token's value is the body without its backticks.
+func rawStringEnd(tok lexer.Token) protocol.Position {
+	end := tok.Pos
+	if i := strings.LastIndexByte(tok.Value, '\n'); i >= 0 {
+		end.Line += strings.Count(tok.Value, "\n")
+		end.Column = 1 + utf8.RuneCountInString(tok.Value[i+1:]) + 1
+	} else {
+		end.Column += utf8.RuneCountInString(tok.Value) + 2
+	}
+
+	return spanToRange(scaf.Span{Start: end, End: end}).End
+}
+
 // scopeNameRange returns the range for the query name in a scope declaration.
 func scopeNameRange(scope *scaf.QueryScope) protocol.Range {
 	// The scope name starts at the beginning of the line
@@ -118,14 +118,14 @@ func (s *Server) extractItemSymbols(uri protocol.DocumentURI, container string,
 	var symbols []protocol.SymbolInformation
 
 	for _, item := range items {
-		if item.Test != nil {
-			if query == "" || strings.Contains(strings.ToLower(item.Test.Name), query) {
+		for _, test := range item.Tests() {
+			if query == "" || strings.Contains(strings.ToLower(test.Name), query) {
 				symbols = append(symbols, protocol.SymbolInformation{
-					Name: item.Test.Name,
+					Name: test.Name,
 					Kind: protocol.SymbolKindMethod,
 					Location: protocol.Location{
 						URI:   uri,
-						Range: spanToRange(item.Test.Span()),
+						Range: spanToRange(test.Span()),
 					},
 					ContainerName: container,
 				})
@@ -17,6 +17,10 @@ type Module struct {
 
 	// Queries maps query name to query body for fast lookup.
 	Queries map[string]string
+
+	// Exports maps each re-exported query name to the module defining it.
+	// Populated by the Resolver.
+	Exports map[string]*Module
 }
 
 // NewModule creates a Module from a parsed Suite.
@@ -25,6 +29,7 @@ func NewModule(path string, suite *scaf.Suite) *Module {
 		Path:    path,
 		Suite:   suite,
 		Queries: make(map[string]string),
+		Exports: make(map[string]*Module),
 	}
 
 	// Index queries for fast lookup
@@ -49,9 +54,29 @@ func (m *Module) GetSetup() *scaf.SetupClause {
 }
 
 // GetQuery returns a query by name, or empty string if not found.
+// Re-exported queries are looked up in their defining module.
 func (m *Module) GetQuery(name string) (string, bool) {
-	q, ok := m.Queries[name]
-	return q, ok
+	if q, ok := m.Queries[name]; ok {
+		return q, true
+	}
+
+	if def, ok := m.Exports[name]; ok {
+		q, ok := def.Queries[name]
+		return q, ok
+	}
+
+	return "", false
+}
+
+// QueryModule returns the module defining a query reachable through m:
+// m itself, or the original module of a re-exported query. Returns nil if
+// m neither defines nor re-exports name.
+func (m *Module) QueryModule(name string) *Module {
+	if _, ok := m.Queries[name]; ok {
+		return m
+	}
+
+	return m.Exports[name]
 }
 
 // BaseName returns the module name derived from the file path.
@@ -77,14 +102,43 @@ type ResolvedContext struct {
 
 	// AllModules contains all loaded modules by absolute path.
 	AllModules map[string]*Module
+
+	// Namespace maps the root's unqualified query names to their defining module:
+	// the root's own queries, then those of its unaliased imports in import order
+	// (including what they re-export). The first definition of a name wins.
+	Namespace map[string]*Module
 }
 
 // NewResolvedContext creates a new resolution context.
 func NewResolvedContext(root *Module) *ResolvedContext {
-	return &ResolvedContext{
+	ctx := &ResolvedContext{
 		Root:       root,
 		Imports:    make(map[string]*Module),
 		AllModules: map[string]*Module{root.Path: root},
+		Namespace:  make(map[string]*Module),
+	}
+
+	// The root's own re-exports are for its importers, not its namespace.
+	for name := range root.Queries {
+		ctx.Namespace[name] = root
+	}
+
+	return ctx
+}
+
+// mergeNamespace adds a module's queries and re-exports to the root namespace,
+// keeping earlier definitions.
+func (rc *ResolvedContext) mergeNamespace(mod *Module) {
+	for name := range mod.Queries {
+		if _, ok := rc.Namespace[name]; !ok {
+			rc.Namespace[name] = mod
+		}
+	}
+
+	for name, def := range mod.Exports {
+		if _, ok := rc.Namespace[name]; !ok {
+			rc.Namespace[name] = def
+		}
 	}
 }
 
@@ -102,8 +156,18 @@ func (rc *ResolvedContext) ResolveModule(alias string) (*Module, error) {
 }
 
 // ResolveQuery looks up a query by module alias and query name.
+// An empty alias resolves the name in the root namespace.
 // Returns the query body and any error.
 func (rc *ResolvedContext) ResolveQuery(moduleAlias, queryName string) (string, error) {
+	if moduleAlias == "" {
+		mod, ok := rc.Namespace[queryName]
+		if !ok {
+			return "", &ResolveError{Name: queryName, Cause: ErrUnknownQuery}
+		}
+
+		return mod.Queries[queryName], nil
+	}
+
 	mod, ok := rc.Imports[moduleAlias]
 	if !ok {
 		return "", &ResolveError{
@@ -45,7 +45,7 @@ func (r *Resolver) Resolve(rootPath string) (*ResolvedContext, error) {
 	return ctx, nil
 }
 
-// resolveImports recursively loads and resolves imports for a module.
+// resolveImports recursively loads and resolves imports and re-exports for a module.
 func (r *Resolver) resolveImports( //nolint:funcorder
 	mod *Module,
 	ctx *ResolvedContext,
@@ -55,22 +55,16 @@ func (r *Resolver) resolveImports( //nolint:funcorder
 	// Mark as currently visiting
 	visiting[mod.Path] = true
 
+	// aliases maps this module's own import aliases to their modules.
+	aliases := make(map[string]*Module)
+
 	// Process each import
 	for _, imp := range mod.Suite.Imports {
-		// Load the imported module
-		imported, err := r.loader.LoadFrom(imp.Path, mod)
+		imported, err := r.resolveDependency(mod, imp.Path, ctx, visiting, visited, path)
 		if err != nil {
 			return err
 		}
 
-		// Check for cycle
-		if visiting[imported.Path] {
-			// Found a cycle - construct the cycle path
-			cyclePath := append(path, imported.Path) //nolint:gocritic // intentional append to new slice
-
-			return &CycleError{Path: cyclePath}
-		}
-
 		// Add to context with the appropriate alias
 		var alias string
 		if imp.Alias != nil {
@@ -90,16 +84,19 @@ func (r *Resolver) resolveImports( //nolint:funcorder
 		}
 
 		ctx.Imports[alias] = imported
-		ctx.AllModules[imported.Path] = imported
+		aliases[alias] = imported
 
-		// Recursively resolve if not already visited
-		if !visited[imported.Path] {
-			newPath := append(path, imported.Path) //nolint:gocritic // intentional append to new slice
+		// The root's unaliased imports also merge their queries into its namespace
+		if imp.Alias == nil && mod == ctx.Root {
+			ctx.mergeNamespace(imported)
+		}
+	}
 
-			err := r.resolveImports(imported, ctx, visiting, visited, newPath)
-			if err != nil {
-				return err
-			}
+	// Re-exports are resolved once the modules they name are fully resolved.
+	for _, exp := range mod.Suite.Exports {
+		err := r.resolveExport(mod, exp, aliases, ctx, visiting, visited, path)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -110,6 +107,103 @@ func (r *Resolver) resolveImports( //nolint:funcorder
 	return nil
 }
 
+// resolveDependency loads a module referenced from mod by relPath and resolves
+// it recursively, reporting a CycleError if it is already being resolved.
+func (r *Resolver) resolveDependency( //nolint:funcorder
+	mod *Module,
+	relPath string,
+	ctx *ResolvedContext,
+	visiting, visited map[string]bool,
+	path []string,
+) (*Module, error) {
+	dep, err := r.loader.LoadFrom(relPath, mod)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for cycle
+	if visiting[dep.Path] {
+		// Found a cycle - construct the cycle path
+		cyclePath := append(path, dep.Path) //nolint:gocritic // intentional append to new slice
+
+		return nil, &CycleError{Path: cyclePath}
+	}
+
+	ctx.AllModules[dep.Path] = dep
+
+	// Recursively resolve if not already visited
+	if !visited[dep.Path] {
+		newPath := append(path, dep.Path) //nolint:gocritic // intentional append to new slice
+
+		err := r.resolveImports(dep, ctx, visiting, visited, newPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dep, nil
+}
+
+// resolveExport records the queries an export makes reachable through mod,
+// mapping each to the module that defines it. Re-exports of re-exports are
+// followed to the original definition. The first export of a name wins.
+func (r *Resolver) resolveExport( //nolint:funcorder
+	mod *Module,
+	exp *scaf.Export,
+	aliases map[string]*Module,
+	ctx *ResolvedContext,
+	visiting, visited map[string]bool,
+	path []string,
+) error {
+	var (
+		src   *Module
+		label string
+	)
+
+	switch {
+	case exp.Module != nil:
+		label = *exp.Module
+
+		var ok bool
+		if src, ok = aliases[label]; !ok {
+			return &ResolveError{Module: label, AvailablePath: mod.Path, Cause: ErrUnknownModule}
+		}
+	case exp.From != nil:
+		label = *exp.From
+
+		var err error
+		if src, err = r.resolveDependency(mod, label, ctx, visiting, visited, path); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	names := exp.Names
+	if exp.Module != nil {
+		for name := range src.Queries {
+			names = append(names, name)
+		}
+
+		for name := range src.Exports {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		def := src.QueryModule(name)
+		if def == nil {
+			return &ResolveError{Module: label, Name: name, AvailablePath: src.Path, Cause: ErrUnknownQuery}
+		}
+
+		if _, ok := mod.Exports[name]; !ok {
+			mod.Exports[name] = def
+		}
+	}
+
+	return nil
+}
+
 // ResolveFromSuite creates a ResolvedContext for an already-parsed suite.
 // This is useful when the suite is parsed separately (e.g., in tests).
 func (r *Resolver) ResolveFromSuite(path string, suite *scaf.Suite) (*ResolvedContext, error) {
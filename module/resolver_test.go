@@ -491,6 +491,180 @@ query Q ` + "`Q`" + `
 	t.Logf("Got expected error: %v", err)
 }
 
+func TestResolver_UnaliasedNamespace(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"db.scaf":    "query CreateUser `CREATE (:User {id: $id})`\nquery GetUser `DB`\n",
+		"other.scaf": "query CreateUser `OTHER`\n",
+		"root.scaf":  "import \"./db\"\nimport \"./other\"\nquery GetUser `ROOT`\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, err := module.NewResolver(module.NewLoader()).Resolve(filepath.Join(tmpDir, "root.scaf"))
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "merged from unaliased import", query: "CreateUser", want: "CREATE (:User {id: $id})"},
+		{name: "local query shadows import", query: "GetUser", want: "ROOT"},
+	}
+
+	for _, tt := range tests {
+		got, err := ctx.ResolveQuery("", tt.query)
+		if err != nil {
+			t.Fatalf("%s: ResolveQuery() error: %v", tt.name, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: ResolveQuery(%q) = %q, want %q", tt.name, tt.query, got, tt.want)
+		}
+	}
+
+	// Base-name references still work
+	if got, err := ctx.ResolveQuery("other", "CreateUser"); err != nil || got != "OTHER" {
+		t.Errorf("ResolveQuery(other, CreateUser) = %q, %v", got, err)
+	}
+
+	if _, err := ctx.ResolveQuery("", "Missing"); !errors.Is(err, module.ErrUnknownQuery) {
+		t.Errorf("ResolveQuery(Missing) error = %v, want %v", err, module.ErrUnknownQuery)
+	}
+}
+
+func TestResolver_ReExports(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	// hub re-exports all of fixtures (which itself re-exports seed) and one query of users.
+	files := map[string]string{
+		"seed.scaf":     "query Seed `SEED`\n",
+		"users.scaf":    "query CreateUser `CREATE (:User)`\nquery DeleteUser `DELETE`\n",
+		"fixtures.scaf": "export { Seed } from \"./seed\"\nquery CreatePost `CREATE (:Post)`\n",
+		"hub.scaf":      "import fixtures \"./fixtures\"\nexport fixtures\nexport { CreateUser } from \"./users\"\n",
+		"root.scaf":     "import hub \"./hub\"\nquery Q `Q`\n",
+		"flat.scaf":     "import \"./hub\"\nquery Q `Q`\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, err := module.NewResolver(module.NewLoader()).Resolve(filepath.Join(tmpDir, "root.scaf"))
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+		from  string
+	}{
+		{query: "CreateUser", want: "CREATE (:User)", from: "users.scaf"},
+		{query: "CreatePost", want: "CREATE (:Post)", from: "fixtures.scaf"},
+		{query: "Seed", want: "SEED", from: "seed.scaf"},
+	}
+
+	hub := ctx.Imports["hub"]
+
+	for _, tt := range tests {
+		got, err := ctx.ResolveQuery("hub", tt.query)
+		if err != nil {
+			t.Fatalf("ResolveQuery(hub, %s) error: %v", tt.query, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("ResolveQuery(hub, %s) = %q, want %q", tt.query, got, tt.want)
+		}
+
+		if def := hub.QueryModule(tt.query); def == nil || filepath.Base(def.Path) != tt.from {
+			t.Errorf("QueryModule(%s) = %v, want %s", tt.query, def, tt.from)
+		}
+	}
+
+	// Only the named queries of users are re-exported.
+	if _, err := ctx.ResolveQuery("hub", "DeleteUser"); !errors.Is(err, module.ErrUnknownQuery) {
+		t.Errorf("ResolveQuery(hub, DeleteUser) error = %v, want %v", err, module.ErrUnknownQuery)
+	}
+
+	// Unaliased importers of the hub see its re-exports unqualified.
+	flat, err := module.NewResolver(module.NewLoader()).Resolve(filepath.Join(tmpDir, "flat.scaf"))
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+
+	if got, err := flat.ResolveQuery("", "Seed"); err != nil || got != "SEED" {
+		t.Errorf("ResolveQuery(Seed) = %q, %v", got, err)
+	}
+}
+
+func TestResolver_ReExportErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  error
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"a.scaf": "export { Q } from \"./b\"\n",
+				"b.scaf": "export { Q } from \"./a\"\n",
+			},
+			want: module.ErrCyclicDependency,
+		},
+		{
+			name: "unknown query",
+			files: map[string]string{
+				"a.scaf": "export { Missing } from \"./b\"\n",
+				"b.scaf": "query Q `Q`\n",
+			},
+			want: module.ErrUnknownQuery,
+		},
+		{
+			name: "unknown module",
+			files: map[string]string{
+				"a.scaf": "export fixtures\n",
+			},
+			want: module.ErrUnknownModule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := module.NewResolver(module.NewLoader()).Resolve(filepath.Join(tmpDir, "a.scaf"))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Resolve() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
 func TestResolver_ResolveFromSuite(t *testing.T) {
 	t.Parallel()
 
@@ -1,9 +1,12 @@
 package scaf
 
 import (
+	"errors"
 	"io"
+	"strconv"
 
 	"github.com/alecthomas/participle/v2"
+	"github.com/alecthomas/participle/v2/lexer"
 )
 
 // dslLexer is the custom lexer for the scaf DSL.
@@ -20,7 +23,7 @@ var parser = participle.MustBuild[Suite](
 // This function is thread-safe.
 //
 // On parse errors, returns a partial AST containing everything successfully parsed
-// up to the error location, along with the error. Callers should use the partial
+// up to the error location, along with a *ParseError. Callers should use the partial
 // AST for features like completion and hover even when errors are present.
 func Parse(data []byte) (*Suite, error) {
 	return ParseWithRecovery(data, false)
@@ -34,12 +37,32 @@ func Parse(data []byte) (*Suite, error) {
 //
 // Recovery uses statement-boundary synchronization:
 //   - Skips to closing braces `}` (block terminators)
-//   - Skips to keywords that start new constructs: test, group, query, import, setup, teardown, assert
+//   - Skips to keywords that start new constructs: test, group, query, import, setup, teardown, assert, profile
 //   - Handles nested braces and parentheses correctly
 func ParseWithRecovery(data []byte, withRecovery bool) (*Suite, error) {
 	return parseWithOptions(data, withRecovery, nil)
 }
 
+// ParseStrict parses a scaf DSL file, rejecting any unrecognized top-level input.
+// Unlike recovery mode, which may silently skip stray tokens, strict mode reports
+// typos like "quary GetUser" as an *UnknownConstructError spanning the offending token.
+// This is intended for CI and other non-interactive tooling.
+//
+// Like Parse, the partial AST is returned alongside any error.
+func ParseStrict(data []byte) (*Suite, error) {
+	suite, err := Parse(data)
+
+	// The suite only ends when no top-level construct matches what follows;
+	// an error inside a construct leaves it without an end.
+	if err != nil && suite != nil && suite.EndPos.Line > 0 {
+		if unknownErr := unknownConstruct(data, suite.EndPos.Offset); unknownErr != nil {
+			return suite, newParseError(unknownErr)
+		}
+	}
+
+	return suite, err
+}
+
 // ParseWithRecoveryTrace is like ParseWithRecovery but writes recovery trace to w.
 // Useful for debugging recovery behavior.
 func ParseWithRecoveryTrace(data []byte, withRecovery bool, w io.Writer) (*Suite, error) {
@@ -70,6 +93,7 @@ func parseWithOptions(data []byte, withRecovery bool, traceWriter io.Writer) (*S
 					"setup",    // Setup clause
 					"teardown", // Teardown clause
 					"assert",   // Assert block
+					"profile",  // Setup profile
 				),
 				// Handle nested braces correctly so we don't sync to a } inside a nested block
 				participle.NestedDelimiters("{", "}"),
@@ -91,10 +115,16 @@ func parseWithOptions(data []byte, withRecovery bool, traceWriter io.Writer) (*S
 	// Attach comments even to partial ASTs - Participle populates as much
 	// of the AST as possible before the error location
 	if suite != nil {
+		promoteTrailingClauses(suite)
 		attachComments(suite, dslLexer.Trivia())
+		attachBodySpans(suite, data)
+
+		for _, q := range suite.Queries {
+			q.indexParamTypes()
+		}
 	}
 
-	return suite, err
+	return suite, newParseError(err)
 }
 
 // ExportedLexer returns the lexer definition for testing purposes.
@@ -103,3 +133,75 @@ func parseWithOptions(data []byte, withRecovery bool, traceWriter io.Writer) (*S
 func ExportedLexer() *dslDefinition {
 	return dslLexer
 }
+
+// UnknownConstructError reports unrecognized input at the top level of a file.
+type UnknownConstructError struct {
+	// Token is the offending token.
+	Token lexer.Token
+	// Span covers the offending token.
+	Span Span
+}
+
+func (e *UnknownConstructError) Error() string {
+	return e.Span.Start.String() + ": " + e.Message()
+}
+
+// Message returns the error message without position information.
+func (e *UnknownConstructError) Message() string {
+	return "unknown top-level construct " + strconv.Quote(e.Token.Value) +
+		" (expected meta, import, export, query, setup, teardown, profile, or a query scope)"
+}
+
+// Position returns the start position of the offending token.
+func (e *UnknownConstructError) Position() lexer.Position {
+	return e.Span.Start
+}
+
+// unknownConstruct returns an *UnknownConstructError for the first token at or
+// after offset if the grammar can't begin a top-level construct there: parsing
+// from it fails on the token itself or, for an identifier (which the grammar can
+// only take as a query scope's name), on the token after it. Otherwise it
+// returns nil, leaving the parse error to describe a malformed construct.
+func unknownConstruct(data []byte, offset int) error {
+	l := newLexerState("", string(data), nil)
+
+	first, err := nextSignificant(l)
+	for err == nil && !first.EOF() && first.Pos.Offset < offset {
+		first, err = nextSignificant(l)
+	}
+
+	if err != nil || first.EOF() {
+		return nil //nolint:nilerr // Lexer errors are reported by the parser.
+	}
+
+	end := l.pos()
+
+	second, err := nextSignificant(l)
+	if err != nil {
+		return nil //nolint:nilerr // Lexer errors are reported by the parser.
+	}
+
+	_, err = Parse(data[first.Pos.Offset:])
+
+	var unexpected *participle.UnexpectedTokenError
+	if !errors.As(err, &unexpected) {
+		return nil
+	}
+
+	at := first.Pos.Offset + unexpected.Unexpected.Pos.Offset
+	if at != first.Pos.Offset && (first.Type != TokenIdent || at != second.Pos.Offset) {
+		return nil
+	}
+
+	return &UnknownConstructError{Token: first, Span: Span{Start: first.Pos, End: end}}
+}
+
+// nextSignificant returns the next token that isn't whitespace or a comment.
+func nextSignificant(l *lexerState) (lexer.Token, error) {
+	for {
+		tok, err := l.Next()
+		if err != nil || (tok.Type != TokenWhitespace && tok.Type != TokenComment) {
+			return tok, err
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package scaf_test
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/alecthomas/participle/v2/lexer"
 	"github.com/google/go-cmp/cmp"
 	"github.com/rlch/scaf"
 )
@@ -274,6 +276,45 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "idempotent assert",
+			input: `
+				query Q ` + "`Q`" + `
+				Q {
+					test "t" {
+						assert idempotent
+						assert { c == 1 }
+					}
+				}
+			`,
+			expected: &scaf.Suite{
+				Queries: []*scaf.Query{{Name: "Q", Body: "Q"}},
+				Scopes: []*scaf.QueryScope{
+					{
+						QueryName: "Q",
+						Items: []*scaf.TestOrGroup{
+							{
+								Test: &scaf.Test{
+									Name: "t",
+									Asserts: []*scaf.Assert{
+										{Idempotent: true},
+										{
+											Conditions: []*scaf.Expr{
+												{ExprTokens: []*scaf.ExprToken{
+													{Ident: ptr("c")},
+													{Op: ptr("==")},
+													{Number: ptr("1")},
+												}},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "multiple queries and scopes",
 			input: `
@@ -441,6 +482,54 @@ func TestParseImports(t *testing.T) {
 	}
 }
 
+func TestParseExports(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []*scaf.Export
+	}{
+		{
+			name: "export module",
+			input: `
+				import fixtures "./fixtures"
+				export fixtures
+				query Q ` + "`Q`" + `
+			`,
+			expected: []*scaf.Export{
+				{Module: ptr("fixtures")},
+			},
+		},
+		{
+			name: "export names from path",
+			input: `
+				export { CreateUser, DeleteUser, } from "./users"
+				export {} from "./empty"
+			`,
+			expected: []*scaf.Export{
+				{Names: []string{"CreateUser", "DeleteUser"}, From: ptr("./users")},
+				{From: ptr("./empty")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := scaf.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, result.Exports, cmpIgnoreAST); diff != "" {
+				t.Errorf("Parse() exports mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestParseSetupCall(t *testing.T) {
 	t.Parallel()
 
@@ -485,6 +574,24 @@ func TestParseSetupCall(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "unqualified setup call",
+			input: `
+				query Q ` + "`Q`" + `
+				Q {
+					setup CreateUser($id: 1)
+					test "t" {}
+				}
+			`,
+			expected: &scaf.SetupClause{
+				Call: &scaf.SetupCall{
+					Query: "CreateUser",
+					Params: []*scaf.SetupParam{
+						{Name: "$id", Value: &scaf.ParamValue{Literal: &scaf.Value{Number: ptr(1.0)}}},
+					},
+				},
+			},
+		},
 		{
 			name: "setup module reference",
 			input: `
@@ -603,6 +710,9 @@ func TestParseValues(t *testing.T) {
 		{name: "true", input: `true`, expected: &scaf.Value{Boolean: boolPtr(true)}},
 		{name: "false", input: `false`, expected: &scaf.Value{Boolean: boolPtr(false)}},
 		{name: "null", input: `null`, expected: &scaf.Value{Null: true}},
+		{name: "absent", input: `absent`, expected: &scaf.Value{Absent: true}},
+		{name: "bytes", input: `bytes("aGVsbG8=")`, expected: &scaf.Value{Bytes: scaf.Bytes("hello")}},
+		{name: "empty bytes", input: `bytes("")`, expected: &scaf.Value{Bytes: scaf.Bytes{}}},
 		{
 			name:     "empty list",
 			input:    `[]`,
@@ -672,6 +782,7 @@ func TestValueToGo(t *testing.T) {
 		{name: "number", value: &scaf.Value{Number: ptr(42.0)}, expected: 42.0},
 		{name: "bool", value: &scaf.Value{Boolean: boolPtr(true)}, expected: true},
 		{name: "null", value: &scaf.Value{Null: true}, expected: nil},
+		{name: "bytes", value: &scaf.Value{Bytes: scaf.Bytes{0xde, 0xad}}, expected: []byte{0xde, 0xad}},
 		{name: "empty value", value: &scaf.Value{}, expected: nil},
 		{
 			name: "list",
@@ -740,6 +851,87 @@ func TestParseComments(t *testing.T) {
 	}
 }
 
+func TestParseDirectives(t *testing.T) {
+	t.Parallel()
+
+	src := `
+		// scaf:export
+		query Q ` + "`Q`" + `
+		Q {
+			// scaf:focus
+			group "g" {
+				// Not a directive
+				// scaf:skip
+				test "t" {}
+			}
+			test "plain" {}
+		}
+	`
+
+	result, err := scaf.Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	items := result.Scopes[0].Items
+	group := items[0].Group
+
+	if diff := cmp.Diff([]string{"focus"}, group.Directives); diff != "" {
+		t.Errorf("group directives mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"skip"}, group.Items[0].Test.Directives); diff != "" {
+		t.Errorf("test directives mismatch (-want +got):\n%s", diff)
+	}
+
+	if !group.Items[0].Test.HasDirective(scaf.DirectiveSkip) || group.HasDirective(scaf.DirectiveSkip) {
+		t.Error("HasDirective(skip) should be true only for the test")
+	}
+
+	if d := items[1].Test.Directives; d != nil {
+		t.Errorf("plain test directives = %v, want nil", d)
+	}
+
+	if !result.Queries[0].HasDirective(scaf.DirectiveExport) {
+		t.Error("query HasDirective(export) = false, want true")
+	}
+}
+
+func TestParseDirectiveReasons(t *testing.T) {
+	t.Parallel()
+
+	src := `
+		query Q ` + "`Q`" + `
+		Q {
+			// scaf:skip("flaky on CI")
+			test "quoted" {}
+			// scaf:skip waiting on #42
+			test "plain" {}
+			// scaf:skip
+			test "bare" {}
+		}
+	`
+
+	result, err := scaf.Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	items := result.Scopes[0].Items
+	want := []string{"flaky on CI", "waiting on #42", ""}
+
+	for i, reason := range want {
+		test := items[i].Test
+		if !test.HasDirective(scaf.DirectiveSkip) {
+			t.Errorf("%s: HasDirective(skip) = false", test.Name)
+		}
+
+		if got := test.DirectiveArg(scaf.DirectiveSkip); got != reason {
+			t.Errorf("%s: DirectiveArg(skip) = %q, want %q", test.Name, got, reason)
+		}
+	}
+}
+
 func TestValueString(t *testing.T) {
 	t.Parallel()
 
@@ -749,11 +941,13 @@ func TestValueString(t *testing.T) {
 		expected string
 	}{
 		{name: "null", value: &scaf.Value{Null: true}, expected: "null"},
+		{name: "absent", value: &scaf.Value{Absent: true}, expected: "absent"},
 		{name: "string", value: &scaf.Value{Str: ptr("hello")}, expected: `"hello"`},
 		{name: "number", value: &scaf.Value{Number: ptr(42.0)}, expected: "42"},
 		{name: "float", value: &scaf.Value{Number: ptr(3.14)}, expected: "3.14"},
 		{name: "bool true", value: &scaf.Value{Boolean: boolPtr(true)}, expected: "true"},
 		{name: "bool false", value: &scaf.Value{Boolean: boolPtr(false)}, expected: "false"},
+		{name: "bytes", value: &scaf.Value{Bytes: scaf.Bytes("hello")}, expected: `bytes("aGVsbG8=")`},
 		{name: "empty list", value: &scaf.Value{List: &scaf.List{}}, expected: "[]"},
 		{
 			name: "list",
@@ -982,39 +1176,674 @@ func TestParseQueryAssert(t *testing.T) {
 	}
 }
 
-// TODO: TestParseComputedField - ComputedFields feature removed temporarily
-// Will be re-added with proper syntax disambiguation (e.g., "mock u { field: expr }")
-
-func TestParseWithRecovery(t *testing.T) {
+func TestParseExpectedRows(t *testing.T) {
 	t.Parallel()
 
-	tests := []struct {
-		name           string
-		input          string
-		expectError    bool
-		checkAST       func(t *testing.T, suite *scaf.Suite)
-	}{
-		{
-			name: "valid input with recovery enabled",
-			input: `
-				query Q ` + "`Q`" + `
-				Q {
-					test "t" {
-						$id: 1
-					}
-				}
-			`,
-			expectError: false,
-			checkAST: func(t *testing.T, suite *scaf.Suite) {
-				if len(suite.Scopes) != 1 {
-					t.Errorf("Expected 1 scope, got %d", len(suite.Scopes))
+	input := `
+		query Q ` + "`MATCH (u:User) RETURN u.name AS name`" + `
+		Q {
+			test "all users" {
+				rows {
+					{name: "Alice"},
+					{name: "Bob", age: 30},
 				}
-				if len(suite.Scopes[0].Items) != 1 {
-					t.Errorf("Expected 1 item, got %d", len(suite.Scopes[0].Items))
+			}
+			test "rows as a field" {
+				rows: 2
+			}
+		}
+	`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	test := result.Scopes[0].Items[0].Test
+	if len(test.ExpectedRows) != 2 {
+		t.Fatalf("ExpectedRows count = %d, want 2", len(test.ExpectedRows))
+	}
+
+	got := []any{test.ExpectedRows[0], test.ExpectedRows[1]}
+	want := []any{
+		&scaf.Map{Entries: []*scaf.MapEntry{{Key: "name", Value: &scaf.Value{Str: ptr("Alice")}}}},
+		&scaf.Map{Entries: []*scaf.MapEntry{
+			{Key: "name", Value: &scaf.Value{Str: ptr("Bob")}},
+			{Key: "age", Value: &scaf.Value{Number: ptr(30.0)}},
+		}},
+	}
+
+	if diff := cmp.Diff(want, got, cmpIgnoreAST); diff != "" {
+		t.Errorf("ExpectedRows mismatch (-want +got):\n%s", diff)
+	}
+
+	// "rows" is not reserved - it still works as an ordinary statement key.
+	other := result.Scopes[0].Items[1].Test
+	if len(other.ExpectedRows) != 0 || len(other.Statements) != 1 || other.Statements[0].Key() != "rows" {
+		t.Errorf("expected a single rows statement, got %+v", other)
+	}
+}
+
+func TestParseQueryParamDefaults(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		query ListUsers($limit = 10, $active = true, $role = "admin",) ` + "`MATCH (u:User) RETURN u LIMIT $limit`" + `
+		query GetUser ` + "`MATCH (u:User) RETURN u`" + `
+	`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	want := []*scaf.ParamDefault{
+		{Name: "$limit", Value: &scaf.Value{Number: ptr(10.0)}},
+		{Name: "$active", Value: &scaf.Value{Boolean: boolPtr(true)}},
+		{Name: "$role", Value: &scaf.Value{Str: ptr("admin")}},
+	}
+
+	if diff := cmp.Diff(want, result.Queries[0].Defaults, cmpIgnoreAST); diff != "" {
+		t.Errorf("Defaults mismatch (-want +got):\n%s", diff)
+	}
+
+	defaults := result.Queries[0].ParamDefaults()
+	if len(defaults) != 3 || defaults["limit"] == nil || defaults["limit"].ToGo() != 10.0 {
+		t.Errorf("ParamDefaults() = %v, want limit=10 keyed without $", defaults)
+	}
+
+	if got := result.Queries[1].ParamDefaults(); got != nil {
+		t.Errorf("ParamDefaults() without defaults = %v, want nil", got)
+	}
+
+	if _, err := scaf.ParseStrict([]byte(input)); err != nil {
+		t.Errorf("ParseStrict() error: %v", err)
+	}
+}
+
+func TestParseProfiles(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		query GetUser ` + "`MATCH (u:User) RETURN u`" + `
+		query profile ` + "`MATCH (p:Profile) RETURN p`" + `
+
+		profile Base {
+			setup fixtures.SetupUsers()
+			teardown ` + "`MATCH (n) DETACH DELETE n`" + `
+		}
+
+		GetUser extends Base {
+			setup ` + "`CREATE (:Post)`" + `
+			test "t" {}
+		}
+
+		profile {
+			test "t" {}
+		}
+	`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if len(result.Profiles) != 1 {
+		t.Fatalf("Profiles count = %d, want 1", len(result.Profiles))
+	}
+
+	base := result.Profile("Base")
+	if base == nil || base.Setup == nil || base.Setup.Call == nil || base.Teardown == nil {
+		t.Fatalf("Profile(Base) = %+v, want setup call and teardown", base)
+	}
+
+	if got := base.Setup.Call.Module + "." + base.Setup.Call.Query; got != "fixtures.SetupUsers" {
+		t.Errorf("profile setup = %q, want fixtures.SetupUsers", got)
+	}
+
+	if len(result.Scopes) != 2 {
+		t.Fatalf("Scopes count = %d, want 2", len(result.Scopes))
+	}
+
+	scope := result.Scopes[0]
+	if scope.QueryName != "GetUser" || scope.Extends == nil || *scope.Extends != "Base" {
+		t.Errorf("scope = %q extends %v, want GetUser extends Base", scope.QueryName, scope.Extends)
+	}
+
+	// "profile" is not reserved - it still works as a query scope name.
+	if plain := result.Scopes[1]; plain.QueryName != "profile" || plain.Extends != nil {
+		t.Errorf("scope = %q extends %v, want plain profile scope", plain.QueryName, plain.Extends)
+	}
+
+	if result.Profile("Missing") != nil {
+		t.Error("Profile(Missing) should be nil")
+	}
+}
+
+func TestParseSequence(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		query Q ` + "`Q`" + `
+
+		Q {
+			sequence {
+				test "create" { $id: 1 }
+				test "read" { u.id: 1 }
+			}
+			group "g" {
+				sequence {}
+			}
+		}
+	`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	items := result.Scopes[0].Items
+	if len(items) != 2 || items[0].Sequence == nil || items[0].Group != nil {
+		t.Fatalf("Items = %+v, want a sequence then a group", items)
+	}
+
+	var names []string
+	for _, test := range items[0].Tests() {
+		names = append(names, test.Name)
+	}
+
+	if !cmp.Equal(names, []string{"create", "read"}) {
+		t.Errorf("sequence tests = %v, want [create read]", names)
+	}
+
+	// Sequence tests are addressed as if written in the enclosing scope.
+	if _, _, test := result.TestByPath([]string{"Q", "read"}); test == nil {
+		t.Error("TestByPath(Q/read) = nil, want the sequence test")
+	}
+
+	if nested := items[1].Group.Items; len(nested) != 1 || nested[0].Sequence == nil {
+		t.Errorf("group items = %+v, want an empty sequence", nested)
+	}
+}
+
+func TestParseExpect(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		query Q ` + "`Q`" + `
+
+		Q {
+			test "t" {
+				$id: 1
+				expect: "a statement"
+				expect {
+					name: "Alice",
+					tags: ["a", "b"]
 				}
-			},
-		},
-		// Note: Recovery only works for parse errors, not lexer errors.
+				assert { name != "" }
+			}
+		}
+	`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	test := result.Scopes[0].Items[0].Test
+
+	// "expect" is not reserved - it still works as a statement key.
+	if len(test.Statements) != 2 || test.Statements[1].Key() != "expect" {
+		t.Errorf("Statements = %v, want $id and expect", test.Statements)
+	}
+
+	if test.Expect == nil {
+		t.Fatal("Expect = nil, want a map")
+	}
+
+	want := map[string]any{"name": "Alice", "tags": []any{"a", "b"}}
+	if got := (&scaf.Value{Map: test.Expect}).ToGo(); !cmp.Equal(got, want) {
+		t.Errorf("Expect = %v, want %v", got, want)
+	}
+
+	if len(test.Asserts) != 1 {
+		t.Errorf("Asserts count = %d, want 1", len(test.Asserts))
+	}
+}
+
+func TestParsePositionalColumn(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		query Q ` + "`MATCH (u:User) RETURN count(*)`" + `
+
+		Q {
+			test "t" {
+				result[0]: 5
+				result: "a field"
+				result.count: 1
+			}
+		}
+	`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	stmts := result.Scopes[0].Items[0].Test.Statements
+	if len(stmts) != 3 {
+		t.Fatalf("Statements count = %d, want 3", len(stmts))
+	}
+
+	if stmts[0].Column == nil || *stmts[0].Column != 0 || stmts[0].KeyParts != nil || stmts[0].Key() != "result[0]" {
+		t.Errorf("positional statement = %+v, want column 0", stmts[0])
+	}
+
+	// "result" is not reserved - without an index it is an ordinary key.
+	for i, want := range []string{"result", "result.count"} {
+		if stmt := stmts[i+1]; stmt.Column != nil || stmt.Key() != want {
+			t.Errorf("statement %d = %+v, want key %q", i+1, stmt, want)
+		}
+	}
+
+	if _, err := scaf.Parse([]byte("query Q `Q`\nQ { test \"t\" { result[1.5]: 1 } }")); err == nil {
+		t.Error("Parse(result[1.5]) succeeded, want an error")
+	}
+
+	for key, want := range map[string]int{"result[0]": 0, "result[12]": 12, "result[-1]": -1, "result[01]": -1, "result": -1, "count(*)": -1} {
+		got, ok := scaf.ParseColumnKey(key)
+		if !ok {
+			got = -1
+		}
+
+		if got != want {
+			t.Errorf("ParseColumnKey(%q) = %d, want %d", key, got, want)
+		}
+	}
+}
+
+func TestParseShebang(t *testing.T) {
+	t.Parallel()
+
+	input := "#!scaf dialect=sql\nquery GetUser `SELECT 1`\n\nGetUser {\n\ttest \"t\" {}\n}\n"
+
+	result, err := scaf.ParseStrict([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseStrict() error: %v", err)
+	}
+
+	if result.Shebang != "#!scaf dialect=sql" {
+		t.Errorf("Shebang = %q, want %q", result.Shebang, "#!scaf dialect=sql")
+	}
+
+	if got := result.Dialect(); got != "sql" {
+		t.Errorf("Dialect() = %q, want sql", got)
+	}
+
+	if len(result.Queries) != 1 || len(result.Scopes) != 1 {
+		t.Errorf("Queries = %d, Scopes = %d, want 1 each", len(result.Queries), len(result.Scopes))
+	}
+
+	// "#!" only starts a shebang on the first line.
+	if _, err := scaf.Parse([]byte("query Q `Q`\n#!scaf\n")); err == nil {
+		t.Error("Expected an error for a shebang after the first line")
+	}
+
+	plain, err := scaf.Parse([]byte("#!/usr/bin/env scaf\nquery Q `Q`"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if got := plain.Dialect(); got != "" {
+		t.Errorf("Dialect() = %q for a non-scaf shebang, want empty", got)
+	}
+}
+
+func TestParseMeta(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		meta {owner: "team-x", jira: "ABC-123", tags: ["slow"]}
+
+		import fixtures "./fixtures"
+
+		query meta ` + "`MATCH (n) RETURN n`" + `
+
+		meta {
+			test "t" {}
+		}
+	`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	want := map[string]any{"owner": "team-x", "jira": "ABC-123", "tags": []any{"slow"}}
+	if got := result.Metadata(); !cmp.Equal(got, want) {
+		t.Errorf("Metadata() = %v, want %v", got, want)
+	}
+
+	if len(result.Imports) != 1 {
+		t.Errorf("Imports count = %d, want 1", len(result.Imports))
+	}
+
+	// "meta" is not reserved - it still works as a query scope name.
+	if len(result.Scopes) != 1 || result.Scopes[0].QueryName != "meta" {
+		t.Errorf("Scopes = %+v, want a meta scope", result.Scopes)
+	}
+
+	plain, err := scaf.Parse([]byte("query Q `Q`"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if plain.Meta != nil || plain.Metadata() != nil {
+		t.Errorf("Meta = %v, want none", plain.Meta)
+	}
+}
+
+func TestSuiteTestByPath(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		query Q ` + "`Q`" + `
+		Q {
+			test "top" {}
+			group "outer" {
+				group "inner" {
+					test "deep" {}
+				}
+			}
+		}
+	`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	scope, groups, test := suite.TestByPath([]string{"Q", "outer", "inner", "deep"})
+	if test == nil || test.Name != "deep" || scope.QueryName != "Q" {
+		t.Fatalf("TestByPath() = %v, %v, want Q/outer/inner/deep", scope, test)
+	}
+
+	if len(groups) != 2 || groups[0].Name != "outer" || groups[1].Name != "inner" {
+		t.Errorf("TestByPath() groups = %v, want [outer inner]", groups)
+	}
+
+	if _, groups, test := suite.TestByPath([]string{"Q", "top"}); test == nil || len(groups) != 0 {
+		t.Errorf("TestByPath(Q/top) = %v, %v, want top-level test", groups, test)
+	}
+
+	for _, path := range [][]string{{"Q"}, {"Q", "outer"}, {"Q", "missing"}, {"Other", "top"}} {
+		if _, _, test := suite.TestByPath(path); test != nil {
+			t.Errorf("TestByPath(%v) = %v, want nil", path, test)
+		}
+	}
+}
+
+func TestSuitePaths(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		query Q ` + "`Q`" + `
+		query R ` + "`R`" + `
+		Q {
+			test "top" {}
+			group "outer" {
+				group "inner" {
+					test "deep" {}
+				}
+				test "mid" {}
+			}
+		}
+		R {}
+	`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	want := []string{"Q", "Q/top", "Q/outer", "Q/outer/inner", "Q/outer/inner/deep", "Q/outer/mid", "R"}
+	if diff := cmp.Diff(want, suite.Paths()); diff != "" {
+		t.Errorf("Paths() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseBodySpans(t *testing.T) {
+	t.Parallel()
+
+	input := "query Q `\n  MATCH (n)\n  RETURN n\n`\n" +
+		"Q {\n" +
+		"\tsetup { `CREATE (:A)` }\n" +
+		"\tteardown `MATCH (n) DELETE n`\n" +
+		"\ttest \"t\" {\n" +
+		"\t\tassert `RETURN 1 AS x` { x == 1 }\n" +
+		"\t}\n" +
+		"}\n"
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	body := suite.Queries[0].BodySpan
+	wantStart := lexer.Position{Offset: 9, Line: 1, Column: 10}
+	wantEnd := lexer.Position{Offset: 33, Line: 4, Column: 1}
+
+	if body.Start != wantStart || body.End != wantEnd {
+		t.Errorf("Query BodySpan = %v-%v, want %v-%v", body.Start, body.End, wantStart, wantEnd)
+	}
+
+	if got := input[body.Start.Offset:body.End.Offset]; got != suite.Queries[0].Body {
+		t.Errorf("Query body slice = %q, want %q", got, suite.Queries[0].Body)
+	}
+
+	setupItem := suite.Scopes[0].Setup.Block[0]
+	if got := input[setupItem.BodySpan.Start.Offset:setupItem.BodySpan.End.Offset]; got != "CREATE (:A)" {
+		t.Errorf("setup item body slice = %q", got)
+	}
+
+	assertQuery := suite.Scopes[0].Items[0].Test.Asserts[0].Query
+	if got := assertQuery.BodySpan.Start; got.Line != 9 || got.Column != 11 {
+		t.Errorf("assert BodySpan start = %v, want 9:11", got)
+	}
+
+	// Teardowns have no node, so they only appear in the suite-wide list.
+	if len(suite.BodySpans) != 4 {
+		t.Fatalf("BodySpans = %d, want 4", len(suite.BodySpans))
+	}
+
+	teardown := suite.BodySpans[2]
+	if got := input[teardown.Start.Offset:teardown.End.Offset]; got != "MATCH (n) DELETE n" {
+		t.Errorf("teardown body slice = %q", got)
+	}
+
+	if !teardown.ContainsOffset(teardown.End.Offset) || teardown.ContainsOffset(teardown.Start.Offset-1) {
+		t.Error("ContainsOffset should include the end and exclude the opening backtick")
+	}
+}
+
+func TestParseDataTable(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		import data "./data"
+		query Q ` + "`Q`" + `
+		Q {
+			setup {
+				data User { id, name | 1, "Alice" | 2, null }
+				data
+			}
+			test "t" {}
+		}
+	`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	block := suite.Scopes[0].Setup.Block
+	if len(block) != 2 {
+		t.Fatalf("setup block items = %d, want 2", len(block))
+	}
+
+	table := block[0].Data
+	if table == nil || table.Label != "User" {
+		t.Fatalf("block[0].Data = %+v, want data User", table)
+	}
+
+	want := []map[string]any{
+		{"id": 1.0, "name": "Alice"},
+		{"id": 2.0, "name": nil},
+	}
+	if diff := cmp.Diff(want, table.Bindings()); diff != "" {
+		t.Errorf("Bindings() mismatch (-want +got):\n%s", diff)
+	}
+
+	// A module alias named "data" is still a module setup.
+	if block[1].Module == nil || *block[1].Module != "data" {
+		t.Errorf("block[1] = %+v, want module data", block[1])
+	}
+}
+
+func TestParseSetupBindings(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		import fixtures "./fixtures"
+		query Q ` + "`Q`" + `
+		setup all = ` + "`MATCH (n) RETURN n`" + `
+		Q {
+			setup {
+				fixtures
+				users = data User { id | 1 | 2 }
+				posts = fixtures.CreatePosts($n: 2)
+			}
+			test "t" {
+				setup fixtures.Seed()
+			}
+		}
+	`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if diff := cmp.Diff(ptr("all"), suite.Setup.Binding); diff != "" {
+		t.Errorf("suite setup binding mismatch (-want +got):\n%s", diff)
+	}
+
+	var bindings []*string
+	for _, item := range suite.Scopes[0].Setup.Block {
+		bindings = append(bindings, item.Binding)
+	}
+
+	if diff := cmp.Diff([]*string{nil, ptr("users"), ptr("posts")}, bindings); diff != "" {
+		t.Errorf("block bindings mismatch (-want +got):\n%s", diff)
+	}
+
+	if setup := suite.Scopes[0].Items[0].Test.Setup; setup.Binding != nil || setup.Call == nil {
+		t.Errorf("test setup = %+v, want an unbound call", setup)
+	}
+}
+
+func TestParseComputedValues(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		query Q ` + "`Q`" + `
+		Q {
+			test "t" {
+				count: len(users)
+				most: max(len(users), len(admins), 2)
+				total: int(len(users))
+				b: bytes("aGk=")
+			}
+		}
+	`
+
+	suite, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	stmts := suite.Scopes[0].Items[0].Test.Statements
+
+	tests := []struct {
+		key  string
+		want string
+		refs []string
+	}{
+		{key: "count", want: "len(users)", refs: []string{"users"}},
+		{key: "most", want: "max(len(users), len(admins), 2)", refs: []string{"users", "admins"}},
+		{key: "total", want: "int(len(users))", refs: []string{"users"}},
+	}
+
+	for i, tt := range tests {
+		c := stmts[i].Value.Computed
+		if c == nil {
+			t.Fatalf("%s: Computed is nil, got %s", tt.key, stmts[i].Value)
+		}
+
+		if got := c.String(); got != tt.want {
+			t.Errorf("%s: String() = %q, want %q", tt.key, got, tt.want)
+		}
+
+		var refs []string
+		for _, ref := range c.Refs() {
+			refs = append(refs, ref.String())
+		}
+
+		if diff := cmp.Diff(tt.refs, refs); diff != "" {
+			t.Errorf("%s: Refs() mismatch (-want +got):\n%s", tt.key, diff)
+		}
+	}
+
+	// bytes(...) stays a bytes literal.
+	if v := stmts[3].Value; v.Computed != nil || v.Bytes == nil {
+		t.Errorf("b = %+v, want a bytes literal", v)
+	}
+}
+
+// TODO: TestParseComputedField - ComputedFields feature removed temporarily
+// Will be re-added with proper syntax disambiguation (e.g., "mock u { field: expr }")
+
+func TestParseWithRecovery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		input          string
+		expectError    bool
+		checkAST       func(t *testing.T, suite *scaf.Suite)
+	}{
+		{
+			name: "valid input with recovery enabled",
+			input: `
+				query Q ` + "`Q`" + `
+				Q {
+					test "t" {
+						$id: 1
+					}
+				}
+			`,
+			expectError: false,
+			checkAST: func(t *testing.T, suite *scaf.Suite) {
+				if len(suite.Scopes) != 1 {
+					t.Errorf("Expected 1 scope, got %d", len(suite.Scopes))
+				}
+				if len(suite.Scopes[0].Items) != 1 {
+					t.Errorf("Expected 1 item, got %d", len(suite.Scopes[0].Items))
+				}
+			},
+		},
+		// Note: Recovery only works for parse errors, not lexer errors.
 		// Unterminated strings cause lexer errors before parsing begins.
 		// Test cases below use syntactically invalid (but lexically valid) input.
 		{
@@ -1336,6 +2165,199 @@ func TestParseWithRecovery(t *testing.T) {
 	}
 }
 
+func TestParseStrict(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid input", func(t *testing.T) {
+		t.Parallel()
+
+		input := `
+import fixtures "./fixtures"
+import "./other"
+
+query GetUser ` + "`MATCH (u:User {id: $id}) RETURN u`" + `
+
+setup fixtures.CreateUser($id: 1)
+teardown ` + "`MATCH (n) DETACH DELETE n`" + `
+
+GetUser {
+	setup {
+		fixtures
+		fixtures.Seed()
+	}
+	test "t" {
+		$id: 1
+	}
+}
+`
+
+		suite, err := scaf.ParseStrict([]byte(input))
+		if err != nil {
+			t.Fatalf("ParseStrict() error: %v", err)
+		}
+
+		if len(suite.Scopes) != 1 {
+			t.Errorf("Expected 1 scope, got %d", len(suite.Scopes))
+		}
+	})
+
+	t.Run("exports", func(t *testing.T) {
+		t.Parallel()
+
+		input := "import fixtures \"./fixtures\"\nexport fixtures\nexport { CreateUser } from \"./users\"\n\nquery Q `Q`\n"
+
+		suite, err := scaf.ParseStrict([]byte(input))
+		if err != nil {
+			t.Fatalf("ParseStrict() error: %v", err)
+		}
+
+		if len(suite.Exports) != 2 {
+			t.Errorf("Expected 2 exports, got %d", len(suite.Exports))
+		}
+	})
+
+	t.Run("profiles", func(t *testing.T) {
+		t.Parallel()
+
+		input := "query Q `Q`\n\nprofile Base {\n\tsetup `CREATE (:User)`\n}\n\nQ extends Base {\n\ttest \"t\" {}\n}\n"
+
+		suite, err := scaf.ParseStrict([]byte(input))
+		if err != nil {
+			t.Fatalf("ParseStrict() error: %v", err)
+		}
+
+		if len(suite.Profiles) != 1 || len(suite.Scopes) != 1 {
+			t.Errorf("Expected 1 profile and 1 scope, got %d and %d", len(suite.Profiles), len(suite.Scopes))
+		}
+	})
+
+	t.Run("misspelled keyword", func(t *testing.T) {
+		t.Parallel()
+
+		input := "query Q `Q`\nquary GetUser `MATCH (u) RETURN u`\n\nQ {\n\ttest \"t\" {}\n}\n"
+
+		_, err := scaf.ParseStrict([]byte(input))
+
+		var unknownErr *scaf.UnknownConstructError
+		if !errors.As(err, &unknownErr) {
+			t.Fatalf("Expected UnknownConstructError, got %v", err)
+		}
+
+		if unknownErr.Token.Value != "quary" {
+			t.Errorf("Token = %q, want %q", unknownErr.Token.Value, "quary")
+		}
+
+		if unknownErr.Span.Start.Line != 2 || unknownErr.Span.Start.Column != 1 || unknownErr.Span.End.Column != 6 {
+			t.Errorf("Span = %v-%v, want 2:1-2:6", unknownErr.Span.Start, unknownErr.Span.End)
+		}
+
+		// Recovery mode still produces a partial AST for the same input.
+		suite, err := scaf.ParseWithRecovery([]byte(input), true)
+		if err == nil {
+			t.Error("Expected recovery error, got nil")
+		}
+
+		if suite == nil || len(suite.Queries) != 1 {
+			t.Fatalf("Expected partial AST with 1 query, got %+v", suite)
+		}
+	})
+
+	t.Run("stray trailing token", func(t *testing.T) {
+		t.Parallel()
+
+		input := "query Q `Q`\n\nQ {\n\ttest \"t\" {}\n}\n}\n"
+
+		_, err := scaf.ParseStrict([]byte(input))
+
+		var unknownErr *scaf.UnknownConstructError
+		if !errors.As(err, &unknownErr) {
+			t.Fatalf("Expected UnknownConstructError, got %v", err)
+		}
+	})
+
+	t.Run("malformed known construct", func(t *testing.T) {
+		t.Parallel()
+
+		// A misplaced or incomplete construct keeps the parser's error.
+		for _, input := range []string{
+			"query Q `Q`\nsetup `S`\nquery R `R`\n",
+			"query Q `Q`\nQ extends {\n}\n",
+			"query 42\n",
+			"query Q `Q`\nQ {\n\ttest \"t\" {\n\t\t$id 1\n\t}\n}\n",
+		} {
+			_, err := scaf.ParseStrict([]byte(input))
+
+			var unknownErr *scaf.UnknownConstructError
+			if err == nil || errors.As(err, &unknownErr) {
+				t.Errorf("ParseStrict(%q) error = %v, want a plain parse error", input, err)
+			}
+		}
+	})
+}
+
+func TestParseError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		parse   func([]byte) (*scaf.Suite, error)
+		input   string
+		line    int
+		column  int
+		message string
+	}{
+		{
+			name:    "syntax error",
+			parse:   scaf.Parse,
+			input:   "query Q `Q`\nQ {\n\ttest \"t\" {\n\t\t$id 1\n\t}\n}\n",
+			line:    4,
+			column:  3,
+			message: `unexpected token "$id" (expected "}")`,
+		},
+		{
+			name:    "lexer error",
+			parse:   scaf.Parse,
+			input:   "query Q `unterminated",
+			line:    1,
+			column:  9,
+			message: "unterminated raw string",
+		},
+		{
+			name:    "strict mode",
+			parse:   scaf.ParseStrict,
+			input:   "query Q `Q`\nquary GetUser `Q`\n",
+			line:    2,
+			column:  1,
+			message: `unknown top-level construct "quary" (expected meta, import, export, query, setup, teardown, profile, or a query scope)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tt.parse([]byte(tt.input))
+
+			var parseErr *scaf.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected *scaf.ParseError, got %T: %v", err, err)
+			}
+
+			if parseErr.Span.Start.Line != tt.line || parseErr.Span.Start.Column != tt.column {
+				t.Errorf("Span.Start = %v, want %d:%d", parseErr.Span.Start, tt.line, tt.column)
+			}
+
+			if parseErr.Message != tt.message {
+				t.Errorf("Message = %q, want %q", parseErr.Message, tt.message)
+			}
+
+			if want := parseErr.Span.Start.String() + ": " + tt.message; err.Error() != want {
+				t.Errorf("Error() = %q, want %q", err.Error(), want)
+			}
+		})
+	}
+}
+
 func TestIsComplete(t *testing.T) {
 	t.Parallel()
 
@@ -1489,3 +2511,37 @@ func TestIsComplete(t *testing.T) {
 		})
 	}
 }
+
+func TestParseQueryParamTypes(t *testing.T) {
+	t.Parallel()
+
+	input := `
+		query GetUser($id: int, $tags: []string, $limit: int = 10, $active = true) ` + "`MATCH (u:User) RETURN u`" + `
+	`
+
+	result, err := scaf.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	want := []*scaf.ParamDefault{
+		{Name: "$id", Type: ptr("int")},
+		{Name: "$tags", Type: ptr("[]string")},
+		{Name: "$limit", Type: ptr("int"), Value: &scaf.Value{Number: ptr(10.0)}},
+		{Name: "$active", Value: &scaf.Value{Boolean: boolPtr(true)}},
+	}
+
+	q := result.Queries[0]
+	if diff := cmp.Diff(want, q.Defaults, cmpIgnoreAST); diff != "" {
+		t.Errorf("Defaults mismatch (-want +got):\n%s", diff)
+	}
+
+	wantTypes := map[string]string{"id": "int", "tags": "[]string", "limit": "int"}
+	if diff := cmp.Diff(wantTypes, q.ParamTypes); diff != "" {
+		t.Errorf("ParamTypes mismatch (-want +got):\n%s", diff)
+	}
+
+	if defaults := q.ParamDefaults(); len(defaults) != 2 || defaults["limit"] == nil {
+		t.Errorf("ParamDefaults() = %v, want limit and active", defaults)
+	}
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// BenchStats summarizes the latency of a test's main query across benchmark iterations.
+type BenchStats struct {
+	Iterations int
+	Min        time.Duration
+	Median     time.Duration
+	P95        time.Duration
+	Max        time.Duration
+}
+
+// WithBench enables benchmark mode: after a test passes, its main query is run
+// warmup times untimed and then iterations times timed, with the same parameters.
+// Setup runs once and assertions are evaluated once, against the first execution;
+// only the query is measured. A non-positive iterations disables benchmarking.
+func WithBench(warmup, iterations int) Option {
+	return func(r *Runner) {
+		r.benchWarmup = max(warmup, 0)
+		r.benchIterations = max(iterations, 0)
+	}
+}
+
+// benchQuery runs the query for the configured warmup and iterations and
+// returns its latency stats, or nil if benchmarking is disabled.
+func (r *Runner) benchQuery(ctx context.Context, exec executor, query string, params map[string]any) (*BenchStats, error) {
+	if r.benchIterations == 0 {
+		return nil, nil //nolint:nilnil // nil stats means benchmarking is off
+	}
+
+	for range r.benchWarmup {
+		if _, err := exec.Execute(ctx, query, params); err != nil {
+			return nil, err
+		}
+	}
+
+	samples := make([]time.Duration, r.benchIterations)
+
+	for i := range samples {
+		start := time.Now()
+
+		if _, err := exec.Execute(ctx, query, params); err != nil {
+			return nil, err
+		}
+
+		samples[i] = time.Since(start)
+	}
+
+	return newBenchStats(samples), nil
+}
+
+// newBenchStats computes latency stats from non-empty samples, using the
+// nearest-rank method for percentiles.
+func newBenchStats(samples []time.Duration) *BenchStats {
+	slices.Sort(samples)
+
+	return &BenchStats{
+		Iterations: len(samples),
+		Min:        samples[0],
+		Median:     percentile(samples, 50),
+		P95:        percentile(samples, 95),
+		Max:        samples[len(samples)-1],
+	}
+}
+
+// percentile returns the p-th percentile of sorted samples by nearest rank.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+
+	return sorted[max(rank, 1)-1]
+}
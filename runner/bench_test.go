@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rlch/scaf"
+)
+
+// timedDatabase sleeps one millisecond longer on each call.
+type timedDatabase struct {
+	calls int
+}
+
+func (d *timedDatabase) Name() string          { return "timed" }
+func (d *timedDatabase) Dialect() scaf.Dialect { return nil }
+func (d *timedDatabase) Close() error          { return nil }
+
+func (d *timedDatabase) Execute(context.Context, string, map[string]any) ([]map[string]any, error) {
+	d.calls++
+	time.Sleep(time.Duration(d.calls) * time.Millisecond)
+
+	return []map[string]any{{"n": int64(1)}}, nil
+}
+
+func TestRunner_Bench(t *testing.T) {
+	db := &timedDatabase{}
+	r := New(WithDatabase(db), WithBench(2, 10))
+
+	suite := &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "Q", Body: "RETURN 1 AS n"}},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "Q",
+			Items: []*scaf.TestOrGroup{{Test: &scaf.Test{
+				Name: "t",
+				Statements: []*scaf.Statement{
+					{KeyParts: &scaf.DottedIdent{Parts: []string{"n"}}, Value: &scaf.Value{Number: ptr(1.0)}},
+				},
+			}}},
+		}},
+	}
+
+	result, err := r.Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One measured test run, two warmup runs, ten timed runs.
+	if db.calls != 13 {
+		t.Errorf("query ran %d times, want 13", db.calls)
+	}
+
+	tr := result.Tests["Q/t"]
+	if tr == nil || tr.Status != ActionPass {
+		t.Fatalf("test result = %+v, want pass", tr)
+	}
+
+	b := tr.Bench
+	if b == nil {
+		t.Fatal("Bench is nil")
+	}
+
+	if b.Iterations != 10 {
+		t.Errorf("Iterations = %d, want 10", b.Iterations)
+	}
+
+	// Timed calls sleep 4ms through 13ms.
+	if b.Min < 4*time.Millisecond || b.Max < 13*time.Millisecond {
+		t.Errorf("Min = %s, Max = %s, want at least 4ms and 13ms", b.Min, b.Max)
+	}
+
+	if b.Min > b.Median || b.Median > b.P95 || b.P95 > b.Max {
+		t.Errorf("stats out of order: min %s, median %s, p95 %s, max %s", b.Min, b.Median, b.P95, b.Max)
+	}
+}
+
+func TestRunner_BenchDisabled(t *testing.T) {
+	r := New(WithDatabase(&mockDatabase{}))
+
+	stats, err := r.benchQuery(context.Background(), &mockDatabase{}, "Q", nil)
+	if err != nil || stats != nil {
+		t.Errorf("benchQuery() = %v, %v; want nil, nil", stats, err)
+	}
+}
+
+func TestNewBenchStats(t *testing.T) {
+	samples := make([]time.Duration, 20)
+	for i := range samples {
+		samples[i] = time.Duration(20-i) * time.Millisecond
+	}
+
+	got := newBenchStats(samples)
+	want := BenchStats{
+		Iterations: 20,
+		Min:        time.Millisecond,
+		Median:     10 * time.Millisecond,
+		P95:        19 * time.Millisecond,
+		Max:        20 * time.Millisecond,
+	}
+
+	if *got != want {
+		t.Errorf("newBenchStats() = %+v, want %+v", *got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/expr"
+)
+
+// bindings maps setup binding names to the rows they bound. Each scope level
+// (suite, query scope, group, test) works on a copy of its parent's, so inner
+// bindings shadow outer ones without leaking to sibling scopes.
+type bindings map[string]any
+
+// child returns a copy of b for a nested scope.
+func (b bindings) child() bindings {
+	c := maps.Clone(b)
+	if c == nil {
+		c = make(bindings)
+	}
+
+	return c
+}
+
+// bind records rows under a setup's binding name, if it has one.
+func (b bindings) bind(name *string, rows []map[string]any) {
+	if name == nil {
+		return
+	}
+
+	if rows == nil {
+		rows = []map[string]any{}
+	}
+
+	b[*name] = rows
+}
+
+// evalComputed evaluates a computed expected value against the bindings in scope.
+func evalComputed(c *scaf.Computed, binds bindings) (any, error) {
+	out, err := expr.EvalString(c.String(), binds)
+	if err != nil {
+		return nil, fmt.Errorf("computed value: %w", err)
+	}
+
+	// Numbers compare like parsed literals.
+	if n, ok := out.(int); ok {
+		return float64(n), nil
+	}
+
+	return out, nil
+}
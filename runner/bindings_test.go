@@ -0,0 +1,151 @@
+package runner //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rlch/scaf"
+)
+
+func TestRunner_ComputedExpectations(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query CountUsers ` + "`COUNT`" + `
+
+setup {
+	users = data User { id | 1 | 2 }
+}
+
+CountUsers {
+	test "matches suite binding" {
+		count: len(users)
+	}
+
+	test "undefined binding" {
+		count: len(admins)
+	}
+
+	group "shadowed" {
+		setup {
+			users = data User { id | 1 }
+		}
+
+		test "sees inner binding" {
+			count: len(users)
+		}
+	}
+
+	test "test setup binding" {
+		setup {
+			admins = data Admin { id | 1 | 2 }
+		}
+		count: len(admins)
+	}
+
+	test "sibling binding is out of scope" {
+		count: len(admins)
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &mockDatabase{
+		dialect: bulkDialect{},
+		results: []map[string]any{{"count": int64(2)}},
+	}
+
+	result, err := New(WithDatabase(d)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Action{
+		"CountUsers/matches suite binding":           ActionPass,
+		"CountUsers/undefined binding":               ActionError,
+		"CountUsers/shadowed/sees inner binding":     ActionFail,
+		"CountUsers/test setup binding":              ActionPass,
+		"CountUsers/sibling binding is out of scope": ActionError,
+	}
+
+	for path, action := range want {
+		if tr := result.Tests[path]; tr == nil || tr.Status != action {
+			t.Errorf("%s = %+v, want %s", path, tr, action)
+		}
+	}
+}
+
+func TestRunner_BoundQuerySetup(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query CountUsers ` + "`COUNT`" + `
+
+CountUsers {
+	setup users = ` + "`MATCH (u:User) RETURN u`" + `
+
+	test "counts bound rows" {
+		count: len(users)
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both queries return the same single row, so the bound setup has one row.
+	d := &mockDatabase{results: []map[string]any{{"count": int64(1)}}}
+
+	result, err := New(WithDatabase(d)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Passed != 1 {
+		t.Errorf("Passed = %d, want 1: %+v", result.Passed, result.Tests)
+	}
+}
+
+func TestRunner_ComputedInput(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query GetUser ` + "`GET`" + `
+
+setup users = ` + "`MATCH (u:User) RETURN u`" + `
+
+GetUser {
+	test "t" {
+		$limit: len(users)
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New(WithDatabase(&mockDatabase{})).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := result.Tests["GetUser/t"]
+	if tr == nil || !errors.Is(tr.Error, ErrComputedInput) {
+		t.Errorf("GetUser/t = %+v, want %v", tr, ErrComputedInput)
+	}
+}
+
+func TestRunner_UnbindableSetup(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+import fixtures "./fixtures"
+
+query GetUser ` + "`GET`" + `
+
+setup users = fixtures
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New(WithDatabase(&mockDatabase{})).Run(context.Background(), suite, "test.scaf")
+	if !errors.Is(err, ErrUnbindableSetup) {
+		t.Errorf("Run() error = %v, want %v", err, ErrUnbindableSetup)
+	}
+}
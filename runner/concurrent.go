@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rlch/scaf"
+)
+
+// runScopesConcurrently runs up to r.concurrency scopes at once.
+//
+// Only isolated scopes overlap: those whose every write happens inside a
+// per-test transaction that is rolled back. A scope with setup or teardown of
+// its own (or in a profile or group) writes shared state, so it runs alone.
+// Each scope's events are buffered and replayed through handler in source
+// order, so reports don't depend on which scope finished first. A scope that
+// fails with an error cancels the others, as a sequential run stops there.
+func (r *Runner) runScopesConcurrently(
+	ctx context.Context,
+	suite *scaf.Suite,
+	queries map[string]string,
+	binds bindings,
+	suitePath string,
+	handler Handler,
+	result *Result,
+) error {
+	type scopeRun struct {
+		events []Event
+		err    error
+		done   chan struct{}
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		aborted atomic.Bool
+		shared  sync.RWMutex // held exclusively by scopes that aren't isolated
+		slots   = make(chan struct{}, r.concurrency)
+		stopped atomic.Bool
+		budget  = &failBudget{max: r.failLimit()}
+		runs    = make([]*scopeRun, len(suite.Scopes))
+	)
+
+	for i, scope := range suite.Scopes {
+		run := &scopeRun{done: make(chan struct{})}
+		runs[i] = run
+
+		go func() {
+			defer close(run.done)
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if scopeIsolated(suite, scope) {
+				shared.RLock()
+				defer shared.RUnlock()
+			} else {
+				shared.Lock()
+				defer shared.Unlock()
+			}
+
+			if stopped.Load() {
+				return
+			}
+
+			rec := &recordingHandler{budget: budget}
+			run.err = r.runQueryScope(runCtx, suite, scope, queries, binds.child(), suitePath, rec, NewResult())
+			run.events = rec.events
+
+			switch {
+			case errors.Is(run.err, ErrMaxFailures):
+				stopped.Store(true)
+			case run.err != nil:
+				stopped.Store(true)
+				aborted.Store(true)
+				cancel(run.err)
+			}
+		}()
+	}
+
+	var firstErr error
+
+	for _, run := range runs {
+		<-run.done
+
+		for _, event := range run.events {
+			// Tests cut short by another scope's error didn't fail on their own.
+			if aborted.Load() && errors.Is(event.Error, context.Canceled) {
+				continue
+			}
+
+			// Every test that ran is reported, even past the failure limit.
+			_ = handler.Event(ctx, event, result)
+		}
+
+		if firstErr == nil && run.err != nil {
+			firstErr = run.err
+		}
+	}
+
+	// Report the error that stopped the run, not one it caused in an
+	// earlier scope.
+	if aborted.Load() {
+		return context.Cause(runCtx)
+	}
+
+	return firstErr
+}
+
+// scopeIsolated reports whether a scope writes only inside per-test
+// transactions, so it can run alongside other isolated scopes.
+func scopeIsolated(suite *scaf.Suite, scope *scaf.QueryScope) bool {
+	if scope.Setup != nil || scope.Teardown != nil {
+		return false
+	}
+
+	if scope.Extends != nil {
+		if p := suite.Profile(*scope.Extends); p != nil && (p.Setup != nil || p.Teardown != nil) {
+			return false
+		}
+	}
+
+	return itemsIsolated(scope.Items)
+}
+
+func itemsIsolated(items []*scaf.TestOrGroup) bool {
+	for _, item := range items {
+		if g := item.Group; g != nil {
+			if g.Setup != nil || g.Teardown != nil || !itemsIsolated(g.Items) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// failLimit returns the failure limit from WithMaxFailures and WithFailFast.
+func (r *Runner) failLimit() int {
+	if r.failFast && r.maxFails <= 0 {
+		return 1
+	}
+
+	return r.maxFails
+}
+
+// recordingHandler buffers a scope's events for ordered replay, counting
+// failures against a budget shared by all scopes.
+type recordingHandler struct {
+	events []Event
+	budget *failBudget
+}
+
+func (h *recordingHandler) Event(_ context.Context, event Event, _ *Result) error {
+	h.events = append(h.events, event)
+
+	if event.Action == ActionFail || event.Action == ActionError {
+		return h.budget.spend()
+	}
+
+	return nil
+}
+
+func (h *recordingHandler) Err(_ string) error {
+	return nil
+}
+
+// failBudget counts failures across concurrently running scopes.
+type failBudget struct {
+	max   int
+	count atomic.Int64
+}
+
+// spend records a failure, returning ErrMaxFailures once the limit is reached.
+func (b *failBudget) spend() error {
+	if n := b.count.Add(1); b.max > 0 && n >= int64(b.max) {
+		return ErrMaxFailures
+	}
+
+	return nil
+}
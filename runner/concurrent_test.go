@@ -0,0 +1,252 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rlch/scaf"
+)
+
+// concurrentDatabase is a transactional stub that tracks how many transaction
+// queries are in flight. Each one waits (up to a timeout) until wait of them
+// are running at once, so a sequential runner can't reach the peak. The wait is
+// lifted once reached.
+type concurrentDatabase struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	wait   int
+	active int
+	peak   int
+
+	// activeAtSetup is the number of transaction queries in flight whenever a
+	// query ran directly on the database (setup and teardown).
+	activeAtSetup []int
+}
+
+func newConcurrentDatabase(wait int) *concurrentDatabase {
+	d := &concurrentDatabase{wait: wait}
+	d.cond = sync.NewCond(&d.mu)
+
+	return d
+}
+
+func (d *concurrentDatabase) Name() string { return "concurrent" }
+
+func (d *concurrentDatabase) Dialect() scaf.Dialect { return nil }
+
+func (d *concurrentDatabase) Execute(_ context.Context, _ string, _ map[string]any) ([]map[string]any, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.activeAtSetup = append(d.activeAtSetup, d.active)
+
+	return nil, nil
+}
+
+func (d *concurrentDatabase) Close() error { return nil }
+
+func (d *concurrentDatabase) Begin(_ context.Context) (scaf.DatabaseTransaction, error) {
+	return &concurrentTx{db: d}, nil
+}
+
+type concurrentTx struct {
+	db *concurrentDatabase
+}
+
+func (t *concurrentTx) Execute(_ context.Context, query string, _ map[string]any) ([]map[string]any, error) {
+	d := t.db
+
+	d.mu.Lock()
+	d.active++
+	d.peak = max(d.peak, d.active)
+
+	// Release everyone once wait queries have overlapped.
+	if d.active >= d.wait {
+		d.wait = 0
+		d.cond.Broadcast()
+	}
+
+	deadline := time.AfterFunc(2*time.Second, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.wait = 0
+		d.cond.Broadcast()
+	})
+
+	for d.active < d.wait {
+		d.cond.Wait()
+	}
+
+	d.active--
+	d.mu.Unlock()
+	deadline.Stop()
+
+	return []map[string]any{{"q": query}}, nil
+}
+
+func (t *concurrentTx) Commit(_ context.Context) error { return nil }
+
+func (t *concurrentTx) Rollback(_ context.Context) error { return nil }
+
+func concurrentSuite(t *testing.T, src string) *scaf.Suite {
+	t.Helper()
+
+	suite, err := scaf.Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return suite
+}
+
+func TestRunner_ConcurrentScopes(t *testing.T) {
+	suite := concurrentSuite(t, `
+query A `+"`A`"+`
+query B `+"`B`"+`
+query C `+"`C`"+`
+
+A {
+	test "a" { q: "A" }
+}
+
+B {
+	test "b" { q: "B" }
+	test "wrong" { q: "A" }
+}
+
+C {
+	test "c" { q: "C" }
+}
+`)
+
+	d := newConcurrentDatabase(3)
+
+	result, err := New(WithDatabase(d), WithConcurrency(3)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.peak != 3 {
+		t.Errorf("peak concurrent queries = %d, want 3", d.peak)
+	}
+
+	wantOrder := []string{"A/a", "B/b", "B/wrong", "C/c"}
+	if !slices.Equal(result.Order, wantOrder) {
+		t.Errorf("Order = %v, want %v regardless of completion order", result.Order, wantOrder)
+	}
+
+	if result.Passed != 3 || result.Failed != 1 {
+		t.Errorf("passed %d, failed %d; want 3 and 1", result.Passed, result.Failed)
+	}
+
+	if tr := result.Tests["B/wrong"]; tr == nil || tr.Actual != "B" {
+		t.Errorf("B/wrong = %+v, want a failure seeing its own scope's rows", tr)
+	}
+}
+
+func TestRunner_ConcurrentScopesSerializeSharedState(t *testing.T) {
+	suite := concurrentSuite(t, `
+query A `+"`A`"+`
+query B `+"`B`"+`
+query C `+"`C`"+`
+
+A {
+	test "a" {}
+}
+
+B {
+	setup `+"`CREATE (:Shared)`"+`
+	test "b" {}
+	teardown `+"`MATCH (n:Shared) DELETE n`"+`
+}
+
+C {
+	test "c" {}
+}
+`)
+
+	d := newConcurrentDatabase(0)
+
+	result, err := New(WithDatabase(d), WithConcurrency(3)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.activeAtSetup) != 2 {
+		t.Fatalf("ran %d setup/teardown queries, want 2", len(d.activeAtSetup))
+	}
+
+	for _, n := range d.activeAtSetup {
+		if n != 0 {
+			t.Errorf("scope setup ran with %d other queries in flight, want it to run alone", n)
+		}
+	}
+
+	if result.Passed != 3 {
+		t.Errorf("Passed = %d, want 3", result.Passed)
+	}
+}
+
+// blockingDatabase runs transaction queries until their context is canceled.
+type blockingDatabase struct{ mockDatabase }
+
+func (d *blockingDatabase) Begin(_ context.Context) (scaf.DatabaseTransaction, error) {
+	return blockingTx{}, nil
+}
+
+type blockingTx struct{}
+
+func (blockingTx) Execute(ctx context.Context, _ string, _ map[string]any) ([]map[string]any, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(2 * time.Second):
+		return nil, nil
+	}
+}
+
+func (blockingTx) Commit(_ context.Context) error   { return nil }
+func (blockingTx) Rollback(_ context.Context) error { return nil }
+
+func TestRunner_ConcurrentScopeErrorStopsOthers(t *testing.T) {
+	suite := concurrentSuite(t, `
+query A `+"`A`"+`
+
+A {
+	test "blocks" {}
+}
+
+Undefined {
+	test "never runs" {}
+}
+`)
+
+	start := time.Now()
+
+	result, err := New(WithDatabase(&blockingDatabase{}), WithConcurrency(2)).Run(context.Background(), suite, "test.scaf")
+	if !errors.Is(err, ErrUnknownQuery) {
+		t.Errorf("got %v, want ErrUnknownQuery, as a sequential run reports", err)
+	}
+
+	if elapsed := time.Since(start); elapsed >= 2*time.Second {
+		t.Errorf("run took %v; the blocked scope wasn't canceled", elapsed)
+	}
+
+	if result.Errors != 0 {
+		t.Errorf("Errors = %d, want the canceled test left unreported", result.Errors)
+	}
+}
+
+func TestRunner_ConcurrencyRefusesKeepState(t *testing.T) {
+	r := New(WithDatabase(newConcurrentDatabase(0)), WithConcurrency(2), WithKeepState(true))
+
+	_, err := r.Run(context.Background(), &scaf.Suite{}, "test.scaf")
+	if !errors.Is(err, ErrConcurrentKeepState) {
+		t.Errorf("got %v, want ErrConcurrentKeepState", err)
+	}
+}
@@ -0,0 +1,41 @@
+package runner //nolint:testpackage
+
+import (
+	"context"
+	"maps"
+
+	"github.com/rlch/scaf"
+)
+
+// ParamTrackingDatabase records executed queries and their parameters.
+// It is exported for the runner_test package's tests as well as this one's.
+type ParamTrackingDatabase struct {
+	// Results is returned for queries without an entry in ResultsByQuery.
+	Results        []map[string]any
+	ResultsByQuery map[string][]map[string]any
+
+	Executed []ExecutedQuery
+}
+
+// ExecutedQuery is a query run through a ParamTrackingDatabase.
+type ExecutedQuery struct {
+	Query  string
+	Params map[string]any
+}
+
+func (d *ParamTrackingDatabase) Name() string { return "param-tracking" }
+
+func (d *ParamTrackingDatabase) Dialect() scaf.Dialect { return nil }
+
+func (d *ParamTrackingDatabase) Execute(_ context.Context, query string, params map[string]any) ([]map[string]any, error) {
+	// Clone params to avoid mutation issues
+	d.Executed = append(d.Executed, ExecutedQuery{Query: query, Params: maps.Clone(params)})
+
+	if results, ok := d.ResultsByQuery[query]; ok {
+		return results, nil
+	}
+
+	return d.Results, nil
+}
+
+func (d *ParamTrackingDatabase) Close() error { return nil }
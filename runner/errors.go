@@ -20,15 +20,42 @@ var (
 	// ErrConnectionFailed is returned when database connection fails.
 	ErrConnectionFailed = errors.New("runner: database connection failed")
 
+	// ErrConcurrentKeepState is returned when concurrency is combined with keep-state,
+	// which disables the per-test rollback that concurrent scopes rely on.
+	ErrConcurrentKeepState = errors.New("runner: concurrency requires per-test rollback and cannot keep state")
+
 	// ErrUnknownQuery is returned when a referenced query is not found.
 	ErrUnknownQuery = errors.New("runner: unknown query")
 
+	// ErrUnknownProfile is returned when a scope extends a profile that is not defined.
+	ErrUnknownProfile = errors.New("runner: unknown profile")
+
+	// ErrDuplicateClause is returned when a scope has a second setup or teardown
+	// written after its tests, which would otherwise never run.
+	ErrDuplicateClause = errors.New("runner: scope has more than one setup or teardown")
+
+	// ErrTestNotFound is returned when no test matches a requested path.
+	ErrTestNotFound = errors.New("runner: test not found")
+
 	// ErrExprNotBool is returned when an expression does not return a boolean.
 	ErrExprNotBool = errors.New("runner: expression did not return bool")
 
 	// ErrNoModuleContext is returned when named setup requires module resolution.
 	ErrNoModuleContext = errors.New("runner: named setup requires module resolution")
 
+	// ErrNoBulkInsert is returned when a data table is used with a dialect that can't insert it.
+	ErrNoBulkInsert = errors.New("runner: dialect does not support data tables")
+
+	// ErrUnbindableSetup is returned when a module setup or setup block is bound to a name.
+	ErrUnbindableSetup = errors.New("runner: only a query or data table setup can be bound")
+
+	// ErrComputedInput is returned when a computed value is used as a query parameter.
+	ErrComputedInput = errors.New("runner: computed values are only allowed as expected outputs")
+
+	// ErrColumnPosition is returned when a positional key (result[N]) can't be
+	// matched to a column of the query's result.
+	ErrColumnPosition = errors.New("runner: cannot resolve result column position")
+
 	// ErrAssertNoQuery is returned when an assert has no inline or named query.
 	ErrAssertNoQuery = errors.New("runner: assert query has no inline or named query")
 
@@ -4,6 +4,8 @@ package runner
 import (
 	"strings"
 	"time"
+
+	"github.com/rlch/scaf"
 )
 
 // Action represents the type of test event.
@@ -34,14 +36,27 @@ type Event struct {
 	Elapsed time.Duration // Time taken (for terminal events)
 	Output  string        // Log output (for ActionOutput)
 	Error   error         // Error details (for ActionFail/ActionError)
+	Reason  string        // Why the test was skipped, if given (for ActionSkip)
 
 	// For assertion failures
 	Expected any
 	Actual   any
 	Field    string // Which field failed (e.g., "u.name")
 
-	// Source location for diagnostics
-	Line int // 0-indexed line number in source file
+	// Source location of the test, for diagnostics; nil when unknown
+	Pos *Position
+
+	// Query latency, for passing tests run in benchmark mode
+	Bench *BenchStats
+
+	// Main query plan, for passing tests run in profile mode
+	Plan *scaf.QueryPlan
+}
+
+// Position is a 0-indexed location in a source file.
+type Position struct {
+	Line   int
+	Column int
 }
 
 // PathString returns the path as a slash-separated string.
@@ -0,0 +1,363 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rlch/scaf"
+)
+
+// Plan describes how a single test would run, resolved without executing anything.
+type Plan struct {
+	// Path is the test path: query scope, groups, then the test name.
+	Path []string
+	// QueryName and Query are the scope's query and its body.
+	QueryName string
+	Query     string
+	// Params are the test's input bindings ($-prefixed statements) in declaration order.
+	Params []*scaf.Statement
+	// Defaults are the query's parameter defaults that apply because the test omits them.
+	Defaults []*scaf.ParamDefault
+	// Setup lists every setup query in execution order, from the suite down to the test.
+	Setup []PlanStep
+	// Teardown lists every teardown query in execution order, from the innermost group outwards.
+	Teardown []PlanStep
+	// Expected are the test's expected output statements.
+	Expected []*scaf.Statement
+	// Expect is the expected row as a whole, if the test declares an expect block.
+	Expect *scaf.Map
+	// Rows is the expected result set, if the test declares one.
+	Rows []*scaf.Map
+	// Asserts are the test's assert blocks.
+	Asserts []*scaf.Assert
+}
+
+// PlanStep is a single resolved setup or teardown query.
+type PlanStep struct {
+	// Level is where the clause is declared, e.g. "suite", "profile Base", "group edge cases".
+	Level string
+	// Source describes the clause: "inline", a module alias, a "module.Query" call, or "data Label".
+	Source string
+	// Query is the resolved query body.
+	Query string
+	// Params are the parameters passed to the query.
+	Params map[string]any
+}
+
+// Explain resolves the test at path into a Plan. Setup calls and module setups are
+// resolved through the runner's module context, exactly as Run would, but nothing
+// is executed and no database is required.
+func (r *Runner) Explain(suite *scaf.Suite, path []string) (*Plan, error) {
+	scope, groups, test := suite.TestByPath(path)
+	if test == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTestNotFound, strings.Join(path, "/"))
+	}
+
+	plan := &Plan{
+		Path:      path,
+		QueryName: scope.QueryName,
+		Expect:    test.Expect,
+		Rows:      test.ExpectedRows,
+		Asserts:   test.Asserts,
+	}
+
+	query := suite.Query(scope.QueryName)
+	if query == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownQuery, scope.QueryName)
+	}
+
+	plan.Query = query.Body
+
+	var profile *scaf.Profile
+	if scope.Extends != nil {
+		profile = suite.Profile(*scope.Extends)
+		if profile == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProfile, *scope.Extends)
+		}
+	}
+
+	// Setup runs outermost first.
+	type clause struct {
+		level    string
+		setup    *scaf.SetupClause
+		teardown *string
+	}
+
+	clauses := []clause{{"suite", suite.Setup, suite.Teardown}}
+	if profile != nil {
+		clauses = append(clauses, clause{"profile " + profile.Name, profile.Setup, profile.Teardown})
+	}
+
+	clauses = append(clauses, clause{"scope " + scope.QueryName, scope.Setup, scope.Teardown})
+	for _, g := range groups {
+		clauses = append(clauses, clause{"group " + g.Name, g.Setup, g.Teardown})
+	}
+
+	clauses = append(clauses, clause{"test", test.Setup, nil})
+
+	for _, c := range clauses {
+		steps, err := r.planSetup(c.level, "", c.setup)
+		if err != nil {
+			return nil, fmt.Errorf("%s setup: %w", c.level, err)
+		}
+
+		plan.Setup = append(plan.Setup, steps...)
+	}
+
+	// Teardown runs innermost first.
+	for i := len(clauses) - 1; i >= 0; i-- {
+		if c := clauses[i]; c.teardown != nil {
+			plan.Teardown = append(plan.Teardown, PlanStep{Level: c.level, Source: "inline", Query: *c.teardown})
+		}
+	}
+
+	provided := make(map[string]bool)
+
+	for _, stmt := range test.Statements {
+		if key := stmt.Key(); len(key) > 0 && key[0] == '$' {
+			plan.Params = append(plan.Params, stmt)
+			provided[strings.TrimPrefix(key, "$")] = true
+		} else {
+			plan.Expected = append(plan.Expected, stmt)
+		}
+	}
+
+	for _, d := range query.Defaults {
+		if d.Value != nil && !provided[strings.TrimPrefix(d.Name, "$")] {
+			plan.Defaults = append(plan.Defaults, d)
+		}
+	}
+
+	return plan, nil
+}
+
+// planSetup resolves a setup clause into steps, mirroring executeSetup.
+// Steps from a module's setup clause are prefixed with the module alias.
+func (r *Runner) planSetup(level, prefix string, setup *scaf.SetupClause) ([]PlanStep, error) {
+	if setup == nil {
+		return nil, nil
+	}
+
+	if setup.Inline != nil || setup.Module != nil || setup.Call != nil {
+		return r.planSetupItem(level, prefix, &scaf.SetupItem{
+			Inline: setup.Inline,
+			Module: setup.Module,
+			Call:   setup.Call,
+		})
+	}
+
+	var steps []PlanStep
+
+	for _, item := range setup.Block {
+		itemSteps, err := r.planSetupItem(level, prefix, item)
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, itemSteps...)
+	}
+
+	return steps, nil
+}
+
+// planSetupItem resolves a single setup item, mirroring executeSetupItem.
+func (r *Runner) planSetupItem(level, prefix string, item *scaf.SetupItem) ([]PlanStep, error) {
+	switch {
+	case item.Inline != nil:
+		return []PlanStep{{Level: level, Source: prefix + "inline", Query: *item.Inline}}, nil
+
+	case item.Data != nil:
+		// Without a database the dialect's insert query is unknown; show the rows alone.
+		query, err := r.bulkInsertQuery(item.Data)
+		if err != nil {
+			query = "(bulk insert chosen by the database dialect)"
+		}
+
+		return []PlanStep{{
+			Level:  level,
+			Source: prefix + "data " + item.Data.Label,
+			Query:  query,
+			Params: map[string]any{"rows": item.Data.Bindings()},
+		}}, nil
+
+	case item.Module != nil:
+		if r.modules == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNoModuleContext, *item.Module)
+		}
+
+		mod, err := r.modules.ResolveModule(*item.Module)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve module: %w", err)
+		}
+
+		modSetup := mod.GetSetup()
+		if modSetup == nil {
+			return nil, fmt.Errorf("module %q has no setup clause", *item.Module)
+		}
+
+		return r.planSetup(level, prefix+*item.Module+": ", modSetup)
+
+	case item.Call != nil:
+		if r.modules == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNoModuleContext, item.Call.Ref())
+		}
+
+		queryBody, err := r.modules.ResolveQuery(item.Call.Module, item.Call.Query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve query: %w", err)
+		}
+
+		return []PlanStep{{
+			Level:  level,
+			Source: prefix + item.Call.Ref(),
+			Query:  queryBody,
+			Params: setupCallParams(item.Call),
+		}}, nil
+	}
+
+	return nil, nil
+}
+
+// Write renders the plan as human-readable text.
+func (p *Plan) Write(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString(strings.Join(p.Path, "/") + "\n")
+
+	b.WriteString("\nquery " + p.QueryName + ":\n")
+	writeIndented(&b, p.Query)
+
+	if len(p.Params) > 0 || len(p.Defaults) > 0 {
+		b.WriteString("\nparams:\n")
+
+		for _, stmt := range p.Params {
+			b.WriteString("  " + stmt.Key() + ": " + stmt.Value.String() + "\n")
+		}
+
+		for _, d := range p.Defaults {
+			b.WriteString("  " + d.Name + ": " + d.Value.String() + " (default)\n")
+		}
+	}
+
+	writeSteps(&b, "setup", p.Setup)
+	writeSteps(&b, "teardown", p.Teardown)
+
+	if len(p.Expected) > 0 {
+		b.WriteString("\nexpect:\n")
+
+		for _, stmt := range p.Expected {
+			b.WriteString("  " + stmt.Key() + ": " + stmt.Value.String() + "\n")
+		}
+	}
+
+	if p.Expect != nil {
+		b.WriteString("\nexpect row:\n  " + (&scaf.Value{Map: p.Expect}).String() + "\n")
+	}
+
+	if len(p.Rows) > 0 {
+		b.WriteString("\nrows:\n")
+
+		for _, row := range p.Rows {
+			b.WriteString("  " + (&scaf.Value{Map: row}).String() + "\n")
+		}
+	}
+
+	if len(p.Asserts) > 0 {
+		b.WriteString("\nasserts:\n")
+
+		for _, a := range p.Asserts {
+			b.WriteString("  " + assertString(a) + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeSteps(b *strings.Builder, title string, steps []PlanStep) {
+	if len(steps) == 0 {
+		return
+	}
+
+	b.WriteString("\n" + title + ":\n")
+
+	for _, step := range steps {
+		b.WriteString("  [" + step.Level + "] " + step.Source + "\n")
+		writeIndented(b, step.Query)
+
+		if len(step.Params) > 0 {
+			keys := make([]string, 0, len(step.Params))
+			for k := range step.Params {
+				keys = append(keys, k)
+			}
+
+			sort.Strings(keys)
+
+			parts := make([]string, len(keys))
+			for i, k := range keys {
+				parts[i] = "$" + k + ": " + planValue(step.Params[k])
+			}
+
+			b.WriteString("    with " + strings.Join(parts, ", ") + "\n")
+		}
+	}
+}
+
+// writeIndented writes each line of a query body indented beneath its heading.
+func writeIndented(b *strings.Builder, query string) {
+	for line := range strings.SplitSeq(strings.TrimSpace(query), "\n") {
+		b.WriteString("    " + strings.TrimSpace(line) + "\n")
+	}
+}
+
+func planValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func assertString(a *scaf.Assert) string {
+	if a.Idempotent {
+		return "assert idempotent"
+	}
+
+	var b strings.Builder
+
+	b.WriteString("assert ")
+
+	if q := a.Query; q != nil {
+		switch {
+		case q.Inline != nil:
+			b.WriteString("`" + *q.Inline + "` ")
+		case q.QueryName != nil:
+			params := make([]string, len(q.Params))
+			for i, p := range q.Params {
+				params[i] = p.Name + ": " + p.Value.String()
+			}
+
+			b.WriteString(*q.QueryName + "(" + strings.Join(params, ", ") + ") ")
+		}
+	}
+
+	conds := make([]string, len(a.Conditions))
+	for i, c := range a.Conditions {
+		conds[i] = c.String()
+	}
+
+	if len(conds) == 0 {
+		b.WriteString("{}")
+	} else {
+		b.WriteString("{ " + strings.Join(conds, "; ") + " }")
+	}
+
+	return b.String()
+}
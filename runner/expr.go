@@ -2,9 +2,10 @@ package runner
 
 import (
 	"fmt"
+	"maps"
 	"strings"
 
-	"github.com/expr-lang/expr"
+	"github.com/rlch/scaf/expr"
 )
 
 // ExprResult holds the result of evaluating an expression.
@@ -14,11 +15,11 @@ type ExprResult struct {
 	Error      error  // Any error during compilation or evaluation
 }
 
-// EvalExpr evaluates a single expression string against an environment.
-// Returns the result of the boolean expression, or an error if:
-// - The expression fails to compile
-// - The expression fails to evaluate
-// - The expression doesn't return a boolean.
+// EvalExpr evaluates a single expression string against an environment with
+// the expr package. Returns the result of the boolean expression, or an error if:
+// - The expression fails to compile (expr.ErrCompile)
+// - The expression fails to evaluate (expr.ErrEval)
+// - The expression doesn't return a boolean (ErrExprNotBool).
 func EvalExpr(exprStr string, env map[string]any) ExprResult {
 	result := ExprResult{Expression: exprStr}
 
@@ -28,18 +29,9 @@ func EvalExpr(exprStr string, env map[string]any) ExprResult {
 		return result
 	}
 
-	// Compile the expression
-	program, err := expr.Compile(exprStr, expr.Env(env), expr.AsBool())
+	output, err := expr.EvalString(exprStr, env)
 	if err != nil {
-		result.Error = fmt.Errorf("compile expression %q: %w", exprStr, err)
-
-		return result
-	}
-
-	// Run the expression
-	output, err := expr.Run(program, env)
-	if err != nil {
-		result.Error = fmt.Errorf("evaluate expression %q: %w", exprStr, err)
+		result.Error = err
 
 		return result
 	}
@@ -107,3 +99,51 @@ func FirstFailure(results []ExprResult) *ExprResult {
 
 	return nil
 }
+
+// assertEnv returns the environment assert conditions see for a result row: its
+// columns, with each dotted column such as "u.age" also nested under its prefix
+// (u.age), unless the prefix is itself a column that isn't a map.
+func assertEnv(row map[string]any) map[string]any {
+	env := maps.Clone(row)
+	if env == nil {
+		env = make(map[string]any)
+	}
+
+	for column, value := range row {
+		parts := strings.Split(column, ".")
+		if len(parts) < 2 {
+			continue
+		}
+
+		current := env
+
+		for _, part := range parts[:len(parts)-1] {
+			next, isMap := current[part].(map[string]any)
+			_, taken := current[part]
+
+			switch {
+			case isMap:
+				next = maps.Clone(next) // Don't modify the row's own maps.
+			case taken:
+				next = nil // A scalar column shadows the field.
+			default:
+				next = make(map[string]any)
+			}
+
+			if next == nil {
+				current = nil
+
+				break
+			}
+
+			current[part] = next
+			current = next
+		}
+
+		if current != nil {
+			current[parts[len(parts)-1]] = value
+		}
+	}
+
+	return env
+}
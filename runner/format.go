@@ -3,9 +3,16 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/rlch/scaf"
 )
 
 // Formatter renders test events and results.
@@ -63,6 +70,15 @@ func (v *VerboseFormatter) Format(event Event, _ *Result) error {
 		_, _ = fmt.Fprintf(v.w, "=== RUN   %s\n", event.PathString())
 	case ActionPass:
 		_, _ = fmt.Fprintf(v.w, "--- PASS: %s (%s)\n", event.PathString(), event.Elapsed)
+
+		if b := event.Bench; b != nil {
+			_, _ = fmt.Fprintf(v.w, "    bench: %d runs, min %s, median %s, p95 %s, max %s\n",
+				b.Iterations, b.Min, b.Median, b.P95, b.Max)
+		}
+
+		if p := event.Plan; p != nil {
+			_, _ = fmt.Fprintf(v.w, "    plan: %s, %d db hits, %d rows\n", p.Operator, p.DBHits, p.Rows)
+		}
 	case ActionFail:
 		_, _ = fmt.Fprintf(v.w, "--- FAIL: %s (%s)\n", event.PathString(), event.Elapsed)
 
@@ -73,6 +89,10 @@ func (v *VerboseFormatter) Format(event Event, _ *Result) error {
 		}
 	case ActionSkip:
 		_, _ = fmt.Fprintf(v.w, "--- SKIP: %s (%s)\n", event.PathString(), event.Elapsed)
+
+		if event.Reason != "" {
+			_, _ = fmt.Fprintf(v.w, "    %s\n", event.Reason)
+		}
 	case ActionError:
 		_, _ = fmt.Fprintf(v.w, "--- ERROR: %s (%s)\n", event.PathString(), event.Elapsed)
 		_, _ = fmt.Fprintf(v.w, "    %v\n", event.Error)
@@ -104,6 +124,12 @@ func (v *VerboseFormatter) Summary(result *Result) error {
 	)
 	_, _ = fmt.Fprintf(v.w, "  elapsed: %s\n", result.Elapsed().Round(time.Millisecond))
 
+	for _, path := range result.Order {
+		if tr := result.Tests[path]; tr.Runs > 1 && tr.Failures > 0 {
+			_, _ = fmt.Fprintf(v.w, "  %s: failed %d of %d runs\n", path, tr.Failures, tr.Runs)
+		}
+	}
+
 	return nil
 }
 
@@ -124,24 +150,76 @@ func NewJSONFormatter(w io.Writer) *JSONFormatter {
 // jsonError represents an error with source location.
 type jsonError struct {
 	Message  string `json:"message"`
-	Line     *int   `json:"line,omitempty"`
+	Line     *int   `json:"line,omitempty"`     // 0-indexed
+	Column   *int   `json:"column,omitempty"`   // 0-indexed
 	Severity int    `json:"severity,omitempty"` // 1=error, 2=warn, 3=info, 4=hint
 }
 
+// newJSONError returns an error-severity entry, located at pos if it is known.
+func newJSONError(message string, pos *Position) jsonError {
+	je := jsonError{Message: message, Severity: 1}
+	if pos != nil {
+		je.Line, je.Column = &pos.Line, &pos.Column
+	}
+
+	return je
+}
+
 type jsonEvent struct {
-	Time     string       `json:"time"`
-	Action   string       `json:"action"`
-	ID       string       `json:"id"`
-	Suite    string       `json:"suite,omitempty"`
-	Path     string       `json:"path"`
-	Test     string       `json:"test,omitempty"`
-	Elapsed  float64      `json:"elapsed,omitempty"`
-	Output   string       `json:"output,omitempty"`
-	Short    string       `json:"short,omitempty"`
-	Errors   []jsonError  `json:"errors,omitempty"`
-	Field    string       `json:"field,omitempty"`
-	Expected any          `json:"expected,omitempty"`
-	Actual   any          `json:"actual,omitempty"`
+	Time     string      `json:"time"`
+	Action   string      `json:"action"`
+	ID       string      `json:"id"`
+	Suite    string      `json:"suite,omitempty"`
+	Path     string      `json:"path"`
+	Test     string      `json:"test,omitempty"`
+	Elapsed  float64     `json:"elapsed,omitempty"`
+	Output   string      `json:"output,omitempty"`
+	Short    string      `json:"short,omitempty"`
+	Errors   []jsonError `json:"errors,omitempty"`
+	Reason   string      `json:"reason,omitempty"`
+	Field    string      `json:"field,omitempty"`
+	Expected any         `json:"expected,omitempty"`
+	Actual   any         `json:"actual,omitempty"`
+	Bench    *jsonBench  `json:"bench,omitempty"`
+	Plan     *jsonPlan   `json:"plan,omitempty"`
+}
+
+// jsonBench reports benchmark latencies in seconds, like elapsed.
+type jsonBench struct {
+	Iterations int     `json:"iterations"`
+	Min        float64 `json:"min"`
+	Median     float64 `json:"median"`
+	P95        float64 `json:"p95"`
+	Max        float64 `json:"max"`
+}
+
+// jsonPlan summarizes a profiled query plan.
+type jsonPlan struct {
+	Operator string `json:"operator"`
+	DBHits   int64  `json:"dbHits"`
+	Rows     int64  `json:"rows"`
+}
+
+func newJSONPlan(p *scaf.QueryPlan) *jsonPlan {
+	if p == nil {
+		return nil
+	}
+
+	return &jsonPlan{Operator: p.Operator, DBHits: p.DBHits, Rows: p.Rows}
+}
+
+func newJSONBench(b *BenchStats) *jsonBench {
+	if b == nil {
+		return nil
+	}
+
+	return &jsonBench{
+		Iterations: b.Iterations,
+		Min:        b.Min.Seconds(),
+		Median:     b.Median.Seconds(),
+		P95:        b.P95.Seconds(),
+		Max:        b.Max.Seconds(),
+	}
 }
 
 // Format outputs a JSON event.
@@ -157,19 +235,21 @@ func (j *JSONFormatter) Format(event Event, _ *Result) error {
 
 	if event.Action.IsTerminal() {
 		je.Elapsed = event.Elapsed.Seconds()
+		je.Bench = newJSONBench(event.Bench)
+		je.Plan = newJSONPlan(event.Plan)
 	}
 
 	if event.Output != "" {
 		je.Output = event.Output
 	}
 
+	if event.Action == ActionSkip {
+		je.Reason = event.Reason
+	}
+
 	if event.Error != nil {
 		je.Short = event.Error.Error()
-		je.Errors = []jsonError{{
-			Message:  event.Error.Error(),
-			Line:     intPtr(event.Line),
-			Severity: 1,
-		}}
+		je.Errors = []jsonError{newJSONError(event.Error.Error(), event.Pos)}
 	}
 
 	if event.Action == ActionFail {
@@ -179,29 +259,24 @@ func (j *JSONFormatter) Format(event Event, _ *Result) error {
 
 		if event.Field != "" {
 			je.Short = fmt.Sprintf("%s: expected %v, got %v", event.Field, event.Expected, event.Actual)
-			je.Errors = []jsonError{{
-				Message:  je.Short,
-				Line:     intPtr(event.Line),
-				Severity: 1,
-			}}
+			je.Errors = []jsonError{newJSONError(je.Short, event.Pos)}
 		}
 	}
 
 	return j.enc.Encode(je)
 }
 
-func intPtr(i int) *int {
-	if i == 0 {
-		return nil
-	}
-
-	return &i
-}
-
 type jsonTestResult struct {
 	Status string      `json:"status"`
+	Reason string      `json:"reason,omitempty"`
 	Short  string      `json:"short,omitempty"`
 	Errors []jsonError `json:"errors,omitempty"`
+	Bench  *jsonBench  `json:"bench,omitempty"`
+	Plan   *jsonPlan   `json:"plan,omitempty"`
+
+	// Set only when the test ran more than once
+	Runs     int `json:"runs,omitempty"`
+	Failures int `json:"failures,omitempty"`
 }
 
 type jsonSummary struct {
@@ -214,6 +289,9 @@ type jsonSummary struct {
 	Elapsed float64                   `json:"elapsed"`
 	Ok      bool                      `json:"ok"`
 	Results map[string]jsonTestResult `json:"results"`
+
+	// Suite meta blocks by suite path
+	Meta map[string]map[string]any `json:"meta,omitempty"`
 }
 
 // Summary outputs the final JSON summary.
@@ -223,22 +301,22 @@ func (j *JSONFormatter) Summary(result *Result) error {
 	for _, tr := range result.Tests {
 		jtr := jsonTestResult{
 			Status: string(tr.Status),
+			Reason: tr.Reason,
+			Bench:  newJSONBench(tr.Bench),
+			Plan:   newJSONPlan(tr.Plan),
+		}
+
+		if tr.Runs > 1 {
+			jtr.Runs = tr.Runs
+			jtr.Failures = tr.Failures
 		}
 
 		if tr.Error != nil {
 			jtr.Short = tr.Error.Error()
-			jtr.Errors = []jsonError{{
-				Message:  tr.Error.Error(),
-				Line:     intPtr(tr.Line),
-				Severity: 1,
-			}}
+			jtr.Errors = []jsonError{newJSONError(tr.Error.Error(), tr.Pos)}
 		} else if tr.Status == ActionFail && tr.Field != "" {
 			jtr.Short = fmt.Sprintf("%s: expected %v, got %v", tr.Field, tr.Expected, tr.Actual)
-			jtr.Errors = []jsonError{{
-				Message:  jtr.Short,
-				Line:     intPtr(tr.Line),
-				Severity: 1,
-			}}
+			jtr.Errors = []jsonError{newJSONError(jtr.Short, tr.Pos)}
 		}
 
 		results[tr.ID()] = jtr
@@ -254,7 +332,263 @@ func (j *JSONFormatter) Summary(result *Result) error {
 		Elapsed: result.Elapsed().Seconds(),
 		Ok:      result.Ok(),
 		Results: results,
+		Meta:    result.Meta,
 	})
 }
 
+// -----------------------------------------------------------------------------
+// JUnit Formatter
+// -----------------------------------------------------------------------------
+
+// JUnitFormatter writes a JUnit XML report on Summary, one testsuite per file.
+type JUnitFormatter struct {
+	w io.Writer
+}
+
+// NewJUnitFormatter creates a JUnit XML formatter.
+func NewJUnitFormatter(w io.Writer) *JUnitFormatter {
+	return &JUnitFormatter{w: w}
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	TestCases  []junitTestCase  `xml:"testcase"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// newJUnitProperties renders a suite's meta block as properties sorted by name.
+// Strings are written as is and other values as JSON.
+func newJUnitProperties(meta map[string]any) *junitProperties {
+	if len(meta) == 0 {
+		return nil
+	}
+
+	props := &junitProperties{}
+
+	for _, name := range slices.Sorted(maps.Keys(meta)) {
+		value, ok := meta[name].(string)
+		if !ok {
+			data, _ := json.Marshal(meta[name])
+			value = string(data)
+		}
+
+		props.Properties = append(props.Properties, junitProperty{Name: name, Value: value})
+	}
+
+	return props
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Format is a no-op; the report is written in one piece by Summary.
+func (j *JUnitFormatter) Format(Event, *Result) error {
+	return nil
+}
+
+// Summary writes the JUnit XML report.
+func (j *JUnitFormatter) Summary(result *Result) error {
+	report := junitTestSuites{
+		Tests:    result.Total,
+		Failures: result.Failed,
+		Errors:   result.Errors,
+		Skipped:  result.Skipped,
+		Time:     junitTime(result.Elapsed()),
+	}
+
+	// Group test cases by suite file, in the order tests ran.
+	index := make(map[string]int)
+
+	var elapsed []time.Duration
+
+	for _, path := range result.Order {
+		tr := result.Tests[path]
+
+		i, ok := index[tr.Suite]
+		if !ok {
+			i = len(report.Suites)
+			index[tr.Suite] = i
+			report.Suites = append(report.Suites, junitTestSuite{
+				Name:       tr.Suite,
+				Properties: newJUnitProperties(result.Meta[tr.Suite]),
+			})
+			elapsed = append(elapsed, 0)
+		}
+
+		suite := &report.Suites[i]
+		suite.Tests++
+		elapsed[i] += tr.Elapsed
+
+		tc := junitTestCase{
+			Name:      tr.PathString(),
+			Classname: tr.Suite,
+			Time:      junitTime(tr.Elapsed),
+			SystemOut: strings.Join(tr.Output, "\n"),
+		}
+
+		switch tr.Status {
+		case ActionFail:
+			suite.Failures++
+
+			msg := &junitMessage{}
+			if tr.Error != nil {
+				msg.Message = tr.Error.Error()
+			} else if tr.Field != "" {
+				msg.Message = fmt.Sprintf("%s: expected %v, got %v", tr.Field, tr.Expected, tr.Actual)
+			}
+
+			msg.Text = msg.Message
+			tc.Failure = msg
+		case ActionError:
+			suite.Errors++
+
+			tc.Error = &junitMessage{}
+			if tr.Error != nil {
+				tc.Error.Message = tr.Error.Error()
+				tc.Error.Text = tc.Error.Message
+			}
+		case ActionSkip:
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: tr.Reason}
+		case ActionPass, ActionRun, ActionOutput, ActionSetup:
+			// Passing tests have no child element
+		}
+
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	for i := range report.Suites {
+		report.Suites[i].Time = junitTime(elapsed[i])
+	}
+
+	_, err := io.WriteString(j.w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(j.w)
+	enc.Indent("", "  ")
 
+	err = enc.Encode(report)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(j.w, "\n")
+
+	return err
+}
+
+// junitTime formats a duration as seconds, the unit JUnit expects.
+func junitTime(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// -----------------------------------------------------------------------------
+// GitHub Formatter
+// -----------------------------------------------------------------------------
+
+// GitHubFormatter writes a GitHub Actions workflow command per failed or
+// errored test (`::error file=...,line=...,col=...::message`), which the Actions UI
+// shows as an annotation on that line. The usual summary goes to a separate
+// writer, typically stderr.
+type GitHubFormatter struct {
+	w       io.Writer
+	summary *VerboseFormatter
+}
+
+// NewGitHubFormatter creates a GitHub Actions formatter that writes
+// annotations to w and the summary to summary.
+func NewGitHubFormatter(w, summary io.Writer) *GitHubFormatter {
+	return &GitHubFormatter{w: w, summary: NewVerboseFormatter(summary)}
+}
+
+// Format is a no-op; annotations are written once per test by Summary, so
+// repeated runs don't annotate a test more than once.
+func (g *GitHubFormatter) Format(Event, *Result) error {
+	return nil
+}
+
+// Summary writes an annotation for each failed or errored test, then the summary.
+func (g *GitHubFormatter) Summary(result *Result) error {
+	for _, tr := range result.FailedTests() {
+		var message string
+
+		switch {
+		case tr.Error != nil:
+			message = tr.Error.Error()
+		case tr.Field != "":
+			message = fmt.Sprintf("%s: expected %v, got %v", tr.Field, tr.Expected, tr.Actual)
+		default:
+			message = "test failed"
+		}
+
+		if err := WriteGitHubAnnotation(g.w, "error", tr.Suite, tr.Pos, tr.PathString(), message); err != nil {
+			return err
+		}
+	}
+
+	return g.summary.Summary(result)
+}
+
+// WriteGitHubAnnotation writes a GitHub Actions workflow command annotating
+// file at pos (omitted when nil). level is the command: error, warning or notice.
+func WriteGitHubAnnotation(w io.Writer, level, file string, pos *Position, title, message string) error {
+	props := []string{"file=" + githubProperty(file)}
+	if pos != nil {
+		props = append(props, "line="+strconv.Itoa(pos.Line+1), "col="+strconv.Itoa(pos.Column+1))
+	}
+
+	props = append(props, "title="+githubProperty(title))
+
+	_, err := fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(props, ","), githubData(message))
+
+	return err
+}
+
+// githubData escapes a workflow command's message.
+func githubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubProperty escapes a workflow command's property value, which also
+// can't contain the separators.
+func githubProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(githubData(s))
+}
@@ -4,6 +4,9 @@ package runner
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 )
@@ -108,4 +111,125 @@ func TestJSONFormatter_Summary(t *testing.T) {
 	if !ok || okVal {
 		t.Errorf("ok = %v, want false", got["ok"])
 	}
-}
\ No newline at end of file
+}
+
+func TestJUnitFormatter_Summary(t *testing.T) {
+	var buf bytes.Buffer
+
+	f := NewJUnitFormatter(&buf)
+
+	result := NewResult()
+	result.Add(Event{Action: ActionPass, Suite: "a.scaf", Path: []string{"Q", "ok"}, Elapsed: 1500 * time.Millisecond})
+	result.Add(Event{Action: ActionError, Suite: "a.scaf", Path: []string{"Q", "broken"}, Error: errors.New("boom")})
+	result.Add(Event{Action: ActionSkip, Suite: "b.scaf", Path: []string{"R", "later"}, Reason: "flaky on CI"})
+	result.Finish()
+
+	_ = f.Format(Event{Action: ActionRun, Path: []string{"Q", "ok"}}, result)
+
+	if buf.Len() != 0 {
+		t.Errorf("Format wrote %q, want nothing until Summary", buf.String())
+	}
+
+	_ = f.Summary(result)
+
+	var got junitTestSuites
+
+	err := xml.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+
+	if got.Tests != 3 || got.Errors != 1 || got.Skipped != 1 || len(got.Suites) != 2 {
+		t.Fatalf("got %+v", got)
+	}
+
+	a := got.Suites[0]
+	if a.Name != "a.scaf" || a.Tests != 2 || a.Errors != 1 || a.Time != "1.500" {
+		t.Errorf("suite a = %+v", a)
+	}
+
+	if e := a.TestCases[1].Error; e == nil || e.Message != "boom" {
+		t.Errorf("error case = %+v, want message boom", a.TestCases[1])
+	}
+
+	if sk := got.Suites[1].TestCases[0].Skipped; sk == nil || sk.Message != "flaky on CI" {
+		t.Errorf("skipped case = %+v, want <skipped> with the reason", got.Suites[1].TestCases[0])
+	}
+}
+
+func TestFormatters_SuiteMeta(t *testing.T) {
+	result := NewResult()
+	result.SetMeta("a.scaf", map[string]any{"owner": "team-x", "tags": []any{"slow"}})
+	result.Add(Event{Action: ActionPass, Suite: "a.scaf", Path: []string{"Q", "ok"}})
+	result.Finish()
+
+	var jsonBuf bytes.Buffer
+
+	_ = NewJSONFormatter(&jsonBuf).Summary(result)
+
+	var summary jsonSummary
+
+	err := json.Unmarshal(jsonBuf.Bytes(), &summary)
+	if err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if owner := summary.Meta["a.scaf"]["owner"]; owner != "team-x" {
+		t.Errorf("JSON meta owner = %v, want team-x", owner)
+	}
+
+	var junitBuf bytes.Buffer
+
+	_ = NewJUnitFormatter(&junitBuf).Summary(result)
+
+	var report junitTestSuites
+
+	err = xml.Unmarshal(junitBuf.Bytes(), &report)
+	if err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+
+	want := []junitProperty{{Name: "owner", Value: "team-x"}, {Name: "tags", Value: `["slow"]`}}
+	if props := report.Suites[0].Properties; props == nil || !slices.Equal(props.Properties, want) {
+		t.Errorf("JUnit properties = %+v, want %+v", props, want)
+	}
+}
+
+func TestGitHubFormatter_Summary(t *testing.T) {
+	var out, summary bytes.Buffer
+
+	f := NewGitHubFormatter(&out, &summary)
+
+	result := NewResult()
+	result.Add(Event{Action: ActionPass, Suite: "a.scaf", Path: []string{"Q", "ok"}})
+	result.Add(Event{
+		Action: ActionFail, Suite: "a.scaf", Path: []string{"Q", "name"}, Pos: &Position{Line: 0, Column: 1},
+		Field: "u.name", Expected: "Alice", Actual: "Bob",
+	})
+	result.Add(Event{
+		Action: ActionError, Suite: "dir/b.scaf", Path: []string{"R", "a, b: c"}, Pos: &Position{Line: 11, Column: 4},
+		Error: errors.New("query failed\n100% broken"),
+	})
+	result.Add(Event{Action: ActionSkip, Suite: "b.scaf", Path: []string{"R", "later"}})
+	result.Add(Event{Action: ActionFail, Suite: "c.scaf", Path: []string{"S", "unplaced"}})
+	result.Finish()
+
+	_ = f.Format(Event{Action: ActionFail, Path: []string{"Q", "name"}}, result)
+
+	if out.Len() != 0 {
+		t.Errorf("Format wrote %q, want nothing until Summary", out.String())
+	}
+
+	_ = f.Summary(result)
+
+	want := "::error file=a.scaf,line=1,col=2,title=Q/name::u.name: expected Alice, got Bob\n" +
+		"::error file=dir/b.scaf,line=12,col=5,title=R/a%2C b%3A c::query failed%0A100%25 broken\n" +
+		"::error file=c.scaf,title=S/unplaced::test failed\n"
+	if got := out.String(); got != want {
+		t.Errorf("annotations:\n%s\nwant:\n%s", got, want)
+	}
+
+	if !bytes.Contains(summary.Bytes(), []byte("5 total, 1 passed, 2 failed, 1 skipped, 1 errors")) {
+		t.Errorf("summary = %q", summary.String())
+	}
+}
@@ -2,44 +2,14 @@ package runner_test
 
 import (
 	"context"
-	"maps"
 	"os"
 	"path/filepath"
 	"testing"
 
-	"github.com/rlch/scaf"
 	"github.com/rlch/scaf/module"
 	"github.com/rlch/scaf/runner"
 )
 
-// paramTrackingDatabase tracks executed queries and their parameters.
-type paramTrackingDatabase struct {
-	results  []map[string]any
-	executed []struct {
-		query  string
-		params map[string]any
-	}
-}
-
-func (d *paramTrackingDatabase) Name() string { return "param-tracking" }
-
-func (d *paramTrackingDatabase) Dialect() scaf.Dialect { return nil }
-
-func (d *paramTrackingDatabase) Execute(_ context.Context, query string, params map[string]any) ([]map[string]any, error) {
-	// Clone params to avoid mutation issues
-	clonedParams := make(map[string]any)
-	maps.Copy(clonedParams, params)
-
-	d.executed = append(d.executed, struct {
-		query  string
-		params map[string]any
-	}{query, clonedParams})
-
-	return d.results, nil
-}
-
-func (d *paramTrackingDatabase) Close() error { return nil }
-
 func TestRunner_FileBasedModuleResolution(t *testing.T) {
 	t.Parallel()
 
@@ -87,7 +57,7 @@ GetTest {
 	}
 
 	// Run with tracking dialect
-	d := &paramTrackingDatabase{results: []map[string]any{{"t.name": "hello"}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{"t.name": "hello"}}}
 	r := runner.New(
 		runner.WithDatabase(d),
 		runner.WithModules(resolved),
@@ -104,18 +74,18 @@ GetTest {
 	}
 
 	// Verify setup was executed with correct params
-	if len(d.executed) < 2 {
-		t.Fatalf("Expected at least 2 queries, got %d", len(d.executed))
+	if len(d.Executed) < 2 {
+		t.Fatalf("Expected at least 2 queries, got %d", len(d.Executed))
 	}
 
 	// First should be the setup
-	if d.executed[0].query != "CREATE (:Test {name: $name})" {
-		t.Errorf("First query = %q", d.executed[0].query)
+	if d.Executed[0].Query != "CREATE (:Test {name: $name})" {
+		t.Errorf("First query = %q", d.Executed[0].Query)
 	}
 
 	// Check params were passed correctly
-	if d.executed[0].params["name"] != "hello" {
-		t.Errorf("Setup params = %v, want name=hello", d.executed[0].params)
+	if d.Executed[0].Params["name"] != "hello" {
+		t.Errorf("Setup params = %v, want name=hello", d.Executed[0].Params)
 	}
 }
 
@@ -176,7 +146,7 @@ GetData {
 	}
 
 	// Run with tracking dialect
-	d := &paramTrackingDatabase{results: []map[string]any{{}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{}}}
 	r := runner.New(
 		runner.WithDatabase(d),
 		runner.WithModules(resolved),
@@ -193,18 +163,18 @@ GetData {
 	}
 
 	// Verify transitive setup was executed with correct params
-	if len(d.executed) < 2 {
-		t.Fatalf("Expected at least 2 queries, got %d", len(d.executed))
+	if len(d.Executed) < 2 {
+		t.Fatalf("Expected at least 2 queries, got %d", len(d.Executed))
 	}
 
 	// First should be the helper setup
-	if d.executed[0].query != "CREATE (:Helper {value: $value})" {
-		t.Errorf("First query = %q", d.executed[0].query)
+	if d.Executed[0].Query != "CREATE (:Helper {value: $value})" {
+		t.Errorf("First query = %q", d.Executed[0].Query)
 	}
 
 	// Check params were passed correctly (float64 from parser)
-	if d.executed[0].params["value"] != float64(42) {
-		t.Errorf("Setup params = %v, want value=42", d.executed[0].params)
+	if d.Executed[0].Params["value"] != float64(42) {
+		t.Errorf("Setup params = %v, want value=42", d.Executed[0].Params)
 	}
 }
 
@@ -261,7 +231,7 @@ GetData {
 	}
 
 	// Run with tracking dialect
-	d := &paramTrackingDatabase{results: []map[string]any{{}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{}}}
 	r := runner.New(
 		runner.WithDatabase(d),
 		runner.WithModules(resolved),
@@ -280,16 +250,16 @@ GetData {
 	// Track which setups were called
 	var hasGlobal, hasScope, hasTest bool
 
-	for _, e := range d.executed {
-		if e.query == "CREATE (:Global)" {
+	for _, e := range d.Executed {
+		if e.Query == "CREATE (:Global)" {
 			hasGlobal = true
 		}
 
-		if e.query == "CREATE (:Scope {id: $id})" && e.params["id"] == float64(1) {
+		if e.Query == "CREATE (:Scope {id: $id})" && e.Params["id"] == float64(1) {
 			hasScope = true
 		}
 
-		if e.query == "CREATE (:Test {name: $name})" && e.params["name"] == "inner" {
+		if e.Query == "CREATE (:Test {name: $name})" && e.Params["name"] == "inner" {
 			hasTest = true
 		}
 	}
@@ -330,7 +300,7 @@ GetData {
 	}
 
 	// Use RunFile which handles resolution automatically
-	d := &paramTrackingDatabase{results: []map[string]any{{}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{}}}
 	r := runner.New(runner.WithDatabase(d))
 
 	result, err := r.RunFile(context.Background(), mainPath)
@@ -344,12 +314,12 @@ GetData {
 	}
 
 	// Verify setup was executed
-	if len(d.executed) < 2 {
-		t.Fatalf("Expected at least 2 queries, got %d", len(d.executed))
+	if len(d.Executed) < 2 {
+		t.Fatalf("Expected at least 2 queries, got %d", len(d.Executed))
 	}
 
-	if d.executed[0].query != "CREATE (:Data)" {
-		t.Errorf("First query = %q, want setup query", d.executed[0].query)
+	if d.Executed[0].Query != "CREATE (:Data)" {
+		t.Errorf("First query = %q, want setup query", d.Executed[0].Query)
 	}
 }
 
@@ -397,7 +367,7 @@ GetData {
 	}
 
 	// Use RunFile
-	d := &paramTrackingDatabase{results: []map[string]any{{}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{}}}
 	r := runner.New(runner.WithDatabase(d))
 
 	result, err := r.RunFile(context.Background(), mainPath)
@@ -410,14 +380,14 @@ GetData {
 	}
 
 	// Verify setup was called with correct params
-	if len(d.executed) < 1 {
+	if len(d.Executed) < 1 {
 		t.Fatal("Expected at least 1 query")
 	}
 
 	found := false
 
-	for _, e := range d.executed {
-		if e.query == "CREATE (:Shared {key: $key})" && e.params["key"] == "test-value" {
+	for _, e := range d.Executed {
+		if e.Query == "CREATE (:Shared {key: $key})" && e.Params["key"] == "test-value" {
 			found = true
 
 			break
@@ -425,7 +395,7 @@ GetData {
 	}
 
 	if !found {
-		t.Errorf("Setup not executed with correct params. Executed: %v", d.executed)
+		t.Errorf("Setup not executed with correct params. Executed: %v", d.Executed)
 	}
 }
 
@@ -465,7 +435,7 @@ GetData {
 	}
 
 	// Use RunFile
-	d := &paramTrackingDatabase{results: []map[string]any{{}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{}}}
 	r := runner.New(runner.WithDatabase(d))
 
 	result, err := r.RunFile(context.Background(), mainPath)
@@ -480,9 +450,9 @@ GetData {
 	// Find the setup execution
 	var setupParams map[string]any
 
-	for _, e := range d.executed {
-		if e.query == "CREATE (:Node {str: $str, num: $num, bool: $bool, nothing: $nothing})" {
-			setupParams = e.params
+	for _, e := range d.Executed {
+		if e.Query == "CREATE (:Node {str: $str, num: $num, bool: $bool, nothing: $nothing})" {
+			setupParams = e.Params
 
 			break
 		}
@@ -542,7 +512,7 @@ GetData {
 	}
 
 	// Run - should fail because nonexistent module
-	d := &paramTrackingDatabase{results: []map[string]any{{}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{}}}
 	r := runner.New(
 		runner.WithDatabase(d),
 		runner.WithModules(resolved),
@@ -601,7 +571,7 @@ GetData {
 	}
 
 	// Run - should fail because setup doesn't exist
-	d := &paramTrackingDatabase{results: []map[string]any{{}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{}}}
 	r := runner.New(
 		runner.WithDatabase(d),
 		runner.WithModules(resolved),
@@ -654,7 +624,7 @@ GetData {
 	}
 
 	// Use RunFile
-	d := &paramTrackingDatabase{results: []map[string]any{{}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{}}}
 	r := runner.New(runner.WithDatabase(d))
 
 	result, err := r.RunFile(context.Background(), mainPath)
@@ -669,8 +639,8 @@ GetData {
 	// Count group setup executions
 	setupCount := 0
 
-	for _, e := range d.executed {
-		if e.query == "CREATE (:Group {name: $name})" && e.params["name"] == "group-setup" {
+	for _, e := range d.Executed {
+		if e.Query == "CREATE (:Group {name: $name})" && e.Params["name"] == "group-setup" {
 			setupCount++
 		}
 	}
@@ -719,7 +689,7 @@ GetData {
 	}
 
 	// Use RunFile
-	d := &paramTrackingDatabase{results: []map[string]any{{}}}
+	d := &runner.ParamTrackingDatabase{Results: []map[string]any{{}}}
 	r := runner.New(runner.WithDatabase(d))
 
 	result, err := r.RunFile(context.Background(), mainPath)
@@ -734,12 +704,12 @@ GetData {
 	// Both setups should have run
 	var hasInline, hasNamed bool
 
-	for _, e := range d.executed {
-		if e.query == "CREATE (:Inline)" {
+	for _, e := range d.Executed {
+		if e.Query == "CREATE (:Inline)" {
 			hasInline = true
 		}
 
-		if e.query == "CREATE (:Named)" {
+		if e.Query == "CREATE (:Named)" {
 			hasNamed = true
 		}
 	}
@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/rlch/scaf"
+)
+
+// WithProfile enables profiling: each test's main query runs through the
+// dialect's profiling mechanism (e.g., Cypher's PROFILE) and the reported plan
+// is attached to passing results. It is a no-op when the dialect or database
+// can't profile.
+func WithProfile(enabled bool) Option {
+	return func(r *Runner) {
+		r.profile = enabled
+	}
+}
+
+// executeMain runs a test's main query, profiling it if enabled and supported.
+// The plan is nil when the query wasn't profiled.
+func (r *Runner) executeMain(
+	ctx context.Context, exec executor, query string, params map[string]any,
+) ([]map[string]any, *scaf.QueryPlan, error) {
+	if r.profile {
+		profiler, canProfile := r.database.Dialect().(scaf.QueryProfiler)
+		pe, canExecute := exec.(scaf.ProfilingExecutor)
+
+		if canProfile && canExecute {
+			return pe.ExecuteProfiled(ctx, query, params, profiler)
+		}
+	}
+
+	rows, err := exec.Execute(ctx, query, params)
+
+	return rows, nil, err
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rlch/scaf"
+)
+
+// profileDialect wraps statements the way Cypher's PROFILE does.
+type profileDialect struct{}
+
+func (profileDialect) Name() string                                { return "profiled" }
+func (profileDialect) Analyze(string) (*scaf.QueryMetadata, error) { return &scaf.QueryMetadata{}, nil }
+func (profileDialect) MarkdownLanguage() string                    { return "text" }
+func (profileDialect) ProfileQuery(statement string) string        { return "PROFILE " + statement }
+
+// planDatabase returns a canned plan for profiled queries.
+type planDatabase struct {
+	mockDatabase
+
+	profiled []string
+}
+
+func (d *planDatabase) ExecuteProfiled(
+	ctx context.Context, query string, params map[string]any, profiler scaf.QueryProfiler,
+) ([]map[string]any, *scaf.QueryPlan, error) {
+	d.profiled = append(d.profiled, profiler.ProfileQuery(query))
+
+	rows, err := d.Execute(ctx, query, params)
+
+	return rows, &scaf.QueryPlan{Operator: "ProduceResults", DBHits: 42, Rows: 1}, err
+}
+
+func profileSuite() *scaf.Suite {
+	return &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "Q", Body: "MATCH (n) RETURN n.name AS name"}},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "Q",
+			Items: []*scaf.TestOrGroup{{Test: &scaf.Test{
+				Name: "t",
+				Statements: []*scaf.Statement{
+					{KeyParts: &scaf.DottedIdent{Parts: []string{"name"}}, Value: &scaf.Value{Str: ptr("Alice")}},
+				},
+			}}},
+		}},
+	}
+}
+
+func TestRunner_Profile(t *testing.T) {
+	db := &planDatabase{mockDatabase: mockDatabase{
+		results: []map[string]any{{"name": "Alice"}},
+		dialect: profileDialect{},
+	}}
+
+	result, err := New(WithDatabase(db), WithProfile(true)).Run(context.Background(), profileSuite(), "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(db.profiled) != 1 || !strings.HasPrefix(db.profiled[0], "PROFILE MATCH") {
+		t.Errorf("profiled queries = %v, want the main query wrapped once", db.profiled)
+	}
+
+	tr := result.Tests["Q/t"]
+	if tr == nil || tr.Status != ActionPass {
+		t.Fatalf("test result = %+v, want pass", tr)
+	}
+
+	want := scaf.QueryPlan{Operator: "ProduceResults", DBHits: 42, Rows: 1}
+	if tr.Plan == nil || *tr.Plan != want {
+		t.Errorf("Plan = %+v, want %+v", tr.Plan, want)
+	}
+}
+
+func TestRunner_ProfileUnsupported(t *testing.T) {
+	// The dialect can't profile, so the query runs normally.
+	db := &planDatabase{mockDatabase: mockDatabase{results: []map[string]any{{"name": "Alice"}}}}
+
+	result, err := New(WithDatabase(db), WithProfile(true)).Run(context.Background(), profileSuite(), "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(db.profiled) != 0 || len(db.executed) != 1 {
+		t.Errorf("profiled %d, executed %d; want 0 and 1", len(db.profiled), len(db.executed))
+	}
+
+	if tr := result.Tests["Q/t"]; tr == nil || tr.Status != ActionPass || tr.Plan != nil {
+		t.Errorf("test result = %+v, want pass without a plan", tr)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/rlch/scaf"
 )
 
 // Result accumulates test results during execution.
@@ -25,6 +27,9 @@ type Result struct {
 
 	// Order preserves insertion order for display
 	Order []string
+
+	// Meta holds each suite's meta block, by suite path, for reports
+	Meta map[string]map[string]any
 }
 
 // NewResult creates an initialized Result.
@@ -46,13 +51,23 @@ func (r *Result) Add(event Event) {
 
 	path := event.PathString()
 
+	if prev, ok := r.Tests[path]; ok {
+		r.addRepeat(prev, event)
+
+		return
+	}
+
 	tr := &TestResult{
 		Suite:   event.Suite,
 		Path:    event.Path,
 		Status:  event.Action,
 		Elapsed: event.Elapsed,
 		Error:   event.Error,
-		Line:    event.Line,
+		Pos:     event.Pos,
+		Reason:  event.Reason,
+		Bench:   event.Bench,
+		Plan:    event.Plan,
+		Runs:    1,
 	}
 
 	if event.Action == ActionFail {
@@ -61,24 +76,80 @@ func (r *Result) Add(event Event) {
 		tr.Field = event.Field
 	}
 
+	if isFailure(event.Action) {
+		tr.Failures = 1
+	}
+
 	r.Tests[path] = tr
 	r.Order = append(r.Order, path)
 	r.Total++
+	r.count(event.Action, 1)
+}
+
+// addRepeat records another run of a test that already has a result, as when
+// the suite is repeated. The test keeps its first failure: a later failure
+// replaces a pass or skip, but never an earlier failure.
+func (r *Result) addRepeat(tr *TestResult, event Event) {
+	tr.Runs++
+
+	if !isFailure(event.Action) {
+		return
+	}
+
+	tr.Failures++
+
+	if isFailure(tr.Status) {
+		return
+	}
+
+	r.count(tr.Status, -1)
+	r.count(event.Action, 1)
+
+	tr.Status = event.Action
+	tr.Elapsed = event.Elapsed
+	tr.Error = event.Error
+	tr.Pos = event.Pos
+	tr.Expected = event.Expected
+	tr.Actual = event.Actual
+	tr.Field = event.Field
+}
 
-	switch event.Action {
+// count adjusts the counter for a terminal action by n.
+func (r *Result) count(action Action, n int) {
+	switch action {
 	case ActionPass:
-		r.Passed++
+		r.Passed += n
 	case ActionFail:
-		r.Failed++
+		r.Failed += n
 	case ActionSkip:
-		r.Skipped++
+		r.Skipped += n
 	case ActionError:
-		r.Errors++
+		r.Errors += n
 	case ActionRun, ActionOutput, ActionSetup:
 		// Not terminal actions
 	}
 }
 
+func isFailure(action Action) bool {
+	return action == ActionFail || action == ActionError
+}
+
+// SetMeta records a suite's metadata. Empty metadata is not recorded.
+func (r *Result) SetMeta(suite string, meta map[string]any) {
+	if len(meta) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Meta == nil {
+		r.Meta = make(map[string]map[string]any)
+	}
+
+	r.Meta[suite] = meta
+}
+
 // AddOutput appends output to an existing test result.
 func (r *Result) AddOutput(event Event) {
 	if event.Action != ActionOutput {
@@ -155,6 +226,14 @@ func (r *Result) Merge(other *Result) {
 
 	maps.Copy(r.Tests, other.Tests)
 
+	if len(other.Meta) > 0 {
+		if r.Meta == nil {
+			r.Meta = make(map[string]map[string]any)
+		}
+
+		maps.Copy(r.Meta, other.Meta)
+	}
+
 	r.Order = append(r.Order, other.Order...)
 
 	// Update end time to the latest
@@ -171,7 +250,19 @@ type TestResult struct {
 	Elapsed time.Duration
 	Error   error
 	Output  []string
-	Line    int // 0-indexed line number in source file
+	Pos     *Position // Location of the test in its source file, if known
+	Reason  string    // Skip reason, for skipped tests
+
+	// Query latency stats, set when running in benchmark mode
+	Bench *BenchStats
+
+	// Main query plan, set when running in profile mode
+	Plan *scaf.QueryPlan
+
+	// Times the test ran and how many of those runs failed or errored;
+	// more than one run when the suite is repeated
+	Runs     int
+	Failures int
 
 	// Assertion failure details
 	Expected any
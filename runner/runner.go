@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"math/rand"
+	"path"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -23,12 +26,25 @@ var (
 
 // Runner executes scaf test suites.
 type Runner struct {
-	database scaf.Database
-	handler  Handler
-	failFast bool
-	filter   *regexp.Regexp
-	modules  *module.ResolvedContext
-	lag      bool // artificial lag for TUI testing
+	database  scaf.Database
+	handler   Handler
+	failFast  bool
+	maxFails  int // stop after this many failures; 0 means no limit
+	filter    *regexp.Regexp
+	exclude   []string // path globs of tests and groups to leave out
+	modules   *module.ResolvedContext
+	lag       bool // artificial lag for TUI testing
+	unordered bool // compare expected rows regardless of order
+	keepState bool // skip teardown and transaction rollback
+
+	concurrency int // max query scopes run at once; 0 or 1 runs them in order
+	repeat      int // times to run the suite; 0 or 1 runs it once
+
+	benchWarmup     int  // untimed query runs before benchmarking
+	benchIterations int  // timed query runs per test; 0 disables benchmarking
+	profile         bool // profile main queries and report their plans
+
+	skipped map[*scaf.Test]string // tests excluded by focus and skip directives, with any skip reason
 }
 
 // Option configures a Runner.
@@ -61,6 +77,15 @@ func WithFailFast(enabled bool) Option {
 	}
 }
 
+// WithMaxFailures stops the run once n tests have failed or errored.
+// Teardown for the current group, scope, and suite still runs, and the result
+// covers every test that ran. A non-positive n means no limit.
+func WithMaxFailures(n int) Option {
+	return func(r *Runner) {
+		r.maxFails = n
+	}
+}
+
 // WithFilter sets a regex pattern to filter which tests run.
 // Tests whose path matches the pattern will be executed.
 func WithFilter(pattern string) Option {
@@ -71,6 +96,15 @@ func WithFilter(pattern string) Option {
 	}
 }
 
+// WithExclude leaves out tests whose path, or the path of an enclosing group,
+// matches any of the given globs (e.g. "GetUser/slow" or "*/legacy *").
+// Exclusion wins over WithFilter.
+func WithExclude(patterns ...string) Option {
+	return func(r *Runner) {
+		r.exclude = append(r.exclude, patterns...)
+	}
+}
+
 // WithModules sets the resolved module context for named setup resolution.
 func WithModules(ctx *module.ResolvedContext) Option {
 	return func(r *Runner) {
@@ -85,6 +119,50 @@ func WithLag(enabled bool) Option {
 	}
 }
 
+// WithUnorderedRows compares a test's expected rows against the result set
+// regardless of order. By default rows must match in the order returned,
+// except in tests marked // scaf:unordered.
+func WithUnorderedRows(enabled bool) Option {
+	return func(r *Runner) {
+		r.unordered = enabled
+	}
+}
+
+// WithKeepState leaves database state in place for debugging: every teardown
+// clause is skipped and tests run outside a rolled-back transaction, so data
+// written by setup and by the test itself persists after the run.
+//
+// Because nothing is cleaned up, tests can observe each other's data, so this
+// cannot be combined with WithConcurrency.
+func WithKeepState(enabled bool) Option {
+	return func(r *Runner) {
+		r.keepState = enabled
+	}
+}
+
+// WithConcurrency runs up to n query scopes at once. It relies on per-test
+// rollback for isolation, so it has no effect unless the database supports
+// transactions, and Run refuses to combine it with WithKeepState. Scopes with
+// their own setup or teardown (or in a profile or group) write shared state
+// and still run one at a time. Events are reported in source order.
+func WithConcurrency(n int) Option {
+	return func(r *Runner) {
+		r.concurrency = n
+	}
+}
+
+// WithRepeat runs the whole suite n times, to expose flaky tests. Suite,
+// scope, and group setup and teardown run again on every iteration. Each test
+// appears once in the result, with Runs and Failures counting its runs and
+// keeping the details of its first failure; a test that fails on any run
+// counts as failed. With WithConcurrency, scopes run concurrently within each
+// iteration and iterations run one after another.
+func WithRepeat(n int) Option {
+	return func(r *Runner) {
+		r.repeat = n
+	}
+}
+
 // New creates a Runner with the given options.
 func New(opts ...Option) *Runner {
 	r := &Runner{}
@@ -134,72 +212,158 @@ func (r *Runner) Run(ctx context.Context, suite *scaf.Suite, suitePath string) (
 		handlers = append(handlers, r.handler)
 	}
 
-	if r.failFast {
-		handlers = append(handlers, NewStopOnFailHandler(1))
+	if r.concurrency > 1 && r.keepState {
+		return nil, ErrConcurrentKeepState
+	}
+
+	maxFails := r.failLimit()
+	if maxFails > 0 {
+		handlers = append(handlers, NewStopOnFailHandler(maxFails))
 	}
 
 	handler := NewMultiHandler(handlers...)
 
+	r.skipped = skippedTests(suite)
+	result.SetMeta(suitePath, suite.Metadata())
+
 	// Build query lookup map
 	queries := make(map[string]string)
 	for _, q := range suite.Queries {
 		queries[q.Name] = q.Body
 	}
 
-	// Execute suite setup
-	if suite.Setup != nil {
-		err := r.executeSetup(ctx, r.database, suite.Setup)
+	for range max(r.repeat, 1) {
+		err := r.runSuite(ctx, suite, queries, suitePath, handler, result)
+		if errors.Is(err, ErrMaxFailures) {
+			break
+		}
+
 		if err != nil {
-			return result, fmt.Errorf("suite setup: %w", err)
+			result.Finish()
+
+			return result, err
 		}
-	}
 
-	// Run all scopes
-	for _, scope := range suite.Scopes {
-		err := r.runQueryScope(ctx, scope, queries, suitePath, handler, result)
-		if errors.Is(err, ErrMaxFailures) {
+		// Concurrent scopes spend a fresh failure budget each iteration.
+		if maxFails > 0 && result.Failed+result.Errors >= maxFails {
 			break
 		}
+	}
+
+	result.Finish()
+
+	return result, nil
+}
+
+// runSuite runs one iteration of the suite: its setup, every scope, and its
+// teardown. It returns ErrMaxFailures once the failure limit is reached.
+func (r *Runner) runSuite(
+	ctx context.Context,
+	suite *scaf.Suite,
+	queries map[string]string,
+	suitePath string,
+	handler Handler,
+	result *Result,
+) error {
+	binds := make(bindings)
 
+	// Execute suite setup
+	if suite.Setup != nil {
+		err := r.executeSetup(ctx, r.database, suite.Setup, binds)
 		if err != nil {
-			// Run suite teardown even on error
-			if suite.Teardown != nil {
-				_ = r.executeQuery(ctx, r.database, *suite.Teardown, nil)
-			}
+			return fmt.Errorf("suite setup: %w", err)
+		}
+	}
 
-			return result, err
+	// Run all scopes, concurrently if enabled and tests are rolled back
+	runScopes := r.runScopes
+	if _, canTx := r.database.(scaf.TransactionalDatabase); r.concurrency > 1 && canTx {
+		runScopes = r.runScopesConcurrently
+	}
+
+	err := runScopes(ctx, suite, queries, binds, suitePath, handler, result)
+	if err != nil && !errors.Is(err, ErrMaxFailures) {
+		// Run suite teardown even on error
+		if suite.Teardown != nil {
+			_ = r.executeTeardown(ctx, suite.Teardown)
 		}
+
+		return err
 	}
 
 	// Execute suite teardown
 	if suite.Teardown != nil {
-		err := r.executeQuery(ctx, r.database, *suite.Teardown, nil)
-		if err != nil {
-			return result, fmt.Errorf("suite teardown: %w", err)
+		teardownErr := r.executeTeardown(ctx, suite.Teardown)
+		if teardownErr != nil {
+			return fmt.Errorf("suite teardown: %w", teardownErr)
 		}
 	}
 
-	result.Finish()
+	return err
+}
 
-	return result, nil
+// runScopes runs the suite's scopes in order, stopping at the first error.
+func (r *Runner) runScopes(
+	ctx context.Context,
+	suite *scaf.Suite,
+	queries map[string]string,
+	binds bindings,
+	suitePath string,
+	handler Handler,
+	result *Result,
+) error {
+	for _, scope := range suite.Scopes {
+		err := r.runQueryScope(ctx, suite, scope, queries, binds.child(), suitePath, handler, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
+// runQueryScope runs a scope's tests. If the scope extends a profile, the profile's
+// setup runs before the scope's setup and its teardown runs after the scope's teardown.
 func (r *Runner) runQueryScope(
 	ctx context.Context,
+	suite *scaf.Suite,
 	scope *scaf.QueryScope,
 	queries map[string]string,
+	binds bindings,
 	suitePath string,
 	handler Handler,
 	result *Result,
 ) error {
-	queryBody, ok := queries[scope.QueryName]
-	if !ok {
+	query := suite.Query(scope.QueryName)
+	if query == nil {
 		return fmt.Errorf("%w: %s", ErrUnknownQuery, scope.QueryName)
 	}
 
+	for _, c := range scope.Trailing {
+		if !c.Promoted(scope) {
+			return fmt.Errorf("%w: %s at line %d", ErrDuplicateClause, scope.QueryName, c.Pos.Line)
+		}
+	}
+
+	var profile *scaf.Profile
+	if scope.Extends != nil {
+		profile = suite.Profile(*scope.Extends)
+		if profile == nil {
+			return fmt.Errorf("%w: %s", ErrUnknownProfile, *scope.Extends)
+		}
+	}
+
+	// Execute profile setup
+	if profile != nil && profile.Setup != nil {
+		err := r.executeSetup(ctx, r.database, profile.Setup, binds)
+		if err != nil {
+			return fmt.Errorf("scope %s profile %s setup: %w", scope.QueryName, profile.Name, err)
+		}
+	}
+
 	// Execute scope setup
 	if scope.Setup != nil {
-		err := r.executeSetup(ctx, r.database, scope.Setup)
+		err := r.executeSetup(ctx, r.database, scope.Setup, binds)
 		if err != nil {
 			return fmt.Errorf("scope %s setup: %w", scope.QueryName, err)
 		}
@@ -213,15 +377,21 @@ func (r *Runner) runQueryScope(
 
 		switch {
 		case item.Test != nil:
-			err = r.runTest(ctx, item.Test, queryBody, queries, path, suitePath, handler, result)
+			err = r.runTest(ctx, item.Test, query, queries, binds, path, suitePath, handler, result)
 		case item.Group != nil:
-			err = r.runGroup(ctx, item.Group, queryBody, queries, path, suitePath, handler, result)
+			err = r.runGroup(ctx, item.Group, query, queries, binds.child(), path, suitePath, handler, result)
+		case item.Sequence != nil:
+			err = r.runSequence(ctx, item.Sequence, query, queries, binds, path, suitePath, handler, result)
 		}
 
 		if errors.Is(err, ErrMaxFailures) {
-			// Run scope teardown before returning
+			// Run scope and profile teardown before returning
 			if scope.Teardown != nil {
-				_ = r.executeQuery(ctx, r.database, *scope.Teardown, nil)
+				_ = r.executeTeardown(ctx, scope.Teardown)
+			}
+
+			if profile != nil && profile.Teardown != nil {
+				_ = r.executeTeardown(ctx, profile.Teardown)
 			}
 
 			return err
@@ -230,20 +400,29 @@ func (r *Runner) runQueryScope(
 
 	// Execute scope teardown
 	if scope.Teardown != nil {
-		err := r.executeQuery(ctx, r.database, *scope.Teardown, nil)
+		err := r.executeTeardown(ctx, scope.Teardown)
 		if err != nil {
 			return fmt.Errorf("scope %s teardown: %w", scope.QueryName, err)
 		}
 	}
 
+	// Execute profile teardown
+	if profile != nil && profile.Teardown != nil {
+		err := r.executeTeardown(ctx, profile.Teardown)
+		if err != nil {
+			return fmt.Errorf("scope %s profile %s teardown: %w", scope.QueryName, profile.Name, err)
+		}
+	}
+
 	return nil
 }
 
 func (r *Runner) runGroup(
 	ctx context.Context,
 	group *scaf.Group,
-	queryBody string,
+	query *scaf.Query,
 	queries map[string]string,
+	binds bindings,
 	parentPath []string,
 	suitePath string,
 	handler Handler,
@@ -255,7 +434,7 @@ func (r *Runner) runGroup(
 
 	// Execute group setup
 	if group.Setup != nil {
-		err := r.executeSetup(ctx, r.database, group.Setup)
+		err := r.executeSetup(ctx, r.database, group.Setup, binds)
 		if err != nil {
 			return fmt.Errorf("group %s setup: %w", group.Name, err)
 		}
@@ -267,15 +446,17 @@ func (r *Runner) runGroup(
 
 		switch {
 		case item.Test != nil:
-			err = r.runTest(ctx, item.Test, queryBody, queries, path, suitePath, handler, result)
+			err = r.runTest(ctx, item.Test, query, queries, binds, path, suitePath, handler, result)
 		case item.Group != nil:
-			err = r.runGroup(ctx, item.Group, queryBody, queries, path, suitePath, handler, result)
+			err = r.runGroup(ctx, item.Group, query, queries, binds.child(), path, suitePath, handler, result)
+		case item.Sequence != nil:
+			err = r.runSequence(ctx, item.Sequence, query, queries, binds, path, suitePath, handler, result)
 		}
 
 		if errors.Is(err, ErrMaxFailures) {
 			// Run group teardown before returning
 			if group.Teardown != nil {
-				_ = r.executeQuery(ctx, r.database, *group.Teardown, nil)
+				_ = r.executeTeardown(ctx, group.Teardown)
 			}
 
 			return err
@@ -284,7 +465,7 @@ func (r *Runner) runGroup(
 
 	// Execute group teardown
 	if group.Teardown != nil {
-		err := r.executeQuery(ctx, r.database, *group.Teardown, nil)
+		err := r.executeTeardown(ctx, group.Teardown)
 		if err != nil {
 			return fmt.Errorf("group %s teardown: %w", group.Name, err)
 		}
@@ -293,11 +474,81 @@ func (r *Runner) runGroup(
 	return nil
 }
 
+// runSequence runs a sequence's tests in order on one executor, so each test
+// sees the writes of the tests before it. The writes are rolled back after the
+// last test when the database supports transactions and state isn't kept.
+func (r *Runner) runSequence(
+	ctx context.Context,
+	seq *scaf.Sequence,
+	query *scaf.Query,
+	queries map[string]string,
+	binds bindings,
+	parentPath []string,
+	suitePath string,
+	handler Handler,
+	result *Result,
+) error {
+	var exec executor = r.database
+
+	if txDB, canTx := r.database.(scaf.TransactionalDatabase); canTx && !r.keepState {
+		tx, err := txDB.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("sequence: begin transaction: %w", err)
+		}
+
+		defer func() {
+			_ = tx.Rollback(ctx)
+		}()
+
+		exec = tx
+	}
+
+	for _, test := range seq.Tests {
+		err := r.runTestOn(ctx, exec, test, query, queries, binds, parentPath, suitePath, handler, result)
+		if errors.Is(err, ErrMaxFailures) {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *Runner) runTest(
 	ctx context.Context,
 	test *scaf.Test,
-	queryBody string,
+	query *scaf.Query,
+	queries map[string]string,
+	binds bindings,
+	parentPath []string,
+	suitePath string,
+	handler Handler,
+	result *Result,
+) error {
+	return r.runTestOn(ctx, nil, test, query, queries, binds, parentPath, suitePath, handler, result)
+}
+
+// locatedHandler sets the position of the test it reports on each event.
+type locatedHandler struct {
+	Handler
+
+	pos *Position
+}
+
+func (h locatedHandler) Event(ctx context.Context, event Event, result *Result) error {
+	event.Pos = h.pos
+
+	return h.Handler.Event(ctx, event, result)
+}
+
+// runTestOn runs a test on exec, or in a transaction of its own (when
+// possible) if exec is nil.
+func (r *Runner) runTestOn(
+	ctx context.Context,
+	exec executor,
+	test *scaf.Test,
+	query *scaf.Query,
 	queries map[string]string,
+	binds bindings,
 	parentPath []string,
 	suitePath string,
 	handler Handler,
@@ -307,13 +558,28 @@ func (r *Runner) runTest(
 	copy(path, parentPath)
 	path[len(parentPath)] = test.Name
 
-	// Check if test matches filter
-	if !r.matchesFilter(path) {
+	// Check if test matches filter and isn't excluded
+	if !r.matchesFilter(path) || r.excluded(path) {
 		return nil
 	}
 
+	// Tests built without parsing have no position.
+	if test.Pos.Line > 0 {
+		handler = locatedHandler{Handler: handler, pos: &Position{Line: test.Pos.Line - 1, Column: test.Pos.Column - 1}}
+	}
+
 	start := time.Now()
 
+	if reason, ok := r.skipped[test]; ok {
+		return handler.Event(ctx, Event{
+			Time:   start,
+			Action: ActionSkip,
+			Suite:  suitePath,
+			Path:   path,
+			Reason: reason,
+		}, result)
+	}
+
 	_ = handler.Event(ctx, Event{
 		Time:   start,
 		Action: ActionRun,
@@ -326,22 +592,28 @@ func (r *Runner) runTest(
 		time.Sleep(time.Duration(500+rand.Intn(1000)) * time.Millisecond) //nolint:gosec // G404: weak random is fine for artificial lag
 	}
 
-	// Try to run test in a transaction for isolation
+	// Tests in a sequence share the sequence's executor
+	if exec != nil {
+		return r.runTestDirect(ctx, exec, test, query, queries, binds, path, suitePath, start, handler, result)
+	}
+
+	// Try to run test in a transaction for isolation, unless state should be kept
 	txDB, canTx := r.database.(scaf.TransactionalDatabase)
-	if canTx {
-		return r.runTestInTransaction(ctx, txDB, test, queryBody, queries, path, suitePath, start, handler, result)
+	if canTx && !r.keepState {
+		return r.runTestInTransaction(ctx, txDB, test, query, queries, binds, path, suitePath, start, handler, result)
 	}
 
 	// Fallback: run without transaction isolation
-	return r.runTestDirect(ctx, r.database, test, queryBody, queries, path, suitePath, start, handler, result)
+	return r.runTestDirect(ctx, r.database, test, query, queries, binds, path, suitePath, start, handler, result)
 }
 
 func (r *Runner) runTestInTransaction(
 	ctx context.Context,
 	txDB scaf.TransactionalDatabase,
 	test *scaf.Test,
-	queryBody string,
+	query *scaf.Query,
 	queries map[string]string,
+	binds bindings,
 	path []string,
 	suitePath string,
 	start time.Time,
@@ -358,15 +630,16 @@ func (r *Runner) runTestInTransaction(
 		_ = tx.Rollback(ctx)
 	}()
 
-	return r.runTestDirect(ctx, tx, test, queryBody, queries, path, suitePath, start, handler, result)
+	return r.runTestDirect(ctx, tx, test, query, queries, binds, path, suitePath, start, handler, result)
 }
 
 func (r *Runner) runTestDirect(
 	ctx context.Context,
 	exec executor,
 	test *scaf.Test,
-	queryBody string,
+	query *scaf.Query,
 	queries map[string]string,
+	binds bindings,
 	path []string,
 	suitePath string,
 	start time.Time,
@@ -375,7 +648,9 @@ func (r *Runner) runTestDirect(
 ) error {
 	// Execute test setup (within transaction if available)
 	if test.Setup != nil {
-		err := r.executeSetup(ctx, exec, test.Setup)
+		binds = binds.child()
+
+		err := r.executeSetup(ctx, exec, test.Setup, binds)
 		if err != nil {
 			return r.emitError(ctx, path, suitePath, start, fmt.Errorf("test setup: %w", err), handler, result)
 		}
@@ -388,14 +663,59 @@ func (r *Runner) runTestDirect(
 	for _, stmt := range test.Statements {
 		key := stmt.Key()
 		if len(key) > 0 && key[0] == '$' {
+			if stmt.Value.Computed != nil {
+				return r.emitError(ctx, path, suitePath, start, fmt.Errorf("%w: %s", ErrComputedInput, key), handler, result)
+			}
+
 			params[key[1:]] = stmt.Value.ToGo()
+		} else if stmt.Value.Computed != nil {
+			expected, err := evalComputed(stmt.Value.Computed, binds)
+			if err != nil {
+				return r.emitError(ctx, path, suitePath, start, fmt.Errorf("%s: %w", key, err), handler, result)
+			}
+
+			expectations[key] = expected
 		} else {
-			expectations[key] = stmt.Value.ToGo()
+			expectations[key] = expectedValue(stmt.Value)
+		}
+	}
+
+	// An expect block's entries apply to fields the statements don't name
+	if test.Expect != nil {
+		for _, e := range test.Expect.Entries {
+			if _, ok := expectations[e.Key]; ok {
+				continue
+			}
+
+			if e.Value.Computed == nil {
+				expectations[e.Key] = expectedValue(e.Value)
+
+				continue
+			}
+
+			expected, err := evalComputed(e.Value.Computed, binds)
+			if err != nil {
+				return r.emitError(ctx, path, suitePath, start, fmt.Errorf("expect %s: %w", e.Key, err), handler, result)
+			}
+
+			expectations[e.Key] = expected
+		}
+	}
+
+	// Positional keys name the query's columns by index
+	if err := r.resolveColumnKeys(query.Body, expectations); err != nil {
+		return r.emitError(ctx, path, suitePath, start, err, handler, result)
+	}
+
+	// Bind query defaults for parameters the test omits
+	for name, value := range query.ParamDefaults() {
+		if _, ok := params[name]; !ok {
+			params[name] = value.ToGo()
 		}
 	}
 
 	// Execute query
-	rows, err := exec.Execute(ctx, queryBody, params)
+	rows, plan, err := r.executeMain(ctx, exec, query.Body, params)
 	if err != nil {
 		return r.emitError(ctx, path, suitePath, start, err, handler, result)
 	}
@@ -408,15 +728,45 @@ func (r *Runner) runTestDirect(
 		actual = make(map[string]any)
 	}
 
+	// An expect block describes the single row as a whole
+	if test.Expect != nil {
+		if field, expected, got, ok := compareWholeRow(expectations, rows); !ok {
+			elapsed := time.Since(start)
+
+			return handler.Event(ctx, Event{
+				Time:     time.Now(),
+				Action:   ActionFail,
+				Suite:    suitePath,
+				Path:     path,
+				Elapsed:  elapsed,
+				Field:    field,
+				Expected: expected,
+				Actual:   got,
+			}, result)
+		}
+	}
+
 	// Check each expectation
 	for field, expected := range expectations {
-		got, exists := actual[field]
-		if !exists {
-			// Field doesn't exist in result - treat as nil
-			got = nil
+		if got, ok := fieldMatches(expected, actual, field); !ok {
+			elapsed := time.Since(start)
+
+			return handler.Event(ctx, Event{
+				Time:     time.Now(),
+				Action:   ActionFail,
+				Suite:    suitePath,
+				Path:     path,
+				Elapsed:  elapsed,
+				Field:    field,
+				Expected: expected,
+				Actual:   got,
+			}, result)
 		}
+	}
 
-		if !valuesEqual(expected, got) {
+	// Compare the full result set against expected rows
+	if len(test.ExpectedRows) > 0 {
+		if field, expected, got, ok := compareRows(test.ExpectedRows, rows, r.unordered || test.HasDirective(scaf.DirectiveUnordered)); !ok {
 			elapsed := time.Since(start)
 
 			return handler.Event(ctx, Event{
@@ -434,23 +784,203 @@ func (r *Runner) runTestDirect(
 
 	// Evaluate assert blocks
 	for _, assert := range test.Asserts {
-		err := r.evaluateAssert(ctx, exec, assert, actual, queries, path, suitePath, start, handler, result)
+		if assert.Idempotent {
+			rerun, err := exec.Execute(ctx, query.Body, params)
+			if err != nil {
+				return r.emitError(ctx, path, suitePath, start, fmt.Errorf("idempotent rerun: %w", err), handler, result)
+			}
+
+			if !resultsEqual(rows, rerun) {
+				elapsed := time.Since(start)
+
+				return handler.Event(ctx, Event{
+					Time:     time.Now(),
+					Action:   ActionFail,
+					Suite:    suitePath,
+					Path:     path,
+					Elapsed:  elapsed,
+					Field:    "idempotent",
+					Expected: rows,
+					Actual:   rerun,
+				}, result)
+			}
+
+			continue
+		}
+
+		err := r.evaluateAssert(ctx, exec, assert, rows, queries, path, suitePath, start, handler, result)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Elapsed covers the test itself; benchmark iterations are reported separately.
 	elapsed := time.Since(start)
 
+	bench, err := r.benchQuery(ctx, exec, query.Body, params)
+	if err != nil {
+		return r.emitError(ctx, path, suitePath, start, fmt.Errorf("bench: %w", err), handler, result)
+	}
+
 	return handler.Event(ctx, Event{
 		Time:    time.Now(),
 		Action:  ActionPass,
 		Suite:   suitePath,
 		Path:    path,
 		Elapsed: elapsed,
+		Bench:   bench,
+		Plan:    plan,
 	}, result)
 }
 
+// compareRows checks the result set against a test's expected rows.
+// Each expected row only constrains the fields it names. When unordered is set,
+// the rows match when each expected row can be paired with a distinct actual row.
+// On mismatch, returns the failing field with its expected and actual values.
+func compareRows(expected []*scaf.Map, actual []map[string]any, unordered bool) (string, any, any, bool) {
+	if len(expected) != len(actual) {
+		return "rows", len(expected), len(actual), false
+	}
+
+	if !unordered {
+		for i, row := range expected {
+			for _, e := range row.Entries {
+				want := expectedValue(e.Value)
+				if got, ok := fieldMatches(want, actual[i], e.Key); !ok {
+					return fmt.Sprintf("rows[%d].%s", i, e.Key), want, got, false
+				}
+			}
+		}
+
+		return "", nil, nil, true
+	}
+
+	// An expected row may fit several actual rows, so taking the first fit can
+	// leave a later expected row without one; find a full matching instead,
+	// moving earlier rows along augmenting paths.
+	fits := make([][]int, len(expected))
+
+	for i, row := range expected {
+		for j, candidate := range actual {
+			if rowMatches(row, candidate) {
+				fits[i] = append(fits[i], j)
+			}
+		}
+	}
+
+	owner := make([]int, len(actual)) // the expected row matched to each actual row, or -1
+	for j := range owner {
+		owner[j] = -1
+	}
+
+	var augment func(i int, visited []bool) bool
+
+	augment = func(i int, visited []bool) bool {
+		for _, j := range fits[i] {
+			if visited[j] {
+				continue
+			}
+
+			visited[j] = true
+
+			if owner[j] < 0 || augment(owner[j], visited) {
+				owner[j] = i
+
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for i, row := range expected {
+		if !augment(i, make([]bool, len(actual))) {
+			return fmt.Sprintf("rows[%d]", i), (&scaf.Value{Map: row}).ToGo(), nil, false
+		}
+	}
+
+	return "", nil, nil, true
+}
+
+// resultsEqual reports whether two result sets have the same rows in the same order.
+func resultsEqual(first, second []map[string]any) bool {
+	if len(first) != len(second) {
+		return false
+	}
+
+	for i, row := range first {
+		if len(row) != len(second[i]) {
+			return false
+		}
+
+		for key, want := range row {
+			got, ok := second[i][key]
+			if !ok || !valuesEqual(want, got) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// compareWholeRow checks that the result is a single row with no fields
+// beyond the expected ones; the expected values are checked separately, like
+// statements. On mismatch, returns the failing field with its expected and
+// actual values.
+func compareWholeRow(expected map[string]any, rows []map[string]any) (string, any, any, bool) {
+	if len(rows) != 1 {
+		return "rows", 1, len(rows), false
+	}
+
+	fields := slices.Sorted(maps.Keys(rows[0]))
+	for _, field := range fields {
+		if _, ok := expected[field]; !ok {
+			return field, absentValue{}, rows[0][field], false
+		}
+	}
+
+	return "", nil, nil, true
+}
+
+// rowMatches reports whether every field of the expected row equals the actual row's value.
+func rowMatches(expected *scaf.Map, actual map[string]any) bool {
+	for _, e := range expected.Entries {
+		if _, ok := fieldMatches(expectedValue(e.Value), actual, e.Key); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// absentValue is the expected value of a field written as `absent`.
+type absentValue struct{}
+
+func (absentValue) String() string { return "absent" }
+
+// expectedValue converts an expected value to Go, keeping absent distinct from null.
+func expectedValue(v *scaf.Value) any {
+	if v.Absent {
+		return absentValue{}
+	}
+
+	return v.ToGo()
+}
+
+// fieldMatches compares an expected value against a field of a result row.
+// A missing field matches null; only a missing field matches absent.
+// Returns the actual value (nil if missing) for reporting.
+func fieldMatches(expected any, row map[string]any, field string) (any, bool) {
+	got, exists := row[field]
+
+	if _, ok := expected.(absentValue); ok {
+		return got, !exists
+	}
+
+	return got, valuesEqual(expected, got)
+}
+
 // valuesEqual compares expected and actual values for equality.
 func valuesEqual(expected, actual any) bool {
 	// Handle nil cases
@@ -490,14 +1020,28 @@ func (r *Runner) executeQuery(ctx context.Context, exec executor, query string,
 	return err
 }
 
+// executeTeardown runs a teardown query, unless it is nil or state is being kept.
+func (r *Runner) executeTeardown(ctx context.Context, teardown *string) error {
+	if teardown == nil || r.keepState {
+		return nil
+	}
+
+	return r.executeQuery(ctx, r.database, *teardown, nil)
+}
+
 // executeSetup executes a setup clause - inline, module, call, or block.
-func (r *Runner) executeSetup(ctx context.Context, exec executor, setup *scaf.SetupClause) error {
+// Rows returned by a bound inline query or call are recorded in binds.
+func (r *Runner) executeSetup(ctx context.Context, exec executor, setup *scaf.SetupClause, binds bindings) error {
 	if setup == nil {
 		return nil
 	}
 
+	if setup.Binding != nil && (setup.Module != nil || len(setup.Block) > 0) {
+		return fmt.Errorf("%w: %s", ErrUnbindableSetup, *setup.Binding)
+	}
+
 	if setup.Inline != nil {
-		return r.executeQuery(ctx, exec, *setup.Inline, nil)
+		return r.executeBound(ctx, exec, setup.Binding, *setup.Inline, nil, binds)
 	}
 
 	if setup.Module != nil {
@@ -505,12 +1049,12 @@ func (r *Runner) executeSetup(ctx context.Context, exec executor, setup *scaf.Se
 	}
 
 	if setup.Call != nil {
-		return r.executeSetupCall(ctx, exec, setup.Call)
+		return r.executeSetupCall(ctx, exec, setup.Call, setup.Binding, binds)
 	}
 
 	// Block setup - execute each item in order
 	for _, item := range setup.Block {
-		err := r.executeSetupItem(ctx, exec, item)
+		err := r.executeSetupItem(ctx, exec, item, binds)
 		if err != nil {
 			return err
 		}
@@ -519,24 +1063,42 @@ func (r *Runner) executeSetup(ctx context.Context, exec executor, setup *scaf.Se
 	return nil
 }
 
-// executeSetupItem executes a single setup item (inline, module, or call).
-func (r *Runner) executeSetupItem(ctx context.Context, exec executor, item *scaf.SetupItem) error {
+// executeSetupItem executes a single setup item (inline, module, call, or data table).
+// A bound data table binds its own rows.
+func (r *Runner) executeSetupItem(ctx context.Context, exec executor, item *scaf.SetupItem, binds bindings) error {
 	if item.Inline != nil {
-		return r.executeQuery(ctx, exec, *item.Inline, nil)
+		return r.executeBound(ctx, exec, item.Binding, *item.Inline, nil, binds)
+	}
+
+	if item.Data != nil {
+		query, err := r.bulkInsertQuery(item.Data)
+		if err != nil {
+			return err
+		}
+
+		rows := item.Data.Bindings()
+		binds.bind(item.Binding, rows)
+
+		return r.executeQuery(ctx, exec, query, map[string]any{"rows": rows})
 	}
 
 	if item.Module != nil {
+		if item.Binding != nil {
+			return fmt.Errorf("%w: %s", ErrUnbindableSetup, *item.Binding)
+		}
+
 		return r.executeModuleSetup(ctx, exec, *item.Module)
 	}
 
 	if item.Call != nil {
-		return r.executeSetupCall(ctx, exec, item.Call)
+		return r.executeSetupCall(ctx, exec, item.Call, item.Binding, binds)
 	}
 
 	return nil
 }
 
 // executeModuleSetup runs an imported module's setup clause.
+// Bindings made by the module's setup stay private to it.
 func (r *Runner) executeModuleSetup(ctx context.Context, exec executor, moduleAlias string) error {
 	if r.modules == nil {
 		return fmt.Errorf("%w: %s", ErrNoModuleContext, moduleAlias)
@@ -555,13 +1117,16 @@ func (r *Runner) executeModuleSetup(ctx context.Context, exec executor, moduleAl
 	}
 
 	// Recursively execute the module's setup
-	return r.executeSetup(ctx, exec, modSetup)
+	return r.executeSetup(ctx, exec, modSetup, make(bindings))
 }
 
-// executeSetupCall executes a query call from a module with parameters.
-func (r *Runner) executeSetupCall(ctx context.Context, exec executor, call *scaf.SetupCall) error {
+// executeSetupCall executes a query call from a module with parameters,
+// binding its rows if binding is set.
+func (r *Runner) executeSetupCall(
+	ctx context.Context, exec executor, call *scaf.SetupCall, binding *string, binds bindings,
+) error {
 	if r.modules == nil {
-		return fmt.Errorf("%w: %s.%s", ErrNoModuleContext, call.Module, call.Query)
+		return fmt.Errorf("%w: %s", ErrNoModuleContext, call.Ref())
 	}
 
 	// Resolve the query from the module
@@ -570,7 +1135,111 @@ func (r *Runner) executeSetupCall(ctx context.Context, exec executor, call *scaf
 		return fmt.Errorf("failed to resolve query: %w", err)
 	}
 
-	// Build params from the call
+	// Execute the query with the provided params
+	return r.executeBound(ctx, exec, binding, queryBody, setupCallParams(call), binds)
+}
+
+// executeBound executes a setup query, recording its rows in binds if binding is set.
+func (r *Runner) executeBound(
+	ctx context.Context, exec executor, binding *string, query string, params map[string]any, binds bindings,
+) error {
+	rows, err := exec.Execute(ctx, query, params)
+	if err != nil {
+		return err
+	}
+
+	binds.bind(binding, rows)
+
+	return nil
+}
+
+// resolveColumnKeys replaces each positional key (result[N]) in expectations
+// with the name of the query's Nth return column, in the order the database's
+// dialect reports them.
+func (r *Runner) resolveColumnKeys(body string, expectations map[string]any) error {
+	var columns []string
+
+	for _, key := range slices.Sorted(maps.Keys(expectations)) {
+		index, ok := scaf.ParseColumnKey(key)
+		if !ok {
+			continue
+		}
+
+		if columns == nil {
+			var err error
+
+			columns, err = r.returnColumns(body)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+
+		if index >= len(columns) {
+			return fmt.Errorf("%w: %s, the query returns %d known column(s)", ErrColumnPosition, key, len(columns))
+		}
+
+		expected := expectations[key]
+		delete(expectations, key)
+		expectations[columns[index]] = expected
+	}
+
+	return nil
+}
+
+// returnColumns returns the names of the query's result columns, in order, up
+// to any wildcard, whose columns aren't known until it runs.
+func (r *Runner) returnColumns(body string) ([]string, error) {
+	var dialect scaf.Dialect
+	if r.database != nil {
+		dialect = r.database.Dialect()
+	}
+
+	if dialect == nil {
+		return nil, fmt.Errorf("%w: the database has no dialect to read the query's columns", ErrColumnPosition)
+	}
+
+	metadata, err := dialect.Analyze(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrColumnPosition, err)
+	}
+
+	columns := []string{}
+	if metadata == nil {
+		return columns, nil
+	}
+
+	for _, ret := range metadata.Returns {
+		if ret.IsWildcard {
+			break
+		}
+
+		if ret.Alias != "" {
+			columns = append(columns, ret.Alias)
+		} else {
+			columns = append(columns, ret.Expression)
+		}
+	}
+
+	return columns, nil
+}
+
+// bulkInsertQuery asks the database's dialect for a query inserting a data table's rows.
+func (r *Runner) bulkInsertQuery(table *scaf.DataTable) (string, error) {
+	var dialect scaf.Dialect
+	if r.database != nil {
+		dialect = r.database.Dialect()
+	}
+
+	inserter, ok := dialect.(scaf.BulkInserter)
+	if !ok {
+		return "", fmt.Errorf("%w: data %s", ErrNoBulkInsert, table.Label)
+	}
+
+	return inserter.BulkInsert(table.Label, table.Columns), nil
+}
+
+// setupCallParams builds query parameters from a setup call's arguments.
+func setupCallParams(call *scaf.SetupCall) map[string]any {
 	params := make(map[string]any)
 
 	for _, p := range call.Params {
@@ -583,8 +1252,7 @@ func (r *Runner) executeSetupCall(ctx context.Context, exec executor, call *scaf
 		params[key] = p.Value.ToGo()
 	}
 
-	// Execute the query with the provided params
-	return r.executeQuery(ctx, exec, queryBody, params)
+	return params
 }
 
 func (r *Runner) emitError(
@@ -606,6 +1274,43 @@ func (r *Runner) emitError(
 	}, result)
 }
 
+// List returns the slash-separated paths of the tests in suite that Run
+// would execute, in source order: those matching the filter, not excluded,
+// and not left out by a skip or focus directive. It needs no database.
+func (r *Runner) List(suite *scaf.Suite) []string {
+	skipped := skippedTests(suite)
+
+	var (
+		paths []string
+		walk  func(parent []string, items []*scaf.TestOrGroup)
+	)
+
+	walk = func(parent []string, items []*scaf.TestOrGroup) {
+		for _, item := range items {
+			if item.Group != nil {
+				walk(append(slices.Clone(parent), item.Group.Name), item.Group.Items)
+
+				continue
+			}
+
+			for _, test := range item.Tests() {
+				path := append(slices.Clone(parent), test.Name)
+				if _, skip := skipped[test]; skip || !r.matchesFilter(path) || r.excluded(path) {
+					continue
+				}
+
+				paths = append(paths, strings.Join(path, "/"))
+			}
+		}
+	}
+
+	for _, scope := range suite.Scopes {
+		walk([]string{scope.QueryName}, scope.Items)
+	}
+
+	return paths
+}
+
 // matchesFilter returns true if the test path matches the filter pattern.
 // If no filter is set, all tests match.
 func (r *Runner) matchesFilter(path []string) bool {
@@ -618,14 +1323,102 @@ func (r *Runner) matchesFilter(path []string) bool {
 	return r.filter.MatchString(pathStr)
 }
 
+// excluded returns true if the test path, or any of its parent group paths,
+// matches an exclude pattern.
+func (r *Runner) excluded(testPath []string) bool {
+	for _, pattern := range r.exclude {
+		for i := 1; i <= len(testPath); i++ {
+			prefix := strings.Join(testPath[:i], "/")
+			if ok, _ := path.Match(pattern, prefix); ok || pattern == prefix {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// skippedTests returns the tests that a suite's directives exclude: those under
+// a skip directive and, if any test or group is focused, those outside every
+// focus directive. Skip wins over focus. Each test maps to the reason given by
+// its nearest skip directive, if any.
+func skippedTests(suite *scaf.Suite) map[*scaf.Test]string {
+	type directed struct {
+		test        *scaf.Test
+		focus, skip bool
+		reason      string
+	}
+
+	var (
+		tests []directed
+		walk  func(items []*scaf.TestOrGroup, focus, skip bool, reason string)
+	)
+
+	// skipDirective reports whether meta skips, and the reason that applies.
+	skipDirective := func(meta *scaf.DirectiveMeta, skip bool, reason string) (bool, string) {
+		if !meta.HasDirective(scaf.DirectiveSkip) {
+			return skip, reason
+		}
+
+		if arg := meta.DirectiveArg(scaf.DirectiveSkip); arg != "" {
+			reason = arg
+		}
+
+		return true, reason
+	}
+
+	walk = func(items []*scaf.TestOrGroup, focus, skip bool, reason string) {
+		for _, item := range items {
+			switch {
+			case item.Test != nil, item.Sequence != nil:
+				for _, test := range item.Tests() {
+					testSkip, testReason := skipDirective(&test.DirectiveMeta, skip, reason)
+					tests = append(tests, directed{
+						test:   test,
+						focus:  focus || test.HasDirective(scaf.DirectiveFocus),
+						skip:   testSkip,
+						reason: testReason,
+					})
+				}
+			case item.Group != nil:
+				groupSkip, groupReason := skipDirective(&item.Group.DirectiveMeta, skip, reason)
+				walk(item.Group.Items,
+					focus || item.Group.HasDirective(scaf.DirectiveFocus),
+					groupSkip, groupReason)
+			}
+		}
+	}
+
+	for _, scope := range suite.Scopes {
+		walk(scope.Items, false, false, "")
+	}
+
+	anyFocus := slices.ContainsFunc(tests, func(d directed) bool { return d.focus })
+
+	skipped := make(map[*scaf.Test]string)
+
+	for _, d := range tests {
+		switch {
+		case d.skip:
+			skipped[d.test] = d.reason
+		case anyFocus && !d.focus:
+			skipped[d.test] = ""
+		}
+	}
+
+	return skipped
+}
+
 // evaluateAssert evaluates an assert block's conditions.
-// If the assert has a query, it runs that query first and evaluates conditions against its results.
-// Otherwise, it evaluates conditions against the main query results.
+// If the assert has a query, it runs that query first and evaluates conditions against its
+// first row. Otherwise, the conditions must hold for every row of the main query, and fail
+// when it returns none. Either way, a dotted column such as "u.age" is also bound as a
+// field of its prefix, so that conditions can refer to it as u.age.
 func (r *Runner) evaluateAssert(
 	ctx context.Context,
 	exec executor,
 	assert *scaf.Assert,
-	mainResult map[string]any,
+	mainRows []map[string]any,
 	queries map[string]string,
 	path []string,
 	suitePath string,
@@ -633,29 +1426,55 @@ func (r *Runner) evaluateAssert(
 	handler Handler,
 	result *Result,
 ) error {
-	// Determine which result to evaluate against
-	env := mainResult
+	envs := mainRows
 
 	// If assert has a query, run it first
 	if assert.Query != nil {
+		mainResult := make(map[string]any)
+		if len(mainRows) > 0 {
+			mainResult = mainRows[0]
+		}
+
 		assertResult, err := r.runAssertQuery(ctx, exec, assert.Query, queries, mainResult)
 		if err != nil {
 			return r.emitError(ctx, path, suitePath, start, fmt.Errorf("assert query: %w", err), handler, result)
 		}
 
-		env = assertResult
+		envs = []map[string]any{assertResult}
 	}
 
 	// Evaluate each condition
 	for _, condition := range assert.Conditions {
 		exprStr := condition.String()
 
-		evalResult := EvalExpr(exprStr, env)
-		if evalResult.Error != nil {
-			return r.emitError(ctx, path, suitePath, start, evalResult.Error, handler, result)
+		if len(envs) == 0 {
+			return handler.Event(ctx, Event{
+				Time:     time.Now(),
+				Action:   ActionFail,
+				Suite:    suitePath,
+				Path:     path,
+				Elapsed:  time.Since(start),
+				Field:    exprStr,
+				Expected: true,
+				Actual:   "no rows",
+			}, result)
 		}
 
-		if !evalResult.Passed {
+		for i, env := range envs {
+			evalResult := EvalExpr(exprStr, assertEnv(env))
+			if evalResult.Error != nil {
+				return r.emitError(ctx, path, suitePath, start, evalResult.Error, handler, result)
+			}
+
+			if evalResult.Passed {
+				continue
+			}
+
+			field := exprStr
+			if len(envs) > 1 {
+				field = fmt.Sprintf("%s (row %d)", exprStr, i)
+			}
+
 			elapsed := time.Since(start)
 
 			return handler.Event(ctx, Event{
@@ -664,7 +1483,7 @@ func (r *Runner) evaluateAssert(
 				Suite:    suitePath,
 				Path:     path,
 				Elapsed:  elapsed,
-				Field:    exprStr,
+				Field:    field,
 				Expected: true,
 				Actual:   false,
 			}, result)
@@ -1,11 +1,19 @@
 package runner //nolint:testpackage
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/rlch/scaf"
+	"github.com/rlch/scaf/dialects/cypher"
 	"github.com/rlch/scaf/module"
 )
 
@@ -14,11 +22,12 @@ type mockDatabase struct {
 	results  []map[string]any
 	err      error
 	executed []string
+	dialect  scaf.Dialect
 }
 
 func (m *mockDatabase) Name() string { return m.name }
 
-func (m *mockDatabase) Dialect() scaf.Dialect { return nil }
+func (m *mockDatabase) Dialect() scaf.Dialect { return m.dialect }
 
 func (m *mockDatabase) Execute(_ context.Context, query string, _ map[string]any) ([]map[string]any, error) {
 	m.executed = append(m.executed, query)
@@ -80,6 +89,25 @@ func TestRunner_SetupError(t *testing.T) {
 	}
 }
 
+func TestRunner_DuplicateClause(t *testing.T) {
+	suite, err := scaf.Parse([]byte("query Q `Q`\n\nQ {\n\tsetup `A`\n\ttest \"t\" {}\n\tsetup `B`\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &mockDatabase{}
+	r := New(WithDatabase(d))
+
+	_, err = r.Run(context.Background(), suite, "test.scaf")
+	if !errors.Is(err, ErrDuplicateClause) {
+		t.Fatalf("got %v, want ErrDuplicateClause", err)
+	}
+
+	if len(d.executed) != 0 {
+		t.Errorf("executed = %v, want nothing run", d.executed)
+	}
+}
+
 func TestRunner_SimpleTest(t *testing.T) {
 	d := &mockDatabase{}
 	h := &mockHandler{}
@@ -143,85 +171,814 @@ func TestRunner_NestedGroups(t *testing.T) {
 		}},
 	}
 
-	result, err := r.Run(context.Background(), suite, "test.scaf")
+	result, err := r.Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Total != 2 {
+		t.Errorf("Total = %d, want 2", result.Total)
+	}
+
+	if _, ok := result.Tests["Query/outer/inner/test1"]; !ok {
+		t.Error("test1 not found at expected path")
+	}
+
+	if _, ok := result.Tests["Query/outer/inner/test2"]; !ok {
+		t.Error("test2 not found at expected path")
+	}
+}
+
+func TestRunner_FailFast(t *testing.T) {
+	d := &mockDatabase{err: errTestFail}
+	r := New(WithDatabase(d), WithFailFast(true))
+
+	setup := "SETUP"
+	suite := &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "Query", Body: "Q"}},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "Query",
+			Items: []*scaf.TestOrGroup{
+				{Test: &scaf.Test{Name: "test1", Setup: &scaf.SetupClause{Inline: &setup}}},
+				{Test: &scaf.Test{Name: "test2"}},
+				{Test: &scaf.Test{Name: "test3"}},
+			},
+		}},
+	}
+
+	result, _ := r.Run(context.Background(), suite, "test.scaf")
+
+	if result.Total > 1 {
+		t.Errorf("Total = %d, should stop after first failure", result.Total)
+	}
+}
+
+func TestRunner_MaxFailures(t *testing.T) {
+	d := &mockDatabase{err: errTestFail}
+	r := New(WithDatabase(d), WithMaxFailures(2))
+
+	teardown := "TEARDOWN"
+	suite := &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "Query", Body: "Q"}},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "Query",
+			Teardown:  &teardown,
+			Items: []*scaf.TestOrGroup{
+				{Test: &scaf.Test{Name: "test1"}},
+				{Test: &scaf.Test{Name: "test2"}},
+				{Test: &scaf.Test{Name: "test3"}},
+				{Test: &scaf.Test{Name: "test4"}},
+			},
+		}},
+	}
+
+	result, err := r.Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Total != 2 || result.Failed+result.Errors != 2 {
+		t.Errorf("Total = %d, failures = %d, want the run to stop after 2 failures",
+			result.Total, result.Failed+result.Errors)
+	}
+
+	// The scope's teardown still runs after bailing out.
+	want := []string{"Q", "Q", "TEARDOWN"}
+	if !slices.Equal(d.executed, want) {
+		t.Errorf("executed = %v, want %v", d.executed, want)
+	}
+}
+
+// flakyDatabase fails every failEvery-th execution of the test query.
+type flakyDatabase struct {
+	mockDatabase
+
+	failEvery int
+	runs      int
+}
+
+func (f *flakyDatabase) Execute(ctx context.Context, query string, params map[string]any) ([]map[string]any, error) {
+	f.executed = append(f.executed, query)
+
+	if query != "Q" {
+		return nil, nil
+	}
+
+	f.runs++
+	if f.runs%f.failEvery == 0 {
+		return nil, errTestFail
+	}
+
+	return nil, nil
+}
+
+func TestRunner_Repeat(t *testing.T) {
+	d := &flakyDatabase{failEvery: 3}
+	r := New(WithDatabase(d), WithRepeat(6))
+
+	suite := &scaf.Suite{
+		Queries:  []*scaf.Query{{Name: "Query", Body: "Q"}},
+		Setup:    &scaf.SetupClause{Inline: ptr("SETUP")},
+		Teardown: ptr("TEARDOWN"),
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "Query",
+			Items:     []*scaf.TestOrGroup{{Test: &scaf.Test{Name: "flaky"}}},
+		}},
+	}
+
+	result, err := r.Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Total != 1 || result.Errors != 1 || result.Passed != 0 {
+		t.Errorf("Total/Errors/Passed = %d/%d/%d, want 1/1/0", result.Total, result.Errors, result.Passed)
+	}
+
+	tr := result.Tests["Query/flaky"]
+	if tr == nil {
+		t.Fatal("flaky test not found")
+	}
+
+	if tr.Runs != 6 || tr.Failures != 2 {
+		t.Errorf("Runs/Failures = %d/%d, want 6/2", tr.Runs, tr.Failures)
+	}
+
+	// Suite setup and teardown run on every iteration.
+	var setups, teardowns int
+
+	for _, q := range d.executed {
+		switch q {
+		case "SETUP":
+			setups++
+		case "TEARDOWN":
+			teardowns++
+		}
+	}
+
+	if setups != 6 || teardowns != 6 {
+		t.Errorf("setups/teardowns = %d/%d, want 6/6", setups, teardowns)
+	}
+}
+
+func TestRunner_ScopeAndGroupSetup(t *testing.T) {
+	d := &mockDatabase{}
+	r := New(WithDatabase(d))
+
+	scopeSetup := "SCOPE"
+	groupSetup := "GROUP"
+	suite := &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "Query", Body: "Q"}},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "Query",
+			Setup: &scaf.SetupClause{Inline: &scopeSetup},
+			Items: []*scaf.TestOrGroup{{
+				Group: &scaf.Group{
+					Name:  "group",
+					Setup: &scaf.SetupClause{Inline: &groupSetup},
+					Items: []*scaf.TestOrGroup{{Test: &scaf.Test{Name: "test"}}},
+				},
+			}},
+		}},
+	}
+
+	_, err := r.Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.executed) < 2 {
+		t.Fatalf("executed = %v, want at least 2", d.executed)
+	}
+
+	if d.executed[0] != scopeSetup {
+		t.Errorf("first = %q, want %q", d.executed[0], scopeSetup)
+	}
+
+	if d.executed[1] != groupSetup {
+		t.Errorf("second = %q, want %q", d.executed[1], groupSetup)
+	}
+}
+
+func TestRunner_ProfileSetupOrder(t *testing.T) {
+	d := &mockDatabase{}
+	r := New(WithDatabase(d))
+
+	suite := &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "Query", Body: "Q"}},
+		Profiles: []*scaf.Profile{{
+			Name:     "Base",
+			Setup:    &scaf.SetupClause{Inline: ptr("PROFILE SETUP")},
+			Teardown: ptr("PROFILE TEARDOWN"),
+		}},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "Query",
+			Extends:   ptr("Base"),
+			Setup:     &scaf.SetupClause{Inline: ptr("SCOPE SETUP")},
+			Teardown:  ptr("SCOPE TEARDOWN"),
+			Items:     []*scaf.TestOrGroup{{Test: &scaf.Test{Name: "test"}}},
+		}},
+	}
+
+	_, err := r.Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"PROFILE SETUP", "SCOPE SETUP", "Q", "SCOPE TEARDOWN", "PROFILE TEARDOWN"}
+	if !slices.Equal(d.executed, want) {
+		t.Errorf("executed = %v, want %v", d.executed, want)
+	}
+}
+
+func TestRunner_UnknownProfile(t *testing.T) {
+	r := New(WithDatabase(&mockDatabase{}))
+
+	suite := &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "Query", Body: "Q"}},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "Query",
+			Extends:   ptr("Missing"),
+			Items:     []*scaf.TestOrGroup{{Test: &scaf.Test{Name: "test"}}},
+		}},
+	}
+
+	_, err := r.Run(context.Background(), suite, "test.scaf")
+	if !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("got %v, want ErrUnknownProfile", err)
+	}
+}
+
+// txMockDatabase is a mockDatabase that supports transactions and records rollbacks.
+type txMockDatabase struct {
+	mockDatabase
+	rollbacks int
+}
+
+func (m *txMockDatabase) Begin(_ context.Context) (scaf.DatabaseTransaction, error) {
+	return &mockTx{db: m}, nil
+}
+
+type mockTx struct {
+	db *txMockDatabase
+}
+
+func (t *mockTx) Execute(ctx context.Context, query string, params map[string]any) ([]map[string]any, error) {
+	return t.db.Execute(ctx, query, params)
+}
+
+func (t *mockTx) Commit(_ context.Context) error { return nil }
+
+func (t *mockTx) Rollback(_ context.Context) error {
+	t.db.rollbacks++
+
+	return nil
+}
+
+func TestRunner_KeepState(t *testing.T) {
+	newSuite := func() *scaf.Suite {
+		return &scaf.Suite{
+			Queries:  []*scaf.Query{{Name: "Query", Body: "Q"}},
+			Teardown: ptr("SUITE TEARDOWN"),
+			Profiles: []*scaf.Profile{{Name: "Base", Teardown: ptr("PROFILE TEARDOWN")}},
+			Scopes: []*scaf.QueryScope{{
+				QueryName: "Query",
+				Extends:   ptr("Base"),
+				Teardown:  ptr("SCOPE TEARDOWN"),
+				Items: []*scaf.TestOrGroup{{
+					Group: &scaf.Group{
+						Name:     "group",
+						Teardown: ptr("GROUP TEARDOWN"),
+						Items:    []*scaf.TestOrGroup{{Test: &scaf.Test{Name: "test"}}},
+					},
+				}},
+			}},
+		}
+	}
+
+	t.Run("default runs teardown and rolls back", func(t *testing.T) {
+		d := &txMockDatabase{}
+
+		_, err := New(WithDatabase(d)).Run(context.Background(), newSuite(), "test.scaf")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"Q", "GROUP TEARDOWN", "SCOPE TEARDOWN", "PROFILE TEARDOWN", "SUITE TEARDOWN"}
+		if !slices.Equal(d.executed, want) {
+			t.Errorf("executed = %v, want %v", d.executed, want)
+		}
+
+		if d.rollbacks != 1 {
+			t.Errorf("rollbacks = %d, want 1", d.rollbacks)
+		}
+	})
+
+	t.Run("keep state skips teardown and rollback", func(t *testing.T) {
+		d := &txMockDatabase{}
+
+		_, err := New(WithDatabase(d), WithKeepState(true)).Run(context.Background(), newSuite(), "test.scaf")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := []string{"Q"}; !slices.Equal(d.executed, want) {
+			t.Errorf("executed = %v, want %v", d.executed, want)
+		}
+
+		if d.rollbacks != 0 {
+			t.Errorf("rollbacks = %d, want 0", d.rollbacks)
+		}
+	})
+}
+
+func TestRunner_Sequence(t *testing.T) {
+	d := &txMockDatabase{}
+	r := New(WithDatabase(d))
+
+	suite := &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "Query", Body: "Q"}},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "Query",
+			Items: []*scaf.TestOrGroup{
+				{Test: &scaf.Test{Name: "alone"}},
+				{Sequence: &scaf.Sequence{Tests: []*scaf.Test{
+					{Name: "create", Setup: &scaf.SetupClause{Inline: ptr("CREATE")}},
+					{Name: "update", Setup: &scaf.SetupClause{Inline: ptr("UPDATE")}},
+					{Name: "read"},
+				}}},
+			},
+		}},
+	}
+
+	result, err := r.Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"Query/alone", "Query/create", "Query/update", "Query/read"}; !slices.Equal(result.Order, want) {
+		t.Errorf("Order = %v, want %v", result.Order, want)
+	}
+
+	if result.Passed != 4 {
+		t.Errorf("Passed = %d, want 4", result.Passed)
+	}
+
+	want := []string{"Q", "CREATE", "Q", "UPDATE", "Q", "Q"}
+	if !slices.Equal(d.executed, want) {
+		t.Errorf("executed = %v, want %v", d.executed, want)
+	}
+
+	// The standalone test and the whole sequence each get one rolled-back transaction.
+	if d.rollbacks != 2 {
+		t.Errorf("rollbacks = %d, want 2", d.rollbacks)
+	}
+}
+
+// memoryDatabase is an in-memory transactional driver over named counters:
+// "INC name" increments a counter and "GET name" returns it as column n.
+// Transactions work on a copy of the counters that Commit writes back.
+type memoryDatabase struct {
+	mu       sync.Mutex
+	counters map[string]int
+}
+
+func (m *memoryDatabase) Name() string { return "memory" }
+
+func (m *memoryDatabase) Dialect() scaf.Dialect { return nil }
+
+func (m *memoryDatabase) Execute(ctx context.Context, query string, params map[string]any) ([]map[string]any, error) {
+	tx, _ := m.Begin(ctx)
+	defer tx.Commit(ctx) //nolint:errcheck // Commit doesn't fail.
+
+	return tx.Execute(ctx, query, params)
+}
+
+func (m *memoryDatabase) Close() error { return nil }
+
+func (m *memoryDatabase) Begin(_ context.Context) (scaf.DatabaseTransaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return &memoryTx{db: m, counters: maps.Clone(m.counters)}, nil
+}
+
+type memoryTx struct {
+	db       *memoryDatabase
+	counters map[string]int
+}
+
+func (t *memoryTx) Execute(_ context.Context, query string, _ map[string]any) ([]map[string]any, error) {
+	op, name, _ := strings.Cut(query, " ")
+
+	switch op {
+	case "INC":
+		if t.counters == nil {
+			t.counters = make(map[string]int)
+		}
+
+		t.counters[name]++
+
+		return nil, nil
+	case "GET":
+		return []map[string]any{{"n": int64(t.counters[name])}}, nil
+	default:
+		return nil, fmt.Errorf("memory: unknown query %q", query)
+	}
+}
+
+func (t *memoryTx) Commit(_ context.Context) error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+
+	t.db.counters = t.counters
+
+	return nil
+}
+
+func (t *memoryTx) Rollback(_ context.Context) error { return nil }
+
+func TestRunner_InMemoryDriver(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query Count ` + "`GET users`" + `
+
+setup ` + "`INC users`" + `
+
+Count {
+	test "sees the suite setup" {
+		n: 1
+	}
+
+	test "sees its own setup" {
+		setup ` + "`INC users`" + `
+		n: 2
+	}
+
+	test "doesn't see other tests' setup" {
+		n: 1
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &memoryDatabase{}
+
+	result, err := New(WithDatabase(d)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Passed != 3 {
+		for _, failed := range result.FailedTests() {
+			t.Logf("%s: %s = %v, want %v", failed.PathString(), failed.Field, failed.Actual, failed.Expected)
+		}
+
+		t.Errorf("Passed = %d, want 3", result.Passed)
+	}
+
+	// Only the suite setup, run outside any test's transaction, was committed.
+	if want := map[string]int{"users": 1}; !maps.Equal(d.counters, want) {
+		t.Errorf("counters = %v, want %v", d.counters, want)
+	}
+}
+
+func TestRunner_TestPosition(t *testing.T) {
+	suite, err := scaf.Parse([]byte("query Q `Q`\n\nQ {\n\ttest \"fails\" {\n\t\tn: 2\n\t}\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := &mockDatabase{results: []map[string]any{{"n": int64(1)}}}
+
+	result, err := New(WithDatabase(db)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := result.FailedTests()
+	if len(failed) != 1 {
+		t.Fatalf("FailedTests() = %d tests, want 1", len(failed))
+	}
+
+	// The test keyword sits on the fourth line, after a tab.
+	if want := (Position{Line: 3, Column: 1}); failed[0].Pos == nil || *failed[0].Pos != want {
+		t.Errorf("Pos = %v, want %v", failed[0].Pos, want)
+	}
+
+	var out bytes.Buffer
+
+	if err := NewGitHubFormatter(&out, io.Discard).Summary(result); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "::error file=test.scaf,line=4,col=2,title=Q/fails::n: expected 2, got 1\n"; out.String() != want {
+		t.Errorf("annotation = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRunner_QueryParamDefaults(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query ListUsers($limit = 10, $role = "admin") ` + "`LIST`" + `
+
+ListUsers {
+	test "uses defaults" {}
+	test "overrides limit" {
+		$limit: 2
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &paramsLogDatabase{}
+
+	_, err = New(WithDatabase(d)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.params) != 2 {
+		t.Fatalf("executed %d queries, want 2", len(d.params))
+	}
+
+	if got := d.params[0]; got["limit"] != 10.0 || got["role"] != "admin" {
+		t.Errorf("params without overrides = %v, want defaults", got)
+	}
+
+	if got := d.params[1]; got["limit"] != 2.0 || got["role"] != "admin" {
+		t.Errorf("params with override = %v, want limit=2 and default role", got)
+	}
+}
+
+func TestRunner_FocusDirective(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query Q ` + "`Q`" + `
+
+Q {
+	test "unfocused" {}
+
+	// scaf:focus
+	group "focused" {
+		test "runs" {}
+
+		// scaf:skip
+		test "skipped anyway" {}
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &mockDatabase{}
+
+	result, err := New(WithDatabase(d)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.executed) != 1 {
+		t.Errorf("executed %d queries, want 1", len(d.executed))
+	}
+
+	want := map[string]Action{
+		"Q/unfocused":              ActionSkip,
+		"Q/focused/runs":           ActionPass,
+		"Q/focused/skipped anyway": ActionSkip,
+	}
+
+	for path, action := range want {
+		if tr := result.Tests[path]; tr == nil || tr.Status != action {
+			t.Errorf("%s = %+v, want %s", path, tr, action)
+		}
+	}
+}
+
+func TestRunner_List(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query Q ` + "`Q`" + `
+query R ` + "`R`" + `
+
+Q {
+	test "unfocused" {}
+
+	// scaf:focus
+	group "focused" {
+		test "runs" {}
+		test "excluded" {}
+
+		// scaf:skip
+		test "skipped" {}
+	}
+}
+
+R {
+	// scaf:focus
+	test "also runs" {}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := New(WithExclude("Q/focused/excluded")).List(suite)
+	want := []string{"Q/focused/runs", "R/also runs"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("List() = %q, want %q", got, want)
+	}
+
+	if got := New(WithFilter("^R/")).List(suite); !slices.Equal(got, want[1:]) {
+		t.Errorf("List() with filter = %q, want %q", got, want[1:])
+	}
+}
+
+func TestRunner_SkipReason(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query Q ` + "`Q`" + `
+
+Q {
+	// scaf:skip("flaky on CI")
+	test "flaky" {}
+
+	// scaf:skip("needs fixtures")
+	group "later" {
+		test "inherits" {}
+
+		// scaf:skip
+		test "keeps group reason" {}
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	handler := NewFormatHandler(NewJSONFormatter(&buf), io.Discard)
+
+	result, err := New(WithDatabase(&mockDatabase{}), WithHandler(handler)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"Q/flaky":                    "flaky on CI",
+		"Q/later/inherits":           "needs fixtures",
+		"Q/later/keeps group reason": "needs fixtures",
+	}
+
+	for path, reason := range want {
+		if tr := result.Tests[path]; tr == nil || tr.Status != ActionSkip || tr.Reason != reason {
+			t.Errorf("%s = %+v, want skipped with reason %q", path, tr, reason)
+		}
+	}
+
+	if !strings.Contains(buf.String(), `"reason":"flaky on CI"`) {
+		t.Errorf("JSON report missing skip reason:\n%s", buf.String())
+	}
+}
+
+func TestRunner_FilterAndExclude(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query GetUser ` + "`GetUser`" + `
+query ListUsers ` + "`ListUsers`" + `
+
+GetUser {
+	test "fast" {}
+
+	group "slow" {
+		test "one" {}
+		test "two" {}
+	}
+
+	group "legacy slow" {
+		test "three" {}
+	}
+}
+
+ListUsers {
+	test "all" {}
+}
+`))
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if result.Total != 2 {
-		t.Errorf("Total = %d, want 2", result.Total)
+	tests := []struct {
+		name    string
+		exclude []string
+		want    []string
+	}{
+		{"exclude group", []string{"GetUser/slow"}, []string{"GetUser/fast", "GetUser/legacy slow/three"}},
+		{"exclude glob", []string{"GetUser/*slow"}, []string{"GetUser/fast"}},
+		{"exclude test", []string{"GetUser/slow/two", "*/fast"}, []string{"GetUser/slow/one", "GetUser/legacy slow/three"}},
 	}
 
-	if _, ok := result.Tests["Query/outer/inner/test1"]; !ok {
-		t.Error("test1 not found at expected path")
-	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(WithDatabase(&mockDatabase{}), WithFilter("^GetUser/"), WithExclude(tt.exclude...))
 
-	if _, ok := result.Tests["Query/outer/inner/test2"]; !ok {
-		t.Error("test2 not found at expected path")
+			result, err := r.Run(context.Background(), suite, "test.scaf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for path := range result.Tests {
+				got = append(got, path)
+			}
+
+			slices.Sort(got)
+			slices.Sort(tt.want)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ran %v, want %v", got, tt.want)
+			}
+		})
 	}
 }
 
-func TestRunner_FailFast(t *testing.T) {
-	d := &mockDatabase{err: errTestFail}
-	r := New(WithDatabase(d), WithFailFast(true))
+func TestRunner_DataTableSetup(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query GetUser ` + "`GET`" + `
 
-	setup := "SETUP"
-	suite := &scaf.Suite{
-		Queries: []*scaf.Query{{Name: "Query", Body: "Q"}},
-		Scopes: []*scaf.QueryScope{{
-			QueryName: "Query",
-			Items: []*scaf.TestOrGroup{
-				{Test: &scaf.Test{Name: "test1", Setup: &scaf.SetupClause{Inline: &setup}}},
-				{Test: &scaf.Test{Name: "test2"}},
-				{Test: &scaf.Test{Name: "test3"}},
-			},
-		}},
+GetUser {
+	setup {
+		data User { id, name | 1, "Alice" | 2, "Bob" }
+	}
+	test "t" {}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	result, _ := r.Run(context.Background(), suite, "test.scaf")
+	d := &paramsLogDatabase{mockDatabase: mockDatabase{dialect: bulkDialect{}}}
 
-	if result.Total > 1 {
-		t.Errorf("Total = %d, should stop after first failure", result.Total)
+	_, err = New(WithDatabase(d)).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
 	}
-}
 
-func TestRunner_ScopeAndGroupSetup(t *testing.T) {
-	d := &mockDatabase{}
-	r := New(WithDatabase(d))
+	want := []string{"INSERT User(id,name)", "GET"}
+	if !slices.Equal(d.executed, want) {
+		t.Fatalf("executed = %v, want %v", d.executed, want)
+	}
 
-	scopeSetup := "SCOPE"
-	groupSetup := "GROUP"
-	suite := &scaf.Suite{
-		Queries: []*scaf.Query{{Name: "Query", Body: "Q"}},
-		Scopes: []*scaf.QueryScope{{
-			QueryName: "Query",
-			Setup: &scaf.SetupClause{Inline: &scopeSetup},
-			Items: []*scaf.TestOrGroup{{
-				Group: &scaf.Group{
-					Name:  "group",
-					Setup: &scaf.SetupClause{Inline: &groupSetup},
-					Items: []*scaf.TestOrGroup{{Test: &scaf.Test{Name: "test"}}},
-				},
-			}},
-		}},
+	rows, _ := d.params[0]["rows"].([]map[string]any)
+	if len(rows) != 2 || rows[0]["id"] != 1.0 || rows[0]["name"] != "Alice" ||
+		rows[1]["id"] != 2.0 || rows[1]["name"] != "Bob" {
+		t.Errorf("rows = %v, want bindings for Alice and Bob", d.params[0]["rows"])
 	}
+}
 
-	_, err := r.Run(context.Background(), suite, "test.scaf")
+func TestRunner_DataTableUnsupportedDialect(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query GetUser ` + "`GET`" + `
+
+GetUser {
+	setup { data User { id | 1 } }
+	test "t" {}
+}
+`))
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if len(d.executed) < 2 {
-		t.Fatalf("executed = %v, want at least 2", d.executed)
+	_, err = New(WithDatabase(&mockDatabase{})).Run(context.Background(), suite, "test.scaf")
+	if !errors.Is(err, ErrNoBulkInsert) {
+		t.Errorf("Run() error = %v, want %v", err, ErrNoBulkInsert)
 	}
+}
 
-	if d.executed[0] != scopeSetup {
-		t.Errorf("first = %q, want %q", d.executed[0], scopeSetup)
-	}
+// bulkDialect is a dialect that renders data tables as a recognisable pseudo-query.
+type bulkDialect struct{}
 
-	if d.executed[1] != groupSetup {
-		t.Errorf("second = %q, want %q", d.executed[1], groupSetup)
-	}
+func (bulkDialect) Name() string { return "bulk" }
+
+func (bulkDialect) Analyze(string) (*scaf.QueryMetadata, error) { return &scaf.QueryMetadata{}, nil }
+
+func (bulkDialect) MarkdownLanguage() string { return "text" }
+
+func (bulkDialect) BulkInsert(label string, columns []string) string {
+	return "INSERT " + label + "(" + strings.Join(columns, ",") + ")"
+}
+
+// paramsLogDatabase records the parameters of each executed query.
+type paramsLogDatabase struct {
+	mockDatabase
+	params []map[string]any
+}
+
+func (m *paramsLogDatabase) Execute(ctx context.Context, query string, params map[string]any) ([]map[string]any, error) {
+	m.params = append(m.params, params)
+
+	return m.mockDatabase.Execute(ctx, query, params)
 }
 
 func TestRunner_AssertPassing(t *testing.T) {
@@ -298,6 +1055,272 @@ func TestRunner_AssertFailing(t *testing.T) {
 	}
 }
 
+func TestRunner_ExpectedRows(t *testing.T) {
+	rowsTest := &scaf.Test{
+		Name: "lists users",
+		ExpectedRows: []*scaf.Map{
+			{Entries: []*scaf.MapEntry{{Key: "name", Value: &scaf.Value{Str: ptr("Alice")}}}},
+			{Entries: []*scaf.MapEntry{
+				{Key: "name", Value: &scaf.Value{Str: ptr("Bob")}},
+				{Key: "age", Value: &scaf.Value{Number: ptr(40.0)}},
+			}},
+		},
+	}
+
+	suite := &scaf.Suite{
+		Queries: []*scaf.Query{{Name: "ListUsers", Body: "MATCH (u:User) RETURN u.name AS name, u.age AS age"}},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "ListUsers",
+			Items:     []*scaf.TestOrGroup{{Test: rowsTest}},
+		}},
+	}
+
+	alice := map[string]any{"name": "Alice", "age": int64(30)}
+	bob := map[string]any{"name": "Bob", "age": int64(40)}
+
+	tests := []struct {
+		name       string
+		results    []map[string]any
+		unordered  bool
+		directives []string
+		wantPassed int
+	}{
+		{name: "ordered match", results: []map[string]any{alice, bob}, wantPassed: 1},
+		{name: "ordered mismatch", results: []map[string]any{bob, alice}, wantPassed: 0},
+		{name: "unordered match", results: []map[string]any{bob, alice}, unordered: true, wantPassed: 1},
+		{name: "missing row", results: []map[string]any{alice}, unordered: true, wantPassed: 0},
+		{
+			name: "unordered directive", results: []map[string]any{bob, alice},
+			directives: []string{scaf.DirectiveUnordered}, wantPassed: 1,
+		},
+		{name: "wrong value", results: []map[string]any{alice, {"name": "Bob", "age": int64(41)}}, wantPassed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rowsTest.Directives = tt.directives
+			r := New(WithDatabase(&mockDatabase{results: tt.results}), WithUnorderedRows(tt.unordered))
+
+			result, err := r.Run(context.Background(), suite, "test.scaf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Passed != tt.wantPassed {
+				t.Errorf("Passed = %d, want %d", result.Passed, tt.wantPassed)
+			}
+
+			if result.Failed != 1-tt.wantPassed {
+				t.Errorf("Failed = %d, want %d", result.Failed, 1-tt.wantPassed)
+			}
+		})
+	}
+}
+
+func TestCompareRows_Unordered(t *testing.T) {
+	row := func(entries ...*scaf.MapEntry) *scaf.Map { return &scaf.Map{Entries: entries} }
+	a := &scaf.MapEntry{Key: "a", Value: &scaf.Value{Number: ptr(1.0)}}
+	b := &scaf.MapEntry{Key: "b", Value: &scaf.Value{Number: ptr(2.0)}}
+
+	// {a: 1} fits both actual rows, but {a: 1, b: 2} only the first, so the
+	// first fit for {a: 1} must give way.
+	expected := []*scaf.Map{row(a), row(a, b)}
+	actual := []map[string]any{{"a": int64(1), "b": int64(2)}, {"a": int64(1), "b": int64(3)}}
+
+	if field, want, got, ok := compareRows(expected, actual, true); !ok {
+		t.Errorf("compareRows failed at %s: want %v, got %v", field, want, got)
+	}
+
+	actual[0]["b"] = int64(3)
+
+	if field, _, _, ok := compareRows(expected, actual, true); ok || field != "rows[1]" {
+		t.Errorf("compareRows = %q, %v; want rows[1], false", field, ok)
+	}
+}
+
+func TestRunner_Expect(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query GetUser ` + "`MATCH (u:User) RETURN u.name AS name, u.age AS age`" + `
+
+GetUser {
+	test "whole row" {
+		expect {name: "Alice", age: 30}
+	}
+
+	test "statement wins" {
+		age: 31
+		expect {name: "Alice", age: 30}
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := map[string]any{"name": "Alice", "age": int64(30)}
+
+	tests := []struct {
+		name       string
+		results    []map[string]any
+		wantFailed map[string]string // test path -> failing field, "" if either may fail
+	}{
+		{
+			name:       "match",
+			results:    []map[string]any{alice},
+			wantFailed: map[string]string{"GetUser/statement wins": "age"},
+		},
+		{
+			name:    "extra field",
+			results: []map[string]any{{"name": "Alice", "age": int64(30), "email": "a@example.com"}},
+			wantFailed: map[string]string{
+				"GetUser/whole row":      "email",
+				"GetUser/statement wins": "email",
+			},
+		},
+		{
+			name:    "two rows",
+			results: []map[string]any{alice, alice},
+			wantFailed: map[string]string{
+				"GetUser/whole row":      "rows",
+				"GetUser/statement wins": "rows",
+			},
+		},
+		{
+			name:    "wrong value",
+			results: []map[string]any{{"name": "Bob", "age": int64(30)}},
+			wantFailed: map[string]string{
+				"GetUser/whole row":      "name",
+				"GetUser/statement wins": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(WithDatabase(&mockDatabase{results: tt.results}))
+
+			result, err := r.Run(context.Background(), suite, "test.scaf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, path := range result.Order {
+				test := result.Tests[path]
+
+				field, wantFail := tt.wantFailed[path]
+				if failed := test.Status == ActionFail; failed != wantFail {
+					t.Errorf("%s: failed = %v, want %v", path, failed, wantFail)
+				}
+
+				if field != "" && test.Field != field {
+					t.Errorf("%s: failing field = %q, want %q", path, test.Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestRunner_PositionalColumns(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query CountUsers ` + "`MATCH (u:User) RETURN u.name, count(*)`" + `
+
+CountUsers {
+	test "by position" {
+		result[0]: "Alice"
+		result[1]: 2
+	}
+
+	test "wrong count" {
+		result[1]: 3
+	}
+
+	test "past the last column" {
+		result[2]: 1
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []map[string]any{{"u.name": "Alice", "count(*)": int64(2)}}
+
+	r := New(WithDatabase(&mockDatabase{results: rows, dialect: cypher.NewDialect()}))
+
+	result, err := r.Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tr := result.Tests["CountUsers/by position"]; tr.Status != ActionPass {
+		t.Errorf("by position = %+v, want pass", tr)
+	}
+
+	if tr := result.Tests["CountUsers/wrong count"]; tr.Status != ActionFail || tr.Field != "count(*)" {
+		t.Errorf("wrong count = %+v, want a failure on count(*)", tr)
+	}
+
+	if tr := result.Tests["CountUsers/past the last column"]; tr.Status != ActionError || !errors.Is(tr.Error, ErrColumnPosition) {
+		t.Errorf("past the last column = %+v, want %v", tr, ErrColumnPosition)
+	}
+
+	// Without a dialect the column order is unknown.
+	result, err = New(WithDatabase(&mockDatabase{results: rows})).Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tr := result.Tests["CountUsers/by position"]; tr.Status != ActionError || !errors.Is(tr.Error, ErrColumnPosition) {
+		t.Errorf("by position without a dialect = %+v, want %v", tr, ErrColumnPosition)
+	}
+}
+
+func TestRunner_AbsentVersusNull(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected *scaf.Value
+		row      map[string]any
+		wantPass bool
+	}{
+		{name: "null matches null", expected: &scaf.Value{Null: true}, row: map[string]any{"u.middleName": nil}, wantPass: true},
+		{name: "null matches missing", expected: &scaf.Value{Null: true}, row: map[string]any{}, wantPass: true},
+		{name: "absent matches missing", expected: &scaf.Value{Absent: true}, row: map[string]any{}, wantPass: true},
+		{name: "absent rejects null", expected: &scaf.Value{Absent: true}, row: map[string]any{"u.middleName": nil}},
+		{name: "absent rejects value", expected: &scaf.Value{Absent: true}, row: map[string]any{"u.middleName": "Q"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite := &scaf.Suite{
+				Queries: []*scaf.Query{{Name: "GetUser", Body: "MATCH (u:User) RETURN u.middleName"}},
+				Scopes: []*scaf.QueryScope{{
+					QueryName: "GetUser",
+					Items: []*scaf.TestOrGroup{{Test: &scaf.Test{
+						Name: "middle name",
+						Statements: []*scaf.Statement{
+							{KeyParts: &scaf.DottedIdent{Parts: []string{"u", "middleName"}}, Value: tt.expected},
+						},
+						ExpectedRows: []*scaf.Map{
+							{Entries: []*scaf.MapEntry{{Key: "u.middleName", Value: tt.expected}}},
+						},
+					}}},
+				}},
+			}
+
+			r := New(WithDatabase(&mockDatabase{results: []map[string]any{tt.row}}))
+
+			result, err := r.Run(context.Background(), suite, "test.scaf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if passed := result.Passed == 1; passed != tt.wantPass {
+				t.Errorf("Passed = %d, want pass = %v", result.Passed, tt.wantPass)
+			}
+		})
+	}
+}
+
 func TestRunner_AssertMultipleConditions(t *testing.T) {
 	d := &mockDatabase{
 		results: []map[string]any{{"age": int64(30), "verified": true}},
@@ -342,6 +1365,72 @@ func TestRunner_AssertMultipleConditions(t *testing.T) {
 	}
 }
 
+func TestRunner_AssertMainQuery(t *testing.T) {
+	suite, err := scaf.Parse([]byte(`
+query GetUsers ` + "`USERS`" + `
+
+GetUsers {
+	test "t" {
+		assert { u.age > 18 && u.name != "" }
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		rows      []map[string]any
+		status    Action
+		wantField string
+		wantValue any
+	}{
+		{
+			name:   "dotted columns",
+			rows:   []map[string]any{{"u.age": int64(30), "u.name": "Alice"}},
+			status: ActionPass,
+		},
+		{
+			name:   "node column",
+			rows:   []map[string]any{{"u": map[string]any{"age": int64(30), "name": "Alice"}}},
+			status: ActionPass,
+		},
+		{
+			name: "every row",
+			rows: []map[string]any{
+				{"u.age": int64(30), "u.name": "Alice"},
+				{"u.age": int64(12), "u.name": "Bob"},
+			},
+			status:    ActionFail,
+			wantField: `u.age > 18 && u.name != "" (row 1)`,
+			wantValue: false,
+		},
+		{
+			name:      "no rows",
+			status:    ActionFail,
+			wantField: `u.age > 18 && u.name != ""`,
+			wantValue: "no rows",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(WithDatabase(&queryAwareDatabase{results: map[string][]map[string]any{"USERS": tt.rows}}))
+
+			result, err := r.Run(context.Background(), suite, "test.scaf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tr := result.Tests["GetUsers/t"]
+			if tr.Status != tt.status || tr.Field != tt.wantField || tr.Actual != tt.wantValue {
+				t.Errorf("result = %+v, want %s on %q with %v", tr, tt.status, tt.wantField, tt.wantValue)
+			}
+		})
+	}
+}
+
 func TestRunner_AssertWithInlineQuery(t *testing.T) {
 	r := New(WithDatabase(&queryAwareDatabase{
 		results: map[string][]map[string]any{
@@ -429,6 +1518,154 @@ func TestRunner_AssertWithNamedQuery(t *testing.T) {
 	}
 }
 
+// upsertDatabase stubs MERGE and CREATE semantics over a set of user ids.
+type upsertDatabase struct {
+	users []int64
+}
+
+func (d *upsertDatabase) Name() string          { return "upsert" }
+func (d *upsertDatabase) Dialect() scaf.Dialect { return nil }
+func (d *upsertDatabase) Close() error          { return nil }
+
+func (d *upsertDatabase) Execute(_ context.Context, query string, params map[string]any) ([]map[string]any, error) {
+	switch query {
+	case "MERGE":
+		id, _ := params["id"].(float64)
+		if !slices.Contains(d.users, int64(id)) {
+			d.users = append(d.users, int64(id))
+		}
+
+		return []map[string]any{{"u.id": int64(id)}}, nil
+	case "CREATE":
+		d.users = append(d.users, int64(len(d.users)+1))
+
+		return []map[string]any{{"u.id": int64(len(d.users))}}, nil
+	case "COUNT":
+		return []map[string]any{{"c": int64(len(d.users))}}, nil
+	}
+
+	return nil, nil
+}
+
+func TestRunner_AssertIdempotent(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		passed int
+		failed int
+	}{
+		{name: "merge", body: "MERGE", passed: 1},
+		{name: "create", body: "CREATE", failed: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(WithDatabase(&upsertDatabase{}))
+
+			suite := &scaf.Suite{
+				Queries: []*scaf.Query{{Name: "UpsertUser", Body: tt.body}},
+				Scopes: []*scaf.QueryScope{{
+					QueryName: "UpsertUser",
+					Items: []*scaf.TestOrGroup{{
+						Test: &scaf.Test{
+							Name:       "upsert",
+							Statements: []*scaf.Statement{scaf.NewStatement("$id", &scaf.Value{Number: ptr(1.0)})},
+							Asserts: []*scaf.Assert{
+								{Idempotent: true},
+								{
+									Query: &scaf.AssertQuery{Inline: ptr("COUNT")},
+									Conditions: []*scaf.Expr{{
+										ExprTokens: []*scaf.ExprToken{
+											{Ident: ptr("c")},
+											{Op: ptr("==")},
+											{Number: ptr("1")},
+										},
+									}},
+								},
+							},
+						},
+					}},
+				}},
+			}
+
+			result, err := r.Run(context.Background(), suite, "test.scaf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Passed != tt.passed || result.Failed != tt.failed {
+				t.Errorf("Passed = %d, Failed = %d, want %d, %d", result.Passed, result.Failed, tt.passed, tt.failed)
+			}
+
+			if tr := result.Tests["UpsertUser/upsert"]; tt.failed > 0 && tr.Field != "idempotent" {
+				t.Errorf("failing field = %q, want idempotent", tr.Field)
+			}
+		})
+	}
+}
+
+func TestRunner_AssertQueryFieldRefParam(t *testing.T) {
+	d := &ParamTrackingDatabase{
+		ResultsByQuery: map[string][]map[string]any{
+			"MAIN":    {{"u.id": int64(42)}},
+			"COUNTER": {{"cnt": int64(3)}},
+		},
+	}
+	r := New(WithDatabase(d))
+
+	suite := &scaf.Suite{
+		Queries: []*scaf.Query{
+			{Name: "GetUser", Body: "MAIN"},
+			{Name: "CountPosts", Body: "COUNTER"},
+		},
+		Scopes: []*scaf.QueryScope{{
+			QueryName: "GetUser",
+			Items: []*scaf.TestOrGroup{{
+				Test: &scaf.Test{
+					Name: "binds field ref",
+					Asserts: []*scaf.Assert{{
+						Query: &scaf.AssertQuery{
+							QueryName: ptr("CountPosts"),
+							Params: []*scaf.SetupParam{{
+								Name:  "$authorId",
+								Value: &scaf.ParamValue{FieldRef: &scaf.DottedIdent{Parts: []string{"u", "id"}}},
+							}},
+						},
+						Conditions: []*scaf.Expr{{
+							ExprTokens: []*scaf.ExprToken{
+								{Ident: ptr("cnt")},
+								{Op: ptr("==")},
+								{Number: ptr("3")},
+							},
+						}},
+					}},
+				},
+			}},
+		}},
+	}
+
+	result, err := r.Run(context.Background(), suite, "test.scaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Passed != 1 {
+		t.Errorf("Passed = %d, want 1", result.Passed)
+	}
+
+	var got any
+
+	for _, e := range d.Executed {
+		if e.Query == "COUNTER" {
+			got = e.Params["authorId"]
+		}
+	}
+
+	if got != int64(42) {
+		t.Errorf("CountPosts $authorId = %v, want 42", got)
+	}
+}
+
 // queryAwareDatabase returns different results based on the query body.
 type queryAwareDatabase struct {
 	results map[string][]map[string]any
@@ -173,9 +173,9 @@ func BuildSuiteTree(suite *scaf.Suite, suitePath string) SuiteTree {
 
 func addItems(parent *treeNode, items []*scaf.TestOrGroup, pathPrefix []string, suitePath string, idx map[string]*treeNode) {
 	for _, item := range items {
-		if item.Test != nil {
+		for _, test := range item.Tests() {
 			testNode := &treeNode{
-				name:   item.Test.Name,
+				name:   test.Name,
 				kind:   kindTest,
 				parent: parent,
 			}
@@ -184,7 +184,7 @@ func addItems(parent *treeNode, items []*scaf.TestOrGroup, pathPrefix []string,
 			// Index by "suite::path" to avoid collisions between files
 			path := make([]string, len(pathPrefix)+1)
 			copy(path, pathPrefix)
-			path[len(pathPrefix)] = item.Test.Name
+			path[len(pathPrefix)] = test.Name
 			key := suitePath + "::" + strings.Join(path, "/")
 			idx[key] = testNode
 		}
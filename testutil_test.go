@@ -20,15 +20,18 @@ var cmpIgnoreAST = cmp.Options{
 	// Ignore position and token types completely
 	cmpopts.IgnoreTypes(lexer.Position{}, lexer.Token{}, []lexer.Token{}),
 	// Ignore comment fields (from embedded CommentMeta)
-	cmpopts.IgnoreFields(scaf.Suite{}, "LeadingComments", "TrailingComment"),
+	cmpopts.IgnoreFields(scaf.Suite{}, "LeadingComments", "TrailingComment", "BodySpans"),
 	cmpopts.IgnoreFields(scaf.Import{}, "LeadingComments", "TrailingComment"),
 	cmpopts.IgnoreFields(scaf.Query{}, "LeadingComments", "TrailingComment"),
+	cmpopts.IgnoreFields(scaf.SetupClause{}, "LeadingComments", "TrailingComment"),
+	cmpopts.IgnoreFields(scaf.SetupItem{}, "LeadingComments", "TrailingComment"),
+	cmpopts.IgnoreFields(scaf.Profile{}, "LeadingComments", "TrailingComment", "Close"),
 	cmpopts.IgnoreFields(scaf.QueryScope{}, "LeadingComments", "TrailingComment", "Close"),
 	cmpopts.IgnoreFields(scaf.Group{}, "LeadingComments", "TrailingComment", "Close"),
 	cmpopts.IgnoreFields(scaf.Test{}, "LeadingComments", "TrailingComment", "Close"),
 	cmpopts.IgnoreFields(scaf.Assert{}, "Close"),
 	// Ignore recovery metadata (from embedded RecoveryMeta)
-	cmpopts.IgnoreFields(scaf.Statement{}, "RecoveredSpan"),
+	cmpopts.IgnoreFields(scaf.Statement{}, "LeadingComments", "TrailingComment", "RecoveredSpan"),
 }
 
 // ptr returns a pointer to the given value.
@@ -1,6 +1,11 @@
 package scaf
 
-import "github.com/alecthomas/participle/v2/lexer"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/participle/v2/lexer"
+)
 
 // Span represents a range in source code.
 type Span struct {
@@ -88,8 +93,11 @@ func attachComments(suite *Suite, trivia *TriviaList) {
 		attached := false
 
 		for _, span := range spans {
-			// Trailing: comment starts on same line as node ends, after the node
-			if t.Span.Start.Line == span.End.Line && t.Span.Start.Offset > span.End.Offset {
+			// Trailing: comment starts on same line as node ends, at or after the
+			// node's (exclusive) end. An empty node, such as an empty file's
+			// suite, has nothing for a comment to trail.
+			if t.Span.Start.Line == span.End.Line && t.Span.Start.Offset >= span.End.Offset &&
+				span.End.Offset > span.Start.Offset {
 				if cm[span] == nil {
 					cm[span] = &nodeComments{}
 				}
@@ -148,14 +156,41 @@ func applyComments(suite *Suite, cm commentMap) {
 		}
 	}
 
+	// Exports
+	for _, exp := range suite.Exports {
+		if c := cm[exp.Span()]; c != nil {
+			exp.LeadingComments = c.leading
+			exp.TrailingComment = c.trailing
+		}
+	}
+
 	// Queries
 	for _, q := range suite.Queries {
 		if c := cm[q.Span()]; c != nil {
 			q.LeadingComments = c.leading
 			q.TrailingComment = c.trailing
+			q.DirectiveMeta = parseDirectives(c.leading)
 		}
 	}
 
+	// Comments before a query that starts the file belong to the suite, but
+	// their directives still apply to the query.
+	if len(suite.Queries) > 0 && suite.Queries[0].Pos == suite.Pos && suite.Queries[0].Directives == nil {
+		suite.Queries[0].DirectiveMeta = parseDirectives(suite.LeadingComments)
+	}
+
+	applySetupComments(suite.Setup, cm)
+
+	// Profiles
+	for _, p := range suite.Profiles {
+		if c := cm[p.Span()]; c != nil {
+			p.LeadingComments = c.leading
+			p.TrailingComment = c.trailing
+		}
+
+		applySetupComments(p.Setup, cm)
+	}
+
 	// Scopes
 	for _, scope := range suite.Scopes {
 		applyScopeComments(scope, cm)
@@ -172,14 +207,20 @@ func applyScopeComments(scope *QueryScope, cm commentMap) {
 		scope.TrailingComment = c.trailing
 	}
 
+	applySetupComments(scope.Setup, cm)
+
 	for _, item := range scope.Items {
-		if item.Test != nil {
-			if c := cm[item.Test.Span()]; c != nil {
-				item.Test.LeadingComments = c.leading
-				item.Test.TrailingComment = c.trailing
+		if item.Sequence != nil {
+			if c := cm[item.Sequence.Span()]; c != nil {
+				item.Sequence.LeadingComments = c.leading
+				item.Sequence.TrailingComment = c.trailing
 			}
 		}
 
+		for _, test := range item.Tests() {
+			applyTestComments(test, cm)
+		}
+
 		if item.Group != nil {
 			applyGroupComments(item.Group, cm)
 		}
@@ -194,22 +235,108 @@ func applyGroupComments(group *Group, cm commentMap) {
 	if c := cm[group.Span()]; c != nil {
 		group.LeadingComments = c.leading
 		group.TrailingComment = c.trailing
+		group.DirectiveMeta = parseDirectives(c.leading)
 	}
 
+	applySetupComments(group.Setup, cm)
+
 	for _, item := range group.Items {
-		if item.Test != nil {
-			if c := cm[item.Test.Span()]; c != nil {
-				item.Test.LeadingComments = c.leading
-				item.Test.TrailingComment = c.trailing
+		if item.Sequence != nil {
+			if c := cm[item.Sequence.Span()]; c != nil {
+				item.Sequence.LeadingComments = c.leading
+				item.Sequence.TrailingComment = c.trailing
 			}
 		}
 
+		for _, test := range item.Tests() {
+			applyTestComments(test, cm)
+		}
+
 		if item.Group != nil {
 			applyGroupComments(item.Group, cm)
 		}
 	}
 }
 
+func applyTestComments(test *Test, cm commentMap) {
+	if c := cm[test.Span()]; c != nil {
+		test.LeadingComments = c.leading
+		test.TrailingComment = c.trailing
+		test.DirectiveMeta = parseDirectives(c.leading)
+	}
+
+	applySetupComments(test.Setup, cm)
+
+	for _, stmt := range test.Statements {
+		if c := cm[stmt.Span()]; c != nil {
+			stmt.LeadingComments = c.leading
+			stmt.TrailingComment = c.trailing
+		}
+	}
+}
+
+func applySetupComments(setup *SetupClause, cm commentMap) {
+	if setup == nil {
+		return
+	}
+
+	if c := cm[setup.Span()]; c != nil {
+		setup.LeadingComments = c.leading
+		setup.TrailingComment = c.trailing
+	}
+
+	for _, item := range setup.Block {
+		if c := cm[item.Span()]; c != nil {
+			item.LeadingComments = c.leading
+			item.TrailingComment = c.trailing
+		}
+	}
+}
+
+// parseDirectives collects "// scaf:<name>" comments, with the argument that
+// follows the name, either parenthesized and quoted or as plain text.
+// Unrecognized names are kept for tools to report.
+func parseDirectives(comments []string) DirectiveMeta {
+	var meta DirectiveMeta
+
+	for _, comment := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(comment, "//"))
+
+		directive, ok := strings.CutPrefix(text, "scaf:")
+		if !ok {
+			continue
+		}
+
+		name, arg := directive, ""
+		if i := strings.IndexAny(directive, "( \t"); i >= 0 {
+			name, arg = directive[:i], strings.TrimSpace(directive[i:])
+		}
+
+		if name == "" {
+			continue
+		}
+
+		meta.Directives = append(meta.Directives, name)
+
+		if inner, ok := strings.CutPrefix(arg, "("); ok {
+			arg = strings.TrimSpace(strings.TrimSuffix(inner, ")"))
+			if unquoted, err := strconv.Unquote(arg); err == nil {
+				arg = unquoted
+			}
+		}
+
+		if arg != "" {
+			if meta.DirectiveArgs == nil {
+				meta.DirectiveArgs = make(map[string]string)
+			}
+
+			meta.DirectiveArgs[name] = arg
+		}
+	}
+
+	return meta
+}
+
 // isClosestNode checks if targetSpan is the closest node after the comment.
 func isClosestNode(commentSpan, targetSpan Span, allSpans []Span) bool {
 	for _, span := range allSpans {
@@ -244,10 +371,21 @@ func collectSpans(suite *Suite, spans *[]Span) {
 		*spans = append(*spans, imp.Span())
 	}
 
+	for _, exp := range suite.Exports {
+		*spans = append(*spans, exp.Span())
+	}
+
 	for _, q := range suite.Queries {
 		*spans = append(*spans, q.Span())
 	}
 
+	collectSetupSpans(suite.Setup, spans)
+
+	for _, p := range suite.Profiles {
+		*spans = append(*spans, p.Span())
+		collectSetupSpans(p.Setup, spans)
+	}
+
 	for _, scope := range suite.Scopes {
 		collectScopeSpans(scope, spans)
 	}
@@ -259,10 +397,15 @@ func collectScopeSpans(scope *QueryScope, spans *[]Span) {
 	}
 
 	*spans = append(*spans, scope.Span())
+	collectSetupSpans(scope.Setup, spans)
 
 	for _, item := range scope.Items {
-		if item.Test != nil {
-			*spans = append(*spans, item.Test.Span())
+		if item.Sequence != nil {
+			*spans = append(*spans, item.Sequence.Span())
+		}
+
+		for _, test := range item.Tests() {
+			collectTestSpans(test, spans)
 		}
 
 		if item.Group != nil {
@@ -277,14 +420,44 @@ func collectGroupSpans(group *Group, spans *[]Span) {
 	}
 
 	*spans = append(*spans, group.Span())
+	collectSetupSpans(group.Setup, spans)
 
 	for _, item := range group.Items {
-		if item.Test != nil {
-			*spans = append(*spans, item.Test.Span())
+		if item.Sequence != nil {
+			*spans = append(*spans, item.Sequence.Span())
+		}
+
+		for _, test := range item.Tests() {
+			collectTestSpans(test, spans)
 		}
 
 		if item.Group != nil {
 			collectGroupSpans(item.Group, spans)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// collectTestSpans adds a test, its setup, and its statements. The test comes
+// first so a trailing comment after a one-line test attaches to the test.
+func collectTestSpans(test *Test, spans *[]Span) {
+	*spans = append(*spans, test.Span())
+	collectSetupSpans(test.Setup, spans)
+
+	for _, stmt := range test.Statements {
+		*spans = append(*spans, stmt.Span())
+	}
+}
+
+// collectSetupSpans adds a setup clause and its block items. The clause comes
+// first so a trailing comment after a one-line block attaches to the clause.
+func collectSetupSpans(setup *SetupClause, spans *[]Span) {
+	if setup == nil {
+		return
+	}
+
+	*spans = append(*spans, setup.Span())
+
+	for _, item := range setup.Block {
+		*spans = append(*spans, item.Span())
+	}
+}